@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"context"
+	"log"
+
+	"NyteBubo/internal/workflows"
+
+	"github.com/spf13/cobra"
+)
+
+var abortDeleteBranch bool
+
+var abortCmd = &cobra.Command{
+	Use:   "abort owner/repo#issue",
+	Short: "Stop all work on an issue",
+	Long: `Stop all work on an issue until it is retried, like the abort slash command.
+With --delete-branch, the bot's open pull request for the issue is closed and its branch deleted.`,
+	Example: "  nytebubo abort owner/repo#42 --delete-branch",
+	Args:    cobra.ExactArgs(1),
+	Run:     runAbort,
+}
+
+func init() {
+	rootCmd.AddCommand(abortCmd)
+	abortCmd.Flags().BoolVar(&abortDeleteBranch, "delete-branch", false, "Close the bot's pull request and delete the issue branch")
+	addDryRunFlag(abortCmd)
+}
+
+func runAbort(cmd *cobra.Command, args []string) {
+	owner, repo, issueNumber, err := parseRepoRef(args[0])
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if issueNumber == 0 {
+		log.Fatalf("Error: no issue number in %q (expected owner/repo#issue)", args[0])
+	}
+
+	config, _ := loadConfig()
+	githubToken, llmAPIKey := loadCredentials(&config)
+
+	agent, err := workflows.NewIssueAgent(context.Background(), githubToken, llmAPIKey, config)
+	if err != nil {
+		log.Fatalf("Failed to create agent: %v", err)
+	}
+	defer agent.Close()
+
+	if err := agent.AbortIssue(owner, repo, issueNumber, abortDeleteBranch); err != nil {
+		agent.Close()
+		log.Fatalf("Abort failed: %v", err)
+	}
+	printIssueState(agent, owner, repo, issueNumber)
+}
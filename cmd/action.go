@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"NyteBubo/internal/workflows"
+	"NyteBubo/server"
+
+	"github.com/spf13/cobra"
+)
+
+// actionsBotLogin is the login GitHub Actions' GITHUB_TOKEN comments as
+const actionsBotLogin = "github-actions[bot]"
+
+var actionCmd = &cobra.Command{
+	Use:   "action",
+	Short: "Handle a GitHub Actions event and exit",
+	Long: `Handle the event that triggered a GitHub Actions workflow and exit.
+The event is read from GITHUB_EVENT_NAME and GITHUB_EVENT_PATH and handled like the matching
+webhook; GITHUB_TOKEN is used when no other GitHub token is configured.
+
+A workflow_dispatch event with an "issue" input works on that issue, and a schedule event
+polls the repository once.`,
+	Run: runAction,
+}
+
+func init() {
+	rootCmd.AddCommand(actionCmd)
+	addDryRunFlag(actionCmd)
+}
+
+func runAction(cmd *cobra.Command, args []string) {
+	eventName := os.Getenv("GITHUB_EVENT_NAME")
+	eventPath := os.Getenv("GITHUB_EVENT_PATH")
+	if eventName == "" || eventPath == "" {
+		log.Fatalf("Error: GITHUB_EVENT_NAME and GITHUB_EVENT_PATH must be set (is this running in GitHub Actions?)")
+	}
+	payload, err := os.ReadFile(eventPath)
+	if err != nil {
+		log.Fatalf("Failed to read event payload: %v", err)
+	}
+
+	config, _ := loadConfig()
+	if len(config.Repositories) == 0 {
+		if repository := os.Getenv("GITHUB_REPOSITORY"); repository != "" {
+			config.Repositories = []string{repository}
+		}
+	}
+	// GITHUB_TOKEN can't look up its own user
+	if config.GitHubLogin == "" {
+		config.GitHubLogin = actionsBotLogin
+	}
+	// The job ends when the event is handled, so comments can't wait for a batch window
+	config.CommentRateLimit.BatchWindowSeconds = 0
+	githubToken, llmAPIKey := loadCredentials(&config)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	agent, err := workflows.NewIssueAgent(ctx, githubToken, llmAPIKey, config)
+	if err != nil {
+		log.Fatalf("Failed to create agent: %v", err)
+	}
+	defer agent.Close()
+
+	log.Printf("Handling %s event", eventName)
+	switch eventName {
+	case "workflow_dispatch":
+		err = runDispatchedIssue(agent, payload)
+	case "schedule":
+		err = agent.PollOnce(ctx, config.Repositories)
+	default:
+		err = server.NewWebhookServer(agent, "").HandleEvent(eventName, payload)
+	}
+	if err != nil {
+		agent.Close()
+		log.Fatalf("Action failed: %v", err)
+	}
+}
+
+// runDispatchedIssue works on the issue given as a workflow_dispatch input
+func runDispatchedIssue(agent *workflows.IssueAgent, payload []byte) error {
+	var event struct {
+		Inputs struct {
+			Issue json.RawMessage `json:"issue"`
+		} `json:"inputs"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("failed to parse event payload: %w", err)
+	}
+	// Inputs arrive as strings, or as numbers for number inputs
+	issueNumber, err := strconv.Atoi(strings.Trim(string(event.Inputs.Issue), `"`))
+	if err != nil || issueNumber <= 0 {
+		return fmt.Errorf("invalid issue input %s", event.Inputs.Issue)
+	}
+
+	owner, repo, ok := strings.Cut(os.Getenv("GITHUB_REPOSITORY"), "/")
+	if !ok {
+		return fmt.Errorf("GITHUB_REPOSITORY must be set to owner/repo")
+	}
+	return agent.RunIssue(owner, repo, issueNumber)
+}
@@ -1,16 +1,22 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
 
 	"NyteBubo/internal/types"
 	"NyteBubo/internal/workflows"
 	"NyteBubo/server"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
 var agentCmd = &cobra.Command{
@@ -22,31 +28,12 @@ var agentCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(agentCmd)
+	addDryRunFlag(agentCmd)
 }
 
 func runAgent(cmd *cobra.Command, args []string) {
-	// Load configuration with defaults
-	config := types.Config{
-		WorkingDir:   "./workspace",
-		StateDBPath:  "./agent_state.db",
-		PollInterval: 30,
-		Repositories: []string{},
-		WebhookMode:  false,
-		ServerPort:   8080,
-	}
-
-	// Try to load config.yaml if it exists
-	configPath := "config.yaml"
-	if _, err := os.Stat(configPath); err == nil {
-		data, err := os.ReadFile(configPath)
-		if err != nil {
-			log.Fatalf("Failed to read config.yaml: %v", err)
-		}
-
-		if err := yaml.Unmarshal(data, &config); err != nil {
-			log.Fatalf("Failed to parse config.yaml: %v", err)
-		}
-	} else {
+	config, found := loadConfig()
+	if !found {
 		log.Println("No config.yaml found, using defaults. Run 'nytebubo init' to create one.")
 		log.Fatal("Error: repositories list is required. Please create a config.yaml file.")
 	}
@@ -55,40 +42,82 @@ func runAgent(cmd *cobra.Command, args []string) {
 	if !config.WebhookMode && len(config.Repositories) == 0 {
 		log.Fatal("Error: repositories list cannot be empty in polling mode. Please add repositories to config.yaml")
 	}
-
-	// Get credentials from environment variables (preferred) or config file
-	openRouterAPIKey := os.Getenv("OPENROUTER_API_KEY")
-	if openRouterAPIKey == "" && config.OpenRouterAPIKey == "" {
-		log.Fatal("OPENROUTER_API_KEY environment variable is not set and not found in config.yaml")
+	if config.WebhookMode && len(config.GitLab.Repositories) > 0 {
+		slog.Warn("GitLab repositories don't receive webhook events; add them to poll_repositories to poll them")
 	}
-	if openRouterAPIKey == "" {
-		openRouterAPIKey = config.OpenRouterAPIKey
+	if config.WebhookMode && len(config.PollRepositories) > 0 && config.PollInterval <= 0 {
+		log.Fatal("Error: poll_interval must be set to poll poll_repositories in webhook mode")
 	}
 
-	githubToken := os.Getenv("GITHUB_TOKEN")
-	if githubToken == "" && config.GitHubToken == "" {
-		log.Fatal("GITHUB_TOKEN environment variable is not set and not found in config.yaml")
-	}
-	if githubToken == "" {
-		githubToken = config.GitHubToken
-	}
+	githubToken, llmAPIKey := loadCredentials(&config)
+
+	// Ctrl+C or SIGTERM stops new work and lets in-flight work checkpoint before exiting
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// Create the issue agent
-	agent, err := workflows.NewIssueAgent(githubToken, openRouterAPIKey, config.OpenRouterModel, config.StateDBPath, config.WorkingDir)
+	agent, err := workflows.NewIssueAgent(ctx, githubToken, llmAPIKey, config)
 	if err != nil {
 		log.Fatalf("Failed to create agent: %v", err)
 	}
 	defer agent.Close()
 
+	// Closed once the dashboard has stopped and the retries it started have finished
+	dashboardDone := make(chan struct{})
+	if config.Dashboard.Enabled {
+		go startDashboard(ctx, agent, config, dashboardDone)
+	} else {
+		close(dashboardDone)
+	}
+	if config.Cleanup.Enabled {
+		go agent.RunJanitor(ctx)
+	}
+	if config.Reports.Enabled {
+		if config.Reports.Repository == "" && !config.Reports.Gist {
+			slog.Warn("Monthly cost reports are enabled but set neither reports.repository nor reports.gist")
+		} else {
+			go agent.RunReporter(ctx)
+		}
+	}
+	if config.MetricsAddress != "" {
+		go func() {
+			if err := server.ServeMetrics(ctx, config.MetricsAddress, server.NewHealthChecker(agent)); err != nil {
+				slog.Error("Metrics server error", "error", err)
+			}
+		}()
+	}
+
 	// Start in appropriate mode
 	if config.WebhookMode {
-		startWebhookMode(agent, config)
+		startWebhookMode(ctx, agent, config)
 	} else {
-		startPollingMode(agent, config)
+		startPollingMode(ctx, agent, config)
+	}
+	<-dashboardDone
+	slog.Info("NyteBubo stopped")
+}
+
+func startDashboard(ctx context.Context, agent *workflows.IssueAgent, config types.Config, done chan<- struct{}) {
+	defer close(done)
+
+	token := os.Getenv("DASHBOARD_TOKEN")
+	if token == "" {
+		token = config.Dashboard.Token
+	}
+	addr := config.Dashboard.Address
+	if addr == "" {
+		addr = "127.0.0.1:8090"
+	}
+	if token == "" {
+		slog.Warn("DASHBOARD_TOKEN is not set. Anyone who can reach the dashboard can retry and abort work.")
+	}
+
+	if err := server.NewDashboard(agent, token).Start(ctx, addr); err != nil {
+		slog.Error("Dashboard error", "error", err)
 	}
 }
 
-func startPollingMode(agent *workflows.IssueAgent, config types.Config) {
+func startPollingMode(ctx context.Context, agent *workflows.IssueAgent, config types.Config) {
 	fmt.Printf(`
 ╔═══════════════════════════════════════════════╗
 ║        NyteBubo Agent Starting (Polling)      ║
@@ -105,18 +134,18 @@ The agent is now polling for assigned issues.
 No public endpoint required - runs entirely on your local network!
 
 Press Ctrl+C to stop the agent.
-`, config.PollInterval, config.Repositories, config.WorkingDir, config.StateDBPath)
+`, config.PollInterval, config.Repositories, config.WorkingDir, config.RedactedStateDatabase())
 
 	// Start polling
-	if err := agent.StartPolling(config.PollInterval, config.Repositories); err != nil {
+	if err := agent.StartPolling(ctx, config.PollInterval, config.Repositories); err != nil {
 		log.Fatalf("Polling error: %v", err)
 	}
 }
 
-func startWebhookMode(agent *workflows.IssueAgent, config types.Config) {
+func startWebhookMode(ctx context.Context, agent *workflows.IssueAgent, config types.Config) {
 	webhookSecret := os.Getenv("WEBHOOK_SECRET")
 	if webhookSecret == "" && config.WebhookSecret == "" {
-		log.Println("Warning: WEBHOOK_SECRET is not set. Webhook signature verification will be disabled.")
+		slog.Warn("WEBHOOK_SECRET is not set. Webhook signature verification will be disabled.")
 	}
 	if webhookSecret == "" {
 		webhookSecret = config.WebhookSecret
@@ -124,6 +153,25 @@ func startWebhookMode(agent *workflows.IssueAgent, config types.Config) {
 
 	// Create and start the webhook server
 	webhookServer := server.NewWebhookServer(agent, webhookSecret)
+	webhookServer.SetWorkers(config.WebhookWorkers)
+
+	options := server.ServerOptions{
+		Address:      config.ListenAddress(),
+		BasePath:     config.Server.BasePath,
+		TLSCertFile:  config.Server.TLSCert,
+		TLSKeyFile:   config.Server.TLSKey,
+		ReadTimeout:  time.Duration(config.Server.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(config.Server.WriteTimeout) * time.Second,
+	}
+	scheme := "http"
+	if options.TLSCertFile != "" {
+		scheme = "https"
+	}
+	webhookPath, livePath, readyPath := options.Routes()
+	host := options.Address
+	if strings.HasPrefix(host, ":") {
+		host = "your-server" + host
+	}
 
 	fmt.Printf(`
 ╔═══════════════════════════════════════════════╗
@@ -132,21 +180,56 @@ func startWebhookMode(agent *workflows.IssueAgent, config types.Config) {
 
 Configuration:
   Mode: Webhook
-  Server Port: %d
+  Listen Address: %s
   Working Directory: %s
   State Database: %s
 
 The agent is now listening for GitHub webhook events.
 Configure your GitHub repository webhook to point to:
-  http://your-server:%d/webhook
+  %s://%s%s
 
-Health check endpoint:
-  http://your-server:%d/health
+Liveness and readiness probes:
+  %s://%s%s
+  %s://%s%s
 
 Press Ctrl+C to stop the server.
-`, config.ServerPort, config.WorkingDir, config.StateDBPath, config.ServerPort, config.ServerPort)
+`, options.Address, config.WorkingDir, config.RedactedStateDatabase(), scheme, host, webhookPath, scheme, host, livePath, scheme, host, readyPath)
+
+	// Replay events GitHub failed to deliver while the server was down
+	webhookServer.CatchUp(config.Repositories, config.WebhookURL)
+	webhookServer.ResumeInterrupted()
 
-	if err := webhookServer.Start(config.ServerPort); err != nil {
+	// Poll repositories without a webhook, and sweep the rest for events that never arrived.
+	// Comments and mentions are claimed by ID, so whichever sees one first handles it.
+	if len(config.PollRepositories) > 0 {
+		go pollAlongside(ctx, agent, config.PollInterval, config.PollRepositories)
+	}
+	if config.SweepInterval > 0 {
+		if sweepRepos := excludeRepositories(config.Repositories, config.PollRepositories); len(sweepRepos) > 0 {
+			go pollAlongside(ctx, agent, config.SweepInterval, sweepRepos)
+		}
+	}
+
+	if err := webhookServer.Start(ctx, options); err != nil {
 		log.Fatalf("Server error: %v", err)
 	}
 }
+
+// pollAlongside polls repositories next to the webhook server until ctx is cancelled
+func pollAlongside(ctx context.Context, agent *workflows.IssueAgent, pollInterval int, repositories []string) {
+	slog.Info("🔄 Polling alongside the webhook server", "interval", pollInterval, "repositories", repositories)
+	if err := agent.StartPolling(ctx, pollInterval, repositories); err != nil {
+		slog.Error("Polling error", "error", err)
+	}
+}
+
+// excludeRepositories returns the repositories not in excluded
+func excludeRepositories(repositories, excluded []string) []string {
+	var remaining []string
+	for _, repoFullName := range repositories {
+		if !slices.Contains(excluded, repoFullName) {
+			remaining = append(remaining, repoFullName)
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"NyteBubo/internal/workflows"
+
+	"github.com/spf13/cobra"
+)
+
+var cleanupAgeDays int
+
+var cleanupCmd = &cobra.Command{
+	Use:   "cleanup",
+	Short: "Delete branches and workspaces of finished issues",
+	Long: `Delete the remote branches and sandbox workspaces of issues that were completed or
+abandoned more than cleanup.age days ago (default 7), like the janitor does every hour when
+cleanup.enabled is set. Each issue is only cleaned up once after it finishes.`,
+	Example: "  nytebubo cleanup --age 30",
+	Args:    cobra.NoArgs,
+	Run:     runCleanup,
+}
+
+func init() {
+	rootCmd.AddCommand(cleanupCmd)
+	cleanupCmd.Flags().IntVar(&cleanupAgeDays, "age", 0, "Clean up issues finished more than this many days ago (default: cleanup.age)")
+	addDryRunFlag(cleanupCmd)
+}
+
+func runCleanup(cmd *cobra.Command, args []string) {
+	config, _ := loadConfig()
+	githubToken, llmAPIKey := loadCredentials(&config)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	agent, err := workflows.NewIssueAgent(ctx, githubToken, llmAPIKey, config)
+	if err != nil {
+		log.Fatalf("Failed to create agent: %v", err)
+	}
+	defer agent.Close()
+
+	age := agent.CleanupAge()
+	if cleanupAgeDays > 0 {
+		age = time.Duration(cleanupAgeDays) * 24 * time.Hour
+	}
+	result, err := agent.CleanUp(ctx, age)
+	if err != nil {
+		agent.Close()
+		log.Fatalf("Cleanup failed: %v", err)
+	}
+	fmt.Printf("Cleaned up %d issue(s): %d branch(es) deleted, %d workspace(s) removed\n", result.Issues, result.Branches, result.Workspaces)
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"NyteBubo/internal/core"
+	"NyteBubo/internal/types"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// dryRun is set by --dry-run and turns on dry_run regardless of config.yaml
+var dryRun bool
+
+// addDryRunFlag adds --dry-run to a command that works on repositories
+func addDryRunFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print comments, pull requests and diffs instead of writing to repositories")
+}
+
+// loadConfig reads config.yaml over the defaults and sets up logging. It reports whether
+// the file exists.
+func loadConfig() (types.Config, bool) {
+	// Load configuration with defaults
+	config := types.Config{
+		WorkingDir:   "./workspace",
+		StateDBPath:  "./agent_state.db",
+		PollInterval: 30,
+		Repositories: []string{},
+		WebhookMode:  false,
+		ServerPort:   8080,
+	}
+
+	// Try to load config.yaml if it exists
+	configPath := "config.yaml"
+	found := false
+	if _, err := os.Stat(configPath); err == nil {
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			log.Fatalf("Failed to read config.yaml: %v", err)
+		}
+
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			log.Fatalf("Failed to parse config.yaml: %v", err)
+		}
+		found = true
+	}
+	if dryRun {
+		config.DryRun = true
+	}
+	// The database URL can hold a password, so it may come from the environment instead
+	if database := os.Getenv("STATE_DB"); database != "" {
+		config.StateDB = database
+	}
+	config.StateDBKey = os.Getenv("STATE_DB_ENCRYPTION_KEY")
+
+	if err := core.SetupLogging(config.LogLevel, config.LogFormat); err != nil {
+		log.Fatalf("Error: %v in config.yaml", err)
+	}
+	return config, found
+}
+
+// loadCredentials returns the GitHub token and LLM API key, and fills in the GitLab and
+// Gitea tokens. Environment variables take precedence over config.yaml.
+func loadCredentials(config *types.Config) (githubToken, llmAPIKey string) {
+	// Get credentials from environment variables (preferred) or config file
+	envVar, configKey := "OPENROUTER_API_KEY", config.OpenRouterAPIKey
+	switch config.Provider {
+	case "", "openrouter":
+	case "anthropic":
+		envVar, configKey = "ANTHROPIC_API_KEY", config.AnthropicAPIKey
+	case "openai":
+		envVar, configKey = "OPENAI_API_KEY", config.OpenAIAPIKey
+	case "local":
+		envVar, configKey = "LOCAL_API_KEY", config.Local.APIKey
+	case "azure":
+		envVar, configKey = "AZURE_OPENAI_API_KEY", config.Azure.APIKey
+		for variable, value := range map[string]*string{
+			"AZURE_TENANT_ID":     &config.Azure.TenantID,
+			"AZURE_CLIENT_ID":     &config.Azure.ClientID,
+			"AZURE_CLIENT_SECRET": &config.Azure.ClientSecret,
+		} {
+			if env := os.Getenv(variable); env != "" {
+				*value = env
+			}
+		}
+	default:
+		log.Fatalf("Error: unknown provider %q in config.yaml (expected \"openrouter\", \"anthropic\", \"openai\", \"local\" or \"azure\")", config.Provider)
+	}
+	llmAPIKey = os.Getenv(envVar)
+	// Local servers usually don't check a key, and Azure can use Entra ID tokens instead
+	keyOptional := config.Provider == "local" || (config.Provider == "azure" && config.Azure.UsesEntraID())
+	if llmAPIKey == "" && configKey == "" && !keyOptional {
+		log.Fatalf("%s environment variable is not set and not found in config.yaml", envVar)
+	}
+	if llmAPIKey == "" {
+		llmAPIKey = configKey
+	}
+
+	githubToken = os.Getenv("GITHUB_TOKEN")
+	if githubToken == "" && config.GitHubToken == "" {
+		log.Fatal("GITHUB_TOKEN environment variable is not set and not found in config.yaml")
+	}
+	if githubToken == "" {
+		githubToken = config.GitHubToken
+	}
+
+	if len(config.GitLab.Repositories) > 0 {
+		if token := os.Getenv("GITLAB_TOKEN"); token != "" {
+			config.GitLab.Token = token
+		}
+		if config.GitLab.Token == "" {
+			log.Fatal("GITLAB_TOKEN environment variable is not set and not found in config.yaml")
+		}
+	}
+	if len(config.Gitea.Repositories) > 0 {
+		if token := os.Getenv("GITEA_TOKEN"); token != "" {
+			config.Gitea.Token = token
+		}
+		if config.Gitea.URL == "" {
+			log.Fatal("Error: gitea.url is required when gitea.repositories is set")
+		}
+		if config.Gitea.Token == "" {
+			log.Fatal("GITEA_TOKEN environment variable is not set and not found in config.yaml")
+		}
+	}
+
+	return githubToken, llmAPIKey
+}
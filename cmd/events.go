@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"NyteBubo/internal/core"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	eventsStatus string
+	eventsLimit  int
+	eventsJSON   bool
+)
+
+var eventsCmd = &cobra.Command{
+	Use:   "events [id]",
+	Short: "Show queued and handled webhook events",
+	Long: `Show the webhook events in the state database, newest first, with their status and
+the error of those that failed. Events are queued when they arrive and kept for 7 days after
+they are handled. Give an event's ID to print its payload.`,
+	Example: `  nytebubo events
+  nytebubo events --status failed
+  nytebubo events 42`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runEvents,
+}
+
+func init() {
+	rootCmd.AddCommand(eventsCmd)
+	eventsCmd.Flags().StringVar(&eventsStatus, "status", "", "Only show events with this status: pending, processing, done or failed")
+	eventsCmd.Flags().IntVar(&eventsLimit, "limit", 20, "Show at most this many events")
+	eventsCmd.Flags().BoolVar(&eventsJSON, "json", false, "Print the events as JSON")
+}
+
+// eventInfo is how a webhook event is shown by the events command
+type eventInfo struct {
+	ID          int64      `json:"id"`
+	Source      string     `json:"source"`
+	Event       string     `json:"event"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	Error       string     `json:"error,omitempty"`
+	ReceivedAt  time.Time  `json:"received_at"`
+	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+}
+
+func runEvents(cmd *cobra.Command, args []string) {
+	config, _ := loadConfig()
+	stateManager, err := core.NewStateManager(config.StateDatabase(), config.StateDBKey)
+	if err != nil {
+		log.Fatalf("Failed to open state database: %v", err)
+	}
+	defer stateManager.Close()
+
+	if len(args) == 1 {
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			log.Fatalf("Error: invalid event ID %q", args[0])
+		}
+		event, err := stateManager.GetEvent(id)
+		if err != nil {
+			log.Fatalf("Failed to get event: %v", err)
+		}
+		if event == nil {
+			log.Fatalf("Error: no event with ID %d", id)
+		}
+		os.Stdout.Write(event.Payload)
+		fmt.Println()
+		return
+	}
+
+	events, err := stateManager.ListEvents(eventsStatus, eventsLimit)
+	if err != nil {
+		log.Fatalf("Failed to list events: %v", err)
+	}
+	infos := []eventInfo{}
+	for _, event := range events {
+		infos = append(infos, eventInfo{
+			ID:          event.ID,
+			Source:      event.Source,
+			Event:       event.Type,
+			Status:      event.Status,
+			Attempts:    event.Attempts,
+			Error:       event.Error,
+			ReceivedAt:  event.ReceivedAt,
+			ProcessedAt: event.ProcessedAt,
+		})
+	}
+
+	if eventsJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(infos); err != nil {
+			log.Fatalf("Failed to write JSON: %v", err)
+		}
+		return
+	}
+
+	counts, err := stateManager.CountEvents()
+	if err != nil {
+		log.Fatalf("Failed to count events: %v", err)
+	}
+	fmt.Printf("%d pending, %d processing, %d done, %d failed\n\n",
+		counts["pending"], counts["processing"], counts["done"], counts["failed"])
+	if len(infos) == 0 {
+		fmt.Println("No events found in database.")
+		return
+	}
+	displayEvents(infos)
+}
+
+func displayEvents(infos []eventInfo) {
+	fmt.Printf("%-8s %-36s %-11s %-8s %-20s %s\n", "ID", "Event", "Status", "Attempts", "Received", "Error")
+	fmt.Println(strings.Repeat("─", 110))
+
+	for _, info := range infos {
+		event := info.Event
+		if info.Source != "github" {
+			event = info.Source + ":" + event
+		}
+		fmt.Printf("%-8d %-36s %-11s %-8d %-20s %s\n",
+			info.ID,
+			event,
+			info.Status,
+			info.Attempts,
+			info.ReceivedAt.Local().Format("2006-01-02 15:04:05"),
+			info.Error,
+		)
+	}
+}
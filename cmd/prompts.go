@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"NyteBubo/internal/core"
+
+	"github.com/spf13/cobra"
+)
+
+var promptsExport string
+
+var promptsCmd = &cobra.Command{
+	Use:   "prompts [name]",
+	Short: "List the system prompts or print one",
+	Long: `List the system prompts sent to the model, or print the template of one as currently
+configured. Export the built-in templates to a directory, edit the ones you want to change,
+delete the rest and point prompts_dir at the directory to override them.`,
+	Example: `  nytebubo prompts
+  nytebubo prompts codegen
+  nytebubo prompts --export prompts`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runPrompts,
+}
+
+func init() {
+	rootCmd.AddCommand(promptsCmd)
+	promptsCmd.Flags().StringVar(&promptsExport, "export", "", "Write the built-in templates to this directory")
+}
+
+func runPrompts(cmd *cobra.Command, args []string) {
+	if promptsExport != "" {
+		if err := os.MkdirAll(promptsExport, 0755); err != nil {
+			log.Fatalf("Failed to create %s: %v", promptsExport, err)
+		}
+		for _, name := range core.PromptNames() {
+			text, err := core.DefaultPrompt(name)
+			if err != nil {
+				log.Fatal(err)
+			}
+			path := filepath.Join(promptsExport, name+".tmpl")
+			if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+				log.Fatalf("Failed to write %s: %v", path, err)
+			}
+			fmt.Println(path)
+		}
+		return
+	}
+
+	if len(args) == 0 {
+		for _, name := range core.PromptNames() {
+			fmt.Println(name)
+		}
+		return
+	}
+
+	config, _ := loadConfig()
+	name := args[0]
+	text, err := core.DefaultPrompt(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if override, err := os.ReadFile(filepath.Join(config.PromptsDir, name+".tmpl")); config.PromptsDir != "" && err == nil {
+		text = string(override)
+	}
+	if override, ok := config.Prompts[name]; ok {
+		text = override
+	}
+	fmt.Print(text)
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"NyteBubo/internal/workflows"
+
+	"github.com/spf13/cobra"
+)
+
+var retryForce bool
+
+var retryCmd = &cobra.Command{
+	Use:   "retry owner/repo#issue",
+	Short: "Start an issue's implementation over",
+	Long: `Discard an issue's implementation progress and implement it again, like the retry slash command.
+The implementation runs before the command exits. An issue that still looks like it's being
+implemented is left alone unless --force is given, e.g. when the agent crashed while working on it.`,
+	Example: "  nytebubo retry owner/repo#42",
+	Args:    cobra.ExactArgs(1),
+	Run:     runRetry,
+}
+
+func init() {
+	rootCmd.AddCommand(retryCmd)
+	retryCmd.Flags().BoolVar(&retryForce, "force", false, "Retry even if the issue is still marked as implementing")
+	addDryRunFlag(retryCmd)
+}
+
+func runRetry(cmd *cobra.Command, args []string) {
+	owner, repo, issueNumber, err := parseRepoRef(args[0])
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if issueNumber == 0 {
+		log.Fatalf("Error: no issue number in %q (expected owner/repo#issue)", args[0])
+	}
+
+	config, _ := loadConfig()
+	githubToken, llmAPIKey := loadCredentials(&config)
+
+	// Ctrl+C or SIGTERM stops the run at its next step, leaving a checkpoint to resume from
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	agent, err := workflows.NewIssueAgent(ctx, githubToken, llmAPIKey, config)
+	if err != nil {
+		log.Fatalf("Failed to create agent: %v", err)
+	}
+	defer agent.Close()
+
+	if err := agent.RetryIssue(owner, repo, issueNumber, retryForce); err != nil {
+		agent.Close()
+		log.Fatalf("Retry failed: %v", err)
+	}
+	printIssueState(agent, owner, repo, issueNumber)
+}
@@ -17,7 +17,12 @@ The agent runs as a server waiting for GitHub webhook events.`,
         fmt.Println("\nAvailable commands:")
         fmt.Println("  init   - Create a config.yaml file")
         fmt.Println("  agent  - Start the polling agent server")
+        fmt.Println("  run    - Work on a single issue once and exit")
+        fmt.Println("  action - Handle a GitHub Actions event and exit")
         fmt.Println("  stats  - View token usage statistics")
+        fmt.Println("  status - Show the state of tracked issues")
+        fmt.Println("  retry  - Start an issue's implementation over")
+        fmt.Println("  abort  - Stop all work on an issue")
         fmt.Println("\nUse 'nytebubo [command] --help' for more information about a command.")
     },
 }
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"NyteBubo/internal/workflows"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	runRepo  string
+	runIssue int
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Work on a single issue once and exit",
+	Long: `Take one issue through the issue-to-PR workflow once and exit, without running the agent.
+A new issue is analyzed and, if nothing needs clarifying, implemented and opened as a pull request.
+Running it again continues an issue from where it left off (e.g. after someone answered the
+bot's questions), which makes it suitable for cron jobs and CI.`,
+	Example: "  nytebubo run --repo owner/repo --issue 42",
+	Run:     runOnce,
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+	runCmd.Flags().StringVarP(&runRepo, "repo", "r", "", "Repository (owner/repo)")
+	runCmd.Flags().IntVarP(&runIssue, "issue", "i", 0, "Issue number")
+	runCmd.MarkFlagRequired("repo")
+	runCmd.MarkFlagRequired("issue")
+	addDryRunFlag(runCmd)
+}
+
+func runOnce(cmd *cobra.Command, args []string) {
+	owner, repo, ok := strings.Cut(runRepo, "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		log.Fatalf("Error: invalid repository %q (expected owner/repo)", runRepo)
+	}
+	if runIssue <= 0 {
+		log.Fatalf("Error: invalid issue number %d", runIssue)
+	}
+
+	config, _ := loadConfig()
+	githubToken, llmAPIKey := loadCredentials(&config)
+
+	// Ctrl+C or SIGTERM stops the run at its next step, leaving a checkpoint to resume from
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	agent, err := workflows.NewIssueAgent(ctx, githubToken, llmAPIKey, config)
+	if err != nil {
+		log.Fatalf("Failed to create agent: %v", err)
+	}
+	defer agent.Close()
+
+	runErr := agent.RunIssue(owner, repo, runIssue)
+	printIssueState(agent, owner, repo, runIssue)
+
+	if runErr != nil {
+		agent.Close()
+		log.Fatalf("Run failed: %v", runErr)
+	}
+}
+
+// printIssueState prints an issue's status and cost after a command worked on it
+func printIssueState(agent *workflows.IssueAgent, owner, repo string, issueNumber int) {
+	state, err := agent.StateManager().GetState(owner, repo, issueNumber)
+	if err != nil {
+		log.Printf("Failed to read the issue's state: %v", err)
+	} else if state != nil {
+		fmt.Printf("\n%s/%s#%d: %s", owner, repo, issueNumber, state.Status)
+		if state.PRNumber != nil {
+			fmt.Printf(" (pull request #%d)", *state.PRNumber)
+		}
+		fmt.Printf("\nCost: $%.4f\n", state.TotalCost)
+	}
+}
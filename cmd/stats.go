@@ -2,9 +2,14 @@ package cmd
 
 import (
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"NyteBubo/internal/core"
 	"NyteBubo/internal/types"
@@ -14,21 +19,84 @@ import (
 )
 
 var (
-	exportCSV bool
-	csvFile   string
+	exportCSV   bool
+	csvFile     string
+	statsSince  string
+	statsUntil  string
+	statsRepo   string
+	statsFormat string
 )
 
 var statsCmd = &cobra.Command{
 	Use:   "stats",
 	Short: "View token usage statistics for issues",
-	Long:  `Display token usage and cost statistics for all processed issues. Optionally export to CSV.`,
-	Run:   runStats,
+	Long: `Display token usage and cost statistics for all processed issues. Optionally export to CSV.
+
+--since and --until limit the report to issues worked on in that window, and the model and
+purpose breakdowns to LLM calls made in it. Dates are YYYY-MM-DD (--until includes the day)
+or RFC 3339 timestamps.`,
+	Example: `  nytebubo stats
+  nytebubo stats --since 2026-09-01 --until 2026-09-30 --format markdown
+  nytebubo stats --repo myorg/api --format json`,
+	Run: runStats,
 }
 
 func init() {
 	rootCmd.AddCommand(statsCmd)
 	statsCmd.Flags().BoolVarP(&exportCSV, "export", "e", false, "Export statistics to CSV file")
 	statsCmd.Flags().StringVarP(&csvFile, "file", "f", "usage_stats.csv", "CSV file name for export")
+	statsCmd.Flags().StringVar(&statsSince, "since", "", "Only count activity from this date on")
+	statsCmd.Flags().StringVar(&statsUntil, "until", "", "Only count activity up to this date")
+	statsCmd.Flags().StringVar(&statsRepo, "repo", "", "Only count this repository (owner/repo) or owner")
+	statsCmd.Flags().StringVar(&statsFormat, "format", "text", "Output format: text, json, markdown or csv")
+}
+
+// statsReport is everything the stats command reports, in the shape printed as JSON
+type statsReport struct {
+	Since      *time.Time   `json:"since,omitempty"`
+	Until      *time.Time   `json:"until,omitempty"`
+	Repository string       `json:"repository,omitempty"`
+	Issues     []issueStats `json:"issues"`
+	Totals     statsTotals  `json:"totals"`
+	ByModel    []callStats  `json:"by_model"`
+	ByPurpose  []callStats  `json:"by_purpose"`
+}
+
+// issueStats is an issue's usage as reported by the stats command
+type issueStats struct {
+	Repository      string     `json:"repository"`
+	Issue           int        `json:"issue"`
+	Status          string     `json:"status"`
+	PRNumber        *int       `json:"pr_number,omitempty"`
+	InputTokens     int64      `json:"input_tokens"`
+	OutputTokens    int64      `json:"output_tokens"`
+	ReasoningTokens int64      `json:"reasoning_tokens"`
+	Cost            float64    `json:"cost"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+}
+
+// statsTotals sums the reported issues, and the calls made in the window
+type statsTotals struct {
+	Issues          int     `json:"issues"`
+	InputTokens     int64   `json:"input_tokens"`
+	OutputTokens    int64   `json:"output_tokens"`
+	ReasoningTokens int64   `json:"reasoning_tokens"`
+	Cost            float64 `json:"cost"`
+	Calls           int     `json:"calls"`
+	CallCost        float64 `json:"call_cost"` // Spent on calls in the window, unlike Cost, which is the issues' lifetime totals
+}
+
+// callStats sums the LLM calls made with a model or for a purpose
+type callStats struct {
+	Name         string  `json:"name"`
+	Calls        int     `json:"calls"`
+	Failures     int     `json:"failures"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
+	AvgLatencyMS int64   `json:"avg_latency_ms"`
 }
 
 func runStats(cmd *cobra.Command, args []string) {
@@ -49,90 +117,310 @@ func runStats(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	if database := os.Getenv("STATE_DB"); database != "" {
+		config.StateDB = database
+	}
+	config.StateDBKey = os.Getenv("STATE_DB_ENCRYPTION_KEY")
+
+	if !slices.Contains([]string{"text", "json", "markdown", "csv"}, statsFormat) {
+		log.Fatalf("Error: unknown format %q (expected text, json, markdown or csv)", statsFormat)
+	}
+	filter, err := statsFilter()
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
 	// Open state manager
-	stateManager, err := core.NewStateManager(config.StateDBPath)
+	stateManager, err := core.NewStateManager(config.StateDatabase(), config.StateDBKey)
 	if err != nil {
 		log.Fatalf("Failed to open state database: %v", err)
 	}
 	defer stateManager.Close()
 
-	// Get all issues with stats
-	states, err := stateManager.GetAllIssuesWithStats()
+	report, err := buildStatsReport(stateManager, filter)
 	if err != nil {
 		log.Fatalf("Failed to get statistics: %v", err)
 	}
 
-	if len(states) == 0 {
-		fmt.Println("No issues found in database.")
-		return
-	}
+	switch statsFormat {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Fatalf("Failed to write JSON: %v", err)
+		}
+	case "markdown":
+		writeStatsMarkdown(os.Stdout, report)
+	case "csv":
+		if err := writeCSV(os.Stdout, report.Issues); err != nil {
+			log.Fatalf("Failed to write CSV: %v", err)
+		}
+	default:
+		if len(report.Issues) == 0 {
+			fmt.Println("No issues found in database.")
+			return
+		}
 
-	// Display statistics
-	displayStats(states)
+		// Display statistics
+		displayStats(report)
+	}
 
 	// Export to CSV if requested
 	if exportCSV {
-		if err := exportToCSV(states, csvFile); err != nil {
+		if err := exportToCSV(report.Issues, csvFile); err != nil {
 			log.Fatalf("Failed to export to CSV: %v", err)
 		}
-		fmt.Printf("\n✅ Statistics exported to: %s\n", csvFile)
+		fmt.Fprintf(os.Stderr, "\n✅ Statistics exported to: %s\n", csvFile)
+	}
+}
+
+// statsFilter builds the filter given by the --since, --until and --repo flags
+func statsFilter() (core.StatsFilter, error) {
+	var filter core.StatsFilter
+	var err error
+	if statsSince != "" {
+		if filter.Since, err = parseStatsTime(statsSince, false); err != nil {
+			return filter, err
+		}
+	}
+	if statsUntil != "" {
+		if filter.Until, err = parseStatsTime(statsUntil, true); err != nil {
+			return filter, err
+		}
+	}
+	if statsRepo != "" {
+		filter.Owner, filter.Repo, _ = strings.Cut(statsRepo, "/")
+	}
+	return filter, nil
+}
+
+// parseStatsTime parses a YYYY-MM-DD date in local time or an RFC 3339 timestamp. With
+// endOfDay, a date means the end of that day.
+func parseStatsTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q (expected YYYY-MM-DD or an RFC 3339 timestamp)", value)
 	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
 }
 
-func displayStats(states []core.State) {
+// buildStatsReport gathers the issues and LLM calls matching filter
+func buildStatsReport(stateManager *core.StateManager, filter core.StatsFilter) (statsReport, error) {
+	report := statsReport{Repository: statsRepo, Issues: []issueStats{}}
+	if !filter.Since.IsZero() {
+		report.Since = &filter.Since
+	}
+	if !filter.Until.IsZero() {
+		report.Until = &filter.Until
+	}
+
+	states, err := stateManager.ListIssueStats(filter)
+	if err != nil {
+		return report, err
+	}
+	for _, state := range states {
+		report.Issues = append(report.Issues, issueStats{
+			Repository:      state.Owner + "/" + state.Repo,
+			Issue:           state.IssueNumber,
+			Status:          state.Status,
+			PRNumber:        state.PRNumber,
+			InputTokens:     state.TotalInputTokens,
+			OutputTokens:    state.TotalOutputTokens,
+			ReasoningTokens: state.TotalReasoningTokens,
+			Cost:            state.TotalCost,
+			CreatedAt:       state.CreatedAt,
+			UpdatedAt:       state.UpdatedAt,
+			CompletedAt:     state.CompletedAt,
+		})
+		report.Totals.Issues++
+		report.Totals.InputTokens += state.TotalInputTokens
+		report.Totals.OutputTokens += state.TotalOutputTokens
+		report.Totals.ReasoningTokens += state.TotalReasoningTokens
+		report.Totals.Cost += state.TotalCost
+	}
+
+	if report.ByModel, err = callBreakdown(stateManager, "model", filter); err != nil {
+		return report, err
+	}
+	if report.ByPurpose, err = callBreakdown(stateManager, "purpose", filter); err != nil {
+		return report, err
+	}
+	for _, model := range report.ByModel {
+		report.Totals.Calls += model.Calls
+		report.Totals.CallCost += model.Cost
+	}
+	return report, nil
+}
+
+// callBreakdown sums the LLM calls matching filter by "model" or "purpose"
+func callBreakdown(stateManager *core.StateManager, groupBy string, filter core.StatsFilter) ([]callStats, error) {
+	breakdown, err := stateManager.LLMCallBreakdown(groupBy, filter)
+	if err != nil {
+		return nil, err
+	}
+	stats := []callStats{}
+	for _, row := range breakdown {
+		stats = append(stats, callStats{
+			Name:         row.Key,
+			Calls:        row.Calls,
+			Failures:     row.Failures,
+			InputTokens:  row.InputTokens,
+			OutputTokens: row.OutputTokens,
+			Cost:         row.Cost,
+			AvgLatencyMS: row.AvgLatency.Milliseconds(),
+		})
+	}
+	return stats, nil
+}
+
+func displayStats(report statsReport) {
 	fmt.Println("\n╔═══════════════════════════════════════════════════════════════════════╗")
 	fmt.Println("║                     Token Usage Statistics                             ║")
 	fmt.Println("╚═══════════════════════════════════════════════════════════════════════╝\n")
 
-	var totalInputTokens int64
-	var totalOutputTokens int64
-	var totalCost float64
-
 	fmt.Printf("%-30s %-12s %-12s %-10s %s\n", "Issue", "Input Tokens", "Output Tokens", "Cost", "Status")
 	fmt.Println("────────────────────────────────────────────────────────────────────────────")
 
-	for _, state := range states {
-		issueID := fmt.Sprintf("%s/%s#%d", state.Owner, state.Repo, state.IssueNumber)
+	for _, issue := range report.Issues {
+		issueID := fmt.Sprintf("%s#%d", issue.Repository, issue.Issue)
 		fmt.Printf("%-30s %12d %12d  $%8.4f  %s\n",
 			issueID,
-			state.TotalInputTokens,
-			state.TotalOutputTokens,
-			state.TotalCost,
-			state.Status,
+			issue.InputTokens,
+			issue.OutputTokens,
+			issue.Cost,
+			issue.Status,
 		)
-
-		totalInputTokens += state.TotalInputTokens
-		totalOutputTokens += state.TotalOutputTokens
-		totalCost += state.TotalCost
 	}
 
+	totals := report.Totals
 	fmt.Println("────────────────────────────────────────────────────────────────────────────")
 	fmt.Printf("%-30s %12d %12d  $%8.4f\n",
 		"TOTAL",
-		totalInputTokens,
-		totalOutputTokens,
-		totalCost,
+		totals.InputTokens,
+		totals.OutputTokens,
+		totals.Cost,
 	)
 
 	// Summary statistics
-	avgCostPerIssue := totalCost / float64(len(states))
+	avgCostPerIssue := totals.Cost / float64(totals.Issues)
 	fmt.Printf("\n📊 Summary:\n")
-	fmt.Printf("  Total Issues: %d\n", len(states))
+	if period := statsPeriod(report); period != "" {
+		fmt.Printf("  Period: %s\n", period)
+	}
+	fmt.Printf("  Total Issues: %d\n", totals.Issues)
 	fmt.Printf("  Total Tokens: %d (input) + %d (output) = %d total\n",
-		totalInputTokens, totalOutputTokens, totalInputTokens+totalOutputTokens)
-	fmt.Printf("  Total Cost: $%.4f\n", totalCost)
+		totals.InputTokens, totals.OutputTokens, totals.InputTokens+totals.OutputTokens)
+	if totals.ReasoningTokens > 0 {
+		fmt.Printf("  Reasoning Tokens: %d (included in output)\n", totals.ReasoningTokens)
+	}
+	fmt.Printf("  Total Cost: $%.4f\n", totals.Cost)
 	fmt.Printf("  Average Cost per Issue: $%.4f\n", avgCostPerIssue)
+	if report.Since != nil || report.Until != nil {
+		fmt.Printf("  Spent in Period: $%.4f (%d calls)\n", totals.CallCost, totals.Calls)
+	}
+	fmt.Println()
+
+	displayCallBreakdown("Model", report.ByModel)
+	displayCallBreakdown("Purpose", report.ByPurpose)
+}
+
+// displayCallBreakdown prints LLM calls summed by model or purpose
+func displayCallBreakdown(title string, breakdown []callStats) {
+	if len(breakdown) == 0 {
+		return
+	}
+
+	fmt.Printf("📈 By %s:\n", strings.ToLower(title))
+	fmt.Printf("  %-40s %7s %8s %12s %12s %10s %10s\n", title, "Calls", "Failed", "Input", "Output", "Cost", "Latency")
+	for _, row := range breakdown {
+		fmt.Printf("  %-40s %7d %8d %12d %12d %10s %10s\n",
+			row.Name, row.Calls, row.Failures, row.InputTokens, row.OutputTokens, fmt.Sprintf("$%.4f", row.Cost),
+			(time.Duration(row.AvgLatencyMS) * time.Millisecond).String())
+	}
 	fmt.Println()
 }
 
-func exportToCSV(states []core.State, filename string) error {
+// statsPeriod describes the report's time window, or returns "" when it covers all time
+func statsPeriod(report statsReport) string {
+	switch {
+	case report.Since != nil && report.Until != nil:
+		return fmt.Sprintf("%s to %s", statsSince, statsUntil)
+	case report.Since != nil:
+		return "since " + statsSince
+	case report.Until != nil:
+		return "until " + statsUntil
+	}
+	return ""
+}
+
+// writeStatsMarkdown writes the report as Markdown tables, e.g. for a monthly cost report
+func writeStatsMarkdown(w io.Writer, report statsReport) {
+	fmt.Fprintln(w, "# NyteBubo Usage")
+	fmt.Fprintln(w)
+	var scope []string
+	if period := statsPeriod(report); period != "" {
+		scope = append(scope, "**Period:** "+period)
+	}
+	if report.Repository != "" {
+		scope = append(scope, "**Repository:** "+report.Repository)
+	}
+	if len(scope) > 0 {
+		fmt.Fprintln(w, strings.Join(scope, " · "))
+		fmt.Fprintln(w)
+	}
+
+	totals := report.Totals
+	fmt.Fprintln(w, "| Issue | Status | Input Tokens | Output Tokens | Cost |")
+	fmt.Fprintln(w, "|-------|--------|-------------:|--------------:|-----:|")
+	for _, issue := range report.Issues {
+		fmt.Fprintf(w, "| %s#%d | `%s` | %d | %d | $%.4f |\n",
+			issue.Repository, issue.Issue, issue.Status, issue.InputTokens, issue.OutputTokens, issue.Cost)
+	}
+	fmt.Fprintf(w, "| **Total (%d issues)** | | %d | %d | **$%.4f** |\n",
+		totals.Issues, totals.InputTokens, totals.OutputTokens, totals.Cost)
+	if report.Since != nil || report.Until != nil {
+		fmt.Fprintf(w, "\nSpent in the period: **$%.4f** over %d LLM calls.\n", totals.CallCost, totals.Calls)
+	}
+
+	writeCallBreakdownMarkdown(w, "Model", report.ByModel)
+	writeCallBreakdownMarkdown(w, "Purpose", report.ByPurpose)
+}
+
+// writeCallBreakdownMarkdown writes LLM calls summed by model or purpose as a Markdown table
+func writeCallBreakdownMarkdown(w io.Writer, title string, breakdown []callStats) {
+	if len(breakdown) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n## By %s\n\n", title)
+	fmt.Fprintf(w, "| %s | Calls | Failed | Input Tokens | Output Tokens | Cost | Avg Latency |\n", title)
+	fmt.Fprintln(w, "|---|------:|-------:|-------------:|--------------:|-----:|------------:|")
+	for _, row := range breakdown {
+		fmt.Fprintf(w, "| `%s` | %d | %d | %d | %d | $%.4f | %s |\n",
+			row.Name, row.Calls, row.Failures, row.InputTokens, row.OutputTokens, row.Cost,
+			(time.Duration(row.AvgLatencyMS) * time.Millisecond).String())
+	}
+}
+
+func exportToCSV(issues []issueStats, filename string) error {
 	file, err := os.Create(filename)
 	if err != nil {
 		return fmt.Errorf("failed to create CSV file: %w", err)
 	}
 	defer file.Close()
 
-	writer := csv.NewWriter(file)
+	return writeCSV(file, issues)
+}
+
+// writeCSV writes one row of usage per issue
+func writeCSV(w io.Writer, issues []issueStats) error {
+	writer := csv.NewWriter(w)
 	defer writer.Flush()
 
 	// Write header
@@ -144,6 +432,7 @@ func exportToCSV(states []core.State, filename string) error {
 		"PR Number",
 		"Input Tokens",
 		"Output Tokens",
+		"Reasoning Tokens",
 		"Total Tokens",
 		"Cost",
 		"Created At",
@@ -155,29 +444,31 @@ func exportToCSV(states []core.State, filename string) error {
 	}
 
 	// Write data rows
-	for _, state := range states {
+	for _, issue := range issues {
 		prNumber := ""
-		if state.PRNumber != nil {
-			prNumber = fmt.Sprintf("%d", *state.PRNumber)
+		if issue.PRNumber != nil {
+			prNumber = fmt.Sprintf("%d", *issue.PRNumber)
 		}
 
 		completedAt := ""
-		if state.CompletedAt != nil {
-			completedAt = state.CompletedAt.Format("2006-01-02 15:04:05")
+		if issue.CompletedAt != nil {
+			completedAt = issue.CompletedAt.Format("2006-01-02 15:04:05")
 		}
 
+		owner, repo, _ := strings.Cut(issue.Repository, "/")
 		row := []string{
-			state.Owner,
-			state.Repo,
-			fmt.Sprintf("%d", state.IssueNumber),
-			state.Status,
+			owner,
+			repo,
+			fmt.Sprintf("%d", issue.Issue),
+			issue.Status,
 			prNumber,
-			fmt.Sprintf("%d", state.TotalInputTokens),
-			fmt.Sprintf("%d", state.TotalOutputTokens),
-			fmt.Sprintf("%d", state.TotalInputTokens+state.TotalOutputTokens),
-			fmt.Sprintf("%.4f", state.TotalCost),
-			state.CreatedAt.Format("2006-01-02 15:04:05"),
-			state.UpdatedAt.Format("2006-01-02 15:04:05"),
+			fmt.Sprintf("%d", issue.InputTokens),
+			fmt.Sprintf("%d", issue.OutputTokens),
+			fmt.Sprintf("%d", issue.ReasoningTokens),
+			fmt.Sprintf("%d", issue.InputTokens+issue.OutputTokens),
+			fmt.Sprintf("%.4f", issue.Cost),
+			issue.CreatedAt.Format("2006-01-02 15:04:05"),
+			issue.UpdatedAt.Format("2006-01-02 15:04:05"),
 			completedAt,
 		}
 
@@ -186,5 +477,6 @@ func exportToCSV(states []core.State, filename string) error {
 		}
 	}
 
-	return nil
+	writer.Flush()
+	return writer.Error()
 }
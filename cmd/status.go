@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"NyteBubo/internal/core"
+
+	"github.com/spf13/cobra"
+)
+
+var statusJSON bool
+
+var statusCmd = &cobra.Command{
+	Use:   "status [owner/repo[#issue]]",
+	Short: "Show the state of tracked issues",
+	Long: `Show the status, branch, pull request, last update and cost of the issues in the state database.
+Give a repository to show only its issues, or an issue to show just that one.`,
+	Example: `  nytebubo status
+  nytebubo status owner/repo
+  nytebubo status owner/repo#42 --json`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runStatus,
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "Print the states as JSON")
+}
+
+// issueStatus is how an issue's state is shown by the status command
+type issueStatus struct {
+	Owner      string    `json:"owner"`
+	Repo       string    `json:"repo"`
+	Issue      int       `json:"issue"`
+	Status     string    `json:"status"`
+	Branch     string    `json:"branch,omitempty"`
+	PR         *int      `json:"pr,omitempty"`
+	Checkpoint string    `json:"checkpoint,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Cost       float64   `json:"cost"`
+}
+
+func runStatus(cmd *cobra.Command, args []string) {
+	var owner, repo string
+	issueNumber := 0
+	if len(args) == 1 {
+		var err error
+		owner, repo, issueNumber, err = parseRepoRef(args[0])
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+	}
+
+	config, _ := loadConfig()
+	stateManager, err := core.NewStateManager(config.StateDatabase(), config.StateDBKey)
+	if err != nil {
+		log.Fatalf("Failed to open state database: %v", err)
+	}
+	defer stateManager.Close()
+
+	states, err := stateManager.GetAllIssuesWithStats()
+	if err != nil {
+		log.Fatalf("Failed to get states: %v", err)
+	}
+
+	statuses := []issueStatus{}
+	for _, state := range states {
+		if owner != "" && (!strings.EqualFold(state.Owner, owner) || !strings.EqualFold(state.Repo, repo)) {
+			continue
+		}
+		if issueNumber != 0 && state.IssueNumber != issueNumber {
+			continue
+		}
+		statuses = append(statuses, issueStatus{
+			Owner:      state.Owner,
+			Repo:       state.Repo,
+			Issue:      state.IssueNumber,
+			Status:     state.Status,
+			Branch:     state.BranchName,
+			PR:         state.PRNumber,
+			Checkpoint: state.Checkpoint,
+			UpdatedAt:  state.UpdatedAt,
+			Cost:       state.TotalCost,
+		})
+	}
+
+	if statusJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(statuses); err != nil {
+			log.Fatalf("Failed to write JSON: %v", err)
+		}
+		return
+	}
+
+	if len(statuses) == 0 {
+		if issueNumber != 0 {
+			fmt.Printf("%s/%s#%d isn't tracked.\n", owner, repo, issueNumber)
+		} else {
+			fmt.Println("No issues found in database.")
+		}
+		return
+	}
+	displayStatus(statuses)
+}
+
+func displayStatus(statuses []issueStatus) {
+	fmt.Printf("%-30s %-26s %-36s %-6s %-20s %s\n", "Issue", "Status", "Branch", "PR", "Updated", "Cost")
+	fmt.Println(strings.Repeat("─", 130))
+
+	for _, status := range statuses {
+		issueID := fmt.Sprintf("%s/%s#%d", status.Owner, status.Repo, status.Issue)
+		state := status.Status
+		if status.Checkpoint != "" {
+			state += " (" + status.Checkpoint + ")"
+		}
+		pr := "-"
+		if status.PR != nil {
+			pr = fmt.Sprintf("#%d", *status.PR)
+		}
+		branch := status.Branch
+		if branch == "" {
+			branch = "-"
+		}
+		fmt.Printf("%-30s %-26s %-36s %-6s %-20s $%.4f\n",
+			issueID,
+			state,
+			branch,
+			pr,
+			status.UpdatedAt.Local().Format("2006-01-02 15:04:05"),
+			status.Cost,
+		)
+	}
+}
+
+// parseRepoRef parses "owner/repo" or "owner/repo#42". The issue number is 0 when only a
+// repository is given.
+func parseRepoRef(ref string) (owner, repo string, issueNumber int, err error) {
+	repoRef, number, hasIssue := strings.Cut(ref, "#")
+	owner, repo, ok := strings.Cut(repoRef, "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", "", 0, fmt.Errorf("invalid repository %q (expected owner/repo or owner/repo#issue)", ref)
+	}
+	if hasIssue {
+		issueNumber, err = strconv.Atoi(number)
+		if err != nil || issueNumber <= 0 {
+			return "", "", 0, fmt.Errorf("invalid issue number in %q", ref)
+		}
+	}
+	return owner, repo, issueNumber, nil
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"NyteBubo/internal/core"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	transcriptLast int
+	transcriptJSON bool
+)
+
+var transcriptCmd = &cobra.Command{
+	Use:   "transcript owner/repo#issue",
+	Short: "Print the LLM requests and responses of an issue",
+	Long: `Print every request sent to the LLM for an issue or pull request and the response to it,
+oldest first, from the transcripts written to llm_transcripts_dir. Credentials are redacted
+when the transcripts are written.`,
+	Example: `  nytebubo transcript owner/repo#42
+  nytebubo transcript owner/repo#42 --last 1
+  nytebubo transcript owner/repo#42 --json`,
+	Args: cobra.ExactArgs(1),
+	Run:  runTranscript,
+}
+
+func init() {
+	rootCmd.AddCommand(transcriptCmd)
+	transcriptCmd.Flags().IntVar(&transcriptLast, "last", 0, "Only print the last n requests")
+	transcriptCmd.Flags().BoolVar(&transcriptJSON, "json", false, "Print the transcripts as JSON")
+}
+
+func runTranscript(cmd *cobra.Command, args []string) {
+	owner, repo, issueNumber, err := parseRepoRef(args[0])
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if issueNumber == 0 {
+		log.Fatalf("Error: give an issue, e.g. %s#42", args[0])
+	}
+
+	config, _ := loadConfig()
+	if config.LLMTranscriptsDir == "" {
+		log.Fatal("Error: llm_transcripts_dir isn't set in config.yaml, so no transcripts are written")
+	}
+
+	transcripts, err := core.ReadTranscripts(config.LLMTranscriptsDir, owner, repo, issueNumber)
+	if err != nil {
+		log.Fatalf("Failed to read transcripts: %v", err)
+	}
+	if transcriptLast > 0 && len(transcripts) > transcriptLast {
+		transcripts = transcripts[len(transcripts)-transcriptLast:]
+	}
+
+	if transcriptJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if transcripts == nil {
+			transcripts = []core.Transcript{}
+		}
+		if err := encoder.Encode(transcripts); err != nil {
+			log.Fatalf("Failed to encode transcripts: %v", err)
+		}
+		return
+	}
+
+	if len(transcripts) == 0 {
+		fmt.Printf("No transcripts for %s/%s#%d in %s.\n", owner, repo, issueNumber, config.LLMTranscriptsDir)
+		return
+	}
+	for i, transcript := range transcripts {
+		if i > 0 {
+			fmt.Println()
+		}
+		displayTranscript(transcript)
+	}
+}
+
+// displayTranscript prints a request, its messages and the response
+func displayTranscript(t core.Transcript) {
+	latency := (time.Duration(t.LatencyMs) * time.Millisecond).Round(100 * time.Millisecond)
+	fmt.Printf("=== %s  %s  %s/%s  (%d in, %d out, $%.4f, %s)\n",
+		t.Time.Local().Format("2006-01-02 15:04:05"), t.Purpose, t.Provider, t.Model,
+		t.Usage.InputTokens, t.Usage.OutputTokens, t.Usage.Cost, latency)
+	if t.Schema != "" {
+		fmt.Printf("Structured output: %s\n", t.Schema)
+	}
+
+	fmt.Printf("\n--- system\n%s\n", t.System)
+	for _, msg := range t.Messages {
+		if msg.Images > 0 {
+			fmt.Printf("\n--- %s (%d images)\n%s\n", msg.Role, msg.Images, msg.Content)
+		} else {
+			fmt.Printf("\n--- %s\n%s\n", msg.Role, msg.Content)
+		}
+	}
+	if t.Error != "" {
+		fmt.Printf("\n--- error\n%s\n", t.Error)
+	} else {
+		fmt.Printf("\n--- response\n%s\n", t.Response)
+	}
+}
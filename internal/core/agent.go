@@ -0,0 +1,588 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultMaxTokens is used when no max_tokens is configured for a stage
+const defaultMaxTokens = 8096
+
+// Workflow stages, used to select per-stage generation parameters
+const (
+	StageAnalysis = "analysis"
+	StageCodegen  = "codegen"
+	StageReview   = "review"
+	StageChat     = "chat"
+)
+
+// GenerationParams controls model sampling for a request. Nil/zero fields are
+// left unset so the provider's defaults apply.
+type GenerationParams struct {
+	Temperature     *float64
+	TopP            *float64
+	MaxTokens       int
+	Stop            []string
+	ReasoningEffort string // "low", "medium" or "high" for reasoning models; empty leaves it to the model
+}
+
+// merge returns p with any fields set in override taking precedence
+func (p GenerationParams) merge(override GenerationParams) GenerationParams {
+	if override.Temperature != nil {
+		p.Temperature = override.Temperature
+	}
+	if override.TopP != nil {
+		p.TopP = override.TopP
+	}
+	if override.MaxTokens > 0 {
+		p.MaxTokens = override.MaxTokens
+	}
+	if len(override.Stop) > 0 {
+		p.Stop = override.Stop
+	}
+	if override.ReasoningEffort != "" {
+		p.ReasoningEffort = override.ReasoningEffort
+	}
+	return p
+}
+
+// TokenUsage tracks API token usage
+type TokenUsage struct {
+	InputTokens  int64
+	OutputTokens int64
+	TotalTokens  int64
+	Cost         float64 // Actual cost reported by the provider (0 when unavailable)
+	Model        string  // Model that actually served the request (may be a fallback)
+
+	CostEstimated bool // Cost was estimated from the price table rather than reported
+	Truncated     bool // Output stopped at the max_tokens limit rather than finishing
+
+	ReasoningTokens int64 // Portion of OutputTokens spent on reasoning/thinking
+}
+
+// ClaudeAgent sends prompts to the configured LLM provider, handling instructions,
+// context trimming, retries and model fallback
+type ClaudeAgent struct {
+	provider     LLMProvider
+	ctx          context.Context
+	model        string
+	instructions string // Repository-provided instructions prepended to every system prompt
+
+	// Generation parameters: global defaults plus per-stage overrides
+	defaultParams GenerationParams
+	stageParams   map[string]GenerationParams
+
+	// Primary model per stage, overriding model (e.g. a cheap model for conversation)
+	stageModels map[string]string
+
+	// Models tried in order when the primary model fails
+	fallbackModels []string
+
+	// Vision-capable model used for requests with image attachments (defaults to model)
+	visionModel string
+
+	// Model used for embeddings (long-term memory)
+	embeddingModel string
+
+	// Context window in tokens; prompts are trimmed to fit when set
+	contextWindow int
+
+	// Stream completions (when the provider supports it) and report progress
+	streaming  bool
+	onProgress ProgressFunc
+
+	// Logger tagged with the issue being worked on (default logger when unset)
+	logger *slog.Logger
+
+	// Where each request is recorded, and the issue or pull request it is attributed to
+	calls    CallRecorder
+	callsFor issueRef
+
+	// Prices for estimating cost when the provider doesn't report it, over the built-in table
+	prices map[string]ModelPrice
+
+	// System prompt templates (the built-in ones when nil)
+	prompts *Prompts
+
+	// Directory every request and response is written to, with credentials redacted
+	transcriptsDir string
+	credentials    []string
+}
+
+// NewClaudeAgent creates an agent backed by OpenRouter
+// If model is empty, defaults to "qwen/qwen3-coder:free" - best free coding model on OpenRouter
+func NewClaudeAgent(apiKey, model string) *ClaudeAgent {
+	return NewClaudeAgentWithProvider(NewOpenRouterProvider(apiKey), model)
+}
+
+// NewClaudeAgentWithProvider creates an agent that sends requests through the given provider.
+// If model is empty, the provider's default model is used.
+func NewClaudeAgentWithProvider(provider LLMProvider, model string) *ClaudeAgent {
+	if model == "" {
+		model = provider.DefaultModel()
+	}
+
+	return &ClaudeAgent{
+		provider: provider,
+		ctx:      context.Background(),
+		model:    model,
+	}
+}
+
+// Provider returns the name of the LLM provider requests are sent to
+func (ca *ClaudeAgent) Provider() string {
+	return ca.provider.Name()
+}
+
+// SetGenerationParams configures the global and per-stage generation parameters
+func (ca *ClaudeAgent) SetGenerationParams(defaults GenerationParams, stages map[string]GenerationParams) {
+	ca.defaultParams = defaults
+	ca.stageParams = stages
+}
+
+// SetStageModels configures the primary model used for each stage; stages without one use
+// the agent's model
+func (ca *ClaudeAgent) SetStageModels(models map[string]string) {
+	ca.stageModels = models
+}
+
+// SetFallbackModels configures the ordered list of models tried when the primary model fails
+func (ca *ClaudeAgent) SetFallbackModels(models []string) {
+	ca.fallbackModels = models
+}
+
+// SetVisionModel configures the model used for requests that include images
+func (ca *ClaudeAgent) SetVisionModel(model string) {
+	ca.visionModel = model
+}
+
+// SetContextWindow configures the model context window used to trim oversized prompts
+func (ca *ClaudeAgent) SetContextWindow(tokens int) {
+	ca.contextWindow = tokens
+}
+
+// SetContext sets the context for API calls; cancelling it aborts requests in flight
+func (ca *ClaudeAgent) SetContext(ctx context.Context) {
+	ca.ctx = ctx
+}
+
+// SetStreaming enables streamed completions for providers that support them
+func (ca *ClaudeAgent) SetStreaming(enabled bool) {
+	ca.streaming = enabled
+}
+
+// WithProgress returns a copy of the agent that reports streaming progress to fn
+func (ca *ClaudeAgent) WithProgress(fn ProgressFunc) *ClaudeAgent {
+	clone := *ca
+	clone.onProgress = fn
+	return &clone
+}
+
+// EstimatePromptTokens estimates the prompt size of a request before it is sent
+func (ca *ClaudeAgent) EstimatePromptTokens(messages []AgentMessage, systemPrompt string) int {
+	if ca.instructions != "" && !strings.Contains(systemPrompt, ca.instructions) {
+		systemPrompt = ca.instructions + "\n\n" + systemPrompt
+	}
+	return CountPromptTokens(systemPrompt, messages)
+}
+
+// paramsForStage resolves the generation parameters for a workflow stage
+func (ca *ClaudeAgent) paramsForStage(stage string) GenerationParams {
+	params := GenerationParams{MaxTokens: defaultMaxTokens}.merge(ca.defaultParams)
+	if override, ok := ca.stageParams[stage]; ok {
+		params = params.merge(override)
+	}
+	return params
+}
+
+// WithInstructions returns a copy of the agent that prepends the given
+// repository instructions (e.g. from NYTEBUBO.md) to every system prompt
+func (ca *ClaudeAgent) WithInstructions(instructions string) *ClaudeAgent {
+	clone := *ca
+	clone.instructions = strings.TrimSpace(instructions)
+	return &clone
+}
+
+// WithLogger returns a copy of the agent that logs, and has its provider log, through logger
+func (ca *ClaudeAgent) WithLogger(logger *slog.Logger) *ClaudeAgent {
+	clone := *ca
+	clone.logger = logger
+	return &clone
+}
+
+// log returns the agent's logger
+func (ca *ClaudeAgent) log() *slog.Logger {
+	if ca.logger != nil {
+		return ca.logger
+	}
+	return slog.Default()
+}
+
+// requestContext returns the context for provider calls, carrying the agent's logger
+func (ca *ClaudeAgent) requestContext() context.Context {
+	return withLogger(ca.ctx, ca.log())
+}
+
+// WithModel returns a copy of the agent that uses a different primary model for every stage
+func (ca *ClaudeAgent) WithModel(model string) *ClaudeAgent {
+	clone := *ca
+	clone.model = model
+	clone.stageModels = nil
+	return &clone
+}
+
+// AgentMessage represents a message in the conversation
+type AgentMessage struct {
+	Role    string
+	Content string
+	Images  []ImageAttachment `json:"-"` // Sent to vision models only, never persisted
+}
+
+const (
+	// maxServerErrorRetries is how many times a 5xx is retried on the same model
+	maxServerErrorRetries = 2
+	// serverErrorBackoff is the base delay between same-model retries
+	serverErrorBackoff = 5 * time.Second
+)
+
+// APIError is returned when the LLM provider responds with a non-200 status
+type APIError struct {
+	Provider   string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	provider := e.Provider
+	if provider == "" {
+		provider = "LLM"
+	}
+	return fmt.Sprintf("%s API error (%d): %s", provider, e.StatusCode, e.Message)
+}
+
+// isServerError reports whether err is a transient provider-side failure
+func isServerError(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500
+	}
+	return false
+}
+
+// isFallbackError reports whether a failed request should be retried on the next model.
+// Rate limits, server errors, network failures and capability errors (unsupported
+// structured output, unknown model, context too long) all warrant a fallback;
+// authentication failures do not, since every model shares the same key.
+func isFallbackError(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode != http.StatusUnauthorized && apiErr.StatusCode != http.StatusForbidden
+	}
+	return true
+}
+
+// SendMessageWithStructuredOutput sends a message with optional JSON schema for structured output
+// If useStructuredOutput is true, it attempts JSON schema first, then falls back to regular format
+func (ca *ClaudeAgent) SendMessageWithStructuredOutput(stage string, messages []AgentMessage, systemPrompt string, useStructuredOutput bool) (string, TokenUsage, error) {
+	if useStructuredOutput {
+		return ca.sendWithSchema(stage, messages, systemPrompt, codeChangesSchema())
+	}
+	return ca.sendMessageInternal(stage, messages, systemPrompt, nil)
+}
+
+// sendWithSchema asks for JSON matching schema, retrying without it if the model doesn't support structured output
+func (ca *ClaudeAgent) sendWithSchema(stage string, messages []AgentMessage, systemPrompt string, schema *jsonSchema) (string, TokenUsage, error) {
+	// Try with structured output first (across the whole model chain)
+	response, usage, err := ca.sendMessageInternal(stage, messages, systemPrompt, schema)
+	if err == nil {
+		return response, usage, nil
+	}
+
+	// A truncated JSON response can't be continued, but a plain one can
+	if errors.Is(err, ErrTruncated) {
+		ca.log().Warn("✂️  Structured response cut off at max_tokens, retrying without structured output", "schema", schema.Name)
+		return ca.sendMessageInternal(stage, messages, systemPrompt, nil)
+	}
+
+	// If structured output failed, log and retry without it
+	ca.log().Warn("⚠️  Structured output not supported by model, falling back to unstructured output", "schema", schema.Name, "error", err)
+	return ca.sendMessageInternal(stage, messages, systemPrompt, nil)
+}
+
+// SendMessage sends a message to the provider and gets a response with usage tracking
+func (ca *ClaudeAgent) SendMessage(messages []AgentMessage, systemPrompt string) (string, TokenUsage, error) {
+	return ca.sendMessageInternal(StageChat, messages, systemPrompt, nil)
+}
+
+// SendMessageForStage sends a message using the generation parameters of the given stage
+func (ca *ClaudeAgent) SendMessageForStage(stage string, messages []AgentMessage, systemPrompt string) (string, TokenUsage, error) {
+	return ca.sendMessageInternal(stage, messages, systemPrompt, nil)
+}
+
+// sendMessageInternal is the internal implementation that handles both structured and regular output.
+// It tries the primary model first and walks the fallback chain on provider failures.
+func (ca *ClaudeAgent) sendMessageInternal(stage string, messages []AgentMessage, systemPrompt string, schema *jsonSchema) (string, TokenUsage, error) {
+	// Prepend maintainer instructions from the repository, if any and the prompt template
+	// didn't place them itself
+	if ca.instructions != "" && !strings.Contains(systemPrompt, ca.instructions) {
+		systemPrompt = fmt.Sprintf("The maintainers of this repository provided the following instructions. Follow them unless they conflict with the task:\n\n%s\n\n---\n\n%s", ca.instructions, systemPrompt)
+	}
+
+	// Trim the oldest turns if the prompt plus the response budget won't fit the context window
+	params := ca.paramsForStage(stage)
+	if ca.contextWindow > 0 {
+		var removed int
+		messages, removed = trimToContextWindow(systemPrompt, messages, ca.contextWindow-params.MaxTokens)
+		if removed > 0 {
+			ca.log().Info("✂️  Trimmed older messages to fit the context window", "removed", removed, "context_window", ca.contextWindow)
+		}
+	}
+	ca.log().Debug("🔢 Estimated prompt size", "tokens", CountPromptTokens(systemPrompt, messages))
+
+	hasImages := false
+	for _, msg := range messages {
+		if len(msg.Images) > 0 {
+			hasImages = true
+		}
+	}
+
+	req := CompletionRequest{
+		System:   systemPrompt,
+		Messages: messages,
+		Params:   params,
+		Schema:   schema,
+	}
+
+	// Walk the model chain: primary model first, then configured fallbacks.
+	// Requests carrying images go to the vision model when one is configured.
+	primary := ca.model
+	if model := ca.stageModels[stage]; model != "" {
+		primary = model
+	}
+	if hasImages && ca.visionModel != "" {
+		primary = ca.visionModel
+	}
+	models := ca.modelChain(primary)
+	var lastErr error
+	for i, model := range models {
+		req.Model = model
+		responseText, usage, err := ca.sendWithRetries(stage, req)
+		if err == nil && usage.Truncated {
+			// Continuing goes to the same model; a fallback model would be cut off the same way
+			responseText, usage, err = ca.continueResponse(stage, req, responseText, usage)
+			if err != nil {
+				return "", TokenUsage{}, err
+			}
+		}
+		if err == nil {
+			if i > 0 {
+				ca.log().Info("🔀 Request served by fallback model", "model", usage.Model, "primary", primary)
+			}
+			return responseText, usage, nil
+		}
+
+		lastErr = err
+		if !isFallbackError(err) {
+			return "", TokenUsage{}, err
+		}
+		if i < len(models)-1 {
+			ca.log().Warn("⚠️  Model failed, trying fallback model", "model", model, "fallback", models[i+1], "error", err)
+		}
+	}
+
+	return "", TokenUsage{}, lastErr
+}
+
+// modelChain returns the primary model followed by any distinct fallback models
+func (ca *ClaudeAgent) modelChain(primary string) []string {
+	models := []string{primary}
+	for _, model := range ca.fallbackModels {
+		if model != "" && !slices.Contains(models, model) {
+			models = append(models, model)
+		}
+	}
+	return models
+}
+
+// sendWithRetries retries transient server errors on the same model before giving up
+func (ca *ClaudeAgent) sendWithRetries(stage string, req CompletionRequest) (string, TokenUsage, error) {
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		responseText, usage, err := ca.complete(req)
+		if err == nil {
+			ca.estimateCost(&usage, req.Model)
+		}
+		LLMLatency.Observe(req.Model, time.Since(start).Seconds())
+		ca.recordCall(stage, req.Model, usage, time.Since(start), err)
+		ca.writeTranscript(stage, req, responseText, usage, time.Since(start), err)
+		if err != nil {
+			LLMErrors.Inc(req.Model)
+		} else {
+			RecordUsage(usage)
+			ca.logUsage(usage)
+		}
+		if err == nil || !isServerError(err) || attempt >= maxServerErrorRetries {
+			return responseText, usage, err
+		}
+
+		wait := time.Duration(attempt+1) * serverErrorBackoff
+		ca.log().Warn("⏳ Server error, retrying", "model", req.Model, "wait", wait, "error", err)
+		if sleepErr := sleepContext(ca.ctx, wait); sleepErr != nil {
+			return responseText, usage, err
+		}
+	}
+}
+
+// complete sends a single request, streaming it when enabled and supported
+func (ca *ClaudeAgent) complete(req CompletionRequest) (string, TokenUsage, error) {
+	streamer, ok := ca.provider.(StreamingProvider)
+	if !ca.streaming || !ok {
+		return ca.provider.Complete(ca.requestContext(), req)
+	}
+
+	var received strings.Builder
+	var progress StreamProgress
+	scannedAt := 0
+	onDelta := func(delta string) {
+		received.WriteString(delta)
+		progress.Chars = received.Len()
+
+		// Rescanning the whole response on every token is wasteful; check every few hundred bytes
+		if progress.Chars-scannedAt < streamScanInterval {
+			return
+		}
+		scannedAt = progress.Chars
+		files := streamedFiles(received.String())
+		if len(files) <= len(progress.Files) {
+			return
+		}
+		progress.Files = files
+		ca.log().Info("✍️  Generating file", "number", len(files), "path", files[len(files)-1])
+		if ca.onProgress != nil {
+			ca.onProgress(progress)
+		}
+	}
+
+	responseText, usage, err := streamer.Stream(ca.requestContext(), req, onDelta)
+	if ca.onProgress != nil {
+		progress.Done = true
+		ca.onProgress(progress)
+	}
+	return responseText, usage, err
+}
+
+// AnalyzeIssue asks Claude to analyze a GitHub issue
+func (ca *ClaudeAgent) AnalyzeIssue(title, body string) (string, TokenUsage, error) {
+	return ca.AnalyzeIssueWithImages(title, body, nil)
+}
+
+// AnalyzeIssueWithImages analyzes a GitHub issue, passing any screenshots to a
+// vision-capable model. If the image request fails it retries with text only.
+func (ca *ClaudeAgent) AnalyzeIssueWithImages(title, body string, images []ImageAttachment) (string, TokenUsage, error) {
+	systemPrompt := ca.SystemPrompt(PromptAnalysis, PromptData{})
+
+	userMessage := fmt.Sprintf(`Please analyze this GitHub issue:
+
+Title: %s
+
+Description:
+%s
+
+Instructions:
+- First, provide a clear summary of what this issue is asking for
+- If you have ANY clarifying questions or uncertainties, ask them clearly
+- If EVERYTHING is completely clear and you need no additional information, ONLY THEN say you're ready to proceed
+
+IMPORTANT: Either ask questions OR confirm readiness - never do both in the same response.`, title, body)
+
+	messages := []AgentMessage{
+		{Role: "user", Content: userMessage},
+	}
+
+	if len(images) > 0 {
+		imageMessages := []AgentMessage{{
+			Role:    "user",
+			Content: userMessage + fmt.Sprintf("\n\nThe issue includes %d attached image(s), shown below. Use them to understand the problem.", len(images)),
+			Images:  images,
+		}}
+
+		response, usage, err := ca.SendMessageForStage(StageAnalysis, imageMessages, systemPrompt)
+		if err == nil {
+			return response, usage, nil
+		}
+		ca.log().Warn("⚠️  Image analysis failed, retrying with text only", "error", err)
+	}
+
+	return ca.SendMessageForStage(StageAnalysis, messages, systemPrompt)
+}
+
+// GenerateCode asks Claude to generate code for a specific task
+// It attempts to use structured JSON output for compatible models, with markdown fallback
+func (ca *ClaudeAgent) GenerateCode(task, context, language string, conversationHistory []AgentMessage) (string, TokenUsage, error) {
+	systemPrompt := ca.SystemPrompt(PromptCodegen, PromptData{Language: language, Context: context, Task: task})
+
+	// Try structured output first, fallback to regular message if model doesn't support it
+	return ca.SendMessageWithStructuredOutput(StageCodegen, conversationHistory, systemPrompt, true)
+}
+
+// GenerateEdits asks the model for search/replace edits to existing files instead of
+// complete rewrites, which keeps large files from being truncated
+func (ca *ClaudeAgent) GenerateEdits(task, context, language string, conversationHistory []AgentMessage) (string, TokenUsage, error) {
+	systemPrompt := ca.SystemPrompt(PromptEdits, PromptData{Language: language, Context: context, Task: task})
+
+	return ca.SendMessageForStage(StageCodegen, conversationHistory, systemPrompt)
+}
+
+// ReviewFeedback processes review feedback and generates updated code
+func (ca *ClaudeAgent) ReviewFeedback(feedback string, previousCode string, conversationHistory []AgentMessage) (string, TokenUsage, error) {
+	systemPrompt := ca.SystemPrompt(PromptReviewFeedback, PromptData{})
+
+	userMessage := fmt.Sprintf(`Here's the review feedback on the code:
+
+%s
+
+Previous code:
+%s
+
+Please update the code based on this feedback.`, feedback, previousCode)
+
+	// Add the new message to the conversation history
+	updatedHistory := append(conversationHistory, AgentMessage{
+		Role:    "user",
+		Content: userMessage,
+	})
+
+	return ca.SendMessageForStage(StageReview, updatedHistory, systemPrompt)
+}
+
+// ResolveConflicts resolves merge conflicts left by rebasing the bot's branch onto its
+// base. files maps each conflicted path to its content with conflict markers.
+func (ca *ClaudeAgent) ResolveConflicts(base string, files map[string]string, conversationHistory []AgentMessage) (string, TokenUsage, error) {
+	systemPrompt := ca.SystemPrompt(PromptConflicts, PromptData{})
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var userMessage strings.Builder
+	userMessage.WriteString(fmt.Sprintf("Rebasing onto %s left conflicts in these files:\n", base))
+	for _, path := range paths {
+		userMessage.WriteString(fmt.Sprintf("\n--- %s ---\n%s\n", path, files[path]))
+	}
+
+	updatedHistory := append(append([]AgentMessage{}, conversationHistory...), AgentMessage{
+		Role:    "user",
+		Content: userMessage.String(),
+	})
+
+	return ca.SendMessageForStage(StageCodegen, updatedHistory, systemPrompt)
+}
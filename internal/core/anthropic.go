@@ -0,0 +1,238 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	anthropicAPIURL  = "https://api.anthropic.com/v1/messages"
+	anthropicVersion = "2023-06-01"
+)
+
+// anthropicThinkingBudgets maps reasoning effort to an extended thinking token budget
+var anthropicThinkingBudgets = map[string]int{
+	"low":    2048,
+	"medium": 8192,
+	"high":   16384,
+}
+
+// anthropicProvider sends requests directly to Anthropic's Messages API
+type anthropicProvider struct {
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewAnthropicProvider creates an Anthropic Messages API client
+func NewAnthropicProvider(apiKey string) LLMProvider {
+	return &anthropicProvider{
+		apiKey:     apiKey,
+		httpClient: &http.Client{},
+	}
+}
+
+// Name implements LLMProvider
+func (p *anthropicProvider) Name() string {
+	return "Anthropic"
+}
+
+// DefaultModel implements LLMProvider
+func (p *anthropicProvider) DefaultModel() string {
+	return "claude-sonnet-4-5"
+}
+
+// Endpoint implements LLMProvider
+func (p *anthropicProvider) Endpoint() string {
+	return anthropicAPIURL
+}
+
+// Anthropic Messages API request/response structures
+type anthropicMessage struct {
+	Role    string                  `json:"role"`
+	Content []anthropicContentBlock `json:"content"`
+}
+
+type anthropicContentBlock struct {
+	Type   string                `json:"type"`
+	Text   string                `json:"text,omitempty"`
+	Source *anthropicImageSource `json:"source,omitempty"`
+}
+
+type anthropicImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
+type anthropicThinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens"`
+}
+
+type anthropicRequest struct {
+	Model         string             `json:"model"`
+	System        string             `json:"system,omitempty"`
+	Messages      []anthropicMessage `json:"messages"`
+	MaxTokens     int                `json:"max_tokens"`
+	Temperature   *float64           `json:"temperature,omitempty"`
+	TopP          *float64           `json:"top_p,omitempty"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+	Thinking      *anthropicThinking `json:"thinking,omitempty"`
+}
+
+type anthropicResponse struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int64 `json:"input_tokens"`
+		OutputTokens int64 `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// anthropicMessages converts the conversation into Anthropic's format. The API requires
+// alternating roles starting with a user turn, so consecutive turns from the same role
+// are merged.
+func anthropicMessages(messages []AgentMessage) []anthropicMessage {
+	var result []anthropicMessage
+	for _, msg := range messages {
+		role := msg.Role
+		if role != "assistant" {
+			role = "user"
+		}
+
+		blocks := []anthropicContentBlock{{Type: "text", Text: msg.Content}}
+		for _, image := range msg.Images {
+			if source := anthropicImage(image.DataURL); source != nil {
+				blocks = append(blocks, anthropicContentBlock{Type: "image", Source: source})
+			}
+		}
+
+		if len(result) > 0 && result[len(result)-1].Role == role {
+			result[len(result)-1].Content = append(result[len(result)-1].Content, blocks...)
+			continue
+		}
+		if len(result) == 0 && role == "assistant" {
+			result = append(result, anthropicMessage{
+				Role:    "user",
+				Content: []anthropicContentBlock{{Type: "text", Text: "(conversation start)"}},
+			})
+		}
+		result = append(result, anthropicMessage{Role: role, Content: blocks})
+	}
+	return result
+}
+
+// anthropicImage converts a base64 data URL into an image source block
+func anthropicImage(dataURL string) *anthropicImageSource {
+	header, data, ok := strings.Cut(strings.TrimPrefix(dataURL, "data:"), ",")
+	if !ok {
+		return nil
+	}
+	mediaType, encoding, _ := strings.Cut(header, ";")
+	if encoding != "base64" {
+		return nil
+	}
+	return &anthropicImageSource{Type: "base64", MediaType: mediaType, Data: data}
+}
+
+// Complete performs a single request against the Anthropic Messages API.
+// Structured output is not requested; callers fall back to parsing the text response.
+func (p *anthropicProvider) Complete(ctx context.Context, completion CompletionRequest) (string, TokenUsage, error) {
+	params := completion.Params
+	reqBody := anthropicRequest{
+		Model:         completion.Model,
+		System:        completion.System,
+		Messages:      anthropicMessages(completion.Messages),
+		MaxTokens:     params.MaxTokens,
+		Temperature:   params.Temperature,
+		TopP:          params.TopP,
+		StopSequences: params.Stop,
+	}
+	if reqBody.MaxTokens <= 0 {
+		reqBody.MaxTokens = defaultMaxTokens
+	}
+
+	// Extended thinking needs a budget below max_tokens and doesn't allow sampling overrides
+	if budget, ok := anthropicThinkingBudgets[strings.ToLower(params.ReasoningEffort)]; ok {
+		reqBody.Thinking = &anthropicThinking{Type: "enabled", BudgetTokens: budget}
+		if reqBody.MaxTokens <= budget {
+			reqBody.MaxTokens = budget + defaultMaxTokens
+		}
+		reqBody.Temperature = nil
+		reqBody.TopP = nil
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", TokenUsage{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", anthropicAPIURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", TokenUsage{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", p.apiKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", TokenUsage{}, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", TokenUsage{}, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Anthropic errors use the same {"error": {"type", "message"}} shape as OpenRouter
+	if resp.StatusCode != http.StatusOK {
+		var errResp openRouterError
+		if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error.Message != "" {
+			return "", TokenUsage{}, &APIError{Provider: p.Name(), StatusCode: resp.StatusCode, Message: errResp.Error.Message}
+		}
+		return "", TokenUsage{}, &APIError{Provider: p.Name(), StatusCode: resp.StatusCode, Message: string(body)}
+	}
+
+	var apiResp anthropicResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return "", TokenUsage{}, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	// Only text blocks are returned; thinking blocks are never posted
+	var text strings.Builder
+	for _, block := range apiResp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", TokenUsage{}, fmt.Errorf("no text content in response")
+	}
+
+	modelUsed := apiResp.Model
+	if modelUsed == "" {
+		modelUsed = reqBody.Model
+	}
+
+	usage := TokenUsage{
+		InputTokens:  apiResp.Usage.InputTokens,
+		OutputTokens: apiResp.Usage.OutputTokens,
+		TotalTokens:  apiResp.Usage.InputTokens + apiResp.Usage.OutputTokens,
+		Model:        modelUsed,
+		Truncated:    apiResp.StopReason == "max_tokens",
+	}
+
+	return StripReasoning(text.String()), usage, nil
+}
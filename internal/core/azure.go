@@ -0,0 +1,110 @@
+package core
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"golang.org/x/oauth2/microsoft"
+)
+
+const (
+	defaultAzureAPIVersion = "2024-10-21"
+	// azureTokenScope is the Entra ID scope of tokens for Azure OpenAI
+	azureTokenScope = "https://cognitiveservices.azure.com/.default"
+)
+
+// AzureOptions configures an Azure OpenAI resource
+type AzureOptions struct {
+	Endpoint    string            // Resource endpoint, e.g. "https://my-resource.openai.azure.com"
+	APIVersion  string            // api-version query parameter (default: defaultAzureAPIVersion)
+	Deployments map[string]string // Model name -> deployment; other names are used as deployments as they are
+
+	// Entra ID (Azure AD) application credentials, used instead of the API key when set
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+}
+
+// azureRouting sends requests for a model to its Azure OpenAI deployment and authenticates
+// them with an API key or an Entra ID token
+type azureRouting struct {
+	endpoint    string
+	apiVersion  string
+	deployments map[string]string
+	apiKey      string
+	tokens      oauth2.TokenSource // Set when authenticating with Entra ID
+}
+
+// NewAzureProvider creates an Azure OpenAI client. Requests are authenticated with Entra ID
+// tokens when the application credentials are set, and with apiKey otherwise.
+func NewAzureProvider(options AzureOptions, apiKey string) (LLMProvider, error) {
+	if options.Endpoint == "" {
+		return nil, fmt.Errorf("azure endpoint is not set")
+	}
+	routing := &azureRouting{
+		endpoint:    strings.TrimSuffix(options.Endpoint, "/"),
+		apiVersion:  cmp.Or(options.APIVersion, defaultAzureAPIVersion),
+		deployments: options.Deployments,
+		apiKey:      apiKey,
+	}
+
+	switch {
+	case options.TenantID != "" && options.ClientID != "" && options.ClientSecret != "":
+		credentials := clientcredentials.Config{
+			ClientID:     options.ClientID,
+			ClientSecret: options.ClientSecret,
+			TokenURL:     microsoft.AzureADEndpoint(options.TenantID).TokenURL,
+			Scopes:       []string{azureTokenScope},
+		}
+		// Tokens are cached and refreshed shortly before they expire
+		routing.tokens = credentials.TokenSource(context.Background())
+	case options.TenantID != "" || options.ClientID != "" || options.ClientSecret != "":
+		return nil, fmt.Errorf("azure Entra ID authentication needs a tenant ID, client ID and client secret")
+	case apiKey == "":
+		return nil, fmt.Errorf("azure needs an API key or Entra ID application credentials")
+	}
+
+	return &openAIProvider{
+		name:                  "Azure OpenAI",
+		apiKey:                apiKey,
+		defaultModel:          "gpt-4.1",
+		defaultEmbeddingModel: strings.TrimPrefix(defaultEmbeddingModel, "openai/"),
+		azure:                 routing,
+		httpClient:            &http.Client{},
+	}, nil
+}
+
+// deployment returns the deployment serving a model
+func (a *azureRouting) deployment(model string) string {
+	model = strings.TrimPrefix(model, "openai/")
+	if deployment, ok := a.deployments[model]; ok {
+		return deployment
+	}
+	return model
+}
+
+// url returns the URL of an operation, such as "chat/completions", on a model's deployment
+func (a *azureRouting) url(operation, model string) string {
+	return fmt.Sprintf("%s/openai/deployments/%s/%s?api-version=%s",
+		a.endpoint, url.PathEscape(a.deployment(model)), operation, url.QueryEscape(a.apiVersion))
+}
+
+// authorize adds an Entra ID token or the API key to a request
+func (a *azureRouting) authorize(req *http.Request) error {
+	if a.tokens == nil {
+		req.Header.Set("api-key", a.apiKey)
+		return nil
+	}
+	token, err := a.tokens.Token()
+	if err != nil {
+		return fmt.Errorf("failed to get Entra ID token: %w", err)
+	}
+	token.SetAuthHeader(req)
+	return nil
+}
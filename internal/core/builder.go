@@ -11,6 +11,11 @@ type LanguageBuilder struct {
 	BuildCommand []string
 	TestCommand  []string
 	RunCommand   []string
+	// FormatCommand and LintCommand run on changed source files, given in place of a
+	// {files} argument; without one they check the whole project
+	FormatCommand []string
+	LintCommand   []string
+	Extensions    []string // Source file extensions the format and lint commands take
 }
 
 // DetectLanguage attempts to detect the repository's primary language
@@ -80,34 +85,49 @@ func (s *Sandbox) DetectLanguage() (string, error) {
 func GetBuilder(language string) *LanguageBuilder {
 	builders := map[string]*LanguageBuilder{
 		"go": {
-			Language:     "go",
-			BuildCommand: []string{"go", "build", "./..."},
-			TestCommand:  []string{"go", "test", "./..."},
-			RunCommand:   []string{"go", "run", "."},
+			Language:      "go",
+			BuildCommand:  []string{"go", "build", "./..."},
+			TestCommand:   []string{"go", "test", "./..."},
+			RunCommand:    []string{"go", "run", "."},
+			FormatCommand: []string{"gofmt", "-w", filesPlaceholder},
+			LintCommand:   []string{"golangci-lint", "run", "--new-from-rev=HEAD"},
+			Extensions:    []string{".go"},
 		},
 		"python": {
-			Language:     "python",
-			BuildCommand: []string{"python", "-m", "py_compile"},
-			TestCommand:  []string{"pytest", "."},
-			RunCommand:   []string{"python", "main.py"},
+			Language:      "python",
+			BuildCommand:  []string{"python", "-m", "py_compile"},
+			TestCommand:   []string{"pytest", "."},
+			RunCommand:    []string{"python", "main.py"},
+			FormatCommand: []string{"black", "--quiet", filesPlaceholder},
+			LintCommand:   []string{"ruff", "check", filesPlaceholder},
+			Extensions:    []string{".py"},
 		},
 		"javascript": {
-			Language:     "javascript",
-			BuildCommand: []string{"npm", "install"},
-			TestCommand:  []string{"npm", "test"},
-			RunCommand:   []string{"npm", "start"},
+			Language:      "javascript",
+			BuildCommand:  []string{"npm", "install"},
+			TestCommand:   []string{"npm", "test"},
+			RunCommand:    []string{"npm", "start"},
+			FormatCommand: []string{"npx", "--no-install", "prettier", "--write", filesPlaceholder},
+			LintCommand:   []string{"npx", "--no-install", "eslint", filesPlaceholder},
+			Extensions:    javascriptExtensions,
 		},
 		"typescript": {
-			Language:     "typescript",
-			BuildCommand: []string{"npm", "run", "build"},
-			TestCommand:  []string{"npm", "test"},
-			RunCommand:   []string{"npm", "start"},
+			Language:      "typescript",
+			BuildCommand:  []string{"npm", "run", "build"},
+			TestCommand:   []string{"npm", "test"},
+			RunCommand:    []string{"npm", "start"},
+			FormatCommand: []string{"npx", "--no-install", "prettier", "--write", filesPlaceholder},
+			LintCommand:   []string{"npx", "--no-install", "eslint", filesPlaceholder},
+			Extensions:    javascriptExtensions,
 		},
 		"rust": {
-			Language:     "rust",
-			BuildCommand: []string{"cargo", "build"},
-			TestCommand:  []string{"cargo", "test"},
-			RunCommand:   []string{"cargo", "run"},
+			Language:      "rust",
+			BuildCommand:  []string{"cargo", "build"},
+			TestCommand:   []string{"cargo", "test"},
+			RunCommand:    []string{"cargo", "run"},
+			FormatCommand: []string{"rustfmt", "--edition", "2021", filesPlaceholder},
+			LintCommand:   []string{"cargo", "clippy", "--quiet"},
+			Extensions:    []string{".rs"},
 		},
 		"java": {
 			Language:     "java",
@@ -130,62 +150,182 @@ func GetBuilder(language string) *LanguageBuilder {
 	}
 }
 
-// Build runs the build command in the sandbox
-func (s *Sandbox) Build() (string, error) {
-	language, err := s.DetectLanguage()
+// builder returns the repository's language and the commands that build and test it:
+// those set with SetCommands, then the language's defaults. custom reports whether any
+// command was set by hand.
+func (s *Sandbox) builder() (language string, builder *LanguageBuilder, custom bool, err error) {
+	language, err = s.DetectLanguage()
 	if err != nil {
-		return "", fmt.Errorf("failed to detect language: %w", err)
+		return "", nil, false, fmt.Errorf("failed to detect language: %w", err)
+	}
+	defaults := *GetBuilder(language)
+	builder = &defaults
+
+	if s.buildCommand != "" {
+		builder.BuildCommand = []string{"sh", "-c", s.buildCommand}
+	}
+	if s.testCommand != "" {
+		builder.TestCommand = []string{"sh", "-c", s.testCommand}
 	}
+	return language, builder, s.buildCommand != "" || s.testCommand != "", nil
+}
 
-	builder := GetBuilder(language)
-	if builder.BuildCommand == nil {
-		fmt.Printf("⚠️  No build command for language: %s\n", language)
-		return "No build command available", nil
+// SetCommands overrides the shell commands that build and test the repository. Empty
+// commands are left to the language's defaults.
+func (s *Sandbox) SetCommands(buildCommand, testCommand string) {
+	s.buildCommand = buildCommand
+	s.testCommand = testCommand
+}
+
+// verifyTarget is a directory built and tested with one language's commands
+type verifyTarget struct {
+	dir      string // Relative to the repository root
+	language string
+	builder  *LanguageBuilder
+}
+
+// where describes the target's directory for outputs and errors, or "" for the root
+func (t verifyTarget) where() string {
+	if t.dir == "." {
+		return ""
 	}
+	return " in " + t.dir
+}
+
+// commandPrefix wraps the commands for a language run in a directory, e.g. to run them
+// under a specific toolchain version. A nil prefix runs them directly.
+type commandPrefix func(language, dir string) ([]string, error)
 
-	fmt.Printf("🔨 Building project (%s)...\n", language)
-	output, err := s.RunCommand(builder.BuildCommand[0], builder.BuildCommand[1:]...)
+// targets returns where to build and test: the whole repository, or in a monorepo only
+// the subprojects with uncommitted changes. Custom commands always run at the root.
+func (s *Sandbox) targets() ([]verifyTarget, error) {
+	language, builder, custom, err := s.builder()
 	if err != nil {
-		return output, fmt.Errorf("build failed: %w", err)
+		return nil, err
 	}
+	if !custom {
+		if affected := s.affectedSubprojects(); len(affected) > 0 {
+			targets := make([]verifyTarget, len(affected))
+			for i, project := range affected {
+				targets[i] = verifyTarget{dir: project.Dir, language: project.Language, builder: GetBuilder(project.Language)}
+			}
+			return targets, nil
+		}
+	}
+	return []verifyTarget{{dir: ".", language: language, builder: builder}}, nil
+}
 
-	fmt.Printf("✅ Build successful\n")
-	return output, nil
+// affectedSubprojects returns the monorepo subprojects the uncommitted changes touch, or
+// nil when the whole repository should be verified
+func (s *Sandbox) affectedSubprojects() []Subproject {
+	subprojects, err := s.DetectSubprojects()
+	if err != nil || len(subprojects) == 0 {
+		return nil
+	}
+	changed, err := s.ChangedFiles()
+	if err != nil || len(changed) == 0 {
+		return nil
+	}
+	return SubprojectsFor(subprojects, changed)
 }
 
-// Test runs the test command in the sandbox
-func (s *Sandbox) Test() (string, error) {
-	language, err := s.DetectLanguage()
+// runTarget runs one of a target's commands in its directory
+func (s *Sandbox) runTarget(target verifyTarget, prefix commandPrefix, command []string) (string, error) {
+	if prefix != nil {
+		wrapper, err := prefix(target.language, target.dir)
+		if err != nil {
+			return "", err
+		}
+		command = append(append([]string{}, wrapper...), command...)
+	}
+	output, err := s.runCommandIn(target.dir, command[0], command[1:]...)
+	if target.dir != "." {
+		output = fmt.Sprintf("==> %s\n%s", target.dir, output)
+	}
+	return output, err
+}
+
+// Build runs the build command in the sandbox
+func (s *Sandbox) Build() (string, error) {
+	return s.build(nil)
+}
+
+// build runs the build command for each target, wrapped by prefix when running under a
+// specific toolchain
+func (s *Sandbox) build(prefix commandPrefix) (string, error) {
+	targets, err := s.targets()
 	if err != nil {
-		return "", fmt.Errorf("failed to detect language: %w", err)
+		return "", err
 	}
 
-	builder := GetBuilder(language)
-	if builder.TestCommand == nil {
-		fmt.Printf("⚠️  No test command for language: %s\n", language)
-		return "No test command available", nil
+	var outputs []string
+	for _, target := range targets {
+		if target.builder.BuildCommand == nil {
+			s.Logger().Warn("⚠️  No build command for language", "language", target.language, "dir", target.dir)
+			outputs = append(outputs, "No build command available"+target.where())
+			continue
+		}
+
+		s.Logger().Info("🔨 Building project", "language", target.language, "dir", target.dir)
+		output, err := s.runTarget(target, prefix, target.builder.BuildCommand)
+		outputs = append(outputs, output)
+		if err != nil {
+			return strings.Join(outputs, "\n"), fmt.Errorf("build failed%s: %w", target.where(), err)
+		}
 	}
 
-	fmt.Printf("🧪 Running tests (%s)...\n", language)
-	output, err := s.RunCommand(builder.TestCommand[0], builder.TestCommand[1:]...)
+	s.Logger().Info("✅ Build successful")
+	return strings.Join(outputs, "\n"), nil
+}
+
+// Test runs the test command in the sandbox
+func (s *Sandbox) Test() (string, error) {
+	return s.test(nil)
+}
+
+// test runs the test command for each target, wrapped by prefix when running under a
+// specific toolchain
+func (s *Sandbox) test(prefix commandPrefix) (string, error) {
+	targets, err := s.targets()
 	if err != nil {
-		return output, fmt.Errorf("tests failed: %w", err)
+		return "", err
 	}
 
-	fmt.Printf("✅ Tests passed\n")
-	return output, nil
+	var outputs []string
+	for _, target := range targets {
+		if target.builder.TestCommand == nil {
+			s.Logger().Warn("⚠️  No test command for language", "language", target.language, "dir", target.dir)
+			outputs = append(outputs, "No test command available"+target.where())
+			continue
+		}
+
+		s.Logger().Info("🧪 Running tests", "language", target.language, "dir", target.dir)
+		output, err := s.runTarget(target, prefix, target.builder.TestCommand)
+		outputs = append(outputs, output)
+		if err != nil {
+			return strings.Join(outputs, "\n"), fmt.Errorf("tests failed%s: %w", target.where(), err)
+		}
+	}
+
+	s.Logger().Info("✅ Tests passed")
+	return strings.Join(outputs, "\n"), nil
 }
 
 // Verify runs both build and test
 func (s *Sandbox) Verify() (buildOutput, testOutput string, err error) {
+	return s.verify(nil)
+}
+
+// verify runs build and test with the given command prefix
+func (s *Sandbox) verify(prefix commandPrefix) (buildOutput, testOutput string, err error) {
 	// Try to build
-	buildOutput, buildErr := s.Build()
+	buildOutput, buildErr := s.build(prefix)
 	if buildErr != nil {
 		return buildOutput, "", buildErr
 	}
 
 	// Try to test
-	testOutput, testErr := s.Test()
+	testOutput, testErr := s.test(prefix)
 	if testErr != nil {
 		return buildOutput, testOutput, testErr
 	}
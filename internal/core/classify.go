@@ -0,0 +1,79 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Readiness is the machine-readable verdict on whether an issue can be implemented
+type Readiness struct {
+	ReadyToImplement bool     `json:"ready_to_implement"`
+	Questions        []string `json:"questions"`
+}
+
+// readinessSchema returns the JSON schema for readiness classification
+func readinessSchema() *jsonSchema {
+	return &jsonSchema{
+		Name:   "readiness",
+		Strict: true,
+		Schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"ready_to_implement": map[string]any{
+					"type":        "boolean",
+					"description": "True if the assistant has everything it needs and no open questions",
+				},
+				"questions": map[string]any{
+					"type":        "array",
+					"description": "Questions the assistant is waiting on (empty if none)",
+					"items":       map[string]any{"type": "string"},
+				},
+			},
+			"required":             []string{"ready_to_implement", "questions"},
+			"additionalProperties": false,
+		},
+	}
+}
+
+// ClassifyReadiness decides from the conversation whether the assistant's latest message
+// leaves questions that must be answered before implementation can start
+func (ca *ClaudeAgent) ClassifyReadiness(conversation []AgentMessage) (Readiness, TokenUsage, error) {
+	systemPrompt := ca.SystemPrompt(PromptReadiness, PromptData{})
+
+	messages := append(append([]AgentMessage{}, conversation...), AgentMessage{
+		Role:    "user",
+		Content: "Classify the assistant's latest message. Respond with the JSON object only.",
+	})
+
+	response, usage, err := ca.sendWithSchema(StageAnalysis, messages, systemPrompt, readinessSchema())
+	if err != nil {
+		return Readiness{}, usage, err
+	}
+
+	readiness, err := parseReadiness(response)
+	if err != nil {
+		return Readiness{}, usage, err
+	}
+	return readiness, usage, nil
+}
+
+// parseReadiness extracts the readiness JSON from a response, tolerating code fences and surrounding text
+func parseReadiness(response string) (Readiness, error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return Readiness{}, fmt.Errorf("no JSON object in classification response")
+	}
+
+	var readiness Readiness
+	if err := json.Unmarshal([]byte(response[start:end+1]), &readiness); err != nil {
+		return Readiness{}, fmt.Errorf("failed to parse classification response: %w", err)
+	}
+
+	// A verdict that lists open questions is never ready
+	if len(readiness.Questions) > 0 {
+		readiness.ReadyToImplement = false
+	}
+	return readiness, nil
+}
@@ -0,0 +1,38 @@
+package core
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// LastCleanup returns when an issue's branch and workspace were last cleaned up, or the
+// zero time if they never were
+func (sm *StateManager) LastCleanup(owner, repo string, issueNumber int) (time.Time, error) {
+	var cleanedAt time.Time
+	err := sm.db.QueryRow(`
+		SELECT cleaned_at FROM cleanups
+		WHERE owner = ? AND repo = ? AND issue_number = ?
+	`, owner, repo, issueNumber).Scan(&cleanedAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get last cleanup: %w", err)
+	}
+	return cleanedAt, nil
+}
+
+// RecordCleanup records that an issue's branch and workspace were cleaned up, so later
+// runs skip the issue until it finishes again
+func (sm *StateManager) RecordCleanup(owner, repo string, issueNumber int) error {
+	_, err := sm.db.Exec(`
+		INSERT INTO cleanups (owner, repo, issue_number, cleaned_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(owner, repo, issue_number) DO UPDATE SET cleaned_at = excluded.cleaned_at
+	`, owner, repo, issueNumber, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record cleanup: %w", err)
+	}
+	return nil
+}
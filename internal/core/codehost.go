@@ -0,0 +1,209 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v63/github"
+)
+
+// CodeHost is a service hosting repositories, issues and pull requests. The go-github
+// types are the shared data model; other hosts convert their API responses into them.
+type CodeHost interface {
+	GetAuthenticatedUser() (*github.User, error)
+	GetPermissionLevel(owner, repo, user string) (string, error)
+	GetRepository(owner, repo string) (*github.Repository, error)
+	GetFileContent(owner, repo, path, ref string) (string, error)
+	DeleteBranch(owner, repo, branch string) error
+	// CloneURL returns an HTTPS clone URL carrying the host's credentials
+	CloneURL(owner, repo string) string
+	// IssueURL and PullRequestURL return the web pages of an issue and a pull request
+	IssueURL(owner, repo string, number int) string
+	PullRequestURL(owner, repo string, number int) string
+	DownloadImage(imageURL string) (ImageAttachment, error)
+
+	GetIssue(owner, repo string, number int) (*github.Issue, error)
+	ListRepositoryIssues(owner, repo, assignee string) ([]*github.Issue, error)
+	ListOpenIssues(owner, repo string) ([]*github.Issue, error)
+	ListLabeledIssues(owner, repo, label string) ([]*github.Issue, error)
+	ListLabels(owner, repo string) ([]string, error)
+	// ListMentioningIssues returns open issues mentioning user that were updated after since,
+	// including pull requests on hosts that number them alongside issues
+	ListMentioningIssues(owner, repo, user string, since time.Time) ([]*github.Issue, error)
+	AddAssignee(owner, repo string, number int, assignee string) error
+	RemoveAssignee(owner, repo string, number int, assignee string) error
+	CloseIssue(owner, repo string, number int) error
+	CreateIssue(owner, repo, title, body string) (*github.Issue, error)
+	// AddLabels adds labels to an issue, creating them where the host allows
+	AddLabels(owner, repo string, number int, labels []string) error
+
+	// Comment methods take the issue number as well as the comment ID, since some
+	// hosts only address comments within their issue
+	ListIssueComments(owner, repo string, number int) ([]*github.IssueComment, error)
+	CreateIssueComment(owner, repo string, number int, body string) error
+	CreateIssueCommentWithID(owner, repo string, number int, body string) (int64, error)
+	EditIssueComment(owner, repo string, number int, commentID int64, body string) error
+	DeleteIssueComment(owner, repo string, number int, commentID int64) error
+	ListIssueCommentReactions(owner, repo string, number int, commentID int64) ([]*github.Reaction, error)
+
+	CreatePullRequest(owner, repo, title, body, head, base string, draft bool) (*github.PullRequest, error)
+	ClosePullRequest(owner, repo string, number int) error
+	// MarkPullRequestReady takes a pull request out of draft so it can be reviewed
+	MarkPullRequestReady(owner, repo string, pr *github.PullRequest) error
+	// SetPullRequestMetadata adds labels, assignees and requested reviewers to a pull
+	// request and sets its milestone, applying as much as it can when some of it fails
+	SetPullRequestMetadata(owner, repo string, number int, metadata PullRequestMetadata) error
+	GetPullRequest(owner, repo string, number int) (*github.PullRequest, error)
+	// ListOpenPullRequests returns open pull requests with their requested reviewers
+	ListOpenPullRequests(owner, repo string) ([]*github.PullRequest, error)
+	// GetPullRequestDiff returns a pull request's changes as a unified diff
+	GetPullRequestDiff(owner, repo string, number int) (string, error)
+	CreatePullRequestComment(owner, repo string, number int, body string) error
+	ListPRComments(owner, repo string, number int) ([]*github.PullRequestComment, error)
+	ListReviews(owner, repo string, number int) ([]*github.PullRequestReview, error)
+	ListPullRequestFiles(owner, repo string, number int) ([]string, error)
+	// CreateReview posts a review that comments without approving or requesting changes
+	CreateReview(owner, repo string, pr *github.PullRequest, body string, comments []ReviewComment) error
+	CountApprovals(owner, repo string, number int) (approvals int, changesRequested bool, err error)
+	ChecksPassed(owner, repo, ref string) (passed, pending bool, err error)
+	ListFailedChecks(owner, repo, ref string) ([]FailedCheck, error)
+	MergePullRequest(owner, repo string, number int, method, sha string) error
+	EnableAutoMerge(owner, repo string, pr *github.PullRequest, method string) error
+}
+
+// ReviewComment is an inline review comment on a line added by a pull request
+type ReviewComment struct {
+	Path string
+	Line int // Line number in the new version of the file
+	Body string
+}
+
+// PullRequestMetadata is added to a pull request after it is opened
+type PullRequestMetadata struct {
+	Labels        []string
+	Assignees     []string
+	Reviewers     []string // Users to request reviews from
+	TeamReviewers []string // Team slugs to request reviews from, where the host has teams
+	Milestone     string   // Milestone title
+}
+
+// FailedCheck is a failed CI job, check run or commit status
+type FailedCheck struct {
+	Name string
+	Log  string // Tail of the job log, or the check's description when no log is available
+}
+
+// maxCheckLogBytes is how much of the end of a CI job log is kept
+const maxCheckLogBytes = 64 * 1024
+
+// readLogTail reads a job log and keeps its end, where failures are reported
+func readLogTail(r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, 32<<20))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxCheckLogBytes {
+		data = data[len(data)-maxCheckLogBytes:]
+		// Start at a line boundary
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	return string(data), nil
+}
+
+// IsNotFound reports whether err is a 404 from any code host
+func IsNotFound(err error) bool {
+	var ghErr *github.ErrorResponse
+	if errors.As(err, &ghErr) && ghErr.Response != nil && ghErr.Response.StatusCode == http.StatusNotFound {
+		return true
+	}
+	return isGitLabNotFound(err) || isGiteaNotFound(err)
+}
+
+// CodeHosts routes each repository to the code host serving it. Repositories default
+// to GitHub unless registered with another host.
+type CodeHosts struct {
+	github *GitHubClient
+	byRepo map[string]CodeHost // "owner/repo" -> host
+	dryRun *DryRun             // Set to report writes instead of making them
+
+	mu     sync.Mutex
+	logins map[CodeHost]string // Authenticated bot login per host
+}
+
+// NewCodeHosts creates a router that sends every repository to GitHub
+func NewCodeHosts(github *GitHubClient) *CodeHosts {
+	return &CodeHosts{
+		github: github,
+		byRepo: make(map[string]CodeHost),
+		logins: make(map[CodeHost]string),
+	}
+}
+
+// Register serves a repository ("owner/repo") from host instead of GitHub
+func (h *CodeHosts) Register(repoFullName string, host CodeHost) {
+	h.byRepo[repoFullName] = host
+}
+
+// SetDryRun reports every change to a repository to dryRun instead of making it
+func (h *CodeHosts) SetDryRun(dryRun *DryRun) {
+	h.dryRun = dryRun
+}
+
+// For returns the host serving a repository
+func (h *CodeHosts) For(owner, repo string) CodeHost {
+	host := h.host(owner, repo)
+	if h.dryRun != nil {
+		return &dryRunHost{CodeHost: host, dryRun: h.dryRun}
+	}
+	return host
+}
+
+// host returns the host serving a repository, without the dry run
+func (h *CodeHosts) host(owner, repo string) CodeHost {
+	if host, ok := h.byRepo[owner+"/"+repo]; ok {
+		return host
+	}
+	return h.github
+}
+
+// GitHub returns the GitHub client, for GitHub-only features such as webhook catch-up
+func (h *CodeHosts) GitHub() *GitHubClient {
+	return h.github
+}
+
+// SetGitHubLogin sets the bot's GitHub login, for tokens that can't look up their own
+// user (such as the GITHUB_TOKEN of a GitHub Actions workflow)
+func (h *CodeHosts) SetGitHubLogin(login string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.logins[h.github] = login
+}
+
+// BotLogin returns the bot's login on the host serving a repository
+func (h *CodeHosts) BotLogin(owner, repo string) (string, error) {
+	host := h.host(owner, repo)
+
+	h.mu.Lock()
+	login, ok := h.logins[host]
+	h.mu.Unlock()
+	if ok {
+		return login, nil
+	}
+
+	user, err := host.GetAuthenticatedUser()
+	if err != nil {
+		return "", fmt.Errorf("failed to get authenticated user: %w", err)
+	}
+
+	h.mu.Lock()
+	h.logins[host] = user.GetLogin()
+	h.mu.Unlock()
+	return user.GetLogin(), nil
+}
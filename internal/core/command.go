@@ -0,0 +1,100 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// Defaults for commands run in the sandbox, such as builds and tests
+const (
+	DefaultCommandTimeout = 10 * time.Minute
+	DefaultMaxOutputBytes = 1 << 20
+)
+
+// commandWaitDelay is how long a killed command's output pipes are waited on, since
+// processes it started may still hold them open
+const commandWaitDelay = 5 * time.Second
+
+// SetCommandLimits bounds how long commands may run and how much of their output is kept.
+// Zero disables either limit.
+func (s *Sandbox) SetCommandLimits(timeout time.Duration, maxOutputBytes int) {
+	s.commandTimeout = timeout
+	s.maxOutputBytes = maxOutputBytes
+}
+
+// RunCommand executes a command in the sandbox and returns its combined output. A command
+// running past the timeout is killed along with the processes it started, and output
+// beyond the size limit is cut from the middle, keeping the start and the end.
+func (s *Sandbox) RunCommand(command string, args ...string) (string, error) {
+	return s.runCommandIn(".", command, args...)
+}
+
+// runCommandIn runs a command like RunCommand, in a directory of the repository
+func (s *Sandbox) runCommandIn(dir, command string, args ...string) (string, error) {
+	ctx := context.Background()
+	if s.commandTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.commandTimeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Dir = filepath.Join(s.repoPath, filepath.FromSlash(dir))
+	killProcessGroup(cmd)
+	cmd.WaitDelay = commandWaitDelay
+
+	output := &cappedOutput{max: s.maxOutputBytes}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	err := cmd.Run()
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		s.Logger().Warn("⏱️  Command timed out", "command", command, "timeout", s.commandTimeout)
+		return output.String(), fmt.Errorf("%s timed out after %s", command, s.commandTimeout)
+	}
+	return output.String(), err
+}
+
+// cappedOutput collects command output, keeping at most max bytes: the first half and
+// the most recent half
+type cappedOutput struct {
+	max   int
+	head  bytes.Buffer
+	tail  []byte
+	total int
+}
+
+func (o *cappedOutput) Write(p []byte) (int, error) {
+	o.total += len(p)
+	if o.max <= 0 {
+		o.head.Write(p)
+		return len(p), nil
+	}
+
+	data := p
+	if room := o.max/2 - o.head.Len(); room > 0 {
+		if room > len(data) {
+			room = len(data)
+		}
+		o.head.Write(data[:room])
+		data = data[room:]
+	}
+	o.tail = append(o.tail, data...)
+	if keep := o.max - o.max/2; len(o.tail) > keep {
+		o.tail = append(o.tail[:0], o.tail[len(o.tail)-keep:]...)
+	}
+	return len(p), nil
+}
+
+func (o *cappedOutput) String() string {
+	kept := o.head.Len() + len(o.tail)
+	if kept == o.total {
+		return o.head.String() + string(o.tail)
+	}
+	return fmt.Sprintf("%s\n\n... %d bytes of output omitted ...\n\n%s", o.head.String(), o.total-kept, o.tail)
+}
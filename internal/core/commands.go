@@ -0,0 +1,29 @@
+package core
+
+import (
+	"strings"
+)
+
+// CommandPrefix starts a slash command addressed to the agent in a comment
+const CommandPrefix = "/nytebubo"
+
+// Command is a slash command parsed from a comment, e.g. "/nytebubo set-model gpt-4o"
+type Command struct {
+	Name string
+	Args []string
+}
+
+// ParseCommand returns the first slash command in a comment. Commands must start a line.
+func ParseCommand(body string) (Command, bool) {
+	for _, line := range strings.Split(body, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || !strings.EqualFold(fields[0], CommandPrefix) {
+			continue
+		}
+		if len(fields) == 1 {
+			return Command{Name: "help"}, true
+		}
+		return Command{Name: strings.ToLower(fields[1]), Args: fields[2:]}, true
+	}
+	return Command{}, false
+}
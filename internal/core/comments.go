@@ -0,0 +1,103 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// Kinds of comments recorded as processed. Each kind has its own ID space on some hosts.
+const (
+	CommentKindIssue  = "issue_comment" // Comments on issues and pull request conversations
+	CommentKindPR     = "pr_comment"    // Review comments on pull request diffs
+	CommentKindReview = "review"        // Review summaries
+	CommentKindBody   = "issue_body"    // Mentions in issue and pull request descriptions, by issue ID
+)
+
+// commentTrackingKey is the setting holding when processed comments started being recorded
+const commentTrackingKey = "comment_tracking_since"
+
+// CommentTrackingSince returns when processed comments started being recorded. Comments
+// created before then were handled without being recorded.
+func (sm *StateManager) CommentTrackingSince() (time.Time, error) {
+	value, err := sm.GetSetting(commentTrackingKey)
+	if err != nil {
+		return time.Time{}, err
+	}
+	since, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s setting: %w", commentTrackingKey, err)
+	}
+	return since, nil
+}
+
+// IsCommentProcessed reports whether a comment was already handled
+func (sm *StateManager) IsCommentProcessed(owner, repo, kind string, commentID int64) (bool, error) {
+	var count int
+	err := sm.db.QueryRow(`
+		SELECT COUNT(*) FROM processed_comments
+		WHERE owner = ? AND repo = ? AND kind = ? AND comment_id = ?
+	`, owner, repo, kind, commentID).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check processed comment: %w", err)
+	}
+	return count > 0, nil
+}
+
+// ClaimComment records a comment as processed. It reports false if it already was, so a
+// comment delivered twice (or seen by both the webhook and the poller) is handled once.
+func (sm *StateManager) ClaimComment(owner, repo, kind string, commentID int64) (bool, error) {
+	result, err := sm.db.Exec(`
+		INSERT INTO processed_comments (owner, repo, kind, comment_id, processed_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT DO NOTHING
+	`, owner, repo, kind, commentID, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to claim comment: %w", err)
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim comment: %w", err)
+	}
+	return claimed > 0, nil
+}
+
+// ReleaseComment forgets that a comment was processed, so one whose handling failed is
+// handled again when its event is retried
+func (sm *StateManager) ReleaseComment(owner, repo, kind string, commentID int64) error {
+	if _, err := sm.db.Exec(`
+		DELETE FROM processed_comments
+		WHERE owner = ? AND repo = ? AND kind = ? AND comment_id = ?
+	`, owner, repo, kind, commentID); err != nil {
+		return fmt.Errorf("failed to release comment: %w", err)
+	}
+	return nil
+}
+
+// MarkCommentsProcessed records comments as processed
+func (sm *StateManager) MarkCommentsProcessed(owner, repo, kind string, commentIDs []int64) error {
+	if len(commentIDs) == 0 {
+		return nil
+	}
+
+	tx, err := sm.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, id := range commentIDs {
+		if _, err := tx.Exec(`
+			INSERT INTO processed_comments (owner, repo, kind, comment_id, processed_at)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT DO NOTHING
+		`, owner, repo, kind, id, now); err != nil {
+			return fmt.Errorf("failed to mark comment processed: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to mark comments processed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxContinuations is how many times a response cut off at max_tokens is continued
+// before the request fails
+const maxContinuations = 3
+
+// maxContinuationOverlap bounds how much of the end of a response is compared with the
+// start of its continuation when removing text the model repeated
+const maxContinuationOverlap = 500
+
+// continuationPrompt asks the model to carry on with a response it was cut off in
+const continuationPrompt = "Your previous response was cut off by the output token limit. Continue it exactly where it stopped, starting with the next character. Don't repeat anything, start over or add an introduction."
+
+// ErrTruncated is returned when a response is still cut off at max_tokens after being
+// continued, or when a structured response is cut off, which can't be continued
+var ErrTruncated = errors.New("response cut off at max_tokens")
+
+// continueResponse asks the model to carry on with a response that was cut off at
+// max_tokens until it finishes, stitching the parts together. Structured output fails with
+// ErrTruncated instead, since half a JSON document can't be continued reliably.
+func (ca *ClaudeAgent) continueResponse(stage string, req CompletionRequest, response string, usage TokenUsage) (string, TokenUsage, error) {
+	if req.Schema != nil {
+		return "", usage, fmt.Errorf("%w (structured output %s)", ErrTruncated, req.Schema.Name)
+	}
+
+	for continuation := 1; continuation <= maxContinuations; continuation++ {
+		ca.log().Warn("✂️  Response cut off at max_tokens, asking the model to continue",
+			"model", req.Model, "max_tokens", req.Params.MaxTokens, "continuation", continuation)
+
+		next := req
+		next.Messages = append(slices.Clone(req.Messages),
+			AgentMessage{Role: "assistant", Content: response},
+			AgentMessage{Role: "user", Content: continuationPrompt},
+		)
+		part, partUsage, err := ca.sendWithRetries(stage, next)
+		if err != nil {
+			return "", usage, err
+		}
+		usage = addUsage(usage, partUsage)
+		response = stitchContinuation(response, part)
+		if !partUsage.Truncated {
+			return response, usage, nil
+		}
+	}
+	return "", usage, fmt.Errorf("%w after %d continuations", ErrTruncated, maxContinuations)
+}
+
+// stitchContinuation appends a continuation to the response it continues, dropping the
+// start of the continuation when the model repeated the end of the response
+func stitchContinuation(response, continuation string) string {
+	for overlap := min(len(response), len(continuation), maxContinuationOverlap); overlap > 0; overlap-- {
+		if strings.HasSuffix(response, continuation[:overlap]) {
+			// A short match is as likely to be a coincidence, e.g. a newline, as a repeat
+			if overlap >= 20 {
+				return response + continuation[overlap:]
+			}
+			break
+		}
+	}
+	return response + continuation
+}
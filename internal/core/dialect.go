@@ -0,0 +1,182 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// dialect covers the differences between the databases the state can be stored in.
+// Queries are written for SQLite with ? placeholders and translated where needed.
+type dialect interface {
+	// driver is the database/sql driver name
+	driver() string
+	// rebind rewrites a query's placeholders for the database
+	rebind(query string) string
+	// ddl rewrites a schema statement's column types for the database
+	ddl(statement string) string
+	// addColumn adds a column to a table unless it already exists
+	addColumn(tx *stateTx, table, column, definition string) error
+	// forUpdate is appended to a SELECT to lock the rows it reads until the transaction ends
+	forUpdate() string
+	// shared reports whether several agents can use the database at once
+	shared() bool
+}
+
+// isPostgresDSN reports whether a state database setting is a PostgreSQL connection URL
+// rather than a SQLite file path
+func isPostgresDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
+
+// sqliteDialect stores the state in a local SQLite file
+type sqliteDialect struct{}
+
+func (sqliteDialect) driver() string              { return "sqlite" }
+func (sqliteDialect) rebind(query string) string  { return query }
+func (sqliteDialect) ddl(statement string) string { return statement }
+func (sqliteDialect) forUpdate() string           { return "" }
+func (sqliteDialect) shared() bool                { return false }
+
+func (sqliteDialect) addColumn(tx *stateTx, table, column, definition string) error {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return fmt.Errorf("failed to inspect table %s: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+
+	if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// postgresDialect stores the state in PostgreSQL, which several agent replicas can share
+type postgresDialect struct{}
+
+// postgresTypes maps SQLite column types to their PostgreSQL equivalents. Integers are
+// 64-bit since comment and review IDs don't fit in 32 bits, and REAL is only 32-bit in
+// PostgreSQL.
+var postgresTypes = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`\bINTEGER PRIMARY KEY AUTOINCREMENT\b`), "BIGSERIAL PRIMARY KEY"},
+	{regexp.MustCompile(`\bINTEGER\b`), "BIGINT"},
+	{regexp.MustCompile(`\bREAL\b`), "DOUBLE PRECISION"},
+	{regexp.MustCompile(`\bDATETIME\b`), "TIMESTAMPTZ"},
+}
+
+func (postgresDialect) driver() string    { return "pgx" }
+func (postgresDialect) forUpdate() string { return " FOR UPDATE" }
+func (postgresDialect) shared() bool      { return true }
+
+func (postgresDialect) rebind(query string) string {
+	if !strings.Contains(query, "?") {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (postgresDialect) ddl(statement string) string {
+	for _, t := range postgresTypes {
+		statement = t.re.ReplaceAllString(statement, t.repl)
+	}
+	return statement
+}
+
+func (d postgresDialect) addColumn(tx *stateTx, table, column, definition string) error {
+	if _, err := tx.Exec(d.ddl(fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", table, column, definition))); err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// stateDB runs queries written for SQLite against the configured database
+type stateDB struct {
+	*sql.DB
+	dialect dialect
+}
+
+func (db *stateDB) Exec(query string, args ...any) (sql.Result, error) {
+	return db.DB.Exec(db.dialect.rebind(query), args...)
+}
+
+func (db *stateDB) Query(query string, args ...any) (*sql.Rows, error) {
+	return db.DB.Query(db.dialect.rebind(query), args...)
+}
+
+func (db *stateDB) QueryRow(query string, args ...any) *sql.Row {
+	return db.DB.QueryRow(db.dialect.rebind(query), args...)
+}
+
+func (db *stateDB) Begin() (*stateTx, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &stateTx{Tx: tx, dialect: db.dialect}, nil
+}
+
+// beginConn starts a transaction on a connection of its own, for locks held while work
+// goes on elsewhere
+func (db *stateDB) beginConn(ctx context.Context) (*sql.Conn, *stateTx, error) {
+	conn, err := db.DB.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, &stateTx{Tx: tx, dialect: db.dialect}, nil
+}
+
+// stateTx is a transaction on a stateDB
+type stateTx struct {
+	*sql.Tx
+	dialect dialect
+}
+
+func (tx *stateTx) Exec(query string, args ...any) (sql.Result, error) {
+	return tx.Tx.Exec(tx.dialect.rebind(query), args...)
+}
+
+func (tx *stateTx) Query(query string, args ...any) (*sql.Rows, error) {
+	return tx.Tx.Query(tx.dialect.rebind(query), args...)
+}
+
+func (tx *stateTx) QueryRow(query string, args ...any) *sql.Row {
+	return tx.Tx.QueryRow(tx.dialect.rebind(query), args...)
+}
@@ -0,0 +1,323 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v63/github"
+)
+
+// dryRunNumberBase is where the numbers of issues and pull requests that were never opened start
+const dryRunNumberBase = 1000000
+
+// DryRun records the changes the agent would make to repositories instead of making them.
+// Comments, pull requests and pushed diffs are printed to stdout, or written to a
+// directory with one file per change.
+type DryRun struct {
+	dir string // Empty for stdout
+
+	mu        sync.Mutex
+	seq       int
+	nextID    int64
+	comments  map[int64]bool                 // Comments that weren't posted
+	diffs     map[string]string              // "owner/repo:branch" -> diff that would have been pushed
+	pulls     map[string]*github.PullRequest // "owner/repo#number" -> pull request that wasn't opened
+	nextPulls map[string]int                 // "owner/repo" -> next issue or pull request number
+}
+
+// NewDryRun creates a dry run that writes changes to dir, or prints them if dir is empty
+func NewDryRun(dir string) (*DryRun, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create dry run directory: %w", err)
+		}
+	}
+	return &DryRun{
+		dir:       dir,
+		nextID:    dryRunNumberBase,
+		comments:  make(map[int64]bool),
+		diffs:     make(map[string]string),
+		pulls:     make(map[string]*github.PullRequest),
+		nextPulls: make(map[string]int),
+	}, nil
+}
+
+// Report records a change to an issue or pull request
+func (d *DryRun) Report(owner, repo string, number int, action, content string) {
+	d.write(owner, repo, number, action, "md", content)
+}
+
+// ReportPush records the diff of a branch that would have been pushed
+func (d *DryRun) ReportPush(owner, repo string, issueNumber int, branch, diff string) {
+	d.mu.Lock()
+	d.diffs[owner+"/"+repo+":"+branch] = diff
+	d.mu.Unlock()
+	d.write(owner, repo, issueNumber, "push "+branch, "diff", diff)
+}
+
+func (d *DryRun) write(owner, repo string, number int, action, ext, content string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.seq++
+
+	logger := IssueLogger(owner, repo, number)
+	if d.dir == "" {
+		fmt.Printf("\n=== [dry run] %s/%s#%d: %s ===\n%s\n", owner, repo, number, action, strings.TrimRight(content, "\n"))
+		logger.Info("🧪 Dry run: skipped change", "action", action)
+		return
+	}
+
+	slug := strings.NewReplacer("/", "-", " ", "-").Replace(action)
+	name := fmt.Sprintf("%03d-%s-%s-%d-%s.%s", d.seq, owner, repo, number, slug, ext)
+	path := filepath.Join(d.dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		logger.Warn("⚠️  Failed to write dry run output", "path", path, "error", err)
+		return
+	}
+	logger.Info("🧪 Dry run: skipped change", "action", action, "file", path)
+}
+
+// commentID returns a made-up ID for a comment that wasn't posted
+func (d *DryRun) commentID() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.nextID++
+	d.comments[d.nextID] = true
+	return d.nextID
+}
+
+// isComment reports whether a comment ID was made up by the dry run
+func (d *DryRun) isComment(commentID int64) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.comments[commentID]
+}
+
+// nextNumber returns a made-up number for an issue or pull request that wasn't opened.
+// The caller holds d.mu.
+func (d *DryRun) nextNumber(owner, repo string) int {
+	key := owner + "/" + repo
+	if d.nextPulls[key] == 0 {
+		d.nextPulls[key] = dryRunNumberBase
+	}
+	d.nextPulls[key]++
+	return d.nextPulls[key]
+}
+
+// openIssue returns an issue that wasn't opened
+func (d *DryRun) openIssue(owner, repo, title, body string) *github.Issue {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	number := d.nextNumber(owner, repo)
+	return &github.Issue{
+		Number:  github.Int(number),
+		Title:   github.String(title),
+		Body:    github.String(body),
+		State:   github.String("open"),
+		HTMLURL: github.String(fmt.Sprintf("dry-run://%s/%s/issues/%d", owner, repo, number)),
+	}
+}
+
+// openPull records a pull request that wasn't opened and returns it
+func (d *DryRun) openPull(owner, repo, title, body, head, base string, draft bool) *github.PullRequest {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key := owner + "/" + repo
+	number := d.nextNumber(owner, repo)
+
+	pr := &github.PullRequest{
+		Number:  github.Int(number),
+		Title:   github.String(title),
+		Body:    github.String(body),
+		State:   github.String("open"),
+		Draft:   github.Bool(draft),
+		HTMLURL: github.String(fmt.Sprintf("dry-run://%s/%s/pull/%d", owner, repo, number)),
+		Head:    &github.PullRequestBranch{Ref: github.String(head)},
+		Base:    &github.PullRequestBranch{Ref: github.String(base)},
+	}
+	d.pulls[fmt.Sprintf("%s#%d", key, number)] = pr
+	return pr
+}
+
+// pull returns a pull request that wasn't opened, or nil for a real one
+func (d *DryRun) pull(owner, repo string, number int) *github.PullRequest {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.pulls[fmt.Sprintf("%s/%s#%d", owner, repo, number)]
+}
+
+// pullDiff returns the diff pushed to a pull request's branch
+func (d *DryRun) pullDiff(owner, repo string, pr *github.PullRequest) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.diffs[owner+"/"+repo+":"+pr.GetHead().GetRef()]
+}
+
+// dryRunHost reads from a code host but reports writes to a dry run instead of making
+// them. Pull requests that were never opened are answered from the dry run.
+type dryRunHost struct {
+	CodeHost
+	dryRun *DryRun
+}
+
+func (h *dryRunHost) DeleteBranch(owner, repo, branch string) error {
+	h.dryRun.Report(owner, repo, 0, "delete branch "+branch, "")
+	return nil
+}
+
+func (h *dryRunHost) AddAssignee(owner, repo string, number int, assignee string) error {
+	h.dryRun.Report(owner, repo, number, "assign "+assignee, "")
+	return nil
+}
+
+func (h *dryRunHost) RemoveAssignee(owner, repo string, number int, assignee string) error {
+	h.dryRun.Report(owner, repo, number, "unassign "+assignee, "")
+	return nil
+}
+
+func (h *dryRunHost) AddLabels(owner, repo string, number int, labels []string) error {
+	h.dryRun.Report(owner, repo, number, "label "+strings.Join(labels, ", "), "")
+	return nil
+}
+
+func (h *dryRunHost) CreateIssue(owner, repo, title, body string) (*github.Issue, error) {
+	issue := h.dryRun.openIssue(owner, repo, title, body)
+	h.dryRun.Report(owner, repo, issue.GetNumber(), "open issue", fmt.Sprintf("# %s\n\n%s", title, body))
+	return issue, nil
+}
+
+func (h *dryRunHost) CloseIssue(owner, repo string, number int) error {
+	h.dryRun.Report(owner, repo, number, "close", "")
+	return nil
+}
+
+func (h *dryRunHost) ListIssueComments(owner, repo string, number int) ([]*github.IssueComment, error) {
+	if h.dryRun.pull(owner, repo, number) != nil {
+		return nil, nil
+	}
+	return h.CodeHost.ListIssueComments(owner, repo, number)
+}
+
+func (h *dryRunHost) CreateIssueComment(owner, repo string, number int, body string) error {
+	h.dryRun.Report(owner, repo, number, "comment", body)
+	return nil
+}
+
+func (h *dryRunHost) CreateIssueCommentWithID(owner, repo string, number int, body string) (int64, error) {
+	h.dryRun.Report(owner, repo, number, "comment", body)
+	return h.dryRun.commentID(), nil
+}
+
+func (h *dryRunHost) EditIssueComment(owner, repo string, number int, commentID int64, body string) error {
+	h.dryRun.Report(owner, repo, number, fmt.Sprintf("edit comment %d", commentID), body)
+	return nil
+}
+
+func (h *dryRunHost) DeleteIssueComment(owner, repo string, number int, commentID int64) error {
+	h.dryRun.Report(owner, repo, number, fmt.Sprintf("delete comment %d", commentID), "")
+	return nil
+}
+
+func (h *dryRunHost) ListIssueCommentReactions(owner, repo string, number int, commentID int64) ([]*github.Reaction, error) {
+	if h.dryRun.isComment(commentID) {
+		return nil, nil
+	}
+	return h.CodeHost.ListIssueCommentReactions(owner, repo, number, commentID)
+}
+
+func (h *dryRunHost) CreatePullRequest(owner, repo, title, body, head, base string, draft bool) (*github.PullRequest, error) {
+	pr := h.dryRun.openPull(owner, repo, title, body, head, base, draft)
+	action := "open pull request"
+	if draft {
+		action = "open draft pull request"
+	}
+	h.dryRun.Report(owner, repo, pr.GetNumber(), action, fmt.Sprintf("# %s\n\n%s → %s\n\n%s", title, head, base, body))
+	return pr, nil
+}
+
+func (h *dryRunHost) ClosePullRequest(owner, repo string, number int) error {
+	h.dryRun.Report(owner, repo, number, "close pull request", "")
+	return nil
+}
+
+func (h *dryRunHost) SetPullRequestMetadata(owner, repo string, number int, metadata PullRequestMetadata) error {
+	h.dryRun.Report(owner, repo, number, "set pull request metadata", fmt.Sprintf("Labels: %s\nAssignees: %s\nReviewers: %s\nTeam reviewers: %s\nMilestone: %s",
+		strings.Join(metadata.Labels, ", "), strings.Join(metadata.Assignees, ", "), strings.Join(metadata.Reviewers, ", "),
+		strings.Join(metadata.TeamReviewers, ", "), metadata.Milestone))
+	return nil
+}
+
+func (h *dryRunHost) MarkPullRequestReady(owner, repo string, pr *github.PullRequest) error {
+	h.dryRun.Report(owner, repo, pr.GetNumber(), "mark ready for review", "")
+	return nil
+}
+
+func (h *dryRunHost) GetPullRequest(owner, repo string, number int) (*github.PullRequest, error) {
+	if pr := h.dryRun.pull(owner, repo, number); pr != nil {
+		return pr, nil
+	}
+	return h.CodeHost.GetPullRequest(owner, repo, number)
+}
+
+func (h *dryRunHost) GetPullRequestDiff(owner, repo string, number int) (string, error) {
+	if pr := h.dryRun.pull(owner, repo, number); pr != nil {
+		return h.dryRun.pullDiff(owner, repo, pr), nil
+	}
+	return h.CodeHost.GetPullRequestDiff(owner, repo, number)
+}
+
+func (h *dryRunHost) CreatePullRequestComment(owner, repo string, number int, body string) error {
+	h.dryRun.Report(owner, repo, number, "pull request comment", body)
+	return nil
+}
+
+func (h *dryRunHost) ListPRComments(owner, repo string, number int) ([]*github.PullRequestComment, error) {
+	if h.dryRun.pull(owner, repo, number) != nil {
+		return nil, nil
+	}
+	return h.CodeHost.ListPRComments(owner, repo, number)
+}
+
+func (h *dryRunHost) ListReviews(owner, repo string, number int) ([]*github.PullRequestReview, error) {
+	if h.dryRun.pull(owner, repo, number) != nil {
+		return nil, nil
+	}
+	return h.CodeHost.ListReviews(owner, repo, number)
+}
+
+func (h *dryRunHost) ListPullRequestFiles(owner, repo string, number int) ([]string, error) {
+	if h.dryRun.pull(owner, repo, number) != nil {
+		return nil, nil
+	}
+	return h.CodeHost.ListPullRequestFiles(owner, repo, number)
+}
+
+func (h *dryRunHost) CreateReview(owner, repo string, pr *github.PullRequest, body string, comments []ReviewComment) error {
+	var b strings.Builder
+	b.WriteString(body)
+	for _, c := range comments {
+		fmt.Fprintf(&b, "\n\n%s:%d\n%s", c.Path, c.Line, c.Body)
+	}
+	h.dryRun.Report(owner, repo, pr.GetNumber(), "review", b.String())
+	return nil
+}
+
+func (h *dryRunHost) CountApprovals(owner, repo string, number int) (int, bool, error) {
+	if h.dryRun.pull(owner, repo, number) != nil {
+		return 0, false, nil
+	}
+	return h.CodeHost.CountApprovals(owner, repo, number)
+}
+
+func (h *dryRunHost) MergePullRequest(owner, repo string, number int, method, sha string) error {
+	h.dryRun.Report(owner, repo, number, "merge", method)
+	return nil
+}
+
+func (h *dryRunHost) EnableAutoMerge(owner, repo string, pr *github.PullRequest, method string) error {
+	h.dryRun.Report(owner, repo, pr.GetNumber(), "enable auto-merge", method)
+	return nil
+}
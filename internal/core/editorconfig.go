@@ -0,0 +1,314 @@
+package core
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FormatSettings are the .editorconfig properties applied to generated files
+type FormatSettings struct {
+	IndentStyle            string // "tab" or "space"
+	IndentSize             int
+	EndOfLine              string // "lf", "crlf" or "cr"
+	InsertFinalNewline     *bool
+	TrimTrailingWhitespace *bool
+}
+
+// editorConfigSection is a glob section of an .editorconfig file
+type editorConfigSection struct {
+	pattern    *regexp.Regexp
+	properties map[string]string
+}
+
+// FormatSettingsFor resolves the .editorconfig settings for a file in the sandbox.
+// Files are read from the file's directory up to the repository root (or a file
+// declaring root = true); closer files take precedence.
+func (s *Sandbox) FormatSettingsFor(relativePath string) FormatSettings {
+	relativePath = filepath.ToSlash(relativePath)
+
+	// Collect config files from the nearest directory upwards
+	var configDirs []string
+	dir := path.Dir(relativePath)
+	for {
+		configDirs = append(configDirs, dir)
+		if dir == "." || dir == "/" {
+			break
+		}
+		dir = path.Dir(dir)
+	}
+
+	properties := make(map[string]string)
+	var chain []string
+	for _, configDir := range configDirs {
+		configPath := filepath.Join(s.repoPath, filepath.FromSlash(configDir), ".editorconfig")
+		if _, err := os.Stat(configPath); err != nil {
+			continue
+		}
+		chain = append(chain, configDir)
+		if isRootEditorConfig(configPath) {
+			break
+		}
+	}
+
+	// Apply from the outermost file inwards so nearer files win
+	for i := len(chain) - 1; i >= 0; i-- {
+		configDir := chain[i]
+		sections, err := parseEditorConfig(filepath.Join(s.repoPath, filepath.FromSlash(configDir), ".editorconfig"))
+		if err != nil {
+			continue
+		}
+
+		target := relativePath
+		if configDir != "." {
+			target = strings.TrimPrefix(relativePath, configDir+"/")
+		}
+		for _, section := range sections {
+			if section.pattern.MatchString(target) {
+				for key, value := range section.properties {
+					properties[key] = value
+				}
+			}
+		}
+	}
+
+	return formatSettingsFromProperties(properties)
+}
+
+// formatSettingsFromProperties converts raw .editorconfig properties into FormatSettings
+func formatSettingsFromProperties(properties map[string]string) FormatSettings {
+	var settings FormatSettings
+
+	switch properties["indent_style"] {
+	case "tab", "space":
+		settings.IndentStyle = properties["indent_style"]
+	}
+
+	size := properties["indent_size"]
+	if size == "tab" {
+		size = properties["tab_width"]
+	}
+	if n, err := strconv.Atoi(size); err == nil && n > 0 {
+		settings.IndentSize = n
+	} else if n, err := strconv.Atoi(properties["tab_width"]); err == nil && n > 0 {
+		settings.IndentSize = n
+	}
+
+	switch properties["end_of_line"] {
+	case "lf", "crlf", "cr":
+		settings.EndOfLine = properties["end_of_line"]
+	}
+
+	settings.InsertFinalNewline = parseEditorConfigBool(properties["insert_final_newline"])
+	settings.TrimTrailingWhitespace = parseEditorConfigBool(properties["trim_trailing_whitespace"])
+
+	return settings
+}
+
+func parseEditorConfigBool(value string) *bool {
+	switch value {
+	case "true":
+		b := true
+		return &b
+	case "false":
+		b := false
+		return &b
+	}
+	return nil
+}
+
+// isRootEditorConfig reports whether an .editorconfig file declares root = true
+func isRootEditorConfig(configPath string) bool {
+	file, err := os.Open(configPath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			return false
+		}
+		if key, value, ok := strings.Cut(line, "="); ok &&
+			strings.EqualFold(strings.TrimSpace(key), "root") &&
+			strings.EqualFold(strings.TrimSpace(value), "true") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseEditorConfig reads the glob sections of an .editorconfig file
+func parseEditorConfig(configPath string) ([]editorConfigSection, error) {
+	file, err := os.Open(configPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var sections []editorConfigSection
+	var current *editorConfigSection
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			pattern, err := editorConfigGlob(line[1 : len(line)-1])
+			if err != nil {
+				current = nil
+				continue
+			}
+			sections = append(sections, editorConfigSection{pattern: pattern, properties: make(map[string]string)})
+			current = &sections[len(sections)-1]
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || current == nil {
+			continue
+		}
+		current.properties[strings.ToLower(strings.TrimSpace(key))] = strings.ToLower(strings.TrimSpace(value))
+	}
+
+	return sections, scanner.Err()
+}
+
+// editorConfigGlob translates an .editorconfig section glob into a regular expression.
+// Globs without a slash match the file name in any directory.
+func editorConfigGlob(glob string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	if strings.Contains(glob, "/") {
+		glob = strings.TrimPrefix(glob, "/")
+		sb.WriteString("^")
+	} else {
+		sb.WriteString("^(?:.*/)?")
+	}
+
+	braceDepth := 0
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				sb.WriteString(".*")
+				i++
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '{':
+			braceDepth++
+			sb.WriteString("(?:")
+		case '}':
+			if braceDepth > 0 {
+				braceDepth--
+				sb.WriteString(")")
+			} else {
+				sb.WriteString(`\}`)
+			}
+		case ',':
+			if braceDepth > 0 {
+				sb.WriteString("|")
+			} else {
+				sb.WriteString(",")
+			}
+		case '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+
+	return regexp.Compile(sb.String())
+}
+
+// ApplyFormatSettings rewrites content to follow the given settings. When no line
+// ending is configured, the line ending of the existing file (if any) is preserved.
+func ApplyFormatSettings(content string, settings FormatSettings, existing string) string {
+	eol := "\n"
+	switch settings.EndOfLine {
+	case "crlf":
+		eol = "\r\n"
+	case "cr":
+		eol = "\r"
+	case "":
+		if strings.Contains(existing, "\r\n") {
+			eol = "\r\n"
+		}
+	}
+
+	normalized := strings.ReplaceAll(content, "\r\n", "\n")
+	normalized = strings.ReplaceAll(normalized, "\r", "\n")
+	hadFinalNewline := strings.HasSuffix(normalized, "\n")
+	lines := strings.Split(strings.TrimSuffix(normalized, "\n"), "\n")
+
+	for i, line := range lines {
+		if settings.TrimTrailingWhitespace != nil && *settings.TrimTrailingWhitespace {
+			line = strings.TrimRight(line, " \t")
+		}
+		lines[i] = reindent(line, settings)
+	}
+
+	result := strings.Join(lines, eol)
+
+	finalNewline := hadFinalNewline
+	if settings.InsertFinalNewline != nil {
+		finalNewline = *settings.InsertFinalNewline
+	}
+	if finalNewline && result != "" {
+		result += eol
+	}
+
+	return result
+}
+
+// reindent converts a line's leading indentation to the configured style
+func reindent(line string, settings FormatSettings) string {
+	size := settings.IndentSize
+	if settings.IndentStyle == "" || size <= 0 {
+		return line
+	}
+
+	body := strings.TrimLeft(line, " \t")
+	indent := line[:len(line)-len(body)]
+	if indent == "" {
+		return line
+	}
+
+	// Measure the indentation width, counting tabs as one indent level
+	width := 0
+	for _, c := range indent {
+		if c == '\t' {
+			width += size
+		} else {
+			width++
+		}
+	}
+
+	if settings.IndentStyle == "tab" {
+		return strings.Repeat("\t", width/size) + strings.Repeat(" ", width%size) + body
+	}
+	return strings.Repeat(" ", width) + body
+}
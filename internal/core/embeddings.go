@@ -0,0 +1,114 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const openRouterEmbeddingsURL = "https://openrouter.ai/api/v1/embeddings"
+
+// defaultEmbeddingModel is used when no embedding model is configured
+const defaultEmbeddingModel = "openai/text-embedding-3-small"
+
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Model string `json:"model"`
+	Data  []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+	Usage openRouterUsage `json:"usage"`
+}
+
+// SetEmbeddingModel configures the model used for embeddings
+func (ca *ClaudeAgent) SetEmbeddingModel(model string) {
+	ca.embeddingModel = model
+}
+
+// Embed returns an embedding vector for each input text
+func (ca *ClaudeAgent) Embed(texts []string) ([][]float64, TokenUsage, error) {
+	embedder, ok := ca.provider.(EmbeddingProvider)
+	if !ok {
+		return nil, TokenUsage{}, fmt.Errorf("provider %s does not support embeddings", ca.provider.Name())
+	}
+	start := time.Now()
+	embeddings, usage, err := embedder.Embed(ca.requestContext(), ca.embeddingModel, texts)
+	if err == nil {
+		ca.estimateCost(&usage, ca.embeddingModel)
+	}
+	ca.recordCall(PurposeEmbedding, ca.embeddingModel, usage, time.Since(start), err)
+	if err == nil {
+		RecordUsage(usage)
+	}
+	return embeddings, usage, err
+}
+
+// Embed implements EmbeddingProvider using OpenRouter's /embeddings endpoint
+func (p *openRouterProvider) Embed(ctx context.Context, model string, texts []string) ([][]float64, TokenUsage, error) {
+	if model == "" {
+		model = defaultEmbeddingModel
+	}
+	jsonData, err := json.Marshal(embeddingRequest{Model: model, Input: texts})
+	if err != nil {
+		return nil, TokenUsage{}, fmt.Errorf("failed to marshal embedding request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", openRouterEmbeddingsURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, TokenUsage{}, fmt.Errorf("failed to create embedding request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, TokenUsage{}, fmt.Errorf("failed to send embedding request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, TokenUsage{}, fmt.Errorf("failed to read embedding response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp openRouterError
+		if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error.Message != "" {
+			return nil, TokenUsage{}, &APIError{Provider: p.Name(), StatusCode: resp.StatusCode, Message: errResp.Error.Message}
+		}
+		return nil, TokenUsage{}, &APIError{Provider: p.Name(), StatusCode: resp.StatusCode, Message: string(body)}
+	}
+
+	var apiResp embeddingResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return nil, TokenUsage{}, fmt.Errorf("failed to parse embedding response: %w", err)
+	}
+	if len(apiResp.Data) != len(texts) {
+		return nil, TokenUsage{}, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(apiResp.Data))
+	}
+
+	embeddings := make([][]float64, len(texts))
+	for _, item := range apiResp.Data {
+		if item.Index < 0 || item.Index >= len(embeddings) {
+			return nil, TokenUsage{}, fmt.Errorf("embedding index %d out of range", item.Index)
+		}
+		embeddings[item.Index] = item.Embedding
+	}
+
+	usage := TokenUsage{
+		InputTokens: apiResp.Usage.PromptTokens,
+		TotalTokens: apiResp.Usage.TotalTokens,
+		Model:       model,
+	}
+
+	return embeddings, usage, nil
+}
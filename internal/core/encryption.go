@@ -0,0 +1,72 @@
+package core
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// encryptedPrefix marks a column value encrypted with the state encryption key
+const encryptedPrefix = "enc:v1:"
+
+// errNoEncryptionKey is returned when reading encrypted state without a key
+var errNoEncryptionKey = errors.New("state is encrypted but STATE_DB_ENCRYPTION_KEY isn't set")
+
+// newStateCipher returns the AES-256-GCM cipher for a base64-encoded 32-byte key
+func newStateCipher(key string) (cipher.AEAD, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: not base64: %w", err)
+	}
+	if len(raw) != 32 {
+		return nil, fmt.Errorf("invalid encryption key: got %d bytes, expected 32", len(raw))
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts a column value when an encryption key is set. Empty values are left as
+// they are.
+func (sm *StateManager) seal(value string) (string, error) {
+	if sm.aead == nil || value == "" {
+		return value, nil
+	}
+	nonce := make([]byte, sm.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := sm.aead.Seal(nonce, nonce, []byte(value), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// unseal decrypts a column value written by seal. Values saved before encryption was
+// turned on are returned as they are, and are encrypted the next time they're saved.
+func (sm *StateManager) unseal(value string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, encryptedPrefix)
+	if !ok {
+		return value, nil
+	}
+	if sm.aead == nil {
+		return "", errNoEncryptionKey
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode encrypted value: %w", err)
+	}
+	nonceSize := sm.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", fmt.Errorf("encrypted value is too short")
+	}
+	plaintext, err := sm.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt (wrong STATE_DB_ENCRYPTION_KEY?): %w", err)
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,244 @@
+package core
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WebhookEvent is a webhook delivery kept in the state database until it has been handled
+type WebhookEvent struct {
+	ID          int64
+	Source      string // "github" or "gitea"
+	Type        string // Event name, e.g. "issue_comment"
+	Payload     []byte
+	Status      string // "pending", "processing", "done" or "failed"
+	Attempts    int
+	Error       string
+	ReceivedAt  time.Time
+	ProcessedAt *time.Time
+}
+
+// EnqueueEvent stores a webhook delivery to be handled by a worker
+func (sm *StateManager) EnqueueEvent(source, eventType string, payload []byte) error {
+	sealed, err := sm.seal(string(payload))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt event payload: %w", err)
+	}
+	_, err = sm.db.Exec(`
+		INSERT INTO webhook_events (source, event_type, payload, status, received_at)
+		VALUES (?, ?, ?, 'pending', ?)
+	`, source, eventType, sealed, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to enqueue event: %w", err)
+	}
+	return nil
+}
+
+const (
+	// MaxEventAttempts is how many times a webhook event is handled before it's left failed
+	MaxEventAttempts = 3
+	// eventClaimLease is how long an event can be processing before another agent sharing
+	// the database takes it to have been interrupted
+	eventClaimLease = time.Hour
+)
+
+// ClaimEvent marks the oldest pending event that isn't waiting to be retried as processing
+// and returns it, or nil when none is pending
+func (sm *StateManager) ClaimEvent() (*WebhookEvent, error) {
+	for {
+		var id int64
+		err := sm.db.QueryRow(`
+			SELECT id FROM webhook_events
+			WHERE status = 'pending' AND (retry_after IS NULL OR retry_after <= ?)
+			ORDER BY id LIMIT 1
+		`, time.Now()).Scan(&id)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find pending event: %w", err)
+		}
+
+		result, err := sm.db.Exec(`
+			UPDATE webhook_events SET status = 'processing', attempts = attempts + 1, claimed_by = ?, claimed_at = ?
+			WHERE id = ? AND status = 'pending'
+		`, sm.instance, time.Now(), id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim event: %w", err)
+		}
+		// Another worker claimed it first
+		if claimed, err := result.RowsAffected(); err == nil && claimed == 0 {
+			continue
+		}
+
+		event, err := sm.GetEvent(id)
+		if err != nil {
+			// e.g. an encrypted payload without the key; it would fail the same way every time
+			sm.FinishEvent(id, err.Error())
+			return nil, err
+		}
+		return event, nil
+	}
+}
+
+// GetEvent returns an event with its payload, or nil if there is none with the ID
+func (sm *StateManager) GetEvent(id int64) (*WebhookEvent, error) {
+	event, err := sm.scanEvent(sm.db.QueryRow(`
+		SELECT id, source, event_type, payload, status, attempts, error, received_at, processed_at
+		FROM webhook_events WHERE id = ?
+	`, id), true)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return event, err
+}
+
+// FinishEvent records that an event was handled, as failed when handlingErr isn't empty
+func (sm *StateManager) FinishEvent(id int64, handlingErr string) error {
+	status := "done"
+	if handlingErr != "" {
+		status = "failed"
+	}
+	_, err := sm.db.Exec(`
+		UPDATE webhook_events SET status = ?, error = ?, processed_at = ?
+		WHERE id = ?
+	`, status, handlingErr, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to finish event: %w", err)
+	}
+	return nil
+}
+
+// RetryEvent makes an event whose handling failed pending again, to be handled once after
+// has passed
+func (sm *StateManager) RetryEvent(id int64, handlingErr string, after time.Time) error {
+	_, err := sm.db.Exec(`
+		UPDATE webhook_events SET status = 'pending', error = ?, retry_after = ?
+		WHERE id = ?
+	`, handlingErr, after, id)
+	if err != nil {
+		return fmt.Errorf("failed to retry event: %w", err)
+	}
+	return nil
+}
+
+// RequeueInterruptedEvents makes events left processing by this agent's last shutdown or
+// crash pending again, so they are replayed, along with those another agent sharing the
+// database has held for longer than the claim lease. Interrupted events that have had all
+// their attempts are marked failed instead. It returns how many were requeued and failed.
+func (sm *StateManager) RequeueInterruptedEvents() (requeued, failed int, err error) {
+	tx, err := sm.db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Events claimed before claims were recorded have no claimant and count as interrupted
+	interrupted := `status = 'processing' AND (claimed_by = ? OR claimed_at IS NULL OR claimed_at < ?)`
+	now := time.Now()
+	stale := now.Add(-eventClaimLease)
+
+	result, err := tx.Exec(`
+		UPDATE webhook_events SET status = 'failed', error = ?, processed_at = ?
+		WHERE `+interrupted+` AND attempts >= ?
+	`, fmt.Sprintf("interrupted on each of %d attempts", MaxEventAttempts), now, sm.instance, stale, MaxEventAttempts)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to fail interrupted events: %w", err)
+	}
+	failedRows, _ := result.RowsAffected()
+
+	result, err = tx.Exec(`
+		UPDATE webhook_events SET status = 'pending'
+		WHERE `+interrupted, sm.instance, stale)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to requeue interrupted events: %w", err)
+	}
+	requeuedRows, _ := result.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to requeue interrupted events: %w", err)
+	}
+	return int(requeuedRows), int(failedRows), nil
+}
+
+// PruneEvents deletes handled events received before cutoff and returns how many there were
+func (sm *StateManager) PruneEvents(cutoff time.Time) (int, error) {
+	result, err := sm.db.Exec(`
+		DELETE FROM webhook_events
+		WHERE status IN ('done', 'failed') AND received_at < ?
+	`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune events: %w", err)
+	}
+	pruned, _ := result.RowsAffected()
+	return int(pruned), nil
+}
+
+// ListEvents returns up to limit events, newest first, optionally only those with a status.
+// Payloads aren't loaded.
+func (sm *StateManager) ListEvents(status string, limit int) ([]WebhookEvent, error) {
+	rows, err := sm.db.Query(`
+		SELECT id, source, event_type, '', status, attempts, error, received_at, processed_at
+		FROM webhook_events
+		WHERE ? = '' OR status = ?
+		ORDER BY id DESC LIMIT ?
+	`, status, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []WebhookEvent
+	for rows.Next() {
+		event, err := sm.scanEvent(rows, false)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *event)
+	}
+	return events, rows.Err()
+}
+
+// CountEvents returns how many events have each status
+func (sm *StateManager) CountEvents() (map[string]int, error) {
+	rows, err := sm.db.Query(`SELECT status, COUNT(*) FROM webhook_events GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count events: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan event count: %w", err)
+		}
+		counts[status] = count
+	}
+	return counts, rows.Err()
+}
+
+// scanEvent reads a webhook_events row, decrypting its payload when withPayload is set
+func (sm *StateManager) scanEvent(row interface{ Scan(...any) error }, withPayload bool) (*WebhookEvent, error) {
+	var event WebhookEvent
+	var payload string
+	var processedAt sql.NullTime
+	if err := row.Scan(&event.ID, &event.Source, &event.Type, &payload, &event.Status, &event.Attempts,
+		&event.Error, &event.ReceivedAt, &processedAt); err != nil {
+		return nil, fmt.Errorf("failed to scan event: %w", err)
+	}
+	if processedAt.Valid {
+		event.ProcessedAt = &processedAt.Time
+	}
+	if withPayload {
+		unsealed, err := sm.unseal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt event payload: %w", err)
+		}
+		event.Payload = []byte(unsealed)
+	}
+	return &event, nil
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// FollowUp is work a pull request leaves for later, opened as an issue of its own
+type FollowUp struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// followUpsSchema returns the JSON schema for follow-up work
+func followUpsSchema() *jsonSchema {
+	return &jsonSchema{
+		Name:   "follow_ups",
+		Strict: true,
+		Schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"follow_ups": map[string]any{
+					"type":        "array",
+					"description": "Work the summary defers or admits is missing (empty if none)",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"title": map[string]any{"type": "string"},
+							"body":  map[string]any{"type": "string"},
+						},
+						"required":             []string{"title", "body"},
+						"additionalProperties": false,
+					},
+				},
+			},
+			"required":             []string{"follow_ups"},
+			"additionalProperties": false,
+		},
+	}
+}
+
+// FindFollowUps asks Claude for the TODOs and known limitations a pull request's summary
+// notes, so they can be tracked as issues rather than lost in the pull request
+func (ca *ClaudeAgent) FindFollowUps(issueTitle, summary string) ([]FollowUp, TokenUsage, error) {
+	systemPrompt := ca.SystemPrompt(PromptFollowUps, PromptData{})
+
+	messages := []AgentMessage{{
+		Role:    "user",
+		Content: fmt.Sprintf("Issue: %s\n\nPull request summary:\n\n%s\n\nList the follow-up work. Respond with the JSON object only.", issueTitle, summary),
+	}}
+	response, usage, err := ca.sendWithSchema(StageAnalysis, messages, systemPrompt, followUpsSchema())
+	if err != nil {
+		return nil, usage, err
+	}
+
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return nil, usage, fmt.Errorf("no JSON object in follow-up response")
+	}
+	var parsed struct {
+		FollowUps []FollowUp `json:"follow_ups"`
+	}
+	if err := json.Unmarshal([]byte(response[start:end+1]), &parsed); err != nil {
+		return nil, usage, fmt.Errorf("failed to parse follow-up response: %w", err)
+	}
+
+	var followUps []FollowUp
+	for _, followUp := range parsed.FollowUps {
+		if strings.TrimSpace(followUp.Title) != "" {
+			followUps = append(followUps, followUp)
+		}
+	}
+	return followUps, usage, nil
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const openRouterGenerationURL = "https://openrouter.ai/api/v1/generation"
+
+// generationLookupDelays are the waits before each /generation lookup attempt;
+// stats are usually available shortly after the completion finishes
+var generationLookupDelays = []time.Duration{500 * time.Millisecond, 1 * time.Second, 2 * time.Second}
+
+// generationStats is the authoritative accounting OpenRouter records for a completion
+type generationStats struct {
+	ID                     string  `json:"id"`
+	Model                  string  `json:"model"`
+	TotalCost              float64 `json:"total_cost"`
+	NativeTokensPrompt     int64   `json:"native_tokens_prompt"`
+	NativeTokensCompletion int64   `json:"native_tokens_completion"`
+	NativeTokensReasoning  int64   `json:"native_tokens_reasoning"`
+}
+
+type generationResponse struct {
+	Data generationStats `json:"data"`
+}
+
+// fetchGenerationStats queries OpenRouter's /generation endpoint for the actual
+// cost and native token counts of a completion, retrying while stats are pending
+func (p *openRouterProvider) fetchGenerationStats(ctx context.Context, generationID string) (*generationStats, error) {
+	endpoint := openRouterGenerationURL + "?id=" + url.QueryEscape(generationID)
+
+	var lastErr error
+	for _, delay := range generationLookupDelays {
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create generation request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+		resp, err := p.httpClient.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to query generation stats: %w", err)
+			continue
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("failed to read generation stats: %w", err)
+			continue
+		}
+
+		// 404 means the stats haven't been recorded yet
+		if resp.StatusCode == http.StatusNotFound {
+			lastErr = fmt.Errorf("generation %s not found yet", generationID)
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, &APIError{Provider: p.Name(), StatusCode: resp.StatusCode, Message: string(body)}
+		}
+
+		var genResp generationResponse
+		if err := json.Unmarshal(body, &genResp); err != nil {
+			return nil, fmt.Errorf("failed to parse generation stats: %w", err)
+		}
+		return &genResp.Data, nil
+	}
+
+	return nil, lastErr
+}
+
+// backfillUsage replaces estimated usage with OpenRouter's recorded generation stats
+func (p *openRouterProvider) backfillUsage(ctx context.Context, generationID string, usage *TokenUsage) error {
+	stats, err := p.fetchGenerationStats(ctx, generationID)
+	if err != nil {
+		return err
+	}
+
+	usage.Cost = stats.TotalCost
+	if stats.NativeTokensPrompt > 0 || stats.NativeTokensCompletion > 0 {
+		usage.InputTokens = stats.NativeTokensPrompt
+		usage.OutputTokens = stats.NativeTokensCompletion
+		usage.TotalTokens = stats.NativeTokensPrompt + stats.NativeTokensCompletion
+	}
+	if stats.NativeTokensReasoning > 0 {
+		usage.ReasoningTokens = stats.NativeTokensReasoning
+	}
+	if stats.Model != "" {
+		usage.Model = stats.Model
+	}
+
+	return nil
+}
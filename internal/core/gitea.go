@@ -0,0 +1,966 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v63/github"
+)
+
+// giteaPageSize is the page size for list requests (Gitea's default maximum)
+const giteaPageSize = 50
+
+// giteaDraftPrefix marks a pull request as a work in progress (Gitea's default prefix)
+const giteaDraftPrefix = "WIP: "
+
+// GiteaClient talks to a Gitea or Forgejo instance's REST API and converts its
+// responses into go-github types, so the workflows can treat it like GitHub
+type GiteaClient struct {
+	baseURL string // Instance URL without a trailing slash, e.g. "https://git.example.com"
+	token   string
+	ctx     context.Context
+	http    *http.Client
+}
+
+// NewGiteaClient creates a client for the Gitea or Forgejo instance at baseURL
+func NewGiteaClient(baseURL, token string) *GiteaClient {
+	return &GiteaClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		ctx:     context.Background(),
+		http:    &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// giteaError is a failed Gitea API response
+type giteaError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *giteaError) Error() string {
+	return fmt.Sprintf("Gitea API error (status %d): %s", e.StatusCode, e.Message)
+}
+
+// isGiteaNotFound reports whether err is a Gitea 404
+func isGiteaNotFound(err error) bool {
+	var gtErr *giteaError
+	return errors.As(err, &gtErr) && gtErr.StatusCode == http.StatusNotFound
+}
+
+// Gitea API response types (only the fields the agent uses). They mirror GitHub's
+// closely but not exactly, so they are decoded separately and converted.
+type giteaUser struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+}
+
+type giteaIssue struct {
+	Number    int         `json:"number"`
+	Title     string      `json:"title"`
+	Body      string      `json:"body"`
+	State     string      `json:"state"`
+	HTMLURL   string      `json:"html_url"`
+	User      giteaUser   `json:"user"`
+	Assignees []giteaUser `json:"assignees"`
+	Labels    []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	PullRequest *struct{} `json:"pull_request"` // Set when the issue is a pull request
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type giteaComment struct {
+	ID        int64     `json:"id"`
+	Body      string    `json:"body"`
+	User      giteaUser `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type giteaBranch struct {
+	Ref string `json:"ref"`
+	SHA string `json:"sha"`
+}
+
+type giteaPullRequest struct {
+	Number    int         `json:"number"`
+	Title     string      `json:"title"`
+	Body      string      `json:"body"`
+	State     string      `json:"state"`
+	HTMLURL   string      `json:"html_url"`
+	Merged    bool        `json:"merged"`
+	Draft     bool        `json:"draft"`
+	Mergeable bool        `json:"mergeable"`
+	User      giteaUser   `json:"user"`
+	Reviewers []giteaUser `json:"requested_reviewers"`
+	Head      giteaBranch `json:"head"`
+	Base      giteaBranch `json:"base"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+type giteaReview struct {
+	ID          int64     `json:"id"`
+	Body        string    `json:"body"`
+	State       string    `json:"state"`
+	Dismissed   bool      `json:"dismissed"`
+	User        giteaUser `json:"user"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+func (u giteaUser) toGitHub() *github.User {
+	return &github.User{ID: github.Int64(u.ID), Login: github.String(u.Login)}
+}
+
+func (i giteaIssue) toGitHub() *github.Issue {
+	issue := &github.Issue{
+		Number:    github.Int(i.Number),
+		Title:     github.String(i.Title),
+		Body:      github.String(i.Body),
+		State:     github.String(i.State),
+		HTMLURL:   github.String(i.HTMLURL),
+		User:      i.User.toGitHub(),
+		CreatedAt: &github.Timestamp{Time: i.CreatedAt},
+		UpdatedAt: &github.Timestamp{Time: i.UpdatedAt},
+	}
+	for _, assignee := range i.Assignees {
+		issue.Assignees = append(issue.Assignees, assignee.toGitHub())
+	}
+	for _, label := range i.Labels {
+		issue.Labels = append(issue.Labels, &github.Label{Name: github.String(label.Name)})
+	}
+	if i.PullRequest != nil {
+		issue.PullRequestLinks = &github.PullRequestLinks{}
+	}
+	return issue
+}
+
+func (c giteaComment) toGitHub() *github.IssueComment {
+	return &github.IssueComment{
+		ID:        github.Int64(c.ID),
+		Body:      github.String(c.Body),
+		User:      c.User.toGitHub(),
+		CreatedAt: &github.Timestamp{Time: c.CreatedAt},
+		UpdatedAt: &github.Timestamp{Time: c.UpdatedAt},
+	}
+}
+
+func (r giteaReview) toGitHub() *github.PullRequestReview {
+	// Gitea names two of its states differently
+	state := r.State
+	switch {
+	case r.Dismissed:
+		state = "DISMISSED"
+	case state == "REQUEST_CHANGES":
+		state = "CHANGES_REQUESTED"
+	case state == "COMMENT":
+		state = "COMMENTED"
+	}
+	return &github.PullRequestReview{
+		ID:          github.Int64(r.ID),
+		Body:        github.String(r.Body),
+		State:       github.String(state),
+		User:        r.User.toGitHub(),
+		SubmittedAt: &github.Timestamp{Time: r.SubmittedAt},
+	}
+}
+
+func (pr giteaPullRequest) toGitHub() *github.PullRequest {
+	converted := &github.PullRequest{
+		Number:    github.Int(pr.Number),
+		Title:     github.String(pr.Title),
+		Body:      github.String(pr.Body),
+		State:     github.String(pr.State),
+		Merged:    github.Bool(pr.Merged),
+		Draft:     github.Bool(pr.Draft),
+		Mergeable: github.Bool(pr.Mergeable),
+		HTMLURL:   github.String(pr.HTMLURL),
+		User:      pr.User.toGitHub(),
+		Head:      &github.PullRequestBranch{Ref: github.String(pr.Head.Ref), SHA: github.String(pr.Head.SHA)},
+		Base:      &github.PullRequestBranch{Ref: github.String(pr.Base.Ref), SHA: github.String(pr.Base.SHA)},
+		CreatedAt: &github.Timestamp{Time: pr.CreatedAt},
+		UpdatedAt: &github.Timestamp{Time: pr.UpdatedAt},
+	}
+	for _, reviewer := range pr.Reviewers {
+		converted.RequestedReviewers = append(converted.RequestedReviewers, reviewer.toGitHub())
+	}
+	return converted
+}
+
+// giteaRepoPath returns the API path of a repository
+func giteaRepoPath(owner, repo string) string {
+	return "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+}
+
+// giteaIssuePath returns the API path of an issue or pull request
+func giteaIssuePath(owner, repo string, number int) string {
+	return giteaRepoPath(owner, repo) + "/issues/" + strconv.Itoa(number)
+}
+
+// giteaCommentPath returns the API path of an issue comment
+func giteaCommentPath(owner, repo string, commentID int64) string {
+	return giteaRepoPath(owner, repo) + "/issues/comments/" + strconv.FormatInt(commentID, 10)
+}
+
+// escapePath escapes each segment of a slash-separated path, such as a file path or a branch name
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// do sends an API request, encoding body as JSON and decoding the response into out (either may be nil)
+func (gt *GiteaClient) do(method, path string, query url.Values, body, out any) error {
+	endpoint := gt.baseURL + "/api/v1" + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(gt.ctx, method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "token "+gt.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := gt.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &giteaError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+	}
+	if text, ok := out.(*string); ok {
+		data, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+		if err != nil {
+			return fmt.Errorf("failed to read response: %w", err)
+		}
+		*text = string(data)
+		return nil
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// giteaListAll fetches every page of a list endpoint
+func giteaListAll[T any](gt *GiteaClient, path string, query url.Values) ([]T, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("limit", strconv.Itoa(giteaPageSize))
+
+	var all []T
+	for page := 1; ; page++ {
+		query.Set("page", strconv.Itoa(page))
+		var items []T
+		if err := gt.do(http.MethodGet, path, query, nil, &items); err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if len(items) < giteaPageSize {
+			return all, nil
+		}
+	}
+}
+
+// CloneURL returns an HTTPS clone URL authenticated with the access token
+func (gt *GiteaClient) CloneURL(owner, repo string) string {
+	u, err := url.Parse(gt.baseURL)
+	if err != nil {
+		return fmt.Sprintf("%s/%s/%s.git", gt.baseURL, owner, repo)
+	}
+	u.User = url.User(gt.token)
+	u.Path = strings.TrimRight(u.Path, "/") + "/" + owner + "/" + repo + ".git"
+	return u.String()
+}
+
+// IssueURL returns the web page of an issue
+func (gt *GiteaClient) IssueURL(owner, repo string, number int) string {
+	return fmt.Sprintf("%s/%s/%s/issues/%d", gt.baseURL, owner, repo, number)
+}
+
+// PullRequestURL returns the web page of a pull request
+func (gt *GiteaClient) PullRequestURL(owner, repo string, number int) string {
+	return fmt.Sprintf("%s/%s/%s/pulls/%d", gt.baseURL, owner, repo, number)
+}
+
+// GetAuthenticatedUser retrieves the user the access token belongs to
+func (gt *GiteaClient) GetAuthenticatedUser() (*github.User, error) {
+	var user giteaUser
+	if err := gt.do(http.MethodGet, "/user", nil, nil, &user); err != nil {
+		return nil, fmt.Errorf("failed to get authenticated user: %w", err)
+	}
+	return user.toGitHub(), nil
+}
+
+// GetPermissionLevel returns a user's permission on a repository ("admin", "write", "read" or "none")
+func (gt *GiteaClient) GetPermissionLevel(owner, repo, user string) (string, error) {
+	var result struct {
+		Permission string `json:"permission"`
+	}
+	err := gt.do(http.MethodGet, giteaRepoPath(owner, repo)+"/collaborators/"+url.PathEscape(user)+"/permission", nil, nil, &result)
+	if isGiteaNotFound(err) {
+		return "none", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get permission level: %w", err)
+	}
+
+	// Gitea reports repository owners separately; GitHub calls them admins
+	if result.Permission == "owner" {
+		return "admin", nil
+	}
+	return result.Permission, nil
+}
+
+// GetRepository retrieves repository information, with its most used language
+func (gt *GiteaClient) GetRepository(owner, repo string) (*github.Repository, error) {
+	var repository struct {
+		Name          string `json:"name"`
+		FullName      string `json:"full_name"`
+		DefaultBranch string `json:"default_branch"`
+		HTMLURL       string `json:"html_url"`
+	}
+	if err := gt.do(http.MethodGet, giteaRepoPath(owner, repo), nil, nil, &repository); err != nil {
+		return nil, fmt.Errorf("failed to get repository: %w", err)
+	}
+
+	result := &github.Repository{
+		Name:          github.String(repository.Name),
+		FullName:      github.String(repository.FullName),
+		DefaultBranch: github.String(repository.DefaultBranch),
+		HTMLURL:       github.String(repository.HTMLURL),
+	}
+
+	var languages map[string]int64
+	if err := gt.do(http.MethodGet, giteaRepoPath(owner, repo)+"/languages", nil, nil, &languages); err == nil {
+		var top int64
+		for language, size := range languages {
+			if size > top {
+				top = size
+				result.Language = github.String(language)
+			}
+		}
+	}
+	return result, nil
+}
+
+// GetFileContent retrieves the content of a file; an empty ref means the default branch
+func (gt *GiteaClient) GetFileContent(owner, repo, path, ref string) (string, error) {
+	var query url.Values
+	if ref != "" {
+		query = url.Values{"ref": {ref}}
+	}
+
+	var file struct {
+		Content string `json:"content"`
+	}
+	if err := gt.do(http.MethodGet, giteaRepoPath(owner, repo)+"/contents/"+escapePath(path), query, nil, &file); err != nil {
+		return "", fmt.Errorf("failed to get file content: %w", err)
+	}
+
+	content, err := base64.StdEncoding.DecodeString(file.Content)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode file content: %w", err)
+	}
+	return string(content), nil
+}
+
+// DeleteBranch deletes a branch from the repository
+func (gt *GiteaClient) DeleteBranch(owner, repo, branch string) error {
+	if err := gt.do(http.MethodDelete, giteaRepoPath(owner, repo)+"/branches/"+escapePath(branch), nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete branch: %w", err)
+	}
+	return nil
+}
+
+// DownloadImage downloads an image attachment and returns it as a data URL. The token
+// is only sent to the Gitea instance itself.
+func (gt *GiteaClient) DownloadImage(imageURL string) (ImageAttachment, error) {
+	return fetchImage(gt.ctx, gt.http, imageURL, func(req *http.Request) {
+		if base, err := url.Parse(gt.baseURL); err == nil && req.URL.Host == base.Host {
+			req.Header.Set("Authorization", "token "+gt.token)
+		}
+	})
+}
+
+// GetIssue retrieves an issue
+func (gt *GiteaClient) GetIssue(owner, repo string, number int) (*github.Issue, error) {
+	var issue giteaIssue
+	if err := gt.do(http.MethodGet, giteaIssuePath(owner, repo, number), nil, nil, &issue); err != nil {
+		return nil, fmt.Errorf("failed to get issue: %w", err)
+	}
+	return issue.toGitHub(), nil
+}
+
+// listIssues retrieves open issues (not pull requests) matching query
+func (gt *GiteaClient) listIssues(owner, repo string, query url.Values) ([]*github.Issue, error) {
+	query.Set("state", "open")
+	if !query.Has("type") {
+		query.Set("type", "issues")
+	}
+	issues, err := giteaListAll[giteaIssue](gt, giteaRepoPath(owner, repo)+"/issues", query)
+	if err != nil {
+		return nil, err
+	}
+
+	converted := make([]*github.Issue, 0, len(issues))
+	for _, issue := range issues {
+		converted = append(converted, issue.toGitHub())
+	}
+	return converted, nil
+}
+
+// ListRepositoryIssues retrieves open issues assigned to a user
+func (gt *GiteaClient) ListRepositoryIssues(owner, repo, assignee string) ([]*github.Issue, error) {
+	issues, err := gt.listIssues(owner, repo, url.Values{"assigned_by": {assignee}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repository issues: %w", err)
+	}
+	return issues, nil
+}
+
+// ListOpenIssues retrieves a repository's open issues
+func (gt *GiteaClient) ListOpenIssues(owner, repo string) ([]*github.Issue, error) {
+	issues, err := gt.listIssues(owner, repo, url.Values{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open issues: %w", err)
+	}
+	return issues, nil
+}
+
+// ListLabels returns the names of a repository's labels
+func (gt *GiteaClient) ListLabels(owner, repo string) ([]string, error) {
+	labels, err := giteaListAll[struct {
+		Name string `json:"name"`
+	}](gt, giteaRepoPath(owner, repo)+"/labels", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list labels: %w", err)
+	}
+	names := make([]string, 0, len(labels))
+	for _, label := range labels {
+		names = append(names, label.Name)
+	}
+	return names, nil
+}
+
+// ListLabeledIssues retrieves open issues that carry a label
+func (gt *GiteaClient) ListLabeledIssues(owner, repo, label string) ([]*github.Issue, error) {
+	issues, err := gt.listIssues(owner, repo, url.Values{"labels": {label}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list labeled issues: %w", err)
+	}
+	return issues, nil
+}
+
+// ListMentioningIssues returns open issues and pull requests mentioning user that were
+// updated after since
+func (gt *GiteaClient) ListMentioningIssues(owner, repo, user string, since time.Time) ([]*github.Issue, error) {
+	issues, err := gt.listIssues(owner, repo, url.Values{
+		"type":         {""}, // Both issues and pull requests
+		"mentioned_by": {user},
+		"since":        {since.UTC().Format(time.RFC3339)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mentioning issues: %w", err)
+	}
+	return issues, nil
+}
+
+// setAssignees replaces an issue's assignees with the result of update
+func (gt *GiteaClient) setAssignees(owner, repo string, number int, update func(logins []string) []string) error {
+	var issue giteaIssue
+	if err := gt.do(http.MethodGet, giteaIssuePath(owner, repo, number), nil, nil, &issue); err != nil {
+		return err
+	}
+	logins := make([]string, 0, len(issue.Assignees))
+	for _, assignee := range issue.Assignees {
+		logins = append(logins, assignee.Login)
+	}
+	return gt.do(http.MethodPatch, giteaIssuePath(owner, repo, number), nil, map[string]any{"assignees": update(logins)}, nil)
+}
+
+// AddAssignee assigns a user to an issue
+func (gt *GiteaClient) AddAssignee(owner, repo string, number int, assignee string) error {
+	err := gt.setAssignees(owner, repo, number, func(logins []string) []string {
+		for _, login := range logins {
+			if login == assignee {
+				return logins
+			}
+		}
+		return append(logins, assignee)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add assignee: %w", err)
+	}
+	return nil
+}
+
+// RemoveAssignee removes a user from an issue's assignees
+func (gt *GiteaClient) RemoveAssignee(owner, repo string, number int, assignee string) error {
+	err := gt.setAssignees(owner, repo, number, func(logins []string) []string {
+		kept := []string{}
+		for _, login := range logins {
+			if login != assignee {
+				kept = append(kept, login)
+			}
+		}
+		return kept
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove assignee: %w", err)
+	}
+	return nil
+}
+
+// AddLabels adds labels to an issue. Gitea only adds labels the repository already has.
+func (gt *GiteaClient) AddLabels(owner, repo string, number int, labels []string) error {
+	if err := gt.addLabels(owner, repo, number, labels); err != nil {
+		return fmt.Errorf("failed to add labels: %w", err)
+	}
+	return nil
+}
+
+// CreateIssue opens an issue
+func (gt *GiteaClient) CreateIssue(owner, repo, title, body string) (*github.Issue, error) {
+	var issue giteaIssue
+	if err := gt.do(http.MethodPost, giteaRepoPath(owner, repo)+"/issues", nil, map[string]string{"title": title, "body": body}, &issue); err != nil {
+		return nil, fmt.Errorf("failed to create issue: %w", err)
+	}
+	return issue.toGitHub(), nil
+}
+
+// CloseIssue closes an issue
+func (gt *GiteaClient) CloseIssue(owner, repo string, number int) error {
+	if err := gt.do(http.MethodPatch, giteaIssuePath(owner, repo, number), nil, map[string]string{"state": "closed"}, nil); err != nil {
+		return fmt.Errorf("failed to close issue: %w", err)
+	}
+	return nil
+}
+
+// ListIssueComments retrieves all comments for an issue
+func (gt *GiteaClient) ListIssueComments(owner, repo string, number int) ([]*github.IssueComment, error) {
+	var comments []giteaComment
+	if err := gt.do(http.MethodGet, giteaIssuePath(owner, repo, number)+"/comments", nil, nil, &comments); err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+	converted := make([]*github.IssueComment, 0, len(comments))
+	for _, comment := range comments {
+		converted = append(converted, comment.toGitHub())
+	}
+	return converted, nil
+}
+
+// CreateIssueComment adds a comment to an issue
+func (gt *GiteaClient) CreateIssueComment(owner, repo string, number int, body string) error {
+	_, err := gt.CreateIssueCommentWithID(owner, repo, number, body)
+	return err
+}
+
+// CreateIssueCommentWithID adds a comment to an issue and returns its ID so it can be edited later
+func (gt *GiteaClient) CreateIssueCommentWithID(owner, repo string, number int, body string) (int64, error) {
+	var comment giteaComment
+	if err := gt.do(http.MethodPost, giteaIssuePath(owner, repo, number)+"/comments", nil, map[string]string{"body": body}, &comment); err != nil {
+		return 0, fmt.Errorf("failed to create comment: %w", err)
+	}
+	return comment.ID, nil
+}
+
+// EditIssueComment replaces the body of an existing issue comment
+func (gt *GiteaClient) EditIssueComment(owner, repo string, number int, commentID int64, body string) error {
+	if err := gt.do(http.MethodPatch, giteaCommentPath(owner, repo, commentID), nil, map[string]string{"body": body}, nil); err != nil {
+		return fmt.Errorf("failed to edit comment: %w", err)
+	}
+	return nil
+}
+
+// DeleteIssueComment removes an issue comment
+func (gt *GiteaClient) DeleteIssueComment(owner, repo string, number int, commentID int64) error {
+	if err := gt.do(http.MethodDelete, giteaCommentPath(owner, repo, commentID), nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+	return nil
+}
+
+// ListIssueCommentReactions retrieves the reactions on an issue comment
+func (gt *GiteaClient) ListIssueCommentReactions(owner, repo string, number int, commentID int64) ([]*github.Reaction, error) {
+	var reactions []struct {
+		Content string    `json:"content"`
+		User    giteaUser `json:"user"`
+	}
+	if err := gt.do(http.MethodGet, giteaCommentPath(owner, repo, commentID)+"/reactions", nil, nil, &reactions); err != nil {
+		return nil, fmt.Errorf("failed to list reactions: %w", err)
+	}
+
+	converted := make([]*github.Reaction, 0, len(reactions))
+	for _, reaction := range reactions {
+		converted = append(converted, &github.Reaction{Content: github.String(reaction.Content), User: reaction.User.toGitHub()})
+	}
+	return converted, nil
+}
+
+// CreatePullRequest creates a new pull request
+func (gt *GiteaClient) CreatePullRequest(owner, repo, title, body, head, base string, draft bool) (*github.PullRequest, error) {
+	if draft {
+		title = giteaDraftPrefix + title
+	}
+	var pr giteaPullRequest
+	err := gt.do(http.MethodPost, giteaRepoPath(owner, repo)+"/pulls", nil, map[string]string{
+		"title": title,
+		"body":  body,
+		"head":  head,
+		"base":  base,
+	}, &pr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pull request: %w", err)
+	}
+	return pr.toGitHub(), nil
+}
+
+// MarkPullRequestReady takes a pull request out of draft by removing the work-in-progress
+// prefix from its title
+func (gt *GiteaClient) MarkPullRequestReady(owner, repo string, pr *github.PullRequest) error {
+	title := strings.TrimPrefix(pr.GetTitle(), giteaDraftPrefix)
+	if err := gt.do(http.MethodPatch, giteaRepoPath(owner, repo)+"/pulls/"+strconv.Itoa(pr.GetNumber()), nil, map[string]string{"title": title}, nil); err != nil {
+		return fmt.Errorf("failed to mark pull request ready: %w", err)
+	}
+	return nil
+}
+
+// ClosePullRequest closes a pull request without merging it
+func (gt *GiteaClient) ClosePullRequest(owner, repo string, number int) error {
+	if err := gt.do(http.MethodPatch, giteaRepoPath(owner, repo)+"/pulls/"+strconv.Itoa(number), nil, map[string]string{"state": "closed"}, nil); err != nil {
+		return fmt.Errorf("failed to close pull request: %w", err)
+	}
+	return nil
+}
+
+// SetPullRequestMetadata adds labels, assignees and requested reviewers to a pull request
+// and sets its milestone
+func (gt *GiteaClient) SetPullRequestMetadata(owner, repo string, number int, metadata PullRequestMetadata) error {
+	var errs []error
+	if len(metadata.Labels) > 0 {
+		if err := gt.addLabels(owner, repo, number, metadata.Labels); err != nil {
+			errs = append(errs, fmt.Errorf("failed to add labels: %w", err))
+		}
+	}
+	if len(metadata.Assignees) > 0 {
+		err := gt.setAssignees(owner, repo, number, func(logins []string) []string {
+			for _, assignee := range metadata.Assignees {
+				if !slices.Contains(logins, assignee) {
+					logins = append(logins, assignee)
+				}
+			}
+			return logins
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to add assignees: %w", err))
+		}
+	}
+	if len(metadata.Reviewers) > 0 || len(metadata.TeamReviewers) > 0 {
+		body := map[string][]string{"reviewers": metadata.Reviewers, "team_reviewers": metadata.TeamReviewers}
+		if err := gt.do(http.MethodPost, giteaRepoPath(owner, repo)+"/pulls/"+strconv.Itoa(number)+"/requested_reviewers", nil, body, nil); err != nil {
+			errs = append(errs, fmt.Errorf("failed to request reviewers: %w", err))
+		}
+	}
+	if metadata.Milestone != "" {
+		if err := gt.setMilestone(owner, repo, number, metadata.Milestone); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// addLabels adds labels to an issue or pull request by name
+func (gt *GiteaClient) addLabels(owner, repo string, number int, names []string) error {
+	labels, err := giteaListAll[struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}](gt, giteaRepoPath(owner, repo)+"/labels", nil)
+	if err != nil {
+		return err
+	}
+	var ids []int64
+	for _, name := range names {
+		found := false
+		for _, label := range labels {
+			if label.Name == name {
+				ids = append(ids, label.ID)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("label not found: %s", name)
+		}
+	}
+	return gt.do(http.MethodPost, giteaIssuePath(owner, repo, number)+"/labels", nil, map[string][]int64{"labels": ids}, nil)
+}
+
+// setMilestone sets an issue or pull request's milestone by title
+func (gt *GiteaClient) setMilestone(owner, repo string, number int, title string) error {
+	milestones, err := giteaListAll[struct {
+		ID    int64  `json:"id"`
+		Title string `json:"title"`
+	}](gt, giteaRepoPath(owner, repo)+"/milestones", url.Values{"name": {title}, "state": {"open"}})
+	if err != nil {
+		return fmt.Errorf("failed to list milestones: %w", err)
+	}
+	for _, milestone := range milestones {
+		if milestone.Title == title {
+			if err := gt.do(http.MethodPatch, giteaIssuePath(owner, repo, number), nil, map[string]int64{"milestone": milestone.ID}, nil); err != nil {
+				return fmt.Errorf("failed to set milestone: %w", err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("milestone not found: %s", title)
+}
+
+// GetPullRequest retrieves a pull request
+func (gt *GiteaClient) GetPullRequest(owner, repo string, number int) (*github.PullRequest, error) {
+	var pr giteaPullRequest
+	if err := gt.do(http.MethodGet, giteaRepoPath(owner, repo)+"/pulls/"+strconv.Itoa(number), nil, nil, &pr); err != nil {
+		return nil, fmt.Errorf("failed to get pull request: %w", err)
+	}
+	return pr.toGitHub(), nil
+}
+
+// ListOpenPullRequests retrieves a repository's open pull requests
+func (gt *GiteaClient) ListOpenPullRequests(owner, repo string) ([]*github.PullRequest, error) {
+	prs, err := giteaListAll[giteaPullRequest](gt, giteaRepoPath(owner, repo)+"/pulls", url.Values{"state": {"open"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+	converted := make([]*github.PullRequest, 0, len(prs))
+	for _, pr := range prs {
+		converted = append(converted, pr.toGitHub())
+	}
+	return converted, nil
+}
+
+// GetPullRequestDiff retrieves a pull request's unified diff
+func (gt *GiteaClient) GetPullRequestDiff(owner, repo string, number int) (string, error) {
+	var diff string
+	if err := gt.do(http.MethodGet, giteaRepoPath(owner, repo)+"/pulls/"+strconv.Itoa(number)+".diff", nil, nil, &diff); err != nil {
+		return "", fmt.Errorf("failed to get pull request diff: %w", err)
+	}
+	return diff, nil
+}
+
+// CreateReview submits a comment-only review on the pull request's head commit
+func (gt *GiteaClient) CreateReview(owner, repo string, pr *github.PullRequest, body string, comments []ReviewComment) error {
+	type reviewComment struct {
+		Path        string `json:"path"`
+		Body        string `json:"body"`
+		NewPosition int    `json:"new_position"`
+	}
+	review := struct {
+		Body     string          `json:"body"`
+		Event    string          `json:"event"`
+		CommitID string          `json:"commit_id"`
+		Comments []reviewComment `json:"comments"`
+	}{Body: body, Event: "COMMENT", CommitID: pr.GetHead().GetSHA(), Comments: []reviewComment{}}
+	for _, comment := range comments {
+		review.Comments = append(review.Comments, reviewComment{Path: comment.Path, Body: comment.Body, NewPosition: comment.Line})
+	}
+
+	if err := gt.do(http.MethodPost, giteaRepoPath(owner, repo)+"/pulls/"+strconv.Itoa(pr.GetNumber())+"/reviews", nil, review, nil); err != nil {
+		return fmt.Errorf("failed to create review: %w", err)
+	}
+	return nil
+}
+
+// CreatePullRequestComment adds a comment to a pull request's conversation. Issues and
+// pull requests share numbers on Gitea, as on GitHub.
+func (gt *GiteaClient) CreatePullRequestComment(owner, repo string, number int, body string) error {
+	return gt.CreateIssueComment(owner, repo, number, body)
+}
+
+// ListPRComments retrieves the conversation comments on a pull request
+func (gt *GiteaClient) ListPRComments(owner, repo string, number int) ([]*github.PullRequestComment, error) {
+	var comments []giteaComment
+	if err := gt.do(http.MethodGet, giteaIssuePath(owner, repo, number)+"/comments", nil, nil, &comments); err != nil {
+		return nil, fmt.Errorf("failed to list PR comments: %w", err)
+	}
+	converted := make([]*github.PullRequestComment, 0, len(comments))
+	for _, comment := range comments {
+		converted = append(converted, &github.PullRequestComment{
+			ID:        github.Int64(comment.ID),
+			Body:      github.String(comment.Body),
+			User:      comment.User.toGitHub(),
+			CreatedAt: &github.Timestamp{Time: comment.CreatedAt},
+			UpdatedAt: &github.Timestamp{Time: comment.UpdatedAt},
+		})
+	}
+	return converted, nil
+}
+
+// ListPullRequestFiles returns the paths of the files changed by a pull request
+func (gt *GiteaClient) ListPullRequestFiles(owner, repo string, number int) ([]string, error) {
+	type changedFile struct {
+		Filename         string `json:"filename"`
+		PreviousFilename string `json:"previous_filename"`
+	}
+	files, err := giteaListAll[changedFile](gt, giteaRepoPath(owner, repo)+"/pulls/"+strconv.Itoa(number)+"/files", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PR files: %w", err)
+	}
+
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, file.Filename)
+		if file.PreviousFilename != "" {
+			paths = append(paths, file.PreviousFilename)
+		}
+	}
+	return paths, nil
+}
+
+// ListReviews retrieves the reviews submitted on a pull request
+func (gt *GiteaClient) ListReviews(owner, repo string, number int) ([]*github.PullRequestReview, error) {
+	reviews, err := giteaListAll[giteaReview](gt, giteaRepoPath(owner, repo)+"/pulls/"+strconv.Itoa(number)+"/reviews", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reviews: %w", err)
+	}
+	converted := make([]*github.PullRequestReview, 0, len(reviews))
+	for _, review := range reviews {
+		converted = append(converted, review.toGitHub())
+	}
+	return converted, nil
+}
+
+// CountApprovals returns how many reviewers currently approve a pull request and
+// whether any reviewer's latest review requests changes
+func (gt *GiteaClient) CountApprovals(owner, repo string, number int) (approvals int, changesRequested bool, err error) {
+	reviews, err := gt.ListReviews(owner, repo, number)
+	if err != nil {
+		return 0, false, err
+	}
+
+	// Only each reviewer's latest approving or blocking review counts
+	latest := make(map[string]string)
+	for _, review := range reviews {
+		switch state := review.GetState(); state {
+		case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+			latest[review.GetUser().GetLogin()] = state
+		}
+	}
+
+	for _, state := range latest {
+		switch state {
+		case "APPROVED":
+			approvals++
+		case "CHANGES_REQUESTED":
+			changesRequested = true
+		}
+	}
+	return approvals, changesRequested, nil
+}
+
+// ChecksPassed reports whether the combined commit status of ref (which includes
+// Gitea Actions) succeeded. pending is true while any check is still running.
+func (gt *GiteaClient) ChecksPassed(owner, repo, ref string) (passed, pending bool, err error) {
+	var status struct {
+		State      string `json:"state"`
+		TotalCount int    `json:"total_count"`
+	}
+	if err := gt.do(http.MethodGet, giteaRepoPath(owner, repo)+"/commits/"+escapePath(ref)+"/status", nil, nil, &status); err != nil {
+		return false, false, fmt.Errorf("failed to get commit status: %w", err)
+	}
+	if status.TotalCount == 0 {
+		return true, false, nil
+	}
+
+	switch status.State {
+	case "success", "warning":
+		return true, false, nil
+	case "pending":
+		return false, true, nil
+	}
+	return false, false, nil
+}
+
+// ListFailedChecks returns the failed commit statuses on ref. Gitea's API doesn't serve
+// Actions logs, so each check carries its description and a link to the run.
+func (gt *GiteaClient) ListFailedChecks(owner, repo, ref string) ([]FailedCheck, error) {
+	var status struct {
+		Statuses []struct {
+			Context     string `json:"context"`
+			Status      string `json:"status"`
+			Description string `json:"description"`
+			TargetURL   string `json:"target_url"`
+		} `json:"statuses"`
+	}
+	if err := gt.do(http.MethodGet, giteaRepoPath(owner, repo)+"/commits/"+escapePath(ref)+"/status", nil, nil, &status); err != nil {
+		return nil, fmt.Errorf("failed to get commit status: %w", err)
+	}
+
+	var failed []FailedCheck
+	for _, st := range status.Statuses {
+		if st.Status == "failure" || st.Status == "error" {
+			failed = append(failed, FailedCheck{Name: st.Context, Log: strings.TrimSpace(st.Description + "\n" + st.TargetURL)})
+		}
+	}
+	return failed, nil
+}
+
+// merge merges a pull request, or schedules it to merge once checks pass
+func (gt *GiteaClient) merge(owner, repo string, number int, method, sha string, whenChecksSucceed bool) error {
+	body := map[string]any{
+		"Do":                        method,
+		"head_commit_id":            sha,
+		"merge_when_checks_succeed": whenChecksSucceed,
+	}
+	return gt.do(http.MethodPost, giteaRepoPath(owner, repo)+"/pulls/"+strconv.Itoa(number)+"/merge", nil, body, nil)
+}
+
+// MergePullRequest merges a pull request with the given method ("merge", "squash" or "rebase")
+func (gt *GiteaClient) MergePullRequest(owner, repo string, number int, method, sha string) error {
+	if err := gt.merge(owner, repo, number, method, sha, false); err != nil {
+		return fmt.Errorf("failed to merge pull request: %w", err)
+	}
+	return nil
+}
+
+// EnableAutoMerge schedules a pull request to merge once its status checks pass
+func (gt *GiteaClient) EnableAutoMerge(owner, repo string, pr *github.PullRequest, method string) error {
+	if err := gt.merge(owner, repo, pr.GetNumber(), method, pr.GetHead().GetSHA(), true); err != nil {
+		return fmt.Errorf("failed to enable auto-merge: %w", err)
+	}
+	return nil
+}
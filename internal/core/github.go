@@ -1,8 +1,17 @@
 package core
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/google/go-github/v63/github"
 	"golang.org/x/oauth2"
@@ -13,6 +22,7 @@ type GitHubClient struct {
 	client *github.Client
 	ctx    context.Context
 	token  string
+	limits *retryTransport // Tracks the rate limit budgets
 }
 
 // GetPullRequest retrieves a pull request
@@ -24,6 +34,32 @@ func (gc *GitHubClient) GetPullRequest(owner, repo string, number int) (*github.
 	return pr, nil
 }
 
+// ListOpenPullRequests retrieves a repository's open pull requests
+func (gc *GitHubClient) ListOpenPullRequests(owner, repo string) ([]*github.PullRequest, error) {
+	var prs []*github.PullRequest
+	opts := &github.PullRequestListOptions{State: "open", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := gc.client.PullRequests.List(gc.ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pull requests: %w", err)
+		}
+		prs = append(prs, page...)
+		if resp.NextPage == 0 {
+			return prs, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// GetPullRequestDiff retrieves a pull request's unified diff
+func (gc *GitHubClient) GetPullRequestDiff(owner, repo string, number int) (string, error) {
+	diff, _, err := gc.client.PullRequests.GetRaw(gc.ctx, owner, repo, number, github.RawOptions{Type: github.Diff})
+	if err != nil {
+		return "", fmt.Errorf("failed to get pull request diff: %w", err)
+	}
+	return diff, nil
+}
+
 // NewGitHubClient creates a new GitHub API client
 func NewGitHubClient(token string) *GitHubClient {
 	ctx := context.Background()
@@ -31,11 +67,14 @@ func NewGitHubClient(token string) *GitHubClient {
 		&oauth2.Token{AccessToken: token},
 	)
 	tc := oauth2.NewClient(ctx, ts)
+	limits := newRetryTransport(newETagTransport(tc.Transport))
+	tc.Transport = limits
 
 	return &GitHubClient{
 		client: github.NewClient(tc),
 		ctx:    ctx,
 		token:  token,
+		limits: limits,
 	}
 }
 
@@ -44,6 +83,22 @@ func (gc *GitHubClient) GetToken() string {
 	return gc.token
 }
 
+// CloneURL returns an HTTPS clone URL authenticated with the GitHub token. The
+// x-access-token user works for personal access tokens and app/Actions tokens alike.
+func (gc *GitHubClient) CloneURL(owner, repo string) string {
+	return fmt.Sprintf("https://x-access-token:%s@github.com/%s/%s.git", gc.token, owner, repo)
+}
+
+// IssueURL returns the web page of an issue
+func (gc *GitHubClient) IssueURL(owner, repo string, number int) string {
+	return fmt.Sprintf("https://github.com/%s/%s/issues/%d", owner, repo, number)
+}
+
+// PullRequestURL returns the web page of a pull request
+func (gc *GitHubClient) PullRequestURL(owner, repo string, number int) string {
+	return fmt.Sprintf("https://github.com/%s/%s/pull/%d", owner, repo, number)
+}
+
 // GetClient returns the underlying GitHub client
 func (gc *GitHubClient) GetClient() *github.Client {
 	return gc.client
@@ -75,6 +130,39 @@ func (gc *GitHubClient) CreateIssueComment(owner, repo string, number int, body
 	return nil
 }
 
+// CreateIssueCommentWithID adds a comment to an issue and returns its ID so it can be edited later
+func (gc *GitHubClient) CreateIssueCommentWithID(owner, repo string, number int, body string) (int64, error) {
+	comment := &github.IssueComment{
+		Body: github.String(body),
+	}
+	created, _, err := gc.client.Issues.CreateComment(gc.ctx, owner, repo, number, comment)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create comment: %w", err)
+	}
+	return created.GetID(), nil
+}
+
+// EditIssueComment replaces the body of an existing issue comment
+func (gc *GitHubClient) EditIssueComment(owner, repo string, number int, commentID int64, body string) error {
+	comment := &github.IssueComment{
+		Body: github.String(body),
+	}
+	_, _, err := gc.client.Issues.EditComment(gc.ctx, owner, repo, commentID, comment)
+	if err != nil {
+		return fmt.Errorf("failed to edit comment: %w", err)
+	}
+	return nil
+}
+
+// DeleteIssueComment removes an issue comment
+func (gc *GitHubClient) DeleteIssueComment(owner, repo string, number int, commentID int64) error {
+	_, err := gc.client.Issues.DeleteComment(gc.ctx, owner, repo, commentID)
+	if err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+	return nil
+}
+
 // ListIssueComments retrieves all comments for an issue
 func (gc *GitHubClient) ListIssueComments(owner, repo string, number int) ([]*github.IssueComment, error) {
 	opts := &github.IssueListCommentsOptions{
@@ -87,6 +175,15 @@ func (gc *GitHubClient) ListIssueComments(owner, repo string, number int) ([]*gi
 	return comments, nil
 }
 
+// ListIssueCommentReactions retrieves the reactions on an issue comment
+func (gc *GitHubClient) ListIssueCommentReactions(owner, repo string, number int, commentID int64) ([]*github.Reaction, error) {
+	reactions, _, err := gc.client.Reactions.ListIssueCommentReactions(gc.ctx, owner, repo, commentID, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reactions: %w", err)
+	}
+	return reactions, nil
+}
+
 // GetRepository retrieves repository information
 func (gc *GitHubClient) GetRepository(owner, repo string) (*github.Repository, error) {
 	repository, _, err := gc.client.Repositories.Get(gc.ctx, owner, repo)
@@ -97,12 +194,13 @@ func (gc *GitHubClient) GetRepository(owner, repo string) (*github.Repository, e
 }
 
 // CreatePullRequest creates a new pull request
-func (gc *GitHubClient) CreatePullRequest(owner, repo, title, body, head, base string) (*github.PullRequest, error) {
+func (gc *GitHubClient) CreatePullRequest(owner, repo, title, body, head, base string, draft bool) (*github.PullRequest, error) {
 	pr := &github.NewPullRequest{
 		Title: github.String(title),
 		Body:  github.String(body),
 		Head:  github.String(head),
 		Base:  github.String(base),
+		Draft: github.Bool(draft),
 	}
 
 	pullRequest, _, err := gc.client.PullRequests.Create(gc.ctx, owner, repo, pr)
@@ -112,6 +210,81 @@ func (gc *GitHubClient) CreatePullRequest(owner, repo, title, body, head, base s
 	return pullRequest, nil
 }
 
+// ClosePullRequest closes a pull request without merging it
+func (gc *GitHubClient) ClosePullRequest(owner, repo string, number int) error {
+	_, _, err := gc.client.PullRequests.Edit(gc.ctx, owner, repo, number, &github.PullRequest{State: github.String("closed")})
+	if err != nil {
+		return fmt.Errorf("failed to close pull request: %w", err)
+	}
+	return nil
+}
+
+// SetPullRequestMetadata adds labels, assignees and requested reviewers to a pull request
+// and sets its milestone
+func (gc *GitHubClient) SetPullRequestMetadata(owner, repo string, number int, metadata PullRequestMetadata) error {
+	var errs []error
+	if len(metadata.Labels) > 0 {
+		if _, _, err := gc.client.Issues.AddLabelsToIssue(gc.ctx, owner, repo, number, metadata.Labels); err != nil {
+			errs = append(errs, fmt.Errorf("failed to add labels: %w", err))
+		}
+	}
+	if len(metadata.Assignees) > 0 {
+		if _, _, err := gc.client.Issues.AddAssignees(gc.ctx, owner, repo, number, metadata.Assignees); err != nil {
+			errs = append(errs, fmt.Errorf("failed to add assignees: %w", err))
+		}
+	}
+	if len(metadata.Reviewers) > 0 || len(metadata.TeamReviewers) > 0 {
+		request := github.ReviewersRequest{Reviewers: metadata.Reviewers, TeamReviewers: metadata.TeamReviewers}
+		if _, _, err := gc.client.PullRequests.RequestReviewers(gc.ctx, owner, repo, number, request); err != nil {
+			errs = append(errs, fmt.Errorf("failed to request reviewers: %w", err))
+		}
+	}
+	if metadata.Milestone != "" {
+		if err := gc.setMilestone(owner, repo, number, metadata.Milestone); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// setMilestone sets an issue or pull request's milestone by title
+func (gc *GitHubClient) setMilestone(owner, repo string, number int, title string) error {
+	opts := &github.MilestoneListOptions{State: "open", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		milestones, resp, err := gc.client.Issues.ListMilestones(gc.ctx, owner, repo, opts)
+		if err != nil {
+			return fmt.Errorf("failed to list milestones: %w", err)
+		}
+		for _, milestone := range milestones {
+			if milestone.GetTitle() != title {
+				continue
+			}
+			if _, _, err := gc.client.Issues.Edit(gc.ctx, owner, repo, number, &github.IssueRequest{Milestone: milestone.Number}); err != nil {
+				return fmt.Errorf("failed to set milestone: %w", err)
+			}
+			return nil
+		}
+		if resp.NextPage == 0 {
+			return fmt.Errorf("milestone not found: %s", title)
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// CreatePullRequestComment adds a comment to a pull request's conversation
+func (gc *GitHubClient) CreatePullRequestComment(owner, repo string, number int, body string) error {
+	return gc.CreateIssueComment(owner, repo, number, body)
+}
+
+// RemoveAssignee removes a user from an issue's assignees
+func (gc *GitHubClient) RemoveAssignee(owner, repo string, number int, assignee string) error {
+	_, _, err := gc.client.Issues.RemoveAssignees(gc.ctx, owner, repo, number, []string{assignee})
+	if err != nil {
+		return fmt.Errorf("failed to remove assignee: %w", err)
+	}
+	return nil
+}
+
 // ListPRComments retrieves all comments (review comments + issue comments) for a PR
 func (gc *GitHubClient) ListPRComments(owner, repo string, number int) ([]*github.PullRequestComment, error) {
 	opts := &github.PullRequestListCommentsOptions{
@@ -121,9 +294,139 @@ func (gc *GitHubClient) ListPRComments(owner, repo string, number int) ([]*githu
 	if err != nil {
 		return nil, fmt.Errorf("failed to list PR comments: %w", err)
 	}
+
+	// Comments in the PR's conversation tab are issue comments
+	conversation, err := gc.ListIssueComments(owner, repo, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PR conversation comments: %w", err)
+	}
+	for _, comment := range conversation {
+		comments = append(comments, &github.PullRequestComment{
+			ID:        comment.ID,
+			Body:      comment.Body,
+			User:      comment.User,
+			HTMLURL:   comment.HTMLURL,
+			CreatedAt: comment.CreatedAt,
+			UpdatedAt: comment.UpdatedAt,
+		})
+	}
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].GetCreatedAt().Before(comments[j].GetCreatedAt().Time)
+	})
 	return comments, nil
 }
 
+// ListReviews retrieves the reviews submitted on a pull request
+func (gc *GitHubClient) ListReviews(owner, repo string, number int) ([]*github.PullRequestReview, error) {
+	var reviews []*github.PullRequestReview
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := gc.client.PullRequests.ListReviews(gc.ctx, owner, repo, number, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list reviews: %w", err)
+		}
+		reviews = append(reviews, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return reviews, nil
+}
+
+// CreateReview submits a comment-only review on the pull request's head commit
+func (gc *GitHubClient) CreateReview(owner, repo string, pr *github.PullRequest, body string, comments []ReviewComment) error {
+	review := &github.PullRequestReviewRequest{
+		CommitID: github.String(pr.GetHead().GetSHA()),
+		Body:     github.String(body),
+		Event:    github.String("COMMENT"),
+	}
+	for _, comment := range comments {
+		review.Comments = append(review.Comments, &github.DraftReviewComment{
+			Path: github.String(comment.Path),
+			Line: github.Int(comment.Line),
+			Side: github.String("RIGHT"),
+			Body: github.String(comment.Body),
+		})
+	}
+
+	if _, _, err := gc.client.PullRequests.CreateReview(gc.ctx, owner, repo, pr.GetNumber(), review); err != nil {
+		return fmt.Errorf("failed to create review: %w", err)
+	}
+	return nil
+}
+
+// ListPullRequestFiles returns the paths of the files changed by a pull request
+func (gc *GitHubClient) ListPullRequestFiles(owner, repo string, number int) ([]string, error) {
+	var paths []string
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		files, resp, err := gc.client.PullRequests.ListFiles(gc.ctx, owner, repo, number, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list PR files: %w", err)
+		}
+		for _, file := range files {
+			paths = append(paths, file.GetFilename())
+			if file.GetPreviousFilename() != "" {
+				paths = append(paths, file.GetPreviousFilename())
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return paths, nil
+}
+
+// FindHookID returns the ID of the repository webhook delivering to url (0 if none)
+func (gc *GitHubClient) FindHookID(owner, repo, url string) (int64, error) {
+	hooks, _, err := gc.client.Repositories.ListHooks(gc.ctx, owner, repo, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list hooks: %w", err)
+	}
+	for _, hook := range hooks {
+		if hook.GetConfig().GetURL() == url {
+			return hook.GetID(), nil
+		}
+	}
+	return 0, nil
+}
+
+// ListHookDeliveries returns a webhook's deliveries made after since, newest first
+func (gc *GitHubClient) ListHookDeliveries(owner, repo string, hookID int64, since time.Time) ([]*github.HookDelivery, error) {
+	var deliveries []*github.HookDelivery
+	opts := &github.ListCursorOptions{PerPage: 100}
+	for {
+		page, resp, err := gc.client.Repositories.ListHookDeliveries(gc.ctx, owner, repo, hookID, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list hook deliveries: %w", err)
+		}
+		for _, delivery := range page {
+			if !delivery.GetDeliveredAt().Time.After(since) {
+				return deliveries, nil
+			}
+			deliveries = append(deliveries, delivery)
+		}
+		if resp.Cursor == "" || len(page) == 0 {
+			return deliveries, nil
+		}
+		opts.Cursor = resp.Cursor
+	}
+}
+
+// GetHookDeliveryPayload returns the raw JSON payload of a webhook delivery
+func (gc *GitHubClient) GetHookDeliveryPayload(owner, repo string, hookID, deliveryID int64) ([]byte, error) {
+	delivery, _, err := gc.client.Repositories.GetHookDelivery(gc.ctx, owner, repo, hookID, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hook delivery: %w", err)
+	}
+	if delivery.Request == nil || delivery.Request.RawPayload == nil {
+		return nil, fmt.Errorf("hook delivery %d has no payload", deliveryID)
+	}
+	return *delivery.Request.RawPayload, nil
+}
+
 // GetFileContent retrieves the content of a file from a repository
 func (gc *GitHubClient) GetFileContent(owner, repo, path, ref string) (string, error) {
 	opts := &github.RepositoryContentGetOptions{Ref: ref}
@@ -230,6 +533,29 @@ func (gc *GitHubClient) ListAssignedIssues(username string, repositories []strin
 	return allIssues, nil
 }
 
+// ListLabeledIssues retrieves open issues in a repository that carry a label
+func (gc *GitHubClient) ListLabeledIssues(owner, repo, label string) ([]*github.Issue, error) {
+	opts := &github.IssueListByRepoOptions{
+		State:       "open",
+		Labels:      []string{label},
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	issues, _, err := gc.client.Issues.ListByRepo(gc.ctx, owner, repo, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list labeled issues: %w", err)
+	}
+
+	var issuesOnly []*github.Issue
+	for _, issue := range issues {
+		if !issue.IsPullRequest() {
+			issuesOnly = append(issuesOnly, issue)
+		}
+	}
+
+	return issuesOnly, nil
+}
+
 // ListRepositoryIssues retrieves all open issues from a specific repository
 func (gc *GitHubClient) ListRepositoryIssues(owner, repo, assignee string) ([]*github.Issue, error) {
 	opts := &github.IssueListByRepoOptions{
@@ -257,3 +583,347 @@ func (gc *GitHubClient) ListRepositoryIssues(owner, repo, assignee string) ([]*g
 
 	return issuesOnly, nil
 }
+
+// ListOpenIssues retrieves the most recently created open issues in a repository
+func (gc *GitHubClient) ListOpenIssues(owner, repo string) ([]*github.Issue, error) {
+	return gc.ListRepositoryIssues(owner, repo, "")
+}
+
+// ListLabels returns the names of a repository's labels
+func (gc *GitHubClient) ListLabels(owner, repo string) ([]string, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	var names []string
+	for {
+		labels, resp, err := gc.client.Issues.ListLabels(gc.ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list labels: %w", err)
+		}
+		for _, label := range labels {
+			names = append(names, label.GetName())
+		}
+		if resp.NextPage == 0 {
+			return names, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// ListMentioningIssues returns open issues and pull requests in a repository mentioning user
+// that were updated after since
+func (gc *GitHubClient) ListMentioningIssues(owner, repo, user string, since time.Time) ([]*github.Issue, error) {
+	opts := &github.IssueListByRepoOptions{
+		State:     "open",
+		Mentioned: user,
+		Since:     since,
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+
+	issues, _, err := gc.client.Issues.ListByRepo(gc.ctx, owner, repo, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mentioning issues: %w", err)
+	}
+	return issues, nil
+}
+
+// GetPermissionLevel returns a user's permission on a repository ("admin", "write", "read" or "none")
+func (gc *GitHubClient) GetPermissionLevel(owner, repo, user string) (string, error) {
+	level, _, err := gc.client.Repositories.GetPermissionLevel(gc.ctx, owner, repo, user)
+	if err != nil {
+		return "", fmt.Errorf("failed to get permission level: %w", err)
+	}
+	return level.GetPermission(), nil
+}
+
+// AddAssignee assigns a user to an issue
+func (gc *GitHubClient) AddAssignee(owner, repo string, number int, assignee string) error {
+	_, _, err := gc.client.Issues.AddAssignees(gc.ctx, owner, repo, number, []string{assignee})
+	if err != nil {
+		return fmt.Errorf("failed to add assignee: %w", err)
+	}
+	return nil
+}
+
+// DownloadImage downloads an image attachment and returns it as a data URL.
+// The GitHub token is only sent to GitHub-owned hosts so it never leaks to
+// third-party image hosts.
+func (gc *GitHubClient) DownloadImage(imageURL string) (ImageAttachment, error) {
+	return fetchImage(gc.ctx, http.DefaultClient, imageURL, func(req *http.Request) {
+		if isGitHubHost(req.URL.Hostname()) {
+			req.Header.Set("Authorization", "token "+gc.token)
+		}
+	})
+}
+
+// isGitHubHost reports whether host belongs to GitHub (and may receive the token)
+func isGitHubHost(host string) bool {
+	return host == "github.com" || strings.HasSuffix(host, ".github.com") ||
+		strings.HasSuffix(host, ".githubusercontent.com")
+}
+
+// CountApprovals returns how many reviewers currently approve a pull request and
+// whether any reviewer's latest review requests changes
+func (gc *GitHubClient) CountApprovals(owner, repo string, number int) (approvals int, changesRequested bool, err error) {
+	reviews, _, err := gc.client.PullRequests.ListReviews(gc.ctx, owner, repo, number, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to list reviews: %w", err)
+	}
+
+	// Only each reviewer's latest approving or blocking review counts
+	latest := make(map[string]string)
+	for _, review := range reviews {
+		switch state := review.GetState(); state {
+		case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+			latest[review.GetUser().GetLogin()] = state
+		}
+	}
+
+	for _, state := range latest {
+		switch state {
+		case "APPROVED":
+			approvals++
+		case "CHANGES_REQUESTED":
+			changesRequested = true
+		}
+	}
+	return approvals, changesRequested, nil
+}
+
+// ChecksPassed reports whether every commit status and check run on ref succeeded.
+// pending is true while any of them is still running.
+func (gc *GitHubClient) ChecksPassed(owner, repo, ref string) (passed, pending bool, err error) {
+	status, _, err := gc.client.Repositories.GetCombinedStatus(gc.ctx, owner, repo, ref, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return false, false, fmt.Errorf("failed to get commit status: %w", err)
+	}
+	// A ref with no commit statuses reports "pending"; only count it when statuses exist
+	if status.GetTotalCount() > 0 {
+		switch status.GetState() {
+		case "pending":
+			pending = true
+		case "failure", "error":
+			return false, false, nil
+		}
+	}
+
+	runs, _, err := gc.client.Checks.ListCheckRunsForRef(gc.ctx, owner, repo, ref, &github.ListCheckRunsOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return false, false, fmt.Errorf("failed to list check runs: %w", err)
+	}
+	for _, run := range runs.CheckRuns {
+		if run.GetStatus() != "completed" {
+			pending = true
+			continue
+		}
+		switch run.GetConclusion() {
+		case "success", "neutral", "skipped":
+		default:
+			return false, false, nil
+		}
+	}
+
+	return !pending, pending, nil
+}
+
+// actionsLogTimestampRe matches the timestamp GitHub Actions puts at the start of each log line
+var actionsLogTimestampRe = regexp.MustCompile(`(?m)^\d{4}-\d{2}-\d{2}T[\d:.]+Z `)
+
+// ListFailedChecks returns the failed commit statuses and check runs on ref, with the job
+// logs of failed GitHub Actions jobs
+func (gc *GitHubClient) ListFailedChecks(owner, repo, ref string) ([]FailedCheck, error) {
+	var failed []FailedCheck
+
+	status, _, err := gc.client.Repositories.GetCombinedStatus(gc.ctx, owner, repo, ref, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit status: %w", err)
+	}
+	for _, st := range status.Statuses {
+		if st.GetState() == "failure" || st.GetState() == "error" {
+			failed = append(failed, FailedCheck{Name: st.GetContext(), Log: strings.TrimSpace(st.GetDescription() + "\n" + st.GetTargetURL())})
+		}
+	}
+
+	runs, _, err := gc.client.Checks.ListCheckRunsForRef(gc.ctx, owner, repo, ref, &github.ListCheckRunsOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list check runs: %w", err)
+	}
+	for _, run := range runs.CheckRuns {
+		switch run.GetConclusion() {
+		case "", "success", "neutral", "skipped":
+			continue
+		}
+
+		// Check runs created by GitHub Actions are workflow jobs with the same ID
+		log := ""
+		if run.GetApp().GetSlug() == "github-actions" {
+			if log, err = gc.workflowJobLog(owner, repo, run.GetID()); err != nil {
+				slog.Debug("Couldn't download job log", "check", run.GetName(), "error", err)
+			}
+		}
+		if log == "" {
+			output := run.GetOutput()
+			log = strings.TrimSpace(strings.Join([]string{output.GetTitle(), output.GetSummary(), output.GetText()}, "\n\n"))
+		}
+		failed = append(failed, FailedCheck{Name: run.GetName(), Log: log})
+	}
+
+	return failed, nil
+}
+
+// workflowJobLog downloads the end of a GitHub Actions job's log
+func (gc *GitHubClient) workflowJobLog(owner, repo string, jobID int64) (string, error) {
+	logURL, _, err := gc.client.Actions.GetWorkflowJobLogs(gc.ctx, owner, repo, jobID, 3)
+	if err != nil {
+		return "", fmt.Errorf("failed to get job log URL: %w", err)
+	}
+
+	// The log URL is pre-signed, so no credentials are sent
+	req, err := http.NewRequestWithContext(gc.ctx, http.MethodGet, logURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download job log: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download job log: status %d", resp.StatusCode)
+	}
+
+	log, err := readLogTail(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read job log: %w", err)
+	}
+	return actionsLogTimestampRe.ReplaceAllString(log, ""), nil
+}
+
+// MergePullRequest merges a pull request with the given method ("merge", "squash" or "rebase")
+func (gc *GitHubClient) MergePullRequest(owner, repo string, number int, method, sha string) error {
+	_, _, err := gc.client.PullRequests.Merge(gc.ctx, owner, repo, number, "", &github.PullRequestOptions{
+		MergeMethod: method,
+		SHA:         sha,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to merge pull request: %w", err)
+	}
+	return nil
+}
+
+// EnableAutoMerge turns on GitHub's native auto-merge for a pull request, which merges
+// it once branch protection requirements are met
+func (gc *GitHubClient) EnableAutoMerge(owner, repo string, pr *github.PullRequest, method string) error {
+	query := `mutation($id: ID!, $method: PullRequestMergeMethod!) {
+		enablePullRequestAutoMerge(input: {pullRequestId: $id, mergeMethod: $method}) { clientMutationId }
+	}`
+	if err := gc.graphQL(query, map[string]string{"id": pr.GetNodeID(), "method": strings.ToUpper(method)}); err != nil {
+		return fmt.Errorf("failed to enable auto-merge: %w", err)
+	}
+	return nil
+}
+
+// MarkPullRequestReady takes a draft pull request out of draft, which the REST API can't do
+func (gc *GitHubClient) MarkPullRequestReady(owner, repo string, pr *github.PullRequest) error {
+	query := `mutation($id: ID!) {
+		markPullRequestReadyForReview(input: {pullRequestId: $id}) { clientMutationId }
+	}`
+	if err := gc.graphQL(query, map[string]string{"id": pr.GetNodeID()}); err != nil {
+		return fmt.Errorf("failed to mark pull request ready: %w", err)
+	}
+	return nil
+}
+
+// graphQL runs a GraphQL mutation, returning the first error GitHub reports
+func (gc *GitHubClient) graphQL(query string, variables map[string]string) error {
+	payload, err := json.Marshal(map[string]any{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(gc.ctx, http.MethodPost, "https://api.github.com/graphql", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := gc.client.Client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("unexpected response: status %d", resp.StatusCode)
+	}
+	if len(result.Errors) > 0 {
+		return errors.New(result.Errors[0].Message)
+	}
+	return nil
+}
+
+// DeleteBranch deletes a branch from the repository
+func (gc *GitHubClient) DeleteBranch(owner, repo, branch string) error {
+	_, err := gc.client.Git.DeleteRef(gc.ctx, owner, repo, "refs/heads/"+branch)
+	if err != nil {
+		return fmt.Errorf("failed to delete branch: %w", err)
+	}
+	return nil
+}
+
+// CreateIssue opens an issue
+func (gc *GitHubClient) CreateIssue(owner, repo, title, body string) (*github.Issue, error) {
+	issue, _, err := gc.client.Issues.Create(gc.ctx, owner, repo, &github.IssueRequest{
+		Title: github.String(title),
+		Body:  github.String(body),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create issue: %w", err)
+	}
+	return issue, nil
+}
+
+// CreateGist creates a secret gist holding one file and returns its URL
+func (gc *GitHubClient) CreateGist(description, filename, content string) (string, error) {
+	gist, _, err := gc.client.Gists.Create(gc.ctx, &github.Gist{
+		Description: github.String(description),
+		Public:      github.Bool(false),
+		Files: map[github.GistFilename]github.GistFile{
+			github.GistFilename(filename): {Content: github.String(content)},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create gist: %w", err)
+	}
+	return gist.GetHTMLURL(), nil
+}
+
+// AddLabels adds labels to an issue, creating any the repository doesn't have
+func (gc *GitHubClient) AddLabels(owner, repo string, number int, labels []string) error {
+	if _, _, err := gc.client.Issues.AddLabelsToIssue(gc.ctx, owner, repo, number, labels); err != nil {
+		return fmt.Errorf("failed to add labels: %w", err)
+	}
+	return nil
+}
+
+// CloseIssue closes an issue
+func (gc *GitHubClient) CloseIssue(owner, repo string, number int) error {
+	closed := "closed"
+	_, _, err := gc.client.Issues.Edit(gc.ctx, owner, repo, number, &github.IssueRequest{State: &closed})
+	if err != nil {
+		return fmt.Errorf("failed to close issue: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+)
+
+const (
+	// maxCachedResponses caps how many GitHub responses are kept for conditional requests
+	maxCachedResponses = 2000
+	// maxCachedBodyBytes is the largest response body that is cached
+	maxCachedBodyBytes = 1 << 20
+)
+
+// cachedResponse is a GitHub API response kept to answer a 304 Not Modified
+type cachedResponse struct {
+	etag   string
+	header http.Header
+	body   []byte
+}
+
+// etagTransport makes conditional GET requests to the GitHub API. Responses with an ETag
+// are cached, later requests for the same URL send If-None-Match, and a 304 (which doesn't
+// count against the rate limit) is answered from the cache. Polling the same issue and
+// comment lists every interval then only downloads them when something changed.
+type etagTransport struct {
+	base http.RoundTripper
+
+	mu        sync.Mutex
+	responses map[string]*cachedResponse
+}
+
+func newETagTransport(base http.RoundTripper) *etagTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &etagTransport{base: base, responses: make(map[string]*cachedResponse)}
+}
+
+// RoundTrip sends a request, revalidating a cached response when there is one
+func (t *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("If-None-Match") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	// The same URL returns JSON or a raw diff depending on Accept
+	key := req.URL.String() + "\n" + req.Header.Get("Accept")
+	t.mu.Lock()
+	cached := t.responses[key]
+	t.mu.Unlock()
+
+	if cached != nil {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		// Keep the fresh rate limit headers from the 304
+		header := cached.header.Clone()
+		for name, values := range resp.Header {
+			header[name] = values
+		}
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(cached.body)),
+			ContentLength: int64(len(cached.body)),
+			Request:       req,
+		}, nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode != http.StatusOK || etag == "" || resp.ContentLength > maxCachedBodyBytes {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCachedBodyBytes+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if len(body) > maxCachedBodyBytes {
+		// A body of unknown length turned out too large to cache: hand back what was read
+		// followed by the rest of the stream, closed through the original body
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.responses[key]; !ok && len(t.responses) >= maxCachedResponses {
+		// Evict an arbitrary entry; polled URLs are re-cached on their next request
+		for evict := range t.responses {
+			delete(t.responses, evict)
+			break
+		}
+	}
+	t.responses[key] = &cachedResponse{etag: etag, header: resp.Header.Clone(), body: body}
+	return resp, nil
+}
@@ -0,0 +1,211 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxGitHubRetries is how many times a failed GitHub API request is retried
+	maxGitHubRetries = 4
+	// githubRetryBackoff is the base delay between retries, doubled on each attempt
+	githubRetryBackoff = 2 * time.Second
+	// maxGitHubRetryWait caps how long a single retry waits, including Retry-After
+	// and rate limit resets; longer waits fail the request instead
+	maxGitHubRetryWait = 2 * time.Minute
+	// rateLimitWarnFraction is the share of the hourly quota left when a warning is logged
+	rateLimitWarnFraction = 0.1
+)
+
+// rateLimitBudget is a GitHub API quota reported by the latest response for its resource
+type rateLimitBudget struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// retryTransport retries GitHub API requests that hit rate limits, and idempotent ones that
+// failed on network errors or bad gateways, and tracks the primary rate limits from the
+// X-RateLimit headers
+type retryTransport struct {
+	base http.RoundTripper
+
+	mu      sync.Mutex
+	budgets map[string]rateLimitBudget // By resource, e.g. "core" or "search"
+	warned  map[string]bool            // Whether the low quota warning was logged for the current window
+}
+
+func newRetryTransport(base http.RoundTripper) *retryTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &retryTransport{
+		base:    base,
+		budgets: make(map[string]rateLimitBudget),
+		warned:  make(map[string]bool),
+	}
+}
+
+// RoundTrip sends a request, retrying transient failures with exponential backoff
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.Body != nil {
+			if req.GetBody == nil {
+				return nil, errors.New("can't retry GitHub request with a body that can't be replayed")
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(req)
+		if resp != nil {
+			t.record(resp.Header)
+		}
+
+		reason, wait := t.retryReason(req, resp, err, attempt)
+		if reason == "" || attempt >= maxGitHubRetries || wait > maxGitHubRetryWait {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		GitHubRetries.Inc(reason)
+		slog.Warn("⏳ GitHub API request failed, retrying", "reason", reason, "method", req.Method,
+			"path", req.URL.Path, "attempt", attempt+1, "wait", wait.Round(time.Second))
+		if err := sleepContext(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryReason classifies a failed request as retryable and returns how long to wait
+// first, or an empty reason if the result should be returned as it is. A POST or PATCH
+// that failed on the network or a bad gateway may have been applied already, so only
+// rate limits, which GitHub rejects before doing anything, retry those.
+func (t *retryTransport) retryReason(req *http.Request, resp *http.Response, err error, attempt int) (string, time.Duration) {
+	backoff := githubRetryBackoff << attempt
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || !isIdempotent(req.Method) {
+			return "", 0
+		}
+		return "network", backoff
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		if isIdempotent(req.Method) {
+			return "server_error", backoff
+		}
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		// Secondary rate limits say how long to back off in Retry-After
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			return "secondary_rate_limit", time.Duration(seconds) * time.Second
+		}
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			if reset, ok := rateLimitReset(resp.Header); ok {
+				return "rate_limit", time.Until(reset) + time.Second
+			}
+		}
+		// Abuse detection without a Retry-After header waits at least a minute
+		if resp.StatusCode == http.StatusForbidden && isSecondaryRateLimit(resp) {
+			return "secondary_rate_limit", max(backoff, time.Minute)
+		}
+	}
+	return "", 0
+}
+
+// record updates a resource's rate limit budget from a response's X-RateLimit headers
+// and warns when its quota is nearly used up
+func (t *retryTransport) record(header http.Header) {
+	limit, err1 := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	remaining, err2 := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	reset, ok := rateLimitReset(header)
+	if err1 != nil || err2 != nil || !ok {
+		return
+	}
+	// Search and GraphQL have separate, smaller quotas
+	resource := header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = "core"
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !reset.Equal(t.budgets[resource].Reset) {
+		t.warned[resource] = false
+	}
+	t.budgets[resource] = rateLimitBudget{Limit: limit, Remaining: remaining, Reset: reset}
+	GitHubRateLimitRemaining.Set(resource, float64(remaining))
+
+	if !t.warned[resource] && float64(remaining) < float64(limit)*rateLimitWarnFraction {
+		t.warned[resource] = true
+		slog.Warn("⚠️  GitHub API rate limit is running low", "resource", resource, "remaining", remaining,
+			"limit", limit, "resets_in", time.Until(reset).Round(time.Second))
+	}
+}
+
+// budget returns a resource's latest rate limit budget, and false if no response has
+// reported it yet
+func (t *retryTransport) budget(resource string) (rateLimitBudget, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	budget, ok := t.budgets[resource]
+	return budget, ok
+}
+
+// isIdempotent reports whether repeating a request with this method has the same effect
+// as sending it once
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// rateLimitReset parses the X-RateLimit-Reset header (Unix seconds)
+func rateLimitReset(header http.Header) (time.Time, bool) {
+	seconds, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0), true
+}
+
+// isSecondaryRateLimit reports whether a 403 response is GitHub's abuse detection. The body
+// is read and replaced so the caller still sees the full error.
+func isSecondaryRateLimit(resp *http.Response) bool {
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+	resp.Body = io.NopCloser(strings.NewReader(string(data)))
+	if err != nil {
+		return false
+	}
+	text := strings.ToLower(string(data))
+	return strings.Contains(text, "secondary rate limit") || strings.Contains(text, "abuse detection")
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
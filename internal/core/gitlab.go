@@ -0,0 +1,1043 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v63/github"
+)
+
+// gitLabDraftPrefix marks a merge request as a draft
+const gitLabDraftPrefix = "Draft: "
+
+// GitLabClient talks to a GitLab instance's REST API and converts its responses
+// into go-github types, so the workflows can treat it like GitHub
+type GitLabClient struct {
+	baseURL string // Instance URL without a trailing slash, e.g. "https://gitlab.com"
+	token   string
+	ctx     context.Context
+	http    *http.Client
+
+	mu      sync.Mutex
+	userIDs map[string]int64 // Username -> user ID
+}
+
+// NewGitLabClient creates a client for the GitLab instance at baseURL ("" means gitlab.com)
+func NewGitLabClient(baseURL, token string) *GitLabClient {
+	if baseURL == "" {
+		baseURL = "https://gitlab.com"
+	}
+	return &GitLabClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		ctx:     context.Background(),
+		http:    &http.Client{Timeout: 60 * time.Second},
+		userIDs: make(map[string]int64),
+	}
+}
+
+// gitLabError is a failed GitLab API response
+type gitLabError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *gitLabError) Error() string {
+	return fmt.Sprintf("GitLab API error (status %d): %s", e.StatusCode, e.Message)
+}
+
+// isGitLabNotFound reports whether err is a GitLab 404
+func isGitLabNotFound(err error) bool {
+	var glErr *gitLabError
+	return errors.As(err, &glErr) && glErr.StatusCode == http.StatusNotFound
+}
+
+// GitLab API response types (only the fields the agent uses)
+type glUser struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+type glIssue struct {
+	IID         int       `json:"iid"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	State       string    `json:"state"`
+	WebURL      string    `json:"web_url"`
+	Author      glUser    `json:"author"`
+	Assignees   []glUser  `json:"assignees"`
+	Labels      []string  `json:"labels"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type glNote struct {
+	ID        int64     `json:"id"`
+	Body      string    `json:"body"`
+	System    bool      `json:"system"`
+	Author    glUser    `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type glMergeRequest struct {
+	IID                       int        `json:"iid"`
+	Title                     string     `json:"title"`
+	Description               string     `json:"description"`
+	State                     string     `json:"state"`
+	WebURL                    string     `json:"web_url"`
+	SourceBranch              string     `json:"source_branch"`
+	TargetBranch              string     `json:"target_branch"`
+	SHA                       string     `json:"sha"`
+	Draft                     bool       `json:"draft"`
+	HasConflicts              bool       `json:"has_conflicts"`
+	MergeWhenPipelineSucceeds bool       `json:"merge_when_pipeline_succeeds"`
+	Author                    glUser     `json:"author"`
+	Reviewers                 []glUser   `json:"reviewers"`
+	DiffRefs                  glDiffRefs `json:"diff_refs"`
+	CreatedAt                 time.Time  `json:"created_at"`
+	UpdatedAt                 time.Time  `json:"updated_at"`
+}
+
+// glDiffRefs are the commits a merge request's diff is computed between, needed to
+// position diff comments
+type glDiffRefs struct {
+	BaseSHA  string `json:"base_sha"`
+	HeadSHA  string `json:"head_sha"`
+	StartSHA string `json:"start_sha"`
+}
+
+type glProject struct {
+	Name              string `json:"name"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	DefaultBranch     string `json:"default_branch"`
+	WebURL            string `json:"web_url"`
+}
+
+func (u glUser) toGitHub() *github.User {
+	return &github.User{ID: github.Int64(u.ID), Login: github.String(u.Username)}
+}
+
+func (i glIssue) toGitHub() *github.Issue {
+	issue := &github.Issue{
+		Number:    github.Int(i.IID),
+		Title:     github.String(i.Title),
+		Body:      github.String(i.Description),
+		State:     github.String(gitLabState(i.State)),
+		HTMLURL:   github.String(i.WebURL),
+		User:      i.Author.toGitHub(),
+		CreatedAt: &github.Timestamp{Time: i.CreatedAt},
+		UpdatedAt: &github.Timestamp{Time: i.UpdatedAt},
+	}
+	for _, assignee := range i.Assignees {
+		issue.Assignees = append(issue.Assignees, assignee.toGitHub())
+	}
+	for _, label := range i.Labels {
+		issue.Labels = append(issue.Labels, &github.Label{Name: github.String(label)})
+	}
+	return issue
+}
+
+func (n glNote) toIssueComment() *github.IssueComment {
+	return &github.IssueComment{
+		ID:        github.Int64(n.ID),
+		Body:      github.String(n.Body),
+		User:      n.Author.toGitHub(),
+		CreatedAt: &github.Timestamp{Time: n.CreatedAt},
+		UpdatedAt: &github.Timestamp{Time: n.UpdatedAt},
+	}
+}
+
+func (mr glMergeRequest) toGitHub() *github.PullRequest {
+	pr := &github.PullRequest{
+		Number:    github.Int(mr.IID),
+		Title:     github.String(mr.Title),
+		Body:      github.String(mr.Description),
+		State:     github.String(gitLabState(mr.State)),
+		Merged:    github.Bool(mr.State == "merged"),
+		Draft:     github.Bool(mr.Draft),
+		Mergeable: github.Bool(!mr.HasConflicts),
+		HTMLURL:   github.String(mr.WebURL),
+		User:      mr.Author.toGitHub(),
+		Head:      &github.PullRequestBranch{Ref: github.String(mr.SourceBranch), SHA: github.String(mr.SHA)},
+		Base:      &github.PullRequestBranch{Ref: github.String(mr.TargetBranch)},
+		CreatedAt: &github.Timestamp{Time: mr.CreatedAt},
+		UpdatedAt: &github.Timestamp{Time: mr.UpdatedAt},
+	}
+	if mr.MergeWhenPipelineSucceeds {
+		pr.AutoMerge = &github.PullRequestAutoMerge{}
+	}
+	for _, reviewer := range mr.Reviewers {
+		pr.RequestedReviewers = append(pr.RequestedReviewers, reviewer.toGitHub())
+	}
+	return pr
+}
+
+// gitLabState maps GitLab's issue and merge request states onto GitHub's "open" and "closed"
+func gitLabState(state string) string {
+	if state == "opened" {
+		return "open"
+	}
+	return "closed"
+}
+
+// gitLabProjectPath returns the API path of a project, addressed by its URL-encoded full path
+func gitLabProjectPath(owner, repo string) string {
+	return "/projects/" + url.PathEscape(owner+"/"+repo)
+}
+
+// do sends an API request, encoding body as JSON and decoding the response into out (either
+// may be nil). A *string out receives the end of a plain text response, such as a job log.
+func (gl *GitLabClient) do(method, path string, query url.Values, body, out any) (*http.Response, error) {
+	endpoint := gl.baseURL + "/api/v4" + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(gl.ctx, method, endpoint, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("PRIVATE-TOKEN", gl.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := gl.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return resp, &gitLabError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+	}
+	if text, ok := out.(*string); ok {
+		if *text, err = readLogTail(resp.Body); err != nil {
+			return resp, fmt.Errorf("failed to read response: %w", err)
+		}
+		return resp, nil
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return resp, nil
+}
+
+// gitLabListAll fetches every page of a list endpoint
+func gitLabListAll[T any](gl *GitLabClient, path string, query url.Values) ([]T, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("per_page", "100")
+
+	var all []T
+	for page := "1"; page != ""; {
+		query.Set("page", page)
+		var items []T
+		resp, err := gl.do(http.MethodGet, path, query, nil, &items)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		page = resp.Header.Get("X-Next-Page")
+	}
+	return all, nil
+}
+
+// userID looks up a user's numeric ID by username
+func (gl *GitLabClient) userID(username string) (int64, error) {
+	gl.mu.Lock()
+	id, ok := gl.userIDs[username]
+	gl.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	var users []glUser
+	if _, err := gl.do(http.MethodGet, "/users", url.Values{"username": {username}}, nil, &users); err != nil {
+		return 0, fmt.Errorf("failed to look up user %s: %w", username, err)
+	}
+	if len(users) == 0 {
+		return 0, fmt.Errorf("user not found: %s", username)
+	}
+
+	gl.mu.Lock()
+	gl.userIDs[username] = users[0].ID
+	gl.mu.Unlock()
+	return users[0].ID, nil
+}
+
+// CloneURL returns an HTTPS clone URL authenticated with the access token
+func (gl *GitLabClient) CloneURL(owner, repo string) string {
+	u, err := url.Parse(gl.baseURL)
+	if err != nil {
+		return fmt.Sprintf("%s/%s/%s.git", gl.baseURL, owner, repo)
+	}
+	u.User = url.UserPassword("oauth2", gl.token)
+	u.Path = strings.TrimRight(u.Path, "/") + "/" + owner + "/" + repo + ".git"
+	return u.String()
+}
+
+// IssueURL returns the web page of an issue
+func (gl *GitLabClient) IssueURL(owner, repo string, number int) string {
+	return fmt.Sprintf("%s/%s/%s/-/issues/%d", gl.baseURL, owner, repo, number)
+}
+
+// PullRequestURL returns the web page of a merge request
+func (gl *GitLabClient) PullRequestURL(owner, repo string, number int) string {
+	return fmt.Sprintf("%s/%s/%s/-/merge_requests/%d", gl.baseURL, owner, repo, number)
+}
+
+// GetAuthenticatedUser retrieves the user the access token belongs to
+func (gl *GitLabClient) GetAuthenticatedUser() (*github.User, error) {
+	var user glUser
+	if _, err := gl.do(http.MethodGet, "/user", nil, nil, &user); err != nil {
+		return nil, fmt.Errorf("failed to get authenticated user: %w", err)
+	}
+	return user.toGitHub(), nil
+}
+
+// GetPermissionLevel maps a member's GitLab access level onto GitHub's permission names
+func (gl *GitLabClient) GetPermissionLevel(owner, repo, user string) (string, error) {
+	id, err := gl.userID(user)
+	if err != nil {
+		return "", fmt.Errorf("failed to get permission level: %w", err)
+	}
+
+	var member struct {
+		AccessLevel int `json:"access_level"`
+	}
+	_, err = gl.do(http.MethodGet, gitLabProjectPath(owner, repo)+"/members/all/"+strconv.FormatInt(id, 10), nil, nil, &member)
+	if isGitLabNotFound(err) {
+		return "none", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get permission level: %w", err)
+	}
+
+	switch {
+	case member.AccessLevel >= 50: // Owner
+		return "admin", nil
+	case member.AccessLevel >= 40: // Maintainer
+		return "maintain", nil
+	case member.AccessLevel >= 30: // Developer
+		return "write", nil
+	case member.AccessLevel >= 20: // Reporter
+		return "triage", nil
+	case member.AccessLevel > 0: // Guest
+		return "read", nil
+	}
+	return "none", nil
+}
+
+// GetRepository retrieves project information, with its most used language
+func (gl *GitLabClient) GetRepository(owner, repo string) (*github.Repository, error) {
+	var project glProject
+	if _, err := gl.do(http.MethodGet, gitLabProjectPath(owner, repo), nil, nil, &project); err != nil {
+		return nil, fmt.Errorf("failed to get repository: %w", err)
+	}
+
+	repository := &github.Repository{
+		Name:          github.String(project.Name),
+		FullName:      github.String(project.PathWithNamespace),
+		DefaultBranch: github.String(project.DefaultBranch),
+		HTMLURL:       github.String(project.WebURL),
+	}
+
+	var languages map[string]float64
+	if _, err := gl.do(http.MethodGet, gitLabProjectPath(owner, repo)+"/languages", nil, nil, &languages); err == nil {
+		var top float64
+		for language, share := range languages {
+			if share > top {
+				top = share
+				repository.Language = github.String(language)
+			}
+		}
+	}
+	return repository, nil
+}
+
+// GetFileContent retrieves the content of a file; an empty ref means the default branch
+func (gl *GitLabClient) GetFileContent(owner, repo, path, ref string) (string, error) {
+	if ref == "" {
+		repository, err := gl.GetRepository(owner, repo)
+		if err != nil {
+			return "", err
+		}
+		ref = repository.GetDefaultBranch()
+	}
+
+	var file struct {
+		Content string `json:"content"`
+	}
+	_, err := gl.do(http.MethodGet, gitLabProjectPath(owner, repo)+"/repository/files/"+url.PathEscape(path), url.Values{"ref": {ref}}, nil, &file)
+	if err != nil {
+		return "", fmt.Errorf("failed to get file content: %w", err)
+	}
+
+	content, err := base64.StdEncoding.DecodeString(file.Content)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode file content: %w", err)
+	}
+	return string(content), nil
+}
+
+// DeleteBranch deletes a branch from the project
+func (gl *GitLabClient) DeleteBranch(owner, repo, branch string) error {
+	if _, err := gl.do(http.MethodDelete, gitLabProjectPath(owner, repo)+"/repository/branches/"+url.PathEscape(branch), nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete branch: %w", err)
+	}
+	return nil
+}
+
+// DownloadImage downloads an image attachment and returns it as a data URL. The token
+// is only sent to the GitLab instance itself.
+func (gl *GitLabClient) DownloadImage(imageURL string) (ImageAttachment, error) {
+	return fetchImage(gl.ctx, gl.http, imageURL, func(req *http.Request) {
+		if base, err := url.Parse(gl.baseURL); err == nil && req.URL.Host == base.Host {
+			req.Header.Set("PRIVATE-TOKEN", gl.token)
+		}
+	})
+}
+
+// gitLabIssuePath returns the API path of an issue
+func gitLabIssuePath(owner, repo string, number int) string {
+	return gitLabProjectPath(owner, repo) + "/issues/" + strconv.Itoa(number)
+}
+
+// gitLabMergeRequestPath returns the API path of a merge request
+func gitLabMergeRequestPath(owner, repo string, number int) string {
+	return gitLabProjectPath(owner, repo) + "/merge_requests/" + strconv.Itoa(number)
+}
+
+// GetIssue retrieves an issue by its project-scoped number
+func (gl *GitLabClient) GetIssue(owner, repo string, number int) (*github.Issue, error) {
+	var issue glIssue
+	if _, err := gl.do(http.MethodGet, gitLabIssuePath(owner, repo, number), nil, nil, &issue); err != nil {
+		return nil, fmt.Errorf("failed to get issue: %w", err)
+	}
+	return issue.toGitHub(), nil
+}
+
+// listIssues retrieves open issues matching query
+func (gl *GitLabClient) listIssues(owner, repo string, query url.Values) ([]*github.Issue, error) {
+	query.Set("state", "opened")
+	issues, err := gitLabListAll[glIssue](gl, gitLabProjectPath(owner, repo)+"/issues", query)
+	if err != nil {
+		return nil, err
+	}
+
+	converted := make([]*github.Issue, 0, len(issues))
+	for _, issue := range issues {
+		converted = append(converted, issue.toGitHub())
+	}
+	return converted, nil
+}
+
+// ListRepositoryIssues retrieves open issues assigned to a user, newest first
+func (gl *GitLabClient) ListRepositoryIssues(owner, repo, assignee string) ([]*github.Issue, error) {
+	issues, err := gl.listIssues(owner, repo, url.Values{
+		"assignee_username": {assignee},
+		"order_by":          {"created_at"},
+		"sort":              {"desc"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repository issues: %w", err)
+	}
+	return issues, nil
+}
+
+// ListOpenIssues retrieves a project's open issues, newest first
+func (gl *GitLabClient) ListOpenIssues(owner, repo string) ([]*github.Issue, error) {
+	issues, err := gl.listIssues(owner, repo, url.Values{
+		"order_by": {"created_at"},
+		"sort":     {"desc"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open issues: %w", err)
+	}
+	return issues, nil
+}
+
+// ListLabels returns the names of a project's labels
+func (gl *GitLabClient) ListLabels(owner, repo string) ([]string, error) {
+	labels, err := gitLabListAll[struct {
+		Name string `json:"name"`
+	}](gl, gitLabProjectPath(owner, repo)+"/labels", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list labels: %w", err)
+	}
+	names := make([]string, 0, len(labels))
+	for _, label := range labels {
+		names = append(names, label.Name)
+	}
+	return names, nil
+}
+
+// ListLabeledIssues retrieves open issues that carry a label
+func (gl *GitLabClient) ListLabeledIssues(owner, repo, label string) ([]*github.Issue, error) {
+	issues, err := gl.listIssues(owner, repo, url.Values{"labels": {label}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list labeled issues: %w", err)
+	}
+	return issues, nil
+}
+
+// ListMentioningIssues returns open issues updated after since. GitLab can't filter by
+// mention, so callers must check the issue and its comments for the mention themselves.
+func (gl *GitLabClient) ListMentioningIssues(owner, repo, user string, since time.Time) ([]*github.Issue, error) {
+	issues, err := gl.listIssues(owner, repo, url.Values{"updated_after": {since.UTC().Format(time.RFC3339)}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mentioning issues: %w", err)
+	}
+	return issues, nil
+}
+
+// setAssignees replaces an issue's assignees with the result of update
+func (gl *GitLabClient) setAssignees(owner, repo string, number int, update func(ids []int64) []int64) error {
+	var issue glIssue
+	if _, err := gl.do(http.MethodGet, gitLabIssuePath(owner, repo, number), nil, nil, &issue); err != nil {
+		return err
+	}
+	ids := make([]int64, 0, len(issue.Assignees))
+	for _, assignee := range issue.Assignees {
+		ids = append(ids, assignee.ID)
+	}
+
+	ids = update(ids)
+	if len(ids) == 0 {
+		ids = []int64{0} // GitLab unassigns everyone when given 0
+	}
+	_, err := gl.do(http.MethodPut, gitLabIssuePath(owner, repo, number), nil, map[string]any{"assignee_ids": ids}, nil)
+	return err
+}
+
+// AddAssignee assigns a user to an issue
+func (gl *GitLabClient) AddAssignee(owner, repo string, number int, assignee string) error {
+	id, err := gl.userID(assignee)
+	if err != nil {
+		return fmt.Errorf("failed to add assignee: %w", err)
+	}
+	err = gl.setAssignees(owner, repo, number, func(ids []int64) []int64 {
+		for _, existing := range ids {
+			if existing == id {
+				return ids
+			}
+		}
+		return append(ids, id)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add assignee: %w", err)
+	}
+	return nil
+}
+
+// RemoveAssignee removes a user from an issue's assignees
+func (gl *GitLabClient) RemoveAssignee(owner, repo string, number int, assignee string) error {
+	id, err := gl.userID(assignee)
+	if err != nil {
+		return fmt.Errorf("failed to remove assignee: %w", err)
+	}
+	err = gl.setAssignees(owner, repo, number, func(ids []int64) []int64 {
+		kept := ids[:0]
+		for _, existing := range ids {
+			if existing != id {
+				kept = append(kept, existing)
+			}
+		}
+		return kept
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove assignee: %w", err)
+	}
+	return nil
+}
+
+// AddLabels adds labels to an issue, creating any the project doesn't have
+func (gl *GitLabClient) AddLabels(owner, repo string, number int, labels []string) error {
+	if _, err := gl.do(http.MethodPut, gitLabIssuePath(owner, repo, number), nil, map[string]string{"add_labels": strings.Join(labels, ",")}, nil); err != nil {
+		return fmt.Errorf("failed to add labels: %w", err)
+	}
+	return nil
+}
+
+// CreateIssue opens an issue
+func (gl *GitLabClient) CreateIssue(owner, repo, title, body string) (*github.Issue, error) {
+	var issue glIssue
+	if _, err := gl.do(http.MethodPost, gitLabProjectPath(owner, repo)+"/issues", nil, map[string]string{"title": title, "description": body}, &issue); err != nil {
+		return nil, fmt.Errorf("failed to create issue: %w", err)
+	}
+	return issue.toGitHub(), nil
+}
+
+// CloseIssue closes an issue
+func (gl *GitLabClient) CloseIssue(owner, repo string, number int) error {
+	if _, err := gl.do(http.MethodPut, gitLabIssuePath(owner, repo, number), nil, map[string]string{"state_event": "close"}, nil); err != nil {
+		return fmt.Errorf("failed to close issue: %w", err)
+	}
+	return nil
+}
+
+// listNotes retrieves the user comments on an issue or merge request, oldest first,
+// skipping GitLab's system notes ("changed the description", "added label" ...)
+func (gl *GitLabClient) listNotes(path string) ([]glNote, error) {
+	notes, err := gitLabListAll[glNote](gl, path+"/notes", url.Values{"order_by": {"created_at"}, "sort": {"asc"}})
+	if err != nil {
+		return nil, err
+	}
+	comments := notes[:0]
+	for _, note := range notes {
+		if !note.System {
+			comments = append(comments, note)
+		}
+	}
+	return comments, nil
+}
+
+// ListIssueComments retrieves all comments for an issue
+func (gl *GitLabClient) ListIssueComments(owner, repo string, number int) ([]*github.IssueComment, error) {
+	notes, err := gl.listNotes(gitLabIssuePath(owner, repo, number))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+	comments := make([]*github.IssueComment, 0, len(notes))
+	for _, note := range notes {
+		comments = append(comments, note.toIssueComment())
+	}
+	return comments, nil
+}
+
+// CreateIssueComment adds a comment to an issue
+func (gl *GitLabClient) CreateIssueComment(owner, repo string, number int, body string) error {
+	_, err := gl.CreateIssueCommentWithID(owner, repo, number, body)
+	return err
+}
+
+// CreateIssueCommentWithID adds a comment to an issue and returns its ID so it can be edited later
+func (gl *GitLabClient) CreateIssueCommentWithID(owner, repo string, number int, body string) (int64, error) {
+	var note glNote
+	if _, err := gl.do(http.MethodPost, gitLabIssuePath(owner, repo, number)+"/notes", nil, map[string]string{"body": body}, &note); err != nil {
+		return 0, fmt.Errorf("failed to create comment: %w", err)
+	}
+	return note.ID, nil
+}
+
+// EditIssueComment replaces the body of an existing issue comment
+func (gl *GitLabClient) EditIssueComment(owner, repo string, number int, commentID int64, body string) error {
+	path := gitLabIssuePath(owner, repo, number) + "/notes/" + strconv.FormatInt(commentID, 10)
+	if _, err := gl.do(http.MethodPut, path, nil, map[string]string{"body": body}, nil); err != nil {
+		return fmt.Errorf("failed to edit comment: %w", err)
+	}
+	return nil
+}
+
+// DeleteIssueComment removes an issue comment
+func (gl *GitLabClient) DeleteIssueComment(owner, repo string, number int, commentID int64) error {
+	path := gitLabIssuePath(owner, repo, number) + "/notes/" + strconv.FormatInt(commentID, 10)
+	if _, err := gl.do(http.MethodDelete, path, nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+	return nil
+}
+
+// ListIssueCommentReactions retrieves the emoji awarded to an issue comment, named as
+// GitHub reactions ("thumbsup" becomes "+1")
+func (gl *GitLabClient) ListIssueCommentReactions(owner, repo string, number int, commentID int64) ([]*github.Reaction, error) {
+	type award struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+		User glUser `json:"user"`
+	}
+	path := gitLabIssuePath(owner, repo, number) + "/notes/" + strconv.FormatInt(commentID, 10) + "/award_emoji"
+	awards, err := gitLabListAll[award](gl, path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reactions: %w", err)
+	}
+
+	reactions := make([]*github.Reaction, 0, len(awards))
+	for _, a := range awards {
+		content := a.Name
+		switch a.Name {
+		case "thumbsup":
+			content = "+1"
+		case "thumbsdown":
+			content = "-1"
+		}
+		reactions = append(reactions, &github.Reaction{ID: github.Int64(a.ID), Content: github.String(content), User: a.User.toGitHub()})
+	}
+	return reactions, nil
+}
+
+// CreatePullRequest opens a merge request from head into base
+func (gl *GitLabClient) CreatePullRequest(owner, repo, title, body, head, base string, draft bool) (*github.PullRequest, error) {
+	if draft {
+		title = gitLabDraftPrefix + title
+	}
+	var mr glMergeRequest
+	_, err := gl.do(http.MethodPost, gitLabProjectPath(owner, repo)+"/merge_requests", nil, map[string]string{
+		"title":         title,
+		"description":   body,
+		"source_branch": head,
+		"target_branch": base,
+	}, &mr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pull request: %w", err)
+	}
+	return mr.toGitHub(), nil
+}
+
+// MarkPullRequestReady takes a merge request out of draft by removing the draft prefix
+// from its title
+func (gl *GitLabClient) MarkPullRequestReady(owner, repo string, pr *github.PullRequest) error {
+	title := strings.TrimPrefix(pr.GetTitle(), gitLabDraftPrefix)
+	if _, err := gl.do(http.MethodPut, gitLabMergeRequestPath(owner, repo, pr.GetNumber()), nil, map[string]string{"title": title}, nil); err != nil {
+		return fmt.Errorf("failed to mark pull request ready: %w", err)
+	}
+	return nil
+}
+
+// ClosePullRequest closes a merge request without merging it
+func (gl *GitLabClient) ClosePullRequest(owner, repo string, number int) error {
+	if _, err := gl.do(http.MethodPut, gitLabMergeRequestPath(owner, repo, number), nil, map[string]string{"state_event": "close"}, nil); err != nil {
+		return fmt.Errorf("failed to close pull request: %w", err)
+	}
+	return nil
+}
+
+// SetPullRequestMetadata adds labels, assignees and reviewers to a merge request and sets
+// its milestone. GitLab has no team reviewers, so those are skipped.
+func (gl *GitLabClient) SetPullRequestMetadata(owner, repo string, number int, metadata PullRequestMetadata) error {
+	var errs []error
+	update := map[string]any{}
+	if len(metadata.Labels) > 0 {
+		update["add_labels"] = strings.Join(metadata.Labels, ",")
+	}
+	if len(metadata.Assignees) > 0 || len(metadata.Reviewers) > 0 {
+		var mr struct {
+			Assignees []glUser `json:"assignees"`
+			Reviewers []glUser `json:"reviewers"`
+		}
+		if _, err := gl.do(http.MethodGet, gitLabMergeRequestPath(owner, repo, number), nil, nil, &mr); err != nil {
+			errs = append(errs, fmt.Errorf("failed to get pull request: %w", err))
+		} else {
+			if ids, err := gl.addUserIDs(mr.Assignees, metadata.Assignees); err != nil {
+				errs = append(errs, fmt.Errorf("failed to add assignees: %w", err))
+			} else if len(metadata.Assignees) > 0 {
+				update["assignee_ids"] = ids
+			}
+			if ids, err := gl.addUserIDs(mr.Reviewers, metadata.Reviewers); err != nil {
+				errs = append(errs, fmt.Errorf("failed to request reviewers: %w", err))
+			} else if len(metadata.Reviewers) > 0 {
+				update["reviewer_ids"] = ids
+			}
+		}
+	}
+	if metadata.Milestone != "" {
+		milestones, err := gitLabListAll[struct {
+			ID int64 `json:"id"`
+		}](gl, gitLabProjectPath(owner, repo)+"/milestones", url.Values{"title": {metadata.Milestone}, "state": {"active"}})
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("failed to list milestones: %w", err))
+		case len(milestones) == 0:
+			errs = append(errs, fmt.Errorf("milestone not found: %s", metadata.Milestone))
+		default:
+			update["milestone_id"] = milestones[0].ID
+		}
+	}
+
+	if len(update) > 0 {
+		if _, err := gl.do(http.MethodPut, gitLabMergeRequestPath(owner, repo, number), nil, update, nil); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update pull request: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// addUserIDs returns the IDs of existing users plus those of usernames
+func (gl *GitLabClient) addUserIDs(existing []glUser, usernames []string) ([]int64, error) {
+	ids := make([]int64, 0, len(existing)+len(usernames))
+	for _, user := range existing {
+		ids = append(ids, user.ID)
+	}
+	for _, username := range usernames {
+		id, err := gl.userID(username)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// GetPullRequest retrieves a merge request
+func (gl *GitLabClient) GetPullRequest(owner, repo string, number int) (*github.PullRequest, error) {
+	var mr glMergeRequest
+	if _, err := gl.do(http.MethodGet, gitLabMergeRequestPath(owner, repo, number), nil, nil, &mr); err != nil {
+		return nil, fmt.Errorf("failed to get pull request: %w", err)
+	}
+	return mr.toGitHub(), nil
+}
+
+// ListOpenPullRequests retrieves a project's open merge requests
+func (gl *GitLabClient) ListOpenPullRequests(owner, repo string) ([]*github.PullRequest, error) {
+	mrs, err := gitLabListAll[glMergeRequest](gl, gitLabProjectPath(owner, repo)+"/merge_requests", url.Values{"state": {"opened"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+	prs := make([]*github.PullRequest, 0, len(mrs))
+	for _, mr := range mrs {
+		prs = append(prs, mr.toGitHub())
+	}
+	return prs, nil
+}
+
+// GetPullRequestDiff assembles a unified diff from a merge request's per-file diffs
+func (gl *GitLabClient) GetPullRequestDiff(owner, repo string, number int) (string, error) {
+	type diff struct {
+		OldPath     string `json:"old_path"`
+		NewPath     string `json:"new_path"`
+		Diff        string `json:"diff"`
+		NewFile     bool   `json:"new_file"`
+		DeletedFile bool   `json:"deleted_file"`
+	}
+	diffs, err := gitLabListAll[diff](gl, gitLabMergeRequestPath(owner, repo, number)+"/diffs", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get pull request diff: %w", err)
+	}
+
+	var b strings.Builder
+	for _, d := range diffs {
+		oldPath, newPath := "a/"+d.OldPath, "b/"+d.NewPath
+		if d.NewFile {
+			oldPath = "/dev/null"
+		}
+		if d.DeletedFile {
+			newPath = "/dev/null"
+		}
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\n--- %s\n+++ %s\n%s", d.OldPath, d.NewPath, oldPath, newPath, d.Diff)
+		if !strings.HasSuffix(d.Diff, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// CreateReview starts a discussion on each commented line and adds the summary as a note.
+// Comments GitLab can't place on the diff are folded into the summary.
+func (gl *GitLabClient) CreateReview(owner, repo string, pr *github.PullRequest, body string, comments []ReviewComment) error {
+	path := gitLabMergeRequestPath(owner, repo, pr.GetNumber())
+	var mr glMergeRequest
+	if _, err := gl.do(http.MethodGet, path, nil, nil, &mr); err != nil {
+		return fmt.Errorf("failed to create review: %w", err)
+	}
+
+	var unplaced []string
+	for _, comment := range comments {
+		discussion := map[string]any{
+			"body": comment.Body,
+			"position": map[string]any{
+				"position_type": "text",
+				"base_sha":      mr.DiffRefs.BaseSHA,
+				"start_sha":     mr.DiffRefs.StartSHA,
+				"head_sha":      mr.DiffRefs.HeadSHA,
+				"new_path":      comment.Path,
+				"new_line":      comment.Line,
+			},
+		}
+		if _, err := gl.do(http.MethodPost, path+"/discussions", nil, discussion, nil); err != nil {
+			slog.Debug("Failed to place review comment", "path", comment.Path, "line", comment.Line, "error", err)
+			unplaced = append(unplaced, fmt.Sprintf("**%s:%d** %s", comment.Path, comment.Line, comment.Body))
+		}
+	}
+	if len(unplaced) > 0 {
+		body += "\n\n" + strings.Join(unplaced, "\n\n")
+	}
+
+	if _, err := gl.do(http.MethodPost, path+"/notes", nil, map[string]string{"body": body}, nil); err != nil {
+		return fmt.Errorf("failed to create review: %w", err)
+	}
+	return nil
+}
+
+// CreatePullRequestComment adds a comment to a merge request
+func (gl *GitLabClient) CreatePullRequestComment(owner, repo string, number int, body string) error {
+	if _, err := gl.do(http.MethodPost, gitLabMergeRequestPath(owner, repo, number)+"/notes", nil, map[string]string{"body": body}, nil); err != nil {
+		return fmt.Errorf("failed to create comment: %w", err)
+	}
+	return nil
+}
+
+// ListPRComments retrieves all comments on a merge request, including diff comments
+func (gl *GitLabClient) ListPRComments(owner, repo string, number int) ([]*github.PullRequestComment, error) {
+	notes, err := gl.listNotes(gitLabMergeRequestPath(owner, repo, number))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PR comments: %w", err)
+	}
+	comments := make([]*github.PullRequestComment, 0, len(notes))
+	for _, note := range notes {
+		comments = append(comments, &github.PullRequestComment{
+			ID:        github.Int64(note.ID),
+			Body:      github.String(note.Body),
+			User:      note.Author.toGitHub(),
+			CreatedAt: &github.Timestamp{Time: note.CreatedAt},
+			UpdatedAt: &github.Timestamp{Time: note.UpdatedAt},
+		})
+	}
+	return comments, nil
+}
+
+// ListReviews returns no reviews: GitLab has no review summaries, and review comments
+// are notes already returned by ListPRComments
+func (gl *GitLabClient) ListReviews(owner, repo string, number int) ([]*github.PullRequestReview, error) {
+	return nil, nil
+}
+
+// ListPullRequestFiles returns the paths of the files changed by a merge request
+func (gl *GitLabClient) ListPullRequestFiles(owner, repo string, number int) ([]string, error) {
+	type diff struct {
+		OldPath string `json:"old_path"`
+		NewPath string `json:"new_path"`
+	}
+	diffs, err := gitLabListAll[diff](gl, gitLabMergeRequestPath(owner, repo, number)+"/diffs", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PR files: %w", err)
+	}
+
+	var paths []string
+	for _, d := range diffs {
+		paths = append(paths, d.NewPath)
+		if d.OldPath != d.NewPath {
+			paths = append(paths, d.OldPath)
+		}
+	}
+	return paths, nil
+}
+
+// CountApprovals returns how many users approve a merge request. GitLab has no
+// "request changes" review state, so changesRequested is always false.
+func (gl *GitLabClient) CountApprovals(owner, repo string, number int) (approvals int, changesRequested bool, err error) {
+	var result struct {
+		ApprovedBy []struct {
+			User glUser `json:"user"`
+		} `json:"approved_by"`
+	}
+	if _, err := gl.do(http.MethodGet, gitLabMergeRequestPath(owner, repo, number)+"/approvals", nil, nil, &result); err != nil {
+		return 0, false, fmt.Errorf("failed to list approvals: %w", err)
+	}
+	return len(result.ApprovedBy), false, nil
+}
+
+// ChecksPassed reports whether the latest pipeline for ref succeeded. pending is true
+// while it is still running. A ref without pipelines counts as passed.
+func (gl *GitLabClient) ChecksPassed(owner, repo, ref string) (passed, pending bool, err error) {
+	var pipelines []struct {
+		Status string `json:"status"`
+	}
+	query := url.Values{"sha": {ref}, "order_by": {"id"}, "sort": {"desc"}, "per_page": {"1"}}
+	if _, err := gl.do(http.MethodGet, gitLabProjectPath(owner, repo)+"/pipelines", query, nil, &pipelines); err != nil {
+		return false, false, fmt.Errorf("failed to list pipelines: %w", err)
+	}
+	if len(pipelines) == 0 {
+		return true, false, nil
+	}
+
+	switch pipelines[0].Status {
+	case "success", "skipped", "manual":
+		return true, false, nil
+	case "failed", "canceled":
+		return false, false, nil
+	}
+	return false, true, nil
+}
+
+// ListFailedChecks returns the failed jobs of the latest pipeline for ref, with their logs.
+// Jobs allowed to fail are left out.
+func (gl *GitLabClient) ListFailedChecks(owner, repo, ref string) ([]FailedCheck, error) {
+	var pipelines []struct {
+		ID int64 `json:"id"`
+	}
+	query := url.Values{"sha": {ref}, "order_by": {"id"}, "sort": {"desc"}, "per_page": {"1"}}
+	if _, err := gl.do(http.MethodGet, gitLabProjectPath(owner, repo)+"/pipelines", query, nil, &pipelines); err != nil {
+		return nil, fmt.Errorf("failed to list pipelines: %w", err)
+	}
+	if len(pipelines) == 0 {
+		return nil, nil
+	}
+
+	type job struct {
+		ID           int64  `json:"id"`
+		Name         string `json:"name"`
+		Stage        string `json:"stage"`
+		AllowFailure bool   `json:"allow_failure"`
+	}
+	path := gitLabProjectPath(owner, repo) + "/pipelines/" + strconv.FormatInt(pipelines[0].ID, 10) + "/jobs"
+	jobs, err := gitLabListAll[job](gl, path, url.Values{"scope[]": {"failed"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pipeline jobs: %w", err)
+	}
+
+	var failed []FailedCheck
+	for _, j := range jobs {
+		if j.AllowFailure {
+			continue
+		}
+		var log string
+		if _, err := gl.do(http.MethodGet, gitLabProjectPath(owner, repo)+"/jobs/"+strconv.FormatInt(j.ID, 10)+"/trace", nil, nil, &log); err != nil {
+			slog.Debug("Couldn't download job log", "job", j.Name, "error", err)
+		}
+		failed = append(failed, FailedCheck{Name: j.Stage + ": " + j.Name, Log: log})
+	}
+	return failed, nil
+}
+
+// MergePullRequest merges a merge request. "squash" squashes the commits; "merge" and
+// "rebase" both use the project's configured merge method.
+func (gl *GitLabClient) MergePullRequest(owner, repo string, number int, method, sha string) error {
+	body := map[string]any{"sha": sha, "squash": method == "squash"}
+	if _, err := gl.do(http.MethodPut, gitLabMergeRequestPath(owner, repo, number)+"/merge", nil, body, nil); err != nil {
+		return fmt.Errorf("failed to merge pull request: %w", err)
+	}
+	return nil
+}
+
+// EnableAutoMerge sets a merge request to merge when its pipeline succeeds
+func (gl *GitLabClient) EnableAutoMerge(owner, repo string, pr *github.PullRequest, method string) error {
+	body := map[string]any{
+		"merge_when_pipeline_succeeds": true,
+		"squash":                       method == "squash",
+		"sha":                          pr.GetHead().GetSHA(),
+	}
+	if _, err := gl.do(http.MethodPut, gitLabMergeRequestPath(owner, repo, pr.GetNumber())+"/merge", nil, body, nil); err != nil {
+		return fmt.Errorf("failed to enable auto-merge: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// healthCheckKey is the setting rewritten to check the state database accepts writes
+const healthCheckKey = "health_checked_at"
+
+// CheckWritable reports an error if the state database can't be written to
+func (sm *StateManager) CheckWritable() error {
+	return sm.SetSetting(healthCheckKey, time.Now().UTC().Format(time.RFC3339))
+}
+
+// CheckReachable reports an error if the LLM provider's API can't be reached. Any HTTP
+// response counts, since the check sends no credentials.
+func (ca *ClaudeAgent) CheckReachable(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, ca.provider.Endpoint(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s API unreachable: %w", ca.provider.Name(), err)
+	}
+	resp.Body.Close()
+	return nil
+}
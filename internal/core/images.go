@@ -0,0 +1,87 @@
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// ImageAttachment is an image passed to a vision-capable model
+type ImageAttachment struct {
+	URL     string // Original URL the image was downloaded from
+	DataURL string // Base64 data URL sent to the model
+}
+
+var (
+	markdownImageRe = regexp.MustCompile(`!\[[^\]]*\]\(\s*<?(https?://[^\s)>]+)>?(?:\s+"[^"]*")?\s*\)`)
+	htmlImageRe     = regexp.MustCompile(`(?i)<img[^>]+src\s*=\s*["'](https?://[^"']+)["']`)
+)
+
+// ExtractImageURLs returns the unique image URLs referenced in a markdown body,
+// covering both ![alt](url) syntax and <img src="url"> tags
+func ExtractImageURLs(body string) []string {
+	var urls []string
+	seen := make(map[string]bool)
+
+	for _, re := range []*regexp.Regexp{markdownImageRe, htmlImageRe} {
+		for _, match := range re.FindAllStringSubmatch(body, -1) {
+			url := strings.TrimSpace(match[1])
+			if url == "" || seen[url] {
+				continue
+			}
+			seen[url] = true
+			urls = append(urls, url)
+		}
+	}
+
+	return urls
+}
+
+// maxImageSize caps the size of downloaded image attachments
+const maxImageSize = 5 * 1024 * 1024
+
+// fetchImage downloads an image and returns it as a data URL. authorize adds the code
+// host's credentials to the request; it must only do so for the host's own URLs so
+// tokens never leak to third-party image hosts.
+func fetchImage(ctx context.Context, client *http.Client, imageURL string, authorize func(req *http.Request)) (ImageAttachment, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return ImageAttachment{}, fmt.Errorf("failed to create image request: %w", err)
+	}
+	authorize(req)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return ImageAttachment{}, fmt.Errorf("failed to download image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ImageAttachment{}, fmt.Errorf("failed to download image: status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return ImageAttachment{}, fmt.Errorf("failed to read image: %w", err)
+	}
+	if len(data) > maxImageSize {
+		return ImageAttachment{}, fmt.Errorf("image exceeds %d bytes", maxImageSize)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		contentType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(contentType, "image/") {
+		return ImageAttachment{}, fmt.Errorf("not an image: %s", contentType)
+	}
+
+	return ImageAttachment{
+		URL:     imageURL,
+		DataURL: "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data),
+	}, nil
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// filesPlaceholder is replaced with the changed source files in format and lint commands
+const filesPlaceholder = "{files}"
+
+// javascriptExtensions are the source files of JavaScript and TypeScript projects
+var javascriptExtensions = []string{".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx"}
+
+// Format runs the language's formatter on the changed source files. Formatting is best
+// effort: a formatter that isn't installed is skipped and one that fails is only logged.
+func (s *Sandbox) Format() {
+	targets, err := s.targets()
+	if err != nil {
+		s.Logger().Warn("⚠️  Not formatting changes", "error", err)
+		return
+	}
+	for _, target := range targets {
+		command := s.toolCommand(target, target.builder.FormatCommand)
+		if command == nil {
+			continue
+		}
+		s.Logger().Info("🎨 Formatting changes", "language", target.language, "dir", target.dir)
+		if output, err := s.runCommandIn(target.dir, command[0], command[1:]...); err != nil {
+			s.Logger().Warn("⚠️  Formatter failed", "command", command[0], "error", err, "output", output)
+		}
+	}
+}
+
+// Lint runs the language's linter on the changed source files and returns its output,
+// with an error when it reports problems. Linters that aren't installed are skipped.
+func (s *Sandbox) Lint() (string, error) {
+	targets, err := s.targets()
+	if err != nil {
+		return "", err
+	}
+
+	var outputs []string
+	var lintErr error
+	for _, target := range targets {
+		command := s.toolCommand(target, target.builder.LintCommand)
+		if command == nil {
+			continue
+		}
+		s.Logger().Info("🧹 Linting changes", "language", target.language, "dir", target.dir)
+		output, err := s.runTarget(target, nil, command)
+		outputs = append(outputs, output)
+		if err != nil && lintErr == nil {
+			lintErr = fmt.Errorf("lint failed%s: %w", target.where(), err)
+		}
+	}
+	return strings.Join(outputs, "\n"), lintErr
+}
+
+// toolCommand returns a format or lint command for a target with its {files} argument
+// filled in, or nil when there's nothing for it to check or the tool isn't installed
+func (s *Sandbox) toolCommand(target verifyTarget, command []string) []string {
+	if len(command) == 0 || !s.toolInstalled(target.dir, command) {
+		return nil
+	}
+	i := slices.Index(command, filesPlaceholder)
+	if i < 0 {
+		return command
+	}
+
+	files := s.changedSources(target)
+	if len(files) == 0 {
+		return nil
+	}
+	return slices.Concat(command[:i], files, command[i+1:])
+}
+
+// changedSources lists the target's changed source files that still exist, relative to
+// the target's directory
+func (s *Sandbox) changedSources(target verifyTarget) []string {
+	changed, err := s.ChangedFiles()
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, file := range changed {
+		file = filepath.ToSlash(file)
+		if !slices.Contains(target.builder.Extensions, path.Ext(file)) || !fileExists(filepath.Join(s.repoPath, filepath.FromSlash(file))) {
+			continue
+		}
+		if target.dir != "." {
+			relative, ok := strings.CutPrefix(file, target.dir+"/")
+			if !ok {
+				continue
+			}
+			file = relative
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// toolInstalled reports whether a command's program is available. Tools run through npx
+// must be installed in the project, since npx would otherwise try to download them.
+func (s *Sandbox) toolInstalled(dir string, command []string) bool {
+	if command[0] != "npx" {
+		_, err := exec.LookPath(command[0])
+		return err == nil
+	}
+	for _, arg := range command[1:] {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		for _, root := range []string{dir, "."} {
+			if fileExists(filepath.Join(s.repoPath, filepath.FromSlash(root), "node_modules", ".bin", arg)) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
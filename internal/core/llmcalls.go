@@ -0,0 +1,161 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PurposeEmbedding marks embedding requests in the call log; completions are recorded
+// with their workflow stage as the purpose
+const PurposeEmbedding = "embedding"
+
+// maxCallErrorLength caps the error text kept for a failed call
+const maxCallErrorLength = 500
+
+// LLMCall is a single request to the LLM provider
+type LLMCall struct {
+	Owner           string
+	Repo            string
+	Number          int // Issue or pull request the call was made for; 0 when unattributed
+	Provider        string
+	Model           string
+	Purpose         string // Workflow stage ("analysis", "codegen", "review", "chat") or "embedding"
+	InputTokens     int64
+	OutputTokens    int64
+	ReasoningTokens int64
+	Cost            float64
+	Latency         time.Duration
+	Success         bool
+	Error           string
+	CreatedAt       time.Time
+}
+
+// CallRecorder stores the calls an agent makes
+type CallRecorder interface {
+	RecordLLMCall(call LLMCall) error
+}
+
+// issueRef identifies the issue or pull request work is done for
+type issueRef struct {
+	owner, repo string
+	number      int
+}
+
+// SetCallRecorder records every request the agent makes, successful or not, with recorder
+func (ca *ClaudeAgent) SetCallRecorder(recorder CallRecorder) {
+	ca.calls = recorder
+}
+
+// WithIssue returns a copy of the agent whose requests are attributed to an issue or pull request
+func (ca *ClaudeAgent) WithIssue(owner, repo string, number int) *ClaudeAgent {
+	clone := *ca
+	clone.callsFor = issueRef{owner: owner, repo: repo, number: number}
+	return &clone
+}
+
+// recordCall records one request to the provider. Failing to record it doesn't fail the request.
+func (ca *ClaudeAgent) recordCall(purpose, model string, usage TokenUsage, latency time.Duration, err error) {
+	if ca.calls == nil {
+		return
+	}
+	if usage.Model != "" {
+		model = usage.Model
+	}
+	call := LLMCall{
+		Owner:           ca.callsFor.owner,
+		Repo:            ca.callsFor.repo,
+		Number:          ca.callsFor.number,
+		Provider:        ca.provider.Name(),
+		Model:           model,
+		Purpose:         purpose,
+		InputTokens:     usage.InputTokens,
+		OutputTokens:    usage.OutputTokens,
+		ReasoningTokens: usage.ReasoningTokens,
+		Cost:            usage.Cost,
+		Latency:         latency,
+		Success:         err == nil,
+		CreatedAt:       time.Now(),
+	}
+	if err != nil {
+		call.Error = err.Error()
+		if len(call.Error) > maxCallErrorLength {
+			call.Error = strings.ToValidUTF8(call.Error[:maxCallErrorLength], "")
+		}
+	}
+	if recordErr := ca.calls.RecordLLMCall(call); recordErr != nil {
+		ca.log().Warn("⚠️  Failed to record LLM call", "error", recordErr)
+	}
+}
+
+// RecordLLMCall stores a request to the LLM provider in the call log
+func (sm *StateManager) RecordLLMCall(call LLMCall) error {
+	// Stored as an integer, which both databases accept
+	success := 0
+	if call.Success {
+		success = 1
+	}
+
+	_, err := sm.db.Exec(`
+		INSERT INTO llm_calls (owner, repo, number, provider, model, purpose, input_tokens, output_tokens,
+		                       reasoning_tokens, cost, latency_ms, success, error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, call.Owner, call.Repo, call.Number, call.Provider, call.Model, call.Purpose, call.InputTokens, call.OutputTokens,
+		call.ReasoningTokens, call.Cost, call.Latency.Milliseconds(), success, call.Error, call.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record LLM call: %w", err)
+	}
+	return nil
+}
+
+// CallBreakdown sums the recorded calls sharing a model, purpose or repository
+type CallBreakdown struct {
+	Key          string // The model, purpose or repository
+	Calls        int
+	Failures     int
+	InputTokens  int64
+	OutputTokens int64
+	Cost         float64
+	AvgLatency   time.Duration
+}
+
+// callGroupings are what calls can be grouped by, and the llm_calls expression for each
+var callGroupings = map[string]string{
+	"model":      "model",
+	"purpose":    "purpose",
+	"repository": "owner || '/' || repo",
+}
+
+// LLMCallBreakdown sums the recorded calls matching filter by "model", "purpose" or
+// "repository" ("owner/repo", or "/" for calls not made for an issue), most expensive first
+func (sm *StateManager) LLMCallBreakdown(groupBy string, filter StatsFilter) ([]CallBreakdown, error) {
+	column, ok := callGroupings[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("calls can't be grouped by %q", groupBy)
+	}
+	where, args := filter.where("created_at", "created_at")
+	rows, err := sm.db.Query(`
+		SELECT `+column+`, COUNT(*), SUM(CASE WHEN success = 0 THEN 1 ELSE 0 END),
+		       SUM(input_tokens), SUM(output_tokens), SUM(cost), AVG(latency_ms)
+		FROM llm_calls
+		WHERE `+where+`
+		GROUP BY `+column+`
+		ORDER BY SUM(cost) DESC, COUNT(*) DESC
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum LLM calls: %w", err)
+	}
+	defer rows.Close()
+
+	var breakdown []CallBreakdown
+	for rows.Next() {
+		var row CallBreakdown
+		var avgLatency float64
+		if err := rows.Scan(&row.Key, &row.Calls, &row.Failures, &row.InputTokens, &row.OutputTokens, &row.Cost, &avgLatency); err != nil {
+			return nil, fmt.Errorf("failed to scan LLM call sums: %w", err)
+		}
+		row.AvgLatency = time.Duration(avgLatency) * time.Millisecond
+		breakdown = append(breakdown, row)
+	}
+	return breakdown, rows.Err()
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultLocalBaseURL        = "http://localhost:11434/v1" // Ollama
+	defaultLocalModel          = "qwen2.5-coder"
+	defaultLocalEmbeddingModel = "nomic-embed-text"
+)
+
+// How a schema is requested from an OpenAI-compatible server
+const (
+	StructuredOutputSchema = "json_schema" // response_format with the JSON schema
+	StructuredOutputJSON   = "json_object" // JSON mode, with the schema given in the system prompt
+	StructuredOutputNone   = "none"        // Not requested; the markdown format is used instead
+)
+
+// LocalOptions configures a local server with an OpenAI-compatible API, such as Ollama,
+// llama.cpp or vLLM
+type LocalOptions struct {
+	BaseURL           string // API root (default: Ollama on localhost)
+	MergeSystemPrompt bool   // For models whose chat template has no system role
+	StructuredOutput  string // StructuredOutputSchema (default), StructuredOutputJSON or StructuredOutputNone
+}
+
+// NewLocalProvider creates a client for a local OpenAI-compatible server. The API key is
+// optional, since local servers usually don't check one.
+func NewLocalProvider(options LocalOptions, apiKey string) (LLMProvider, error) {
+	switch options.StructuredOutput {
+	case "", StructuredOutputSchema, StructuredOutputJSON, StructuredOutputNone:
+	default:
+		return nil, fmt.Errorf("unknown structured output mode %q (expected %q, %q or %q)",
+			options.StructuredOutput, StructuredOutputSchema, StructuredOutputJSON, StructuredOutputNone)
+	}
+
+	baseURL := strings.TrimSuffix(cmp.Or(options.BaseURL, defaultLocalBaseURL), "/")
+	return &openAIProvider{
+		name:                  "Local",
+		baseURL:               baseURL,
+		apiKey:                apiKey,
+		defaultModel:          defaultLocalModel,
+		defaultEmbeddingModel: defaultLocalEmbeddingModel,
+		legacyMaxTokens:       true,
+		mergeSystemPrompt:     options.MergeSystemPrompt,
+		structuredOutput:      options.StructuredOutput,
+		httpClient:            &http.Client{},
+	}, nil
+}
+
+// responseFormat returns the response format asking for JSON matching schema, if any, and
+// the system prompt to send with it
+func (p *openAIProvider) responseFormat(schema *jsonSchema, system string) (*responseFormat, string) {
+	if schema == nil {
+		return nil, system
+	}
+	switch p.structuredOutput {
+	case StructuredOutputNone:
+		return nil, system
+	case StructuredOutputJSON:
+		encoded, err := json.Marshal(schema.Schema)
+		if err != nil {
+			return nil, system
+		}
+		instructions := "Respond with only a JSON object matching this JSON schema, ignoring any other response format described above:\n\n" + string(encoded)
+		if system != "" {
+			instructions = system + "\n\n" + instructions
+		}
+		return &responseFormat{Type: "json_object"}, instructions
+	}
+	return &responseFormat{Type: "json_schema", JSONSchema: schema}, system
+}
+
+// mergeSystemPrompt prepends the system prompt to the first user message, for models
+// that reject or ignore a system role
+func mergeSystemPrompt(system string, messages []AgentMessage) []AgentMessage {
+	if system == "" {
+		return messages
+	}
+	merged := append([]AgentMessage(nil), messages...)
+	for i, msg := range merged {
+		if msg.Role == "user" {
+			merged[i].Content = system + "\n\n---\n\n" + msg.Content
+			return merged
+		}
+	}
+	return append([]AgentMessage{{Role: "user", Content: system}}, merged...)
+}
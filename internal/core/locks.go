@@ -0,0 +1,89 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// issueLock is an issue claimed by this agent: its issue_locks row stays locked until the
+// transaction holding it ends
+type issueLock struct {
+	conn  *sql.Conn
+	tx    *stateTx
+	count int // Claims by this agent that haven't been released yet
+}
+
+// TryLockIssue claims an issue so other agents sharing the database leave it alone until
+// release is called. ok is false when another agent holds the claim. Claims are per
+// agent, so nested claims on the same issue succeed. With SQLite there is only one agent
+// and every claim succeeds.
+func (sm *StateManager) TryLockIssue(owner, repo string, issueNumber int) (release func(), ok bool, err error) {
+	if !sm.db.dialect.shared() {
+		return func() {}, true, nil
+	}
+
+	key := fmt.Sprintf("%s/%s#%d", owner, repo, issueNumber)
+	sm.locksMu.Lock()
+	defer sm.locksMu.Unlock()
+
+	if lock := sm.locks[key]; lock != nil {
+		lock.count++
+		return sm.releaser(key), true, nil
+	}
+
+	if _, err := sm.db.Exec(`
+		INSERT INTO issue_locks (owner, repo, issue_number)
+		VALUES (?, ?, ?)
+		ON CONFLICT DO NOTHING
+	`, owner, repo, issueNumber); err != nil {
+		return nil, false, fmt.Errorf("failed to create issue lock: %w", err)
+	}
+
+	conn, tx, err := sm.db.beginConn(context.Background())
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	var locked int
+	err = tx.QueryRow(`
+		SELECT issue_number FROM issue_locks
+		WHERE owner = ? AND repo = ? AND issue_number = ?
+		FOR UPDATE SKIP LOCKED
+	`, owner, repo, issueNumber).Scan(&locked)
+	if err != nil {
+		tx.Rollback()
+		conn.Close()
+		if err == sql.ErrNoRows {
+			return func() {}, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to lock issue: %w", err)
+	}
+
+	if sm.locks == nil {
+		sm.locks = make(map[string]*issueLock)
+	}
+	sm.locks[key] = &issueLock{conn: conn, tx: tx, count: 1}
+	return sm.releaser(key), true, nil
+}
+
+// releaser returns the function releasing one claim on an issue. The lock is given up
+// when the last claim is released.
+func (sm *StateManager) releaser(key string) func() {
+	released := false
+	return func() {
+		sm.locksMu.Lock()
+		defer sm.locksMu.Unlock()
+		lock := sm.locks[key]
+		if released || lock == nil {
+			return
+		}
+		released = true
+		lock.count--
+		if lock.count > 0 {
+			return
+		}
+		delete(sm.locks, key)
+		lock.tx.Rollback()
+		lock.conn.Close()
+	}
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// SetupLogging installs the default structured logger. Level is "debug", "info" (default),
+// "warn" or "error"; format is "text" (default) or "json". Output from the standard log
+// package goes through the same logger.
+func SetupLogging(level, format string) error {
+	var lvl slog.Level
+	switch strings.ToLower(level) {
+	case "debug":
+		lvl = slog.LevelDebug
+	case "", "info":
+		lvl = slog.LevelInfo
+	case "warn", "warning":
+		lvl = slog.LevelWarn
+	case "error":
+		lvl = slog.LevelError
+	default:
+		return fmt.Errorf("unknown log level %q (expected debug, info, warn or error)", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "", "text":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("unknown log format %q (expected text or json)", format)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// IssueLogger returns a logger that tags every line with the issue it concerns, so the
+// work on one issue can be followed across repositories and interleaved goroutines
+func IssueLogger(owner, repo string, issueNumber int) *slog.Logger {
+	return slog.Default().With("owner", owner, "repo", repo, "issue", issueNumber)
+}
+
+// RepoLogger returns a logger that tags every line with a repository
+func RepoLogger(owner, repo string) *slog.Logger {
+	return slog.Default().With("owner", owner, "repo", repo)
+}
+
+// Logger returns a logger tagged with the state's issue
+func (s *State) Logger() *slog.Logger {
+	return IssueLogger(s.Owner, s.Repo, s.IssueNumber)
+}
+
+// loggerKey is the context key for a request-scoped logger
+type loggerKey struct{}
+
+// withLogger returns a context carrying logger, for code that only receives a context
+func withLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// loggerFrom returns the logger carried by ctx, or the default logger
+func loggerFrom(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
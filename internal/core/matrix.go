@@ -0,0 +1,106 @@
+package core
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// versionPlaceholder is replaced with the toolchain version in images and command prefixes
+const versionPlaceholder = "{version}"
+
+// defaultMatrixImages maps a language to the official container image used for each version
+var defaultMatrixImages = map[string]string{
+	"go":         "golang:{version}",
+	"python":     "python:{version}",
+	"javascript": "node:{version}",
+	"typescript": "node:{version}",
+	"rust":       "rust:{version}",
+	"java":       "maven:3-eclipse-temurin-{version}",
+}
+
+// MatrixConfig describes the toolchain versions verification runs against
+type MatrixConfig struct {
+	Versions []string
+	// Image is the container image template, e.g. "golang:{version}" (default: official image for the language)
+	Image string
+	// CommandPrefix runs commands through a version manager instead of a container,
+	// e.g. ["mise", "exec", "go@{version}", "--"]
+	CommandPrefix []string
+}
+
+// MatrixResult is the verification outcome for a single toolchain version
+type MatrixResult struct {
+	Version     string
+	BuildOutput string
+	TestOutput  string
+	Err         error
+}
+
+// VerifyMatrix runs build and test once per configured toolchain version
+func (s *Sandbox) VerifyMatrix(config MatrixConfig) ([]MatrixResult, error) {
+	targets, err := s.targets()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []MatrixResult
+	for _, version := range config.Versions {
+		prefix := func(language, dir string) ([]string, error) {
+			return s.matrixPrefix(config, language, version, dir)
+		}
+		// A version that can't be set up is a configuration problem, not a failed verification
+		for _, target := range targets {
+			if _, err := prefix(target.language, target.dir); err != nil {
+				return nil, err
+			}
+		}
+
+		s.Logger().Info("🧮 Verifying with toolchain version", "version", version)
+		buildOutput, testOutput, verifyErr := s.verify(prefix)
+		results = append(results, MatrixResult{
+			Version:     version,
+			BuildOutput: buildOutput,
+			TestOutput:  testOutput,
+			Err:         verifyErr,
+		})
+	}
+
+	return results, nil
+}
+
+// matrixPrefix builds the command prefix that runs a command in dir under the given toolchain version
+func (s *Sandbox) matrixPrefix(config MatrixConfig, language, version, dir string) ([]string, error) {
+	if len(config.CommandPrefix) > 0 {
+		prefix := make([]string, len(config.CommandPrefix))
+		for i, part := range config.CommandPrefix {
+			prefix[i] = strings.ReplaceAll(part, versionPlaceholder, version)
+		}
+		return prefix, nil
+	}
+
+	image := config.Image
+	if image == "" {
+		image = defaultMatrixImages[language]
+	}
+	if image == "" {
+		return nil, fmt.Errorf("no container image known for language %q; set an image for the test matrix", language)
+	}
+
+	return []string{
+		"docker", "run", "--rm",
+		"-v", s.repoPath + ":/workspace",
+		"-w", path.Join("/workspace", dir),
+		strings.ReplaceAll(image, versionPlaceholder, version),
+	}, nil
+}
+
+// FirstFailure returns the first failing result in a matrix, if any
+func FirstFailure(results []MatrixResult) *MatrixResult {
+	for i := range results {
+		if results[i].Err != nil {
+			return &results[i]
+		}
+	}
+	return nil
+}
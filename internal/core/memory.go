@@ -0,0 +1,126 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Memory kinds stored for a repository
+const (
+	MemoryConversation = "conversation" // Clarified understanding of an issue
+	MemorySolution     = "solution"     // Summary of the changes made for an issue
+)
+
+// Memory is a piece of past context stored with its embedding
+type Memory struct {
+	ID          int64
+	Owner       string
+	Repo        string
+	IssueNumber int
+	Kind        string
+	Content     string
+	Embedding   []float64
+	CreatedAt   time.Time
+}
+
+// MemoryMatch is a memory returned from a similarity search
+type MemoryMatch struct {
+	Memory
+	Similarity float64
+}
+
+// SaveMemory stores a memory, replacing any previous memory of the same kind for the issue
+func (sm *StateManager) SaveMemory(memory *Memory) error {
+	embeddingJSON, err := json.Marshal(memory.Embedding)
+	if err != nil {
+		return fmt.Errorf("failed to marshal embedding: %w", err)
+	}
+
+	if memory.CreatedAt.IsZero() {
+		memory.CreatedAt = time.Now()
+	}
+
+	query := `
+		INSERT INTO memories (owner, repo, issue_number, kind, content, embedding, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(owner, repo, issue_number, kind) DO UPDATE SET
+			content = excluded.content,
+			embedding = excluded.embedding,
+			created_at = excluded.created_at
+	`
+
+	_, err = sm.db.Exec(query, memory.Owner, memory.Repo, memory.IssueNumber, memory.Kind,
+		memory.Content, string(embeddingJSON), memory.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save memory: %w", err)
+	}
+
+	return nil
+}
+
+// SearchMemories returns the memories in a repository most similar to the query
+// embedding, excluding the given issue. Only matches at or above minSimilarity are returned.
+func (sm *StateManager) SearchMemories(owner, repo string, excludeIssue int, query []float64, limit int, minSimilarity float64) ([]MemoryMatch, error) {
+	rows, err := sm.db.Query(`
+		SELECT id, owner, repo, issue_number, kind, content, embedding, created_at
+		FROM memories
+		WHERE owner = ? AND repo = ? AND issue_number != ?
+	`, owner, repo, excludeIssue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query memories: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []MemoryMatch
+	for rows.Next() {
+		var memory Memory
+		var embeddingJSON string
+		if err := rows.Scan(&memory.ID, &memory.Owner, &memory.Repo, &memory.IssueNumber,
+			&memory.Kind, &memory.Content, &embeddingJSON, &memory.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan memory: %w", err)
+		}
+
+		if err := json.Unmarshal([]byte(embeddingJSON), &memory.Embedding); err != nil {
+			continue // Skip corrupt rows rather than failing the whole search
+		}
+
+		similarity := cosineSimilarity(query, memory.Embedding)
+		if similarity >= minSimilarity {
+			matches = append(matches, MemoryMatch{Memory: memory, Similarity: similarity})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read memories: %w", err)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Similarity > matches[j].Similarity
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	return matches, nil
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors (0 if incompatible)
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// AnswerMention asks Claude to reply to someone who @-mentioned the bot in a pull request's
+// discussion. The thread is the conversation so far, oldest first, ending with the mention.
+func (ca *ClaudeAgent) AnswerMention(title, description, diff, thread string) (string, TokenUsage, error) {
+	systemPrompt := ca.SystemPrompt(PromptMention, PromptData{})
+
+	messages := []AgentMessage{{
+		Role:    "user",
+		Content: fmt.Sprintf("Pull request: %s\n\n%s\n\nDiff:\n%s\n\nConversation:\n%s\n\nReply to the latest message that mentions you.", title, description, diff, thread),
+	}}
+
+	return ca.SendMessageForStage(StageChat, messages, systemPrompt)
+}
+
+// RecordMentionReply counts a reply to a mention on a pull request and adds its cost to the
+// monthly spend
+func (sm *StateManager) RecordMentionReply(owner, repo string, number int, cost float64) error {
+	tx, err := sm.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if _, err := tx.Exec(`
+		INSERT INTO mention_replies (owner, repo, number, replies, cost, replied_at)
+		VALUES (?, ?, ?, 1, ?, ?)
+		ON CONFLICT(owner, repo, number) DO UPDATE SET
+			replies = mention_replies.replies + 1,
+			cost = mention_replies.cost + excluded.cost,
+			replied_at = excluded.replied_at
+	`, owner, repo, number, cost, now); err != nil {
+		return fmt.Errorf("failed to record mention reply: %w", err)
+	}
+	if cost > 0 {
+		if _, err := tx.Exec(`
+			INSERT INTO monthly_spend (month, cost) VALUES (?, ?)
+			ON CONFLICT(month) DO UPDATE SET cost = monthly_spend.cost + excluded.cost
+		`, spendMonth(now), cost); err != nil {
+			return fmt.Errorf("failed to record spend: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to record mention reply: %w", err)
+	}
+	return nil
+}
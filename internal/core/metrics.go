@@ -0,0 +1,196 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Operational metrics, exposed in the Prometheus text format at /metrics
+var (
+	IssuesProcessed = newCounterVec("nytebubo_issues_processed_total", "Issues the agent started working on, by repository.", "repo")
+	PRsCreated      = newCounterVec("nytebubo_prs_created_total", "Pull requests opened by the agent, by repository.", "repo")
+	LLMLatency      = newHistogramVec("nytebubo_llm_request_duration_seconds", "Latency of LLM completion requests, by model.", "model",
+		[]float64{1, 2.5, 5, 10, 20, 40, 60, 120, 300, 600})
+	LLMRequests   = newCounterVec("nytebubo_llm_requests_total", "Completed LLM requests, by the model that served them.", "model")
+	LLMErrors     = newCounterVec("nytebubo_llm_errors_total", "Failed LLM completion requests, by model.", "model")
+	LLMTokens     = newCounterVec("nytebubo_llm_tokens_total", "Tokens used by LLM requests, by type (input, output or reasoning).", "type")
+	LLMCost       = newCounterVec("nytebubo_llm_cost_dollars_total", "Cost of LLM requests in USD as reported by the provider, by model.", "model")
+	PollErrors    = newCounterVec("nytebubo_poll_errors_total", "Errors while polling GitHub, by stage.", "stage")
+	WebhookEvents = newCounterVec("nytebubo_webhook_events_total", "Webhook events received, by GitHub event type.", "event")
+	GitHubRetries = newCounterVec("nytebubo_github_retries_total", "GitHub API requests retried, by reason.", "reason")
+
+	GitHubRateLimitRemaining = newGaugeVec("nytebubo_github_rate_limit_remaining", "GitHub API requests left in the current rate limit window, by resource.", "resource")
+)
+
+// metricsRegistry lists every metric in exposition order
+var metricsRegistry = []metric{IssuesProcessed, PRsCreated, LLMLatency, LLMRequests, LLMErrors, LLMTokens, LLMCost, PollErrors, WebhookEvents, GitHubRetries, GitHubRateLimitRemaining}
+
+// metric is a metric family that can write itself in the Prometheus text format
+type metric interface {
+	write(w io.Writer)
+}
+
+// CounterVec is a counter partitioned by the value of one label
+type CounterVec struct {
+	name, help, label string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func newCounterVec(name, help, label string) *CounterVec {
+	return &CounterVec{name: name, help: help, label: label, values: make(map[string]float64)}
+}
+
+// Inc adds one to the counter for a label value
+func (c *CounterVec) Inc(labelValue string) {
+	c.Add(labelValue, 1)
+}
+
+// Add adds v to the counter for a label value
+func (c *CounterVec) Add(labelValue string, v float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[labelValue] += v
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	for _, value := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s{%s=%q} %s\n", c.name, c.label, value, formatFloat(c.values[value]))
+	}
+}
+
+// GaugeVec is a gauge partitioned by the value of one label
+type GaugeVec struct {
+	name, help, label string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func newGaugeVec(name, help, label string) *GaugeVec {
+	return &GaugeVec{name: name, help: help, label: label, values: make(map[string]float64)}
+}
+
+// Set sets the gauge for a label value
+func (g *GaugeVec) Set(labelValue string, v float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.values[labelValue] = v
+}
+
+func (g *GaugeVec) write(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name)
+	for _, value := range sortedKeys(g.values) {
+		fmt.Fprintf(w, "%s{%s=%q} %s\n", g.name, g.label, value, formatFloat(g.values[value]))
+	}
+}
+
+// HistogramVec is a histogram partitioned by the value of one label
+type HistogramVec struct {
+	name, help, label string
+	buckets           []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+// histogramSeries holds the cumulative observations for one label value
+type histogramSeries struct {
+	counts []uint64 // Observations less than or equal to each bucket bound
+	count  uint64
+	sum    float64
+}
+
+func newHistogramVec(name, help, label string, buckets []float64) *HistogramVec {
+	return &HistogramVec{name: name, help: help, label: label, buckets: buckets, series: make(map[string]*histogramSeries)}
+}
+
+// Observe records a value for a label value
+func (h *HistogramVec) Observe(labelValue string, v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[labelValue]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[labelValue] = s
+	}
+	for i, bound := range h.buckets {
+		if v <= bound {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	for _, value := range sortedKeys(h.series) {
+		s := h.series[value]
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket{%s=%q,le=%q} %d\n", h.name, h.label, value, formatFloat(bound), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{%s=%q,le=\"+Inf\"} %d\n", h.name, h.label, value, s.count)
+		fmt.Fprintf(w, "%s_sum{%s=%q} %s\n", h.name, h.label, value, formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count{%s=%q} %d\n", h.name, h.label, value, s.count)
+	}
+}
+
+// RecordUsage adds an API call's tokens and cost to the usage metrics
+func RecordUsage(usage TokenUsage) {
+	LLMRequests.Inc(usage.Model)
+	LLMTokens.Add("input", float64(usage.InputTokens))
+	LLMTokens.Add("output", float64(usage.OutputTokens))
+	if usage.ReasoningTokens > 0 {
+		LLMTokens.Add("reasoning", float64(usage.ReasoningTokens))
+	}
+	if usage.Cost > 0 {
+		LLMCost.Add(usage.Model, usage.Cost)
+	}
+}
+
+// WriteMetrics writes every metric in the Prometheus text exposition format
+func WriteMetrics(w io.Writer) {
+	for _, m := range metricsRegistry {
+		m.write(w)
+	}
+}
+
+// MetricsHandler serves the metrics for Prometheus to scrape
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WriteMetrics(w)
+	})
+}
+
+// sortedKeys returns a map's keys in order, so output is stable between scrapes
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatFloat renders a sample value
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,435 @@
+package core
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// migration upgrades the state database schema by one version
+type migration struct {
+	description string
+	up          func(tx *stateTx) error
+}
+
+// migrations bring the state database up to date, in order: migrations[i] upgrades the
+// schema to version i+1. Add new columns and tables by appending a migration; never
+// change one that has been released, since existing databases have already run it.
+var migrations = []migration{
+	{"initial schema", createInitialSchema},
+	{"issue locks", createIssueLocks},
+	{"mention replies", createMentionReplies},
+	{"pull request issues", createPullRequestIssues},
+	{"cleanups", createCleanups},
+	{"webhook events", createWebhookEvents},
+	{"issue priorities", createIssuePriorities},
+	{"llm calls", createLLMCalls},
+	{"clarification rounds", addClarificationRounds},
+	{"issue parts", createIssueParts},
+	{"webhook event retries", addWebhookEventRetries},
+	{"webhook event claims", addWebhookEventClaims},
+}
+
+// migrate creates the schema_version table and runs the migrations the database hasn't
+// had yet, each in its own transaction
+func migrate(db *stateDB) error {
+	if _, err := db.Exec(db.dialect.ddl(`CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		description TEXT NOT NULL,
+		applied_at DATETIME NOT NULL
+	)`)); err != nil {
+		return fmt.Errorf("failed to create schema_version table: %w", err)
+	}
+
+	version, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than this version of NyteBubo supports (%d)", version, len(migrations))
+	}
+
+	for i := version; i < len(migrations); i++ {
+		if err := runMigration(db, i+1, migrations[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// schemaVersion returns the database's schema version, 0 before any migration has run
+func schemaVersion(db *stateDB) (int, error) {
+	var version sql.NullInt64
+	if err := db.QueryRow(`SELECT MAX(version) FROM schema_version`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+// runMigration applies a migration and records the version it brings the schema to
+func runMigration(db *stateDB, version int, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start migration %d: %w", version, err)
+	}
+	defer tx.Rollback()
+
+	if err := m.up(tx); err != nil {
+		return fmt.Errorf("migration %d (%s) failed: %w", version, m.description, err)
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_version (version, description, applied_at) VALUES (?, ?, ?)`,
+		version, m.description, time.Now()); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", version, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", version, err)
+	}
+	return nil
+}
+
+// createInitialSchema creates the tables as they were when versioned migrations were
+// introduced, adding any columns missing from databases created before then
+func createInitialSchema(tx *stateTx) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS agent_states (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		owner TEXT NOT NULL,
+		repo TEXT NOT NULL,
+		issue_number INTEGER NOT NULL,
+		status TEXT NOT NULL,
+		pr_number INTEGER,
+		branch_name TEXT,
+		conversation TEXT,
+		total_input_tokens INTEGER DEFAULT 0,
+		total_output_tokens INTEGER DEFAULT 0,
+		total_reasoning_tokens INTEGER DEFAULT 0,
+		total_cost REAL DEFAULT 0,
+		blocked_by_pr INTEGER,
+		reminder_sent_at DATETIME,
+		plan_comment_id INTEGER,
+		approved_by TEXT DEFAULT '',
+		model TEXT DEFAULT '',
+		resume_status TEXT DEFAULT '',
+		budget_baseline REAL DEFAULT 0,
+		budget_resumed_at DATETIME,
+		checkpoint TEXT DEFAULT '',
+		checkpoint_data TEXT DEFAULT '',
+		conflict_attempt TEXT DEFAULT '',
+		ci_fix_attempts INTEGER DEFAULT 0,
+		ci_failure_sha TEXT DEFAULT '',
+		conversation_summary TEXT DEFAULT '',
+		last_comment_id INTEGER DEFAULT 0,
+		verified INTEGER DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL,
+		completed_at DATETIME,
+		UNIQUE(owner, repo, issue_number)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_states_lookup
+	ON agent_states(owner, repo, issue_number);
+
+	CREATE TABLE IF NOT EXISTS memories (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		owner TEXT NOT NULL,
+		repo TEXT NOT NULL,
+		issue_number INTEGER NOT NULL,
+		kind TEXT NOT NULL,
+		content TEXT NOT NULL,
+		embedding TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		UNIQUE(owner, repo, issue_number, kind)
+	);
+
+	CREATE TABLE IF NOT EXISTS monthly_spend (
+		month TEXT PRIMARY KEY,
+		cost REAL NOT NULL DEFAULT 0
+	);
+
+	CREATE TABLE IF NOT EXISTS pr_reviews (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		owner TEXT NOT NULL,
+		repo TEXT NOT NULL,
+		pr_number INTEGER NOT NULL,
+		status TEXT NOT NULL,
+		reviewed_sha TEXT DEFAULT '',
+		reviews INTEGER DEFAULT 0,
+		total_input_tokens INTEGER DEFAULT 0,
+		total_output_tokens INTEGER DEFAULT 0,
+		total_reasoning_tokens INTEGER DEFAULT 0,
+		total_cost REAL DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL,
+		UNIQUE(owner, repo, pr_number)
+	);
+
+	CREATE TABLE IF NOT EXISTS triaged_issues (
+		owner TEXT NOT NULL,
+		repo TEXT NOT NULL,
+		issue_number INTEGER NOT NULL,
+		cost REAL NOT NULL DEFAULT 0,
+		triaged_at DATETIME NOT NULL,
+		PRIMARY KEY(owner, repo, issue_number)
+	);
+
+	CREATE TABLE IF NOT EXISTS settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS processed_comments (
+		owner TEXT NOT NULL,
+		repo TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		comment_id INTEGER NOT NULL,
+		processed_at DATETIME NOT NULL,
+		PRIMARY KEY(owner, repo, kind, comment_id)
+	);
+	`
+
+	if err := execSchema(tx, schema); err != nil {
+		return fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	// Databases created before versioned migrations may predate some of these columns
+	if err := ensureColumn(tx, "agent_states", "total_reasoning_tokens", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "agent_states", "blocked_by_pr", "INTEGER"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "agent_states", "reminder_sent_at", "DATETIME"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "agent_states", "plan_comment_id", "INTEGER"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "agent_states", "approved_by", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "agent_states", "model", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "agent_states", "resume_status", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "agent_states", "budget_baseline", "REAL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "agent_states", "budget_resumed_at", "DATETIME"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "agent_states", "checkpoint", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "agent_states", "checkpoint_data", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "agent_states", "conflict_attempt", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "agent_states", "ci_fix_attempts", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "agent_states", "ci_failure_sha", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "agent_states", "conversation_summary", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "agent_states", "last_comment_id", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn(tx, "agent_states", "verified", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
+
+	// Remember when processed comments started being recorded (kept from the first run)
+	if _, err := tx.Exec(`INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`,
+		commentTrackingKey, time.Now().UTC().Format(time.RFC3339), time.Now()); err != nil {
+		return fmt.Errorf("failed to record comment tracking start: %w", err)
+	}
+
+	return nil
+}
+
+// ensureColumn adds a column to an existing table if it is missing
+func ensureColumn(tx *stateTx, table, column, definition string) error {
+	return tx.dialect.addColumn(tx, table, column, definition)
+}
+
+// execSchema runs each statement of a schema written for SQLite, translated for the database
+func execSchema(tx *stateTx, schema string) error {
+	for _, statement := range strings.Split(schema, ";") {
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
+		if _, err := tx.Exec(tx.dialect.ddl(statement)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createIssueLocks adds the rows agents sharing a database lock to claim an issue
+func createIssueLocks(tx *stateTx) error {
+	return execSchema(tx, `
+	CREATE TABLE IF NOT EXISTS issue_locks (
+		owner TEXT NOT NULL,
+		repo TEXT NOT NULL,
+		issue_number INTEGER NOT NULL,
+		PRIMARY KEY(owner, repo, issue_number)
+	);
+	`)
+}
+
+// createMentionReplies adds the table counting replies to mentions on pull requests the
+// bot doesn't own
+func createMentionReplies(tx *stateTx) error {
+	return execSchema(tx, `
+	CREATE TABLE IF NOT EXISTS mention_replies (
+		owner TEXT NOT NULL,
+		repo TEXT NOT NULL,
+		number INTEGER NOT NULL,
+		replies INTEGER NOT NULL DEFAULT 0,
+		cost REAL NOT NULL DEFAULT 0,
+		replied_at DATETIME NOT NULL,
+		PRIMARY KEY(owner, repo, number)
+	);
+	`)
+}
+
+// createPullRequestIssues adds the table linking pull requests to the issues they close,
+// starting with the issue each existing bot pull request was opened for
+func createPullRequestIssues(tx *stateTx) error {
+	if err := execSchema(tx, `
+	CREATE TABLE IF NOT EXISTS pr_issues (
+		owner TEXT NOT NULL,
+		repo TEXT NOT NULL,
+		pr_number INTEGER NOT NULL,
+		issue_number INTEGER NOT NULL,
+		PRIMARY KEY(owner, repo, pr_number, issue_number)
+	);
+	`); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+		INSERT INTO pr_issues (owner, repo, pr_number, issue_number)
+		SELECT owner, repo, pr_number, issue_number FROM agent_states WHERE pr_number IS NOT NULL
+	`)
+	return err
+}
+
+// createCleanups adds the table recording when a finished issue's branch and workspace
+// were last cleaned up
+func createCleanups(tx *stateTx) error {
+	return execSchema(tx, `
+	CREATE TABLE IF NOT EXISTS cleanups (
+		owner TEXT NOT NULL,
+		repo TEXT NOT NULL,
+		issue_number INTEGER NOT NULL,
+		cleaned_at DATETIME NOT NULL,
+		PRIMARY KEY(owner, repo, issue_number)
+	);
+	`)
+}
+
+// createWebhookEvents adds the queue webhook deliveries are stored in until a worker has
+// handled them
+func createWebhookEvents(tx *stateTx) error {
+	return execSchema(tx, `
+	CREATE TABLE IF NOT EXISTS webhook_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		source TEXT NOT NULL,
+		event_type TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		received_at DATETIME NOT NULL,
+		processed_at DATETIME
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_webhook_events_status
+	ON webhook_events(status, id);
+	`)
+}
+
+// createIssuePriorities adds the priorities set with /nytebubo priority
+func createIssuePriorities(tx *stateTx) error {
+	return execSchema(tx, `
+	CREATE TABLE IF NOT EXISTS issue_priorities (
+		owner TEXT NOT NULL,
+		repo TEXT NOT NULL,
+		issue_number INTEGER NOT NULL,
+		priority INTEGER NOT NULL,
+		set_by TEXT NOT NULL DEFAULT '',
+		set_at DATETIME NOT NULL,
+		PRIMARY KEY(owner, repo, issue_number)
+	);
+	`)
+}
+
+// createLLMCalls adds the log of requests to the LLM provider, for attributing cost and
+// tokens to models and workflow stages
+func createLLMCalls(tx *stateTx) error {
+	return execSchema(tx, `
+	CREATE TABLE IF NOT EXISTS llm_calls (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		owner TEXT NOT NULL DEFAULT '',
+		repo TEXT NOT NULL DEFAULT '',
+		number INTEGER NOT NULL DEFAULT 0,
+		provider TEXT NOT NULL,
+		model TEXT NOT NULL,
+		purpose TEXT NOT NULL,
+		input_tokens INTEGER NOT NULL DEFAULT 0,
+		output_tokens INTEGER NOT NULL DEFAULT 0,
+		reasoning_tokens INTEGER NOT NULL DEFAULT 0,
+		cost REAL NOT NULL DEFAULT 0,
+		latency_ms INTEGER NOT NULL DEFAULT 0,
+		success INTEGER NOT NULL DEFAULT 1,
+		error TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_llm_calls_created_at
+	ON llm_calls(created_at);
+	`)
+}
+
+// addClarificationRounds adds the count of clarifying questions asked on an issue
+func addClarificationRounds(tx *stateTx) error {
+	return ensureColumn(tx, "agent_states", "clarification_rounds", "INTEGER DEFAULT 0")
+}
+
+// createIssueParts adds the table of the pull requests large issues are split into
+func createIssueParts(tx *stateTx) error {
+	return execSchema(tx, `
+	CREATE TABLE IF NOT EXISTS issue_parts (
+		owner TEXT NOT NULL,
+		repo TEXT NOT NULL,
+		issue_number INTEGER NOT NULL,
+		part INTEGER NOT NULL,
+		title TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		pr_number INTEGER,
+		merged_at DATETIME,
+		PRIMARY KEY(owner, repo, issue_number, part)
+	);
+	`)
+}
+
+// addWebhookEventRetries records when a failed webhook event may be handled again
+func addWebhookEventRetries(tx *stateTx) error {
+	return ensureColumn(tx, "webhook_events", "retry_after", "DATETIME")
+}
+
+// addWebhookEventClaims records which agent is handling a webhook event and since when
+func addWebhookEventClaims(tx *stateTx) error {
+	if err := ensureColumn(tx, "webhook_events", "claimed_by", "TEXT"); err != nil {
+		return err
+	}
+	return ensureColumn(tx, "webhook_events", "claimed_at", "DATETIME")
+}
@@ -0,0 +1,272 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const openAIBaseURL = "https://api.openai.com/v1"
+
+// openAIProvider sends requests directly to OpenAI's Chat Completions API, or to a
+// server exposing a compatible API
+type openAIProvider struct {
+	name         string
+	baseURL      string // API root, e.g. "https://api.openai.com/v1"
+	apiKey       string // Sent as a bearer token when set
+	defaultModel string
+
+	// Default embedding model, without the "openai/" prefix
+	defaultEmbeddingModel string
+
+	// Quirks of compatible servers and the models they run
+	legacyMaxTokens   bool   // Send max_tokens instead of max_completion_tokens
+	mergeSystemPrompt bool   // Send the system prompt in the first user message
+	structuredOutput  string // How schemas are requested (default: StructuredOutputSchema)
+
+	// Azure OpenAI routes requests to deployments and authenticates its own way
+	azure *azureRouting
+
+	httpClient *http.Client
+}
+
+// NewOpenAIProvider creates an OpenAI API client
+func NewOpenAIProvider(apiKey string) LLMProvider {
+	return &openAIProvider{
+		name:                  "OpenAI",
+		baseURL:               openAIBaseURL,
+		apiKey:                apiKey,
+		defaultModel:          "gpt-4.1",
+		defaultEmbeddingModel: strings.TrimPrefix(defaultEmbeddingModel, "openai/"),
+		httpClient:            &http.Client{},
+	}
+}
+
+// Name implements LLMProvider
+func (p *openAIProvider) Name() string {
+	return p.name
+}
+
+// DefaultModel implements LLMProvider
+func (p *openAIProvider) DefaultModel() string {
+	return p.defaultModel
+}
+
+// Endpoint implements LLMProvider
+func (p *openAIProvider) Endpoint() string {
+	return p.url("chat/completions", p.defaultModel)
+}
+
+// url returns the URL of an API operation, such as "chat/completions", for a model
+func (p *openAIProvider) url(operation, model string) string {
+	if p.azure != nil {
+		return p.azure.url(operation, model)
+	}
+	return p.baseURL + "/" + operation
+}
+
+// openAIRequest is an OpenAI chat completion request. It shares message and response
+// types with OpenRouter, which exposes an OpenAI-compatible API, but uses
+// max_completion_tokens and a top-level reasoning_effort.
+type openAIRequest struct {
+	Model               string              `json:"model"`
+	Messages            []openRouterMessage `json:"messages"`
+	MaxTokens           int                 `json:"max_tokens,omitempty"`
+	MaxCompletionTokens int                 `json:"max_completion_tokens,omitempty"`
+	Temperature         *float64            `json:"temperature,omitempty"`
+	TopP                *float64            `json:"top_p,omitempty"`
+	Stop                []string            `json:"stop,omitempty"`
+	ReasoningEffort     string              `json:"reasoning_effort,omitempty"`
+	ResponseFormat      *responseFormat     `json:"response_format,omitempty"`
+	Stream              bool                `json:"stream,omitempty"`
+	StreamOptions       *openAIStreamOpts   `json:"stream_options,omitempty"`
+}
+
+// openAIStreamOpts asks OpenAI to report usage in the final streamed chunk
+type openAIStreamOpts struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// Complete performs a single chat completion request against OpenAI or a compatible server
+func (p *openAIProvider) Complete(ctx context.Context, completion CompletionRequest) (string, TokenUsage, error) {
+	return p.send(ctx, completion, nil)
+}
+
+// Stream implements StreamingProvider using OpenAI's server-sent events
+func (p *openAIProvider) Stream(ctx context.Context, completion CompletionRequest, onDelta func(string)) (string, TokenUsage, error) {
+	return p.send(ctx, completion, onDelta)
+}
+
+// send performs a chat completion request, streaming the response when onDelta is set
+func (p *openAIProvider) send(ctx context.Context, completion CompletionRequest, onDelta func(string)) (string, TokenUsage, error) {
+	system, messages := completion.System, completion.Messages
+	format, system := p.responseFormat(completion.Schema, system)
+	if p.mergeSystemPrompt {
+		system, messages = "", mergeSystemPrompt(system, messages)
+	}
+
+	var apiMessages []openRouterMessage
+	if system != "" {
+		apiMessages = append(apiMessages, openRouterMessage{Role: "system", Content: system})
+	}
+	for _, msg := range messages {
+		apiMessages = append(apiMessages, openRouterMessage{Role: msg.Role, Content: messageContent(msg)})
+	}
+
+	params := completion.Params
+	reqBody := openAIRequest{
+		Model:           completion.Model,
+		Messages:        apiMessages,
+		Temperature:     params.Temperature,
+		TopP:            params.TopP,
+		Stop:            params.Stop,
+		ReasoningEffort: params.ReasoningEffort,
+		ResponseFormat:  format,
+	}
+	if p.legacyMaxTokens {
+		reqBody.MaxTokens = params.MaxTokens
+	} else {
+		reqBody.MaxCompletionTokens = params.MaxTokens
+	}
+
+	var apiResp openRouterResponse
+	if onDelta != nil {
+		reqBody.Stream = true
+		reqBody.StreamOptions = &openAIStreamOpts{IncludeUsage: true}
+		streamed, err := p.stream(ctx, reqBody, onDelta)
+		if err != nil {
+			return "", TokenUsage{}, err
+		}
+		apiResp = *streamed
+	} else if err := p.post(ctx, p.url("chat/completions", reqBody.Model), reqBody, &apiResp); err != nil {
+		return "", TokenUsage{}, err
+	}
+	if len(apiResp.Choices) == 0 {
+		return "", TokenUsage{}, fmt.Errorf("no choices in response")
+	}
+
+	modelUsed := apiResp.Model
+	if modelUsed == "" {
+		modelUsed = reqBody.Model
+	}
+
+	usage := TokenUsage{
+		InputTokens:  apiResp.Usage.PromptTokens,
+		OutputTokens: apiResp.Usage.CompletionTokens,
+		TotalTokens:  apiResp.Usage.TotalTokens,
+		Model:        modelUsed,
+		Truncated:    apiResp.Choices[0].FinishReason == "length",
+
+		ReasoningTokens: apiResp.Usage.CompletionTokensDetails.ReasoningTokens,
+	}
+
+	return StripReasoning(apiResp.Choices[0].Message.Content), usage, nil
+}
+
+// Embed implements EmbeddingProvider using the /embeddings endpoint
+func (p *openAIProvider) Embed(ctx context.Context, model string, texts []string) ([][]float64, TokenUsage, error) {
+	// Accept OpenRouter-style names such as "openai/text-embedding-3-small"
+	model = strings.TrimPrefix(model, "openai/")
+	if model == "" {
+		model = p.defaultEmbeddingModel
+	}
+
+	var apiResp embeddingResponse
+	if err := p.post(ctx, p.url("embeddings", model), embeddingRequest{Model: model, Input: texts}, &apiResp); err != nil {
+		return nil, TokenUsage{}, err
+	}
+	if len(apiResp.Data) != len(texts) {
+		return nil, TokenUsage{}, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(apiResp.Data))
+	}
+
+	embeddings := make([][]float64, len(texts))
+	for _, item := range apiResp.Data {
+		if item.Index < 0 || item.Index >= len(embeddings) {
+			return nil, TokenUsage{}, fmt.Errorf("embedding index %d out of range", item.Index)
+		}
+		embeddings[item.Index] = item.Embedding
+	}
+
+	usage := TokenUsage{
+		InputTokens: apiResp.Usage.PromptTokens,
+		TotalTokens: apiResp.Usage.TotalTokens,
+		Model:       model,
+	}
+
+	return embeddings, usage, nil
+}
+
+// post sends a JSON request to the API and decodes the response into out
+func (p *openAIProvider) post(ctx context.Context, endpoint string, payload, out any) error {
+	resp, err := p.do(ctx, endpoint, payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// stream sends a streaming chat completion request and assembles the response
+func (p *openAIProvider) stream(ctx context.Context, payload openAIRequest, onDelta func(string)) (*openRouterResponse, error) {
+	resp, err := p.do(ctx, p.url("chat/completions", payload.Model), payload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return readChatStream(p.Name(), resp.Body, onDelta)
+}
+
+// do sends a JSON request to the API, returning the response on a 200 status
+func (p *openAIProvider) do(ctx context.Context, endpoint string, payload any) (*http.Response, error) {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.azure != nil {
+		if err := p.azure.authorize(req); err != nil {
+			return nil, err
+		}
+	} else if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+
+		var errResp openRouterError
+		if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error.Message != "" {
+			return nil, &APIError{Provider: p.Name(), StatusCode: resp.StatusCode, Message: errResp.Error.Message}
+		}
+		return nil, &APIError{Provider: p.Name(), StatusCode: resp.StatusCode, Message: string(body)}
+	}
+
+	return resp, nil
+}
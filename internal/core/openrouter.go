@@ -6,62 +6,92 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"strconv"
 )
 
 const openRouterAPIURL = "https://openrouter.ai/api/v1/chat/completions"
 
-// TokenUsage tracks API token usage
-type TokenUsage struct {
-	InputTokens  int64
-	OutputTokens int64
-	TotalTokens  int64
-	Cost         float64 // Actual cost from OpenRouter API
-}
-
-// ClaudeAgent wraps the OpenRouter API client
-type ClaudeAgent struct {
+// openRouterProvider sends requests through OpenRouter's OpenAI-compatible API
+type openRouterProvider struct {
 	apiKey     string
 	httpClient *http.Client
-	ctx        context.Context
-	model      string
 }
 
-// NewClaudeAgent creates a new OpenRouter API client
-// If model is empty, defaults to "qwen/qwen3-coder:free" - best free coding model on OpenRouter
-func NewClaudeAgent(apiKey, model string) *ClaudeAgent {
-	if model == "" {
-		model = "qwen/qwen3-coder:free" // Best free coding model on OpenRouter
-	}
-
-	return &ClaudeAgent{
+// NewOpenRouterProvider creates an OpenRouter API client
+func NewOpenRouterProvider(apiKey string) LLMProvider {
+	return &openRouterProvider{
 		apiKey:     apiKey,
 		httpClient: &http.Client{},
-		ctx:        context.Background(),
-		model:      model,
 	}
 }
 
-// AgentMessage represents a message in the conversation
-type AgentMessage struct {
-	Role    string
-	Content string
+// Name implements LLMProvider
+func (p *openRouterProvider) Name() string {
+	return "OpenRouter"
+}
+
+// DefaultModel implements LLMProvider
+func (p *openRouterProvider) DefaultModel() string {
+	return "qwen/qwen3-coder:free" // Best free coding model on OpenRouter
+}
+
+// Endpoint implements LLMProvider
+func (p *openRouterProvider) Endpoint() string {
+	return openRouterAPIURL
 }
 
 // OpenRouter API request/response structures
 type openRouterMessage struct {
 	Role    string `json:"role"`
-	Content string `json:"content"`
+	Content any    `json:"content"` // string, or []contentPart for multimodal messages
+}
+
+// contentPart is one element of a multimodal message
+type contentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *imageURL `json:"image_url,omitempty"`
+}
+
+type imageURL struct {
+	URL string `json:"url"`
+}
+
+// messageContent builds the API content for a message, using content parts when images are attached
+func messageContent(msg AgentMessage) any {
+	if len(msg.Images) == 0 {
+		return msg.Content
+	}
+
+	parts := []contentPart{{Type: "text", Text: msg.Content}}
+	for _, image := range msg.Images {
+		parts = append(parts, contentPart{Type: "image_url", ImageURL: &imageURL{URL: image.DataURL}})
+	}
+	return parts
+}
+
+type openRouterResponseMessage struct {
+	Role      string `json:"role"`
+	Content   string `json:"content"`
+	Reasoning string `json:"reasoning,omitempty"` // Reasoning returned separately by reasoning models (never posted)
 }
 
 type openRouterRequest struct {
 	Model          string              `json:"model"`
 	Messages       []openRouterMessage `json:"messages"`
 	MaxTokens      int                 `json:"max_tokens,omitempty"`
-	Temperature    float64             `json:"temperature,omitempty"`
+	Temperature    *float64            `json:"temperature,omitempty"`
+	TopP           *float64            `json:"top_p,omitempty"`
+	Stop           []string            `json:"stop,omitempty"`
+	Reasoning      *reasoningConfig    `json:"reasoning,omitempty"`
 	ResponseFormat *responseFormat     `json:"response_format,omitempty"`
+	Stream         bool                `json:"stream,omitempty"`
+}
+
+// reasoningConfig controls reasoning/thinking for models that support it
+type reasoningConfig struct {
+	Effort string `json:"effort,omitempty"`
 }
 
 type responseFormat struct {
@@ -75,15 +105,72 @@ type jsonSchema struct {
 	Schema map[string]any `json:"schema"`
 }
 
+// File actions in structured code changes
+const (
+	FileActionCreate = "create"
+	FileActionModify = "modify"
+	FileActionDelete = "delete"
+	FileActionRename = "rename"
+)
+
+// codeChangesSchema returns the JSON schema used for structured code generation
+func codeChangesSchema() *jsonSchema {
+	return &jsonSchema{
+		Name:   "code_changes",
+		Strict: true,
+		Schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"summary": map[string]any{
+					"type":        "string",
+					"description": "A brief summary of the changes made",
+				},
+				"files": map[string]any{
+					"type":        "array",
+					"description": "List of files to create, modify, delete or rename",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"action": map[string]any{
+								"type": "string",
+								"enum": []string{FileActionCreate, FileActionModify, FileActionDelete, FileActionRename},
+							},
+							"path": map[string]any{
+								"type":        "string",
+								"description": "File path relative to repository root (the new path for a rename)",
+							},
+							"old_path": map[string]any{
+								"type":        "string",
+								"description": "Path the file is renamed from (empty unless renaming)",
+							},
+							"content": map[string]any{
+								"type":        "string",
+								"description": "Complete file content (empty for a delete, or for a rename that keeps the content)",
+							},
+						},
+						"required":             []string{"action", "path", "old_path", "content"},
+						"additionalProperties": false,
+					},
+				},
+			},
+			"required":             []string{"summary", "files"},
+			"additionalProperties": false,
+		},
+	}
+}
+
 type openRouterUsage struct {
-	PromptTokens     int64 `json:"prompt_tokens"`
-	CompletionTokens int64 `json:"completion_tokens"`
-	TotalTokens      int64 `json:"total_tokens"`
+	PromptTokens            int64 `json:"prompt_tokens"`
+	CompletionTokens        int64 `json:"completion_tokens"`
+	TotalTokens             int64 `json:"total_tokens"`
+	CompletionTokensDetails struct {
+		ReasoningTokens int64 `json:"reasoning_tokens"`
+	} `json:"completion_tokens_details"`
 }
 
 type openRouterChoice struct {
-	Message      openRouterMessage `json:"message"`
-	FinishReason string            `json:"finish_reason"`
+	Message      openRouterResponseMessage `json:"message"`
+	FinishReason string                    `json:"finish_reason"`
 }
 
 type openRouterResponse struct {
@@ -101,97 +188,51 @@ type openRouterError struct {
 	} `json:"error"`
 }
 
-// SendMessageWithStructuredOutput sends a message with optional JSON schema for structured output
-// If useStructuredOutput is true, it attempts JSON schema first, then falls back to regular format
-func (ca *ClaudeAgent) SendMessageWithStructuredOutput(messages []AgentMessage, systemPrompt string, useStructuredOutput bool) (string, TokenUsage, error) {
-	if useStructuredOutput {
-		// Try with structured output first
-		response, usage, err := ca.sendMessageInternal(messages, systemPrompt, true)
-		if err == nil {
-			return response, usage, nil
-		}
-
-		// If structured output failed, log and retry without it
-		log.Printf("⚠️  Structured output not supported by model, falling back to markdown format")
-	}
-
-	// Use regular format (no structured output)
-	return ca.sendMessageInternal(messages, systemPrompt, false)
+// Complete performs a single chat completion request against OpenRouter
+func (p *openRouterProvider) Complete(ctx context.Context, completion CompletionRequest) (string, TokenUsage, error) {
+	return p.send(ctx, completion, nil)
 }
 
-// SendMessage sends a message to OpenRouter and gets a response with usage tracking
-func (ca *ClaudeAgent) SendMessage(messages []AgentMessage, systemPrompt string) (string, TokenUsage, error) {
-	return ca.sendMessageInternal(messages, systemPrompt, false)
+// Stream implements StreamingProvider using OpenRouter's server-sent events
+func (p *openRouterProvider) Stream(ctx context.Context, completion CompletionRequest, onDelta func(string)) (string, TokenUsage, error) {
+	return p.send(ctx, completion, onDelta)
 }
 
-// sendMessageInternal is the internal implementation that handles both structured and regular output
-func (ca *ClaudeAgent) sendMessageInternal(messages []AgentMessage, systemPrompt string, useStructuredOutput bool) (string, TokenUsage, error) {
+// send performs a chat completion request, streaming the response when onDelta is set
+func (p *openRouterProvider) send(ctx context.Context, completion CompletionRequest, onDelta func(string)) (string, TokenUsage, error) {
 	// Build messages array with system prompt first
 	var apiMessages []openRouterMessage
-
-	// Add system prompt as first message
-	if systemPrompt != "" {
+	if completion.System != "" {
 		apiMessages = append(apiMessages, openRouterMessage{
 			Role:    "system",
-			Content: systemPrompt,
+			Content: completion.System,
 		})
 	}
-
-	// Add conversation messages
-	for _, msg := range messages {
+	for _, msg := range completion.Messages {
 		apiMessages = append(apiMessages, openRouterMessage{
 			Role:    msg.Role,
-			Content: msg.Content,
+			Content: messageContent(msg),
 		})
 	}
 
-	// Create request
+	params := completion.Params
 	reqBody := openRouterRequest{
-		Model:     ca.model,
-		Messages:  apiMessages,
-		MaxTokens: 8096,
+		Model:       completion.Model,
+		Messages:    apiMessages,
+		MaxTokens:   params.MaxTokens,
+		Temperature: params.Temperature,
+		TopP:        params.TopP,
+		Stop:        params.Stop,
+	}
+	if params.ReasoningEffort != "" {
+		reqBody.Reasoning = &reasoningConfig{Effort: params.ReasoningEffort}
 	}
 
 	// Add structured output schema if requested
-	if useStructuredOutput {
-		reqBody.ResponseFormat = &responseFormat{
-			Type: "json_schema",
-			JSONSchema: &jsonSchema{
-				Name:   "code_changes",
-				Strict: true,
-				Schema: map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"summary": map[string]any{
-							"type":        "string",
-							"description": "A brief summary of the changes made",
-						},
-						"files": map[string]any{
-							"type":        "array",
-							"description": "List of files to create or modify",
-							"items": map[string]any{
-								"type": "object",
-								"properties": map[string]any{
-									"path": map[string]any{
-										"type":        "string",
-										"description": "File path relative to repository root",
-									},
-									"content": map[string]any{
-										"type":        "string",
-										"description": "Complete file content",
-									},
-								},
-								"required":             []string{"path", "content"},
-								"additionalProperties": false,
-							},
-						},
-					},
-					"required":             []string{"summary", "files"},
-					"additionalProperties": false,
-				},
-			},
-		}
+	if completion.Schema != nil {
+		reqBody.ResponseFormat = &responseFormat{Type: "json_schema", JSONSchema: completion.Schema}
 	}
+	reqBody.Stream = onDelta != nil
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
@@ -199,43 +240,53 @@ func (ca *ClaudeAgent) sendMessageInternal(messages []AgentMessage, systemPrompt
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequestWithContext(ca.ctx, "POST", openRouterAPIURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", openRouterAPIURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", TokenUsage{}, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+ca.apiKey)
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
 	req.Header.Set("HTTP-Referer", "https://github.com/yourusername/NyteBubo") // Optional: for OpenRouter analytics
 	req.Header.Set("X-Title", "NyteBubo GitHub Agent")                        // Optional: for OpenRouter analytics
 
 	// Send request
-	resp, err := ca.httpClient.Do(req)
+	resp, err := p.httpClient.Do(req)
 	if err != nil {
 		return "", TokenUsage{}, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", TokenUsage{}, fmt.Errorf("failed to read response: %w", err)
-	}
-
 	// Check for HTTP errors
 	if resp.StatusCode != http.StatusOK {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", TokenUsage{}, fmt.Errorf("failed to read response: %w", err)
+		}
 		var errResp openRouterError
 		if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error.Message != "" {
-			return "", TokenUsage{}, fmt.Errorf("OpenRouter API error (%d): %s", resp.StatusCode, errResp.Error.Message)
+			return "", TokenUsage{}, &APIError{Provider: p.Name(), StatusCode: resp.StatusCode, Message: errResp.Error.Message}
 		}
-		return "", TokenUsage{}, fmt.Errorf("OpenRouter API error: status %d, body: %s", resp.StatusCode, string(body))
+		return "", TokenUsage{}, &APIError{Provider: p.Name(), StatusCode: resp.StatusCode, Message: string(body)}
 	}
 
 	// Parse response
 	var apiResp openRouterResponse
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return "", TokenUsage{}, fmt.Errorf("failed to parse response: %w", err)
+	if onDelta != nil {
+		streamed, err := readChatStream(p.Name(), resp.Body, onDelta)
+		if err != nil {
+			return "", TokenUsage{}, err
+		}
+		apiResp = *streamed
+	} else {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", TokenUsage{}, fmt.Errorf("failed to read response: %w", err)
+		}
+		if err := json.Unmarshal(body, &apiResp); err != nil {
+			return "", TokenUsage{}, fmt.Errorf("failed to parse response: %w", err)
+		}
 	}
 
 	// Extract response text
@@ -243,17 +294,23 @@ func (ca *ClaudeAgent) sendMessageInternal(messages []AgentMessage, systemPrompt
 		return "", TokenUsage{}, fmt.Errorf("no choices in response")
 	}
 
-	responseText := apiResp.Choices[0].Message.Content
+	// Reasoning is returned in a separate field by most providers, but some models
+	// inline it as <think> blocks; strip those so they never reach comments or parsing
+	responseText := StripReasoning(apiResp.Choices[0].Message.Content)
 
-	// Get actual cost from OpenRouter response header
+	// Get cost from OpenRouter response header (frequently absent; backfilled below)
 	actualCost := 0.0
 	costHeader := resp.Header.Get("X-OpenRouter-Generation-Cost")
 	if costHeader != "" {
 		if parsedCost, err := strconv.ParseFloat(costHeader, 64); err == nil {
 			actualCost = parsedCost
 		}
-	} else {
-		log.Printf("⚠️  Warning: OpenRouter did not provide cost data in response header")
+	}
+
+	// Get model name from response (useful when using auto-routing)
+	modelUsed := apiResp.Model
+	if modelUsed == "" {
+		modelUsed = reqBody.Model
 	}
 
 	// Track token usage
@@ -262,123 +319,22 @@ func (ca *ClaudeAgent) sendMessageInternal(messages []AgentMessage, systemPrompt
 		OutputTokens: apiResp.Usage.CompletionTokens,
 		TotalTokens:  apiResp.Usage.TotalTokens,
 		Cost:         actualCost,
-	}
+		Model:        modelUsed,
+		Truncated:    apiResp.Choices[0].FinishReason == "length",
 
-	// Get model name from response (useful when using auto-routing)
-	modelUsed := apiResp.Model
-	if modelUsed == "" {
-		modelUsed = ca.model
+		ReasoningTokens: apiResp.Usage.CompletionTokensDetails.ReasoningTokens,
 	}
 
-	// Log usage information
-	log.Printf("📊 OpenRouter API [%s] - Input: %d | Output: %d | Total: %d tokens | Cost: $%.4f",
-		modelUsed, usage.InputTokens, usage.OutputTokens, usage.TotalTokens, usage.Cost)
-
-	return responseText, usage, nil
-}
-
-
-// AnalyzeIssue asks Claude to analyze a GitHub issue
-func (ca *ClaudeAgent) AnalyzeIssue(title, body string) (string, TokenUsage, error) {
-	systemPrompt := `You are a helpful AI coding assistant that analyzes GitHub issues.
-Your job is to:
-1. Understand what the issue is asking for
-2. Ask clarifying questions if anything is unclear
-3. Provide a clear summary of what needs to be done
-
-Be concise and professional.`
-
-	userMessage := fmt.Sprintf(`Please analyze this GitHub issue:
-
-Title: %s
-
-Description:
-%s
-
-Instructions:
-- First, provide a clear summary of what this issue is asking for
-- If you have ANY clarifying questions or uncertainties, ask them clearly
-- If EVERYTHING is completely clear and you need no additional information, ONLY THEN say you're ready to proceed
-
-IMPORTANT: Either ask questions OR confirm readiness - never do both in the same response.`, title, body)
-
-	messages := []AgentMessage{
-		{Role: "user", Content: userMessage},
+	// Fetch the authoritative cost and native token counts for this generation
+	if apiResp.ID != "" {
+		if err := p.backfillUsage(ctx, apiResp.ID, &usage); err != nil {
+			logger := loggerFrom(ctx)
+			logger.Warn("⚠️  Failed to fetch generation stats", "generation", apiResp.ID, "error", err)
+			if costHeader == "" {
+				logger.Warn("⚠️  OpenRouter did not provide cost data in response header")
+			}
+		}
 	}
 
-	return ca.SendMessage(messages, systemPrompt)
-}
-
-// GenerateCode asks Claude to generate code for a specific task
-// It attempts to use structured JSON output for compatible models, with markdown fallback
-func (ca *ClaudeAgent) GenerateCode(task, context, language string, conversationHistory []AgentMessage) (string, TokenUsage, error) {
-	systemPrompt := fmt.Sprintf(`You are an expert software engineer working on a GitHub issue.
-You have full access to the repository and need to implement the requested changes.
-
-Programming Language: %s
-Repository Context: %s
-
-Your task: %s
-
-IMPORTANT - Response Format:
-Provide a summary of your changes followed by the file changes.
-
-For each file you create or modify, use this format:
-
-` + "```" + `%s path/to/file.ext
-complete file content here
-` + "```" + `
-
-Examples:
-
-` + "```" + `markdown README.md
-# Project Title
-This is the content of README.md
-` + "```" + `
-
-` + "```" + `python main.py
-def hello():
-    print("Hello World")
-` + "```" + `
-
-Rules:
-1. Use code blocks with three backticks
-2. After backticks, put the language/format followed by a SPACE, then the file path
-3. Put complete file content on the next line
-4. Close with three backticks
-5. One code block per file
-6. File paths are relative to repository root
-
-This format is critical for automatic processing.`, language, context, task, language)
-
-	// Try structured output first, fallback to regular message if model doesn't support it
-	return ca.SendMessageWithStructuredOutput(conversationHistory, systemPrompt, true)
-}
-
-// ReviewFeedback processes review feedback and generates updated code
-func (ca *ClaudeAgent) ReviewFeedback(feedback string, previousCode string, conversationHistory []AgentMessage) (string, TokenUsage, error) {
-	systemPrompt := `You are an expert software engineer responding to code review feedback.
-Your job is to:
-1. Understand the feedback
-2. Make the necessary changes
-3. Explain what you changed and why
-
-Be professional and collaborative.`
-
-	userMessage := fmt.Sprintf(`Here's the review feedback on the code:
-
-%s
-
-Previous code:
-%s
-
-Please update the code based on this feedback.`, feedback, previousCode)
-
-	// Add the new message to the conversation history
-	updatedHistory := append(conversationHistory, AgentMessage{
-		Role:    "user",
-		Content: userMessage,
-	})
-
-	return ca.SendMessage(updatedHistory, systemPrompt)
+	return responseText, usage, nil
 }
@@ -0,0 +1,181 @@
+package core
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// IssuePart is one of the pull requests a large issue is split into. Parts are implemented
+// and merged in order, each starting once the one before it has merged.
+type IssuePart struct {
+	Number      int        `json:"-"` // Position in the order, from 1
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	PRNumber    *int       `json:"-"`
+	MergedAt    *time.Time `json:"-"`
+}
+
+// IssueDecomposition is the model's proposal for splitting an issue into pull requests
+type IssueDecomposition struct {
+	Reason string      `json:"reason"`
+	Parts  []IssuePart `json:"parts"`
+}
+
+// decompositionSchema returns the JSON schema for issue decompositions
+func decompositionSchema() *jsonSchema {
+	return &jsonSchema{
+		Name:   "issue_decomposition",
+		Strict: true,
+		Schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"reason": map[string]any{
+					"type":        "string",
+					"description": "Why the issue is or isn't split, in one sentence",
+				},
+				"parts": map[string]any{
+					"type":        "array",
+					"description": "Pull requests in merge order, each reviewable and mergeable on its own; a single part when the issue fits in one",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"title":       map[string]any{"type": "string"},
+							"description": map[string]any{"type": "string"},
+						},
+						"required":             []string{"title", "description"},
+						"additionalProperties": false,
+					},
+				},
+			},
+			"required":             []string{"reason", "parts"},
+			"additionalProperties": false,
+		},
+	}
+}
+
+// DecomposeIssue asks Claude whether an issue is too large for one pull request and, if
+// so, how to split it into pull requests that are merged in order
+func (ca *ClaudeAgent) DecomposeIssue(task, context, language string, conversationHistory []AgentMessage) (IssueDecomposition, TokenUsage, error) {
+	systemPrompt := ca.SystemPrompt(PromptDecompose, PromptData{Language: language, Context: context, Task: task})
+
+	messages := append(append([]AgentMessage{}, conversationHistory...), AgentMessage{
+		Role:    "user",
+		Content: "Decide how many pull requests this issue needs. Respond with the JSON object only.",
+	})
+
+	response, usage, err := ca.sendWithSchema(StageCodegen, messages, systemPrompt, decompositionSchema())
+	if err != nil {
+		return IssueDecomposition{}, usage, err
+	}
+
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return IssueDecomposition{}, usage, fmt.Errorf("no JSON object in decomposition response")
+	}
+	var decomposition IssueDecomposition
+	if err := json.Unmarshal([]byte(response[start:end+1]), &decomposition); err != nil {
+		return IssueDecomposition{}, usage, fmt.Errorf("failed to parse decomposition response: %w", err)
+	}
+	if len(decomposition.Parts) == 0 {
+		return IssueDecomposition{}, usage, fmt.Errorf("decomposition has no parts")
+	}
+	for i := range decomposition.Parts {
+		decomposition.Parts[i].Number = i + 1
+	}
+	return decomposition, usage, nil
+}
+
+// CurrentPart returns the first part that hasn't merged, or nil when all have
+func CurrentPart(parts []IssuePart) *IssuePart {
+	for i := range parts {
+		if parts[i].MergedAt == nil {
+			return &parts[i]
+		}
+	}
+	return nil
+}
+
+// SaveIssueParts records the pull requests an issue is split into, replacing any recorded before
+func (sm *StateManager) SaveIssueParts(owner, repo string, issueNumber int, parts []IssuePart) error {
+	tx, err := sm.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		DELETE FROM issue_parts WHERE owner = ? AND repo = ? AND issue_number = ?
+	`, owner, repo, issueNumber); err != nil {
+		return fmt.Errorf("failed to clear issue parts: %w", err)
+	}
+	for _, part := range parts {
+		if _, err := tx.Exec(`
+			INSERT INTO issue_parts (owner, repo, issue_number, part, title, description, pr_number, merged_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, owner, repo, issueNumber, part.Number, part.Title, part.Description, part.PRNumber, part.MergedAt); err != nil {
+			return fmt.Errorf("failed to save part %d: %w", part.Number, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save issue parts: %w", err)
+	}
+	return nil
+}
+
+// ListIssueParts returns the pull requests an issue is split into, in order. An issue that
+// hasn't been split has none.
+func (sm *StateManager) ListIssueParts(owner, repo string, issueNumber int) ([]IssuePart, error) {
+	rows, err := sm.db.Query(`
+		SELECT part, title, description, pr_number, merged_at FROM issue_parts
+		WHERE owner = ? AND repo = ? AND issue_number = ?
+		ORDER BY part
+	`, owner, repo, issueNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list issue parts: %w", err)
+	}
+	defer rows.Close()
+
+	var parts []IssuePart
+	for rows.Next() {
+		var part IssuePart
+		var prNumber sql.NullInt64
+		var mergedAt sql.NullTime
+		if err := rows.Scan(&part.Number, &part.Title, &part.Description, &prNumber, &mergedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan issue part: %w", err)
+		}
+		if prNumber.Valid {
+			prNum := int(prNumber.Int64)
+			part.PRNumber = &prNum
+		}
+		if mergedAt.Valid {
+			part.MergedAt = &mergedAt.Time
+		}
+		parts = append(parts, part)
+	}
+	return parts, rows.Err()
+}
+
+// SetIssuePartPR records the pull request opened for a part of an issue
+func (sm *StateManager) SetIssuePartPR(owner, repo string, issueNumber, part, prNumber int) error {
+	if _, err := sm.db.Exec(`
+		UPDATE issue_parts SET pr_number = ? WHERE owner = ? AND repo = ? AND issue_number = ? AND part = ?
+	`, prNumber, owner, repo, issueNumber, part); err != nil {
+		return fmt.Errorf("failed to record part %d's pull request: %w", part, err)
+	}
+	return nil
+}
+
+// MarkIssuePartMerged records that the pull request of a part of an issue merged
+func (sm *StateManager) MarkIssuePartMerged(owner, repo string, issueNumber, part int, mergedAt time.Time) error {
+	if _, err := sm.db.Exec(`
+		UPDATE issue_parts SET merged_at = ? WHERE owner = ? AND repo = ? AND issue_number = ? AND part = ?
+	`, mergedAt, owner, repo, issueNumber, part); err != nil {
+		return fmt.Errorf("failed to mark part %d merged: %w", part, err)
+	}
+	return nil
+}
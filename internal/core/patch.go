@@ -0,0 +1,156 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FileEdit is a search/replace edit to one file
+type FileEdit struct {
+	Path    string
+	Search  string
+	Replace string
+}
+
+// ApplySearchReplace replaces the single occurrence of search in content. When there is
+// no exact match it retries ignoring trailing whitespace, then ignoring indentation
+// (re-indenting the replacement to match the file).
+func ApplySearchReplace(content, search, replace string) (string, error) {
+	if strings.TrimSpace(search) == "" {
+		return "", fmt.Errorf("empty search block")
+	}
+
+	switch strings.Count(content, search) {
+	case 1:
+		return strings.Replace(content, search, replace, 1), nil
+	case 0:
+	default:
+		return "", fmt.Errorf("search block matches %d locations; include more context", strings.Count(content, search))
+	}
+
+	lines := strings.Split(content, "\n")
+	searchLines := strings.Split(strings.TrimRight(search, "\n"), "\n")
+	replaceLines := strings.Split(strings.TrimRight(replace, "\n"), "\n")
+	if replace == "" {
+		replaceLines = nil
+	}
+
+	for _, normalize := range []func(string) string{
+		func(s string) string { return strings.TrimRight(s, " \t\r") },
+		strings.TrimSpace,
+	} {
+		start, err := findLines(lines, searchLines, normalize)
+		if err != nil {
+			return "", err
+		}
+		if start < 0 {
+			continue
+		}
+
+		indent := indentDelta(lines[start], searchLines[0])
+		replacement := make([]string, 0, len(replaceLines))
+		for _, line := range replaceLines {
+			replacement = append(replacement, reindentLine(line, indent))
+		}
+
+		result := append([]string{}, lines[:start]...)
+		result = append(result, replacement...)
+		result = append(result, lines[start+len(searchLines):]...)
+		return strings.Join(result, "\n"), nil
+	}
+
+	return "", fmt.Errorf("search block not found")
+}
+
+// findLines returns the index where needle occurs in haystack once after normalization,
+// -1 if it doesn't occur, or an error if it is ambiguous
+func findLines(haystack, needle []string, normalize func(string) string) (int, error) {
+	found := -1
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		match := true
+		for j, line := range needle {
+			if normalize(haystack[i+j]) != normalize(line) {
+				match = false
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		if found >= 0 {
+			return -1, fmt.Errorf("search block matches several locations; include more context")
+		}
+		found = i
+	}
+	return found, nil
+}
+
+// indentChange re-indents replacement lines: a prefix to add or a number of leading characters to remove
+type indentChange struct {
+	add    string
+	remove int
+}
+
+// indentDelta returns how the file's indentation differs from the search block's
+func indentDelta(fileLine, searchLine string) indentChange {
+	fileIndent := fileLine[:len(fileLine)-len(strings.TrimLeft(fileLine, " \t"))]
+	searchIndent := searchLine[:len(searchLine)-len(strings.TrimLeft(searchLine, " \t"))]
+
+	switch {
+	case strings.HasPrefix(fileIndent, searchIndent):
+		return indentChange{add: fileIndent[len(searchIndent):]}
+	case strings.HasPrefix(searchIndent, fileIndent):
+		return indentChange{remove: len(searchIndent) - len(fileIndent)}
+	}
+	return indentChange{}
+}
+
+// reindentLine applies an indentation change to a replacement line
+func reindentLine(line string, change indentChange) string {
+	if strings.TrimSpace(line) == "" {
+		return line
+	}
+	if change.remove > 0 {
+		leading := len(line) - len(strings.TrimLeft(line, " \t"))
+		return line[min(change.remove, leading):]
+	}
+	return change.add + line
+}
+
+// ApplyEdits applies search/replace edits to one file in the sandbox. Either all edits
+// apply or the file is left untouched. An empty search creates a file that doesn't exist.
+func (s *Sandbox) ApplyEdits(relativePath string, edits []FileEdit) error {
+	fullPath, err := s.resolvePath(relativePath)
+	if err != nil {
+		return err
+	}
+
+	raw, err := os.ReadFile(fullPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	exists := err == nil
+
+	// Edit with LF line endings; WriteFile restores the file's own line endings
+	content := strings.ReplaceAll(string(raw), "\r\n", "\n")
+	for i, edit := range edits {
+		if strings.TrimSpace(edit.Search) == "" {
+			if exists {
+				return fmt.Errorf("edit %d: empty search block for existing file", i+1)
+			}
+			content = edit.Replace
+			exists = true
+			continue
+		}
+		if !exists {
+			return fmt.Errorf("file does not exist")
+		}
+		content, err = ApplySearchReplace(content, edit.Search, edit.Replace)
+		if err != nil {
+			return fmt.Errorf("edit %d: %w", i+1, err)
+		}
+	}
+
+	return s.WriteFile(relativePath, content)
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ImplementationPlan breaks an issue's implementation into steps that are generated separately
+type ImplementationPlan struct {
+	Summary string     `json:"summary"`
+	Steps   []PlanStep `json:"steps"`
+	Risks   []string   `json:"risks"`
+}
+
+// PlanStep is one self-contained change in an implementation plan
+type PlanStep struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Files       []string `json:"files"`
+}
+
+// planSchema returns the JSON schema for implementation plans
+func planSchema() *jsonSchema {
+	return &jsonSchema{
+		Name:   "implementation_plan",
+		Strict: true,
+		Schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"summary": map[string]any{
+					"type":        "string",
+					"description": "One or two sentences describing the overall approach",
+				},
+				"steps": map[string]any{
+					"type":        "array",
+					"description": "Ordered steps, each leaving the code in a working state",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"title":       map[string]any{"type": "string"},
+							"description": map[string]any{"type": "string"},
+							"files": map[string]any{
+								"type":        "array",
+								"description": "Paths of the files this step creates, modifies or deletes",
+								"items":       map[string]any{"type": "string"},
+							},
+						},
+						"required":             []string{"title", "description", "files"},
+						"additionalProperties": false,
+					},
+				},
+				"risks": map[string]any{
+					"type":        "array",
+					"description": "Things that could go wrong or need a reviewer's attention (empty if none)",
+					"items":       map[string]any{"type": "string"},
+				},
+			},
+			"required":             []string{"summary", "steps", "risks"},
+			"additionalProperties": false,
+		},
+	}
+}
+
+// PlanImplementation asks Claude to break the implementation of an issue into ordered
+// steps before any code is written
+func (ca *ClaudeAgent) PlanImplementation(task, context, language string, conversationHistory []AgentMessage) (ImplementationPlan, TokenUsage, error) {
+	systemPrompt := ca.SystemPrompt(PromptPlan, PromptData{Language: language, Context: context, Task: task})
+
+	messages := append(append([]AgentMessage{}, conversationHistory...), AgentMessage{
+		Role:    "user",
+		Content: "Plan the implementation. Respond with the JSON object only.",
+	})
+
+	response, usage, err := ca.sendWithSchema(StageCodegen, messages, systemPrompt, planSchema())
+	if err != nil {
+		return ImplementationPlan{}, usage, err
+	}
+
+	plan, err := parsePlan(response)
+	if err != nil {
+		return ImplementationPlan{}, usage, err
+	}
+	return plan, usage, nil
+}
+
+// parsePlan extracts the plan JSON from a response, tolerating code fences and surrounding text
+func parsePlan(response string) (ImplementationPlan, error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return ImplementationPlan{}, fmt.Errorf("no JSON object in plan response")
+	}
+
+	var plan ImplementationPlan
+	if err := json.Unmarshal([]byte(response[start:end+1]), &plan); err != nil {
+		return ImplementationPlan{}, fmt.Errorf("failed to parse plan response: %w", err)
+	}
+	if len(plan.Steps) == 0 {
+		return ImplementationPlan{}, fmt.Errorf("plan has no steps")
+	}
+	return plan, nil
+}
+
+// String formats the plan as markdown
+func (p ImplementationPlan) String() string {
+	var b strings.Builder
+	for i, step := range p.Steps {
+		b.WriteString(fmt.Sprintf("%d. **%s**", i+1, step.Title))
+		if len(step.Files) > 0 {
+			b.WriteString(" (`" + strings.Join(step.Files, "`, `") + "`)")
+		}
+		if step.Description != "" {
+			b.WriteString("\n   " + strings.ReplaceAll(strings.TrimSpace(step.Description), "\n", "\n   "))
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// maxRepoBackoff caps how long a repository that keeps failing is left unpolled
+const maxRepoBackoff = time.Hour
+
+// minRepoBackoff is the first backoff when polls are closer together than this, e.g. from PollOnce
+const minRepoBackoff = 30 * time.Second
+
+// repoBackoff tracks consecutive poll failures of a repository
+type repoBackoff struct {
+	failures int
+	until    time.Time // The repository isn't polled before this
+}
+
+// backingOff reports whether a repository that has been failing should be skipped this poll
+func (p *Poller) backingOff(repoFullName string) bool {
+	backoff, ok := p.backoffs[repoFullName]
+	return ok && time.Now().Before(backoff.until)
+}
+
+// repoFailed records a failed poll of a repository and returns how many polls in a row
+// have failed and how long the repository is left alone. The delay doubles with each
+// failure, up to maxRepoBackoff, with jitter so repositories sharing a cause don't retry
+// in lockstep.
+func (p *Poller) repoFailed(repoFullName string) (int, time.Duration) {
+	backoff := p.backoffs[repoFullName]
+	backoff.failures++
+
+	delay := max(p.pollInterval, minRepoBackoff)
+	for i := 1; i < backoff.failures && delay < maxRepoBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxRepoBackoff)
+	// Wait between half and all of the delay
+	delay = delay/2 + rand.N(delay/2+1)
+
+	backoff.until = time.Now().Add(delay)
+	p.backoffs[repoFullName] = backoff
+	return backoff.failures, delay
+}
+
+// repoSucceeded clears a repository's failures and returns how many polls in a row had failed
+func (p *Poller) repoSucceeded(repoFullName string) int {
+	failures := p.backoffs[repoFullName].failures
+	delete(p.backoffs, repoFullName)
+	return failures
+}
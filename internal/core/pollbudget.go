@@ -0,0 +1,90 @@
+package core
+
+import (
+	"log/slog"
+	"time"
+)
+
+// rateLimitReserve is the share of a GitHub quota kept back for the work that polls
+// trigger; polling pauses until the quota resets once only the reserve is left
+const rateLimitReserve = 0.05
+
+// rateLimitResources are the GitHub quotas polling draws on
+var rateLimitResources = []string{"core", "search"}
+
+// rateLimitSnapshot returns the GitHub rate limit budgets reported so far, by resource
+func (p *Poller) rateLimitSnapshot() map[string]rateLimitBudget {
+	budgets := make(map[string]rateLimitBudget)
+	github := p.hosts.GitHub()
+	if github == nil || github.limits == nil {
+		return budgets
+	}
+	for _, resource := range rateLimitResources {
+		if budget, ok := github.limits.budget(resource); ok {
+			budgets[resource] = budget
+		}
+	}
+	return budgets
+}
+
+// nextPollWait returns how long to wait before the next poll, given the budgets from before
+// the last one. At the usual interval, polls that use up more of a GitHub quota than it can
+// spare before it resets are spread out over the rest of the window instead, and polling
+// pauses until the reset once only the reserve is left.
+func (p *Poller) nextPollWait(before map[string]rateLimitBudget) time.Duration {
+	wait := p.pollInterval
+	var limited string
+	var limitedBudget rateLimitBudget
+	paused := false
+
+	now := time.Now()
+	for resource, budget := range p.rateLimitSnapshot() {
+		untilReset := budget.Reset.Sub(now)
+		if untilReset <= 0 {
+			continue
+		}
+
+		// A window that reset during the poll is charged everything used since
+		used := budget.Limit - budget.Remaining
+		if previous, ok := before[resource]; ok && previous.Reset.Equal(budget.Reset) {
+			used = previous.Remaining - budget.Remaining
+		}
+
+		spare := budget.Remaining - int(float64(budget.Limit)*rateLimitReserve)
+		resourceWait, pause := untilReset+time.Second, true
+		if spare > 0 {
+			if used <= 0 {
+				continue
+			}
+			if polls := spare / used; polls > 0 {
+				resourceWait, pause = untilReset/time.Duration(polls), false
+			}
+		}
+
+		if resourceWait > wait {
+			wait, paused = resourceWait, pause
+			limited, limitedBudget = resource, budget
+		}
+	}
+
+	switch {
+	case limited == "":
+		if p.throttled {
+			slog.Info("▶️  GitHub rate limit recovered, polling at the usual interval", "interval", p.pollInterval)
+		}
+		p.throttled = false
+	case paused:
+		slog.Warn("⏸️  GitHub rate limit nearly exhausted, pausing polling until it resets", "resource", limited,
+			"remaining", limitedBudget.Remaining, "limit", limitedBudget.Limit, "resets_in", wait.Round(time.Second))
+		p.throttled = true
+	default:
+		log := slog.Debug
+		if !p.throttled {
+			log = slog.Info
+		}
+		log("🐢 Stretching the poll interval to save GitHub rate limit", "resource", limited,
+			"remaining", limitedBudget.Remaining, "limit", limitedBudget.Limit, "interval", wait.Round(time.Second))
+		p.throttled = true
+	}
+	return wait
+}
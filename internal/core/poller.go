@@ -1,8 +1,10 @@
 package core
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"regexp"
 	"strings"
 	"time"
 
@@ -11,104 +13,539 @@ import (
 
 // PollerHandlers contains callbacks for different event types
 type PollerHandlers struct {
-	HandleIssue            func(owner, repo string, issueNumber int) error
-	HandleIssueComment     func(owner, repo string, issueNumber int, commentBody string) error
-	HandlePRComment        func(owner, repo string, prNumber int, commentBody string) error
-	HandleImplementation   func(owner, repo string, issueNumber int) error
+	HandleIssue func(owner, repo string, issueNumber int) error
+	// Comment handlers receive every new comment since the last poll as one batch
+	HandleIssueComments  func(owner, repo string, issueNumber int, commentBodies []string) error
+	HandlePRComments     func(owner, repo string, prNumber int, commentBodies []string) error
+	HandleImplementation func(owner, repo string, issueNumber int) error
+	// HandleStale is called for issues left waiting for clarification; expire is false for the reminder
+	HandleStale func(owner, repo string, issueNumber int, expire bool) error
+	// HandlePullRequest is called on every poll for bot pull requests, to auto-merge them or
+	// finish the issue once they are merged or closed
+	HandlePullRequest func(owner, repo string, prNumber int) error
+	// HandleClosed is called for issues the bot is working on that were closed
+	HandleClosed func(owner, repo string, issueNumber int) error
+	// HandleMention is called when someone @-mentions the bot on an issue or pull request it
+	// isn't working on
+	HandleMention func(owner, repo string, issueNumber int, author, body string) error
+	// HandleCommand is called for each new /nytebubo slash command on an issue the bot is working on
+	HandleCommand func(owner, repo string, issueNumber int, author, body string) error
+	// HandleApproval is called on every poll for issues whose plan awaits approval
+	HandleApproval func(owner, repo string, issueNumber int) error
+	// HandleLabel is called for issues carrying a trigger label that the bot isn't working on
+	HandleLabel func(owner, repo string, issueNumber int, label string) error
+	// HandleNewIssue is called for issues opened since the last poll in repositories with triage enabled
+	HandleNewIssue func(owner, repo string, issueNumber int) error
+	// HandleReview is called for pull requests by others that the bot is requested to review
+	// (requested is true) or that were opened since the last poll
+	HandleReview func(owner, repo string, prNumber int, requested bool) error
+	// CanComment reports whether comments and reviews by author are acted on; the others are
+	// marked handled without being passed to the comment handlers
+	CanComment func(owner, repo string, number int, author string) (bool, error)
 }
 
-// Poller polls GitHub for assigned issues and triggers workflows
+// Poller polls the code hosts for assigned issues and triggers workflows
 type Poller struct {
-	github       *GitHubClient
+	hosts        *CodeHosts
 	stateManager *StateManager
 	pollInterval time.Duration
 	repositories []string // List of repositories to monitor (format: "owner/repo")
-	username     string   // Bot username
+	staleAfter   time.Duration
+	expireAfter  time.Duration
+	// Mention triggers: only mentions newer than mentionsSince are considered
+	mentionTrigger bool
+	mentionsSince  time.Time
+	triggerLabels  func(owner, repo string) []string
+	// Triage: issues opened after triageSince in these repositories ("owner/repo") are triaged
+	triageRepos map[string]bool
+	triageSince time.Time
+	// Pull requests opened after reviewsSince are offered for review
+	reviewsSince time.Time
+	// startedAt marks issues left "implementing" by a previous run as interrupted
+	startedAt time.Time
+	// commentsSince is when processed comments started being recorded; older comments
+	// are judged by their timestamps instead
+	commentsSince time.Time
+	// throttled is set while polls are stretched out or paused to save GitHub rate limit
+	throttled bool
+	// priorityLabels rank issues carrying them ahead of the others, first listed first
+	priorityLabels []string
+	// backoffs holds the repositories whose last polls failed, by "owner/repo"
+	backoffs map[string]repoBackoff
 }
 
 // PollerConfig contains configuration for the poller
 type PollerConfig struct {
 	PollInterval time.Duration
 	Repositories []string
+	// StaleAfter is how long an issue may wait for clarification before a reminder (0 disables)
+	StaleAfter time.Duration
+	// ExpireAfter is how long after the reminder the issue is parked as stale (0 disables)
+	ExpireAfter time.Duration
+	// MentionTrigger starts work on issues where the bot is @-mentioned, not only assigned
+	MentionTrigger bool
+	// TriggerLabels returns the labels that start work on a repository's issues (nil disables)
+	TriggerLabels func(owner, repo string) []string
+	// TriageRepositories lists the repositories whose newly opened issues are triaged
+	TriageRepositories []string
+	// PriorityLabels put issues carrying them ahead of the others, first listed first
+	PriorityLabels []string
 }
 
-// NewPoller creates a new GitHub issue poller
-func NewPoller(github *GitHubClient, stateManager *StateManager, config PollerConfig) (*Poller, error) {
-	// Get the authenticated user
-	user, err := github.GetAuthenticatedUser()
+// NewPoller creates a new issue poller
+func NewPoller(hosts *CodeHosts, stateManager *StateManager, config PollerConfig) (*Poller, error) {
+	// Check every repository's host accepts our credentials before polling starts
+	for _, repoFullName := range config.Repositories {
+		if parts := strings.Split(repoFullName, "/"); len(parts) == 2 {
+			if _, err := hosts.BotLogin(parts[0], parts[1]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	commentsSince, err := stateManager.CommentTrackingSince()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get authenticated user: %w", err)
+		return nil, err
+	}
+
+	triageRepos := make(map[string]bool, len(config.TriageRepositories))
+	for _, repoFullName := range config.TriageRepositories {
+		triageRepos[repoFullName] = true
 	}
 
 	return &Poller{
-		github:       github,
+		hosts:        hosts,
 		stateManager: stateManager,
 		pollInterval: config.PollInterval,
 		repositories: config.Repositories,
-		username:     user.GetLogin(),
+		staleAfter:   config.StaleAfter,
+		expireAfter:  config.ExpireAfter,
+
+		mentionTrigger: config.MentionTrigger,
+		mentionsSince:  time.Now(),
+		triggerLabels:  config.TriggerLabels,
+		triageRepos:    triageRepos,
+		triageSince:    time.Now(),
+		reviewsSince:   time.Now(),
+		startedAt:      time.Now(),
+		commentsSince:  commentsSince,
+		priorityLabels: config.PriorityLabels,
+		backoffs:       make(map[string]repoBackoff),
 	}, nil
 }
 
-// Start begins polling for assigned issues
-func (p *Poller) Start(handlers PollerHandlers) error {
-	log.Printf("Starting poller for user: %s", p.username)
-	log.Printf("Monitoring repositories: %v", p.repositories)
-	log.Printf("Poll interval: %v", p.pollInterval)
-
-	ticker := time.NewTicker(p.pollInterval)
-	defer ticker.Stop()
+// Start begins polling for assigned issues and returns once ctx is cancelled
+func (p *Poller) Start(ctx context.Context, handlers PollerHandlers) error {
+	slog.Info("Starting poller", "repositories", p.repositories, "interval", p.pollInterval)
 
 	// Do an initial poll immediately
-	if err := p.poll(handlers); err != nil {
-		log.Printf("Error during initial poll: %v", err)
+	budgets := p.rateLimitSnapshot()
+	if err := p.poll(ctx, handlers); err != nil {
+		slog.Error("Error during initial poll", "error", err)
+		PollErrors.Inc("poll")
 	}
 
-	// Then poll at intervals
-	for range ticker.C {
-		if err := p.poll(handlers); err != nil {
-			log.Printf("Error during poll: %v", err)
+	// Then poll at intervals, stretched out when the GitHub rate limit runs low
+	for {
+		timer := time.NewTimer(p.nextPollWait(budgets))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			slog.Info("Poller stopped")
+			return nil
+		case <-timer.C:
+			budgets = p.rateLimitSnapshot()
+			if err := p.poll(ctx, handlers); err != nil {
+				slog.Error("Error during poll", "error", err)
+				PollErrors.Inc("poll")
+			}
 		}
 	}
+}
 
-	return nil
+// PollOnce runs a single poll of every repository, e.g. from a scheduled CI job
+func (p *Poller) PollOnce(ctx context.Context, handlers PollerHandlers) error {
+	return p.poll(ctx, handlers)
 }
 
 // poll checks for new assigned issues and processes them
-func (p *Poller) poll(handlers PollerHandlers) error {
-	log.Printf("Polling for assigned issues...")
-
+func (p *Poller) poll(ctx context.Context, handlers PollerHandlers) error {
+	slog.Debug("Polling for assigned issues")
+	pollStart := time.Now()
+
+	// List every repository's assigned issues first, so they are handled in priority order
+	// across repositories
+	var listed []listedRepository
+	var queue []queuedIssue
 	for _, repoFullName := range p.repositories {
+		if ctx.Err() != nil {
+			return nil
+		}
+
 		// Parse owner/repo
 		parts := strings.Split(repoFullName, "/")
 		if len(parts) != 2 {
-			log.Printf("Invalid repository format: %s (expected owner/repo)", repoFullName)
+			slog.Error("Invalid repository format (expected owner/repo)", "repository", repoFullName)
 			continue
 		}
 		owner, repo := parts[0], parts[1]
+		logger := RepoLogger(owner, repo)
+		if p.backingOff(repoFullName) {
+			logger.Debug("Skipping repository while backing off after failed polls")
+			continue
+		}
 
 		// Get assigned issues for this repository
-		issues, err := p.github.ListRepositoryIssues(owner, repo, p.username)
+		issues, err := p.hosts.For(owner, repo).ListRepositoryIssues(owner, repo, p.login(owner, repo))
+		if err != nil {
+			PollErrors.Inc("list_issues")
+			failures, delay := p.repoFailed(repoFullName)
+			if failures == 1 {
+				logger.Error("Failed to list issues", "error", err)
+			} else {
+				logger.Warn("⏳ Repository keeps failing, backing off", "failures", failures,
+					"retry_in", delay.Round(time.Second), "error", err)
+			}
+			continue
+		}
+		if failures := p.repoSucceeded(repoFullName); failures > 1 {
+			logger.Info("✅ Repository polling recovered", "failures", failures)
+		}
+
+		logger.Debug("Found assigned issues", "count", len(issues))
+		listed = append(listed, listedRepository{owner: owner, repo: repo, fullName: repoFullName, issues: issues})
+		queued, err := p.queueIssues(owner, repo, issues)
+		if err != nil {
+			logger.Error("Failed to read issue priorities", "error", err)
+			PollErrors.Inc("priorities")
+		}
+		queue = append(queue, queued...)
+	}
+
+	// Process each issue, most important first
+	for _, queued := range p.schedule(queue) {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err := p.processIssue(queued.owner, queued.repo, queued.issue, handlers); err != nil {
+			IssueLogger(queued.owner, queued.repo, queued.issue.GetNumber()).Error("Error processing issue", "error", err)
+			PollErrors.Inc("process_issue")
+		}
+	}
+
+	for _, listedRepo := range listed {
+		if ctx.Err() != nil {
+			return nil
+		}
+		owner, repo := listedRepo.owner, listedRepo.repo
+		logger := RepoLogger(owner, repo)
+
+		if err := p.pollClosed(owner, repo, listedRepo.issues, handlers); err != nil {
+			logger.Error("Failed to check for closed issues", "error", err)
+			PollErrors.Inc("closed")
+		}
+
+		if p.triggerLabels != nil {
+			if err := p.pollLabels(owner, repo, handlers); err != nil {
+				logger.Error("Failed to check labeled issues", "error", err)
+				PollErrors.Inc("labels")
+			}
+		}
+
+		if p.mentionTrigger {
+			if err := p.pollMentions(owner, repo, handlers); err != nil {
+				logger.Error("Failed to check mentions", "error", err)
+				PollErrors.Inc("mentions")
+			}
+		}
+
+		if err := p.pollReviews(owner, repo, handlers); err != nil {
+			logger.Error("Failed to check pull requests to review", "error", err)
+			PollErrors.Inc("reviews")
+		}
+
+		if p.triageRepos[listedRepo.fullName] {
+			if err := p.pollNewIssues(owner, repo, handlers); err != nil {
+				logger.Error("Failed to check new issues", "error", err)
+				PollErrors.Inc("triage")
+			}
+		}
+	}
+
+	p.mentionsSince = pollStart
+	p.triageSince = pollStart
+	p.reviewsSince = pollStart
+	return nil
+}
+
+// PollIssue runs one poll of a single issue, whether or not it is assigned to the bot:
+// a new issue is analyzed, and an issue the bot is working on moves on from its state
+func (p *Poller) PollIssue(owner, repo string, issueNumber int, handlers PollerHandlers) error {
+	issue, err := p.hosts.For(owner, repo).GetIssue(owner, repo, issueNumber)
+	if err != nil {
+		return err
+	}
+	if issue.GetState() == "closed" {
+		return fmt.Errorf("issue #%d is closed", issueNumber)
+	}
+	return p.processIssue(owner, repo, issue, handlers)
+}
+
+// login returns the bot's login on a repository's host. NewPoller has already looked
+// it up, so it is cached.
+func (p *Poller) login(owner, repo string) string {
+	login, _ := p.hosts.BotLogin(owner, repo)
+	return login
+}
+
+// pollClosed finds issues the bot is working on that were closed since the last poll.
+// Only issues missing from the bot's open assigned issues are looked up.
+func (p *Poller) pollClosed(owner, repo string, assigned []*github.Issue, handlers PollerHandlers) error {
+	if handlers.HandleClosed == nil {
+		return nil
+	}
+
+	states, err := p.stateManager.ListActiveStates(owner, repo)
+	if err != nil {
+		return err
+	}
+	open := make(map[int]bool, len(assigned))
+	for _, issue := range assigned {
+		open[issue.GetNumber()] = true
+	}
+
+	for _, state := range states {
+		if open[state.IssueNumber] {
+			continue
+		}
+		logger := IssueLogger(owner, repo, state.IssueNumber)
+		issue, err := p.hosts.For(owner, repo).GetIssue(owner, repo, state.IssueNumber)
 		if err != nil {
-			log.Printf("Failed to list issues for %s: %v", repoFullName, err)
+			// e.g. a deleted or transferred issue; the others are still checked
+			logger.Warn("⚠️  Failed to check whether issue is closed", "error", err)
+			continue
+		}
+		if issue.GetState() != "closed" {
 			continue
 		}
 
-		log.Printf("Found %d assigned issue(s) in %s", len(issues), repoFullName)
+		logger.Info("📪 Issue was closed")
+		if err := handlers.HandleClosed(owner, repo, state.IssueNumber); err != nil {
+			logger.Error("Error handling closed issue", "error", err)
+		}
+	}
+
+	return nil
+}
+
+// pollLabels starts work on issues carrying a trigger label that the bot isn't working on yet
+func (p *Poller) pollLabels(owner, repo string, handlers PollerHandlers) error {
+	if handlers.HandleLabel == nil {
+		return nil
+	}
+
+	for _, label := range p.triggerLabels(owner, repo) {
+		issues, err := p.hosts.For(owner, repo).ListLabeledIssues(owner, repo, label)
+		if err != nil {
+			return err
+		}
 
-		// Process each issue
 		for _, issue := range issues {
-			if err := p.processIssue(owner, repo, issue, handlers); err != nil {
-				log.Printf("Error processing issue #%d in %s: %v", issue.GetNumber(), repoFullName, err)
+			issueNumber := issue.GetNumber()
+			state, err := p.stateManager.GetState(owner, repo, issueNumber)
+			if err != nil {
+				return fmt.Errorf("failed to get state: %w", err)
 			}
+			if state != nil {
+				continue
+			}
+
+			logger := IssueLogger(owner, repo, issueNumber)
+			logger.Info("🏷️  Issue has a trigger label", "label", label)
+			if err := handlers.HandleLabel(owner, repo, issueNumber, label); err != nil {
+				logger.Error("Error handling label", "error", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// pollNewIssues hands issues opened since the last poll to triage
+func (p *Poller) pollNewIssues(owner, repo string, handlers PollerHandlers) error {
+	if handlers.HandleNewIssue == nil {
+		return nil
+	}
+
+	issues, err := p.hosts.For(owner, repo).ListOpenIssues(owner, repo)
+	if err != nil {
+		return err
+	}
+
+	for _, issue := range issues {
+		if !issue.GetCreatedAt().Time.After(p.triageSince) {
+			continue
+		}
+		issueNumber := issue.GetNumber()
+		if err := handlers.HandleNewIssue(owner, repo, issueNumber); err != nil {
+			IssueLogger(owner, repo, issueNumber).Error("Error triaging issue", "error", err)
+		}
+	}
+
+	return nil
+}
+
+// pollReviews offers pull requests opened by others for review when the bot is a requested
+// reviewer on their latest commit, or when they were opened since the last poll
+func (p *Poller) pollReviews(owner, repo string, handlers PollerHandlers) error {
+	if handlers.HandleReview == nil {
+		return nil
+	}
+
+	prs, err := p.hosts.For(owner, repo).ListOpenPullRequests(owner, repo)
+	if err != nil {
+		return err
+	}
+
+	login := p.login(owner, repo)
+	for _, pr := range prs {
+		if pr.GetUser().GetLogin() == login {
+			continue
+		}
+		requested := false
+		for _, reviewer := range pr.RequestedReviewers {
+			if strings.EqualFold(reviewer.GetLogin(), login) {
+				requested = true
+			}
+		}
+		if requested {
+			state, err := p.stateManager.GetReviewState(owner, repo, pr.GetNumber())
+			if err != nil {
+				return err
+			}
+			if state != nil && state.ReviewedSHA == pr.GetHead().GetSHA() {
+				continue
+			}
+		} else if !pr.GetCreatedAt().Time.After(p.reviewsSince) {
+			continue
+		}
+
+		logger := RepoLogger(owner, repo).With("pr", pr.GetNumber())
+		if err := handlers.HandleReview(owner, repo, pr.GetNumber(), requested); err != nil {
+			logger.Error("Error reviewing pull request", "error", err)
 		}
 	}
 
 	return nil
 }
 
+// pollMentions looks for new @-mentions of the bot on issues and pull requests it isn't
+// working on yet
+func (p *Poller) pollMentions(owner, repo string, handlers PollerHandlers) error {
+	if handlers.HandleMention == nil {
+		return nil
+	}
+
+	login := p.login(owner, repo)
+	issues, err := p.hosts.For(owner, repo).ListMentioningIssues(owner, repo, login, p.mentionsSince)
+	if err != nil {
+		return err
+	}
+
+	for _, issue := range issues {
+		issueNumber := issue.GetNumber()
+		state, err := p.stateManager.GetState(owner, repo, issueNumber)
+		if err != nil {
+			return fmt.Errorf("failed to get state: %w", err)
+		}
+		if state != nil {
+			continue
+		}
+
+		// Candidate mentions: the issue body (for new issues) and new comments, oldest first,
+		// then new review comments on pull requests
+		type mention struct {
+			kind         string
+			id           int64
+			author, body string
+		}
+		var mentions []mention
+		if issue.GetCreatedAt().Time.After(p.mentionsSince) && MentionsUser(issue.GetBody(), login) {
+			mentions = append(mentions, mention{CommentKindBody, issue.GetID(), issue.GetUser().GetLogin(), issue.GetBody()})
+		}
+		comments, err := p.hosts.For(owner, repo).ListIssueComments(owner, repo, issueNumber)
+		if err != nil {
+			return err
+		}
+		for _, comment := range comments {
+			if comment.GetUser().GetLogin() == login || !comment.GetCreatedAt().Time.After(p.mentionsSince) {
+				continue
+			}
+			if MentionsUser(comment.GetBody(), login) {
+				mentions = append(mentions, mention{CommentKindIssue, comment.GetID(), comment.GetUser().GetLogin(), comment.GetBody()})
+			}
+		}
+		if issue.IsPullRequest() {
+			reviewComments, err := p.hosts.For(owner, repo).ListPRComments(owner, repo, issueNumber)
+			if err != nil {
+				return err
+			}
+			for _, comment := range reviewComments {
+				if comment.GetUser().GetLogin() == login || !comment.GetCreatedAt().Time.After(p.mentionsSince) {
+					continue
+				}
+				if MentionsUser(comment.GetBody(), login) {
+					mentions = append(mentions, mention{CommentKindPR, comment.GetID(), comment.GetUser().GetLogin(), comment.GetBody()})
+				}
+			}
+		}
+
+		for _, m := range mentions {
+			// Skip mentions the webhook server already handled
+			if claimed, err := p.stateManager.ClaimComment(owner, repo, m.kind, m.id); err != nil {
+				return err
+			} else if !claimed {
+				continue
+			}
+			logger := IssueLogger(owner, repo, issueNumber)
+			logger.Info("📣 Bot was mentioned", "author", m.author)
+			if err := handlers.HandleMention(owner, repo, issueNumber, m.author, m.body); err != nil {
+				logger.Error("Error handling mention", "error", err)
+			}
+			// Stop once a mention started the workflow
+			if state, err := p.stateManager.GetState(owner, repo, issueNumber); err == nil && state != nil {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// MentionsUser reports whether text @-mentions the given login
+func MentionsUser(text, login string) bool {
+	if login == "" {
+		return false
+	}
+	re := regexp.MustCompile(`(?i)(^|[^\w@])@` + regexp.QuoteMeta(login) + `($|[^\w-])`)
+	return re.MatchString(text)
+}
+
 // processIssue checks if an issue needs to be processed and handles it
 func (p *Poller) processIssue(owner, repo string, issue *github.Issue, handlers PollerHandlers) error {
 	issueNumber := issue.GetNumber()
+	logger := IssueLogger(owner, repo, issueNumber)
+
+	// Agents sharing a database take turns, so an issue is only handled by one of them
+	release, ok, err := p.stateManager.TryLockIssue(owner, repo, issueNumber)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		logger.Debug("Issue is being handled by another agent")
+		return nil
+	}
+	defer release()
 
 	// Check if we've already processed this issue
 	state, err := p.stateManager.GetState(owner, repo, issueNumber)
@@ -118,30 +555,45 @@ func (p *Poller) processIssue(owner, repo string, issue *github.Issue, handlers
 
 	// If we have no state for this issue, it's new - process it
 	if state == nil {
-		log.Printf("New issue detected: %s/%s #%d - %s", owner, repo, issueNumber, issue.GetTitle())
+		logger.Info("New issue detected", "title", issue.GetTitle())
 		if handlers.HandleIssue != nil {
 			return handlers.HandleIssue(owner, repo, issueNumber)
 		}
 		return nil
 	}
 
-	// Reconcile status with latest comments (detect stuck states)
-	if state.Status == "waiting_for_clarification" {
-		log.Printf("🔍 Checking if issue %s/%s #%d needs status reconciliation", owner, repo, issueNumber)
-		if err := p.reconcileStatus(owner, repo, issueNumber, state); err != nil {
-			log.Printf("⚠️  Warning: failed to reconcile status for issue #%d: %v", issueNumber, err)
+	// Slash commands work in every state and take precedence over the checks below;
+	// the next poll continues from whatever state they leave behind
+	if handlers.HandleCommand != nil {
+		handled, err := p.processCommands(owner, repo, issueNumber, state, handlers)
+		if err != nil {
+			return fmt.Errorf("failed to check for commands: %w", err)
+		}
+		if handled {
+			return nil
 		}
-		// Reload state after potential reconciliation
-		state, err = p.stateManager.GetState(owner, repo, issueNumber)
+	}
+
+	// If issue is waiting on an overlapping bot PR, resume once that PR is closed
+	if state.Status == "blocked" && state.BlockedByPR != nil {
+		pr, err := p.hosts.For(owner, repo).GetPullRequest(owner, repo, *state.BlockedByPR)
 		if err != nil {
-			return fmt.Errorf("failed to reload state after reconciliation: %w", err)
+			return fmt.Errorf("failed to check blocking PR: %w", err)
+		}
+		if pr.GetState() != "closed" {
+			return nil
+		}
+		logger.Info("▶️  Blocking PR closed, resuming issue", "pr", *state.BlockedByPR)
+		state.Status = "ready_to_implement"
+		state.BlockedByPR = nil
+		if err := p.stateManager.SaveState(state); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
 		}
-		log.Printf("📊 Issue %s/%s #%d status after reconciliation: %s", owner, repo, issueNumber, state.Status)
 	}
 
 	// If issue is ready to implement, start implementation
 	if state.Status == "ready_to_implement" {
-		log.Printf("Issue %s/%s #%d is ready to implement - starting implementation", owner, repo, issueNumber)
+		logger.Info("Issue is ready to implement, starting implementation")
 		if handlers.HandleImplementation != nil {
 			return handlers.HandleImplementation(owner, repo, issueNumber)
 		}
@@ -150,13 +602,14 @@ func (p *Poller) processIssue(owner, repo string, issue *github.Issue, handlers
 
 	// If issue is stuck in "implementing" status (failed during implementation), retry
 	if state.Status == "implementing" {
-		// Check how long it's been stuck (more than 10 minutes = definitely stuck)
+		// Check how long it's been stuck (more than 10 minutes = definitely stuck);
+		// work left over from before this run started was interrupted and resumes at once
 		stuckDuration := time.Since(state.UpdatedAt)
-		if stuckDuration > 10*time.Minute {
-			log.Printf("⚠️  Issue %s/%s #%d stuck in 'implementing' for %v - retrying", owner, repo, issueNumber, stuckDuration)
+		if stuckDuration > 10*time.Minute || state.UpdatedAt.Before(p.startedAt) {
+			logger.Warn("⚠️  Issue stuck in 'implementing', retrying", "stuck_for", stuckDuration.Round(time.Second))
 			state.Status = "ready_to_implement"
 			if err := p.stateManager.SaveState(state); err != nil {
-				log.Printf("Error resetting stuck status: %v", err)
+				logger.Error("Error resetting stuck status", "error", err)
 			}
 			if handlers.HandleImplementation != nil {
 				return handlers.HandleImplementation(owner, repo, issueNumber)
@@ -166,42 +619,95 @@ func (p *Poller) processIssue(owner, repo string, issue *github.Issue, handlers
 	}
 
 	// If we have state, check if there are new comments we need to process
-	if state.Status == "waiting_for_clarification" {
+	// (a reply to a stale issue picks the conversation back up)
+	if state.Status == "waiting_for_clarification" || state.Status == "waiting_for_approval" || state.Status == "stale" {
 		newComments, err := p.getNewComments(owner, repo, issueNumber, state)
 		if err != nil {
 			return fmt.Errorf("failed to check for new comments: %w", err)
 		}
 
 		if len(newComments) > 0 {
-			log.Printf("New comments detected on issue %s/%s #%d - processing %d comment(s)", owner, repo, issueNumber, len(newComments))
-			// Process each new comment
-			for _, comment := range newComments {
-				if handlers.HandleIssueComment != nil {
-					if err := handlers.HandleIssueComment(owner, repo, issueNumber, comment.GetBody()); err != nil {
-						log.Printf("Error handling comment on issue #%d: %v", issueNumber, err)
+			logger.Info("New comments detected", "count", len(newComments))
+			// Process the new comments together so they get one consolidated response
+			if handlers.HandleIssueComments != nil {
+				var bodies []string
+				ids := make([]int64, len(newComments))
+				lastID := state.LastCommentID
+				for i, comment := range newComments {
+					if p.canComment(owner, repo, issueNumber, comment.GetUser().GetLogin(), handlers) {
+						bodies = append(bodies, comment.GetBody())
 					}
+					ids[i] = comment.GetID()
+					lastID = max(lastID, comment.GetID())
+				}
+				// Comments that aren't acted on are still marked as handled
+				var err error
+				if len(bodies) > 0 {
+					err = handlers.HandleIssueComments(owner, repo, issueNumber, bodies)
 				}
+				if err != nil {
+					logger.Error("Error handling comments", "error", err)
+				} else if err := p.stateManager.MarkCommentsProcessed(owner, repo, CommentKindIssue, ids); err != nil {
+					logger.Error("Error recording handled comments", "error", err)
+				} else if err := p.stateManager.SetLastCommentID(owner, repo, issueNumber, lastID); err != nil {
+					logger.Error("Error recording handled comments", "error", err)
+				}
+			}
+		} else if state.Status == "waiting_for_clarification" {
+			p.checkStale(owner, repo, issueNumber, state, handlers)
+		} else if state.Status == "waiting_for_approval" && handlers.HandleApproval != nil {
+			// Approval by reaction doesn't leave a comment, so check on every poll
+			if err := handlers.HandleApproval(owner, repo, issueNumber); err != nil {
+				logger.Error("Error checking approval", "error", err)
 			}
 		}
 	}
 
-	// Check if there are new PR review comments
+	// Check if there are new PR reviews or review comments
 	if state.Status == "pr_created" || state.Status == "reviewing" {
 		if state.PRNumber != nil {
+			newReviews, err := p.getNewPRReviews(owner, repo, *state.PRNumber, state)
+			if err != nil {
+				return fmt.Errorf("failed to check for new PR reviews: %w", err)
+			}
 			newReviewComments, err := p.getNewPRComments(owner, repo, *state.PRNumber, state)
 			if err != nil {
 				return fmt.Errorf("failed to check for new PR comments: %w", err)
 			}
 
-			if len(newReviewComments) > 0 {
-				log.Printf("New PR review comments detected on %s/%s #%d - processing %d comment(s)", owner, repo, *state.PRNumber, len(newReviewComments))
-				// Process each new PR comment
-				for _, comment := range newReviewComments {
-					if handlers.HandlePRComment != nil {
-						if err := handlers.HandlePRComment(owner, repo, *state.PRNumber, comment.GetBody()); err != nil {
-							log.Printf("Error handling PR comment on #%d: %v", *state.PRNumber, err)
+			if len(newReviews) > 0 || len(newReviewComments) > 0 {
+				logger.Info("New PR review feedback detected", "pr", *state.PRNumber, "reviews", len(newReviews), "comments", len(newReviewComments))
+				// Process the reviews and comments as one round of feedback
+				if handlers.HandlePRComments != nil {
+					var bodies []string
+					var reviewIDs, commentIDs []int64
+					for _, review := range newReviews {
+						if p.canComment(owner, repo, *state.PRNumber, review.GetUser().GetLogin(), handlers) {
+							bodies = append(bodies, FormatReview(review))
+						}
+						reviewIDs = append(reviewIDs, review.GetID())
+					}
+					for _, comment := range newReviewComments {
+						if p.canComment(owner, repo, *state.PRNumber, comment.GetUser().GetLogin(), handlers) {
+							bodies = append(bodies, comment.GetBody())
 						}
+						commentIDs = append(commentIDs, comment.GetID())
 					}
+					var err error
+					if len(bodies) > 0 {
+						err = handlers.HandlePRComments(owner, repo, *state.PRNumber, bodies)
+					}
+					if err != nil {
+						logger.Error("Error handling PR comments", "pr", *state.PRNumber, "error", err)
+					} else if err := p.stateManager.MarkCommentsProcessed(owner, repo, CommentKindReview, reviewIDs); err != nil {
+						logger.Error("Error recording handled reviews", "pr", *state.PRNumber, "error", err)
+					} else if err := p.stateManager.MarkCommentsProcessed(owner, repo, CommentKindPR, commentIDs); err != nil {
+						logger.Error("Error recording handled PR comments", "pr", *state.PRNumber, "error", err)
+					}
+				}
+			} else if handlers.HandlePullRequest != nil {
+				if err := handlers.HandlePullRequest(owner, repo, *state.PRNumber); err != nil {
+					logger.Error("Error checking PR", "pr", *state.PRNumber, "error", err)
 				}
 			}
 		}
@@ -210,60 +716,87 @@ func (p *Poller) processIssue(owner, repo string, issue *github.Issue, handlers
 	return nil
 }
 
-// reconcileStatus checks if the bot's last comment indicates readiness but status doesn't match
-func (p *Poller) reconcileStatus(owner, repo string, issueNumber int, state *State) error {
-	comments, err := p.github.ListIssueComments(owner, repo, issueNumber)
+// canComment reports whether a comment by author is acted on. A failed permission check
+// skips the comment.
+func (p *Poller) canComment(owner, repo string, number int, author string, handlers PollerHandlers) bool {
+	if handlers.CanComment == nil {
+		return true
+	}
+	allowed, err := handlers.CanComment(owner, repo, number, author)
 	if err != nil {
-		return err
+		IssueLogger(owner, repo, number).Warn("⚠️  Failed to check comment permission", "author", author, "error", err)
+		return false
 	}
+	return allowed
+}
 
-	// Find the bot's last comment
-	var lastBotComment *github.IssueComment
-	for i := len(comments) - 1; i >= 0; i-- {
-		if comments[i].GetUser().GetLogin() == p.username {
-			lastBotComment = comments[i]
-			break
-		}
+// processCommands runs slash commands that haven't been handled yet and reports whether
+// there were any
+func (p *Poller) processCommands(owner, repo string, issueNumber int, state *State, handlers PollerHandlers) (bool, error) {
+	comments, err := p.hosts.For(owner, repo).ListIssueComments(owner, repo, issueNumber)
+	if err != nil {
+		return false, err
 	}
 
-	if lastBotComment == nil {
-		return nil // No bot comments yet
+	handled := false
+	for _, comment := range comments {
+		if comment.GetUser().GetLogin() == p.login(owner, repo) {
+			continue
+		}
+		if _, ok := ParseCommand(comment.GetBody()); !ok {
+			continue
+		}
+		createdAt := comment.GetCreatedAt().Time
+		isNew, err := p.isNewComment(owner, repo, CommentKindIssue, comment.GetID(), createdAt, createdAt.After(state.UpdatedAt))
+		if err != nil {
+			return handled, err
+		}
+		if !isNew {
+			continue
+		}
+		// Commands run at most once, even if they fail
+		if err := p.stateManager.MarkCommentsProcessed(owner, repo, CommentKindIssue, []int64{comment.GetID()}); err != nil {
+			return handled, err
+		}
+		handled = true
+		logger := IssueLogger(owner, repo, issueNumber)
+		logger.Info("⌨️  Command received", "author", comment.GetUser().GetLogin())
+		if err := handlers.HandleCommand(owner, repo, issueNumber, comment.GetUser().GetLogin(), comment.GetBody()); err != nil {
+			logger.Error("Error handling command", "error", err)
+		}
 	}
 
-	// Check if the bot's last comment indicates readiness to implement
-	commentBody := lastBotComment.GetBody()
-	lowerComment := strings.ToLower(commentBody)
+	return handled, nil
+}
 
-	previewLen := 100
-	if len(commentBody) < previewLen {
-		previewLen = len(commentBody)
+// checkStale sends a reminder, then expires issues that have waited too long for clarification
+func (p *Poller) checkStale(owner, repo string, issueNumber int, state *State, handlers PollerHandlers) {
+	if p.staleAfter <= 0 || handlers.HandleStale == nil {
+		return
 	}
-	log.Printf("🔍 Last bot comment (first 100 chars): %s", commentBody[:previewLen])
-
-	indicatesReady := strings.Contains(lowerComment, "i'll create a pr") ||
-		strings.Contains(lowerComment, "i will create a pr") ||
-		strings.Contains(lowerComment, "i'll create the pr") ||
-		strings.Contains(lowerComment, "i will create the pr") ||
-		strings.Contains(lowerComment, "i'll start working") ||
-		strings.Contains(lowerComment, "i will start working") ||
-		strings.Contains(lowerComment, "proceeding with") ||
-		strings.Contains(lowerComment, "i'll proceed") ||
-		strings.Contains(lowerComment, "i will proceed")
-
-	log.Printf("🔍 Indicates ready: %v, Current status: %s", indicatesReady, state.Status)
 
-	if indicatesReady && state.Status == "waiting_for_clarification" {
-		log.Printf("🔄 Reconciling status for issue %s/%s #%d: bot indicated readiness but status was waiting", owner, repo, issueNumber)
-		state.Status = "ready_to_implement"
-		return p.stateManager.SaveState(state)
+	logger := IssueLogger(owner, repo, issueNumber)
+	if state.ReminderSentAt == nil {
+		if time.Since(state.UpdatedAt) > p.staleAfter {
+			logger.Info("⏰ Issue has waited for clarification, sending reminder", "waited", time.Since(state.UpdatedAt).Round(time.Minute))
+			if err := handlers.HandleStale(owner, repo, issueNumber, false); err != nil {
+				logger.Error("Error sending reminder", "error", err)
+			}
+		}
+		return
 	}
 
-	return nil
+	if p.expireAfter > 0 && time.Since(*state.ReminderSentAt) > p.expireAfter {
+		logger.Info("💤 No reply to the reminder, marking stale")
+		if err := handlers.HandleStale(owner, repo, issueNumber, true); err != nil {
+			logger.Error("Error expiring issue", "error", err)
+		}
+	}
 }
 
 // getNewComments returns new comments since last processing
 func (p *Poller) getNewComments(owner, repo string, issueNumber int, state *State) ([]*github.IssueComment, error) {
-	comments, err := p.github.ListIssueComments(owner, repo, issueNumber)
+	comments, err := p.hosts.For(owner, repo).ListIssueComments(owner, repo, issueNumber)
 	if err != nil {
 		return nil, err
 	}
@@ -273,13 +806,26 @@ func (p *Poller) getNewComments(owner, repo string, issueNumber int, state *Stat
 	// Filter out bot's own comments and get new user comments
 	for _, comment := range comments {
 		// Skip if it's the bot's own comment
-		if comment.GetUser().GetLogin() == p.username {
+		if comment.GetUser().GetLogin() == p.login(owner, repo) {
 			continue
 		}
 
-		// Check if comment is newer than state update
-		commentTime := comment.GetCreatedAt().Time
-		if commentTime.After(state.UpdatedAt) {
+		// Slash commands are handled separately
+		if _, ok := ParseCommand(comment.GetBody()); ok {
+			continue
+		}
+
+		// Before processed comments were recorded: comment IDs increase, so anything after
+		// the last handled comment is new, or failing that anything since the last update
+		legacy := comment.GetCreatedAt().Time.After(state.UpdatedAt)
+		if state.LastCommentID > 0 {
+			legacy = comment.GetID() > state.LastCommentID
+		}
+		isNew, err := p.isNewComment(owner, repo, CommentKindIssue, comment.GetID(), comment.GetCreatedAt().Time, legacy)
+		if err != nil {
+			return nil, err
+		}
+		if isNew {
 			newComments = append(newComments, comment)
 		}
 	}
@@ -289,7 +835,7 @@ func (p *Poller) getNewComments(owner, repo string, issueNumber int, state *Stat
 
 // getNewPRComments returns new PR review comments since last processing
 func (p *Poller) getNewPRComments(owner, repo string, prNumber int, state *State) ([]*github.PullRequestComment, error) {
-	comments, err := p.github.ListPRComments(owner, repo, prNumber)
+	comments, err := p.hosts.For(owner, repo).ListPRComments(owner, repo, prNumber)
 	if err != nil {
 		return nil, err
 	}
@@ -299,16 +845,86 @@ func (p *Poller) getNewPRComments(owner, repo string, prNumber int, state *State
 	// Filter out bot's own comments and get new review comments
 	for _, comment := range comments {
 		// Skip if it's the bot's own comment
-		if comment.GetUser().GetLogin() == p.username {
+		if comment.GetUser().GetLogin() == p.login(owner, repo) {
+			continue
+		}
+
+		// Slash commands are handled separately
+		if _, ok := ParseCommand(comment.GetBody()); ok {
 			continue
 		}
 
-		// Check if comment is newer than state update
-		commentTime := comment.GetCreatedAt().Time
-		if commentTime.After(state.UpdatedAt) {
+		createdAt := comment.GetCreatedAt().Time
+		isNew, err := p.isNewComment(owner, repo, CommentKindPR, comment.GetID(), createdAt, createdAt.After(state.UpdatedAt))
+		if err != nil {
+			return nil, err
+		}
+		if isNew {
 			newComments = append(newComments, comment)
 		}
 	}
 
 	return newComments, nil
 }
+
+// getNewPRReviews returns reviews with feedback submitted since last processing
+func (p *Poller) getNewPRReviews(owner, repo string, prNumber int, state *State) ([]*github.PullRequestReview, error) {
+	reviews, err := p.hosts.For(owner, repo).ListReviews(owner, repo, prNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var newReviews []*github.PullRequestReview
+	for _, review := range reviews {
+		if review.GetUser().GetLogin() == p.login(owner, repo) || !IsReviewFeedback(review) {
+			continue
+		}
+		submittedAt := review.GetSubmittedAt().Time
+		isNew, err := p.isNewComment(owner, repo, CommentKindReview, review.GetID(), submittedAt, submittedAt.After(state.UpdatedAt))
+		if err != nil {
+			return nil, err
+		}
+		if isNew {
+			newReviews = append(newReviews, review)
+		}
+	}
+
+	return newReviews, nil
+}
+
+// isNewComment reports whether a comment, review comment or review still needs handling.
+// Anything posted since processed comments started being recorded is new until it is
+// recorded; older comments are new if legacy (the previous timestamp rule) says so.
+func (p *Poller) isNewComment(owner, repo, kind string, id int64, createdAt time.Time, legacy bool) (bool, error) {
+	processed, err := p.stateManager.IsCommentProcessed(owner, repo, kind, id)
+	if err != nil || processed {
+		return false, err
+	}
+	if createdAt.After(p.commentsSince) {
+		return true, nil
+	}
+	return legacy, nil
+}
+
+// IsReviewFeedback reports whether a review asks for changes or comments with a summary.
+// Approvals are not treated as feedback, even with a summary.
+func IsReviewFeedback(review *github.PullRequestReview) bool {
+	if strings.TrimSpace(review.GetBody()) == "" {
+		return false
+	}
+	// The API reports states in upper case, webhook payloads in lower case
+	switch strings.ToUpper(review.GetState()) {
+	case "CHANGES_REQUESTED", "COMMENTED":
+		return true
+	}
+	return false
+}
+
+// FormatReview renders a review's summary as feedback, noting whether changes were requested
+func FormatReview(review *github.PullRequestReview) string {
+	verdict := "commented"
+	if strings.EqualFold(review.GetState(), "CHANGES_REQUESTED") {
+		verdict = "requested changes"
+	}
+	return fmt.Sprintf("Review by @%s (%s):\n%s", review.GetUser().GetLogin(), verdict, review.GetBody())
+}
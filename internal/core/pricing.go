@@ -0,0 +1,91 @@
+package core
+
+import (
+	"strings"
+)
+
+// ModelPrice is what a model costs in USD per million tokens
+type ModelPrice struct {
+	Input  float64
+	Output float64
+}
+
+// defaultPrices are list prices used to estimate cost when the provider doesn't report it,
+// keyed by model name without the provider prefix and matched by longest prefix
+var defaultPrices = map[string]ModelPrice{
+	"claude-opus-4":     {Input: 15, Output: 75},
+	"claude-sonnet-4":   {Input: 3, Output: 15},
+	"claude-3-7-sonnet": {Input: 3, Output: 15},
+	"claude-3-5-sonnet": {Input: 3, Output: 15},
+	"claude-3-5-haiku":  {Input: 0.8, Output: 4},
+	"claude-3-haiku":    {Input: 0.25, Output: 1.25},
+
+	"gpt-4o":       {Input: 2.5, Output: 10},
+	"gpt-4o-mini":  {Input: 0.15, Output: 0.6},
+	"gpt-4.1":      {Input: 2, Output: 8},
+	"gpt-4.1-mini": {Input: 0.4, Output: 1.6},
+	"gpt-4.1-nano": {Input: 0.1, Output: 0.4},
+	"o1":           {Input: 15, Output: 60},
+	"o3":           {Input: 2, Output: 8},
+	"o3-mini":      {Input: 1.1, Output: 4.4},
+	"o4-mini":      {Input: 1.1, Output: 4.4},
+
+	"gemini-2.5-pro":   {Input: 1.25, Output: 10},
+	"gemini-2.5-flash": {Input: 0.3, Output: 2.5},
+	"deepseek-chat":    {Input: 0.27, Output: 1.1},
+	"deepseek-r1":      {Input: 0.55, Output: 2.19},
+
+	"text-embedding-3-small": {Input: 0.02},
+	"text-embedding-3-large": {Input: 0.13},
+}
+
+// SetPrices configures model prices that take precedence over the built-in table, keyed
+// like it by model name or prefix
+func (ca *ClaudeAgent) SetPrices(prices map[string]ModelPrice) {
+	ca.prices = prices
+}
+
+// modelPrice looks up the price of a model, configured prices first, by the longest
+// matching prefix of its name with or without the provider prefix
+func (ca *ClaudeAgent) modelPrice(model string) (ModelPrice, bool) {
+	if strings.HasSuffix(model, ":free") {
+		return ModelPrice{}, false
+	}
+	names := []string{model}
+	if _, bare, ok := strings.Cut(model, "/"); ok {
+		names = append(names, bare)
+	}
+
+	for _, table := range []map[string]ModelPrice{ca.prices, defaultPrices} {
+		var best string
+		var price ModelPrice
+		for _, name := range names {
+			for prefix, p := range table {
+				if strings.HasPrefix(name, prefix) && len(prefix) > len(best) {
+					best, price = prefix, p
+				}
+			}
+		}
+		if best != "" {
+			return price, true
+		}
+	}
+	return ModelPrice{}, false
+}
+
+// estimateCost fills in the cost of a request the provider didn't report a cost for from
+// the price table. Free models and models without a known price are left at zero.
+func (ca *ClaudeAgent) estimateCost(usage *TokenUsage, model string) {
+	if usage.Cost > 0 {
+		return
+	}
+	if usage.Model != "" {
+		model = usage.Model
+	}
+	price, ok := ca.modelPrice(model)
+	if !ok {
+		return
+	}
+	usage.Cost = (float64(usage.InputTokens)*price.Input + float64(usage.OutputTokens)*price.Output) / 1e6
+	usage.CostEstimated = usage.Cost > 0
+}
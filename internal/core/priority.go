@@ -0,0 +1,129 @@
+package core
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v63/github"
+)
+
+// SetIssuePriority records the priority a maintainer gave an issue; 1 is handled first.
+// A priority of 0 clears it.
+func (sm *StateManager) SetIssuePriority(owner, repo string, issueNumber, priority int, setBy string) error {
+	var err error
+	if priority <= 0 {
+		_, err = sm.db.Exec(`
+			DELETE FROM issue_priorities WHERE owner = ? AND repo = ? AND issue_number = ?
+		`, owner, repo, issueNumber)
+	} else {
+		_, err = sm.db.Exec(`
+			INSERT INTO issue_priorities (owner, repo, issue_number, priority, set_by, set_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT(owner, repo, issue_number) DO UPDATE SET
+				priority = excluded.priority, set_by = excluded.set_by, set_at = excluded.set_at
+		`, owner, repo, issueNumber, priority, setBy, time.Now())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set issue priority: %w", err)
+	}
+	return nil
+}
+
+// IssuePriorities returns the priorities set on a repository's issues, by issue number
+func (sm *StateManager) IssuePriorities(owner, repo string) (map[int]int, error) {
+	rows, err := sm.db.Query(`
+		SELECT issue_number, priority FROM issue_priorities WHERE owner = ? AND repo = ?
+	`, owner, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get issue priorities: %w", err)
+	}
+	defer rows.Close()
+
+	priorities := make(map[int]int)
+	for rows.Next() {
+		var issueNumber, priority int
+		if err := rows.Scan(&issueNumber, &priority); err != nil {
+			return nil, fmt.Errorf("failed to scan issue priority: %w", err)
+		}
+		priorities[issueNumber] = priority
+	}
+	return priorities, rows.Err()
+}
+
+// listedRepository is a repository whose assigned issues were listed this poll
+type listedRepository struct {
+	owner, repo string
+	fullName    string
+	issues      []*github.Issue
+}
+
+// queuedIssue is an assigned issue waiting its turn in a poll
+type queuedIssue struct {
+	owner, repo string
+	issue       *github.Issue
+	priority    int // Set with /nytebubo priority; 0 when unset
+	labelRank   int // Index of the first priority label the issue carries, len(priorityLabels) for none
+}
+
+// queueIssues prepares a repository's assigned issues for scheduling. Without their
+// explicit priorities, which failed to load, the issues are still returned.
+func (p *Poller) queueIssues(owner, repo string, issues []*github.Issue) ([]queuedIssue, error) {
+	priorities, err := p.stateManager.IssuePriorities(owner, repo)
+	queue := make([]queuedIssue, len(issues))
+	for i, issue := range issues {
+		queue[i] = queuedIssue{
+			owner:     owner,
+			repo:      repo,
+			issue:     issue,
+			priority:  priorities[issue.GetNumber()],
+			labelRank: p.labelRank(issue),
+		}
+	}
+	return queue, err
+}
+
+// labelRank returns the index of the first priority label an issue carries, or
+// len(priorityLabels) if it carries none
+func (p *Poller) labelRank(issue *github.Issue) int {
+	for i, priorityLabel := range p.priorityLabels {
+		for _, label := range issue.Labels {
+			if strings.EqualFold(label.GetName(), priorityLabel) {
+				return i
+			}
+		}
+	}
+	return len(p.priorityLabels)
+}
+
+// schedule orders issues for handling: those given a priority with /nytebubo priority come
+// first, lowest number first, then issues by their priority labels, then the oldest
+func (p *Poller) schedule(queue []queuedIssue) []queuedIssue {
+	slices.SortStableFunc(queue, func(a, b queuedIssue) int {
+		if a.priority != b.priority {
+			switch {
+			case a.priority == 0:
+				return 1
+			case b.priority == 0:
+				return -1
+			}
+			return cmp.Compare(a.priority, b.priority)
+		}
+		if a.labelRank != b.labelRank {
+			return cmp.Compare(a.labelRank, b.labelRank)
+		}
+		return a.issue.GetCreatedAt().Time.Compare(b.issue.GetCreatedAt().Time)
+	})
+
+	if len(queue) > 1 {
+		order := make([]string, len(queue))
+		for i, queued := range queue {
+			order[i] = fmt.Sprintf("%s/%s#%d", queued.owner, queued.repo, queued.issue.GetNumber())
+		}
+		slog.Debug("Scheduled assigned issues", "order", order)
+	}
+	return queue
+}
@@ -0,0 +1,55 @@
+package core
+
+import "fmt"
+
+// LinkPullRequestIssues records that a pull request closes issues, in addition to any
+// already linked to it
+func (sm *StateManager) LinkPullRequestIssues(owner, repo string, prNumber int, issueNumbers []int) error {
+	if len(issueNumbers) == 0 {
+		return nil
+	}
+
+	tx, err := sm.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, issueNumber := range issueNumbers {
+		if _, err := tx.Exec(`
+			INSERT INTO pr_issues (owner, repo, pr_number, issue_number)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT DO NOTHING
+		`, owner, repo, prNumber, issueNumber); err != nil {
+			return fmt.Errorf("failed to link issue #%d: %w", issueNumber, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to link issues: %w", err)
+	}
+	return nil
+}
+
+// ListPullRequestIssues returns the issues linked to a pull request, lowest number first
+func (sm *StateManager) ListPullRequestIssues(owner, repo string, prNumber int) ([]int, error) {
+	rows, err := sm.db.Query(`
+		SELECT issue_number FROM pr_issues
+		WHERE owner = ? AND repo = ? AND pr_number = ?
+		ORDER BY issue_number
+	`, owner, repo, prNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list linked issues: %w", err)
+	}
+	defer rows.Close()
+
+	var issueNumbers []int
+	for rows.Next() {
+		var issueNumber int
+		if err := rows.Scan(&issueNumber); err != nil {
+			return nil, fmt.Errorf("failed to scan linked issue: %w", err)
+		}
+		issueNumbers = append(issueNumbers, issueNumber)
+	}
+	return issueNumbers, rows.Err()
+}
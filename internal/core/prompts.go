@@ -0,0 +1,164 @@
+package core
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+//go:embed prompts/*.tmpl
+var promptFiles embed.FS
+
+// System prompts, each a template in prompts/<name>.tmpl
+const (
+	PromptAnalysis       = "analysis"        // First look at an issue
+	PromptConfirm        = "confirm"         // Deciding whether a conversation is ready for implementation
+	PromptReply          = "reply"           // Replying to issue comments
+	PromptReadiness      = "readiness"       // Classifying whether questions are still open
+	PromptSummarize      = "summarize"       // Summarizing long conversations
+	PromptPlan           = "plan"            // Planning multi-step changes
+	PromptDecompose      = "decompose"       // Splitting large issues into several pull requests
+	PromptFollowUps      = "followups"       // Finding work a pull request leaves for later
+	PromptCodegen        = "codegen"         // Generating complete files
+	PromptEdits          = "edits"           // Generating search/replace edits
+	PromptAgent          = "agent"           // Working in the sandbox with tools
+	PromptReviewFeedback = "review_feedback" // Addressing pull request review feedback
+	PromptConflicts      = "conflicts"       // Resolving merge conflicts
+	PromptPRReview       = "pr_review"       // Reviewing pull requests
+	PromptMention        = "mention"         // Answering mentions on pull requests
+	PromptTriage         = "triage"          // Triaging new issues
+)
+
+// PromptData holds the variables available to prompt templates. Fields a prompt has no
+// value for are empty.
+type PromptData struct {
+	Language    string // Programming language of the repository
+	Context     string // Repository context: files, structure and relevant code
+	Task        string // The issue or plan step being implemented
+	Conventions string // The repository's instructions for the AI, e.g. from NYTEBUBO.md
+
+	MaxSteps   int    // Tool calls available (agent)
+	Labels     string // The repository's labels (triage)
+	OpenIssues string // Other open issues (triage)
+	Comments   int    // New comments being replied to (reply)
+}
+
+// Prompts holds the system prompt templates, built-in ones overridden by the user's
+type Prompts struct {
+	templates map[string]*template.Template
+}
+
+// defaultPrompts are the built-in prompts, used when none are configured
+var defaultPrompts = mustLoadDefaultPrompts()
+
+func mustLoadDefaultPrompts() *Prompts {
+	prompts, err := LoadPrompts("", nil)
+	if err != nil {
+		panic(err)
+	}
+	return prompts
+}
+
+// PromptNames returns the names of the system prompts
+func PromptNames() []string {
+	entries, _ := promptFiles.ReadDir("prompts")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".tmpl"))
+	}
+	return names
+}
+
+// DefaultPrompt returns the built-in template text of a prompt
+func DefaultPrompt(name string) (string, error) {
+	content, err := promptFiles.ReadFile("prompts/" + name + ".tmpl")
+	if err != nil {
+		return "", fmt.Errorf("unknown prompt %q (expected one of %s)", name, strings.Join(PromptNames(), ", "))
+	}
+	return string(content), nil
+}
+
+// LoadPrompts parses the built-in prompts, overridden by <name>.tmpl files in dir and then
+// by overrides, keyed by prompt name. Every template is checked to render.
+func LoadPrompts(dir string, overrides map[string]string) (*Prompts, error) {
+	names := PromptNames()
+	sources := make(map[string]string, len(names))
+	for _, name := range names {
+		text, err := DefaultPrompt(name)
+		if err != nil {
+			return nil, err
+		}
+		sources[name] = text
+	}
+
+	if dir != "" {
+		for _, name := range names {
+			content, err := os.ReadFile(filepath.Join(dir, name+".tmpl"))
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read prompt %s: %w", name, err)
+			}
+			sources[name] = string(content)
+		}
+	}
+	for name, text := range overrides {
+		if !slices.Contains(names, name) {
+			return nil, fmt.Errorf("unknown prompt %q (expected one of %s)", name, strings.Join(names, ", "))
+		}
+		sources[name] = text
+	}
+
+	prompts := &Prompts{templates: make(map[string]*template.Template, len(sources))}
+	for name, text := range sources {
+		tmpl, err := template.New(name).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse prompt %s: %w", name, err)
+		}
+		// Catch unknown variables now rather than on the first request
+		if err := tmpl.Execute(&strings.Builder{}, PromptData{}); err != nil {
+			return nil, fmt.Errorf("failed to render prompt %s: %w", name, err)
+		}
+		prompts.templates[name] = tmpl
+	}
+	return prompts, nil
+}
+
+// render executes a prompt template
+func (p *Prompts) render(name string, data PromptData) (string, error) {
+	tmpl, ok := p.templates[name]
+	if !ok {
+		return "", fmt.Errorf("unknown prompt %q", name)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt %s: %w", name, err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// SetPrompts configures the system prompt templates (the built-in ones when unset)
+func (ca *ClaudeAgent) SetPrompts(prompts *Prompts) {
+	ca.prompts = prompts
+}
+
+// SystemPrompt renders a system prompt, with the repository's instructions as Conventions.
+// A prompt that fails to render falls back to the built-in one.
+func (ca *ClaudeAgent) SystemPrompt(name string, data PromptData) string {
+	data.Conventions = ca.instructions
+	if ca.prompts != nil {
+		prompt, err := ca.prompts.render(name, data)
+		if err == nil {
+			return prompt
+		}
+		ca.log().Warn("⚠️  Failed to render prompt, using the built-in one", "prompt", name, "error", err)
+	}
+	prompt, _ := defaultPrompts.render(name, data)
+	return prompt
+}
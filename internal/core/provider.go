@@ -0,0 +1,80 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Supported LLM providers
+const (
+	ProviderOpenRouter = "openrouter"
+	ProviderAnthropic  = "anthropic"
+	ProviderOpenAI     = "openai"
+	ProviderLocal      = "local"
+	ProviderAzure      = "azure"
+)
+
+// CompletionRequest is a provider-neutral chat completion request
+type CompletionRequest struct {
+	Model    string
+	System   string
+	Messages []AgentMessage
+	Params   GenerationParams
+	Schema   *jsonSchema // Ask for JSON output matching this schema (ignored by providers without support)
+}
+
+// LLMProvider sends completion requests to a specific LLM API
+type LLMProvider interface {
+	// Name identifies the provider in logs and errors
+	Name() string
+	// DefaultModel is used when no model is configured
+	DefaultModel() string
+	// Endpoint is the URL completion requests are sent to, checked by the readiness probe
+	Endpoint() string
+	// Complete performs a single completion request
+	Complete(ctx context.Context, req CompletionRequest) (string, TokenUsage, error)
+}
+
+// EmbeddingProvider is implemented by providers that can compute text embeddings
+type EmbeddingProvider interface {
+	Embed(ctx context.Context, model string, texts []string) ([][]float64, TokenUsage, error)
+}
+
+// ProviderOptions holds the settings of providers that need more than an API key
+type ProviderOptions struct {
+	Local LocalOptions
+	Azure AzureOptions
+}
+
+// NewProvider creates the named LLM provider. An empty name selects OpenRouter.
+func NewProvider(name, apiKey string, options ProviderOptions) (LLMProvider, error) {
+	switch strings.ToLower(name) {
+	case "", ProviderOpenRouter:
+		return NewOpenRouterProvider(apiKey), nil
+	case ProviderAnthropic:
+		return NewAnthropicProvider(apiKey), nil
+	case ProviderOpenAI:
+		return NewOpenAIProvider(apiKey), nil
+	case ProviderLocal:
+		return NewLocalProvider(options.Local, apiKey)
+	case ProviderAzure:
+		return NewAzureProvider(options.Azure, apiKey)
+	default:
+		return nil, fmt.Errorf("unknown provider %q (expected %q, %q, %q, %q or %q)",
+			name, ProviderOpenRouter, ProviderAnthropic, ProviderOpenAI, ProviderLocal, ProviderAzure)
+	}
+}
+
+// logUsage logs the token usage and cost of a completion
+func (ca *ClaudeAgent) logUsage(usage TokenUsage) {
+	ca.log().Info("📊 API usage",
+		"provider", ca.provider.Name(),
+		"model", usage.Model,
+		"input_tokens", usage.InputTokens,
+		"output_tokens", usage.OutputTokens,
+		"reasoning_tokens", usage.ReasoningTokens,
+		"total_tokens", usage.TotalTokens,
+		"cost", fmt.Sprintf("$%.4f", usage.Cost),
+		"cost_estimated", usage.CostEstimated)
+}
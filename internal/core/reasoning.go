@@ -0,0 +1,27 @@
+package core
+
+import (
+	"regexp"
+	"strings"
+)
+
+// reasoningBlockRe matches inline reasoning blocks emitted by some models
+var reasoningBlockRe = regexp.MustCompile(`(?is)<(think|thinking|reasoning)>.*?</(think|thinking|reasoning)>`)
+
+// unclosedReasoningRe matches a reasoning block that was opened but never closed
+// (e.g. when the output was truncated mid-thought)
+var unclosedReasoningRe = regexp.MustCompile(`(?is)^\s*<(think|thinking|reasoning)>.*$`)
+
+// StripReasoning removes inline reasoning/thinking blocks from a model response
+func StripReasoning(content string) string {
+	if !strings.Contains(content, "<") {
+		return content
+	}
+
+	stripped := reasoningBlockRe.ReplaceAllString(content, "")
+	stripped = unclosedReasoningRe.ReplaceAllString(stripped, "")
+	if stripped == content {
+		return content
+	}
+	return strings.TrimSpace(stripped)
+}
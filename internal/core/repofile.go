@@ -0,0 +1,56 @@
+package core
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RepoFileName is the policy file maintainers can check into a repository
+const RepoFileName = ".nytebubo.yaml"
+
+// RepoFile holds the settings maintainers set in a repository's .nytebubo.yaml
+type RepoFile struct {
+	TriggerLabels  []string `yaml:"trigger_labels,omitempty"`  // Labels that start work when the agent runs with trigger: label
+	ProtectedPaths []string `yaml:"protected_paths,omitempty"` // Files the bot must not change, e.g. ".github/**" or "*.lock"
+	Model          string   `yaml:"model,omitempty"`           // Preferred model, unless an issue picks its own
+	Conventions    string   `yaml:"conventions,omitempty"`     // Coding conventions added to the AI's instructions
+	BuildCommand   string   `yaml:"build_command,omitempty"`   // Shell command that builds the project, e.g. "make"
+	TestCommand    string   `yaml:"test_command,omitempty"`    // Shell command that runs the tests, e.g. "make test"
+}
+
+// ParseRepoFile parses the contents of a .nytebubo.yaml
+func ParseRepoFile(content string) (*RepoFile, error) {
+	var file RepoFile
+	if err := yaml.Unmarshal([]byte(content), &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", RepoFileName, err)
+	}
+	return &file, nil
+}
+
+// IsProtectedPath reports whether a repository file matches one of the patterns. A
+// pattern ending in "/" or "/**" covers a directory; one without a slash matches the
+// file name in any directory, like in .gitignore.
+func IsProtectedPath(patterns []string, relativePath string) bool {
+	relativePath = path.Clean(filepath.ToSlash(relativePath))
+	for _, pattern := range patterns {
+		pattern = strings.TrimPrefix(pattern, "/")
+		if dir, ok := strings.CutSuffix(strings.TrimSuffix(pattern, "**"), "/"); ok {
+			if relativePath == dir || strings.HasPrefix(relativePath, dir+"/") {
+				return true
+			}
+			continue
+		}
+		name := relativePath
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(relativePath)
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
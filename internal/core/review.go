@@ -0,0 +1,197 @@
+package core
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CodeReview is the AI's review of a pull request
+type CodeReview struct {
+	Summary  string              `json:"summary"`
+	Comments []CodeReviewComment `json:"comments"`
+}
+
+// CodeReviewComment is a review comment on one added line of a pull request
+type CodeReviewComment struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Body string `json:"body"`
+}
+
+// codeReviewSchema returns the JSON schema for pull request reviews
+func codeReviewSchema() *jsonSchema {
+	return &jsonSchema{
+		Name:   "code_review",
+		Strict: true,
+		Schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"summary": map[string]any{
+					"type":        "string",
+					"description": "Overall assessment of the pull request in markdown",
+				},
+				"comments": map[string]any{
+					"type":        "array",
+					"description": "Comments on specific added lines (empty if none)",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"path": map[string]any{"type": "string"},
+							"line": map[string]any{
+								"type":        "integer",
+								"description": "Line number of an added line in the new version of the file",
+							},
+							"body": map[string]any{"type": "string"},
+						},
+						"required":             []string{"path", "line", "body"},
+						"additionalProperties": false,
+					},
+				},
+			},
+			"required":             []string{"summary", "comments"},
+			"additionalProperties": false,
+		},
+	}
+}
+
+// ReviewPullRequest asks Claude to review a pull request's diff. Added lines in the diff are
+// prefixed with their line number in the new file so comments can be placed on them.
+func (ca *ClaudeAgent) ReviewPullRequest(title, description, diff string) (CodeReview, TokenUsage, error) {
+	systemPrompt := ca.SystemPrompt(PromptPRReview, PromptData{})
+
+	messages := []AgentMessage{{
+		Role:    "user",
+		Content: fmt.Sprintf("Pull request: %s\n\n%s\n\nDiff:\n%s\n\nReview this pull request. Respond with the JSON object only.", title, description, diff),
+	}}
+
+	response, usage, err := ca.sendWithSchema(StageReview, messages, systemPrompt, codeReviewSchema())
+	if err != nil {
+		return CodeReview{}, usage, err
+	}
+
+	review, err := parseCodeReview(response)
+	if err != nil {
+		return CodeReview{}, usage, err
+	}
+	return review, usage, nil
+}
+
+// parseCodeReview extracts the review JSON from a response, tolerating code fences and surrounding text
+func parseCodeReview(response string) (CodeReview, error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return CodeReview{}, fmt.Errorf("no JSON object in review response")
+	}
+
+	var review CodeReview
+	if err := json.Unmarshal([]byte(response[start:end+1]), &review); err != nil {
+		return CodeReview{}, fmt.Errorf("failed to parse review response: %w", err)
+	}
+	return review, nil
+}
+
+// ReviewState tracks the agent's reviews of a pull request it didn't author
+type ReviewState struct {
+	ID          int64
+	Owner       string
+	Repo        string
+	PRNumber    int
+	Status      string // "reviewing" or "reviewed"
+	ReviewedSHA string // Head commit of the latest review
+	Reviews     int    // Reviews posted so far
+	// Token usage tracking
+	TotalInputTokens     int64
+	TotalOutputTokens    int64
+	TotalReasoningTokens int64
+	TotalCost            float64
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+}
+
+// AddUsage adds the token usage and cost of an API call to the review totals
+func (s *ReviewState) AddUsage(usage TokenUsage) {
+	s.TotalInputTokens += usage.InputTokens
+	s.TotalOutputTokens += usage.OutputTokens
+	s.TotalReasoningTokens += usage.ReasoningTokens
+	s.TotalCost += usage.Cost
+}
+
+// GetReviewState retrieves the review state of a pull request (nil if it was never reviewed)
+func (sm *StateManager) GetReviewState(owner, repo string, prNumber int) (*ReviewState, error) {
+	var state ReviewState
+	err := sm.db.QueryRow(`
+		SELECT id, owner, repo, pr_number, status, reviewed_sha, reviews,
+		       total_input_tokens, total_output_tokens, total_reasoning_tokens, total_cost, created_at, updated_at
+		FROM pr_reviews
+		WHERE owner = ? AND repo = ? AND pr_number = ?
+	`, owner, repo, prNumber).Scan(&state.ID, &state.Owner, &state.Repo, &state.PRNumber, &state.Status,
+		&state.ReviewedSHA, &state.Reviews, &state.TotalInputTokens, &state.TotalOutputTokens,
+		&state.TotalReasoningTokens, &state.TotalCost, &state.CreatedAt, &state.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review state: %w", err)
+	}
+	return &state, nil
+}
+
+// SaveReviewState saves or updates the review state of a pull request, adding any new cost
+// to the monthly spend
+func (sm *StateManager) SaveReviewState(state *ReviewState) error {
+	now := time.Now()
+	if state.CreatedAt.IsZero() {
+		state.CreatedAt = now
+	}
+	state.UpdatedAt = now
+
+	tx, err := sm.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var previousCost float64
+	err = tx.QueryRow(`SELECT total_cost FROM pr_reviews WHERE owner = ? AND repo = ? AND pr_number = ?`+sm.db.dialect.forUpdate(),
+		state.Owner, state.Repo, state.PRNumber).Scan(&previousCost)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to save review state: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO pr_reviews (owner, repo, pr_number, status, reviewed_sha, reviews,
+		                        total_input_tokens, total_output_tokens, total_reasoning_tokens, total_cost, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(owner, repo, pr_number) DO UPDATE SET
+			status = excluded.status,
+			reviewed_sha = excluded.reviewed_sha,
+			reviews = excluded.reviews,
+			total_input_tokens = excluded.total_input_tokens,
+			total_output_tokens = excluded.total_output_tokens,
+			total_reasoning_tokens = excluded.total_reasoning_tokens,
+			total_cost = excluded.total_cost,
+			updated_at = excluded.updated_at
+	`, state.Owner, state.Repo, state.PRNumber, state.Status, state.ReviewedSHA, state.Reviews,
+		state.TotalInputTokens, state.TotalOutputTokens, state.TotalReasoningTokens, state.TotalCost,
+		state.CreatedAt, state.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save review state: %w", err)
+	}
+
+	if delta := state.TotalCost - previousCost; delta > 0 {
+		if _, err := tx.Exec(`
+			INSERT INTO monthly_spend (month, cost) VALUES (?, ?)
+			ON CONFLICT(month) DO UPDATE SET cost = monthly_spend.cost + excluded.cost
+		`, spendMonth(now), delta); err != nil {
+			return fmt.Errorf("failed to record spend: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save review state: %w", err)
+	}
+	return nil
+}
@@ -1,11 +1,15 @@
 package core
 
 import (
+	"bytes"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Sandbox provides an isolated workspace for making and testing changes
@@ -15,15 +19,26 @@ type Sandbox struct {
 	owner         string
 	repo          string
 	issueNumber   int
-	githubToken   string
+	cloneURL      string
 	defaultBranch string
+	dryRun        *DryRun // Set to report pushes instead of making them
+
+	commandTimeout time.Duration // Limit on each build or test command (0 disables)
+	maxOutputBytes int           // Command output kept (0 keeps everything)
+	buildCommand   string        // Shell command overriding the detected build command
+	testCommand    string        // Shell command overriding the detected test command
+	protectedPaths []string      // Patterns of files that can't be changed
+}
+
+// WorkspacePath returns the directory an issue's sandbox is cloned into:
+// workspace/owner-repo-123
+func WorkspacePath(workspaceRoot, owner, repo string, issueNumber int) string {
+	return filepath.Join(workspaceRoot, fmt.Sprintf("%s-%s-%d", owner, repo, issueNumber))
 }
 
-// NewSandbox creates a new isolated workspace for an issue
-func NewSandbox(workspaceRoot, owner, repo string, issueNumber int, githubToken string) (*Sandbox, error) {
-	// Create workspace directory: workspace/owner-repo-issue-123
-	workspaceName := fmt.Sprintf("%s-%s-%d", owner, repo, issueNumber)
-	repoPath := filepath.Join(workspaceRoot, workspaceName)
+// NewSandbox creates a new isolated workspace for an issue, cloned from cloneURL
+func NewSandbox(workspaceRoot, owner, repo string, issueNumber int, cloneURL string) (*Sandbox, error) {
+	repoPath := WorkspacePath(workspaceRoot, owner, repo, issueNumber)
 
 	return &Sandbox{
 		workspaceRoot: workspaceRoot,
@@ -31,19 +46,32 @@ func NewSandbox(workspaceRoot, owner, repo string, issueNumber int, githubToken
 		owner:         owner,
 		repo:          repo,
 		issueNumber:   issueNumber,
-		githubToken:   githubToken,
+		cloneURL:      cloneURL,
+
+		commandTimeout: DefaultCommandTimeout,
+		maxOutputBytes: DefaultMaxOutputBytes,
 	}, nil
 }
 
+// SetDryRun reports pushed branches to dryRun instead of pushing them
+func (s *Sandbox) SetDryRun(dryRun *DryRun) {
+	s.dryRun = dryRun
+}
+
+// Logger returns a logger tagged with the sandbox's issue
+func (s *Sandbox) Logger() *slog.Logger {
+	return IssueLogger(s.owner, s.repo, s.issueNumber)
+}
+
 // CloneRepo clones the repository into the sandbox workspace
 func (s *Sandbox) CloneRepo() error {
 	// Check if workspace already exists
 	if _, err := os.Stat(s.repoPath); err == nil {
-		fmt.Printf("📁 Workspace already exists, using existing clone: %s\n", s.repoPath)
+		s.Logger().Info("📁 Workspace already exists, using existing clone", "path", s.repoPath)
 		return nil
 	}
 
-	fmt.Printf("📥 Cloning repository %s/%s into sandbox...\n", s.owner, s.repo)
+	s.Logger().Info("📥 Cloning repository into sandbox")
 
 	// Create workspace root if it doesn't exist
 	if err := os.MkdirAll(s.workspaceRoot, 0755); err != nil {
@@ -51,9 +79,7 @@ func (s *Sandbox) CloneRepo() error {
 	}
 
 	// Clone with HTTPS using token authentication
-	cloneURL := fmt.Sprintf("https://%s@github.com/%s/%s.git", s.githubToken, s.owner, s.repo)
-
-	cmd := exec.Command("git", "clone", cloneURL, s.repoPath)
+	cmd := exec.Command("git", "clone", s.cloneURL, s.repoPath)
 	// Don't show token in output
 	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
 
@@ -62,7 +88,7 @@ func (s *Sandbox) CloneRepo() error {
 		return fmt.Errorf("failed to clone repo: %w\nOutput: %s", err, output)
 	}
 
-	fmt.Printf("✅ Repository cloned successfully\n")
+	s.Logger().Info("✅ Repository cloned successfully")
 	return nil
 }
 
@@ -96,7 +122,7 @@ func (s *Sandbox) GetDefaultBranch() (string, error) {
 
 // CreateBranch creates a new branch for the issue
 func (s *Sandbox) CreateBranch(branchName string) error {
-	fmt.Printf("🌿 Creating branch: %s\n", branchName)
+	s.Logger().Info("🌿 Creating branch", "branch", branchName)
 
 	// Ensure we're on the default branch first
 	defaultBranch, err := s.GetDefaultBranch()
@@ -115,7 +141,7 @@ func (s *Sandbox) CreateBranch(branchName string) error {
 	cmd = exec.Command("git", "pull", "origin", defaultBranch)
 	cmd.Dir = s.repoPath
 	if _, err := cmd.CombinedOutput(); err != nil {
-		fmt.Printf("⚠️  Warning: failed to pull latest changes: %v\n", err)
+		s.Logger().Warn("⚠️  Failed to pull latest changes", "error", err)
 		// Continue anyway - might be empty repo
 	}
 
@@ -126,13 +152,76 @@ func (s *Sandbox) CreateBranch(branchName string) error {
 		return fmt.Errorf("failed to create branch: %w\nOutput: %s", err, output)
 	}
 
-	fmt.Printf("✅ Branch created successfully\n")
+	s.Logger().Info("✅ Branch created successfully")
+	return nil
+}
+
+// CheckoutBranch checks out an existing remote branch, e.g. to push follow-up commits to a pull request
+func (s *Sandbox) CheckoutBranch(branchName string) error {
+	s.Logger().Info("🌿 Checking out branch", "branch", branchName)
+
+	cmd := exec.Command("git", "fetch", "origin", branchName)
+	cmd.Dir = s.repoPath
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to fetch branch %s: %w\nOutput: %s", branchName, err, output)
+	}
+
+	cmd = exec.Command("git", "checkout", "-B", branchName, "origin/"+branchName)
+	cmd.Dir = s.repoPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to checkout branch %s: %w\nOutput: %s", branchName, err, output)
+	}
+
 	return nil
 }
 
-// WriteFile writes content to a file in the sandbox
+// resolvePath returns the absolute path of a repository file, rejecting paths
+// that would escape the workspace or touch git metadata
+func (s *Sandbox) resolvePath(relativePath string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(relativePath))
+	if filepath.IsAbs(cleaned) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid file path %q", relativePath)
+	}
+	if first, _, _ := strings.Cut(filepath.ToSlash(cleaned), "/"); first == ".git" {
+		return "", fmt.Errorf("refusing to access git metadata: %q", relativePath)
+	}
+	return filepath.Join(s.repoPath, cleaned), nil
+}
+
+// InRepository reports whether a path resolves to a file of the repository, outside .git
+func (s *Sandbox) InRepository(relativePath string) bool {
+	_, err := s.resolvePath(relativePath)
+	return err == nil
+}
+
+// SetProtectedPaths refuses changes to files matching the patterns (see IsProtectedPath)
+func (s *Sandbox) SetProtectedPaths(patterns []string) {
+	s.protectedPaths = patterns
+}
+
+// writablePath resolves the path of a file about to be changed, rejecting protected files
+func (s *Sandbox) writablePath(relativePath string) (string, error) {
+	fullPath, err := s.resolvePath(relativePath)
+	if err != nil {
+		return "", err
+	}
+	if IsProtectedPath(s.protectedPaths, relativePath) {
+		return "", fmt.Errorf("refusing to modify protected path %q", relativePath)
+	}
+	return fullPath, nil
+}
+
+// WriteFile writes content to a file in the sandbox, following the repository's .editorconfig
 func (s *Sandbox) WriteFile(relativePath, content string) error {
-	fullPath := filepath.Join(s.repoPath, relativePath)
+	fullPath, err := s.writablePath(relativePath)
+	if err != nil {
+		return err
+	}
+
+	// Match the repository's formatting so changes don't produce whitespace-only diffs
+	existing, _ := os.ReadFile(fullPath)
+	content = ApplyFormatSettings(content, s.FormatSettingsFor(relativePath), string(existing))
 
 	// Create parent directories if they don't exist
 	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
@@ -146,9 +235,53 @@ func (s *Sandbox) WriteFile(relativePath, content string) error {
 	return nil
 }
 
-// ReadFile reads a file from the sandbox
+// DeleteFile removes a file from the sandbox; deleting a file that doesn't exist is not an error
+func (s *Sandbox) DeleteFile(relativePath string) error {
+	fullPath, err := s.writablePath(relativePath)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+// RenameFile moves a file within the sandbox, through git when it's tracked so the
+// rename shows up in the history
+func (s *Sandbox) RenameFile(oldPath, newPath string) error {
+	from, err := s.writablePath(oldPath)
+	if err != nil {
+		return err
+	}
+	to, err := s.writablePath(newPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return fmt.Errorf("failed to create directories: %w", err)
+	}
+
+	cmd := exec.Command("git", "mv", "-f", "--", oldPath, newPath)
+	cmd.Dir = s.repoPath
+	if err := cmd.Run(); err == nil {
+		return nil
+	}
+	// Untracked files aren't known to git
+	if err := os.Rename(from, to); err != nil {
+		return fmt.Errorf("failed to rename file: %w", err)
+	}
+	return nil
+}
+
+// ReadFile reads a file from the sandbox. Paths outside the repository or under .git
+// are refused, since they may come from the model.
 func (s *Sandbox) ReadFile(relativePath string) (string, error) {
-	fullPath := filepath.Join(s.repoPath, relativePath)
+	fullPath, err := s.resolvePath(relativePath)
+	if err != nil {
+		return "", err
+	}
 	content, err := os.ReadFile(fullPath)
 	if err != nil {
 		return "", err
@@ -185,33 +318,109 @@ func (s *Sandbox) ListFiles() ([]string, error) {
 	return files, err
 }
 
-// RunCommand executes a command in the sandbox workspace
-func (s *Sandbox) RunCommand(command string, args ...string) (string, error) {
-	cmd := exec.Command(command, args...)
+// ChangedFiles lists the files modified, added or deleted in the workspace
+func (s *Sandbox) ChangedFiles() ([]string, error) {
+	cmd := exec.Command("git", "status", "--porcelain", "--untracked-files=all")
 	cmd.Dir = s.repoPath
-	output, err := cmd.CombinedOutput()
-	return string(output), err
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changed files: %w", err)
+	}
+
+	var files []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if len(line) < 4 {
+			continue
+		}
+		path := line[3:]
+		// Renames are reported as "old -> new"
+		if oldPath, newPath, ok := strings.Cut(path, " -> "); ok {
+			files = append(files, strings.Trim(oldPath, `"`))
+			path = newPath
+		}
+		files = append(files, strings.Trim(path, `"`))
+	}
+	return files, nil
 }
 
-// Commit commits all changes in the workspace
-func (s *Sandbox) Commit(message string) error {
-	fmt.Printf("💾 Committing changes...\n")
+// FileDiffStat counts the lines a change adds to and removes from a file that existed
+// before it
+type FileDiffStat struct {
+	Path     string
+	Added    int
+	Deleted  int
+	Original int // Lines in the committed file
+}
 
-	// Add all changes
-	cmd := exec.Command("git", "add", ".")
+// DiffStats returns line counts for the uncommitted changes to committed text files.
+// New and binary files aren't included.
+func (s *Sandbox) DiffStats() ([]FileDiffStat, error) {
+	cmd := exec.Command("git", "diff", "HEAD", "--numstat", "--no-renames", "-z")
+	cmd.Dir = s.repoPath
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get diff stats: %w", err)
+	}
+
+	var stats []FileDiffStat
+	for _, line := range strings.Split(string(output), "\x00") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		// Binary files are reported as "-\t-\t<path>"
+		added, addedErr := strconv.Atoi(fields[0])
+		deleted, deletedErr := strconv.Atoi(fields[1])
+		if addedErr != nil || deletedErr != nil {
+			continue
+		}
+
+		cmd := exec.Command("git", "show", "HEAD:"+fields[2])
+		cmd.Dir = s.repoPath
+		content, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read committed %s: %w", fields[2], err)
+		}
+		original := bytes.Count(content, []byte("\n"))
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			original++
+		}
+		stats = append(stats, FileDiffStat{Path: fields[2], Added: added, Deleted: deleted, Original: original})
+	}
+	return stats, nil
+}
+
+// Diff returns the uncommitted changes as a unified diff. The changes are staged first so
+// new files are included, as they would be in a commit.
+func (s *Sandbox) Diff() (string, error) {
+	cmd := exec.Command("git", "add", "-A")
 	cmd.Dir = s.repoPath
 	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to stage changes: %w\nOutput: %s", err, output)
+		return "", fmt.Errorf("failed to stage changes: %w\nOutput: %s", err, output)
 	}
 
-	// Configure git user (required for commits)
-	cmd = exec.Command("git", "config", "user.name", "NyteBubo")
+	cmd = exec.Command("git", "diff", "--cached", "--no-color")
 	cmd.Dir = s.repoPath
-	_ = cmd.Run()
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to diff changes: %w", err)
+	}
+	return string(output), nil
+}
 
-	cmd = exec.Command("git", "config", "user.email", "noreply@nytebubo")
+// Commit formats the changed files and commits all changes in the workspace
+func (s *Sandbox) Commit(message string) error {
+	s.Format()
+	s.Logger().Info("💾 Committing changes")
+
+	// Stage all changes, including deletions and renames
+	cmd := exec.Command("git", "add", "-A")
 	cmd.Dir = s.repoPath
-	_ = cmd.Run()
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to stage changes: %w\nOutput: %s", err, output)
+	}
+
+	s.configureIdentity()
 
 	// Commit
 	cmd = exec.Command("git", "commit", "-m", message)
@@ -224,13 +433,121 @@ func (s *Sandbox) Commit(message string) error {
 		return fmt.Errorf("failed to commit: %w\nOutput: %s", err, output)
 	}
 
-	fmt.Printf("✅ Changes committed\n")
+	s.Logger().Info("✅ Changes committed")
 	return nil
 }
 
-// Push pushes the branch to remote
+// configureIdentity sets the git user that commits are made as
+func (s *Sandbox) configureIdentity() {
+	cmd := exec.Command("git", "config", "user.name", "NyteBubo")
+	cmd.Dir = s.repoPath
+	_ = cmd.Run()
+
+	cmd = exec.Command("git", "config", "user.email", "noreply@nytebubo")
+	cmd.Dir = s.repoPath
+	_ = cmd.Run()
+}
+
+// Rebase rebases the checked-out branch onto the latest commit of base. If a commit
+// conflicts, the rebase stops and the conflicted files are returned for resolving.
+func (s *Sandbox) Rebase(base string) ([]string, error) {
+	s.Logger().Info("🔄 Rebasing branch", "onto", base)
+
+	cmd := exec.Command("git", "fetch", "origin", base)
+	cmd.Dir = s.repoPath
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to fetch branch %s: %w\nOutput: %s", base, err, output)
+	}
+
+	s.configureIdentity()
+	return s.runRebase("origin/" + base)
+}
+
+// ContinueRebase commits the resolved files and continues a stopped rebase, returning
+// the files that conflict in the next commit (none once the rebase is complete)
+func (s *Sandbox) ContinueRebase() ([]string, error) {
+	cmd := exec.Command("git", "add", "-A")
+	cmd.Dir = s.repoPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to stage changes: %w\nOutput: %s", err, output)
+	}
+
+	// A commit whose changes are already in the base is left out
+	cmd = exec.Command("git", "diff", "--cached", "--quiet")
+	cmd.Dir = s.repoPath
+	if cmd.Run() == nil {
+		return s.runRebase("--skip")
+	}
+	return s.runRebase("--continue")
+}
+
+// AbortRebase abandons a stopped rebase, restoring the branch
+func (s *Sandbox) AbortRebase() error {
+	cmd := exec.Command("git", "rebase", "--abort")
+	cmd.Dir = s.repoPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to abort rebase: %w\nOutput: %s", err, output)
+	}
+	return nil
+}
+
+// runRebase runs git rebase with args and returns the conflicted files if it stops
+func (s *Sandbox) runRebase(args ...string) ([]string, error) {
+	cmd := exec.Command("git", append([]string{"rebase"}, args...)...)
+	cmd.Dir = s.repoPath
+	// Keep the existing commit messages instead of opening an editor
+	cmd.Env = append(os.Environ(), "GIT_EDITOR=true")
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil, nil
+	}
+
+	conflicted, diffErr := s.RunCommand("git", "diff", "--name-only", "--diff-filter=U")
+	if diffErr != nil || strings.TrimSpace(conflicted) == "" {
+		return nil, fmt.Errorf("failed to rebase: %w\nOutput: %s", err, output)
+	}
+	var files []string
+	for _, line := range strings.Split(strings.TrimSpace(conflicted), "\n") {
+		files = append(files, strings.TrimSpace(line))
+	}
+	return files, nil
+}
+
+// ForcePush replaces the remote branch with the local one, e.g. after a rebase. The
+// push is refused if the remote branch changed since it was fetched.
+func (s *Sandbox) ForcePush(branchName string) error {
+	if err := s.checkSecrets(); err != nil {
+		return err
+	}
+	if s.dryRun != nil {
+		return s.reportPush(branchName)
+	}
+	s.Logger().Info("📤 Force-pushing branch to remote")
+
+	cmd := exec.Command("git", "push", "--force-with-lease", "origin", branchName)
+	cmd.Dir = s.repoPath
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to push: %w\nOutput: %s", err, output)
+	}
+
+	s.Logger().Info("✅ Branch pushed successfully")
+	return nil
+}
+
+// Push pushes the branch to remote. Changes that look like they contain credentials are
+// refused with a *SecretsFoundError.
 func (s *Sandbox) Push(branchName string) error {
-	fmt.Printf("📤 Pushing branch to remote...\n")
+	if err := s.checkSecrets(); err != nil {
+		return err
+	}
+	if s.dryRun != nil {
+		return s.reportPush(branchName)
+	}
+	s.Logger().Info("📤 Pushing branch to remote")
 
 	// Push with token authentication
 	cmd := exec.Command("git", "push", "-u", "origin", branchName)
@@ -242,19 +559,47 @@ func (s *Sandbox) Push(branchName string) error {
 		return fmt.Errorf("failed to push: %w\nOutput: %s", err, output)
 	}
 
-	fmt.Printf("✅ Branch pushed successfully\n")
+	s.Logger().Info("✅ Branch pushed successfully")
+	return nil
+}
+
+// checkSecrets returns a *SecretsFoundError if the changes to push look like they contain
+// credentials
+func (s *Sandbox) checkSecrets() error {
+	findings, err := s.ScanSecrets()
+	if err != nil {
+		return fmt.Errorf("failed to scan for secrets: %w", err)
+	}
+	if len(findings) > 0 {
+		s.Logger().Warn("🔐 Possible secrets in the changes, not pushing", "findings", len(findings))
+		return &SecretsFoundError{Findings: findings}
+	}
+	return nil
+}
+
+// reportPush reports the changes a push would publish to the dry run
+func (s *Sandbox) reportPush(branchName string) error {
+	defaultBranch, err := s.GetDefaultBranch()
+	if err != nil {
+		return err
+	}
+	diff, err := s.RunCommand("git", "diff", "origin/"+defaultBranch+"...HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to diff branch: %w", err)
+	}
+	s.dryRun.ReportPush(s.owner, s.repo, s.issueNumber, branchName, diff)
 	return nil
 }
 
 // Cleanup removes the sandbox workspace
 func (s *Sandbox) Cleanup() error {
-	fmt.Printf("🧹 Cleaning up workspace: %s\n", s.repoPath)
+	s.Logger().Info("🧹 Cleaning up workspace", "path", s.repoPath)
 
 	if err := os.RemoveAll(s.repoPath); err != nil {
 		return fmt.Errorf("failed to cleanup workspace: %w", err)
 	}
 
-	fmt.Printf("✅ Workspace cleaned up\n")
+	s.Logger().Info("✅ Workspace cleaned up")
 	return nil
 }
 
@@ -0,0 +1,139 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SecretFinding is a line added by the bot's changes that looks like it holds a credential
+type SecretFinding struct {
+	Path string
+	Line int
+	Kind string // What the line looks like, e.g. "AWS access key"
+}
+
+// SecretsFoundError is returned instead of pushing changes that look like they contain
+// credentials, so a person can review them first
+type SecretsFoundError struct {
+	Findings []SecretFinding
+}
+
+func (e *SecretsFoundError) Error() string {
+	return fmt.Sprintf("refusing to push: %d possible secrets in the changes", len(e.Findings))
+}
+
+// secretDetectors recognize well-known credential formats
+var secretDetectors = []struct {
+	kind string
+	re   *regexp.Regexp
+}{
+	{"private key", regexp.MustCompile(`-----BEGIN ([A-Z]+ )?PRIVATE KEY( BLOCK)?-----`)},
+	{"AWS access key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"GitHub token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{60,})\b`)},
+	{"GitLab token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"Stripe key", regexp.MustCompile(`\b[rs]k_live_[A-Za-z0-9]{20,}`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"Anthropic API key", regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{32,}`)},
+	{"OpenAI or OpenRouter API key", regexp.MustCompile(`\bsk-(proj-|or-v1-)?[A-Za-z0-9_-]{32,}`)},
+}
+
+// secretAssignmentRe matches a quoted value assigned to a name like api_key or password.
+// Only random-looking values are reported, so placeholders like "your-api-key-here" aren't.
+var secretAssignmentRe = regexp.MustCompile(`(?i)(api[_-]?key|secret|token|passw(or)?d|credentials?)["']?\s*[:=]+\s*["']([^"'\s]{16,})["']`)
+
+// minSecretEntropy is the Shannon entropy, in bits per character, above which an assigned
+// value looks random rather than like a word or placeholder
+const minSecretEntropy = 3.5
+
+// hunkRe matches a diff hunk header, capturing the first line number in the new file
+var hunkRe = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// ScanSecrets looks for credentials in the lines the branch adds compared to the
+// default branch, including changes that aren't committed yet, e.g. a diff about to be
+// previewed on the issue
+func (s *Sandbox) ScanSecrets() ([]SecretFinding, error) {
+	defaultBranch, err := s.GetDefaultBranch()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command("git", "add", "-A")
+	cmd.Dir = s.repoPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to stage changes: %w\nOutput: %s", err, output)
+	}
+	cmd = exec.Command("git", "merge-base", "origin/"+defaultBranch, "HEAD")
+	cmd.Dir = s.repoPath
+	base, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the branch's base: %w", err)
+	}
+	// Not RunCommand, whose output limit could hide part of the diff
+	cmd = exec.Command("git", "diff", "--cached", "--unified=0", "--no-color", strings.TrimSpace(string(base)))
+	cmd.Dir = s.repoPath
+	diff, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff branch: %w", err)
+	}
+	return scanDiffForSecrets(string(diff)), nil
+}
+
+// scanDiffForSecrets checks the lines a unified diff adds
+func scanDiffForSecrets(diff string) []SecretFinding {
+	var findings []SecretFinding
+	path := ""
+	line := 0
+	for _, text := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(text, "+++ "):
+			path = strings.TrimPrefix(strings.TrimPrefix(text, "+++ "), "b/")
+		case strings.HasPrefix(text, "@@"):
+			if match := hunkRe.FindStringSubmatch(text); match != nil {
+				line, _ = strconv.Atoi(match[1])
+			}
+		case strings.HasPrefix(text, "+"):
+			if kind := secretKind(text[1:]); kind != "" {
+				findings = append(findings, SecretFinding{Path: path, Line: line, Kind: kind})
+			}
+			line++
+		case strings.HasPrefix(text, " "):
+			line++
+		}
+	}
+	return findings
+}
+
+// secretKind returns what kind of credential a line looks like it contains, or ""
+func secretKind(text string) string {
+	for _, detector := range secretDetectors {
+		if detector.re.MatchString(text) {
+			return detector.kind
+		}
+	}
+	for _, match := range secretAssignmentRe.FindAllStringSubmatch(text, -1) {
+		if shannonEntropy(match[3]) >= minSecretEntropy {
+			return "hard-coded " + strings.ToLower(match[1])
+		}
+	}
+	return ""
+}
+
+// shannonEntropy returns the average information per character of s, in bits
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
@@ -1,9 +1,13 @@
 package core
 
 import (
+	"crypto/cipher"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -11,93 +15,155 @@ import (
 
 // State represents the conversation state for an issue
 type State struct {
-	ID              int64
-	Owner           string
-	Repo            string
-	IssueNumber     int
-	Status          string // "analyzing", "waiting_for_clarification", "ready_to_implement", "implementing", "pr_created", "reviewing", "completed"
-	PRNumber        *int
-	BranchName      string
-	Conversation    []AgentMessage
+	ID           int64
+	Owner        string
+	Repo         string
+	IssueNumber  int
+	Status       string // "analyzing", "waiting_for_clarification", "waiting_for_approval", "ready_to_implement", "implementing", "blocked", "pr_created", "reviewing", "completed", "stale", "aborted", "budget_exceeded", "held", "previewing", "needs_human", "abandoned"
+	PRNumber     *int
+	BranchName   string
+	Conversation []AgentMessage
+	// Summary of earlier turns removed from Conversation to keep prompts small
+	ConversationSummary string
+	// Workflow bookkeeping
+	BlockedByPR    *int       // Bot PR touching the same files that must close before work resumes
+	ReminderSentAt *time.Time // When a stale clarification reminder was posted
+	PlanCommentID  *int64     // Comment holding the plan awaiting approval
+	ApprovedBy     string     // Maintainer who approved the plan (approval_required only)
+	Model          string     // Model override for this issue, set with /nytebubo set-model
+	// Budget enforcement
+	ResumeStatus    string     // Status to return to when a budget pause is resumed
+	BudgetBaseline  float64    // Cost already accepted when the issue was last resumed
+	BudgetResumedAt *time.Time // When a maintainer last resumed the issue past the budget
+	// Last completed implementation step (e.g. "generated" or "pushed") and the data needed
+	// to resume after it, so a restart doesn't begin from scratch
+	Checkpoint     string
+	CheckpointData string
+	// Head and base commits of the bot PR when conflicts with its base were last
+	// resolved, so a failed attempt isn't repeated until either branch moves
+	ConflictAttempt string
+	// Fixes pushed for failing CI on the bot PR, and the head commit whose failure was last handled
+	CIFixAttempts int
+	CIFailureSHA  string
+	// Times the bot has asked for clarification without being ready to implement
+	ClarificationRounds int
+	// Newest issue comment the poller has handled; later comments have higher IDs
+	LastCommentID int64
+	// Whether the bot PR's changes passed sandbox build/test verification when it was opened
+	Verified bool
 	// Token usage tracking
-	TotalInputTokens  int64
-	TotalOutputTokens int64
-	TotalCost         float64
-	CreatedAt         time.Time
-	UpdatedAt         time.Time
-	CompletedAt       *time.Time
+	TotalInputTokens     int64
+	TotalOutputTokens    int64
+	TotalReasoningTokens int64 // Subset of output tokens spent on model reasoning
+	TotalCost            float64
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	CompletedAt          *time.Time
+}
+
+// AddUsage adds the token usage and cost of an API call to the state totals
+func (s *State) AddUsage(usage TokenUsage) {
+	s.TotalInputTokens += usage.InputTokens
+	s.TotalOutputTokens += usage.OutputTokens
+	s.TotalReasoningTokens += usage.ReasoningTokens
+	s.TotalCost += usage.Cost
+}
+
+// Messages returns the conversation to send in prompts, with the summary of earlier turns
+// appended to the first message (the issue) when the conversation has been summarized
+func (s *State) Messages() []AgentMessage {
+	messages := append([]AgentMessage(nil), s.Conversation...)
+	if s.ConversationSummary != "" && len(messages) > 0 {
+		messages[0].Content += "\n\n---\n\nSummary of the earlier conversation on this issue:\n\n" + s.ConversationSummary
+	}
+	return messages
 }
 
 // StateManager handles persistence of agent state
 type StateManager struct {
-	db *sql.DB
+	db   *stateDB
+	aead cipher.AEAD // Encrypts conversations and checkpoints at rest when a key is set
+
+	// Issues this agent has locked against other replicas (PostgreSQL only)
+	locksMu sync.Mutex
+	locks   map[string]*issueLock
+
+	// Names this agent among replicas sharing the database, e.g. in webhook event claims
+	instance string
 }
 
-// NewStateManager creates a new state manager
-func NewStateManager(dbPath string) (*StateManager, error) {
-	db, err := sql.Open("sqlite", dbPath)
+// NewStateManager creates a new state manager. database is a SQLite file path or a
+// postgres:// connection URL. With an encryptionKey (32 bytes, base64-encoded),
+// conversations and checkpoints are encrypted before they're stored.
+func NewStateManager(database, encryptionKey string) (*StateManager, error) {
+	var aead cipher.AEAD
+	if encryptionKey != "" {
+		var err error
+		if aead, err = newStateCipher(encryptionKey); err != nil {
+			return nil, err
+		}
+	}
+
+	var d dialect = sqliteDialect{}
+	if isPostgresDSN(database) {
+		d = postgresDialect{}
+	}
+	conn, err := sql.Open(d.driver(), database)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	db := &stateDB{DB: conn, dialect: d}
 
-	// Create tables if they don't exist
-	if err := createTables(db); err != nil {
+	// Create the tables, or upgrade them from an earlier version
+	if err := migrate(db); err != nil {
 		db.Close()
 		return nil, err
 	}
 
-	return &StateManager{db: db}, nil
+	instance, err := os.Hostname()
+	if err != nil || instance == "" {
+		instance = "localhost"
+	}
+	return &StateManager{db: db, aead: aead, instance: instance}, nil
 }
 
-// createTables creates the necessary database tables
-func createTables(db *sql.DB) error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS agent_states (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		owner TEXT NOT NULL,
-		repo TEXT NOT NULL,
-		issue_number INTEGER NOT NULL,
-		status TEXT NOT NULL,
-		pr_number INTEGER,
-		branch_name TEXT,
-		conversation TEXT,
-		total_input_tokens INTEGER DEFAULT 0,
-		total_output_tokens INTEGER DEFAULT 0,
-		total_cost REAL DEFAULT 0,
-		created_at DATETIME NOT NULL,
-		updated_at DATETIME NOT NULL,
-		completed_at DATETIME,
-		UNIQUE(owner, repo, issue_number)
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_states_lookup
-	ON agent_states(owner, repo, issue_number);
-	`
+// stateColumns lists the agent_states columns in the order scanState reads them
+const stateColumns = `id, owner, repo, issue_number, status, pr_number, branch_name,
+		       conversation, total_input_tokens, total_output_tokens, total_reasoning_tokens, total_cost,
+		       blocked_by_pr, reminder_sent_at, plan_comment_id, approved_by, model,
+		       resume_status, budget_baseline, budget_resumed_at, checkpoint, checkpoint_data, conflict_attempt,
+		       ci_fix_attempts, ci_failure_sha, conversation_summary, clarification_rounds, last_comment_id, verified, created_at, updated_at, completed_at`
 
-	_, err := db.Exec(schema)
-	if err != nil {
-		return fmt.Errorf("failed to create tables: %w", err)
-	}
-
-	return nil
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
 }
 
-// GetState retrieves the state for a specific issue
-func (sm *StateManager) GetState(owner, repo string, issueNumber int) (*State, error) {
-	query := `
-		SELECT id, owner, repo, issue_number, status, pr_number, branch_name,
-		       conversation, total_input_tokens, total_output_tokens, total_cost,
-		       created_at, updated_at, completed_at
-		FROM agent_states
-		WHERE owner = ? AND repo = ? AND issue_number = ?
-	`
-
+// scanState reads a State from a row selected with stateColumns
+func (sm *StateManager) scanState(row rowScanner) (*State, error) {
 	var state State
 	var conversationJSON string
 	var prNumber sql.NullInt64
+	var blockedBy sql.NullInt64
+	var reminderSentAt sql.NullTime
+	var planCommentID sql.NullInt64
+	var approvedBy sql.NullString
+	var model sql.NullString
+	var resumeStatus sql.NullString
+	var budgetBaseline sql.NullFloat64
+	var budgetResumedAt sql.NullTime
+	var checkpoint sql.NullString
+	var checkpointData sql.NullString
+	var conflictAttempt sql.NullString
+	var ciFixAttempts sql.NullInt64
+	var ciFailureSHA sql.NullString
+	var conversationSummary sql.NullString
+	var clarificationRounds sql.NullInt64
+	var lastCommentID sql.NullInt64
+	var verified sql.NullBool
 	var completedAt sql.NullTime
 
-	err := sm.db.QueryRow(query, owner, repo, issueNumber).Scan(
+	err := row.Scan(
 		&state.ID,
 		&state.Owner,
 		&state.Repo,
@@ -108,17 +174,31 @@ func (sm *StateManager) GetState(owner, repo string, issueNumber int) (*State, e
 		&conversationJSON,
 		&state.TotalInputTokens,
 		&state.TotalOutputTokens,
+		&state.TotalReasoningTokens,
 		&state.TotalCost,
+		&blockedBy,
+		&reminderSentAt,
+		&planCommentID,
+		&approvedBy,
+		&model,
+		&resumeStatus,
+		&budgetBaseline,
+		&budgetResumedAt,
+		&checkpoint,
+		&checkpointData,
+		&conflictAttempt,
+		&ciFixAttempts,
+		&ciFailureSHA,
+		&conversationSummary,
+		&clarificationRounds,
+		&lastCommentID,
+		&verified,
 		&state.CreatedAt,
 		&state.UpdatedAt,
 		&completedAt,
 	)
-
-	if err == sql.ErrNoRows {
-		return nil, nil // No state found
-	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get state: %w", err)
+		return nil, err
 	}
 
 	if prNumber.Valid {
@@ -126,11 +206,47 @@ func (sm *StateManager) GetState(owner, repo string, issueNumber int) (*State, e
 		state.PRNumber = &prNum
 	}
 
+	if blockedBy.Valid {
+		blockedNum := int(blockedBy.Int64)
+		state.BlockedByPR = &blockedNum
+	}
+
+	if reminderSentAt.Valid {
+		state.ReminderSentAt = &reminderSentAt.Time
+	}
+
+	if planCommentID.Valid {
+		state.PlanCommentID = &planCommentID.Int64
+	}
+	state.ApprovedBy = approvedBy.String
+	state.Model = model.String
+	state.ResumeStatus = resumeStatus.String
+	state.BudgetBaseline = budgetBaseline.Float64
+	if budgetResumedAt.Valid {
+		state.BudgetResumedAt = &budgetResumedAt.Time
+	}
+	state.Checkpoint = checkpoint.String
+	if state.CheckpointData, err = sm.unseal(checkpointData.String); err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	state.ConflictAttempt = conflictAttempt.String
+	state.CIFixAttempts = int(ciFixAttempts.Int64)
+	state.CIFailureSHA = ciFailureSHA.String
+	if state.ConversationSummary, err = sm.unseal(conversationSummary.String); err != nil {
+		return nil, fmt.Errorf("failed to read conversation summary: %w", err)
+	}
+	state.ClarificationRounds = int(clarificationRounds.Int64)
+	state.LastCommentID = lastCommentID.Int64
+	state.Verified = verified.Bool
+
 	if completedAt.Valid {
 		state.CompletedAt = &completedAt.Time
 	}
 
 	// Unmarshal conversation
+	if conversationJSON, err = sm.unseal(conversationJSON); err != nil {
+		return nil, fmt.Errorf("failed to read conversation: %w", err)
+	}
 	if conversationJSON != "" {
 		if err := json.Unmarshal([]byte(conversationJSON), &state.Conversation); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal conversation: %w", err)
@@ -140,6 +256,42 @@ func (sm *StateManager) GetState(owner, repo string, issueNumber int) (*State, e
 	return &state, nil
 }
 
+// GetState retrieves the state for a specific issue
+func (sm *StateManager) GetState(owner, repo string, issueNumber int) (*State, error) {
+	query := `SELECT ` + stateColumns + `
+		FROM agent_states
+		WHERE owner = ? AND repo = ? AND issue_number = ?
+	`
+
+	state, err := sm.scanState(sm.db.QueryRow(query, owner, repo, issueNumber))
+	if err == sql.ErrNoRows {
+		return nil, nil // No state found
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state: %w", err)
+	}
+
+	return state, nil
+}
+
+// GetStateByPR retrieves the state of the issue a bot pull request was opened for
+func (sm *StateManager) GetStateByPR(owner, repo string, prNumber int) (*State, error) {
+	query := `SELECT ` + stateColumns + `
+		FROM agent_states
+		WHERE owner = ? AND repo = ? AND pr_number = ?
+	`
+
+	state, err := sm.scanState(sm.db.QueryRow(query, owner, repo, prNumber))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state: %w", err)
+	}
+
+	return state, nil
+}
+
 // SaveState saves or updates the state for an issue
 func (sm *StateManager) SaveState(state *State) error {
 	// Marshal conversation to JSON
@@ -147,6 +299,18 @@ func (sm *StateManager) SaveState(state *State) error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal conversation: %w", err)
 	}
+	conversation, err := sm.seal(string(conversationJSON))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt conversation: %w", err)
+	}
+	conversationSummary, err := sm.seal(state.ConversationSummary)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt conversation summary: %w", err)
+	}
+	checkpointData, err := sm.seal(state.CheckpointData)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt checkpoint: %w", err)
+	}
 
 	now := time.Now()
 	if state.CreatedAt.IsZero() {
@@ -156,9 +320,11 @@ func (sm *StateManager) SaveState(state *State) error {
 
 	query := `
 		INSERT INTO agent_states (owner, repo, issue_number, status, pr_number, branch_name, conversation,
-		                          total_input_tokens, total_output_tokens, total_cost,
-		                          created_at, updated_at, completed_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		                          total_input_tokens, total_output_tokens, total_reasoning_tokens, total_cost,
+		                          blocked_by_pr, reminder_sent_at, plan_comment_id, approved_by, model,
+		                          resume_status, budget_baseline, budget_resumed_at, checkpoint, checkpoint_data,
+		                          conflict_attempt, ci_fix_attempts, ci_failure_sha, conversation_summary, clarification_rounds, last_comment_id, verified, created_at, updated_at, completed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(owner, repo, issue_number) DO UPDATE SET
 			status = excluded.status,
 			pr_number = excluded.pr_number,
@@ -166,12 +332,52 @@ func (sm *StateManager) SaveState(state *State) error {
 			conversation = excluded.conversation,
 			total_input_tokens = excluded.total_input_tokens,
 			total_output_tokens = excluded.total_output_tokens,
+			total_reasoning_tokens = excluded.total_reasoning_tokens,
 			total_cost = excluded.total_cost,
+			blocked_by_pr = excluded.blocked_by_pr,
+			reminder_sent_at = excluded.reminder_sent_at,
+			plan_comment_id = excluded.plan_comment_id,
+			approved_by = excluded.approved_by,
+			model = excluded.model,
+			resume_status = excluded.resume_status,
+			budget_baseline = excluded.budget_baseline,
+			budget_resumed_at = excluded.budget_resumed_at,
+			checkpoint = excluded.checkpoint,
+			checkpoint_data = excluded.checkpoint_data,
+			conflict_attempt = excluded.conflict_attempt,
+			ci_fix_attempts = excluded.ci_fix_attempts,
+			ci_failure_sha = excluded.ci_failure_sha,
+			conversation_summary = excluded.conversation_summary,
+			clarification_rounds = excluded.clarification_rounds,
+			last_comment_id = excluded.last_comment_id,
+			verified = excluded.verified,
 			updated_at = excluded.updated_at,
 			completed_at = excluded.completed_at
+		RETURNING id
 	`
 
-	result, err := sm.db.Exec(
+	// The cost added since the last save counts towards this month's spend
+	tx, err := sm.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var previousCost float64
+	err = tx.QueryRow(`SELECT total_cost FROM agent_states WHERE owner = ? AND repo = ? AND issue_number = ?`+sm.db.dialect.forUpdate(),
+		state.Owner, state.Repo, state.IssueNumber).Scan(&previousCost)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to read previous cost: %w", err)
+	}
+
+	// Stored as an integer, which both databases accept
+	verified := 0
+	if state.Verified {
+		verified = 1
+	}
+
+	var id int64
+	err = tx.QueryRow(
 		query,
 		state.Owner,
 		state.Repo,
@@ -179,27 +385,81 @@ func (sm *StateManager) SaveState(state *State) error {
 		state.Status,
 		state.PRNumber,
 		state.BranchName,
-		string(conversationJSON),
+		conversation,
 		state.TotalInputTokens,
 		state.TotalOutputTokens,
+		state.TotalReasoningTokens,
 		state.TotalCost,
+		state.BlockedByPR,
+		state.ReminderSentAt,
+		state.PlanCommentID,
+		state.ApprovedBy,
+		state.Model,
+		state.ResumeStatus,
+		state.BudgetBaseline,
+		state.BudgetResumedAt,
+		state.Checkpoint,
+		checkpointData,
+		state.ConflictAttempt,
+		state.CIFixAttempts,
+		state.CIFailureSHA,
+		conversationSummary,
+		state.ClarificationRounds,
+		state.LastCommentID,
+		verified,
 		state.CreatedAt,
 		state.UpdatedAt,
 		state.CompletedAt,
-	)
+	).Scan(&id)
 
 	if err != nil {
 		return fmt.Errorf("failed to save state: %w", err)
 	}
 
-	if state.ID == 0 {
-		id, err := result.LastInsertId()
-		if err != nil {
-			return fmt.Errorf("failed to get last insert id: %w", err)
+	if delta := state.TotalCost - previousCost; delta > 0 {
+		spendQuery := `
+			INSERT INTO monthly_spend (month, cost) VALUES (?, ?)
+			ON CONFLICT(month) DO UPDATE SET cost = monthly_spend.cost + excluded.cost
+		`
+		if _, err := tx.Exec(spendQuery, spendMonth(now), delta); err != nil {
+			return fmt.Errorf("failed to record spend: %w", err)
 		}
-		state.ID = id
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	state.ID = id
+	return nil
+}
+
+// spendMonth identifies the calendar month (UTC) a cost is counted in
+func spendMonth(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// MonthlySpend returns the total cost recorded in the month containing t
+func (sm *StateManager) MonthlySpend(t time.Time) (float64, error) {
+	var cost float64
+	err := sm.db.QueryRow(`SELECT cost FROM monthly_spend WHERE month = ?`, spendMonth(t)).Scan(&cost)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get monthly spend: %w", err)
+	}
+	return cost, nil
+}
+
+// SetLastCommentID records the newest issue comment handled for an issue without
+// touching the rest of its state
+func (sm *StateManager) SetLastCommentID(owner, repo string, issueNumber int, commentID int64) error {
+	_, err := sm.db.Exec(`UPDATE agent_states SET last_comment_id = ? WHERE owner = ? AND repo = ? AND issue_number = ?`,
+		commentID, owner, repo, issueNumber)
+	if err != nil {
+		return fmt.Errorf("failed to save last comment: %w", err)
+	}
 	return nil
 }
 
@@ -215,15 +475,70 @@ func (sm *StateManager) DeleteState(owner, repo string, issueNumber int) error {
 
 // GetAllIssuesWithStats retrieves all issues with their usage stats
 func (sm *StateManager) GetAllIssuesWithStats() ([]State, error) {
-	query := `
-		SELECT id, owner, repo, issue_number, status, pr_number, branch_name,
-		       conversation, total_input_tokens, total_output_tokens, total_cost,
-		       created_at, updated_at, completed_at
+	query := `SELECT ` + stateColumns + `
 		FROM agent_states
 		ORDER BY created_at DESC
 	`
 
-	rows, err := sm.db.Query(query)
+	return sm.queryStates(query)
+}
+
+// ListOpenPRStates returns the states in a repository with an open bot pull request
+func (sm *StateManager) ListOpenPRStates(owner, repo string) ([]State, error) {
+	query := `SELECT ` + stateColumns + `
+		FROM agent_states
+		WHERE owner = ? AND repo = ? AND pr_number IS NOT NULL AND status IN ('pr_created', 'reviewing')
+		ORDER BY created_at
+	`
+
+	return sm.queryStates(query, owner, repo)
+}
+
+// ListActiveStates returns the states in a repository whose work isn't finished, i.e. that
+// aren't completed or abandoned
+func (sm *StateManager) ListActiveStates(owner, repo string) ([]State, error) {
+	query := `SELECT ` + stateColumns + `
+		FROM agent_states
+		WHERE owner = ? AND repo = ? AND status NOT IN ('completed', 'abandoned')
+		ORDER BY created_at
+	`
+
+	return sm.queryStates(query, owner, repo)
+}
+
+// ListBlockedStates returns the states waiting on the given pull request to close
+func (sm *StateManager) ListBlockedStates(owner, repo string, prNumber int) ([]State, error) {
+	query := `SELECT ` + stateColumns + `
+		FROM agent_states
+		WHERE owner = ? AND repo = ? AND status = 'blocked' AND blocked_by_pr = ?
+		ORDER BY created_at
+	`
+
+	return sm.queryStates(query, owner, repo, prNumber)
+}
+
+// ListStatesByStatus returns every state with one of the given statuses
+func (sm *StateManager) ListStatesByStatus(statuses ...string) ([]State, error) {
+	if len(statuses) == 0 {
+		return nil, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(statuses)), ", ")
+	query := `SELECT ` + stateColumns + `
+		FROM agent_states
+		WHERE status IN (` + placeholders + `)
+		ORDER BY updated_at
+	`
+
+	args := make([]any, len(statuses))
+	for i, status := range statuses {
+		args[i] = status
+	}
+	return sm.queryStates(query, args...)
+}
+
+// queryStates runs a query selecting stateColumns and scans every row
+func (sm *StateManager) queryStates(query string, args ...any) ([]State, error) {
+	rows, err := sm.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query states: %w", err)
 	}
@@ -231,52 +546,39 @@ func (sm *StateManager) GetAllIssuesWithStats() ([]State, error) {
 
 	var states []State
 	for rows.Next() {
-		var state State
-		var conversationJSON string
-		var prNumber sql.NullInt64
-		var completedAt sql.NullTime
-
-		err := rows.Scan(
-			&state.ID,
-			&state.Owner,
-			&state.Repo,
-			&state.IssueNumber,
-			&state.Status,
-			&prNumber,
-			&state.BranchName,
-			&conversationJSON,
-			&state.TotalInputTokens,
-			&state.TotalOutputTokens,
-			&state.TotalCost,
-			&state.CreatedAt,
-			&state.UpdatedAt,
-			&completedAt,
-		)
-
+		state, err := sm.scanState(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
+		states = append(states, *state)
+	}
 
-		if prNumber.Valid {
-			prNum := int(prNumber.Int64)
-			state.PRNumber = &prNum
-		}
-
-		if completedAt.Valid {
-			state.CompletedAt = &completedAt.Time
-		}
-
-		// Unmarshal conversation
-		if conversationJSON != "" {
-			if err := json.Unmarshal([]byte(conversationJSON), &state.Conversation); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal conversation: %w", err)
-			}
-		}
+	return states, rows.Err()
+}
 
-		states = append(states, state)
+// GetSetting returns a stored agent setting ("" if unset)
+func (sm *StateManager) GetSetting(key string) (string, error) {
+	var value string
+	err := sm.db.QueryRow(`SELECT value FROM settings WHERE key = ?`, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get setting %s: %w", key, err)
 	}
+	return value, nil
+}
 
-	return states, nil
+// SetSetting stores an agent setting
+func (sm *StateManager) SetSetting(key, value string) error {
+	query := `
+		INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+	`
+	if _, err := sm.db.Exec(query, key, value, time.Now()); err != nil {
+		return fmt.Errorf("failed to set setting %s: %w", key, err)
+	}
+	return nil
 }
 
 // Close closes the database connection
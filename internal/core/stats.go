@@ -0,0 +1,53 @@
+package core
+
+import (
+	"strings"
+	"time"
+)
+
+// StatsFilter narrows usage statistics to a time window and repository. Zero fields
+// don't filter.
+type StatsFilter struct {
+	Since time.Time // Inclusive
+	Until time.Time // Exclusive
+	Owner string
+	Repo  string
+}
+
+// where returns the SQL conditions and arguments for the filter, with startColumn and
+// endColumn holding when a row's activity began and last happened. For a single point in
+// time, such as a call, pass the same column twice.
+func (f StatsFilter) where(startColumn, endColumn string) (string, []any) {
+	conditions := []string{"1 = 1"}
+	var args []any
+	if !f.Since.IsZero() {
+		conditions = append(conditions, endColumn+" >= ?")
+		args = append(args, f.Since)
+	}
+	if !f.Until.IsZero() {
+		conditions = append(conditions, startColumn+" < ?")
+		args = append(args, f.Until)
+	}
+	if f.Owner != "" {
+		conditions = append(conditions, "owner = ?")
+		args = append(args, f.Owner)
+	}
+	if f.Repo != "" {
+		conditions = append(conditions, "repo = ?")
+		args = append(args, f.Repo)
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// ListIssueStats returns the issues, with their usage totals, that were worked on during
+// the filter's window, newest first
+func (sm *StateManager) ListIssueStats(filter StatsFilter) ([]State, error) {
+	where, args := filter.where("created_at", "updated_at")
+	query := `SELECT ` + stateColumns + `
+		FROM agent_states
+		WHERE ` + where + `
+		ORDER BY created_at DESC
+	`
+
+	return sm.queryStates(query, args...)
+}
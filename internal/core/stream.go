@@ -0,0 +1,132 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// streamScanInterval is how many streamed bytes arrive between scans for newly started files
+const streamScanInterval = 256
+
+// StreamingProvider is implemented by providers that can stream completions.
+// onDelta is called with each chunk of generated text as it arrives.
+type StreamingProvider interface {
+	Stream(ctx context.Context, req CompletionRequest, onDelta func(string)) (string, TokenUsage, error)
+}
+
+// StreamProgress describes a completion that is still being generated
+type StreamProgress struct {
+	Chars int      // Characters received so far
+	Files []string // Files the response has started writing, in order
+	Done  bool     // The completion finished (successfully or not)
+}
+
+// ProgressFunc receives progress updates while a completion streams
+type ProgressFunc func(StreamProgress)
+
+var (
+	// streamedFenceRe matches the opening of a markdown file block (```go path/to/file.go)
+	// or the file line of a search/replace edit (FILE: path/to/file.go)
+	streamedFenceRe = regexp.MustCompile("(?m)^(?:```\\S+[ \\t]+|FILE:[ \\t]*)([^\\s`]+)[ \\t]*$")
+	// streamedPathRe matches a file path in a structured JSON response
+	streamedPathRe = regexp.MustCompile(`"path"\s*:\s*"([^"]+)"`)
+)
+
+// streamedFiles lists the files a partial code generation response has started
+func streamedFiles(text string) []string {
+	re := streamedFenceRe
+	if strings.HasPrefix(strings.TrimSpace(text), "{") {
+		re = streamedPathRe
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	for _, match := range re.FindAllStringSubmatch(text, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			files = append(files, match[1])
+		}
+	}
+	return files
+}
+
+// chatStreamChunk is one server-sent event of an OpenAI-compatible streaming response
+type chatStreamChunk struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *openRouterUsage `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// readChatStream consumes an OpenAI-compatible SSE stream, calling onDelta for each
+// content chunk, and assembles the equivalent non-streaming response
+func readChatStream(provider string, body io.Reader, onDelta func(string)) (*openRouterResponse, error) {
+	var resp openRouterResponse
+	var content strings.Builder
+	finishReason := ""
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Blank lines separate events; lines starting with ':' are keep-alive comments
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk chatStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("failed to parse stream chunk: %w", err)
+		}
+		// Errors after the response started can only be reported in-stream; treat them as transient
+		if chunk.Error != nil {
+			return nil, &APIError{Provider: provider, StatusCode: http.StatusBadGateway, Message: chunk.Error.Message}
+		}
+
+		if chunk.ID != "" {
+			resp.ID = chunk.ID
+		}
+		if chunk.Model != "" {
+			resp.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			resp.Usage = *chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				content.WriteString(choice.Delta.Content)
+				onDelta(choice.Delta.Content)
+			}
+			if choice.FinishReason != "" {
+				finishReason = choice.FinishReason
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	resp.Choices = []openRouterChoice{{
+		Message:      openRouterResponseMessage{Role: "assistant", Content: content.String()},
+		FinishReason: finishReason,
+	}}
+	return &resp, nil
+}
@@ -0,0 +1,150 @@
+package core
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Subproject is a directory of a monorepo with a build manifest of its own
+type Subproject struct {
+	Dir      string // Relative to the repository root, with forward slashes
+	Language string
+}
+
+// projectManifests maps the files that make a directory a project to its language
+var projectManifests = map[string]string{
+	"go.mod":           "go",
+	"package.json":     "javascript",
+	"Cargo.toml":       "rust",
+	"pyproject.toml":   "python",
+	"setup.py":         "python",
+	"requirements.txt": "python",
+	"pom.xml":          "java",
+	"build.gradle":     "java",
+}
+
+// workspaceFiles mark a repository root that only ties its subprojects together
+var workspaceFiles = []string{"go.work", "pnpm-workspace.yaml", "lerna.json", "nx.json", "turbo.json"}
+
+// vendoredDirs hold other projects' manifests, which aren't subprojects of the repository
+var vendoredDirs = map[string]bool{"node_modules": true, "vendor": true, "testdata": true, "third_party": true}
+
+// DetectSubprojects finds the projects in a monorepo: the directories with a package
+// manifest, except a root that is only a workspace. It returns nil for a repository
+// that is a single project.
+func (s *Sandbox) DetectSubprojects() ([]Subproject, error) {
+	files, err := s.ListFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	languages := make(map[string]string)
+	for _, file := range files {
+		file = filepath.ToSlash(file)
+		language, ok := projectManifests[path.Base(file)]
+		if !ok || isVendored(file) {
+			continue
+		}
+		dir := path.Dir(file)
+		if language == "javascript" && fileExists(filepath.Join(s.repoPath, filepath.FromSlash(dir), "tsconfig.json")) {
+			language = "typescript"
+		}
+		if _, seen := languages[dir]; !seen {
+			languages[dir] = language
+		}
+	}
+
+	if s.isWorkspaceRoot() {
+		delete(languages, ".")
+	}
+	if len(languages) < 2 {
+		return nil, nil
+	}
+
+	subprojects := make([]Subproject, 0, len(languages))
+	for dir, language := range languages {
+		subprojects = append(subprojects, Subproject{Dir: dir, Language: language})
+	}
+	sort.Slice(subprojects, func(i, j int) bool { return subprojects[i].Dir < subprojects[j].Dir })
+	return subprojects, nil
+}
+
+// isWorkspaceRoot reports whether the repository root declares a workspace of subprojects
+func (s *Sandbox) isWorkspaceRoot() bool {
+	for _, name := range workspaceFiles {
+		if fileExists(filepath.Join(s.repoPath, name)) {
+			return true
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(s.repoPath, "package.json")); err == nil && strings.Contains(string(data), `"workspaces"`) {
+		return true
+	}
+	if data, err := os.ReadFile(filepath.Join(s.repoPath, "Cargo.toml")); err == nil && strings.Contains(string(data), "[workspace]") {
+		return true
+	}
+	return false
+}
+
+// SubprojectsFor returns the subprojects containing the given files, each file belonging
+// to the most deeply nested one. It returns nil when any file is outside every subproject,
+// since then the whole repository is affected.
+func SubprojectsFor(subprojects []Subproject, files []string) []Subproject {
+	var affected []Subproject
+	seen := make(map[string]bool)
+	for _, file := range files {
+		project, ok := subprojectOf(subprojects, filepath.ToSlash(file))
+		if !ok {
+			return nil
+		}
+		if !seen[project.Dir] {
+			seen[project.Dir] = true
+			affected = append(affected, project)
+		}
+	}
+	return affected
+}
+
+// InSubprojects reports whether a file belongs to one of the subprojects
+func InSubprojects(subprojects []Subproject, file string) bool {
+	file = filepath.ToSlash(file)
+	for _, project := range subprojects {
+		if project.Dir == "." || strings.HasPrefix(file, project.Dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// subprojectOf returns the most deeply nested subproject containing a file
+func subprojectOf(subprojects []Subproject, file string) (Subproject, bool) {
+	var best Subproject
+	found := false
+	for _, project := range subprojects {
+		if project.Dir != "." && !strings.HasPrefix(file, project.Dir+"/") {
+			continue
+		}
+		if !found || len(project.Dir) > len(best.Dir) || best.Dir == "." {
+			best, found = project, true
+		}
+	}
+	return best, found
+}
+
+// isVendored reports whether a file is inside a directory of third-party code
+func isVendored(file string) bool {
+	for _, part := range strings.Split(path.Dir(file), "/") {
+		if vendoredDirs[part] {
+			return true
+		}
+	}
+	return false
+}
+
+// fileExists reports whether a regular file exists
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,36 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SummarizeConversation compresses conversation turns into a summary that replaces them in
+// later prompts. A previous summary of even earlier turns is folded into the new one.
+func (ca *ClaudeAgent) SummarizeConversation(previousSummary string, messages []AgentMessage) (string, TokenUsage, error) {
+	systemPrompt := ca.SystemPrompt(PromptSummarize, PromptData{})
+
+	var b strings.Builder
+	if previousSummary != "" {
+		b.WriteString("Summary of the conversation so far:\n\n" + previousSummary + "\n\n---\n\n")
+	}
+	b.WriteString("Conversation to summarize:\n\n")
+	for _, msg := range messages {
+		speaker := "User"
+		if msg.Role == "assistant" {
+			speaker = "Assistant"
+		}
+		b.WriteString(fmt.Sprintf("**%s:**\n%s\n\n", speaker, msg.Content))
+	}
+	b.WriteString("Write the updated summary.")
+
+	response, usage, err := ca.SendMessageForStage(StageAnalysis, []AgentMessage{{Role: "user", Content: b.String()}}, systemPrompt)
+	if err != nil {
+		return "", usage, err
+	}
+	summary := strings.TrimSpace(response)
+	if summary == "" {
+		return "", usage, fmt.Errorf("empty conversation summary")
+	}
+	return summary, usage, nil
+}
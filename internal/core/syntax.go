@@ -0,0 +1,124 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SyntaxError is a changed file that doesn't parse
+type SyntaxError struct {
+	Path    string
+	Message string
+}
+
+func (e SyntaxError) String() string {
+	return e.Path + ": " + e.Message
+}
+
+// syntaxParsers check files of an extension in-process
+var syntaxParsers = map[string]func(content []byte) error{
+	".go": func(content []byte) error {
+		// Without a file name, positions are reported as line:column
+		_, err := parser.ParseFile(token.NewFileSet(), "", content, parser.AllErrors)
+		var list scanner.ErrorList
+		if errors.As(err, &list) && len(list) > 1 {
+			// Report every error rather than the first and a count
+			messages := make([]string, len(list))
+			for i, e := range list {
+				messages[i] = e.Error()
+			}
+			return errors.New(strings.Join(messages, "; "))
+		}
+		return err
+	},
+	".json": func(content []byte) error {
+		var v any
+		return json.Unmarshal(content, &v)
+	},
+	".yaml": parseYAML,
+	".yml":  parseYAML,
+}
+
+// syntaxCommands check that a file of an extension parses, given in place of {files}.
+// Python is parsed with ast rather than py_compile, which would write bytecode into the repository.
+var syntaxCommands = map[string][]string{
+	".py":  {"python", "-c", "import ast, sys; ast.parse(open(sys.argv[1], 'rb').read(), sys.argv[1])", filesPlaceholder},
+	".js":  {"node", "--check", filesPlaceholder},
+	".mjs": {"node", "--check", filesPlaceholder},
+	".cjs": {"node", "--check", filesPlaceholder},
+	".rb":  {"ruby", "-c", filesPlaceholder},
+	".php": {"php", "-l", filesPlaceholder},
+	".sh":  {"bash", "-n", filesPlaceholder},
+}
+
+// parseYAML checks every document in a YAML file
+func parseYAML(content []byte) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var v any
+		if err := decoder.Decode(&v); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// CheckSyntax parses every changed file whose language it knows and returns those that
+// don't parse. It's much quicker than a build and points at the broken file, and covers
+// files the build doesn't compile. Checkers that aren't installed are skipped.
+func (s *Sandbox) CheckSyntax() ([]SyntaxError, error) {
+	changed, err := s.ChangedFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var syntaxErrors []SyntaxError
+	for _, file := range changed {
+		file = filepath.ToSlash(file)
+		fullPath := filepath.Join(s.repoPath, filepath.FromSlash(file))
+		if !fileExists(fullPath) {
+			continue
+		}
+		ext := strings.ToLower(path.Ext(file))
+
+		if parse, ok := syntaxParsers[ext]; ok {
+			content, err := os.ReadFile(fullPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", file, err)
+			}
+			if err := parse(content); err != nil {
+				syntaxErrors = append(syntaxErrors, SyntaxError{Path: file, Message: err.Error()})
+			}
+			continue
+		}
+
+		command, ok := syntaxCommands[ext]
+		if !ok || !s.toolInstalled(".", command) {
+			continue
+		}
+		i := slices.Index(command, filesPlaceholder)
+		command = slices.Concat(command[:i], []string{file}, command[i+1:])
+		if output, err := s.runCommandIn(".", command[0], command[1:]...); err != nil {
+			message := strings.TrimSpace(output)
+			if message == "" {
+				message = err.Error()
+			}
+			syntaxErrors = append(syntaxErrors, SyntaxError{Path: file, Message: message})
+		}
+	}
+	return syntaxErrors, nil
+}
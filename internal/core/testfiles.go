@@ -0,0 +1,43 @@
+package core
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+)
+
+// testFilePatterns recognize test files by the naming conventions of common test frameworks
+var testFilePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`_test\.(go|py|rb|exs)$`),                       // Go, pytest, Minitest, ExUnit
+	regexp.MustCompile(`(^|/)test_[^/]+\.py$`),                         // pytest, unittest
+	regexp.MustCompile(`\.(test|spec)\.[cm]?[jt]sx?$`),                 // Jest, Vitest, Mocha
+	regexp.MustCompile(`_spec\.rb$`),                                   // RSpec
+	regexp.MustCompile(`(^|/)(Test[A-Z]\w*|\w+Tests?)\.(java|kt|cs)$`), // JUnit, xUnit
+	regexp.MustCompile(`(^|/)(tests?|__tests__|spec)/`),                // Test directories, e.g. Rust integration tests
+}
+
+// IsTestFile reports whether a repository file looks like a test
+func IsTestFile(file string) bool {
+	file = path.Clean(filepath.ToSlash(file))
+	for _, pattern := range testFilePatterns {
+		if pattern.MatchString(file) {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangedTestFiles lists the test files added or modified in the workspace
+func (s *Sandbox) ChangedTestFiles() ([]string, error) {
+	changed, err := s.ChangedFiles()
+	if err != nil {
+		return nil, err
+	}
+	var tests []string
+	for _, file := range changed {
+		if IsTestFile(file) && fileExists(filepath.Join(s.repoPath, file)) {
+			tests = append(tests, file)
+		}
+	}
+	return tests, nil
+}
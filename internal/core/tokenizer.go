@@ -0,0 +1,78 @@
+package core
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+const (
+	// tokenizerEncoding is the tiktoken encoding used for estimates. Exact counts
+	// vary by model, but cl100k_base is close enough for budgeting decisions.
+	tokenizerEncoding = "cl100k_base"
+	// messageOverheadTokens approximates the per-message formatting overhead
+	messageOverheadTokens = 4
+	// imageTokenEstimate approximates the prompt cost of one image attachment
+	imageTokenEstimate = 1000
+)
+
+var (
+	encoderOnce sync.Once
+	encoder     *tiktoken.Tiktoken
+)
+
+// getEncoder lazily loads the embedded BPE ranks (no network access required)
+func getEncoder() *tiktoken.Tiktoken {
+	encoderOnce.Do(func() {
+		tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+		enc, err := tiktoken.GetEncoding(tokenizerEncoding)
+		if err != nil {
+			slog.Warn("⚠️  Failed to load tokenizer, using character-based estimates", "error", err)
+			return
+		}
+		encoder = enc
+	})
+	return encoder
+}
+
+// CountTokens estimates the number of tokens in text
+func CountTokens(text string) int {
+	if enc := getEncoder(); enc != nil {
+		return len(enc.Encode(text, nil, nil))
+	}
+	// Roughly four characters per token for English text and code
+	return (len(text) + 3) / 4
+}
+
+// CountPromptTokens estimates the prompt tokens for a system prompt and conversation
+func CountPromptTokens(systemPrompt string, messages []AgentMessage) int {
+	total := 0
+	if systemPrompt != "" {
+		total += CountTokens(systemPrompt) + messageOverheadTokens
+	}
+	for _, msg := range messages {
+		total += CountTokens(msg.Content) + messageOverheadTokens
+		total += len(msg.Images) * imageTokenEstimate
+	}
+	return total
+}
+
+// trimToContextWindow drops the oldest conversation turns (always keeping the
+// first message, which holds the issue, and the latest message) until the
+// prompt fits within budget tokens. It returns the trimmed conversation and
+// the number of messages removed.
+func trimToContextWindow(systemPrompt string, messages []AgentMessage, budget int) ([]AgentMessage, int) {
+	if budget <= 0 || len(messages) <= 2 || CountPromptTokens(systemPrompt, messages) <= budget {
+		return messages, 0
+	}
+
+	trimmed := append([]AgentMessage(nil), messages...)
+	removed := 0
+	for len(trimmed) > 2 && CountPromptTokens(systemPrompt, trimmed) > budget {
+		trimmed = append(trimmed[:1], trimmed[2:]...)
+		removed++
+	}
+	return trimmed, removed
+}
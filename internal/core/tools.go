@@ -0,0 +1,279 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Tools available to the model in the tool loop
+const (
+	ToolReadFile   = "read_file"
+	ToolListDir    = "list_dir"
+	ToolGrep       = "grep"
+	ToolRunTests   = "run_tests"
+	ToolWriteFile  = "write_file"
+	ToolDeleteFile = "delete_file"
+	ToolDone       = "done"
+)
+
+const (
+	// maxToolOutputBytes caps how much of a tool's result is sent back to the model
+	maxToolOutputBytes = 16000
+	// maxGrepMatches caps the matching lines a grep returns
+	maxGrepMatches = 100
+)
+
+// ToolCall is one action the model takes in the tool loop
+type ToolCall struct {
+	Tool    string `json:"tool"`
+	Path    string `json:"path"`    // File or directory for read_file, list_dir, grep, write_file and delete_file
+	Pattern string `json:"pattern"` // Regular expression for grep
+	Content string `json:"content"` // Complete file content for write_file
+	Summary string `json:"summary"` // Summary of the changes for done
+}
+
+// TestRunner builds and tests the sandbox, returning the output and whether it passed
+type TestRunner func() (output string, passed bool)
+
+// toolCallSchema returns the JSON schema for tool calls
+func toolCallSchema() *jsonSchema {
+	return &jsonSchema{
+		Name:   "tool_call",
+		Strict: true,
+		Schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"tool": map[string]any{
+					"type": "string",
+					"enum": []string{ToolReadFile, ToolListDir, ToolGrep, ToolRunTests, ToolWriteFile, ToolDeleteFile, ToolDone},
+				},
+				"path": map[string]any{
+					"type":        "string",
+					"description": "Path relative to the repository root (empty for the root)",
+				},
+				"pattern": map[string]any{
+					"type":        "string",
+					"description": "Extended regular expression to search for (grep only)",
+				},
+				"content": map[string]any{
+					"type":        "string",
+					"description": "Complete new file content (write_file only)",
+				},
+				"summary": map[string]any{
+					"type":        "string",
+					"description": "Summary of the changes made (done only)",
+				},
+			},
+			"required":             []string{"tool", "path", "pattern", "content", "summary"},
+			"additionalProperties": false,
+		},
+	}
+}
+
+// ImplementWithTools lets the model explore and change the sandbox one tool call at a time
+// until it calls done or maxSteps calls are used. It returns the model's summary of the
+// changes; the changes themselves are left in the sandbox.
+func (ca *ClaudeAgent) ImplementWithTools(task, context, language string, conversationHistory []AgentMessage, sandbox *Sandbox, runTests TestRunner, maxSteps int) (string, TokenUsage, error) {
+	systemPrompt := ca.SystemPrompt(PromptAgent, PromptData{Language: language, Context: context, Task: task, MaxSteps: maxSteps})
+
+	messages := append(append([]AgentMessage{}, conversationHistory...), AgentMessage{
+		Role:    "user",
+		Content: "Implement the changes using the tools. Start by exploring the relevant code.",
+	})
+
+	var total TokenUsage
+	for step := 1; step <= maxSteps; step++ {
+		if err := ca.ctx.Err(); err != nil {
+			return "", total, err
+		}
+
+		response, usage, err := ca.sendWithSchema(StageCodegen, messages, systemPrompt, toolCallSchema())
+		total = addUsage(total, usage)
+		if err != nil {
+			return "", total, err
+		}
+		messages = append(messages, AgentMessage{Role: "assistant", Content: response})
+
+		call, err := parseToolCall(response)
+		if err != nil {
+			ca.log().Warn("⚠️  Invalid tool call", "step", step, "error", err)
+			messages = append(messages, AgentMessage{Role: "user", Content: fmt.Sprintf("Error: %v. Respond with one JSON tool call only.", err)})
+			continue
+		}
+		if call.Tool == ToolDone {
+			ca.log().Info("🧰 Tool loop finished", "steps", step)
+			return call.Summary, total, nil
+		}
+
+		ca.log().Info("🧰 Tool call", "step", step, "tool", call.Tool, "path", call.Path)
+		result := runTool(sandbox, call, runTests)
+		if remaining := maxSteps - step; remaining <= 3 {
+			result += fmt.Sprintf("\n\n(%d tool calls left; call done before they run out)", remaining)
+		}
+		messages = append(messages, AgentMessage{Role: "user", Content: result})
+	}
+
+	ca.log().Warn("⚠️  Tool loop ran out of steps", "max_steps", maxSteps)
+	return fmt.Sprintf("Work on %s stopped after %d tool calls.", task, maxSteps), total, nil
+}
+
+// addUsage sums the usage of two requests
+func addUsage(a, b TokenUsage) TokenUsage {
+	a.InputTokens += b.InputTokens
+	a.OutputTokens += b.OutputTokens
+	a.ReasoningTokens += b.ReasoningTokens
+	a.TotalTokens += b.TotalTokens
+	a.Cost += b.Cost
+	a.Truncated = b.Truncated
+	if b.Model != "" {
+		a.Model = b.Model
+	}
+	return a
+}
+
+// parseToolCall extracts the tool call JSON from a response, tolerating code fences and
+// surrounding text
+func parseToolCall(response string) (ToolCall, error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return ToolCall{}, fmt.Errorf("no JSON object in the response")
+	}
+
+	var call ToolCall
+	if err := json.Unmarshal([]byte(response[start:end+1]), &call); err != nil {
+		return ToolCall{}, fmt.Errorf("invalid tool call JSON: %w", err)
+	}
+	switch call.Tool {
+	case ToolReadFile, ToolWriteFile, ToolDeleteFile:
+		if call.Path == "" {
+			return ToolCall{}, fmt.Errorf("%s needs a path", call.Tool)
+		}
+	case ToolGrep:
+		if call.Pattern == "" {
+			return ToolCall{}, fmt.Errorf("grep needs a pattern")
+		}
+	case ToolListDir, ToolRunTests, ToolDone:
+	default:
+		return ToolCall{}, fmt.Errorf("unknown tool %q", call.Tool)
+	}
+	return call, nil
+}
+
+// runTool executes a tool call against the sandbox and describes the result for the model
+func runTool(sandbox *Sandbox, call ToolCall, runTests TestRunner) string {
+	switch call.Tool {
+	case ToolReadFile:
+		path, err := sandbox.resolvePath(call.Path)
+		if err != nil {
+			return "Error: " + err.Error()
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Sprintf("Error: can't read %s: %v", call.Path, err)
+		}
+		return fmt.Sprintf("Contents of %s:\n\n%s", call.Path, truncateToolOutput(string(content)))
+
+	case ToolListDir:
+		return listDir(sandbox, call.Path)
+
+	case ToolGrep:
+		return grepSandbox(sandbox, call.Pattern, call.Path)
+
+	case ToolRunTests:
+		if runTests == nil {
+			return "No build or test commands are configured for this repository."
+		}
+		output, passed := runTests()
+		status := "The build and tests pass."
+		if !passed {
+			status = "The build or tests fail."
+		}
+		return fmt.Sprintf("%s\n\n%s", status, truncateToolOutput(output))
+
+	case ToolWriteFile:
+		if err := sandbox.WriteFile(call.Path, call.Content); err != nil {
+			return "Error: " + err.Error()
+		}
+		return fmt.Sprintf("Wrote %s.", call.Path)
+
+	case ToolDeleteFile:
+		if err := sandbox.DeleteFile(call.Path); err != nil {
+			return "Error: " + err.Error()
+		}
+		return fmt.Sprintf("Deleted %s.", call.Path)
+	}
+	return fmt.Sprintf("Error: unknown tool %q", call.Tool)
+}
+
+// listDir lists a sandbox directory, marking subdirectories with a trailing slash
+func listDir(sandbox *Sandbox, relativePath string) string {
+	dir := sandbox.repoPath
+	if relativePath != "" && relativePath != "." && relativePath != "/" {
+		resolved, err := sandbox.resolvePath(relativePath)
+		if err != nil {
+			return "Error: " + err.Error()
+		}
+		dir = resolved
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Sprintf("Error: can't list %s: %v", relativePath, err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.Name() == ".git" {
+			continue
+		}
+		if entry.IsDir() {
+			names = append(names, entry.Name()+"/")
+		} else {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return fmt.Sprintf("%s is empty.", relativePath)
+	}
+	return truncateToolOutput(strings.Join(names, "\n"))
+}
+
+// grepSandbox searches tracked and new files for lines matching pattern
+func grepSandbox(sandbox *Sandbox, pattern, relativePath string) string {
+	args := []string{"grep", "-n", "-I", "-E", "--untracked", "-e", pattern}
+	if relativePath != "" {
+		if _, err := sandbox.resolvePath(relativePath); err != nil {
+			return "Error: " + err.Error()
+		}
+		args = append(args, "--", relativePath)
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = sandbox.repoPath
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return "No matches."
+		}
+		return fmt.Sprintf("Error: grep failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(output), "\n"), "\n")
+	if len(lines) > maxGrepMatches {
+		lines = append(lines[:maxGrepMatches], fmt.Sprintf("... %d more matches; narrow the pattern or path", len(lines)-maxGrepMatches))
+	}
+	return truncateToolOutput(strings.Join(lines, "\n"))
+}
+
+// truncateToolOutput keeps a tool result within maxToolOutputBytes
+func truncateToolOutput(output string) string {
+	if len(output) <= maxToolOutputBytes {
+		return output
+	}
+	return output[:maxToolOutputBytes] + fmt.Sprintf("\n\n... truncated (%d bytes in total)", len(output))
+}
@@ -0,0 +1,180 @@
+package core
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// transcriptsOther is the directory for transcripts of requests not made for an issue
+const transcriptsOther = "_other"
+
+// Transcript is one request to the LLM provider and its response, written for debugging
+type Transcript struct {
+	Time      time.Time           `json:"time"`
+	Owner     string              `json:"owner,omitempty"`
+	Repo      string              `json:"repo,omitempty"`
+	Number    int                 `json:"number,omitempty"`
+	Purpose   string              `json:"purpose"`
+	Provider  string              `json:"provider"`
+	Model     string              `json:"model"`
+	Params    TranscriptParams    `json:"params"`
+	Schema    string              `json:"schema,omitempty"` // Name of the JSON schema asked for, if any
+	System    string              `json:"system"`
+	Messages  []TranscriptMessage `json:"messages"`
+	Response  string              `json:"response,omitempty"`
+	Error     string              `json:"error,omitempty"`
+	Usage     TranscriptUsage     `json:"usage"`
+	LatencyMs int64               `json:"latency_ms"`
+}
+
+// TranscriptParams are the generation parameters a request was sent with
+type TranscriptParams struct {
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *float64 `json:"top_p,omitempty"`
+	MaxTokens       int      `json:"max_tokens,omitempty"`
+	Stop            []string `json:"stop,omitempty"`
+	ReasoningEffort string   `json:"reasoning_effort,omitempty"`
+}
+
+// TranscriptMessage is a message of a transcribed request. Images are counted, not kept.
+type TranscriptMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	Images  int    `json:"images,omitempty"`
+}
+
+// TranscriptUsage is the token usage and cost of a transcribed request
+type TranscriptUsage struct {
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
+}
+
+// SetTranscripts writes every request and response to files under dir, with the given
+// credentials and anything that looks like a secret redacted. An empty dir disables it.
+func (ca *ClaudeAgent) SetTranscripts(dir string, credentials []string) {
+	ca.transcriptsDir = dir
+	ca.credentials = slices.DeleteFunc(slices.Clone(credentials), func(credential string) bool {
+		return len(credential) < 8
+	})
+}
+
+// writeTranscript records a request and its outcome. Failing to write it doesn't fail the request.
+func (ca *ClaudeAgent) writeTranscript(purpose string, req CompletionRequest, response string, usage TokenUsage, latency time.Duration, err error) {
+	if ca.transcriptsDir == "" {
+		return
+	}
+
+	transcript := Transcript{
+		Time:     time.Now(),
+		Owner:    ca.callsFor.owner,
+		Repo:     ca.callsFor.repo,
+		Number:   ca.callsFor.number,
+		Purpose:  purpose,
+		Provider: ca.provider.Name(),
+		Model:    cmp.Or(usage.Model, req.Model),
+		Params: TranscriptParams{
+			Temperature:     req.Params.Temperature,
+			TopP:            req.Params.TopP,
+			MaxTokens:       req.Params.MaxTokens,
+			Stop:            req.Params.Stop,
+			ReasoningEffort: req.Params.ReasoningEffort,
+		},
+		System:    ca.redact(req.System),
+		Response:  ca.redact(response),
+		Usage:     TranscriptUsage{InputTokens: usage.InputTokens, OutputTokens: usage.OutputTokens, Cost: usage.Cost},
+		LatencyMs: latency.Milliseconds(),
+	}
+	if req.Schema != nil {
+		transcript.Schema = req.Schema.Name
+	}
+	for _, msg := range req.Messages {
+		transcript.Messages = append(transcript.Messages, TranscriptMessage{
+			Role:    msg.Role,
+			Content: ca.redact(msg.Content),
+			Images:  len(msg.Images),
+		})
+	}
+	if err != nil {
+		transcript.Error = ca.redact(err.Error())
+	}
+
+	if writeErr := writeTranscriptFile(ca.transcriptsDir, transcript); writeErr != nil {
+		ca.log().Warn("⚠️  Failed to write LLM transcript", "error", writeErr)
+	}
+}
+
+// redact replaces the agent's credentials and anything that looks like a well-known
+// credential format in text
+func (ca *ClaudeAgent) redact(text string) string {
+	for _, credential := range ca.credentials {
+		text = strings.ReplaceAll(text, credential, "[REDACTED]")
+	}
+	for _, detector := range secretDetectors {
+		text = detector.re.ReplaceAllString(text, "[REDACTED "+detector.kind+"]")
+	}
+	return text
+}
+
+// transcriptDir returns the directory holding the transcripts of an issue or pull request
+func transcriptDir(dir, owner, repo string, number int) string {
+	if owner == "" {
+		return filepath.Join(dir, transcriptsOther)
+	}
+	return filepath.Join(dir, owner, repo, strconv.Itoa(number))
+}
+
+// writeTranscriptFile writes a transcript to its own timestamped file in its issue's directory
+func writeTranscriptFile(dir string, transcript Transcript) error {
+	issueDir := transcriptDir(dir, transcript.Owner, transcript.Repo, transcript.Number)
+	if err := os.MkdirAll(issueDir, 0700); err != nil {
+		return fmt.Errorf("failed to create transcript directory: %w", err)
+	}
+	data, err := json.MarshalIndent(transcript, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode transcript: %w", err)
+	}
+	name := fmt.Sprintf("%s-%s.json", transcript.Time.UTC().Format("20060102T150405.000000Z"), transcript.Purpose)
+	if err := os.WriteFile(filepath.Join(issueDir, name), data, 0600); err != nil {
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	return nil
+}
+
+// ReadTranscripts returns the transcripts written for an issue or pull request, oldest first
+func ReadTranscripts(dir, owner, repo string, number int) ([]Transcript, error) {
+	issueDir := transcriptDir(dir, owner, repo, number)
+	entries, err := os.ReadDir(issueDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transcripts: %w", err)
+	}
+
+	var transcripts []Transcript
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(issueDir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read transcript: %w", err)
+		}
+		var transcript Transcript
+		if err := json.Unmarshal(data, &transcript); err != nil {
+			return nil, fmt.Errorf("failed to parse transcript %s: %w", entry.Name(), err)
+		}
+		transcripts = append(transcripts, transcript)
+	}
+	// File names start with the time, and os.ReadDir sorts them
+	return transcripts, nil
+}
@@ -0,0 +1,158 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Triage is the assessment of a newly opened issue
+type Triage struct {
+	Summary    string            `json:"summary"`
+	Labels     []string          `json:"labels"`
+	Complexity string            `json:"complexity"` // "small", "medium" or "large"
+	Estimate   string            `json:"estimate"`   // Rough effort, e.g. "an hour" or "2-3 days"
+	Duplicates []TriageDuplicate `json:"duplicates"`
+}
+
+// TriageDuplicate is an open issue that a new issue likely duplicates
+type TriageDuplicate struct {
+	Number int    `json:"number"`
+	Reason string `json:"reason"`
+}
+
+// triageSchema returns the JSON schema for issue triage
+func triageSchema() *jsonSchema {
+	return &jsonSchema{
+		Name:   "triage",
+		Strict: true,
+		Schema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"summary": map[string]any{
+					"type":        "string",
+					"description": "One sentence restating what the issue asks for",
+				},
+				"labels": map[string]any{
+					"type":        "array",
+					"description": "Labels from the repository's existing labels that fit the issue",
+					"items":       map[string]any{"type": "string"},
+				},
+				"complexity": map[string]any{
+					"type": "string",
+					"enum": []string{"small", "medium", "large"},
+				},
+				"estimate": map[string]any{
+					"type":        "string",
+					"description": "Rough effort for an experienced contributor",
+				},
+				"duplicates": map[string]any{
+					"type":        "array",
+					"description": "Open issues asking for the same thing (empty if none)",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"number": map[string]any{"type": "integer"},
+							"reason": map[string]any{"type": "string"},
+						},
+						"required":             []string{"number", "reason"},
+						"additionalProperties": false,
+					},
+				},
+			},
+			"required":             []string{"summary", "labels", "complexity", "estimate", "duplicates"},
+			"additionalProperties": false,
+		},
+	}
+}
+
+// TriageIssue suggests labels, estimates complexity and looks for duplicates of a new issue
+// among the repository's other open issues
+func (ca *ClaudeAgent) TriageIssue(issue string, labels []string, openIssues string) (Triage, TokenUsage, error) {
+	labelList := "(none)"
+	if len(labels) > 0 {
+		labelList = strings.Join(labels, ", ")
+	}
+	systemPrompt := ca.SystemPrompt(PromptTriage, PromptData{Labels: labelList, OpenIssues: openIssues})
+
+	messages := []AgentMessage{{
+		Role:    "user",
+		Content: issue + "\n\nTriage this issue. Respond with the JSON object only.",
+	}}
+
+	response, usage, err := ca.sendWithSchema(StageAnalysis, messages, systemPrompt, triageSchema())
+	if err != nil {
+		return Triage{}, usage, err
+	}
+
+	triage, err := parseTriage(response)
+	if err != nil {
+		return Triage{}, usage, err
+	}
+	return triage, usage, nil
+}
+
+// parseTriage extracts the triage JSON from a response, tolerating code fences and surrounding text
+func parseTriage(response string) (Triage, error) {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return Triage{}, fmt.Errorf("no JSON object in triage response")
+	}
+
+	var triage Triage
+	if err := json.Unmarshal([]byte(response[start:end+1]), &triage); err != nil {
+		return Triage{}, fmt.Errorf("failed to parse triage response: %w", err)
+	}
+	return triage, nil
+}
+
+// ClaimTriage records that an issue is being triaged. It reports false if the issue was
+// already triaged, so an issue seen by both the webhook and the poller is only triaged once.
+func (sm *StateManager) ClaimTriage(owner, repo string, issueNumber int) (bool, error) {
+	result, err := sm.db.Exec(`
+		INSERT INTO triaged_issues (owner, repo, issue_number, triaged_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT DO NOTHING
+	`, owner, repo, issueNumber, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to claim triage: %w", err)
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim triage: %w", err)
+	}
+	return claimed > 0, nil
+}
+
+// RecordTriageCost adds the cost of triaging an issue to it and to the monthly spend
+func (sm *StateManager) RecordTriageCost(owner, repo string, issueNumber int, cost float64) error {
+	if cost <= 0 {
+		return nil
+	}
+
+	tx, err := sm.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE triaged_issues SET cost = cost + ?
+		WHERE owner = ? AND repo = ? AND issue_number = ?
+	`, cost, owner, repo, issueNumber); err != nil {
+		return fmt.Errorf("failed to record triage cost: %w", err)
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO monthly_spend (month, cost) VALUES (?, ?)
+		ON CONFLICT(month) DO UPDATE SET cost = monthly_spend.cost + excluded.cost
+	`, spendMonth(time.Now()), cost); err != nil {
+		return fmt.Errorf("failed to record spend: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to record triage cost: %w", err)
+	}
+	return nil
+}
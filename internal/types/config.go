@@ -2,6 +2,7 @@ package types
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 )
 
@@ -9,16 +10,433 @@ import (
 type Config struct {
 	WorkingDir        string   `yaml:"working_dir"`
 	StateDBPath       string   `yaml:"state_db_path"`
+	StateDB           string   `yaml:"state_db,omitempty"` // PostgreSQL URL (postgres://...) to use instead of the state_db_path SQLite file
+	StateDBKey        string   `yaml:"-"`                  // Key encrypting conversations at rest, from STATE_DB_ENCRYPTION_KEY
+	Provider          string   `yaml:"provider,omitempty"` // LLM provider: "openrouter" (default), "anthropic", "openai", "local" or "azure"
 	OpenRouterAPIKey  string   `yaml:"openrouter_api_key,omitempty"`
 	OpenRouterModel   string   `yaml:"openrouter_model,omitempty"` // Model to use (default: "qwen/qwen3-coder:free")
+	AnthropicAPIKey   string   `yaml:"anthropic_api_key,omitempty"`
+	AnthropicModel    string   `yaml:"anthropic_model,omitempty"` // Model to use with provider "anthropic" (default: "claude-sonnet-4-5")
+	OpenAIAPIKey      string   `yaml:"openai_api_key,omitempty"`
+	OpenAIModel       string   `yaml:"openai_model,omitempty"`        // Model to use with provider "openai" (default: "gpt-4.1")
+	FallbackModels    []string `yaml:"fallback_models,omitempty"`     // Models tried in order when the primary model fails
+	Models            []string `yaml:"models,omitempty"`              // Model chain: the primary model, then fallbacks (overrides the provider's model)
+	VisionModel       string   `yaml:"vision_model,omitempty"`        // Vision-capable model for issues with screenshots (default: openrouter_model)
+	MaxIssueImages    int      `yaml:"max_issue_images,omitempty"`    // Images analyzed per issue (default: 4, negative disables)
+	ContextWindow     int      `yaml:"context_window,omitempty"`      // Model context window in tokens; older turns are trimmed to fit (0 disables)
+	SummaryThreshold  int      `yaml:"summary_threshold,omitempty"`   // Conversation size in tokens above which earlier turns are summarized (0 disables)
+	RepoContextTokens int      `yaml:"repo_context_tokens,omitempty"` // Budget for relevant file contents in code generation prompts (default: 12000, negative disables)
+	MaxFixIterations  int      `yaml:"max_fix_iterations,omitempty"`  // AI fix attempts after failed build/test verification (default: 9, negative disables)
+	MaxCIFixAttempts  int      `yaml:"max_ci_fix_attempts,omitempty"` // Fix commits pushed when CI fails on a bot PR (0 disables)
+	RequireTests      bool     `yaml:"require_tests,omitempty"`       // Require unit tests for new behavior in every implementation
+	EditMode          string   `yaml:"edit_mode,omitempty"`           // "whole" (default) rewrites complete files; "patch" asks for search/replace edits; "agent" works in the sandbox with tools
+	MaxAgentSteps     int      `yaml:"max_agent_steps,omitempty"`     // Tool calls per generation in edit_mode "agent" (default: 40)
+	PlanMode          bool     `yaml:"plan_mode,omitempty"`           // Plan multi-file changes first, then generate and verify them one step at a time
+	DryRun            bool     `yaml:"dry_run,omitempty"`             // Print comments, pull requests and diffs instead of writing to repositories
+	DryRunDir         string   `yaml:"dry_run_dir,omitempty"`         // Write dry run output to this directory instead of stdout
+	LLMTranscriptsDir string   `yaml:"llm_transcripts_dir,omitempty"` // Write every LLM request and response, redacted, to files per issue in this directory
+	BranchTemplate    string   `yaml:"branch_template,omitempty"`     // Branch name for an issue with {bot}, {owner}, {repo}, {number} and {slug} (default: "nytebubo/issue-{number}")
+	CreateDraftPRs    bool     `yaml:"create_draft_prs,omitempty"`    // Open pull requests as drafts
+	ReadyWhenGreen    bool     `yaml:"ready_when_green,omitempty"`    // Mark draft pull requests ready for review once CI passes, if sandbox verification passed
+	MergeSummary      bool     `yaml:"merge_summary,omitempty"`       // Post a wrap-up comment with time and cost on the issue when its pull request merges
+	GitHubLogin       string   `yaml:"github_login,omitempty"`        // Bot's GitHub login, for tokens that can't look it up (default: the token's user)
 	GitHubToken       string   `yaml:"github_token,omitempty"`
 	PollInterval      int      `yaml:"poll_interval"` // in seconds
 	Repositories      []string `yaml:"repositories"`  // List of repositories to monitor (format: "owner/repo")
 
+	// Local server with an OpenAI-compatible API, for provider "local" (optional)
+	Local LocalConfig `yaml:"local,omitempty"`
+
+	// Azure OpenAI resource, for provider "azure" (optional)
+	Azure AzureConfig `yaml:"azure,omitempty"`
+
+	// GitLab instance serving some of the repositories instead of GitHub (optional)
+	GitLab GitLabConfig `yaml:"gitlab,omitempty"`
+
+	// Gitea or Forgejo instance serving some of the repositories instead of GitHub (optional)
+	Gitea GiteaConfig `yaml:"gitea,omitempty"`
+
+	// Generation parameters: global defaults, overridable per workflow stage
+	Generation GenerationConfig `yaml:"generation,omitempty"`
+	Analysis   GenerationConfig `yaml:"analysis,omitempty"` // Issue analysis and readiness checks
+	Codegen    GenerationConfig `yaml:"codegen,omitempty"`  // Code generation and fix attempts
+	Review     GenerationConfig `yaml:"review,omitempty"`   // Responding to PR review feedback and reviewing pull requests
+	Chat       GenerationConfig `yaml:"chat,omitempty"`     // Replies to issue comments
+
+	// Models per workflow stage, e.g. a free model for conversation and a paid one for code (optional)
+	AnalysisModel string `yaml:"analysis_model,omitempty"` // Issue analysis and replies to issue comments
+	CodegenModel  string `yaml:"codegen_model,omitempty"`  // Code generation and fix attempts
+	ReviewModel   string `yaml:"review_model,omitempty"`   // Responding to review feedback and reviewing pull requests
+
+	// Model prices for estimating cost when the provider doesn't report it, by model name or prefix (optional)
+	Prices map[string]PriceConfig `yaml:"prices,omitempty"`
+
+	// Go templates overriding pull request text and the bot's canned comments (optional)
+	Templates    map[string]string `yaml:"templates,omitempty"`     // Template name -> template text, e.g. pr_title, pr_body or analysis
+	TemplatesDir string            `yaml:"templates_dir,omitempty"` // Directory of <name>.tmpl files; templates set in config take precedence
+
+	// Go templates overriding the system prompts sent to the model (optional)
+	Prompts    map[string]string `yaml:"prompts,omitempty"`     // Prompt name -> template text, e.g. codegen or analysis
+	PromptsDir string            `yaml:"prompts_dir,omitempty"` // Directory of <name>.tmpl files; prompts set in config take precedence
+
+	// Limits on build and test commands run in the sandbox (optional)
+	Sandbox SandboxConfig `yaml:"sandbox,omitempty"`
+
+	// Changes held for a maintainer's /nytebubo force before they're pushed (optional)
+	Guardrails GuardrailsConfig `yaml:"guardrails,omitempty"`
+
+	// Post the diff and wait for /nytebubo apply before pushing any changes (optional)
+	PreviewDiffs bool `yaml:"preview_diffs,omitempty"`
+
+	// Split large issues into pull requests that are merged one after another (optional)
+	Decompose DecomposeConfig `yaml:"decompose,omitempty"`
+
+	// Open issues for the TODOs and limitations a pull request leaves behind (optional)
+	FollowUps FollowUpsConfig `yaml:"follow_ups,omitempty"`
+
+	// Stream completions and report code generation progress (optional)
+	Streaming StreamingConfig `yaml:"streaming,omitempty"`
+
+	// Long-term memory: embeddings of past work per repository (optional)
+	Memory MemoryConfig `yaml:"memory,omitempty"`
+
+	// How work starts besides assignment: "assign" (default), "label" or "mention"
+	Trigger      string `yaml:"trigger,omitempty"`
+	TriggerLabel string `yaml:"trigger_label,omitempty"` // Label that starts work in "label" mode (default: "nytebubo")
+
+	// Which issues the bot takes on, however work is started (optional)
+	OnlyLabels     []string `yaml:"only_labels,omitempty"`     // Only issues with at least one of these labels
+	SkipLabels     []string `yaml:"skip_labels,omitempty"`     // Never issues with any of these labels
+	TrustedAuthors []string `yaml:"trusted_authors,omitempty"` // Only issues opened by these users
+
+	// Labels whose issues are handled first when several are waiting, highest priority first
+	// (e.g. "priority/high"); the rest follow oldest first
+	PriorityLabels []string `yaml:"priority_labels,omitempty"`
+
+	// Start work when an authorized user @-mentions the bot, not only on assignment (same as trigger: mention)
+	MentionTrigger    bool   `yaml:"mention_trigger,omitempty"`
+	MentionPermission string `yaml:"mention_permission,omitempty"` // Minimum permission to trigger: "triage", "write" (default), "maintain" or "admin"
+
+	// Wait for a maintainer to approve the plan before implementing
+	ApprovalRequired   bool   `yaml:"approval_required,omitempty"`
+	ApprovalPermission string `yaml:"approval_permission,omitempty"` // Minimum permission to approve: "triage", "write" (default), "maintain" or "admin"
+
+	// Minimum permission to run /nytebubo commands: "triage", "write" (default), "maintain" or "admin"
+	CommandPermission string `yaml:"command_permission,omitempty"`
+
+	// Minimum permission for comments and review feedback to be acted on: "read", "triage",
+	// "write" (default), "maintain" or "admin". Issue authors can always reply on their own issues.
+	CommentPermission string `yaml:"comment_permission,omitempty"`
+
+	// Users who may trigger, approve, command and steer the bot whatever their repository permission
+	AllowedUsers []string `yaml:"allowed_users,omitempty"`
+
+	// Spending limits in USD; work pauses until a maintainer runs /nytebubo resume (0 disables)
+	MaxCostPerIssue float64 `yaml:"max_cost_per_issue,omitempty"`
+	MonthlyBudget   float64 `yaml:"monthly_budget,omitempty"`
+
+	// Per-issue comment rate limiting and batching of rapid incoming comments
+	CommentRateLimit CommentRateLimitConfig `yaml:"comment_rate_limit,omitempty"`
+
+	// Reminders and expiry for issues waiting on clarification (polling mode only)
+	Stale StaleConfig `yaml:"stale,omitempty"`
+
+	// Rounds of clarifying questions before an issue is handed to a human
+	Clarification ClarificationConfig `yaml:"clarification,omitempty"`
+
+	// Deletes branches and workspaces of issues finished a while ago (optional)
+	Cleanup CleanupConfig `yaml:"cleanup,omitempty"`
+
+	// Monthly cost report posted to GitHub (optional)
+	Reports ReportsConfig `yaml:"reports,omitempty"`
+
+	// Address to serve Prometheus metrics on, e.g. "127.0.0.1:9090" (empty disables)
+	MetricsAddress string `yaml:"metrics_address,omitempty"`
+
+	// Web dashboard for monitoring issues (optional)
+	Dashboard DashboardConfig `yaml:"dashboard,omitempty"`
+
+	// Logging verbosity and output format
+	LogLevel  string `yaml:"log_level,omitempty"`  // "debug", "info" (default), "warn" or "error"
+	LogFormat string `yaml:"log_format,omitempty"` // "text" (default) or "json"
+
+	// Per-repository settings keyed by "owner/repo" (optional)
+	RepoSettings map[string]RepoConfig `yaml:"repo_settings,omitempty"`
+
 	// Webhook mode (optional, deprecated)
-	ServerPort    int    `yaml:"server_port,omitempty"`
-	WebhookSecret string `yaml:"webhook_secret,omitempty"`
-	WebhookMode   bool   `yaml:"webhook_mode,omitempty"` // Set to true to use webhook mode instead of polling
+	ServerPort     int    `yaml:"server_port,omitempty"`
+	WebhookSecret  string `yaml:"webhook_secret,omitempty"`
+	WebhookMode    bool   `yaml:"webhook_mode,omitempty"`    // Set to true to use webhook mode instead of polling
+	WebhookURL     string `yaml:"webhook_url,omitempty"`     // Public webhook URL; enables catch-up of deliveries missed during downtime
+	WebhookWorkers int    `yaml:"webhook_workers,omitempty"` // Webhook events handled at once (default 4)
+	// Repositories polled at poll_interval alongside the webhook server, e.g. those without a webhook
+	PollRepositories []string `yaml:"poll_repositories,omitempty"`
+	SweepInterval    int      `yaml:"sweep_interval,omitempty"` // Seconds between sweep polls of the other repositories for missed events (0 disables)
+	// Listen address, TLS, base path and timeouts of the webhook server
+	Server ServerConfig `yaml:"server,omitempty"`
+}
+
+// GenerationConfig holds model sampling parameters. Unset fields fall back to
+// the global generation block, then to the built-in defaults.
+type GenerationConfig struct {
+	Temperature     *float64 `yaml:"temperature,omitempty"`
+	TopP            *float64 `yaml:"top_p,omitempty"`
+	MaxTokens       int      `yaml:"max_tokens,omitempty"`
+	Stop            []string `yaml:"stop,omitempty"`
+	ReasoningEffort string   `yaml:"reasoning_effort,omitempty"` // "low", "medium" or "high" (reasoning models only)
+}
+
+// LocalConfig points at a local OpenAI-compatible server such as Ollama or llama.cpp
+type LocalConfig struct {
+	BaseURL           string `yaml:"base_url,omitempty"`            // API root (default: "http://localhost:11434/v1", Ollama)
+	Model             string `yaml:"model,omitempty"`               // Model to use (default: "qwen2.5-coder")
+	APIKey            string `yaml:"api_key,omitempty"`             // Only for servers that check one
+	MergeSystemPrompt bool   `yaml:"merge_system_prompt,omitempty"` // Send the system prompt in the first user message, for models without a system role
+	StructuredOutput  string `yaml:"structured_output,omitempty"`   // "json_schema" (default), "json_object" for JSON mode only, or "none"
+}
+
+// AzureConfig points at an Azure OpenAI resource. Requests authenticate with Entra ID
+// (Azure AD) when the tenant, client ID and client secret are set, and with the API key
+// otherwise.
+type AzureConfig struct {
+	Endpoint     string            `yaml:"endpoint,omitempty"`      // Resource endpoint, e.g. "https://my-resource.openai.azure.com"
+	APIVersion   string            `yaml:"api_version,omitempty"`   // api-version query parameter (default: "2024-10-21")
+	Deployment   string            `yaml:"deployment,omitempty"`    // Deployment to use (default: "gpt-4.1")
+	Deployments  map[string]string `yaml:"deployments,omitempty"`   // Model name -> deployment, for fallback, stage and embedding models
+	APIKey       string            `yaml:"api_key,omitempty"`       // Prefer the AZURE_OPENAI_API_KEY environment variable
+	TenantID     string            `yaml:"tenant_id,omitempty"`     // Entra ID tenant (or AZURE_TENANT_ID)
+	ClientID     string            `yaml:"client_id,omitempty"`     // Entra ID application (or AZURE_CLIENT_ID)
+	ClientSecret string            `yaml:"client_secret,omitempty"` // Prefer the AZURE_CLIENT_SECRET environment variable
+}
+
+// UsesEntraID reports whether requests authenticate with Entra ID tokens instead of an API key
+func (a AzureConfig) UsesEntraID() bool {
+	return a.TenantID != "" && a.ClientID != "" && a.ClientSecret != ""
+}
+
+// PriceConfig is what a model costs in USD per million tokens
+type PriceConfig struct {
+	Input  float64 `yaml:"input"`
+	Output float64 `yaml:"output"`
+}
+
+// SandboxConfig limits the commands run in the sandbox
+type SandboxConfig struct {
+	CommandTimeout int `yaml:"command_timeout,omitempty"`  // Seconds before a build or test command is killed (default: 600, negative disables)
+	MaxOutputBytes int `yaml:"max_output_bytes,omitempty"` // Command output kept, from the start and end (default: 1048576, negative disables)
+}
+
+// GuardrailsConfig holds generated changes that look destructive or off-topic until a
+// maintainer approves them
+type GuardrailsConfig struct {
+	MaxFiles          int  `yaml:"max_files,omitempty"`           // Changes to more files are held (default: 25, negative disables)
+	MaxDeletedPercent int  `yaml:"max_deleted_percent,omitempty"` // Changes removing more of an existing file's lines are held (default: 50, negative disables)
+	UnrelatedFiles    bool `yaml:"unrelated_files,omitempty"`     // Also hold changes to files that share no keywords with the issue
+}
+
+// StreamingConfig controls streamed completions
+type StreamingConfig struct {
+	Enabled         bool `yaml:"enabled"`
+	ProgressComment bool `yaml:"progress_comment,omitempty"` // Keep an edited issue comment updated while code is generated
+}
+
+// MemoryConfig configures embedding-based long-term memory
+type MemoryConfig struct {
+	Enabled        bool    `yaml:"enabled"`
+	EmbeddingModel string  `yaml:"embedding_model,omitempty"` // Default: "openai/text-embedding-3-small"
+	TopK           int     `yaml:"top_k,omitempty"`           // Memories recalled per issue (default: 3)
+	MinSimilarity  float64 `yaml:"min_similarity,omitempty"`  // Cosine similarity threshold (default: 0.75)
+}
+
+// CommentRateLimitConfig throttles the bot's comments and batches bursts of user comments
+type CommentRateLimitConfig struct {
+	MinIntervalSeconds int `yaml:"min_interval_seconds,omitempty"` // Minimum time between bot comments on one issue (0 disables)
+	BatchWindowSeconds int `yaml:"batch_window_seconds,omitempty"` // Wait this long for more comments before responding (webhook mode; 0 disables)
+}
+
+// StaleConfig controls reminders for issues left waiting for clarification
+type StaleConfig struct {
+	ReminderAfterHours int  `yaml:"reminder_after_hours,omitempty"` // Post a reminder after this long without a reply (0 disables)
+	ExpireAfterHours   int  `yaml:"expire_after_hours,omitempty"`   // Mark the issue stale this long after the reminder (0 disables)
+	Unassign           bool `yaml:"unassign,omitempty"`             // Unassign the bot when the issue expires
+}
+
+// DecomposeConfig lets the bot deliver a large issue as several pull requests, opening each
+// once the one before it has merged
+type DecomposeConfig struct {
+	Enabled  bool `yaml:"enabled"`
+	MaxParts int  `yaml:"max_parts,omitempty"` // Most pull requests an issue is split into (default: 4)
+}
+
+// FollowUpsConfig opens an issue for each piece of work a pull request's summary defers, so
+// it isn't lost once the pull request merges
+type FollowUpsConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	Label     string `yaml:"label,omitempty"`      // Label added to follow-up issues (default: "nytebubo-followup")
+	MaxIssues int    `yaml:"max_issues,omitempty"` // Most follow-up issues opened per pull request (default: 3)
+}
+
+// ClarificationConfig limits how long the bot keeps asking questions about an issue
+type ClarificationConfig struct {
+	MaxRounds int    `yaml:"max_rounds,omitempty"` // Rounds of questions before escalating (default: 5, negative disables)
+	Label     string `yaml:"label,omitempty"`      // Label added when escalating (default: "needs-human")
+}
+
+// CleanupConfig controls the janitor that removes what finished issues leave behind
+type CleanupConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Age     int  `yaml:"age,omitempty"` // Days after an issue is completed or abandoned before its branch and workspace are deleted (default: 7)
+}
+
+// ReportsConfig controls the monthly cost report, posted once a month has ended
+type ReportsConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	Repository string `yaml:"repository,omitempty"` // GitHub repository ("owner/repo") to open each report in as an issue
+	Gist       bool   `yaml:"gist,omitempty"`       // Post each report as a secret gist of the bot account
+}
+
+// GitLabConfig routes repositories to a GitLab instance
+type GitLabConfig struct {
+	URL          string   `yaml:"url,omitempty"`          // Instance URL (default: "https://gitlab.com")
+	Token        string   `yaml:"token,omitempty"`        // Access token with the api scope; prefer the GITLAB_TOKEN environment variable
+	Repositories []string `yaml:"repositories,omitempty"` // Projects from the repositories list that live on GitLab ("group/project")
+}
+
+// GiteaConfig routes repositories to a Gitea or Forgejo instance
+type GiteaConfig struct {
+	URL          string   `yaml:"url,omitempty"`          // Instance URL, e.g. "https://git.example.com" (required)
+	Token        string   `yaml:"token,omitempty"`        // Access token with repository and issue write scopes; prefer the GITEA_TOKEN environment variable
+	Repositories []string `yaml:"repositories,omitempty"` // Repositories from the repositories list that live on Gitea ("owner/repo")
+}
+
+// ServerConfig configures the webhook server's listener
+type ServerConfig struct {
+	Address      string `yaml:"address,omitempty"`       // Listen address, e.g. "127.0.0.1:8080" (default: server_port on every interface)
+	BasePath     string `yaml:"base_path,omitempty"`     // Prefix of every route, e.g. "/nytebubo" behind a reverse proxy
+	TLSCert      string `yaml:"tls_cert,omitempty"`      // Certificate file; with tls_key the server speaks HTTPS
+	TLSKey       string `yaml:"tls_key,omitempty"`       // Private key file for tls_cert
+	ReadTimeout  int    `yaml:"read_timeout,omitempty"`  // Seconds to read a request (default 30)
+	WriteTimeout int    `yaml:"write_timeout,omitempty"` // Seconds to write a response (default 30)
+}
+
+// ListenAddress returns the address the webhook server listens on
+func (c Config) ListenAddress() string {
+	if c.Server.Address != "" {
+		return c.Server.Address
+	}
+	return fmt.Sprintf(":%d", c.ServerPort)
+}
+
+// DashboardConfig serves the web dashboard
+type DashboardConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Address string `yaml:"address,omitempty"` // Listen address (default: "127.0.0.1:8090")
+	Token   string `yaml:"token,omitempty"`   // Basic auth password; prefer the DASHBOARD_TOKEN environment variable
+}
+
+// RepoConfig holds settings that apply to a single repository
+type RepoConfig struct {
+	TestMatrix         TestMatrixConfig `yaml:"test_matrix,omitempty"`
+	BuildCommand       string           `yaml:"build_command,omitempty"`       // Shell command that builds the project, e.g. "make" (overrides .nytebubo.yaml and the detected command)
+	TestCommand        string           `yaml:"test_command,omitempty"`        // Shell command that runs the tests, e.g. "make test"
+	SerializeConflicts bool             `yaml:"serialize_conflicts,omitempty"` // Wait for overlapping bot PRs to close instead of opening another
+	ResolveConflicts   bool             `yaml:"resolve_conflicts,omitempty"`   // Rebase bot PRs that conflict with their base branch, resolving conflicts with the AI
+	Triage             bool             `yaml:"triage,omitempty"`              // Comment on every new issue with suggested labels, a complexity estimate and likely duplicates
+	ReviewPRs          bool             `yaml:"review_prs,omitempty"`          // Review every pull request opened by someone else, not only when requested as a reviewer
+	AutoMerge          AutoMergeConfig  `yaml:"auto_merge,omitempty"`
+
+	// Labels, assignees, reviewers and milestone for the bot's pull requests
+	PullRequests PullRequestConfig `yaml:"pull_requests,omitempty"`
+}
+
+// PullRequestConfig is applied to the pull requests the bot opens
+type PullRequestConfig struct {
+	Labels        []string `yaml:"labels,omitempty"`         // e.g. ["ai-generated"]
+	Assignees     []string `yaml:"assignees,omitempty"`      // Users to assign
+	Reviewers     []string `yaml:"reviewers,omitempty"`      // Users to request reviews from
+	TeamReviewers []string `yaml:"team_reviewers,omitempty"` // Team slugs to request reviews from (GitHub and Gitea)
+	Milestone     string   `yaml:"milestone,omitempty"`      // Title of an open milestone
+}
+
+// AutoMergeConfig merges the bot's pull requests once they are approved and CI passes
+type AutoMergeConfig struct {
+	Enabled           bool   `yaml:"enabled"`
+	Method            string `yaml:"method,omitempty"`             // "merge", "squash" or "rebase" (default: "merge")
+	RequiredApprovals int    `yaml:"required_approvals,omitempty"` // Default: 1
+	Native            bool   `yaml:"native,omitempty"`             // Enable GitHub's auto-merge instead of merging directly
+}
+
+// TestMatrixConfig lists toolchain versions that verification runs against
+type TestMatrixConfig struct {
+	Versions      []string `yaml:"versions,omitempty"`       // e.g. ["1.21", "1.22"]
+	Image         string   `yaml:"image,omitempty"`          // Container image template, e.g. "golang:{version}" (default: official image for the language)
+	CommandPrefix []string `yaml:"command_prefix,omitempty"` // Version manager instead of containers, e.g. ["mise", "exec", "go@{version}", "--"]
+}
+
+// ForRepo returns the settings for a repository (zero value if none are configured)
+func (c Config) ForRepo(owner, repo string) RepoConfig {
+	return c.RepoSettings[owner+"/"+repo]
+}
+
+// StateDatabase returns where the state is kept: the state_db URL when set, otherwise the
+// SQLite file at state_db_path
+func (c Config) StateDatabase() string {
+	if c.StateDB != "" {
+		return c.StateDB
+	}
+	return c.StateDBPath
+}
+
+// RedactedStateDatabase returns StateDatabase with the password in a URL hidden, for display
+func (c Config) RedactedStateDatabase() string {
+	database := c.StateDatabase()
+	if u, err := url.Parse(database); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return database
+}
+
+// Model returns the configured model for the selected provider (empty uses the provider default)
+func (c Config) Model() string {
+	if len(c.Models) > 0 {
+		return c.Models[0]
+	}
+	switch c.Provider {
+	case "anthropic":
+		return c.AnthropicModel
+	case "openai":
+		return c.OpenAIModel
+	case "local":
+		return c.Local.Model
+	case "azure":
+		return c.Azure.Deployment
+	}
+	return c.OpenRouterModel
+}
+
+// Fallbacks returns the models tried in order when the primary model fails: the rest of
+// the models chain, then fallback_models
+func (c Config) Fallbacks() []string {
+	if len(c.Models) > 1 {
+		return append(append([]string(nil), c.Models[1:]...), c.FallbackModels...)
+	}
+	return c.FallbackModels
+}
+
+// MentionTriggerEnabled reports whether @-mentions start work
+func (c Config) MentionTriggerEnabled() bool {
+	return c.MentionTrigger || c.Trigger == "mention"
+}
+
+// LabelTrigger returns the label that starts work, or "" when label triggers are off
+func (c Config) LabelTrigger() string {
+	if c.Trigger != "label" {
+		return ""
+	}
+	if c.TriggerLabel == "" {
+		return "nytebubo"
+	}
+	return c.TriggerLabel
 }
 
 func (c Config) Display() string {
@@ -27,8 +445,17 @@ func (c Config) Display() string {
 
 	if c.WebhookMode {
 		b.WriteString("  Mode:            Webhook\n")
-		b.WriteString(fmt.Sprintf("  Server Port:     %d\n", c.ServerPort))
+		b.WriteString(fmt.Sprintf("  Listen Address:  %s\n", c.ListenAddress()))
+		if c.Server.TLSCert != "" {
+			b.WriteString("  TLS:             enabled\n")
+		}
 		b.WriteString(fmt.Sprintf("  Webhook Secret:  %s\n", maskSecret(c.WebhookSecret)))
+		if len(c.PollRepositories) > 0 {
+			b.WriteString(fmt.Sprintf("  Also Polling:    %s (every %ds)\n", strings.Join(c.PollRepositories, ", "), c.PollInterval))
+		}
+		if c.SweepInterval > 0 {
+			b.WriteString(fmt.Sprintf("  Sweep Interval:  %ds\n", c.SweepInterval))
+		}
 	} else {
 		b.WriteString("  Mode:            Polling\n")
 		b.WriteString(fmt.Sprintf("  Poll Interval:   %ds\n", c.PollInterval))
@@ -36,15 +463,79 @@ func (c Config) Display() string {
 	}
 
 	b.WriteString(fmt.Sprintf("  Working Dir:     %s\n", c.WorkingDir))
-	b.WriteString(fmt.Sprintf("  State DB:        %s\n", c.StateDBPath))
-	b.WriteString(fmt.Sprintf("  OpenRouter Key:  %s\n", maskSecret(c.OpenRouterAPIKey)))
-
-	model := c.OpenRouterModel
-	if model == "" {
-		model = "qwen/qwen3-coder:free (default)"
+	b.WriteString(fmt.Sprintf("  State DB:        %s\n", c.RedactedStateDatabase()))
+	if c.StateDBKey != "" {
+		b.WriteString("  Encryption:      conversations encrypted at rest\n")
+	}
+	model := c.Model()
+	switch c.Provider {
+	case "anthropic":
+		b.WriteString("  Provider:        Anthropic\n")
+		b.WriteString(fmt.Sprintf("  Anthropic Key:   %s\n", maskSecret(c.AnthropicAPIKey)))
+		if model == "" {
+			model = "claude-sonnet-4-5 (default)"
+		}
+	case "openai":
+		b.WriteString("  Provider:        OpenAI\n")
+		b.WriteString(fmt.Sprintf("  OpenAI Key:      %s\n", maskSecret(c.OpenAIAPIKey)))
+		if model == "" {
+			model = "gpt-4.1 (default)"
+		}
+	case "local":
+		b.WriteString("  Provider:        Local\n")
+		baseURL := c.Local.BaseURL
+		if baseURL == "" {
+			baseURL = "http://localhost:11434/v1 (default)"
+		}
+		b.WriteString(fmt.Sprintf("  Base URL:        %s\n", baseURL))
+		if model == "" {
+			model = "qwen2.5-coder (default)"
+		}
+	case "azure":
+		b.WriteString("  Provider:        Azure OpenAI\n")
+		b.WriteString(fmt.Sprintf("  Endpoint:        %s\n", c.Azure.Endpoint))
+		if c.Azure.UsesEntraID() {
+			b.WriteString(fmt.Sprintf("  Auth:            Entra ID (client %s)\n", c.Azure.ClientID))
+		} else {
+			b.WriteString(fmt.Sprintf("  Azure Key:       %s\n", maskSecret(c.Azure.APIKey)))
+		}
+		if model == "" {
+			model = "gpt-4.1 (default)"
+		}
+	default:
+		b.WriteString("  Provider:        OpenRouter\n")
+		b.WriteString(fmt.Sprintf("  OpenRouter Key:  %s\n", maskSecret(c.OpenRouterAPIKey)))
+		if model == "" {
+			model = "qwen/qwen3-coder:free (default)"
+		}
 	}
 	b.WriteString(fmt.Sprintf("  AI Model:        %s\n", model))
+	for _, stage := range []struct{ name, model string }{
+		{"Analysis", c.AnalysisModel},
+		{"Codegen", c.CodegenModel},
+		{"Review", c.ReviewModel},
+	} {
+		if stage.model != "" {
+			b.WriteString(fmt.Sprintf("  %-17s%s\n", stage.name+" Model:", stage.model))
+		}
+	}
+	if fallbacks := c.Fallbacks(); len(fallbacks) > 0 {
+		b.WriteString(fmt.Sprintf("  Fallback Models: %s\n", strings.Join(fallbacks, ", ")))
+	}
+	if c.MaxCostPerIssue > 0 {
+		b.WriteString(fmt.Sprintf("  Issue Budget:    $%.2f\n", c.MaxCostPerIssue))
+	}
+	if c.MonthlyBudget > 0 {
+		b.WriteString(fmt.Sprintf("  Monthly Budget:  $%.2f\n", c.MonthlyBudget))
+	}
 	b.WriteString(fmt.Sprintf("  GitHub Token:    %s\n", maskSecret(c.GitHubToken)))
+	if c.DryRun {
+		output := c.DryRunDir
+		if output == "" {
+			output = "stdout"
+		}
+		b.WriteString(fmt.Sprintf("  Dry Run:         %s\n", output))
+	}
 	b.WriteString("\n")
 	return b.String()
 }
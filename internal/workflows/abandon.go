@@ -0,0 +1,124 @@
+package workflows
+
+import (
+	"fmt"
+	"strings"
+
+	"NyteBubo/internal/core"
+	"github.com/google/go-github/v63/github"
+)
+
+// AbandonIssue stops work on an issue that was closed or unassigned from the bot (reason is
+// "closed" or "unassigned"): the bot's open pull request is closed, its branch deleted and
+// the state marked abandoned. An issue closed by merging the bot's pull request is
+// completed instead.
+func (ia *IssueAgent) AbandonIssue(owner, repo string, issueNumber int, reason string) error {
+	state, err := ia.stateManager.GetState(owner, repo, issueNumber)
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+	if !abandonable(state, reason) {
+		return nil
+	}
+
+	var pr *github.PullRequest
+	if state.PRNumber != nil {
+		prNumber := *state.PRNumber
+		lock := ia.busyLock("pr:" + issueKey(owner, repo, prNumber))
+		lock.Lock()
+		defer lock.Unlock()
+
+		// The pull request may have merged, or the issue been abandoned, while waiting for the lock
+		state, err = ia.stateManager.GetState(owner, repo, issueNumber)
+		if err != nil {
+			return fmt.Errorf("failed to get state: %w", err)
+		}
+		if !abandonable(state, reason) {
+			return nil
+		}
+
+		pr, err = ia.host(owner, repo).GetPullRequest(owner, repo, prNumber)
+		if err != nil {
+			return fmt.Errorf("failed to get PR: %w", err)
+		}
+		if pr.GetMerged() {
+			return ia.finishMerged(state, pr)
+		}
+	}
+	if err := ia.closeWork(state, pr, reason); err != nil {
+		return err
+	}
+
+	state.Logger().Info("🚪 Abandoning issue", "reason", reason, "status", state.Status)
+	state.Status = "abandoned"
+	state.PRNumber = nil
+	state.BlockedByPR = nil
+	state.Checkpoint, state.CheckpointData = "", ""
+	if err := ia.stateManager.SaveState(state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return nil
+}
+
+// abandonable reports whether work on an issue stops when it's closed or unassigned
+func abandonable(state *core.State, reason string) bool {
+	if state == nil || state.Status == "completed" || state.Status == "abandoned" {
+		return false
+	}
+	// Expiring a stale issue can unassign the bot, and replies still pick it back up
+	return reason != "unassigned" || state.Status != "stale"
+}
+
+// closeWork closes the issue's pull request if it's open, saying why, and deletes its branch
+func (ia *IssueAgent) closeWork(state *core.State, pr *github.PullRequest, reason string) error {
+	owner, repo := state.Owner, state.Repo
+	logger := state.Logger()
+
+	if pr != nil && pr.GetState() == "open" {
+		prNumber := pr.GetNumber()
+		logger.Info("🚪 Closing pull request for abandoned issue", "pr", prNumber, "reason", reason)
+		comment := fmt.Sprintf("🚪 #%d was %s, so I'm closing this pull request.", state.IssueNumber, reason)
+		if err := ia.postPRComment(owner, repo, prNumber, comment); err != nil {
+			logger.Warn("⚠️  Failed to comment on PR", "pr", prNumber, "error", err)
+		}
+		if err := ia.host(owner, repo).ClosePullRequest(owner, repo, prNumber); err != nil {
+			return err
+		}
+	}
+
+	if state.BranchName != "" {
+		// The branch is only pushed once changes are generated, so it may not exist
+		if err := ia.host(owner, repo).DeleteBranch(owner, repo, state.BranchName); err != nil {
+			logger.Debug("Branch not deleted", "branch", state.BranchName, "error", err)
+		}
+	}
+	return nil
+}
+
+// discardAbandonedWork closes the pull request and deletes the branch an implementation
+// step created after the issue was abandoned, which AbandonIssue couldn't see yet
+func (ia *IssueAgent) discardAbandonedWork(state *core.State) {
+	owner, repo := state.Owner, state.Repo
+	var pr *github.PullRequest
+	if state.PRNumber != nil {
+		var err error
+		if pr, err = ia.host(owner, repo).GetPullRequest(owner, repo, *state.PRNumber); err != nil {
+			state.Logger().Warn("⚠️  Failed to get PR", "pr", *state.PRNumber, "error", err)
+		}
+	}
+	if err := ia.closeWork(state, pr, "abandoned"); err != nil {
+		state.Logger().Warn("⚠️  Failed to close work on abandoned issue", "error", err)
+	}
+}
+
+// HandleUnassignment abandons the issue when the bot itself was unassigned
+func (ia *IssueAgent) HandleUnassignment(owner, repo string, issueNumber int, assignee string) error {
+	login, err := ia.botLogin(owner, repo)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(assignee, login) {
+		return nil
+	}
+	return ia.AbandonIssue(owner, repo, issueNumber, "unassigned")
+}
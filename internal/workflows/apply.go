@@ -0,0 +1,172 @@
+package workflows
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"NyteBubo/internal/core"
+)
+
+// deleteDirectiveRe matches a "DELETE: path" line in a markdown response
+var deleteDirectiveRe = regexp.MustCompile("(?m)^\\s*DELETE:\\s*`?([\\w/._-]+)`?\\s*$")
+
+// renameDirectiveRe matches a "RENAME: old -> new" line in a markdown response
+var renameDirectiveRe = regexp.MustCompile("(?m)^\\s*RENAME:\\s*`?([\\w/._-]+)`?\\s*(?:->|→)\\s*`?([\\w/._-]+)`?\\s*$")
+
+// fileRename moves a file to a new path
+type fileRename struct {
+	From, To string
+}
+
+// parseFileActions extracts the files the AI asked to delete or rename, from either
+// structured JSON output or DELETE:/RENAME: lines outside code blocks
+func parseFileActions(response string) ([]string, []fileRename) {
+	var jsonResponse struct {
+		Files []struct {
+			Action  string `json:"action"`
+			Path    string `json:"path"`
+			OldPath string `json:"old_path"`
+		} `json:"files"`
+		DeletedFiles []string `json:"deleted_files"` // Older responses and checkpoints list deletions separately
+	}
+	if err := json.Unmarshal([]byte(response), &jsonResponse); err == nil {
+		deleted := jsonResponse.DeletedFiles
+		var renames []fileRename
+		for _, file := range jsonResponse.Files {
+			switch {
+			case file.Path == "":
+			case file.Action == core.FileActionDelete:
+				deleted = append(deleted, file.Path)
+			case file.Action == core.FileActionRename && file.OldPath != "" && file.OldPath != file.Path:
+				renames = append(renames, fileRename{From: file.OldPath, To: file.Path})
+			}
+		}
+		return deleted, renames
+	}
+
+	text := stripCodeBlocks(response)
+	var deleted []string
+	for _, match := range deleteDirectiveRe.FindAllStringSubmatch(text, -1) {
+		deleted = append(deleted, match[1])
+	}
+	var renames []fileRename
+	for _, match := range renameDirectiveRe.FindAllStringSubmatch(text, -1) {
+		if match[1] != match[2] {
+			renames = append(renames, fileRename{From: match[1], To: match[2]})
+		}
+	}
+	return deleted, renames
+}
+
+// stripCodeBlocks removes fenced code blocks so directives inside file contents are ignored
+func stripCodeBlocks(text string) string {
+	var b strings.Builder
+	inBlock := false
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inBlock = !inBlock
+			continue
+		}
+		if !inBlock {
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
+
+// codeChanges is the set of file operations parsed from an AI response
+type codeChanges struct {
+	Files   map[string]string // Complete contents of created or rewritten files
+	Edits   []core.FileEdit   // Search/replace edits to existing files (patch mode)
+	Deleted []string
+	Renamed []fileRename // Applied before the other changes, which refer to the new paths
+	Text    string       // The response without edit blocks, used for summaries
+}
+
+// parseChanges extracts every kind of file operation from an AI response
+func parseChanges(response string) codeChanges {
+	edits, rest := parseEditBlocks(response)
+	deleted, renamed := parseFileActions(rest)
+	changes := codeChanges{Edits: edits, Deleted: deleted, Renamed: renamed, Text: rest}
+	if len(edits) == 0 || strings.Contains(rest, "```") {
+		changes.Files = parseCodeChanges(rest)
+	}
+	return changes
+}
+
+// empty reports whether the response contained no file operations
+func (c codeChanges) empty() bool {
+	return len(c.Files) == 0 && len(c.Edits) == 0 && len(c.Deleted) == 0 && len(c.Renamed) == 0
+}
+
+// paths returns every file touched by the changes (values are unused)
+func (c codeChanges) paths() map[string]string {
+	paths := make(map[string]string)
+	for path := range c.Files {
+		paths[path] = ""
+	}
+	for _, edit := range c.Edits {
+		paths[edit.Path] = ""
+	}
+	for _, path := range c.Deleted {
+		paths[path] = ""
+	}
+	for _, rename := range c.Renamed {
+		paths[rename.From] = ""
+		paths[rename.To] = ""
+	}
+	return paths
+}
+
+// applyChanges renames, writes, edits and deletes files in the sandbox. Edits that fail
+// to apply are returned per file so the caller can fall back to whole-file rewrites;
+// other failures are returned as an error. Files that are both written and deleted
+// are kept, since a rename deletes the old path only.
+func applyChanges(sandbox *core.Sandbox, changes codeChanges) (map[string]error, error) {
+	for _, rename := range changes.Renamed {
+		sandbox.Logger().Info("  - Renaming file", "from", rename.From, "to", rename.To)
+		if err := sandbox.RenameFile(rename.From, rename.To); err != nil {
+			return nil, fmt.Errorf("failed to rename %s to %s: %w", rename.From, rename.To, err)
+		}
+	}
+
+	for filePath, content := range changes.Files {
+		sandbox.Logger().Info("  - Writing file", "path", filePath)
+		if err := sandbox.WriteFile(filePath, content); err != nil {
+			return nil, fmt.Errorf("failed to write file %s: %w", filePath, err)
+		}
+	}
+
+	// Group edits by file, keeping their order, so each file is edited all-or-nothing
+	var order []string
+	byFile := make(map[string][]core.FileEdit)
+	for _, edit := range changes.Edits {
+		if _, seen := byFile[edit.Path]; !seen {
+			order = append(order, edit.Path)
+		}
+		byFile[edit.Path] = append(byFile[edit.Path], edit)
+	}
+
+	failed := make(map[string]error)
+	for _, filePath := range order {
+		sandbox.Logger().Info("  - Editing file", "path", filePath, "blocks", len(byFile[filePath]))
+		if err := sandbox.ApplyEdits(filePath, byFile[filePath]); err != nil {
+			sandbox.Logger().Warn("⚠️  Couldn't apply edits", "path", filePath, "error", err)
+			failed[filePath] = err
+		}
+	}
+
+	for _, filePath := range changes.Deleted {
+		if _, written := changes.Files[filePath]; written {
+			continue
+		}
+		sandbox.Logger().Info("  - Deleting file", "path", filePath)
+		if err := sandbox.DeleteFile(filePath); err != nil {
+			return nil, fmt.Errorf("failed to delete file %s: %w", filePath, err)
+		}
+	}
+
+	return failed, nil
+}
@@ -0,0 +1,161 @@
+package workflows
+
+import (
+	"fmt"
+	"regexp"
+
+	"NyteBubo/internal/core"
+)
+
+// approveCommandRe matches an /approve command on a line of its own
+var approveCommandRe = regexp.MustCompile(`(?mi)^\s*/approve\s*$`)
+
+// approvalPermission returns the minimum permission needed to approve a plan
+func (ia *IssueAgent) approvalPermission() string {
+	if ia.config.ApprovalPermission == "" {
+		return "write"
+	}
+	return ia.config.ApprovalPermission
+}
+
+// requestApproval posts the implementation plan and parks the issue until a maintainer approves it
+func (ia *IssueAgent) requestApproval(state *core.State) error {
+	var plan string
+	for i := len(state.Conversation) - 1; i >= 0; i-- {
+		if state.Conversation[i].Role == "assistant" {
+			plan = state.Conversation[i].Content
+			break
+		}
+	}
+
+	state.Logger().Info("📋 Waiting for approval of the plan")
+
+	permission := ia.approvalPermission()
+	comment := ia.render(templatePlanApproval, templateData{
+		Owner:       state.Owner,
+		Repo:        state.Repo,
+		IssueNumber: state.IssueNumber,
+		Summary:     plan,
+		Permission:  permission,
+		Default:     planApprovalComment(plan, permission),
+	})
+	id, err := ia.postCommentWithID(state.Owner, state.Repo, state.IssueNumber, comment)
+	if err != nil {
+		return fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	state.Status = "waiting_for_approval"
+	state.PlanCommentID = &id
+	if err := ia.stateManager.SaveState(state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return nil
+}
+
+// findApproval returns who approved the plan, via an /approve comment or a 👍 reaction
+// on the plan comment, or "" if nobody with enough access has approved it yet
+func (ia *IssueAgent) findApproval(state *core.State) (string, error) {
+	if state.PlanCommentID == nil {
+		return "", nil
+	}
+
+	login, err := ia.botLogin(state.Owner, state.Repo)
+	if err != nil {
+		return "", err
+	}
+
+	required := ia.approvalPermission()
+	permitted := make(map[string]bool)
+	canApprove := func(user string) (bool, error) {
+		if user == "" || user == login {
+			return false, nil
+		}
+		if ok, seen := permitted[user]; seen {
+			return ok, nil
+		}
+		allowed, err := ia.hasPermission(state.Owner, state.Repo, user, required)
+		if err != nil {
+			return false, err
+		}
+		permitted[user] = allowed
+		return allowed, nil
+	}
+
+	comments, err := ia.host(state.Owner, state.Repo).ListIssueComments(state.Owner, state.Repo, state.IssueNumber)
+	if err != nil {
+		return "", err
+	}
+	for _, comment := range comments {
+		if comment.GetID() <= *state.PlanCommentID || !approveCommandRe.MatchString(comment.GetBody()) {
+			continue
+		}
+		author := comment.GetUser().GetLogin()
+		ok, err := canApprove(author)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return author, nil
+		}
+		state.Logger().Info("🚫 Ignoring /approve from user without permission", "author", author, "required", required)
+	}
+
+	reactions, err := ia.host(state.Owner, state.Repo).ListIssueCommentReactions(state.Owner, state.Repo, state.IssueNumber, *state.PlanCommentID)
+	if err != nil {
+		return "", err
+	}
+	for _, reaction := range reactions {
+		if reaction.GetContent() != "+1" {
+			continue
+		}
+		ok, err := canApprove(reaction.GetUser().GetLogin())
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return reaction.GetUser().GetLogin(), nil
+		}
+	}
+
+	return "", nil
+}
+
+// CheckApproval starts implementation once a maintainer has approved the plan.
+// It reports whether the plan was approved.
+func (ia *IssueAgent) CheckApproval(owner, repo string, issueNumber int) (bool, error) {
+	state, err := ia.stateManager.GetState(owner, repo, issueNumber)
+	if err != nil {
+		return false, fmt.Errorf("failed to get state: %w", err)
+	}
+	if state == nil || state.Status != "waiting_for_approval" {
+		return false, nil
+	}
+
+	approver, err := ia.findApproval(state)
+	if err != nil {
+		return false, fmt.Errorf("failed to check approval: %w", err)
+	}
+	if approver == "" {
+		return false, nil
+	}
+
+	core.IssueLogger(owner, repo, issueNumber).Info("✅ Plan approved", "approver", approver)
+
+	state.ApprovedBy = approver
+	state.Status = "ready_to_implement"
+	if err := ia.stateManager.SaveState(state); err != nil {
+		return false, fmt.Errorf("failed to save state: %w", err)
+	}
+
+	return true, ia.StartImplementation(owner, repo, issueNumber)
+}
+
+// containsApproval reports whether any of the comments is an /approve command
+func containsApproval(commentBodies []string) bool {
+	for _, body := range commentBodies {
+		if approveCommandRe.MatchString(body) {
+			return true
+		}
+	}
+	return false
+}
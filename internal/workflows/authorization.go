@@ -0,0 +1,47 @@
+package workflows
+
+import "NyteBubo/internal/core"
+
+// permissionLevels ranks repository permission levels for mention triggers, approvals,
+// commands and comments
+var permissionLevels = map[string]int{"read": 1, "triage": 2, "write": 3, "maintain": 4, "admin": 5}
+
+// hasPermission reports whether a user may do something that needs the required permission
+// on a repository. Users in allowed_users always may.
+func (ia *IssueAgent) hasPermission(owner, repo, user, required string) (bool, error) {
+	if containsFold(ia.config.AllowedUsers, user) {
+		return true, nil
+	}
+	permission, err := ia.host(owner, repo).GetPermissionLevel(owner, repo, user)
+	if err != nil {
+		return false, err
+	}
+	return permissionLevels[permission] >= permissionLevels[required], nil
+}
+
+// commentPermission returns the minimum permission for comments to be acted on
+func (ia *IssueAgent) commentPermission() string {
+	if ia.config.CommentPermission == "" {
+		return "write"
+	}
+	return ia.config.CommentPermission
+}
+
+// CanComment reports whether the bot acts on a comment by author on an issue or pull
+// request: replies to its questions and review feedback. An issue's author may always
+// answer on their own issue; anyone else needs comment_permission.
+func (ia *IssueAgent) CanComment(owner, repo string, number int, author string) (bool, error) {
+	issue, err := ia.host(owner, repo).GetIssue(owner, repo, number)
+	if err != nil {
+		return false, err
+	}
+	if author == issue.GetUser().GetLogin() {
+		return true, nil
+	}
+	required := ia.commentPermission()
+	allowed, err := ia.hasPermission(owner, repo, author, required)
+	if err == nil && !allowed {
+		core.IssueLogger(owner, repo, number).Info("🚫 Ignoring comment from user without permission", "author", author, "required", required)
+	}
+	return allowed, err
+}
@@ -0,0 +1,103 @@
+package workflows
+
+import "fmt"
+
+// HandlePullRequest finishes the issue when its bot pull request is merged or closed.
+// While the pull request is open it rebases it when it conflicts with its base branch,
+// pushes fixes when its CI fails, marks a draft ready for review and merges it once it is
+// approved and CI is green, as configured.
+func (ia *IssueAgent) HandlePullRequest(owner, repo string, prNumber int) error {
+	// Events for the same pull request are handled one at a time, and not while review
+	// feedback is being pushed to it
+	lock := ia.busyLock("pr:" + issueKey(owner, repo, prNumber))
+	lock.Lock()
+	defer lock.Unlock()
+
+	state, err := ia.stateManager.GetStateByPR(owner, repo, prNumber)
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+	if state == nil || state.Status == "completed" {
+		return nil
+	}
+
+	pr, err := ia.host(owner, repo).GetPullRequest(owner, repo, prNumber)
+	if err != nil {
+		return fmt.Errorf("failed to get PR: %w", err)
+	}
+	if pr.GetMerged() {
+		return ia.finishMerged(state, pr)
+	}
+	if pr.GetState() != "open" {
+		return ia.finishClosed(state, pr)
+	}
+
+	repoSettings := ia.config.ForRepo(owner, repo)
+	settings := repoSettings.AutoMerge
+	if !settings.Enabled && !repoSettings.ResolveConflicts && ia.config.MaxCIFixAttempts <= 0 && !ia.config.ReadyWhenGreen {
+		return nil
+	}
+	if pr.GetDraft() {
+		return ia.markReadyWhenGreen(state, pr)
+	}
+
+	if repoSettings.ResolveConflicts && hasConflicts(pr) {
+		return ia.resolveConflicts(state, pr)
+	}
+	if ia.config.MaxCIFixAttempts > 0 {
+		if failed, err := ia.fixFailingChecks(state, pr); failed || err != nil {
+			return err
+		}
+	}
+	if !settings.Enabled {
+		return nil
+	}
+
+	approvals, changesRequested, err := ia.host(owner, repo).CountApprovals(owner, repo, prNumber)
+	if err != nil {
+		return err
+	}
+	required := settings.RequiredApprovals
+	if required <= 0 {
+		required = 1
+	}
+	if changesRequested || approvals < required {
+		return nil
+	}
+
+	method := settings.Method
+	if method == "" {
+		method = "merge"
+	}
+
+	// GitHub's native auto-merge waits for required checks itself
+	if settings.Native {
+		if pr.AutoMerge != nil {
+			return nil
+		}
+		state.Logger().Info("🔀 Enabling auto-merge", "pr", prNumber)
+		if err := ia.host(owner, repo).EnableAutoMerge(owner, repo, pr, method); err != nil {
+			return err
+		}
+		comment := fmt.Sprintf("🔀 This pull request is approved, so I've enabled auto-merge (%s). It will merge once all required checks pass.", method)
+		if err := ia.postPRComment(owner, repo, prNumber, comment); err != nil {
+			state.Logger().Warn("⚠️  Failed to comment on PR", "pr", prNumber, "error", err)
+		}
+		return nil
+	}
+
+	passed, _, err := ia.host(owner, repo).ChecksPassed(owner, repo, pr.GetHead().GetSHA())
+	if err != nil {
+		return err
+	}
+	if !passed {
+		return nil
+	}
+
+	state.Logger().Info("🔀 PR is approved and green, merging", "pr", prNumber, "method", method)
+	if err := ia.host(owner, repo).MergePullRequest(owner, repo, prNumber, method, pr.GetHead().GetSHA()); err != nil {
+		return err
+	}
+
+	return ia.finishMerged(state, pr)
+}
@@ -0,0 +1,94 @@
+package workflows
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"NyteBubo/internal/core"
+)
+
+// defaultBranchTemplate names issue branches when branch_template isn't set
+const defaultBranchTemplate = "nytebubo/issue-{number}"
+
+// maxSlugLength caps the part of a branch name taken from the issue title
+const maxSlugLength = 40
+
+var (
+	// slugUnsafeRe matches runs of characters left out of title slugs
+	slugUnsafeRe = regexp.MustCompile(`[^a-z0-9]+`)
+	// refUnsafeRe matches runs of characters not allowed in a branch name component
+	refUnsafeRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// branchName returns the branch for an issue's changes, following branch_template
+func (ia *IssueAgent) branchName(owner, repo string, issueNumber int) string {
+	template := ia.config.BranchTemplate
+	if template == "" {
+		template = defaultBranchTemplate
+	}
+
+	logger := core.IssueLogger(owner, repo, issueNumber)
+	bot := "nytebubo"
+	if strings.Contains(template, "{bot}") {
+		if login, err := ia.botLogin(owner, repo); err != nil {
+			logger.Warn("⚠️  Failed to get the bot's login for the branch name", "error", err)
+		} else {
+			bot = login
+		}
+	}
+	slug := ""
+	if strings.Contains(template, "{slug}") {
+		if issue, err := ia.host(owner, repo).GetIssue(owner, repo, issueNumber); err != nil {
+			logger.Warn("⚠️  Failed to get the issue title for the branch name", "error", err)
+		} else {
+			slug = titleSlug(issue.GetTitle())
+		}
+	}
+
+	name := strings.NewReplacer(
+		"{bot}", bot,
+		"{owner}", owner,
+		"{repo}", repo,
+		"{number}", strconv.Itoa(issueNumber),
+		"{slug}", slug,
+	).Replace(template)
+	if branch := sanitizeBranchName(name); branch != "" {
+		return branch
+	}
+	return sanitizeBranchName(strings.ReplaceAll(defaultBranchTemplate, "{number}", strconv.Itoa(issueNumber)))
+}
+
+// titleSlug turns an issue title into a short lowercase slug, e.g. "Fix login on Safari!"
+// becomes "fix-login-on-safari"
+func titleSlug(title string) string {
+	slug := strings.Trim(slugUnsafeRe.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+		// Cut at a word boundary when there is one
+		if i := strings.LastIndex(slug, "-"); i > 0 {
+			slug = slug[:i]
+		}
+	}
+	return slug
+}
+
+// sanitizeBranchName makes a name valid for git, e.g. for bot logins like
+// "github-actions[bot]" or a slug left empty
+func sanitizeBranchName(name string) string {
+	var parts []string
+	for _, part := range strings.Split(name, "/") {
+		part = refUnsafeRe.ReplaceAllString(part, "-")
+		for strings.Contains(part, "..") {
+			part = strings.ReplaceAll(part, "..", ".")
+		}
+		for strings.Contains(part, "--") {
+			part = strings.ReplaceAll(part, "--", "-")
+		}
+		part = strings.TrimSuffix(strings.Trim(part, ".-"), ".lock")
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "/")
+}
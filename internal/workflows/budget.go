@@ -0,0 +1,98 @@
+package workflows
+
+import (
+	"fmt"
+	"time"
+
+	"NyteBubo/internal/core"
+)
+
+// budgetExceeded explains which spending limit the issue has hit, or returns "" if it may continue
+func (ia *IssueAgent) budgetExceeded(state *core.State) (string, error) {
+	if limit := ia.config.MaxCostPerIssue; limit > 0 {
+		// Costs a maintainer accepted when resuming don't count again
+		if spent := state.TotalCost - state.BudgetBaseline; spent >= limit {
+			return fmt.Sprintf("This issue has used $%.2f of its $%.2f budget.", spent, limit), nil
+		}
+	}
+
+	if limit := ia.config.MonthlyBudget; limit > 0 {
+		// A resume covers the rest of the month for this issue
+		now := time.Now().UTC()
+		if resumed := state.BudgetResumedAt; resumed != nil && resumed.UTC().Format("2006-01") == now.Format("2006-01") {
+			return "", nil
+		}
+		spent, err := ia.stateManager.MonthlySpend(now)
+		if err != nil {
+			return "", err
+		}
+		if spent >= limit {
+			return fmt.Sprintf("NyteBubo has spent $%.2f of its $%.2f budget for %s.", spent, limit, now.Format("January 2006")), nil
+		}
+	}
+
+	return "", nil
+}
+
+// pauseForBudget stops work on an issue that hit a spending limit until a maintainer
+// resumes it. It reports whether the issue was paused.
+func (ia *IssueAgent) pauseForBudget(state *core.State) (bool, error) {
+	reason, err := ia.budgetExceeded(state)
+	if err != nil {
+		return false, fmt.Errorf("failed to check budget: %w", err)
+	}
+	if reason == "" {
+		return false, nil
+	}
+
+	state.Logger().Warn("💸 Budget reached", "reason", reason)
+
+	state.ResumeStatus = state.Status
+	state.Status = "budget_exceeded"
+	if err := ia.stateManager.SaveState(state); err != nil {
+		return false, fmt.Errorf("failed to save state: %w", err)
+	}
+
+	comment := botComment{
+		Heading: "💸 Budget reached",
+		Summary: reason + " I've paused work on this issue so costs don't keep growing.\n\nA maintainer can run `" + core.CommandPrefix + " resume` to let me continue.",
+	}.String()
+	if err := ia.postComment(state.Owner, state.Repo, state.IssueNumber, comment); err != nil {
+		return true, fmt.Errorf("failed to create comment: %w", err)
+	}
+	return true, nil
+}
+
+// resumeFromBudget accepts the spending so far and returns the issue to where it was paused
+func (ia *IssueAgent) resumeFromBudget(state *core.State, number int) error {
+	if state.Status != "budget_exceeded" {
+		return ia.commandReply(state, number, "This issue isn't paused for budget.")
+	}
+
+	state.Logger().Info("▶️  Resuming past the budget")
+
+	now := time.Now()
+	state.BudgetBaseline = state.TotalCost
+	state.BudgetResumedAt = &now
+	state.Status = state.ResumeStatus
+	if state.Status == "" {
+		state.Status = "waiting_for_clarification"
+	}
+	state.ResumeStatus = ""
+
+	switch state.Status {
+	case "analyzing":
+		if err := ia.stateManager.SaveState(state); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+		return ia.HandleIssueAssignment(state.Owner, state.Repo, state.IssueNumber)
+	case "ready_to_implement", "implementing":
+		state.Status = "ready_to_implement"
+		if err := ia.stateManager.SaveState(state); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+		return ia.StartImplementation(state.Owner, state.Repo, state.IssueNumber)
+	}
+
+	return ia.commandReply(state, number, "▶️ Resumed. Comment again with anything I should pick up.")
+}
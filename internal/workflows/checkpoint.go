@@ -0,0 +1,165 @@
+package workflows
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"NyteBubo/internal/core"
+)
+
+// Implementation steps, in order. State.Checkpoint records the last one completed.
+const (
+	stepBranched  = "branched"  // The sandbox is cloned onto the issue branch
+	stepGenerated = "generated" // The AI response is saved
+	stepApplied   = "applied"   // The changes are written to the sandbox
+	stepVerified  = "verified"  // The build and tests ran, with fixes where needed
+	stepPushed    = "pushed"    // The changes are committed and the branch pushed
+	stepPROpened  = "pr_opened" // The pull request is open
+	stepCommented = "commented" // The issue links to the pull request
+)
+
+// implementationProgress is what completed steps hand on to later ones. It's saved as
+// State.CheckpointData so a restart can continue from the last completed step.
+type implementationProgress struct {
+	Response         string `json:"response,omitempty"`
+	Summary          string `json:"summary,omitempty"`
+	VerificationNote string `json:"verification_note,omitempty"`
+	Verified         bool   `json:"verified,omitempty"`
+	ForcedBy         string `json:"forced_by,omitempty"`  // Maintainer who let changes held by a guardrail through
+	AppliedBy        string `json:"applied_by,omitempty"` // Maintainer who approved the previewed diff
+	// Follow-up issues opened for the pull request, kept so a retried comment lists them
+	// instead of opening them again
+	FollowUps       []followUpIssue `json:"follow_ups,omitempty"`
+	FollowUpsOpened bool            `json:"follow_ups_opened,omitempty"`
+}
+
+// released reports whether a maintainer let held changes through. They're pushed as they
+// were held, without verifying and fixing them again.
+func (p implementationProgress) released() bool {
+	return p.ForcedBy != "" || p.AppliedBy != ""
+}
+
+// loadProgress reads the progress saved with the state's checkpoint. Checkpoints saved
+// after generation by older versions hold the raw AI response instead of JSON.
+func loadProgress(state *core.State) (implementationProgress, error) {
+	var progress implementationProgress
+	if state.CheckpointData == "" {
+		return progress, nil
+	}
+	if err := json.Unmarshal([]byte(state.CheckpointData), &progress); err != nil {
+		if state.Checkpoint == stepGenerated {
+			return implementationProgress{Response: state.CheckpointData}, nil
+		}
+		return progress, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	return progress, nil
+}
+
+// saveProgress saves progress made partway through a step with the last completed
+// checkpoint, so a retry of the step doesn't redo it
+func (ia *IssueAgent) saveProgress(impl *implementation) error {
+	data, err := json.Marshal(impl.progress)
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	impl.state.CheckpointData = string(data)
+	if err := ia.stateManager.SaveState(impl.state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return nil
+}
+
+// resumeIndex returns the first step to run after checkpoint. Steps that only changed the
+// sandbox are lost with it, so they run again; a saved AI response is reused rather than
+// generated again.
+func resumeIndex(checkpoint string) int {
+	switch checkpoint {
+	case stepPushed, stepPROpened:
+		for i, step := range implementationSteps {
+			if step.name == checkpoint {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
+
+// markInterrupted leaves an implementation stopped by shutdown ready to resume from its
+// last checkpoint. Conversation turns added since the checkpoint are dropped so the
+// resumed run doesn't see half-finished fix attempts, but their cost is kept.
+func (ia *IssueAgent) markInterrupted(state *core.State) {
+	saved, err := ia.stateManager.GetState(state.Owner, state.Repo, state.IssueNumber)
+	if err != nil || saved == nil {
+		state.Logger().Warn("⚠️  Failed to record interrupted work", "error", err)
+		return
+	}
+
+	saved.Status = "ready_to_implement"
+	saved.TotalInputTokens = state.TotalInputTokens
+	saved.TotalOutputTokens = state.TotalOutputTokens
+	saved.TotalReasoningTokens = state.TotalReasoningTokens
+	saved.TotalCost = state.TotalCost
+	if err := ia.stateManager.SaveState(saved); err != nil {
+		state.Logger().Warn("⚠️  Failed to record interrupted work", "error", err)
+		return
+	}
+
+	state.Logger().Info("⏸️  Interrupted work will resume on the next start")
+}
+
+// InterruptedImplementations returns the issues whose implementation should be resumed at
+// startup: those left ready by a shutdown and those still marked as implementing after a crash
+func (ia *IssueAgent) InterruptedImplementations() ([]core.State, error) {
+	states, err := ia.stateManager.ListStatesByStatus("ready_to_implement", "implementing")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interrupted work: %w", err)
+	}
+	return states, nil
+}
+
+// holdImplementation stops an implementation before its changes are pushed, with a comment
+// on what a maintainer has to do. The changes as they are in the sandbox, fixes included,
+// are saved with the verified checkpoint, so resumeHeld pushes exactly what was held.
+func (ia *IssueAgent) holdImplementation(impl *implementation, status, comment string) error {
+	state := impl.state
+	response, err := sandboxChangesResponse(impl.sandbox, impl.progress.Summary)
+	if err != nil {
+		return err
+	}
+	impl.progress.Response = response
+	data, err := json.Marshal(impl.progress)
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	state.Checkpoint, state.CheckpointData = stepVerified, string(data)
+
+	if err := ia.postComment(state.Owner, state.Repo, state.IssueNumber, comment); err != nil {
+		return fmt.Errorf("failed to create comment: %w", err)
+	}
+	state.Status = status
+	if err := ia.stateManager.SaveState(state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return nil
+}
+
+// resumeHeld continues a held implementation from its checkpoint, recording the
+// maintainer's decision in the saved progress
+func (ia *IssueAgent) resumeHeld(state *core.State, decide func(progress *implementationProgress)) error {
+	progress, err := loadProgress(state)
+	if err != nil {
+		return err
+	}
+	decide(&progress)
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+
+	state.CheckpointData = string(data)
+	state.Status = "ready_to_implement"
+	if err := ia.stateManager.SaveState(state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return ia.StartImplementation(state.Owner, state.Repo, state.IssueNumber)
+}
@@ -0,0 +1,100 @@
+package workflows
+
+import (
+	"fmt"
+	"strings"
+
+	"NyteBubo/internal/core"
+	"github.com/google/go-github/v63/github"
+)
+
+// fixFailingChecks asks the AI to fix the code when CI fails on a bot pull request and
+// pushes the fix, up to max_ci_fix_attempts times per pull request. It reports whether CI
+// failed on the pull request's head commit.
+func (ia *IssueAgent) fixFailingChecks(state *core.State, pr *github.PullRequest) (bool, error) {
+	owner, repo, prNumber, sha := state.Owner, state.Repo, pr.GetNumber(), pr.GetHead().GetSHA()
+	if state.CIFailureSHA == sha {
+		return true, nil
+	}
+
+	passed, pending, err := ia.host(owner, repo).ChecksPassed(owner, repo, sha)
+	if err != nil || passed || pending {
+		return false, err
+	}
+	if state.Status == "aborted" || state.Status == "budget_exceeded" {
+		return true, nil
+	}
+
+	logger := state.Logger()
+	limit := ia.config.MaxCIFixAttempts
+	if state.CIFixAttempts >= limit {
+		logger.Warn("❌ CI still failing, out of fix attempts", "pr", prNumber, "attempts", state.CIFixAttempts)
+		state.CIFailureSHA = sha
+		if err := ia.stateManager.SaveState(state); err != nil {
+			return true, fmt.Errorf("failed to save state: %w", err)
+		}
+		comment := botComment{
+			Heading: "❌ CI is still failing",
+			Summary: fmt.Sprintf("CI is still failing after %d fix attempt(s), so I've stopped trying. Comment on this pull request with guidance and I'll pick it up from there.", state.CIFixAttempts),
+		}.String()
+		return true, ia.postPRComment(owner, repo, prNumber, comment)
+	}
+	if paused, err := ia.pauseForBudget(state); paused || err != nil {
+		return true, err
+	}
+
+	failures, err := ia.host(owner, repo).ListFailedChecks(owner, repo, sha)
+	if err != nil {
+		return true, err
+	}
+	state.CIFailureSHA = sha
+	if len(failures) == 0 {
+		// e.g. cancelled runs, which leave nothing to fix
+		if err := ia.stateManager.SaveState(state); err != nil {
+			return true, fmt.Errorf("failed to save state: %w", err)
+		}
+		return true, nil
+	}
+
+	names := make([]string, len(failures))
+	for i, failure := range failures {
+		names[i] = "`" + failure.Name + "`"
+	}
+	state.CIFixAttempts++
+	logger.Info("🚨 CI failed, asking AI for a fix", "pr", prNumber, "checks", len(failures), "attempt", state.CIFixAttempts, "max_attempts", limit)
+
+	commitMessage := fmt.Sprintf("Fix CI failures for issue #%d", state.IssueNumber)
+	response, pushed, fixErr := ia.addressFeedback(state, "CI failure", ciFailurePrompt(failures), commitMessage)
+	if err := ia.stateManager.SaveState(state); err != nil {
+		return true, fmt.Errorf("failed to save state: %w", err)
+	}
+	if fixErr != nil {
+		return true, fixErr
+	}
+
+	summary := fmt.Sprintf("CI failed (%s), so I pushed a fix. This was attempt %d of %d.", strings.Join(names, ", "), state.CIFixAttempts, limit)
+	if !pushed {
+		summary = fmt.Sprintf("CI failed (%s), but I couldn't work out a fix from the logs. Comment on this pull request with guidance and I'll pick it up from there.", strings.Join(names, ", "))
+	}
+	comment := botComment{
+		Heading:  "🔧 CI failed",
+		Summary:  summary,
+		Sections: []commentSection{{Title: "Details", Body: strings.TrimSpace(stripCodeBlocks(response))}},
+	}.String()
+	return true, ia.postPRComment(owner, repo, prNumber, comment)
+}
+
+// ciFailurePrompt describes failed CI checks and their logs for the AI
+func ciFailurePrompt(failures []core.FailedCheck) string {
+	var b strings.Builder
+	b.WriteString("CI failed on the pull request. Fix the code so these checks pass:\n")
+	for _, failure := range failures {
+		b.WriteString(fmt.Sprintf("\n### %s\n", failure.Name))
+		if log := logBlock(failure.Log); log != "" {
+			b.WriteString(log + "\n")
+		} else {
+			b.WriteString("(no log available)\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package workflows
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+
+	"NyteBubo/internal/core"
+)
+
+const (
+	defaultMaxClarificationRounds = 5
+	defaultNeedsHumanLabel        = "needs-human"
+)
+
+// clarificationExhausted counts another round of questions on an issue and reports whether
+// it was the last one allowed
+func (ia *IssueAgent) clarificationExhausted(state *core.State) bool {
+	state.ClarificationRounds++
+	maxRounds := ia.config.Clarification.MaxRounds
+	if maxRounds == 0 {
+		maxRounds = defaultMaxClarificationRounds
+	}
+	return maxRounds > 0 && state.ClarificationRounds >= maxRounds
+}
+
+// escalateClarification hands an issue that keeps raising questions to a human: it posts the
+// questions still open, labels the issue and pauses it until a maintainer runs /nytebubo
+// resume or /nytebubo implement
+func (ia *IssueAgent) escalateClarification(state *core.State, questions []string) error {
+	owner, repo, issueNumber := state.Owner, state.Repo, state.IssueNumber
+	state.Logger().Warn("🙋 Clarification limit reached, handing the issue to a human", "rounds", state.ClarificationRounds, "questions", len(questions))
+
+	comment := botComment{
+		Heading: "🙋 Needs a human",
+		Summary: fmt.Sprintf("After %d rounds of questions I still don't have what I need to implement this issue, so I'm pausing it. Answers posted here are kept: once the questions are settled, run `%s resume` to continue the conversation or `%s implement` to start implementing.", state.ClarificationRounds, core.CommandPrefix, core.CommandPrefix),
+	}
+	if len(questions) > 0 {
+		comment.Sections = []commentSection{{Title: "Open questions", Body: "- " + strings.Join(questions, "\n- ")}}
+	}
+	if err := ia.postComment(owner, repo, issueNumber, comment.String()); err != nil {
+		return fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	label := cmp.Or(ia.config.Clarification.Label, defaultNeedsHumanLabel)
+	if err := ia.host(owner, repo).AddLabels(owner, repo, issueNumber, []string{label}); err != nil {
+		state.Logger().Warn("⚠️  Failed to label issue", "label", label, "error", err)
+	}
+
+	state.Status = "needs_human"
+	if err := ia.stateManager.SaveState(state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return nil
+}
+
+// resumeClarification picks an escalated issue's conversation back up with a fresh allowance
+// of questions, replying at once to answers posted while it was paused
+func (ia *IssueAgent) resumeClarification(state *core.State, number int) error {
+	state.Logger().Info("▶️  Resuming clarification")
+	state.Status = "waiting_for_clarification"
+	state.ClarificationRounds = 0
+	if err := ia.commandReply(state, number, "▶️ Resumed with a fresh set of questions. I'll reply to the answers posted here, or run `"+core.CommandPrefix+" implement` to start implementing."); err != nil {
+		return err
+	}
+
+	if last := len(state.Conversation) - 1; last >= 0 && state.Conversation[last].Role == "user" {
+		return ia.HandleIssueComments(state.Owner, state.Repo, state.IssueNumber, nil)
+	}
+	return nil
+}
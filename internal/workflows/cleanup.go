@@ -0,0 +1,134 @@
+package workflows
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"NyteBubo/internal/core"
+)
+
+// defaultCleanupAge is how long a finished issue keeps its branch and workspace when
+// cleanup.age isn't set
+const defaultCleanupAge = 7 * 24 * time.Hour
+
+// cleanupInterval is how often the janitor looks for finished issues to clean up
+const cleanupInterval = time.Hour
+
+// CleanupResult counts what a cleanup run removed
+type CleanupResult struct {
+	Issues     int // Finished issues cleaned up
+	Branches   int // Remote branches deleted
+	Workspaces int // Sandbox workspaces removed
+}
+
+// CleanupAge returns how long after an issue is completed or abandoned its branch and
+// workspace are deleted
+func (ia *IssueAgent) CleanupAge() time.Duration {
+	if ia.config.Cleanup.Age > 0 {
+		return time.Duration(ia.config.Cleanup.Age) * 24 * time.Hour
+	}
+	return defaultCleanupAge
+}
+
+// RunJanitor cleans up issues finished longer than CleanupAge ago, now and then every
+// cleanupInterval, until ctx is cancelled
+func (ia *IssueAgent) RunJanitor(ctx context.Context) {
+	slog.Info("🧹 Starting janitor", "age", ia.CleanupAge(), "interval", cleanupInterval)
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		if result, err := ia.CleanUp(ctx, ia.CleanupAge()); err != nil {
+			slog.Error("Error cleaning up finished issues", "error", err)
+		} else if result.Issues > 0 {
+			slog.Info("🧹 Cleaned up finished issues", "issues", result.Issues, "branches", result.Branches, "workspaces", result.Workspaces)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CleanUp deletes the remote branches and sandbox workspaces of issues that were completed
+// or abandoned more than age ago. Each issue is cleaned up once after it finishes.
+func (ia *IssueAgent) CleanUp(ctx context.Context, age time.Duration) (CleanupResult, error) {
+	var result CleanupResult
+	states, err := ia.stateManager.ListStatesByStatus("completed", "abandoned")
+	if err != nil {
+		return result, err
+	}
+
+	cutoff := time.Now().Add(-age)
+	for i := range states {
+		if ctx.Err() != nil {
+			break
+		}
+		state := &states[i]
+		finishedAt := state.UpdatedAt
+		if state.CompletedAt != nil {
+			finishedAt = *state.CompletedAt
+		}
+		if finishedAt.After(cutoff) {
+			continue
+		}
+		cleanedAt, err := ia.stateManager.LastCleanup(state.Owner, state.Repo, state.IssueNumber)
+		if err != nil {
+			return result, err
+		}
+		if cleanedAt.After(state.UpdatedAt) {
+			continue
+		}
+
+		branch, workspace := ia.cleanUpIssue(state)
+		if branch {
+			result.Branches++
+		}
+		if workspace {
+			result.Workspaces++
+		}
+		result.Issues++
+		if ia.dryRun != nil {
+			continue
+		}
+		if err := ia.stateManager.RecordCleanup(state.Owner, state.Repo, state.IssueNumber); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// cleanUpIssue deletes a finished issue's branch and workspace and reports which existed
+func (ia *IssueAgent) cleanUpIssue(state *core.State) (branch, workspace bool) {
+	owner, repo := state.Owner, state.Repo
+	logger := state.Logger()
+
+	if state.BranchName != "" {
+		// Merged branches are usually deleted already
+		if err := ia.host(owner, repo).DeleteBranch(owner, repo, state.BranchName); err != nil {
+			logger.Debug("Branch not deleted", "branch", state.BranchName, "error", err)
+		} else {
+			logger.Info("🗑️  Deleted branch", "branch", state.BranchName)
+			branch = true
+		}
+	}
+
+	path := core.WorkspacePath(ia.workingDir, owner, repo, state.IssueNumber)
+	if _, err := os.Stat(path); err != nil {
+		return branch, false
+	}
+	if ia.dryRun != nil {
+		ia.dryRun.Report(owner, repo, state.IssueNumber, "remove workspace", path)
+		return branch, true
+	}
+	if err := os.RemoveAll(path); err != nil {
+		logger.Warn("⚠️  Failed to remove workspace", "path", path, "error", err)
+		return branch, false
+	}
+	logger.Info("🧹 Removed workspace", "path", path)
+	return branch, true
+}
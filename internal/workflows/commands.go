@@ -0,0 +1,341 @@
+package workflows
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"NyteBubo/internal/core"
+)
+
+// commandHelp lists the slash commands; the prefix is kept off the start of lines so
+// the help text itself is never parsed as a command
+const commandHelp = "Available commands:\n\n" +
+	"- `" + core.CommandPrefix + " implement` - start implementing now, skipping clarification and approval\n" +
+	"- `" + core.CommandPrefix + " retry` - start the implementation again after a failure\n" +
+	"- `" + core.CommandPrefix + " abort` - stop working on this issue\n" +
+	"- `" + core.CommandPrefix + " resume` - continue after a budget limit or the clarification limit paused the issue\n" +
+	"- `" + core.CommandPrefix + " force` - push changes held back by a guardrail\n" +
+	"- `" + core.CommandPrefix + " apply` - push the previewed changes and open the pull request\n" +
+	"- `" + core.CommandPrefix + " status` - show what I'm doing and what it has cost so far\n" +
+	"- `" + core.CommandPrefix + " set-model <model>` - use a different model for this issue (`default` to reset)\n" +
+	"- `" + core.CommandPrefix + " priority <n>` - handle this issue before others waiting, 1 first (`default` to reset)"
+
+// HandleCommand runs a slash command from an issue or pull request comment. It reports
+// whether the comment was a command, so callers can skip normal comment handling.
+func (ia *IssueAgent) HandleCommand(owner, repo string, number int, author, body string) (bool, error) {
+	command, ok := core.ParseCommand(body)
+	if !ok {
+		return false, nil
+	}
+
+	login, err := ia.botLogin(owner, repo)
+	if err != nil {
+		return false, err
+	}
+	if author == login {
+		return false, nil
+	}
+
+	// Commands on a bot pull request apply to the issue it was opened for
+	state, err := ia.stateManager.GetState(owner, repo, number)
+	if err != nil {
+		return true, fmt.Errorf("failed to get state: %w", err)
+	}
+	if state == nil {
+		state, err = ia.stateManager.GetStateByPR(owner, repo, number)
+		if err != nil {
+			return true, fmt.Errorf("failed to get state: %w", err)
+		}
+	}
+	if state == nil {
+		return true, ia.postComment(owner, repo, number, "I'm not working on this issue yet. Assign me to it first.")
+	}
+
+	required := ia.config.CommandPermission
+	if required == "" {
+		required = "write"
+	}
+	allowed, err := ia.hasPermission(owner, repo, author, required)
+	if err != nil {
+		return true, err
+	}
+	if !allowed {
+		core.IssueLogger(owner, repo, number).Info("🚫 Ignoring command from user without permission", "author", author, "required", required)
+		return true, ia.postComment(owner, repo, number, fmt.Sprintf("Only collaborators with %s access can run commands.", required))
+	}
+
+	core.IssueLogger(owner, repo, number).Info("⌨️  Running command", "author", author, "command", command.Name)
+
+	switch command.Name {
+	case "implement":
+		return true, ia.commandImplement(state, number, author)
+	case "retry":
+		return true, ia.commandRetry(state, number, author)
+	case "abort":
+		return true, ia.commandAbort(state, number)
+	case "resume":
+		if state.Status == "needs_human" {
+			return true, ia.resumeClarification(state, number)
+		}
+		return true, ia.resumeFromBudget(state, number)
+	case "force":
+		return true, ia.commandForce(state, number, author)
+	case "apply":
+		return true, ia.commandApply(state, number, author)
+	case "status":
+		return true, ia.commandStatus(state, number)
+	case "set-model":
+		return true, ia.commandSetModel(state, number, command.Args)
+	case "priority":
+		return true, ia.commandPriority(state, number, author, command.Args)
+	}
+
+	summary := commandHelp
+	if command.Name != "help" {
+		summary = fmt.Sprintf("I don't know the command `%s`.\n\n%s", command.Name, commandHelp)
+	}
+	if err := ia.postComment(owner, repo, number, summary); err != nil {
+		return true, fmt.Errorf("failed to create comment: %w", err)
+	}
+	// Saving marks the command as handled for the poller
+	return true, ia.stateManager.SaveState(state)
+}
+
+// commandImplement starts the implementation regardless of open questions or approval
+func (ia *IssueAgent) commandImplement(state *core.State, number int, author string) error {
+	switch {
+	case state.Status == "implementing":
+		return ia.commandReply(state, number, "I'm already implementing this issue.")
+	case state.PRNumber != nil:
+		return ia.commandReply(state, number, fmt.Sprintf("I've already opened #%d for this issue. Leave review comments there for further changes.", *state.PRNumber))
+	}
+
+	// Running the command counts as approving the plan
+	state.ApprovedBy = author
+	state.Status = "ready_to_implement"
+	state.BlockedByPR = nil
+	state.Checkpoint, state.CheckpointData = "", ""
+	if err := ia.stateManager.SaveState(state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return ia.StartImplementation(state.Owner, state.Repo, state.IssueNumber)
+}
+
+// commandRetry restarts an implementation that failed, got stuck or was aborted
+func (ia *IssueAgent) commandRetry(state *core.State, number int, author string) error {
+	switch {
+	case state.Status == "implementing" && time.Since(state.UpdatedAt) < 10*time.Minute:
+		return ia.commandReply(state, number, "I'm still working on the implementation. Try again if nothing happens in the next few minutes.")
+	case state.PRNumber != nil:
+		return ia.commandReply(state, number, fmt.Sprintf("I've already opened #%d for this issue. Leave review comments there for further changes.", *state.PRNumber))
+	}
+
+	state.Logger().Info("🔁 Retrying implementation")
+	if state.ApprovedBy == "" {
+		state.ApprovedBy = author
+	}
+	state.Status = "ready_to_implement"
+	state.BlockedByPR = nil
+	state.Checkpoint, state.CheckpointData = "", ""
+	if err := ia.stateManager.SaveState(state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return ia.StartImplementation(state.Owner, state.Repo, state.IssueNumber)
+}
+
+// commandAbort stops all work on the issue until it is retried
+func (ia *IssueAgent) commandAbort(state *core.State, number int) error {
+	state.Logger().Info("🛑 Aborting work")
+	heading, summary := "🛑 Stopped", "I've stopped working on this issue. Run `"+core.CommandPrefix+" retry` to start the implementation again."
+	if state.Status == "implementing" {
+		// The running implementation notices between steps
+		heading = "🛑 Stopping"
+		summary = "I'll stop working on this issue after the step of the implementation I'm on. Run `" + core.CommandPrefix + " retry` to start the implementation again."
+	}
+	state.Status = "aborted"
+	comment := botComment{
+		Heading: heading,
+		Summary: summary,
+	}.String()
+	return ia.commandReply(state, number, comment)
+}
+
+// commandStatus reports the issue's state and usage so far
+func (ia *IssueAgent) commandStatus(state *core.State, number int) error {
+	model := state.Model
+	if model == "" {
+		model = ia.config.Model()
+	}
+	if model == "" {
+		model = "provider default"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("- **Status:** `%s` (since %s)\n", state.Status, state.UpdatedAt.Format(time.RFC1123)))
+	if state.PRNumber != nil {
+		sb.WriteString(fmt.Sprintf("- **Pull request:** #%d\n", *state.PRNumber))
+	}
+	if parts := ia.issueParts(state); parts != nil {
+		if part := core.CurrentPart(parts); part != nil {
+			sb.WriteString(fmt.Sprintf("- **Part:** %d of %d, %s\n", part.Number, len(parts), part.Title))
+		}
+	}
+	if state.BranchName != "" {
+		sb.WriteString(fmt.Sprintf("- **Branch:** `%s`\n", state.BranchName))
+	}
+	if state.Checkpoint != "" {
+		sb.WriteString(fmt.Sprintf("- **Last step completed:** `%s`\n", state.Checkpoint))
+	}
+	if state.BlockedByPR != nil {
+		sb.WriteString(fmt.Sprintf("- **Waiting on:** #%d\n", *state.BlockedByPR))
+	}
+	if state.ApprovedBy != "" {
+		sb.WriteString(fmt.Sprintf("- **Approved by:** @%s\n", state.ApprovedBy))
+	}
+	if priorities, err := ia.stateManager.IssuePriorities(state.Owner, state.Repo); err == nil && priorities[state.IssueNumber] > 0 {
+		sb.WriteString(fmt.Sprintf("- **Priority:** %d\n", priorities[state.IssueNumber]))
+	}
+	sb.WriteString(fmt.Sprintf("- **Model:** `%s`\n", model))
+	sb.WriteString(fmt.Sprintf("- **Tokens:** %d in / %d out\n", state.TotalInputTokens, state.TotalOutputTokens))
+	sb.WriteString(fmt.Sprintf("- **Cost:** $%.4f", state.TotalCost))
+
+	comment := botComment{
+		Heading: "📊 Status",
+		Summary: sb.String(),
+	}.String()
+	return ia.commandReply(state, number, comment)
+}
+
+// commandSetModel overrides the model used for the rest of the issue
+func (ia *IssueAgent) commandSetModel(state *core.State, number int, args []string) error {
+	if len(args) != 1 {
+		return ia.commandReply(state, number, "Usage: `"+core.CommandPrefix+" set-model <model>` (or `default` to go back to the configured model)")
+	}
+
+	model := args[0]
+	if strings.EqualFold(model, "default") {
+		state.Model = ""
+		return ia.commandReply(state, number, "I'll use the configured model for this issue again.")
+	}
+
+	state.Model = model
+	return ia.commandReply(state, number, fmt.Sprintf("I'll use `%s` for the rest of this issue.", model))
+}
+
+// commandPriority sets the issue's place among the issues waiting to be handled
+func (ia *IssueAgent) commandPriority(state *core.State, number int, author string, args []string) error {
+	usage := "Usage: `" + core.CommandPrefix + " priority <n>`, where 1 is handled first (or `default` to go back to label and age order)"
+	if len(args) != 1 {
+		return ia.commandReply(state, number, usage)
+	}
+	priority := 0
+	if !strings.EqualFold(args[0], "default") {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return ia.commandReply(state, number, usage)
+		}
+		priority = n
+	}
+
+	if err := ia.stateManager.SetIssuePriority(state.Owner, state.Repo, state.IssueNumber, priority, author); err != nil {
+		return err
+	}
+	if priority == 0 {
+		return ia.commandReply(state, number, "I'll schedule this issue by its labels and age again.")
+	}
+	return ia.commandReply(state, number, fmt.Sprintf("I'll handle this issue with priority %d, ahead of issues without one.", priority))
+}
+
+// RetryIssue runs the retry command on behalf of the operator, e.g. from the dashboard.
+// The bot itself is recorded as the approver. With force, an implementation that looks
+// like it's still running is retried anyway, e.g. after the agent crashed.
+func (ia *IssueAgent) RetryIssue(owner, repo string, issueNumber int, force bool) error {
+	state, err := ia.stateManager.GetState(owner, repo, issueNumber)
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+	if state == nil {
+		return fmt.Errorf("no state for %s/%s #%d", owner, repo, issueNumber)
+	}
+	if force && state.Status == "implementing" {
+		state.Status = "ready_to_implement"
+	}
+	login, err := ia.botLogin(owner, repo)
+	if err != nil {
+		return err
+	}
+	return ia.commandRetry(state, issueNumber, login)
+}
+
+// AbortIssue runs the abort command on behalf of the operator, e.g. from the dashboard.
+// With deleteBranch, the bot's open pull request is closed and its branch deleted, so a
+// retry starts over from scratch.
+func (ia *IssueAgent) AbortIssue(owner, repo string, issueNumber int, deleteBranch bool) error {
+	state, err := ia.stateManager.GetState(owner, repo, issueNumber)
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+	if state == nil {
+		return fmt.Errorf("no state for %s/%s #%d", owner, repo, issueNumber)
+	}
+	if deleteBranch {
+		if err := ia.discardBranch(state); err != nil {
+			return err
+		}
+	}
+	return ia.commandAbort(state, issueNumber)
+}
+
+// discardBranch closes the bot's open pull request and deletes the issue branch. A merged
+// pull request is left alone.
+func (ia *IssueAgent) discardBranch(state *core.State) error {
+	owner, repo := state.Owner, state.Repo
+	logger := state.Logger()
+
+	if state.PRNumber != nil {
+		prNumber := *state.PRNumber
+		lock := ia.busyLock("pr:" + issueKey(owner, repo, prNumber))
+		lock.Lock()
+		defer lock.Unlock()
+
+		pr, err := ia.host(owner, repo).GetPullRequest(owner, repo, prNumber)
+		if err != nil {
+			return fmt.Errorf("failed to get PR: %w", err)
+		}
+		if pr.GetMerged() {
+			return fmt.Errorf("#%d is already merged", prNumber)
+		}
+		if pr.GetState() == "open" {
+			logger.Info("🚪 Closing pull request", "pr", prNumber)
+			if err := ia.host(owner, repo).ClosePullRequest(owner, repo, prNumber); err != nil {
+				return err
+			}
+		}
+		state.PRNumber = nil
+	}
+
+	if state.BranchName != "" {
+		// The branch is only pushed once changes are generated, so it may not exist
+		if err := ia.host(owner, repo).DeleteBranch(owner, repo, state.BranchName); err != nil {
+			logger.Debug("Branch not deleted", "branch", state.BranchName, "error", err)
+		} else {
+			logger.Info("🗑️  Deleted branch", "branch", state.BranchName)
+		}
+	}
+	state.BlockedByPR = nil
+	state.Checkpoint, state.CheckpointData = "", ""
+	return nil
+}
+
+// commandReply saves the state, marking the command as handled, and posts a reply
+func (ia *IssueAgent) commandReply(state *core.State, number int, body string) error {
+	if err := ia.stateManager.SaveState(state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	if err := ia.postComment(state.Owner, state.Repo, number, body); err != nil {
+		return fmt.Errorf("failed to create comment: %w", err)
+	}
+	return nil
+}
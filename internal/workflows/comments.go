@@ -0,0 +1,194 @@
+package workflows
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// collapseThreshold is the length above which AI responses are folded into a <details> section
+	collapseThreshold = 1200
+	// maxLogLines caps how many trailing lines of build/test output are included in comments
+	maxLogLines = 80
+	// commentFooter signs every comment posted by the agent
+	commentFooter = "🤖 NyteBubo"
+)
+
+// commentSection is a collapsible block within a bot comment
+type commentSection struct {
+	Title string
+	Body  string
+}
+
+// botComment is a consistently structured comment: heading, short summary, collapsible details
+type botComment struct {
+	Heading  string
+	Summary  string
+	Sections []commentSection
+	Footer   string
+}
+
+// String renders the comment as GitHub-flavored markdown
+func (c botComment) String() string {
+	var sb strings.Builder
+
+	if c.Heading != "" {
+		sb.WriteString("### " + c.Heading + "\n\n")
+	}
+	if summary := strings.TrimSpace(c.Summary); summary != "" {
+		sb.WriteString(summary + "\n\n")
+	}
+	for _, section := range c.Sections {
+		if strings.TrimSpace(section.Body) == "" {
+			continue
+		}
+		sb.WriteString(details(section.Title, section.Body) + "\n\n")
+	}
+
+	footer := c.Footer
+	if footer == "" {
+		footer = commentFooter
+	}
+	sb.WriteString("---\n\n" + footer)
+
+	return sb.String()
+}
+
+// details wraps body in a collapsed <details> block
+func details(title, body string) string {
+	return fmt.Sprintf("<details>\n<summary>%s</summary>\n\n%s\n\n</details>", title, strings.TrimSpace(body))
+}
+
+// logBlock formats command output as a fenced block, keeping only the last maxLogLines lines
+func logBlock(output string) string {
+	output = strings.TrimRight(output, "\n\r \t")
+	if output == "" {
+		return ""
+	}
+
+	lines := strings.Split(output, "\n")
+	if len(lines) > maxLogLines {
+		omitted := len(lines) - maxLogLines
+		lines = append([]string{fmt.Sprintf("... (%d earlier line(s) omitted)", omitted)}, lines[omitted:]...)
+	}
+
+	// Avoid closing the fence early if the output itself contains backticks
+	return "````\n" + strings.Join(lines, "\n") + "\n````"
+}
+
+// collapseLong splits a long AI response into a short summary and the full text.
+// Short responses are returned as the summary with no details.
+func collapseLong(response string) (summary, full string) {
+	response = strings.TrimSpace(response)
+	if len(response) <= collapseThreshold {
+		return response, ""
+	}
+	return firstParagraph(response), response
+}
+
+// firstParagraph returns the first non-empty paragraph of text, stopping at code blocks
+func firstParagraph(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			break
+		}
+		if trimmed == "" {
+			if len(lines) > 0 {
+				break
+			}
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	paragraph := strings.Join(lines, "\n")
+	if len(paragraph) > collapseThreshold {
+		paragraph = truncateText(paragraph, collapseThreshold)
+	}
+	return paragraph
+}
+
+// analysisComment builds the comment posted after the initial issue analysis
+func analysisComment(response string) string {
+	summary, full := collapseLong(response)
+	comment := botComment{
+		Heading: "👋 Issue analysis",
+		Summary: "I've been assigned to this issue. Here's my understanding:\n\n" + summary,
+	}
+	if full != "" {
+		comment.Sections = append(comment.Sections, commentSection{Title: "Full analysis", Body: full})
+	}
+	return comment.String()
+}
+
+// planApprovalComment asks a maintainer to approve the plan before implementation starts
+func planApprovalComment(plan, permission string) string {
+	summary, full := collapseLong(plan)
+	comment := botComment{
+		Heading: "📋 Plan awaiting approval",
+		Summary: fmt.Sprintf("%s\n\nBefore I start implementing, someone with %s access needs to approve this plan: comment `/approve` or react 👍 to this comment. Reply with any changes you'd like first.", summary, permission),
+	}
+	if full != "" {
+		comment.Sections = append(comment.Sections, commentSection{Title: "Full plan", Body: full})
+	}
+	return comment.String()
+}
+
+// replyComment formats a conversational reply, folding long responses
+func replyComment(response string) string {
+	summary, full := collapseLong(response)
+	if full == "" {
+		return summary
+	}
+	return botComment{
+		Summary:  summary,
+		Sections: []commentSection{{Title: "Full response", Body: full}},
+	}.String()
+}
+
+// formatFailureComment builds the comment posted when generated code couldn't be parsed
+func formatFailureComment(response string) string {
+	return botComment{
+		Heading: "⚠️ Couldn't apply generated changes",
+		Summary: "I attempted to implement this issue, but couldn't generate files in the correct format. Could you please review what I tried and let me know if you need me to try again?",
+		Sections: []commentSection{
+			{Title: "Generated response", Body: response},
+		},
+	}.String()
+}
+
+// verificationFailureNote builds the PR body section shown when verification never passed
+func verificationFailureNote(attempts int, buildOutput, testOutput string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("⚠️ **Note**: Build/test verification failed after %d attempt(s). Please review carefully.", attempts))
+	if block := logBlock(buildOutput); block != "" {
+		sb.WriteString("\n\n" + details("Build output", block))
+	}
+	if block := logBlock(testOutput); block != "" {
+		sb.WriteString("\n\n" + details("Test output", block))
+	}
+	return sb.String()
+}
+
+// missingTestsNote is added to a PR description when tests were required but none were written
+const missingTestsNote = "⚠️ **Note**: Tests are required for changes in this repository, but none were added. Please add tests before merging."
+
+// lintFailureNote is added to a PR description when the changes pass verification but the
+// linter still reports problems
+func lintFailureNote(lintOutput string) string {
+	note := "⚠️ **Note**: The build and tests pass, but the linter still reports problems in the changed files."
+	if block := logBlock(lintOutput); block != "" {
+		note += "\n\n" + details("Lint output", block)
+	}
+	return note
+}
+
+// prCreatedSummary describes the opened pull request, noting when verification never passed
+func prCreatedSummary(prNumber int, verified bool) string {
+	if verified {
+		return fmt.Sprintf("I've created a pull request with tested changes: #%d", prNumber)
+	}
+	return fmt.Sprintf("I've created a pull request: #%d. Build/test verification didn't pass, so please check the details in the PR description.", prNumber)
+}
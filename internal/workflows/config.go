@@ -0,0 +1,119 @@
+package workflows
+
+import (
+	"fmt"
+
+	"NyteBubo/internal/core"
+	"NyteBubo/internal/types"
+)
+
+// generationParams converts a generation config block into core parameters
+func generationParams(c types.GenerationConfig) core.GenerationParams {
+	return core.GenerationParams{
+		Temperature: c.Temperature,
+		TopP:        c.TopP,
+		MaxTokens:   c.MaxTokens,
+		Stop:        c.Stop,
+
+		ReasoningEffort: c.ReasoningEffort,
+	}
+}
+
+// checkGenerationConfig reports the first generation setting outside the range models accept
+func checkGenerationConfig(config types.Config) error {
+	blocks := []struct {
+		name string
+		c    types.GenerationConfig
+	}{
+		{"generation", config.Generation},
+		{"analysis", config.Analysis},
+		{"codegen", config.Codegen},
+		{"review", config.Review},
+		{"chat", config.Chat},
+	}
+	for _, block := range blocks {
+		c := block.c
+		switch {
+		case c.Temperature != nil && (*c.Temperature < 0 || *c.Temperature > 2):
+			return fmt.Errorf("%s.temperature must be between 0 and 2, got %g", block.name, *c.Temperature)
+		case c.TopP != nil && (*c.TopP <= 0 || *c.TopP > 1):
+			return fmt.Errorf("%s.top_p must be above 0 and at most 1, got %g", block.name, *c.TopP)
+		case c.MaxTokens < 0:
+			return fmt.Errorf("%s.max_tokens must not be negative, got %d", block.name, c.MaxTokens)
+		}
+		switch c.ReasoningEffort {
+		case "", "low", "medium", "high":
+		default:
+			return fmt.Errorf("%s.reasoning_effort must be low, medium or high, got %q", block.name, c.ReasoningEffort)
+		}
+	}
+	return nil
+}
+
+// stageGenerationParams builds the per-stage generation overrides from config
+func stageGenerationParams(config types.Config) map[string]core.GenerationParams {
+	return map[string]core.GenerationParams{
+		core.StageAnalysis: generationParams(config.Analysis),
+		core.StageCodegen:  generationParams(config.Codegen),
+		core.StageReview:   generationParams(config.Review),
+		core.StageChat:     generationParams(config.Chat),
+	}
+}
+
+// providerOptions converts the settings of providers that need more than an API key
+func providerOptions(config types.Config) core.ProviderOptions {
+	return core.ProviderOptions{
+		Local: core.LocalOptions{
+			BaseURL:           config.Local.BaseURL,
+			MergeSystemPrompt: config.Local.MergeSystemPrompt,
+			StructuredOutput:  config.Local.StructuredOutput,
+		},
+		Azure: core.AzureOptions{
+			Endpoint:     config.Azure.Endpoint,
+			APIVersion:   config.Azure.APIVersion,
+			Deployments:  config.Azure.Deployments,
+			TenantID:     config.Azure.TenantID,
+			ClientID:     config.Azure.ClientID,
+			ClientSecret: config.Azure.ClientSecret,
+		},
+	}
+}
+
+// stageModels maps workflow stages to their configured models
+func stageModels(config types.Config) map[string]string {
+	return map[string]string{
+		core.StageAnalysis: config.AnalysisModel,
+		core.StageChat:     config.AnalysisModel,
+		core.StageCodegen:  config.CodegenModel,
+		core.StageReview:   config.ReviewModel,
+	}
+}
+
+// modelPrices converts configured model prices into core prices
+func modelPrices(prices map[string]types.PriceConfig) map[string]core.ModelPrice {
+	converted := make(map[string]core.ModelPrice, len(prices))
+	for model, price := range prices {
+		converted[model] = core.ModelPrice{Input: price.Input, Output: price.Output}
+	}
+	return converted
+}
+
+// matrixConfig converts a repository's test matrix settings into core config
+func matrixConfig(c types.TestMatrixConfig) core.MatrixConfig {
+	return core.MatrixConfig{
+		Versions:      c.Versions,
+		Image:         c.Image,
+		CommandPrefix: c.CommandPrefix,
+	}
+}
+
+// pullRequestMetadata converts a repository's pull request settings into core metadata
+func pullRequestMetadata(c types.PullRequestConfig) core.PullRequestMetadata {
+	return core.PullRequestMetadata{
+		Labels:        c.Labels,
+		Assignees:     c.Assignees,
+		Reviewers:     c.Reviewers,
+		TeamReviewers: c.TeamReviewers,
+		Milestone:     c.Milestone,
+	}
+}
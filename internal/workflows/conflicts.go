@@ -0,0 +1,149 @@
+package workflows
+
+import (
+	"fmt"
+	"strings"
+
+	"NyteBubo/internal/core"
+)
+
+// prConflict is another in-flight bot pull request touching the same files
+type prConflict struct {
+	IssueNumber int
+	PRNumber    int
+	Files       []string
+}
+
+// findConflicts returns the bot's other open pull requests in the repository that change any of files
+func (ia *IssueAgent) findConflicts(owner, repo string, issueNumber int, files []string) []prConflict {
+	states, err := ia.stateManager.ListOpenPRStates(owner, repo)
+	if err != nil {
+		core.IssueLogger(owner, repo, issueNumber).Warn("⚠️  Failed to list in-flight pull requests", "error", err)
+		return nil
+	}
+
+	changed := make(map[string]bool, len(files))
+	for _, file := range files {
+		changed[file] = true
+	}
+
+	var conflicts []prConflict
+	for _, other := range states {
+		if other.IssueNumber == issueNumber || other.PRNumber == nil {
+			continue
+		}
+
+		pr, err := ia.host(owner, repo).GetPullRequest(owner, repo, *other.PRNumber)
+		if err != nil || pr.GetState() != "open" {
+			continue
+		}
+
+		prFiles, err := ia.host(owner, repo).ListPullRequestFiles(owner, repo, *other.PRNumber)
+		if err != nil {
+			core.IssueLogger(owner, repo, issueNumber).Warn("⚠️  Failed to list files for PR", "pr", *other.PRNumber, "error", err)
+			continue
+		}
+
+		var overlap []string
+		for _, file := range prFiles {
+			if changed[file] {
+				overlap = append(overlap, file)
+			}
+		}
+		if len(overlap) > 0 {
+			conflicts = append(conflicts, prConflict{
+				IssueNumber: other.IssueNumber,
+				PRNumber:    *other.PRNumber,
+				Files:       overlap,
+			})
+		}
+	}
+
+	return conflicts
+}
+
+// blockOnConflict parks an issue until the conflicting pull request is closed
+func (ia *IssueAgent) blockOnConflict(state *core.State, conflict prConflict) error {
+	state.Logger().Info("⏸️  Issue overlaps with another PR, waiting for it to close", "pr", conflict.PRNumber)
+
+	comment := botComment{
+		Heading: "⏸️ Waiting on a related pull request",
+		Summary: fmt.Sprintf("My changes for this issue touch the same files as #%d (for #%d). To avoid merge conflicts I'll pick this back up once that pull request is merged or closed.", conflict.PRNumber, conflict.IssueNumber),
+		Sections: []commentSection{
+			{Title: "Overlapping files", Body: fileList(conflict.Files)},
+		},
+	}.String()
+	if err := ia.postComment(state.Owner, state.Repo, state.IssueNumber, comment); err != nil {
+		return fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	prNumber := conflict.PRNumber
+	state.Status = "blocked"
+	state.BlockedByPR = &prNumber
+	if err := ia.stateManager.SaveState(state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return nil
+}
+
+// warnConflicts notes overlapping bot pull requests on both issues involved
+func (ia *IssueAgent) warnConflicts(owner, repo string, issueNumber, prNumber int, conflicts []prConflict) {
+	for _, conflict := range conflicts {
+		files := fileList(conflict.Files)
+
+		comment := botComment{
+			Heading: "⚠️ Overlapping pull requests",
+			Summary: fmt.Sprintf("Pull request #%d changes files that #%d (for #%d) also changes. Whichever merges second may need a rebase.", prNumber, conflict.PRNumber, conflict.IssueNumber),
+			Sections: []commentSection{
+				{Title: "Overlapping files", Body: files},
+			},
+		}.String()
+		if err := ia.postComment(owner, repo, issueNumber, comment); err != nil {
+			core.IssueLogger(owner, repo, issueNumber).Warn("⚠️  Failed to comment on issue", "error", err)
+		}
+
+		otherComment := botComment{
+			Heading: "⚠️ Overlapping pull requests",
+			Summary: fmt.Sprintf("Pull request #%d (for #%d) changes files that #%d also changes. Whichever merges second may need a rebase.", prNumber, issueNumber, conflict.PRNumber),
+			Sections: []commentSection{
+				{Title: "Overlapping files", Body: files},
+			},
+		}.String()
+		if err := ia.postComment(owner, repo, conflict.IssueNumber, otherComment); err != nil {
+			core.IssueLogger(owner, repo, conflict.IssueNumber).Warn("⚠️  Failed to comment on issue", "error", err)
+		}
+	}
+}
+
+// ResumeBlocked restarts work on issues that were waiting for a pull request to close
+func (ia *IssueAgent) ResumeBlocked(owner, repo string, prNumber int) error {
+	states, err := ia.stateManager.ListBlockedStates(owner, repo, prNumber)
+	if err != nil {
+		return fmt.Errorf("failed to list blocked issues: %w", err)
+	}
+
+	for i := range states {
+		state := &states[i]
+		state.Logger().Info("▶️  Blocking PR closed, resuming issue", "pr", prNumber)
+
+		state.Status = "ready_to_implement"
+		state.BlockedByPR = nil
+		if err := ia.stateManager.SaveState(state); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+		if err := ia.StartImplementation(owner, repo, state.IssueNumber); err != nil {
+			state.Logger().Warn("⚠️  Failed to resume issue", "error", err)
+		}
+	}
+
+	return nil
+}
+
+// fileList formats paths as a markdown bullet list
+func fileList(files []string) string {
+	lines := make([]string, len(files))
+	for i, file := range files {
+		lines[i] = fmt.Sprintf("- `%s`", file)
+	}
+	return strings.Join(lines, "\n")
+}
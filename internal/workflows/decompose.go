@@ -0,0 +1,177 @@
+package workflows
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"NyteBubo/internal/core"
+	"github.com/google/go-github/v63/github"
+)
+
+// defaultMaxIssueParts caps how many pull requests an issue is split into when
+// decompose.max_parts isn't set
+const defaultMaxIssueParts = 4
+
+// issueParts returns the pull requests the issue is split into, or nil when it's delivered
+// in one
+func (ia *IssueAgent) issueParts(state *core.State) []core.IssuePart {
+	parts, err := ia.stateManager.ListIssueParts(state.Owner, state.Repo, state.IssueNumber)
+	if err != nil {
+		state.Logger().Warn("⚠️  Failed to list issue parts", "error", err)
+		return nil
+	}
+	if len(parts) < 2 {
+		return nil
+	}
+	return parts
+}
+
+// decompose asks the model whether the issue needs more than one pull request the first
+// time it's implemented, and posts the split when it does. A single pull request is
+// recorded as one part, so the question isn't asked again.
+func (ia *IssueAgent) decompose(impl *implementation, task string) error {
+	if !ia.config.Decompose.Enabled {
+		return nil
+	}
+	state := impl.state
+	owner, repo, issueNumber := state.Owner, state.Repo, state.IssueNumber
+	logger := state.Logger()
+
+	parts, err := ia.stateManager.ListIssueParts(owner, repo, issueNumber)
+	if err != nil {
+		return err
+	}
+	if len(parts) > 0 {
+		return nil
+	}
+
+	logger.Info("📦 Deciding whether to split the issue into several pull requests")
+	decomposition, usage, err := impl.claude.DecomposeIssue(task, impl.repoContext, impl.language, state.Messages())
+	state.AddUsage(usage)
+	if err != nil {
+		logger.Warn("⚠️  Failed to decide whether to split the issue, implementing it in one pull request", "error", err)
+		return nil
+	}
+	parts = limitParts(decomposition.Parts, ia.config.Decompose.MaxParts)
+	if err := ia.stateManager.SaveIssueParts(owner, repo, issueNumber, parts); err != nil {
+		return err
+	}
+	if len(parts) < 2 {
+		return nil
+	}
+
+	logger.Info("📦 Splitting the issue", "parts", len(parts))
+	comment := botComment{
+		Heading: "📦 Splitting this issue",
+		Summary: fmt.Sprintf("%s I'll deliver it as %d pull requests, opening each once the one before it has merged.", decomposition.Reason, len(parts)),
+		Sections: []commentSection{
+			{Title: "Pull requests", Body: partList(parts)},
+		},
+	}.String()
+	if err := ia.postComment(owner, repo, issueNumber, comment); err != nil {
+		return fmt.Errorf("failed to create comment: %w", err)
+	}
+	state.Conversation = append(state.Conversation, core.AgentMessage{
+		Role:    "assistant",
+		Content: fmt.Sprintf("I'm splitting this issue into %d pull requests, merged in order:\n\n%s", len(parts), partList(parts)),
+	})
+	return nil
+}
+
+// limitParts folds the parts beyond max into the last one kept, so no work is dropped
+func limitParts(parts []core.IssuePart, max int) []core.IssuePart {
+	if max <= 0 {
+		max = defaultMaxIssueParts
+	}
+	if len(parts) <= max {
+		return parts
+	}
+	last := &parts[max-1]
+	for _, extra := range parts[max:] {
+		last.Description += fmt.Sprintf("\n\nAlso: %s. %s", extra.Title, extra.Description)
+	}
+	return parts[:max]
+}
+
+// partList formats an issue's parts as a numbered markdown list, noting their pull requests
+func partList(parts []core.IssuePart) string {
+	var b strings.Builder
+	for _, part := range parts {
+		b.WriteString(fmt.Sprintf("%d. **%s**", part.Number, part.Title))
+		switch {
+		case part.MergedAt != nil && part.PRNumber != nil:
+			b.WriteString(fmt.Sprintf(" (#%d, merged)", *part.PRNumber))
+		case part.PRNumber != nil:
+			b.WriteString(fmt.Sprintf(" (#%d)", *part.PRNumber))
+		}
+		if description := strings.TrimSpace(part.Description); description != "" {
+			b.WriteString("\n   " + strings.ReplaceAll(description, "\n", "\n   "))
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// partTask narrows the task of implementing an issue to the part being worked on
+func partTask(task string, parts []core.IssuePart, part *core.IssuePart) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s, part %d of %d only: **%s**\n\n%s\n\n", task, part.Number, len(parts), part.Title, part.Description))
+	b.WriteString("The issue is split into these pull requests, merged in order:\n\n" + partList(parts) + "\n\n")
+	if part.Number > 1 {
+		b.WriteString("The parts before this one are already merged. ")
+	}
+	if part.Number < len(parts) {
+		b.WriteString("Later parts will be implemented separately, so don't start on them.")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// finishPart completes a merged pull request that isn't the issue's last part. The issue
+// waits on the merged pull request like on a conflicting one, so the next part is started
+// on a new branch wherever blocked issues are resumed.
+func (ia *IssueAgent) finishPart(state *core.State, pr *github.PullRequest, parts []core.IssuePart, part *core.IssuePart) error {
+	owner, repo, issueNumber := state.Owner, state.Repo, state.IssueNumber
+	prNumber := pr.GetNumber()
+	logger := state.Logger()
+	logger.Info("📦 Part merged, starting the next", "pr", prNumber, "part", part.Number, "parts", len(parts))
+
+	now := time.Now()
+	if err := ia.stateManager.MarkIssuePartMerged(owner, repo, issueNumber, part.Number, now); err != nil {
+		return err
+	}
+	part.MergedAt = &now
+
+	if branch := pr.GetHead().GetRef(); branch != "" {
+		if err := ia.host(owner, repo).DeleteBranch(owner, repo, branch); err != nil {
+			logger.Warn("⚠️  Failed to delete branch", "branch", branch, "error", err)
+		}
+	}
+	ia.removeWorkspace(state)
+
+	next := parts[part.Number]
+	comment := botComment{
+		Heading: "📦 Part merged",
+		Summary: fmt.Sprintf("#%d has been merged, completing part %d of %d. I'm starting on part %d: **%s**.", prNumber, part.Number, len(parts), next.Number, next.Title),
+		Sections: []commentSection{
+			{Title: "Pull requests", Body: partList(parts)},
+		},
+	}.String()
+	if err := ia.postComment(owner, repo, issueNumber, comment); err != nil {
+		logger.Warn("⚠️  Failed to comment on issue", "error", err)
+	}
+
+	// The next part starts from a clean slate on its own branch
+	state.Status = "blocked"
+	state.BlockedByPR = &prNumber
+	state.PRNumber = nil
+	state.BranchName = ""
+	state.Verified = false
+	state.Checkpoint, state.CheckpointData = "", ""
+	state.ConflictAttempt = ""
+	state.CIFixAttempts, state.CIFailureSHA = 0, ""
+	if err := ia.stateManager.SaveState(state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return nil
+}
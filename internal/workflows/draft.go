@@ -0,0 +1,47 @@
+package workflows
+
+import (
+	"fmt"
+
+	"NyteBubo/internal/core"
+	"github.com/google/go-github/v63/github"
+)
+
+// draftNote tells the issue how a draft pull request will leave draft
+func (ia *IssueAgent) draftNote(verified bool) string {
+	if ia.config.ReadyWhenGreen && verified {
+		return "It's a draft for now; I'll mark it ready for review once CI passes."
+	}
+	return "It's a draft; mark it ready for review when you're happy with it."
+}
+
+// markReadyWhenGreen takes a draft bot pull request out of draft once CI passes on it, if
+// ready_when_green is set and its changes passed sandbox verification. Failing CI is fixed
+// first, as for any bot pull request. Drafts are left alone otherwise, including ones a
+// maintainer converted to draft.
+func (ia *IssueAgent) markReadyWhenGreen(state *core.State, pr *github.PullRequest) error {
+	if !ia.config.CreateDraftPRs || !ia.config.ReadyWhenGreen || !state.Verified {
+		return nil
+	}
+	owner, repo, prNumber := state.Owner, state.Repo, pr.GetNumber()
+
+	if ia.config.MaxCIFixAttempts > 0 {
+		if failed, err := ia.fixFailingChecks(state, pr); failed || err != nil {
+			return err
+		}
+	}
+	passed, _, err := ia.host(owner, repo).ChecksPassed(owner, repo, pr.GetHead().GetSHA())
+	if err != nil || !passed {
+		return err
+	}
+
+	state.Logger().Info("🟢 CI passed, marking PR ready for review", "pr", prNumber)
+	if err := ia.host(owner, repo).MarkPullRequestReady(owner, repo, pr); err != nil {
+		return fmt.Errorf("failed to mark PR ready: %w", err)
+	}
+	comment := "🟢 CI passed and the changes passed verification in the sandbox, so this pull request is ready for review."
+	if err := ia.postPRComment(owner, repo, prNumber, comment); err != nil {
+		state.Logger().Warn("⚠️  Failed to comment on PR", "pr", prNumber, "error", err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package workflows
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v63/github"
+)
+
+// issueFilteredOut returns why the only_labels, skip_labels and trusted_authors settings
+// keep the bot from taking on an issue, or "" if they don't
+func (ia *IssueAgent) issueFilteredOut(issue *github.Issue) string {
+	var labels []string
+	for _, label := range issue.Labels {
+		labels = append(labels, label.GetName())
+	}
+
+	for _, label := range labels {
+		if containsFold(ia.config.SkipLabels, label) {
+			return fmt.Sprintf("labeled %q", label)
+		}
+	}
+	if len(ia.config.OnlyLabels) > 0 && !slices.ContainsFunc(labels, func(label string) bool {
+		return containsFold(ia.config.OnlyLabels, label)
+	}) {
+		return "missing a label from only_labels"
+	}
+	if author := issue.GetUser().GetLogin(); len(ia.config.TrustedAuthors) > 0 && !containsFold(ia.config.TrustedAuthors, author) {
+		return fmt.Sprintf("opened by %s, who isn't in trusted_authors", author)
+	}
+	return ""
+}
+
+// containsFold reports whether values contains s, ignoring case
+func containsFold(values []string, s string) bool {
+	return slices.ContainsFunc(values, func(value string) bool {
+		return strings.EqualFold(value, s)
+	})
+}
@@ -0,0 +1,204 @@
+package workflows
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"NyteBubo/internal/core"
+	"github.com/google/go-github/v63/github"
+)
+
+// finishMerged closes the issues a merged pull request fixes, deletes its branch and
+// workspace and completes their states
+func (ia *IssueAgent) finishMerged(state *core.State, pr *github.PullRequest) error {
+	owner, repo := state.Owner, state.Repo
+	prNumber := pr.GetNumber()
+	logger := state.Logger()
+
+	// Parts of a split issue before the last leave it open for the next part
+	if parts := ia.issueParts(state); parts != nil {
+		if part := core.CurrentPart(parts); part != nil {
+			if part.Number < len(parts) {
+				return ia.finishPart(state, pr, parts, part)
+			}
+			if err := ia.stateManager.MarkIssuePartMerged(owner, repo, state.IssueNumber, part.Number, time.Now()); err != nil {
+				logger.Warn("⚠️  Failed to mark the last part merged", "error", err)
+			}
+		}
+	}
+	logger.Info("✅ PR merged, closing issue", "pr", prNumber)
+
+	// The issues linked when the pull request was opened, plus any its description closes now
+	issueNumbers := append([]int{state.IssueNumber}, extractIssueNumbers(pr.GetBody())...)
+	if err := ia.stateManager.LinkPullRequestIssues(owner, repo, prNumber, issueNumbers); err != nil {
+		logger.Warn("⚠️  Failed to link issues to pull request", "pr", prNumber, "error", err)
+	}
+	if linked, err := ia.stateManager.ListPullRequestIssues(owner, repo, prNumber); err != nil {
+		logger.Warn("⚠️  Failed to list issues linked to pull request", "pr", prNumber, "error", err)
+	} else if len(linked) > 0 {
+		issueNumbers = linked
+	}
+
+	now := time.Now()
+	closing := fmt.Sprintf("✅ #%d has been merged. Closing this issue as completed.", prNumber)
+	for _, issueNumber := range issueNumbers {
+		if issueNumber != state.IssueNumber {
+			ia.closeFixedIssue(owner, repo, issueNumber, closing)
+			ia.completeLinkedIssue(owner, repo, issueNumber, prNumber, now)
+			continue
+		}
+		// The wrap-up comment takes the place of the closing one
+		if ia.config.MergeSummary {
+			if err := ia.postComment(owner, repo, issueNumber, mergeSummary(state, pr, issueNumbers, now)); err != nil {
+				logger.Warn("⚠️  Failed to post merge summary", "error", err)
+			}
+			ia.closeFixedIssue(owner, repo, issueNumber, "")
+		} else {
+			ia.closeFixedIssue(owner, repo, issueNumber, closing)
+		}
+	}
+
+	if branch := pr.GetHead().GetRef(); branch != "" {
+		if err := ia.host(owner, repo).DeleteBranch(owner, repo, branch); err != nil {
+			logger.Warn("⚠️  Failed to delete branch", "branch", branch, "error", err)
+		}
+	}
+	ia.removeWorkspace(state)
+
+	state.Status = "completed"
+	state.CompletedAt = &now
+	if err := ia.stateManager.SaveState(state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return nil
+}
+
+// finishClosed stops work on an issue whose pull request was closed without being merged.
+// The branch is deleted, and a maintainer can start over with the retry command.
+func (ia *IssueAgent) finishClosed(state *core.State, pr *github.PullRequest) error {
+	owner, repo := state.Owner, state.Repo
+	prNumber := pr.GetNumber()
+	logger := state.Logger()
+	logger.Info("🚪 PR closed without merging", "pr", prNumber)
+
+	if branch := pr.GetHead().GetRef(); branch != "" {
+		if err := ia.host(owner, repo).DeleteBranch(owner, repo, branch); err != nil {
+			logger.Debug("Branch not deleted", "branch", branch, "error", err)
+		}
+	}
+	ia.removeWorkspace(state)
+
+	// A closed issue is abandoned along with its pull request; an open one waits for a retry
+	issue, err := ia.host(owner, repo).GetIssue(owner, repo, state.IssueNumber)
+	if err != nil {
+		return fmt.Errorf("failed to get issue: %w", err)
+	}
+	if issue.GetState() == "closed" {
+		state.Status = "abandoned"
+	} else {
+		state.Status = "aborted"
+		comment := botComment{
+			Heading: "🚪 Pull request closed",
+			Summary: fmt.Sprintf("#%d was closed without being merged, so I've stopped working on this issue. Comment `%s retry` to start over.", prNumber, core.CommandPrefix),
+		}.String()
+		if err := ia.postComment(owner, repo, state.IssueNumber, comment); err != nil {
+			logger.Warn("⚠️  Failed to comment on issue", "error", err)
+		}
+	}
+
+	state.PRNumber = nil
+	state.BlockedByPR = nil
+	state.Checkpoint, state.CheckpointData = "", ""
+	if err := ia.stateManager.SaveState(state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return nil
+}
+
+// closeFixedIssue closes an issue fixed by a merged pull request, if the host hasn't
+// already, after posting comment when it isn't empty
+func (ia *IssueAgent) closeFixedIssue(owner, repo string, issueNumber int, comment string) {
+	logger := core.IssueLogger(owner, repo, issueNumber)
+	issue, err := ia.host(owner, repo).GetIssue(owner, repo, issueNumber)
+	if err != nil || issue.GetState() != "open" {
+		return
+	}
+	if comment != "" {
+		if err := ia.postComment(owner, repo, issueNumber, comment); err != nil {
+			logger.Warn("⚠️  Failed to comment on issue", "error", err)
+		}
+	}
+	if err := ia.host(owner, repo).CloseIssue(owner, repo, issueNumber); err != nil {
+		logger.Warn("⚠️  Failed to close issue", "error", err)
+	}
+}
+
+// completeLinkedIssue completes the state of another issue a merged pull request fixed,
+// unless the bot is working on it in a pull request of its own
+func (ia *IssueAgent) completeLinkedIssue(owner, repo string, issueNumber, prNumber int, completedAt time.Time) {
+	logger := core.IssueLogger(owner, repo, issueNumber)
+	state, err := ia.stateManager.GetState(owner, repo, issueNumber)
+	if err != nil {
+		logger.Warn("⚠️  Failed to get state", "error", err)
+		return
+	}
+	if state == nil || state.Status == "completed" || state.Status == "abandoned" {
+		return
+	}
+	if state.PRNumber != nil && *state.PRNumber != prNumber {
+		return
+	}
+
+	logger.Info("✅ Issue fixed by merged PR", "pr", prNumber)
+	state.Status = "completed"
+	state.CompletedAt = &completedAt
+	if err := ia.stateManager.SaveState(state); err != nil {
+		logger.Warn("⚠️  Failed to save state", "error", err)
+	}
+}
+
+// removeWorkspace deletes an issue's sandbox workspace, in case one was left behind
+func (ia *IssueAgent) removeWorkspace(state *core.State) {
+	sandbox, err := ia.newSandbox(state.Owner, state.Repo, state.IssueNumber)
+	if err == nil {
+		err = sandbox.Cleanup()
+	}
+	if err != nil {
+		state.Logger().Warn("⚠️  Failed to remove workspace", "error", err)
+	}
+}
+
+// mergeSummary is the wrap-up comment posted on an issue when its pull request merges
+func mergeSummary(state *core.State, pr *github.PullRequest, issueNumbers []int, mergedAt time.Time) string {
+	summary := fmt.Sprintf("#%d has been merged, so this issue is complete.", pr.GetNumber())
+	if merger := pr.GetMergedBy().GetLogin(); merger != "" {
+		summary = fmt.Sprintf("#%d has been merged by @%s, so this issue is complete.", pr.GetNumber(), merger)
+	}
+	var others []string
+	for _, issueNumber := range issueNumbers {
+		if issueNumber != state.IssueNumber {
+			others = append(others, fmt.Sprintf("#%d", issueNumber))
+		}
+	}
+	if len(others) > 0 {
+		summary += fmt.Sprintf(" It also closed %s.", strings.Join(others, ", "))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("- **Time to merge:** %s\n", mergedAt.Sub(state.CreatedAt).Round(time.Minute)))
+	if pr.GetCommits() > 0 {
+		sb.WriteString(fmt.Sprintf("- **Commits:** %d\n", pr.GetCommits()))
+	}
+	if pr.GetChangedFiles() > 0 {
+		sb.WriteString(fmt.Sprintf("- **Changes:** %d files, +%d -%d\n", pr.GetChangedFiles(), pr.GetAdditions(), pr.GetDeletions()))
+	}
+	sb.WriteString(fmt.Sprintf("- **Tokens:** %d in / %d out\n", state.TotalInputTokens, state.TotalOutputTokens))
+	sb.WriteString(fmt.Sprintf("- **Cost:** $%.4f", state.TotalCost))
+
+	return botComment{
+		Heading:  "🎉 Merged",
+		Summary:  summary,
+		Sections: []commentSection{{Title: "Details", Body: sb.String()}},
+	}.String()
+}
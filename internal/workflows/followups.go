@@ -0,0 +1,84 @@
+package workflows
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultFollowUpLabel     = "nytebubo-followup"
+	defaultMaxFollowUpIssues = 3
+)
+
+// followUpIssue is an issue opened for work a pull request left for later
+type followUpIssue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+}
+
+// openFollowUps opens an issue for each piece of work the pull request's summary leaves for
+// later, linking back to the issue and pull request, and returns the issues it opened. The
+// pull request is already open, so failures are only logged.
+func (ia *IssueAgent) openFollowUps(impl *implementation, prNumber int) []followUpIssue {
+	settings := ia.config.FollowUps
+	if !settings.Enabled {
+		return nil
+	}
+	state := impl.state
+	owner, repo, issueNumber := state.Owner, state.Repo, state.IssueNumber
+	logger := state.Logger()
+
+	title := ""
+	if issue, err := ia.host(owner, repo).GetIssue(owner, repo, issueNumber); err == nil {
+		title = issue.GetTitle()
+	}
+	// The agent is only set up when this run cloned the repository
+	claude := impl.claude
+	if claude == nil {
+		claude = ia.claudeFor(state)
+	}
+	followUps, usage, err := claude.FindFollowUps(title, impl.progress.Summary)
+	state.AddUsage(usage)
+	if err != nil {
+		logger.Warn("⚠️  Failed to look for follow-up work", "error", err)
+		return nil
+	}
+
+	maxIssues := settings.MaxIssues
+	if maxIssues <= 0 {
+		maxIssues = defaultMaxFollowUpIssues
+	}
+	if len(followUps) > maxIssues {
+		logger.Info("📌 Only opening the first follow-up issues", "found", len(followUps), "max_issues", maxIssues)
+		followUps = followUps[:maxIssues]
+	}
+	label := settings.Label
+	if label == "" {
+		label = defaultFollowUpLabel
+	}
+
+	var opened []followUpIssue
+	for _, followUp := range followUps {
+		body := fmt.Sprintf("%s\n\n---\n\nLeft for later by #%d, which implements #%d.\n\n🤖 This issue was opened by NyteBubo", strings.TrimSpace(followUp.Body), prNumber, issueNumber)
+		issue, err := ia.host(owner, repo).CreateIssue(owner, repo, followUp.Title, body)
+		if err != nil {
+			logger.Warn("⚠️  Failed to open follow-up issue", "title", followUp.Title, "error", err)
+			continue
+		}
+		if err := ia.host(owner, repo).AddLabels(owner, repo, issue.GetNumber(), []string{label}); err != nil {
+			logger.Warn("⚠️  Failed to label follow-up issue", "follow_up", issue.GetNumber(), "label", label, "error", err)
+		}
+		logger.Info("📌 Opened follow-up issue", "follow_up", issue.GetNumber())
+		opened = append(opened, followUpIssue{Number: issue.GetNumber(), Title: issue.GetTitle()})
+	}
+	return opened
+}
+
+// followUpList formats follow-up issues as a markdown bullet list
+func followUpList(issues []followUpIssue) string {
+	lines := make([]string, len(issues))
+	for i, issue := range issues {
+		lines[i] = fmt.Sprintf("- #%d %s", issue.Number, issue.Title)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,130 @@
+package workflows
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"NyteBubo/internal/core"
+)
+
+const (
+	defaultGuardrailMaxFiles       = 25
+	defaultGuardrailDeletedPercent = 50
+
+	// minGuardedDeletion is the fewest removed lines that count as a large deletion, so
+	// rewriting most of a short file isn't held
+	minGuardedDeletion = 20
+)
+
+// guardrailViolations returns why the changes in the sandbox look destructive or unrelated
+// to the issue, or nil when they don't
+func (ia *IssueAgent) guardrailViolations(sandbox *core.Sandbox, state *core.State) []string {
+	logger := state.Logger()
+	guardrails := ia.config.Guardrails
+
+	changed, err := sandbox.ChangedFiles()
+	if err != nil {
+		logger.Warn("⚠️  Failed to list changed files for guardrails", "error", err)
+		return nil
+	}
+
+	var violations []string
+	maxFiles := guardrails.MaxFiles
+	if maxFiles == 0 {
+		maxFiles = defaultGuardrailMaxFiles
+	}
+	if maxFiles > 0 && len(changed) > maxFiles {
+		violations = append(violations, fmt.Sprintf("%d files are changed, more than the limit of %d.", len(changed), maxFiles))
+	}
+
+	maxDeleted := guardrails.MaxDeletedPercent
+	if maxDeleted == 0 {
+		maxDeleted = defaultGuardrailDeletedPercent
+	}
+	if maxDeleted > 0 {
+		stats, err := sandbox.DiffStats()
+		if err != nil {
+			logger.Warn("⚠️  Failed to count changed lines for guardrails", "error", err)
+		}
+		for _, stat := range stats {
+			if stat.Deleted < minGuardedDeletion || stat.Deleted*100 <= stat.Original*maxDeleted {
+				continue
+			}
+			violations = append(violations, fmt.Sprintf("`%s` loses %d of its %d lines (%d%%).", stat.Path, stat.Deleted, stat.Original, stat.Deleted*100/stat.Original))
+		}
+	}
+
+	if guardrails.UnrelatedFiles {
+		if unrelated := unrelatedFiles(sandbox, state, changed); len(unrelated) > 0 {
+			violations = append(violations, "These files share no keywords with the issue:\n"+fileList(unrelated))
+		}
+	}
+	return violations
+}
+
+// unrelatedFiles returns the changed files that neither their path nor their content ties
+// to the issue, scored like the files offered as context. A file next to a related one
+// counts as related.
+func unrelatedFiles(sandbox *core.Sandbox, state *core.State, changed []string) []string {
+	var text strings.Builder
+	for _, msg := range state.Conversation {
+		if msg.Role == "user" {
+			text.WriteString(msg.Content + "\n")
+		}
+	}
+	keywords := contextKeywords(text.String())
+	mentioned := mentionedPaths(text.String())
+	if len(keywords) == 0 && len(mentioned) == 0 {
+		return nil
+	}
+
+	relatedDirs := make(map[string]bool)
+	var candidates []string
+	for _, file := range changed {
+		// Lockfiles and generated directories follow from other changes
+		if skipContextFile(file) {
+			continue
+		}
+		content, _ := sandbox.ReadFile(file)
+		if scoreContextFile(file, content, keywords, mentioned) > 0 {
+			relatedDirs[path.Dir(file)] = true
+			continue
+		}
+		candidates = append(candidates, file)
+	}
+
+	var unrelated []string
+	for _, file := range candidates {
+		if !relatedDirs[path.Dir(file)] {
+			unrelated = append(unrelated, file)
+		}
+	}
+	return unrelated
+}
+
+// holdForGuardrails parks an implementation whose changes tripped a guardrail until a
+// maintainer lets them through with /nytebubo force or starts over with /nytebubo retry
+func (ia *IssueAgent) holdForGuardrails(impl *implementation, violations []string) error {
+	impl.state.Logger().Warn("🚧 Holding changes that tripped a guardrail", "violations", len(violations))
+
+	comment := botComment{
+		Heading: "🚧 Changes held for review",
+		Summary: "The changes I made for this issue look more destructive or wide-reaching than expected, so I haven't pushed them. Run `" + core.CommandPrefix + " force` to push them and open the pull request anyway, or `" + core.CommandPrefix + " retry` to start over.",
+		Sections: []commentSection{
+			{Title: "Why", Body: "- " + strings.Join(violations, "\n- ")},
+		},
+	}.String()
+	return ia.holdImplementation(impl, "held", comment)
+}
+
+// commandForce resumes an implementation held by a guardrail, pushing its changes as they are
+func (ia *IssueAgent) commandForce(state *core.State, number int, author string) error {
+	if state.Status != "held" {
+		return ia.commandReply(state, number, "I'm not holding any changes for this issue.")
+	}
+	state.Logger().Info("🚧 Pushing changes held by a guardrail", "forced_by", author)
+	return ia.resumeHeld(state, func(progress *implementationProgress) {
+		progress.ForcedBy = author
+	})
+}
@@ -0,0 +1,41 @@
+package workflows
+
+import (
+	"NyteBubo/internal/core"
+)
+
+// defaultMaxIssueImages is the number of images analyzed when max_issue_images is unset
+const defaultMaxIssueImages = 4
+
+// issueImages downloads the screenshots referenced in an issue body so they can
+// be passed to a vision-capable model. Failed downloads are skipped.
+func (ia *IssueAgent) issueImages(state *core.State, body string) []core.ImageAttachment {
+	limit := ia.config.MaxIssueImages
+	if limit == 0 {
+		limit = defaultMaxIssueImages
+	}
+	if limit < 0 {
+		return nil
+	}
+
+	urls := core.ExtractImageURLs(body)
+	if len(urls) > limit {
+		state.Logger().Info("🖼️  Issue references more images than are analyzed", "images", len(urls), "limit", limit)
+		urls = urls[:limit]
+	}
+
+	var images []core.ImageAttachment
+	for _, url := range urls {
+		image, err := ia.host(state.Owner, state.Repo).DownloadImage(url)
+		if err != nil {
+			state.Logger().Warn("⚠️  Failed to download image", "url", url, "error", err)
+			continue
+		}
+		images = append(images, image)
+	}
+
+	if len(images) > 0 {
+		state.Logger().Info("🖼️  Attached images from the issue for analysis", "images", len(images))
+	}
+	return images
+}
@@ -0,0 +1,646 @@
+package workflows
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"NyteBubo/internal/core"
+)
+
+// implementation is one run of the implementation steps for an issue
+type implementation struct {
+	state         *core.State
+	defaultBranch string
+	language      string
+	progress      implementationProgress
+
+	// Set by the steps of this run; lost on restart
+	sandbox     *core.Sandbox
+	claude      *core.ClaudeAgent
+	repoContext string
+	changes     codeChanges
+	applied     bool // The changes are already in the sandbox (plan mode applies them while generating)
+	conflicts   []prConflict
+}
+
+// implementationStep runs one step of the implementation. Returning stop ends the run
+// early without error, e.g. when the issue needs clarifying or is blocked.
+type implementationStep struct {
+	name string
+	run  func(ia *IssueAgent, impl *implementation) (stop bool, err error)
+}
+
+// implementationSteps are run in order, each recording its completion in the state
+var implementationSteps = []implementationStep{
+	{stepBranched, (*IssueAgent).branchStep},
+	{stepGenerated, (*IssueAgent).generateStep},
+	{stepApplied, (*IssueAgent).applyStep},
+	{stepVerified, (*IssueAgent).verifyStep},
+	{stepPushed, (*IssueAgent).pushStep},
+	{stepPROpened, (*IssueAgent).pullRequestStep},
+	{stepCommented, (*IssueAgent).commentStep},
+}
+
+// implementInSandbox clones the repository, applies generated changes, verifies them and
+// opens the pull request, skipping steps already completed before a restart
+func (ia *IssueAgent) implementInSandbox(state *core.State) error {
+	owner, repo, issueNumber := state.Owner, state.Repo, state.IssueNumber
+	logger := state.Logger()
+
+	// Update status
+	state.Status = "implementing"
+	if err := ia.stateManager.SaveState(state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	// Notify that we're starting implementation
+	comment := "🚀 Great! I have a clear understanding now. I'll clone the repository, make changes, and run tests before creating a pull request."
+	if state.Checkpoint != "" {
+		comment = "🔄 Picking up where I left off before restarting."
+	}
+	if err := ia.postComment(owner, repo, issueNumber, comment); err != nil {
+		return fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	// Get repository info
+	repository, err := ia.host(owner, repo).GetRepository(owner, repo)
+	if err != nil {
+		return fmt.Errorf("failed to get repository: %w", err)
+	}
+
+	progress, err := loadProgress(state)
+	if err != nil {
+		return err
+	}
+	impl := &implementation{
+		state:         state,
+		defaultBranch: repository.GetDefaultBranch(),
+		language:      repository.GetLanguage(),
+		progress:      progress,
+	}
+	if impl.defaultBranch == "" {
+		impl.defaultBranch = "main"
+	}
+
+	// Ensure cleanup happens
+	defer func() {
+		if impl.sandbox == nil {
+			return
+		}
+		if err := impl.sandbox.Cleanup(); err != nil {
+			impl.sandbox.Logger().Warn("⚠️  Failed to clean up sandbox", "error", err)
+		}
+	}()
+
+	start := resumeIndex(state.Checkpoint)
+	if state.Checkpoint != "" {
+		logger.Info("♻️  Resuming implementation", "completed", state.Checkpoint, "next", implementationSteps[start].name)
+	}
+	for _, step := range implementationSteps[start:] {
+		if err := ia.ctx.Err(); err != nil {
+			return err
+		}
+		if ia.stoppedElsewhere(state) {
+			return nil
+		}
+
+		stop, err := step.run(ia, impl)
+		if err != nil {
+			logger.Warn("❌ Implementation step failed", "step", step.name, "completed", state.Checkpoint, "error", err)
+			return fmt.Errorf("%s step failed: %w", step.name, err)
+		}
+		if stop {
+			return nil
+		}
+		// Saving the step would overwrite an abort that came in while it ran, and an
+		// abandoned issue's cleanup missed whatever the step pushed or opened
+		if ia.stoppedElsewhere(state) {
+			if state.Status == "abandoned" {
+				ia.discardAbandonedWork(state)
+			}
+			return nil
+		}
+		if err := ia.completeStep(impl, step.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stoppedElsewhere reports whether the issue was aborted or abandoned, e.g. with
+// /nytebubo abort, since this implementation started. The stored status is kept rather than
+// overwritten by the rest of the run.
+func (ia *IssueAgent) stoppedElsewhere(state *core.State) bool {
+	stored, err := ia.stateManager.GetState(state.Owner, state.Repo, state.IssueNumber)
+	if err != nil {
+		state.Logger().Warn("⚠️  Failed to check whether the issue was stopped", "error", err)
+		return false
+	}
+	if stored == nil || (stored.Status != "aborted" && stored.Status != "abandoned") {
+		return false
+	}
+	state.Logger().Info("🛑 Issue was stopped, ending the implementation", "status", stored.Status, "completed", state.Checkpoint)
+	state.Status = stored.Status
+	return true
+}
+
+// completeStep records a completed step with the progress so far. The checkpoint is
+// cleared once the last step is done.
+func (ia *IssueAgent) completeStep(impl *implementation, step string) error {
+	state := impl.state
+	if step == implementationSteps[len(implementationSteps)-1].name {
+		state.Checkpoint, state.CheckpointData = "", ""
+	} else {
+		data, err := json.Marshal(impl.progress)
+		if err != nil {
+			return fmt.Errorf("failed to save checkpoint: %w", err)
+		}
+		state.Checkpoint, state.CheckpointData = step, string(data)
+	}
+	if err := ia.stateManager.SaveState(state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return nil
+}
+
+// branchStep clones the repository onto the issue branch and gathers the context for the AI
+func (ia *IssueAgent) branchStep(impl *implementation) (bool, error) {
+	state := impl.state
+	owner, repo, issueNumber := state.Owner, state.Repo, state.IssueNumber
+
+	// Name the branch once; later runs keep using the recorded name
+	if state.BranchName == "" {
+		state.BranchName = ia.branchName(owner, repo, issueNumber)
+		// Each part of a split issue gets a branch of its own
+		if part := core.CurrentPart(ia.issueParts(state)); part != nil && part.Number > 1 {
+			state.BranchName += fmt.Sprintf("-part-%d", part.Number)
+		}
+	}
+
+	// Create sandbox
+	sandbox, err := ia.newSandbox(owner, repo, issueNumber)
+	if err != nil {
+		return false, fmt.Errorf("failed to create sandbox: %w", err)
+	}
+	impl.sandbox = sandbox
+
+	// Clone repository
+	if err := sandbox.CloneRepo(); err != nil {
+		return false, fmt.Errorf("failed to clone repo: %w", err)
+	}
+
+	// Create branch
+	if err := sandbox.CreateBranch(state.BranchName); err != nil {
+		return false, fmt.Errorf("failed to create branch: %w", err)
+	}
+
+	// Get repo context for AI
+	files, err := sandbox.ListFiles()
+	if err != nil {
+		return false, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	detectedLang, _ := sandbox.DetectLanguage()
+	// In a monorepo, only the subprojects the issue is about are shown to the AI
+	scope := issueSubprojects(sandbox, state.Messages())
+	if len(scope) > 0 {
+		state.Logger().Info("📦 Scoping context to subprojects", "subprojects", subprojectDirs(scope))
+		files = subprojectFiles(files, scope)
+		detectedLang = scope[0].Language
+	}
+	if impl.language == "" {
+		impl.language = detectedLang
+	}
+
+	repository := owner + "/" + repo
+	if len(scope) > 0 {
+		repository += fmt.Sprintf(" (monorepo; this issue concerns %s)", strings.Join(subprojectDirs(scope), ", "))
+	}
+	impl.repoContext = fmt.Sprintf("Repository: %s\nLanguage: %s\nExisting files: %s",
+		repository, impl.language, strings.Join(files, ", "))
+	if fileContext := ia.buildFileContext(sandbox, files, state.Messages()); fileContext != "" {
+		impl.repoContext += "\n\n" + fileContext
+	}
+
+	// Remember the clarified conversation and recall similar past solutions
+	ia.remember(state, core.MemoryConversation, conversationDigest(state.Messages()))
+	if len(state.Conversation) > 0 {
+		if memoryContext := ia.recallMemories(state, state.Conversation[0].Content); memoryContext != "" {
+			impl.repoContext += "\n\n" + memoryContext
+		}
+	}
+
+	impl.claude = ia.withProgress(ia.claudeFor(state), owner, repo, issueNumber)
+	return false, nil
+}
+
+// generateStep asks the AI for the changes, reusing a response saved before a restart
+func (ia *IssueAgent) generateStep(impl *implementation) (bool, error) {
+	state := impl.state
+	owner, repo, issueNumber := state.Owner, state.Repo, state.IssueNumber
+	logger := state.Logger()
+
+	// Generate code with full context
+	task := fmt.Sprintf("Implement the changes for issue #%d", issueNumber)
+	if ia.config.RequireTests {
+		task += ", including unit tests that cover the new behavior. The tests are run and must pass"
+	}
+	ia.summarizeConversation(impl.claude, state)
+	if impl.progress.Response == "" {
+		if err := ia.decompose(impl, task); err != nil {
+			return false, err
+		}
+	}
+	if parts := ia.issueParts(state); parts != nil {
+		if part := core.CurrentPart(parts); part != nil {
+			task = partTask(task, parts, part)
+		}
+	}
+	if impl.progress.Response != "" {
+		logger.Info("♻️  Reusing code generated before the restart")
+	} else if ia.config.PlanMode {
+		logger.Info("🤖 Generating code with AI (with full repo context)")
+		response, applied, err := ia.generateWithPlan(impl.claude, state, impl.sandbox, task, impl.repoContext, impl.language)
+		if err != nil {
+			return false, fmt.Errorf("failed to generate code: %w", err)
+		}
+		impl.progress.Response, impl.applied = response, applied
+	} else {
+		logger.Info("🤖 Generating code with AI (with full repo context)")
+		response, usage, err := ia.generateChanges(impl.claude, impl.sandbox, state, task, impl.repoContext, impl.language, state.Messages())
+		if errors.Is(err, core.ErrTruncated) {
+			// Too much for one response; a plan generates each step separately
+			logger.Warn("✂️  Changes don't fit in one response, planning smaller steps", "error", err)
+			response, impl.applied, err = ia.generateWithPlan(impl.claude, state, impl.sandbox, task, impl.repoContext, impl.language)
+			usage = core.TokenUsage{}
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to generate code: %w", err)
+		}
+		impl.progress.Response = response
+		state.AddUsage(usage)
+	}
+
+	// Parse the code response and extract file changes
+	impl.changes = parseChanges(impl.progress.Response)
+	impl.progress.Summary = extractSummary(impl.changes.Text, impl.changes.paths())
+
+	if impl.changes.empty() {
+		logger.Warn("⚠️  No file changes detected from AI response")
+		summary := impl.progress.Summary
+		comment := ia.render(templateFormatFailure, templateData{Owner: owner, Repo: repo, IssueNumber: issueNumber, Summary: summary, Default: formatFailureComment(summary)})
+		if err := ia.postComment(owner, repo, issueNumber, comment); err != nil {
+			return false, fmt.Errorf("failed to create comment: %w", err)
+		}
+
+		state.Status = "waiting_for_clarification"
+		state.Checkpoint, state.CheckpointData = "", ""
+		if err := ia.stateManager.SaveState(state); err != nil {
+			return false, fmt.Errorf("failed to save state: %w", err)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// applyStep writes the changes to the sandbox, unless the plan's steps already did
+func (ia *IssueAgent) applyStep(impl *implementation) (bool, error) {
+	if impl.applied {
+		return false, nil
+	}
+	impl.state.Logger().Info("📝 Applying file changes to sandbox", "files", len(impl.changes.paths()))
+	if err := ia.applyWithFallback(impl.claude, impl.sandbox, impl.state, impl.changes, impl.progress.Response, impl.repoContext, impl.language); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+// verifyStep builds and tests the changes, feeding failures back to the AI for a limited
+// number of fixes. Failures that remain are noted on the pull request rather than stopping it.
+func (ia *IssueAgent) verifyStep(impl *implementation) (bool, error) {
+	state := impl.state
+	logger := state.Logger()
+
+	if impl.progress.released() {
+		logger.Info("♻️  Keeping the verification of the changes released by a maintainer")
+		return false, nil
+	}
+
+	maxAttempts := ia.maxFixIterations() + 1
+	verified := false
+	attempts := 0
+	var buildOutput, testOutput, lintOutput string
+	var matrixResults []core.MatrixResult
+	var lintErr error
+	missingTests := false
+verification:
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if err := ia.ctx.Err(); err != nil {
+			return false, err
+		}
+		logger.Info("🔍 Verification attempt", "attempt", attempt, "max_attempts", maxAttempts)
+
+		var verifyErr error
+		attempts = attempt
+		// Files that don't parse are fixed before anything slower runs on them
+		syntaxErrors, err := impl.sandbox.CheckSyntax()
+		if err != nil {
+			logger.Warn("⚠️  Failed to check syntax", "error", err)
+		}
+		if len(syntaxErrors) > 0 {
+			buildOutput, testOutput, matrixResults = syntaxReport(syntaxErrors), "", nil
+			verifyErr = fmt.Errorf("%d changed files don't parse", len(syntaxErrors))
+		} else {
+			impl.sandbox.Format()
+			buildOutput, testOutput, matrixResults, verifyErr = ia.verifySandbox(impl.sandbox, state.Owner, state.Repo)
+		}
+		verified = verifyErr == nil
+
+		var task, fixPrompt string
+		switch {
+		case len(syntaxErrors) > 0:
+			logger.Warn("🧩 Syntax errors in the changes", "files", len(syntaxErrors))
+			task = "Fix syntax errors"
+			fixPrompt = fmt.Sprintf("Some of the changed files don't parse. Please fix the syntax errors.\n\nSyntax errors:\n%s\n\nPlease provide the corrected files in full.", logBlock(buildOutput))
+		case !verified:
+			// Tests or build failed
+			logger.Warn("❌ Verification failed", "error", verifyErr)
+			task = "Fix build/test failures"
+			fixPrompt = fmt.Sprintf("The code has build or test failures. Please fix them.\n\nBuild output:\n%s\n\nTest output:\n%s\n\nError: %v\n\nPlease provide the corrected files.", logBlock(buildOutput), logBlock(testOutput), verifyErr)
+		case ia.config.RequireTests && !hasTestChanges(impl.sandbox):
+			logger.Warn("🧪 Changes don't include tests")
+			verified, missingTests = false, true
+			task = "Add tests for the changes"
+			fixPrompt = "The code builds and the existing tests pass, but the changes don't include any tests. Please add unit tests that cover the new behavior, in the project's usual test files and style.\n\nPlease provide the test files."
+		default:
+			missingTests = false
+			// Lint problems only matter once the code builds and passes its tests
+			lintOutput, lintErr = impl.sandbox.Lint()
+			if lintErr == nil {
+				logger.Info("✅ All checks passed")
+				break verification
+			}
+			logger.Warn("🧹 Linter reported problems", "error", lintErr)
+			task = "Fix lint problems"
+			fixPrompt = fmt.Sprintf("The code builds and passes its tests, but the linter reports problems in the changed files. Please fix them without changing behavior.\n\nLint output:\n%s\n\nPlease provide the corrected files.", logBlock(lintOutput))
+		}
+
+		if attempt == maxAttempts {
+			// Out of retries - create PR anyway but note the failures
+			break
+		}
+
+		// Over budget - open the PR with what we have rather than keep spending
+		if reason, err := ia.budgetExceeded(state); err != nil {
+			logger.Warn("⚠️  Failed to check budget", "error", err)
+		} else if reason != "" {
+			logger.Warn("💸 Stopping fix attempts", "reason", reason)
+			break
+		}
+
+		// Ask AI to fix the issues
+		logger.Info("🤖 Asking AI to fix the issues", "fix", attempt, "max_fixes", maxAttempts-1)
+
+		state.Conversation = append(state.Conversation, core.AgentMessage{
+			Role:    "user",
+			Content: fixPrompt,
+		})
+
+		ia.summarizeConversation(impl.claude, state)
+		fixResponse, fixUsage, err := ia.generateChanges(impl.claude, impl.sandbox, state, task, impl.repoContext, impl.language, state.Messages())
+		if err != nil {
+			logger.Warn("⚠️  Failed to get fix from AI", "error", err)
+			break
+		}
+
+		state.AddUsage(fixUsage)
+
+		// Parse and apply fixes
+		fixes := parseChanges(fixResponse)
+		if fixes.empty() {
+			logger.Warn("⚠️  AI didn't provide file fixes")
+			break
+		}
+
+		logger.Info("📝 Applying fixes", "files", len(fixes.paths()))
+		if err := ia.applyWithFallback(impl.claude, impl.sandbox, state, fixes, fixResponse, impl.repoContext, impl.language); err != nil {
+			logger.Warn("⚠️  Failed to apply fixes", "error", err)
+		}
+	}
+
+	verificationNote := ""
+	if missingTests {
+		verificationNote = "\n\n" + missingTestsNote
+	} else if !verified {
+		verificationNote = "\n\n" + verificationFailureNote(attempts, buildOutput, testOutput)
+	} else if lintErr != nil {
+		verificationNote = "\n\n" + lintFailureNote(lintOutput)
+	}
+	if report := matrixReport(matrixResults); report != "" {
+		verificationNote += "\n\n" + report
+	}
+	impl.progress.VerificationNote, impl.progress.Verified = verificationNote, verified
+	return false, nil
+}
+
+// syntaxReport lists the files that don't parse and why, one per line
+func syntaxReport(syntaxErrors []core.SyntaxError) string {
+	lines := make([]string, len(syntaxErrors))
+	for i, syntaxError := range syntaxErrors {
+		lines[i] = syntaxError.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// hasTestChanges reports whether the changes in the sandbox add or modify tests
+func hasTestChanges(sandbox *core.Sandbox) bool {
+	tests, err := sandbox.ChangedTestFiles()
+	if err != nil {
+		sandbox.Logger().Warn("⚠️  Failed to list changed tests", "error", err)
+		return false
+	}
+	return len(tests) > 0
+}
+
+// pushStep commits and pushes the changes, unless they overlap another bot pull request
+// and conflicting work is serialized
+func (ia *IssueAgent) pushStep(impl *implementation) (bool, error) {
+	state := impl.state
+	owner, repo, issueNumber := state.Owner, state.Repo, state.IssueNumber
+
+	// Destructive or off-topic changes wait for a maintainer, as do all changes when they're
+	// previewed before pushing
+	if !impl.progress.released() {
+		if violations := ia.guardrailViolations(impl.sandbox, state); len(violations) > 0 {
+			return true, ia.holdForGuardrails(impl, violations)
+		}
+	}
+	if ia.config.PreviewDiffs && impl.progress.AppliedBy == "" {
+		return true, ia.previewDiff(impl)
+	}
+
+	// Check for other in-flight bot pull requests touching the same files
+	if changedFiles, err := impl.sandbox.ChangedFiles(); err != nil {
+		state.Logger().Warn("⚠️  Failed to list changed files", "error", err)
+	} else {
+		impl.conflicts = ia.findConflicts(owner, repo, issueNumber, changedFiles)
+	}
+	if len(impl.conflicts) > 0 && ia.config.ForRepo(owner, repo).SerializeConflicts {
+		return true, ia.blockOnConflict(state, impl.conflicts[0])
+	}
+
+	// Commit changes
+	commitMsg := fmt.Sprintf("Implement solution for issue #%d\n\n%s", issueNumber, impl.progress.Summary)
+	if err := impl.sandbox.Commit(commitMsg); err != nil {
+		return false, fmt.Errorf("failed to commit: %w", err)
+	}
+
+	// Push to remote
+	if err := impl.sandbox.Push(state.BranchName); err != nil {
+		if findings := secretsFound(err); findings != nil {
+			return true, ia.holdForSecrets(state, findings)
+		}
+		return false, fmt.Errorf("failed to push: %w", err)
+	}
+
+	// Everything up to the pull request is done; a restart only needs to open it
+	impl.progress.Response = ""
+	return false, nil
+}
+
+// holdForSecrets stops an implementation whose changes look like they contain credentials.
+// The issue is aborted so a maintainer can review it and retry.
+func (ia *IssueAgent) holdForSecrets(state *core.State, findings []core.SecretFinding) error {
+	state.Logger().Warn("🔐 Not pushing changes with possible secrets", "findings", len(findings))
+	if err := ia.postComment(state.Owner, state.Repo, state.IssueNumber, secretsComment(findings)); err != nil {
+		return fmt.Errorf("failed to create comment: %w", err)
+	}
+	state.Status = "aborted"
+	if err := ia.stateManager.SaveState(state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return nil
+}
+
+// pullRequestStep opens the pull request for the pushed branch
+func (ia *IssueAgent) pullRequestStep(impl *implementation) (bool, error) {
+	state := impl.state
+	owner, repo, issueNumber := state.Owner, state.Repo, state.IssueNumber
+	progress := impl.progress
+
+	// Get issue for PR
+	issue, err := ia.host(owner, repo).GetIssue(owner, repo, issueNumber)
+	if err != nil {
+		return false, fmt.Errorf("failed to get issue: %w", err)
+	}
+	// The issue may have been closed while the changes were being made
+	if issue.GetState() == "closed" {
+		state.Logger().Info("📪 Issue was closed, not opening a pull request")
+		return true, ia.AbandonIssue(owner, repo, issueNumber, "closed")
+	}
+
+	// Create PR
+	data := issueTemplateData(owner, repo, issue)
+	data.Summary, data.VerificationNote, data.Verified = progress.Summary, progress.VerificationNote, progress.Verified
+	parts := ia.issueParts(state)
+	part := core.CurrentPart(parts)
+	reference := fmt.Sprintf("Fixes #%d", issueNumber)
+	data.Default = fmt.Sprintf("Fix: %s", issue.GetTitle())
+	if part != nil {
+		data.Part, data.Parts = part.Number, len(parts)
+		data.Default += fmt.Sprintf(" (part %d of %d)", part.Number, len(parts))
+		// Only the last part closes the issue
+		if part.Number < len(parts) {
+			reference = fmt.Sprintf("Part %d of %d of #%d: %s", part.Number, len(parts), issueNumber, part.Title)
+		} else {
+			reference += fmt.Sprintf(" (part %d of %d: %s)", part.Number, len(parts), part.Title)
+		}
+	}
+	prTitle := strings.Join(strings.Fields(ia.render(templatePRTitle, data)), " ")
+	data.Default = fmt.Sprintf("%s\n\n%s%s\n\n---\n\n🤖 This PR was automatically generated and tested by NyteBubo", reference, progress.Summary, progress.VerificationNote)
+	prBody := ia.render(templatePRBody, data)
+
+	draft := ia.config.CreateDraftPRs
+	state.Logger().Info("📬 Creating pull request", "draft", draft)
+	pr, err := ia.host(owner, repo).CreatePullRequest(owner, repo, prTitle, prBody, state.BranchName, impl.defaultBranch, draft)
+	if err != nil {
+		return false, fmt.Errorf("failed to create PR: %w", err)
+	}
+	state.Logger().Info("✅ Pull request created", "pr", pr.GetNumber())
+	core.PRsCreated.Inc(owner + "/" + repo)
+	ia.applyPullRequestMetadata(state, pr.GetNumber())
+
+	prNumber := pr.GetNumber()
+	state.PRNumber = &prNumber
+	state.Verified = progress.Verified
+	if part != nil {
+		if err := ia.stateManager.SetIssuePartPR(owner, repo, issueNumber, part.Number, prNumber); err != nil {
+			state.Logger().Warn("⚠️  Failed to record the part's pull request", "part", part.Number, "error", err)
+		}
+	}
+	// A pr_body template can close further issues along with this one
+	linked := extractIssueNumbers(prBody)
+	if part == nil || part.Number == len(parts) {
+		linked = append([]int{issueNumber}, linked...)
+	}
+	if err := ia.stateManager.LinkPullRequestIssues(owner, repo, prNumber, linked); err != nil {
+		state.Logger().Warn("⚠️  Failed to link issues to pull request", "pr", prNumber, "error", err)
+	}
+	ia.remember(state, core.MemorySolution, fmt.Sprintf("Issue #%d: %s\nPull request #%d\n\n%s", issueNumber, issue.GetTitle(), prNumber, progress.Summary))
+	return false, nil
+}
+
+// commentStep links the pull request from the issue and hands the issue over to review
+func (ia *IssueAgent) commentStep(impl *implementation) (bool, error) {
+	state := impl.state
+	owner, repo, issueNumber := state.Owner, state.Repo, state.IssueNumber
+	prNumber := *state.PRNumber
+	progress := impl.progress
+
+	ia.warnConflicts(owner, repo, issueNumber, prNumber, impl.conflicts)
+
+	summary := prCreatedSummary(prNumber, progress.Verified)
+	if ia.config.CreateDraftPRs {
+		summary += " " + ia.draftNote(progress.Verified)
+	}
+	if parts := ia.issueParts(state); parts != nil {
+		if part := core.CurrentPart(parts); part != nil && part.Number < len(parts) {
+			summary += fmt.Sprintf(" It's part %d of %d of this issue; I'll start on the next part once it's merged.", part.Number, len(parts))
+		}
+	}
+	data := templateData{
+		Owner:            owner,
+		Repo:             repo,
+		IssueNumber:      issueNumber,
+		PRNumber:         prNumber,
+		Summary:          progress.Summary,
+		VerificationNote: progress.VerificationNote,
+		Verified:         progress.Verified,
+	}
+	// The issues are recorded before the comment is posted, so a retry doesn't open them twice
+	if ia.config.FollowUps.Enabled && !impl.progress.FollowUpsOpened {
+		impl.progress.FollowUps = ia.openFollowUps(impl, prNumber)
+		impl.progress.FollowUpsOpened = true
+		if err := ia.saveProgress(impl); err != nil {
+			return false, err
+		}
+	}
+	sections := []commentSection{{Title: "Summary of changes", Body: progress.Summary}}
+	if followUps := impl.progress.FollowUps; len(followUps) > 0 {
+		sections = append(sections, commentSection{Title: "Follow-up issues", Body: followUpList(followUps)})
+	}
+	data.Default = botComment{
+		Heading:  "✅ Pull request opened",
+		Summary:  summary,
+		Sections: sections,
+	}.String()
+	prComment := ia.render(templatePROpened, data)
+	if err := ia.postComment(owner, repo, issueNumber, prComment); err != nil {
+		return false, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	state.Status = "pr_created"
+	return false, nil
+}
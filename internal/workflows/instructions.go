@@ -0,0 +1,112 @@
+package workflows
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"NyteBubo/internal/core"
+)
+
+// repoInstructionFiles lists the files checked (in order) for maintainer instructions
+var repoInstructionFiles = []string{"NYTEBUBO.md", "AGENTS.md", "CLAUDE.md"}
+
+const (
+	// instructionsCacheTTL controls how often instruction files are re-fetched
+	instructionsCacheTTL = 10 * time.Minute
+	// maxInstructionsSize caps how much of the instructions file is sent to the model
+	maxInstructionsSize = 16 * 1024
+)
+
+// instructionsCache caches repository instructions to avoid refetching on every event
+type instructionsCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedInstructions
+}
+
+type cachedInstructions struct {
+	content   string
+	fetchedAt time.Time
+}
+
+// repoInstructions returns the maintainer instructions for a repository, if any: its
+// instructions file, then the conventions and protected paths from its .nytebubo.yaml
+func (ia *IssueAgent) repoInstructions(owner, repo string) string {
+	var parts []string
+	if content := ia.instructionsFile(owner, repo); content != "" {
+		parts = append(parts, content)
+	}
+	file := ia.repoFile(owner, repo)
+	if conventions := strings.TrimSpace(file.Conventions); conventions != "" {
+		parts = append(parts, "Coding conventions:\n"+conventions)
+	}
+	if len(file.ProtectedPaths) > 0 {
+		parts = append(parts, "Never create, change or delete files matching these paths; changes to them are rejected:\n- "+strings.Join(file.ProtectedPaths, "\n- "))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// instructionsFile returns the contents of the repository's instructions file, if any
+func (ia *IssueAgent) instructionsFile(owner, repo string) string {
+	key := owner + "/" + repo
+
+	ia.instructions.mu.Lock()
+	if entry, ok := ia.instructions.entries[key]; ok && time.Since(entry.fetchedAt) < instructionsCacheTTL {
+		ia.instructions.mu.Unlock()
+		return entry.content
+	}
+	ia.instructions.mu.Unlock()
+
+	content := ""
+	failed := false
+	for _, path := range repoInstructionFiles {
+		// An empty ref reads from the default branch
+		fileContent, err := ia.host(owner, repo).GetFileContent(owner, repo, path, "")
+		if err != nil {
+			if !core.IsNotFound(err) {
+				core.RepoLogger(owner, repo).Warn("⚠️  Failed to fetch maintainer instructions", "path", path, "error", err)
+				failed = true
+			}
+			continue
+		}
+		if strings.TrimSpace(fileContent) == "" {
+			continue
+		}
+
+		content = fileContent
+		if len(content) > maxInstructionsSize {
+			content = strings.ToValidUTF8(content[:maxInstructionsSize], "")
+		}
+		core.RepoLogger(owner, repo).Info("📘 Loaded maintainer instructions", "path", path)
+		break
+	}
+	// A failed fetch is retried on the next event rather than cached, since the file
+	// it missed may exist
+	if failed {
+		return content
+	}
+
+	ia.instructions.mu.Lock()
+	if ia.instructions.entries == nil {
+		ia.instructions.entries = make(map[string]cachedInstructions)
+	}
+	ia.instructions.entries[key] = cachedInstructions{content: content, fetchedAt: time.Now()}
+	ia.instructions.mu.Unlock()
+
+	return content
+}
+
+// claudeFor returns an AI client configured with the repository's instructions and the
+// model chosen for the issue, or else the one its maintainers prefer
+func (ia *IssueAgent) claudeFor(state *core.State) *core.ClaudeAgent {
+	claude := ia.claude.WithLogger(state.Logger()).WithIssue(state.Owner, state.Repo, state.IssueNumber)
+	if state.Model != "" {
+		claude = claude.WithModel(state.Model)
+	} else if model := ia.repoFile(state.Owner, state.Repo).Model; model != "" {
+		claude = claude.WithModel(model)
+	}
+	if instructions := ia.repoInstructions(state.Owner, state.Repo); instructions != "" {
+		claude = claude.WithInstructions(instructions)
+	}
+	return claude
+}
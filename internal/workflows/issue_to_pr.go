@@ -1,48 +1,133 @@
 package workflows
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"NyteBubo/internal/core"
-	"github.com/google/go-github/v63/github"
+	"NyteBubo/internal/types"
 )
 
+// defaultMaxFixIterations is how many times the AI may fix failed verification before the PR is opened anyway
+const defaultMaxFixIterations = 9
+
 // IssueAgent orchestrates the issue-to-PR workflow
 type IssueAgent struct {
-	github       *core.GitHubClient
+	ctx          context.Context // Cancelled on shutdown; in-flight work stops at the next step
+	hosts        *core.CodeHosts
 	claude       *core.ClaudeAgent
 	stateManager *core.StateManager
 	workingDir   string
+	config       types.Config
+	dryRun       *core.DryRun // Set when changes are reported instead of made
+	templates    map[string]*template.Template
+	instructions instructionsCache
+	repoFiles    repoFileCache
+	throttle     commentThrottle
 }
 
-// NewIssueAgent creates a new issue agent
-func NewIssueAgent(githubToken, claudeAPIKey, model, stateDBPath, workingDir string) (*IssueAgent, error) {
-	github := core.NewGitHubClient(githubToken)
-	claude := core.NewClaudeAgent(claudeAPIKey, model)
+// NewIssueAgent creates a new issue agent. Cancelling ctx aborts AI requests in flight
+// and leaves interrupted implementations to resume from their checkpoint.
+func NewIssueAgent(ctx context.Context, githubToken, claudeAPIKey string, config types.Config) (*IssueAgent, error) {
+	hosts := core.NewCodeHosts(core.NewGitHubClient(githubToken))
+	if config.GitHubLogin != "" {
+		hosts.SetGitHubLogin(config.GitHubLogin)
+	}
+	if len(config.GitLab.Repositories) > 0 {
+		gitlab := core.NewGitLabClient(config.GitLab.URL, config.GitLab.Token)
+		for _, repository := range config.GitLab.Repositories {
+			hosts.Register(repository, gitlab)
+		}
+	}
+	if len(config.Gitea.Repositories) > 0 {
+		gitea := core.NewGiteaClient(config.Gitea.URL, config.Gitea.Token)
+		for _, repository := range config.Gitea.Repositories {
+			hosts.Register(repository, gitea)
+		}
+	}
+
+	if err := checkGenerationConfig(config); err != nil {
+		return nil, err
+	}
+	provider, err := core.NewProvider(config.Provider, claudeAPIKey, providerOptions(config))
+	if err != nil {
+		return nil, err
+	}
+	claude := core.NewClaudeAgentWithProvider(provider, config.Model())
+	claude.SetGenerationParams(generationParams(config.Generation), stageGenerationParams(config))
+	claude.SetStageModels(stageModels(config))
+	claude.SetFallbackModels(config.Fallbacks())
+	claude.SetVisionModel(config.VisionModel)
+	claude.SetEmbeddingModel(config.Memory.EmbeddingModel)
+	claude.SetContextWindow(config.ContextWindow)
+	claude.SetPrices(modelPrices(config.Prices))
+	prompts, err := core.LoadPrompts(config.PromptsDir, config.Prompts)
+	if err != nil {
+		return nil, err
+	}
+	claude.SetPrompts(prompts)
+	claude.SetTranscripts(config.LLMTranscriptsDir, []string{
+		githubToken, claudeAPIKey, config.GitLab.Token, config.Gitea.Token,
+		config.WebhookSecret, config.StateDBKey, config.Azure.ClientSecret,
+	})
+	claude.SetStreaming(config.Streaming.Enabled)
+	claude.SetContext(ctx)
+
+	var dryRun *core.DryRun
+	if config.DryRun {
+		if dryRun, err = core.NewDryRun(config.DryRunDir); err != nil {
+			return nil, err
+		}
+		hosts.SetDryRun(dryRun)
+	}
+
+	templates, err := loadTemplates(config)
+	if err != nil {
+		return nil, err
+	}
 
-	stateManager, err := core.NewStateManager(stateDBPath)
+	stateManager, err := core.NewStateManager(config.StateDatabase(), config.StateDBKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create state manager: %w", err)
 	}
+	claude.SetCallRecorder(stateManager)
 
 	return &IssueAgent{
-		github:       github,
+		ctx:          ctx,
+		hosts:        hosts,
 		claude:       claude,
 		stateManager: stateManager,
-		workingDir:   workingDir,
+		workingDir:   config.WorkingDir,
+		config:       config,
+		dryRun:       dryRun,
+		templates:    templates,
 	}, nil
 }
 
 // HandleIssueAssignment handles when the agent is assigned to an issue
 func (ia *IssueAgent) HandleIssueAssignment(owner, repo string, issueNumber int) error {
-	fmt.Printf("🔍 Starting analysis of issue %s/%s #%d\n", owner, repo, issueNumber)
+	logger := core.IssueLogger(owner, repo, issueNumber)
+
+	// The webhook server and a sweeping poller may both pick up the same assignment
+	lock := ia.busyLock("analysis:" + issueKey(owner, repo, issueNumber))
+	if !lock.TryLock() {
+		logger.Debug("Issue is already being analyzed")
+		return nil
+	}
+	defer lock.Unlock()
+
+	logger.Info("🔍 Starting analysis of issue")
 
 	// Get the issue
-	issue, err := ia.github.GetIssue(owner, repo, issueNumber)
+	issue, err := ia.host(owner, repo).GetIssue(owner, repo, issueNumber)
 	if err != nil {
 		return fmt.Errorf("failed to get issue: %w", err)
 	}
@@ -55,6 +140,10 @@ func (ia *IssueAgent) HandleIssueAssignment(owner, repo string, issueNumber int)
 
 	// If no state, create a new one and load existing conversation from GitHub
 	if state == nil {
+		if reason := ia.issueFilteredOut(issue); reason != "" {
+			logger.Debug("Not taking on issue", "reason", reason)
+			return nil
+		}
 		state = &core.State{
 			Owner:       owner,
 			Repo:        repo,
@@ -62,14 +151,15 @@ func (ia *IssueAgent) HandleIssueAssignment(owner, repo string, issueNumber int)
 			Status:      "analyzing",
 			Conversation: []core.AgentMessage{},
 		}
+		core.IssuesProcessed.Inc(owner + "/" + repo)
 
 		// Fetch existing comments to build conversation history
-		fmt.Printf("📥 Fetching existing comments from GitHub to build context...\n")
-		comments, err := ia.github.ListIssueComments(owner, repo, issueNumber)
+		logger.Info("📥 Fetching existing comments from GitHub to build context")
+		comments, err := ia.host(owner, repo).ListIssueComments(owner, repo, issueNumber)
 		if err != nil {
-			fmt.Printf("⚠️  Warning: failed to fetch existing comments: %v\n", err)
+			logger.Warn("⚠️  Failed to fetch existing comments", "error", err)
 		} else if len(comments) > 0 {
-			fmt.Printf("📚 Found %d existing comment(s) to add to context\n", len(comments))
+			logger.Info("📚 Found existing comments to add to context", "count", len(comments))
 		}
 
 		// Build conversation from issue description and comments
@@ -82,10 +172,10 @@ func (ia *IssueAgent) HandleIssueAssignment(owner, repo string, issueNumber int)
 		})
 
 		// Add existing comments to conversation
-		botUsername, err := ia.github.GetAuthenticatedUser()
+		botUsername, err := ia.botLogin(owner, repo)
 		if err == nil && len(comments) > 0 {
 			for _, comment := range comments {
-				isBot := comment.GetUser().GetLogin() == botUsername.GetLogin()
+				isBot := comment.GetUser().GetLogin() == botUsername
 				role := "user"
 				if isBot {
 					role = "assistant"
@@ -96,10 +186,26 @@ func (ia *IssueAgent) HandleIssueAssignment(owner, repo string, issueNumber int)
 				})
 			}
 		}
+
+		// These comments are part of the conversation now, so they're never handled as new ones
+		ids := make([]int64, len(comments))
+		for i, comment := range comments {
+			ids[i] = comment.GetID()
+		}
+		if err := ia.stateManager.MarkCommentsProcessed(owner, repo, core.CommentKindIssue, ids); err != nil {
+			logger.Warn("⚠️  Failed to record existing comments", "error", err)
+		}
+	}
+
+	// Stop before spending more once a budget is exhausted
+	if paused, err := ia.pauseForBudget(state); paused || err != nil {
+		return err
 	}
 
 	// Analyze with full context
-	fmt.Printf("🤖 Sending issue to AI for analysis (with %d message(s) of context)...\n", len(state.Conversation))
+	logger.Info("🤖 Sending issue to AI for analysis", "messages", len(state.Conversation))
+	claude := ia.claudeFor(state)
+	ia.summarizeConversation(claude, state)
 
 	title := issue.GetTitle()
 	body := issue.GetBody()
@@ -107,14 +213,35 @@ func (ia *IssueAgent) HandleIssueAssignment(owner, repo string, issueNumber int)
 	var response string
 	var usage core.TokenUsage
 
+	// Screenshots in the issue body are passed to a vision-capable model
+	images := ia.issueImages(state, body)
+
+	// Recall similar past work in this repository, if long-term memory is enabled
+	memoryContext := ia.recallMemories(state, fmt.Sprintf("%s\n\n%s", title, body))
+
 	// If we have existing conversation, use it
 	if len(state.Conversation) > 1 {
 		// Already has conversation history, ask AI to confirm understanding
-		systemPrompt := "You are a helpful coding assistant. Review the entire conversation and determine if you have enough information to proceed with implementation. If you do, say so clearly. If not, ask specific clarifying questions."
-		response, usage, err = ia.claude.SendMessage(state.Conversation, systemPrompt)
+		systemPrompt := claude.SystemPrompt(core.PromptConfirm, core.PromptData{})
+		if memoryContext != "" {
+			systemPrompt += "\n\n" + memoryContext
+		}
+
+		// Attach images to a copy of the issue message so they are never persisted
+		conversation := state.Messages()
+		conversation[0].Images = images
+		response, usage, err = claude.SendMessageForStage(core.StageAnalysis, conversation, systemPrompt)
+		if err != nil && len(images) > 0 {
+			logger.Warn("⚠️  Image analysis failed, retrying with text only", "error", err)
+			response, usage, err = claude.SendMessageForStage(core.StageAnalysis, state.Messages(), systemPrompt)
+		}
 	} else {
 		// Fresh issue, analyze it
-		response, usage, err = ia.claude.AnalyzeIssue(title, body)
+		analysisBody := body
+		if memoryContext != "" {
+			analysisBody += "\n\n---\n\n" + memoryContext
+		}
+		response, usage, err = claude.AnalyzeIssueWithImages(title, analysisBody, images)
 		state.Conversation = append(state.Conversation, core.AgentMessage{
 			Role:    "assistant",
 			Content: response,
@@ -124,12 +251,10 @@ func (ia *IssueAgent) HandleIssueAssignment(owner, repo string, issueNumber int)
 	if err != nil {
 		return fmt.Errorf("failed to analyze issue: %w", err)
 	}
-	fmt.Printf("✅ AI analysis complete\n")
+	logger.Info("✅ AI analysis complete")
 
 	// Track token usage
-	state.TotalInputTokens += usage.InputTokens
-	state.TotalOutputTokens += usage.OutputTokens
-	state.TotalCost += usage.Cost
+	state.AddUsage(usage)
 
 	// Add AI response to conversation if not already there
 	if len(state.Conversation) > 0 && state.Conversation[len(state.Conversation)-1].Content != response {
@@ -142,21 +267,25 @@ func (ia *IssueAgent) HandleIssueAssignment(owner, repo string, issueNumber int)
 	// Post the analysis as a comment (only if it's actually new analysis, not just reviewing existing conversation)
 	shouldComment := len(state.Conversation) <= 2 // Only the initial issue and bot response
 
-	// Check if response is asking questions or confirming readiness
-	isAskingQuestion := isResponseAskingQuestions(response)
+	// Decide from a structured classification whether questions are still open
+	ready, questions := ia.readyToImplement(claude, state)
 
 	if shouldComment {
-		commentBody := fmt.Sprintf("👋 Hi! I've been assigned to this issue. Here's my understanding:\n\n%s", response)
-		if err := ia.github.CreateIssueComment(owner, repo, issueNumber, commentBody); err != nil {
+		data := issueTemplateData(owner, repo, issue)
+		data.Summary, data.Default = response, analysisComment(response)
+		if err := ia.postComment(owner, repo, issueNumber, ia.render(templateAnalysis, data)); err != nil {
 			return fmt.Errorf("failed to create comment: %w", err)
 		}
 	}
 
 	// Determine next status based on response
-	if isAskingQuestion {
-		state.Status = "waiting_for_clarification"
-	} else {
+	if ready {
 		state.Status = "ready_to_implement"
+	} else {
+		state.Status = "waiting_for_clarification"
+		if ia.clarificationExhausted(state) {
+			return ia.escalateClarification(state, questions)
+		}
 	}
 
 	// Save state
@@ -172,9 +301,15 @@ func (ia *IssueAgent) HandleIssueAssignment(owner, repo string, issueNumber int)
 	return nil
 }
 
-// HandleIssueComment handles new comments on an issue the agent is working on
+// HandleIssueComment handles a new comment on an issue the agent is working on
 func (ia *IssueAgent) HandleIssueComment(owner, repo string, issueNumber int, commentBody string) error {
-	fmt.Printf("💬 Processing new comment on issue %s/%s #%d\n", owner, repo, issueNumber)
+	return ia.HandleIssueComments(owner, repo, issueNumber, []string{commentBody})
+}
+
+// HandleIssueComments handles a batch of new comments with a single consolidated response
+func (ia *IssueAgent) HandleIssueComments(owner, repo string, issueNumber int, commentBodies []string) error {
+	logger := core.IssueLogger(owner, repo, issueNumber)
+	logger.Info("💬 Processing new comments", "count", len(commentBodies))
 
 	// Get current state
 	state, err := ia.stateManager.GetState(owner, repo, issueNumber)
@@ -186,24 +321,67 @@ func (ia *IssueAgent) HandleIssueComment(owner, repo string, issueNumber int, co
 		return fmt.Errorf("no state found for this issue")
 	}
 
-	// Add the comment to conversation history
-	state.Conversation = append(state.Conversation, core.AgentMessage{
-		Role:    "user",
-		Content: commentBody,
-	})
+	// Aborted, budget-paused, held, previewed and abandoned issues stay quiet until a maintainer command
+	if state.Status == "aborted" || state.Status == "budget_exceeded" || state.Status == "held" || state.Status == "previewing" || state.Status == "abandoned" {
+		return nil
+	}
+
+	// Answers given after escalating are kept for when a maintainer resumes, without a reply
+	if state.Status == "needs_human" {
+		for _, commentBody := range commentBodies {
+			state.Conversation = append(state.Conversation, core.AgentMessage{Role: "user", Content: commentBody})
+		}
+		if err := ia.stateManager.SaveState(state); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+		return nil
+	}
+
+	// An /approve comment starts implementation instead of getting a reply
+	if state.Status == "waiting_for_approval" && containsApproval(commentBodies) {
+		approved, err := ia.CheckApproval(owner, repo, issueNumber)
+		if err != nil || approved {
+			return err
+		}
+		note := fmt.Sprintf("Only collaborators with %s access can approve the plan.", ia.approvalPermission())
+		if err := ia.postComment(owner, repo, issueNumber, note); err != nil {
+			return fmt.Errorf("failed to create comment: %w", err)
+		}
+		return nil
+	}
+
+	// A reply resets the stale clock and revives parked conversations
+	state.ReminderSentAt = nil
+	if state.Status == "stale" {
+		state.Status = "waiting_for_clarification"
+	}
+
+	// Add the comments to conversation history
+	for _, commentBody := range commentBodies {
+		state.Conversation = append(state.Conversation, core.AgentMessage{
+			Role:    "user",
+			Content: commentBody,
+		})
+	}
+
+	// The comments are kept in the conversation if the budget pauses the issue here
+	if paused, err := ia.pauseForBudget(state); paused || err != nil {
+		return err
+	}
 
 	// Get Claude's response
-	fmt.Printf("🤖 Sending comment to AI for response...\n")
-	response, usage, err := ia.claude.SendMessage(state.Conversation, "You are a helpful coding assistant working on a GitHub issue. Respond to the user's comment.")
+	logger.Info("🤖 Sending comments to AI for response")
+	claude := ia.claudeFor(state)
+	systemPrompt := claude.SystemPrompt(core.PromptReply, core.PromptData{Comments: len(commentBodies)})
+	ia.summarizeConversation(claude, state)
+	response, usage, err := claude.SendMessage(state.Messages(), systemPrompt)
 	if err != nil {
 		return fmt.Errorf("failed to get response: %w", err)
 	}
-	fmt.Printf("✅ AI response generated\n")
+	logger.Info("✅ AI response generated")
 
 	// Track token usage
-	state.TotalInputTokens += usage.InputTokens
-	state.TotalOutputTokens += usage.OutputTokens
-	state.TotalCost += usage.Cost
+	state.AddUsage(usage)
 
 	// Update conversation
 	state.Conversation = append(state.Conversation, core.AgentMessage{
@@ -212,20 +390,24 @@ func (ia *IssueAgent) HandleIssueComment(owner, repo string, issueNumber int, co
 	})
 
 	// Post response as comment
-	if err := ia.github.CreateIssueComment(owner, repo, issueNumber, response); err != nil {
+	if err := ia.postComment(owner, repo, issueNumber, replyComment(response)); err != nil {
 		return fmt.Errorf("failed to create comment: %w", err)
 	}
 
 	// Check if we're ready to implement now
 	if state.Status == "waiting_for_clarification" {
 		// Check if the response is still asking questions or ready to proceed
-		if !isResponseAskingQuestions(response) {
+		ready, questions := ia.readyToImplement(claude, state)
+		if ready {
 			state.Status = "ready_to_implement"
 			if err := ia.stateManager.SaveState(state); err != nil {
 				return fmt.Errorf("failed to save state: %w", err)
 			}
 			return ia.StartImplementation(owner, repo, issueNumber)
 		}
+		if ia.clarificationExhausted(state) {
+			return ia.escalateClarification(state, questions)
+		}
 	}
 
 	// Save state
@@ -238,512 +420,172 @@ func (ia *IssueAgent) HandleIssueComment(owner, repo string, issueNumber int, co
 
 // StartImplementationWithSandbox implements the solution using a local sandbox
 func (ia *IssueAgent) StartImplementationWithSandbox(owner, repo string, issueNumber int) error {
-	fmt.Printf("🚀 Starting implementation for issue %s/%s #%d (using sandbox)\n", owner, repo, issueNumber)
+	logger := core.IssueLogger(owner, repo, issueNumber)
 
-	state, err := ia.stateManager.GetState(owner, repo, issueNumber)
+	// Another agent sharing the database may already be implementing it
+	release, ok, err := ia.stateManager.TryLockIssue(owner, repo, issueNumber)
 	if err != nil {
-		return fmt.Errorf("failed to get state: %w", err)
-	}
-
-	if state == nil {
-		return fmt.Errorf("no state found")
-	}
-
-	// Update status
-	state.Status = "implementing"
-	if err := ia.stateManager.SaveState(state); err != nil {
-		return fmt.Errorf("failed to save state: %w", err)
-	}
-
-	// Notify that we're starting implementation
-	comment := "🚀 Great! I have a clear understanding now. I'll clone the repository, make changes, and run tests before creating a pull request."
-	if err := ia.github.CreateIssueComment(owner, repo, issueNumber, comment); err != nil {
-		return fmt.Errorf("failed to create comment: %w", err)
-	}
-
-	// Get repository info
-	repository, err := ia.github.GetRepository(owner, repo)
-	if err != nil {
-		return fmt.Errorf("failed to get repository: %w", err)
-	}
-
-	language := repository.GetLanguage()
-	defaultBranch := repository.GetDefaultBranch()
-	if defaultBranch == "" {
-		defaultBranch = "main"
-	}
-
-	// Create branch name
-	branchName := fmt.Sprintf("nytebubo/issue-%d", issueNumber)
-	if state.BranchName != "" {
-		branchName = state.BranchName
-	} else {
-		state.BranchName = branchName
-		if err := ia.stateManager.SaveState(state); err != nil {
-			return fmt.Errorf("failed to save state: %w", err)
-		}
+		return err
 	}
-
-	// Create sandbox
-	githubToken := ia.github.GetToken()
-	sandbox, err := core.NewSandbox(ia.workingDir, owner, repo, issueNumber, githubToken)
-	if err != nil {
-		return fmt.Errorf("failed to create sandbox: %w", err)
+	if !ok {
+		logger.Info("⏭️  Another agent is working on this issue")
+		return nil
 	}
+	defer release()
 
-	// Ensure cleanup happens
-	defer func() {
-		if err := sandbox.Cleanup(); err != nil {
-			fmt.Printf("⚠️  Warning: failed to cleanup sandbox: %v\n", err)
-		}
-	}()
-
-	// Clone repository
-	if err := sandbox.CloneRepo(); err != nil {
-		return fmt.Errorf("failed to clone repo: %w", err)
+	// Within this agent, the webhook server and a sweeping poller may both start it
+	lock := ia.busyLock("implement:" + issueKey(owner, repo, issueNumber))
+	if !lock.TryLock() {
+		logger.Info("⏭️  Issue is already being implemented")
+		return nil
 	}
+	defer lock.Unlock()
 
-	// Create branch
-	if err := sandbox.CreateBranch(branchName); err != nil {
-		return fmt.Errorf("failed to create branch: %w", err)
-	}
+	logger.Info("🚀 Starting implementation (using sandbox)")
 
-	// Get repo context for AI
-	files, err := sandbox.ListFiles()
+	state, err := ia.stateManager.GetState(owner, repo, issueNumber)
 	if err != nil {
-		return fmt.Errorf("failed to list files: %w", err)
+		return fmt.Errorf("failed to get state: %w", err)
 	}
 
-	detectedLang, _ := sandbox.DetectLanguage()
-	if language == "" {
-		language = detectedLang
+	if state == nil {
+		return fmt.Errorf("no state found")
 	}
 
-	repoContext := fmt.Sprintf("Repository: %s/%s\nLanguage: %s\nExisting files: %s",
-		owner, repo, language, strings.Join(files, ", "))
-
-	// Generate code with full context
-	task := fmt.Sprintf("Implement the changes for issue #%d", issueNumber)
-	fmt.Printf("🤖 Generating code with AI (with full repo context)...\n")
-
-	codeResponse, usage, err := ia.claude.GenerateCode(task, repoContext, language, state.Conversation)
-	if err != nil {
-		return fmt.Errorf("failed to generate code: %w", err)
+	if paused, err := ia.pauseForBudget(state); paused || err != nil {
+		return err
 	}
 
-	// Track token usage
-	state.TotalInputTokens += usage.InputTokens
-	state.TotalOutputTokens += usage.OutputTokens
-	state.TotalCost += usage.Cost
-
-	// Parse the code response and extract file changes
-	fileChanges := parseCodeChanges(codeResponse)
-	summary := extractSummary(codeResponse, fileChanges)
-
-	if len(fileChanges) == 0 {
-		fmt.Printf("⚠️  Warning: No file changes detected from AI response\n")
-		comment := fmt.Sprintf("⚠️ I attempted to implement this issue, but couldn't generate files in the correct format.\n\nHere's what I tried to generate:\n\n%s\n\n---\n\nCould you please review this and let me know if you need me to try again?\n\n🤖 NyteBubo", summary)
-		if err := ia.github.CreateIssueComment(owner, repo, issueNumber, comment); err != nil {
-			return fmt.Errorf("failed to create comment: %w", err)
-		}
-
-		state.Status = "waiting_for_clarification"
-		if err := ia.stateManager.SaveState(state); err != nil {
-			return fmt.Errorf("failed to save state: %w", err)
-		}
-		return nil
-	}
-
-	// Write files to sandbox
-	fmt.Printf("📝 Writing %d file(s) to sandbox...\n", len(fileChanges))
-	for filePath, content := range fileChanges {
-		fmt.Printf("  - Writing %s\n", filePath)
-		if err := sandbox.WriteFile(filePath, content); err != nil {
-			return fmt.Errorf("failed to write file %s: %w", filePath, err)
-		}
+	err = ia.implementInSandbox(state)
+	if err != nil && ia.ctx.Err() != nil {
+		ia.markInterrupted(state)
 	}
+	return err
+}
 
-	// Try to build and test (with retry for AI fixes)
-	maxAttempts := 10
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		fmt.Printf("\n🔍 Verification attempt %d/%d\n", attempt, maxAttempts)
-
-		buildOutput, testOutput, verifyErr := sandbox.Verify()
-
-		if verifyErr == nil {
-			fmt.Printf("✅ All checks passed!\n")
-			break
-		}
-
-		// Tests or build failed
-		fmt.Printf("❌ Verification failed: %v\n", verifyErr)
-
-		if attempt == maxAttempts {
-			// Out of retries - create PR anyway but note the failures
-			summary += fmt.Sprintf("\n\n⚠️ **Note**: Build/test verification failed after %d attempts. Please review carefully.\n\n", maxAttempts)
-			summary += fmt.Sprintf("**Build output:**\n```\n%s\n```\n\n", buildOutput)
-			summary += fmt.Sprintf("**Test output:**\n```\n%s\n```", testOutput)
-			break
-		}
-
-		// Ask AI to fix the issues
-		fmt.Printf("🤖 Asking AI to fix the issues...\n")
-
-		fixPrompt := fmt.Sprintf("The code has build or test failures. Please fix them.\n\nBuild output:\n```\n%s\n```\n\nTest output:\n```\n%s\n```\n\nError: %v\n\nPlease provide the corrected files.", buildOutput, testOutput, verifyErr)
-
-		state.Conversation = append(state.Conversation, core.AgentMessage{
-			Role:    "user",
-			Content: fixPrompt,
-		})
-
-		fixResponse, fixUsage, err := ia.claude.GenerateCode("Fix build/test failures", repoContext, language, state.Conversation)
+// StartImplementation begins implementing the solution
+func (ia *IssueAgent) StartImplementation(owner, repo string, issueNumber int) error {
+	// Wait for a maintainer to approve the plan first
+	if ia.config.ApprovalRequired {
+		state, err := ia.stateManager.GetState(owner, repo, issueNumber)
 		if err != nil {
-			fmt.Printf("⚠️  Failed to get fix from AI: %v\n", err)
-			break
-		}
-
-		state.TotalInputTokens += fixUsage.InputTokens
-		state.TotalOutputTokens += fixUsage.OutputTokens
-		state.TotalCost += fixUsage.Cost
-
-		// Parse and apply fixes
-		fixedFiles := parseCodeChanges(fixResponse)
-		if len(fixedFiles) == 0 {
-			fmt.Printf("⚠️  AI didn't provide file fixes\n")
-			break
+			return fmt.Errorf("failed to get state: %w", err)
 		}
-
-		fmt.Printf("📝 Applying %d fix(es)...\n", len(fixedFiles))
-		for filePath, content := range fixedFiles {
-			fmt.Printf("  - Fixing %s\n", filePath)
-			if err := sandbox.WriteFile(filePath, content); err != nil {
-				fmt.Printf("⚠️  Failed to write fixed file: %v\n", err)
+		if state != nil && state.ApprovedBy == "" {
+			if state.Status == "waiting_for_approval" {
+				return nil
 			}
+			return ia.requestApproval(state)
 		}
 	}
 
-	// Commit changes
-	commitMsg := fmt.Sprintf("Implement solution for issue #%d\n\n%s", issueNumber, summary)
-	if err := sandbox.Commit(commitMsg); err != nil {
-		return fmt.Errorf("failed to commit: %w", err)
-	}
-
-	// Push to remote
-	if err := sandbox.Push(branchName); err != nil {
-		return fmt.Errorf("failed to push: %w", err)
-	}
+	// Use sandbox implementation
+	return ia.StartImplementationWithSandbox(owner, repo, issueNumber)
+}
 
-	// Get issue for PR
-	issue, err := ia.github.GetIssue(owner, repo, issueNumber)
+// OwnsPullRequest reports whether a pull request was opened by the agent for an issue it tracks
+func (ia *IssueAgent) OwnsPullRequest(owner, repo string, prNumber int) (bool, error) {
+	state, err := ia.stateManager.GetStateByPR(owner, repo, prNumber)
 	if err != nil {
-		return fmt.Errorf("failed to get issue: %w", err)
+		return false, fmt.Errorf("failed to get state: %w", err)
 	}
+	return state != nil, nil
+}
 
-	// Create PR
-	prTitle := fmt.Sprintf("Fix: %s", issue.GetTitle())
-	prBody := fmt.Sprintf("Fixes #%d\n\n%s\n\n---\n\n🤖 This PR was automatically generated and tested by NyteBubo", issueNumber, summary)
-
-	fmt.Printf("📬 Creating pull request...\n")
-	pr, err := ia.github.CreatePullRequest(owner, repo, prTitle, prBody, branchName, defaultBranch)
+// PullRequestsForBranches returns the open bot pull requests whose branch is one of branches
+func (ia *IssueAgent) PullRequestsForBranches(owner, repo string, branches []string) ([]int, error) {
+	states, err := ia.stateManager.ListOpenPRStates(owner, repo)
 	if err != nil {
-		return fmt.Errorf("failed to create PR: %w", err)
-	}
-	fmt.Printf("✅ Pull request #%d created successfully!\n", pr.GetNumber())
-
-	// Update state
-	prNumber := pr.GetNumber()
-	state.PRNumber = &prNumber
-	state.Status = "pr_created"
-	if err := ia.stateManager.SaveState(state); err != nil {
-		return fmt.Errorf("failed to save state: %w", err)
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
 	}
 
-	// Comment on the issue with PR link
-	prComment := fmt.Sprintf("✅ I've created a pull request with tested changes: #%d", prNumber)
-	if err := ia.github.CreateIssueComment(owner, repo, issueNumber, prComment); err != nil {
-		return fmt.Errorf("failed to create comment: %w", err)
+	var prNumbers []int
+	for _, state := range states {
+		if state.PRNumber != nil && slices.Contains(branches, state.BranchName) {
+			prNumbers = append(prNumbers, *state.PRNumber)
+		}
 	}
-
-	return nil
+	return prNumbers, nil
 }
 
-// StartImplementation begins implementing the solution
-func (ia *IssueAgent) StartImplementation(owner, repo string, issueNumber int) error {
-	// Use sandbox implementation
-	return ia.StartImplementationWithSandbox(owner, repo, issueNumber)
+// HandlePRComments handles a batch of PR comments as a single round of review feedback
+func (ia *IssueAgent) HandlePRComments(owner, repo string, prNumber int, commentBodies []string) error {
+	return ia.HandlePRComment(owner, repo, prNumber, strings.Join(commentBodies, "\n\n---\n\n"))
 }
 
-// StartImplementationLegacy is the old API-based implementation (kept for reference)
-func (ia *IssueAgent) StartImplementationLegacy(owner, repo string, issueNumber int) error {
-	fmt.Printf("🚀 Starting implementation for issue %s/%s #%d\n", owner, repo, issueNumber)
-
-	state, err := ia.stateManager.GetState(owner, repo, issueNumber)
+// HandlePRComment handles comments on the PR
+func (ia *IssueAgent) HandlePRComment(owner, repo string, prNumber int, commentBody string) error {
+	// Find the issue from the recorded PR number, since a pr_body template may leave out
+	// "Fixes #N"; older pull requests are matched by the first issue their body closes
+	state, err := ia.stateManager.GetStateByPR(owner, repo, prNumber)
 	if err != nil {
 		return fmt.Errorf("failed to get state: %w", err)
 	}
-
 	if state == nil {
-		return fmt.Errorf("no state found")
-	}
-
-	// Update status
-	state.Status = "implementing"
-	if err := ia.stateManager.SaveState(state); err != nil {
-		return fmt.Errorf("failed to save state: %w", err)
-	}
-
-	// Notify that we're starting implementation
-	comment := "🚀 Great! I have a clear understanding now. I'll start working on this and create a pull request shortly."
-	if err := ia.github.CreateIssueComment(owner, repo, issueNumber, comment); err != nil {
-		return fmt.Errorf("failed to create comment: %w", err)
-	}
-
-	// Get repository info
-	repository, err := ia.github.GetRepository(owner, repo)
-	if err != nil {
-		return fmt.Errorf("failed to get repository: %w", err)
-	}
-
-	language := repository.GetLanguage()
-	defaultBranch := repository.GetDefaultBranch()
-	if defaultBranch == "" {
-		defaultBranch = "main" // Default to main if not set
-	}
-
-	// Check if we already have a branch (retry scenario)
-	var branchName string
-	if state.BranchName != "" {
-		// Reuse existing branch from previous attempt
-		branchName = state.BranchName
-		fmt.Printf("♻️  Reusing existing branch: %s\n", branchName)
-	} else {
-		// Create a new branch name
-		branchName = fmt.Sprintf("nytebubo/issue-%d", issueNumber)
-		state.BranchName = branchName
-
-		// Try to create branch - if repo is empty, we'll commit directly to main
-		fmt.Printf("🌿 Creating branch: %s\n", branchName)
-		err = ia.github.CreateBranch(owner, repo, branchName, defaultBranch)
+		pr, err := ia.host(owner, repo).GetPullRequest(owner, repo, prNumber)
 		if err != nil {
-			// Check if repo is empty (409 error)
-			if strings.Contains(err.Error(), "409") || strings.Contains(err.Error(), "empty") {
-				fmt.Printf("📝 Repository is empty - will create initial commit on %s instead of branch\n", defaultBranch)
-				branchName = defaultBranch // Commit directly to main
-				state.BranchName = branchName
-			} else {
-				return fmt.Errorf("failed to create branch: %w", err)
-			}
-		}
-
-		// Save state immediately after creating branch to persist BranchName
-		if err := ia.stateManager.SaveState(state); err != nil {
-			return fmt.Errorf("failed to save state after branch creation: %w", err)
-		}
-	}
-
-	// Get code generation from Claude with retry logic for rate limits
-	task := fmt.Sprintf("Implement the changes for issue #%d", issueNumber)
-	repoContext := fmt.Sprintf("Repository: %s/%s, Language: %s", owner, repo, language)
-
-	fmt.Printf("🤖 Generating code with AI...\n")
-
-	// Backoff pattern: 60s, 120s, 240s, then 240s forever
-	backoffDurations := []time.Duration{60 * time.Second, 120 * time.Second, 240 * time.Second}
-	maxBackoff := 240 * time.Second
-
-	var codeResponse string
-	var usage core.TokenUsage
-
-	attempt := 0
-	for {
-		codeResponse, usage, err = ia.claude.GenerateCode(task, repoContext, language, state.Conversation)
-		if err == nil {
-			// Success!
-			break
+			return fmt.Errorf("failed to get PR: %w", err)
 		}
-
-		// Check if it's a retryable error (rate limit or server error)
-		isRateLimit := strings.Contains(err.Error(), "429") ||
-			strings.Contains(strings.ToLower(err.Error()), "rate limit") ||
-			strings.Contains(strings.ToLower(err.Error()), "rate-limit")
-
-		isServerError := strings.Contains(err.Error(), "500") ||
-			strings.Contains(err.Error(), "502") ||
-			strings.Contains(err.Error(), "503") ||
-			strings.Contains(err.Error(), "504") ||
-			strings.Contains(strings.ToLower(err.Error()), "internal server error") ||
-			strings.Contains(strings.ToLower(err.Error()), "bad gateway") ||
-			strings.Contains(strings.ToLower(err.Error()), "service unavailable") ||
-			strings.Contains(strings.ToLower(err.Error()), "gateway timeout")
-
-		isRetryable := isRateLimit || isServerError
-
-		if !isRetryable {
-			// Non-retryable error, fail immediately
-			return fmt.Errorf("failed to generate code: %w", err)
+		issueNumbers := extractIssueNumbers(pr.GetBody())
+		if len(issueNumbers) == 0 {
+			return fmt.Errorf("could not find issue number in PR body")
 		}
-
-		errorType := "Rate limit"
-		if isServerError {
-			errorType = "Server error"
-		}
-
-		// Calculate wait duration (cap at maxBackoff for attempts >= 3)
-		var waitDuration time.Duration
-		if attempt < len(backoffDurations) {
-			waitDuration = backoffDurations[attempt]
-		} else {
-			waitDuration = maxBackoff
-		}
-
-		attempt++
-		fmt.Printf("⏳ %s detected, waiting %v before retry (attempt %d)...\n", errorType, waitDuration, attempt+1)
-		time.Sleep(waitDuration)
-		fmt.Printf("🔄 Retrying code generation (attempt %d)...\n", attempt+1)
-	}
-
-	fmt.Printf("✅ Code generated successfully\n")
-
-	// Track token usage
-	state.TotalInputTokens += usage.InputTokens
-	state.TotalOutputTokens += usage.OutputTokens
-	state.TotalCost += usage.Cost
-
-	// Parse the code response and extract file changes
-	fileChanges := parseCodeChanges(codeResponse)
-
-	// Extract a human-readable summary for PR/comments
-	summary := extractSummary(codeResponse, fileChanges)
-
-	// Validate that we got file changes
-	if len(fileChanges) == 0 {
-		fmt.Printf("⚠️  Warning: No file changes detected from AI response\n")
-		fmt.Printf("📝 AI Response format was invalid. Posting response and requesting user review.\n")
-
-		// Post the AI's response as a comment for user to review
-		comment := fmt.Sprintf("⚠️ I attempted to implement this issue, but couldn't generate files in the correct format.\n\nHere's what I tried to generate:\n\n%s\n\n---\n\nCould you please review this and let me know if you need me to try again with different instructions?\n\n🤖 NyteBubo", codeResponse)
-		if err := ia.github.CreateIssueComment(owner, repo, issueNumber, comment); err != nil {
-			return fmt.Errorf("failed to create comment: %w", err)
-		}
-
-		// Reset status to waiting for clarification
-		state.Status = "waiting_for_clarification"
-		if err := ia.stateManager.SaveState(state); err != nil {
-			return fmt.Errorf("failed to save state: %w", err)
-		}
-
-		return nil
-	}
-
-	// Apply the changes to the branch
-	fmt.Printf("📝 Applying %d file change(s) to branch %s\n", len(fileChanges), branchName)
-	for filePath, content := range fileChanges {
-		fmt.Printf("  - Updating %s\n", filePath)
-		if err := ia.github.CreateOrUpdateFile(owner, repo, filePath, fmt.Sprintf("Update %s for issue #%d", filePath, issueNumber), content, branchName, nil); err != nil {
-			return fmt.Errorf("failed to update file %s: %w", filePath, err)
+		if state, err = ia.stateManager.GetState(owner, repo, issueNumbers[0]); err != nil {
+			return fmt.Errorf("failed to get state: %w", err)
 		}
 	}
 
-	// Create PR or comment about direct commit
-	issue, err := ia.github.GetIssue(owner, repo, issueNumber)
-	if err != nil {
-		return fmt.Errorf("failed to get issue: %w", err)
+	if state == nil {
+		return fmt.Errorf("no state found")
 	}
 
-	// If we committed directly to main (empty repo), just comment on the issue
-	if branchName == defaultBranch {
-		fmt.Printf("✅ Changes committed directly to %s (empty repository)\n", defaultBranch)
-		state.Status = "completed"
-		if err := ia.stateManager.SaveState(state); err != nil {
-			return fmt.Errorf("failed to save state: %w", err)
-		}
-
-		comment := fmt.Sprintf("✅ I've committed the changes directly to the `%s` branch since the repository was empty.\n\n%s\n\nClosing this issue as completed.\n\n---\n\n🤖 Changes made by NyteBubo", defaultBranch, summary)
-		if err := ia.github.CreateIssueComment(owner, repo, issueNumber, comment); err != nil {
-			return fmt.Errorf("failed to create comment: %w", err)
-		}
-
-		// Close the issue
-		closed := "closed"
-		issueUpdate := &github.IssueRequest{State: &closed}
-		if _, _, err := ia.github.GetClient().Issues.Edit(ia.github.GetContext(), owner, repo, issueNumber, issueUpdate); err != nil {
-			fmt.Printf("⚠️  Warning: failed to close issue: %v\n", err)
-		}
-
+	// Feedback is only addressed while the pull request is under review; a finished,
+	// stopped or held issue keeps its status
+	switch state.Status {
+	case "aborted", "budget_exceeded", "completed", "abandoned", "held", "previewing", "needs_human":
+		state.Logger().Info("⏭️  Ignoring PR feedback", "pr", prNumber, "status", state.Status)
 		return nil
 	}
+	if paused, err := ia.pauseForBudget(state); paused || err != nil {
+		return err
+	}
 
-	// Normal PR flow
-	prTitle := fmt.Sprintf("Fix: %s", issue.GetTitle())
-	prBody := fmt.Sprintf("Fixes #%d\n\n%s\n\n---\n\n🤖 This PR was automatically generated by NyteBubo", issueNumber, summary)
+	// Update status
+	state.Status = "reviewing"
 
-	fmt.Printf("📬 Creating pull request...\n")
-	pr, err := ia.github.CreatePullRequest(owner, repo, prTitle, prBody, branchName, defaultBranch)
-	if err != nil {
-		return fmt.Errorf("failed to create PR: %w", err)
+	commitMessage := fmt.Sprintf("Address review feedback for issue #%d", state.IssueNumber)
+	if _, _, err := ia.addressFeedback(state, "Review feedback", commentBody, commitMessage); err != nil {
+		return err
 	}
-	fmt.Printf("✅ Pull request #%d created successfully!\n", pr.GetNumber())
 
-	// Update state
-	prNumber := pr.GetNumber()
-	state.PRNumber = &prNumber
-	state.Status = "pr_created"
+	// Save state
 	if err := ia.stateManager.SaveState(state); err != nil {
 		return fmt.Errorf("failed to save state: %w", err)
 	}
 
-	// Comment on the issue with PR link
-	prComment := fmt.Sprintf("✅ I've created a pull request: #%d", prNumber)
-	if err := ia.github.CreateIssueComment(owner, repo, issueNumber, prComment); err != nil {
-		return fmt.Errorf("failed to create comment: %w", err)
-	}
-
 	return nil
 }
 
-// HandlePRComment handles comments on the PR
-func (ia *IssueAgent) HandlePRComment(owner, repo string, prNumber int, commentBody string) error {
-	// Find the issue number from PR (we'll need to store this mapping)
-	// For now, we'll extract from the PR body
-	pr, err := ia.github.GetPullRequest(owner, repo, prNumber)
-	if err != nil {
-		return fmt.Errorf("failed to get PR: %w", err)
-	}
-
-	// Extract issue number from PR body
-	issueNumber := extractIssueNumber(pr.GetBody())
-	if issueNumber == 0 {
-		return fmt.Errorf("could not find issue number in PR body")
-	}
-
-	state, err := ia.stateManager.GetState(owner, repo, issueNumber)
-	if err != nil {
-		return fmt.Errorf("failed to get state: %w", err)
-	}
-
-	if state == nil {
-		return fmt.Errorf("no state found")
-	}
-
-	// Update status
-	state.Status = "reviewing"
-
-	// Add comment to conversation
+// addressFeedback asks the AI to act on feedback about the pull request (labelled in the
+// conversation with kind) and pushes its changes to the PR branch as a single commit.
+// It returns the AI's response and whether any changes were pushed.
+func (ia *IssueAgent) addressFeedback(state *core.State, kind, feedback, commitMessage string) (string, bool, error) {
+	// Add feedback to conversation
 	state.Conversation = append(state.Conversation, core.AgentMessage{
 		Role:    "user",
-		Content: fmt.Sprintf("Review feedback: %s", commentBody),
+		Content: fmt.Sprintf("%s: %s", kind, feedback),
 	})
 
 	// Get updated code from Claude
-	response, usage, err := ia.claude.ReviewFeedback(commentBody, "", state.Conversation)
+	claude := ia.claudeFor(state)
+	ia.summarizeConversation(claude, state)
+	response, usage, err := claude.ReviewFeedback(feedback, "", state.Messages())
 	if err != nil {
-		return fmt.Errorf("failed to get review response: %w", err)
+		return "", false, fmt.Errorf("failed to get review response: %w", err)
 	}
 
 	// Track token usage
-	state.TotalInputTokens += usage.InputTokens
-	state.TotalOutputTokens += usage.OutputTokens
-	state.TotalCost += usage.Cost
+	state.AddUsage(usage)
 
 	// Update conversation
 	state.Conversation = append(state.Conversation, core.AgentMessage{
@@ -751,17 +593,58 @@ func (ia *IssueAgent) HandlePRComment(owner, repo string, prNumber int, commentB
 		Content: response,
 	})
 
-	// Parse and apply changes
-	fileChanges := parseCodeChanges(response)
-	for filePath, content := range fileChanges {
-		if err := ia.github.CreateOrUpdateFile(owner, repo, filePath, fmt.Sprintf("Address review feedback for issue #%d", issueNumber), content, state.BranchName, nil); err != nil {
-			return fmt.Errorf("failed to update file %s: %w", filePath, err)
+	changes := parseChanges(response)
+	if changes.empty() {
+		return response, false, nil
+	}
+	if err := ia.pushReviewChanges(state, changes, commitMessage); err != nil {
+		return response, false, err
+	}
+	return response, true, nil
+}
+
+// pushReviewChanges applies review changes in a sandbox checkout of the PR branch and pushes one commit
+func (ia *IssueAgent) pushReviewChanges(state *core.State, changes codeChanges, commitMessage string) error {
+	if state.BranchName == "" {
+		return fmt.Errorf("no branch recorded for issue #%d", state.IssueNumber)
+	}
+
+	sandbox, err := ia.newSandbox(state.Owner, state.Repo, state.IssueNumber)
+	if err != nil {
+		return fmt.Errorf("failed to create sandbox: %w", err)
+	}
+	defer func() {
+		if err := sandbox.Cleanup(); err != nil {
+			sandbox.Logger().Warn("⚠️  Failed to clean up sandbox", "error", err)
 		}
+	}()
+
+	if err := sandbox.CloneRepo(); err != nil {
+		return fmt.Errorf("failed to clone repo: %w", err)
+	}
+	if err := sandbox.CheckoutBranch(state.BranchName); err != nil {
+		return err
 	}
 
-	// Save state
-	if err := ia.stateManager.SaveState(state); err != nil {
-		return fmt.Errorf("failed to save state: %w", err)
+	sandbox.Logger().Info("📝 Applying review changes", "files", len(changes.paths()))
+	failed, err := applyChanges(sandbox, changes)
+	if err != nil {
+		return err
+	}
+	for path, editErr := range failed {
+		sandbox.Logger().Warn("⚠️  Skipping review edits", "path", path, "error", editErr)
+	}
+
+	if err := sandbox.Commit(commitMessage); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	if err := sandbox.Push(state.BranchName); err != nil {
+		if findings := secretsFound(err); findings != nil && state.PRNumber != nil {
+			if commentErr := ia.postPRComment(state.Owner, state.Repo, *state.PRNumber, secretsComment(findings)); commentErr != nil {
+				sandbox.Logger().Warn("⚠️  Failed to comment on PR", "pr", *state.PRNumber, "error", commentErr)
+			}
+		}
+		return fmt.Errorf("failed to push: %w", err)
 	}
 
 	return nil
@@ -775,18 +658,18 @@ func parseCodeChanges(response string) map[string]string {
 	// First, try to parse as JSON (structured output)
 	changes = tryParseJSON(response)
 	if len(changes) > 0 {
-		fmt.Printf("✓ Parsed %d file(s) from JSON structured output\n", len(changes))
+		slog.Debug("✓ Parsed files from JSON structured output", "files", len(changes))
 		return changes
 	}
 
 	// Fallback to markdown parsing with improved regex patterns
 	changes = tryParseMarkdown(response)
 	if len(changes) > 0 {
-		fmt.Printf("✓ Parsed %d file(s) from markdown format\n", len(changes))
+		slog.Debug("✓ Parsed files from markdown format", "files", len(changes))
 		return changes
 	}
 
-	fmt.Printf("⚠️  No file changes detected in response\n")
+	slog.Debug("⚠️  No file changes detected in response")
 	return changes
 }
 
@@ -798,6 +681,7 @@ func tryParseJSON(response string) map[string]string {
 	var jsonResponse struct {
 		Summary string `json:"summary"`
 		Files   []struct {
+			Action  string `json:"action"`
 			Path    string `json:"path"`
 			Content string `json:"content"`
 		} `json:"files"`
@@ -810,7 +694,7 @@ func tryParseJSON(response string) map[string]string {
 
 	// Extract files from JSON structure
 	for _, file := range jsonResponse.Files {
-		if file.Path != "" && file.Content != "" {
+		if file.Path != "" && file.Content != "" && file.Action != core.FileActionDelete {
 			changes[file.Path] = file.Content
 		}
 	}
@@ -877,84 +761,23 @@ func tryParseMarkdown(response string) map[string]string {
 	return changes
 }
 
-// isResponseAskingQuestions determines if the AI response contains clarifying questions
-// Uses multiple heuristics to detect questions more accurately than just checking for "?"
-func isResponseAskingQuestions(response string) bool {
-	lowerResponse := strings.ToLower(response)
-
-	// Count question marks to help determine intent
-	questionMarkCount := strings.Count(response, "?")
-
-	// Strong indicators that questions are being asked
-	strongIndicators := []string{
-		"clarifying question",
-		"could you clarify",
-		"can you clarify",
-		"please clarify",
-		"need clarification",
-		"need to know",
-		"would you like",
-		"do you want",
-		"should i",
-		"which one",
-		"what about",
-		"how should",
-	}
-
-	for _, indicator := range strongIndicators {
-		if strings.Contains(lowerResponse, indicator) {
-			return true
-		}
-	}
-
-	// Check for explicit question sections
-	if strings.Contains(lowerResponse, "questions:") || strings.Contains(lowerResponse, "question?") {
-		return true
-	}
-
-	// If there are multiple question marks, likely asking questions
-	if questionMarkCount >= 2 {
-		return true
-	}
-
-	// Check for readiness indicators - if present, NOT asking questions
-	readyIndicators := []string{
-		"ready to proceed",
-		"ready to implement",
-		"ready to create",
-		"i'll start working",
-		"i'll begin",
-		"clear understanding",
-		"everything is clear",
-		"no questions",
-		"no clarification needed",
-	}
-
-	hasReadyIndicator := false
-	for _, indicator := range readyIndicators {
-		if strings.Contains(lowerResponse, indicator) {
-			hasReadyIndicator = true
-			break
-		}
-	}
-
-	// If has ready indicator and no/few question marks, not asking questions
-	if hasReadyIndicator && questionMarkCount <= 1 {
-		return false
-	}
-
-	// If has a single question mark but also ready indicator, ambiguous - prefer safety (wait for user)
-	if questionMarkCount == 1 && hasReadyIndicator {
-		return true
+// readyToImplement classifies the latest response in the conversation, returning the
+// questions still open. If classification fails the issue keeps waiting, since one more round of conversation costs less than
+// implementing a misunderstanding.
+func (ia *IssueAgent) readyToImplement(claude *core.ClaudeAgent, state *core.State) (bool, []string) {
+	readiness, usage, err := claude.ClassifyReadiness(state.Messages())
+	state.AddUsage(usage)
+	if err != nil {
+		state.Logger().Warn("⚠️  Failed to classify the response, waiting for a reply", "error", err)
+		return false, nil
 	}
 
-	// If ends with question mark and no ready indicators, asking questions
-	if strings.HasSuffix(strings.TrimSpace(response), "?") && !hasReadyIndicator {
-		return true
+	if readiness.ReadyToImplement {
+		state.Logger().Info("🟢 No open questions, ready to implement")
+	} else {
+		state.Logger().Info("❓ Waiting on open questions", "questions", len(readiness.Questions))
 	}
-
-	// Default: if we have any question marks and no clear ready signal, assume asking questions
-	return questionMarkCount > 0 && !hasReadyIndicator
+	return readiness.ReadyToImplement, readiness.Questions
 }
 
 // extractSummary extracts a human-readable summary from the AI response
@@ -1035,16 +858,98 @@ func extractSummary(response string, fileChanges map[string]string) string {
 	return summary
 }
 
-// extractIssueNumber extracts the issue number from PR body
-func extractIssueNumber(body string) int {
-	re := regexp.MustCompile(`Fixes #(\d+)`)
-	matches := re.FindStringSubmatch(body)
-	if len(matches) == 2 {
-		var issueNum int
-		fmt.Sscanf(matches[1], "%d", &issueNum)
-		return issueNum
+// closingKeywordRe matches the issue references that close an issue when a pull request
+// merges, e.g. "Fixes #12" or "closes #3"
+var closingKeywordRe = regexp.MustCompile(`(?i)\b(?:fix(?:e[sd])?|close[sd]?|resolve[sd]?):?\s+#(\d+)\b`)
+
+// extractIssueNumbers returns the issues a pull request body closes, in the order they're
+// first referenced
+func extractIssueNumbers(body string) []int {
+	var issueNumbers []int
+	for _, match := range closingKeywordRe.FindAllStringSubmatch(body, -1) {
+		issueNumber, err := strconv.Atoi(match[1])
+		if err == nil && !slices.Contains(issueNumbers, issueNumber) {
+			issueNumbers = append(issueNumbers, issueNumber)
+		}
 	}
-	return 0
+	return issueNumbers
+}
+
+// GitHub returns the agent's GitHub client
+func (ia *IssueAgent) GitHub() *core.GitHubClient {
+	return ia.hosts.GitHub()
+}
+
+// host returns the code host serving a repository
+func (ia *IssueAgent) host(owner, repo string) core.CodeHost {
+	return ia.hosts.For(owner, repo)
+}
+
+// newSandbox creates a workspace for an issue, cloned from its repository
+func (ia *IssueAgent) newSandbox(owner, repo string, issueNumber int) (*core.Sandbox, error) {
+	sandbox, err := core.NewSandbox(ia.workingDir, owner, repo, issueNumber, ia.host(owner, repo).CloneURL(owner, repo))
+	if err != nil {
+		return nil, err
+	}
+	if ia.dryRun != nil {
+		sandbox.SetDryRun(ia.dryRun)
+	}
+	sandbox.SetCommandLimits(ia.commandTimeout(), ia.maxOutputBytes())
+	// The repository's commands come from its default branch, not the working tree the AI
+	// edits, so a change can't pick the commands it's verified with
+	settings, file := ia.config.ForRepo(owner, repo), ia.repoFile(owner, repo)
+	buildCommand, testCommand := settings.BuildCommand, settings.TestCommand
+	if buildCommand == "" {
+		buildCommand = file.BuildCommand
+	}
+	if testCommand == "" {
+		testCommand = file.TestCommand
+	}
+	sandbox.SetCommands(buildCommand, testCommand)
+	sandbox.SetProtectedPaths(file.ProtectedPaths)
+	return sandbox, nil
+}
+
+// commandTimeout is how long a sandbox command may run, or 0 for no limit
+func (ia *IssueAgent) commandTimeout() time.Duration {
+	switch timeout := ia.config.Sandbox.CommandTimeout; {
+	case timeout < 0:
+		return 0
+	case timeout > 0:
+		return time.Duration(timeout) * time.Second
+	}
+	return core.DefaultCommandTimeout
+}
+
+// maxOutputBytes is how much of a sandbox command's output is kept, or 0 for all of it
+func (ia *IssueAgent) maxOutputBytes() int {
+	switch limit := ia.config.Sandbox.MaxOutputBytes; {
+	case limit < 0:
+		return 0
+	case limit > 0:
+		return limit
+	}
+	return core.DefaultMaxOutputBytes
+}
+
+// StateManager returns the agent's state store
+func (ia *IssueAgent) StateManager() *core.StateManager {
+	return ia.stateManager
+}
+
+// IssueURL returns the web page of an issue on the host serving its repository
+func (ia *IssueAgent) IssueURL(owner, repo string, number int) string {
+	return ia.host(owner, repo).IssueURL(owner, repo, number)
+}
+
+// PullRequestURL returns the web page of a pull request on the host serving its repository
+func (ia *IssueAgent) PullRequestURL(owner, repo string, number int) string {
+	return ia.host(owner, repo).PullRequestURL(owner, repo, number)
+}
+
+// CheckLLM reports an error if the LLM provider's API can't be reached
+func (ia *IssueAgent) CheckLLM(ctx context.Context) error {
+	return ia.claude.CheckReachable(ctx)
 }
 
 // Close closes the agent and cleans up resources
@@ -1052,35 +957,123 @@ func (ia *IssueAgent) Close() error {
 	return ia.stateManager.Close()
 }
 
-// StartPolling begins polling for assigned issues
-func (ia *IssueAgent) StartPolling(pollIntervalSeconds int, repositories []string) error {
+// StartPolling begins polling for assigned issues until ctx is cancelled
+func (ia *IssueAgent) StartPolling(ctx context.Context, pollIntervalSeconds int, repositories []string) error {
+	poller, err := ia.newPoller(pollIntervalSeconds, repositories)
+	if err != nil {
+		return err
+	}
+	return poller.Start(ctx, ia.pollerHandlers())
+}
+
+// PollOnce polls every repository once and returns, for scheduled CI jobs
+func (ia *IssueAgent) PollOnce(ctx context.Context, repositories []string) error {
+	poller, err := ia.newPoller(0, repositories)
+	if err != nil {
+		return err
+	}
+	return poller.PollOnce(ctx, ia.pollerHandlers())
+}
+
+// newPoller creates a poller for the repositories
+func (ia *IssueAgent) newPoller(pollIntervalSeconds int, repositories []string) (*core.Poller, error) {
+	var triggerLabels func(owner, repo string) []string
+	if ia.config.LabelTrigger() != "" {
+		triggerLabels = ia.triggerLabels
+	}
 	poller, err := core.NewPoller(
-		ia.github,
+		ia.hosts,
 		ia.stateManager,
 		core.PollerConfig{
 			PollInterval: time.Duration(pollIntervalSeconds) * time.Second,
 			Repositories: repositories,
+			StaleAfter:   time.Duration(ia.config.Stale.ReminderAfterHours) * time.Hour,
+			ExpireAfter:  time.Duration(ia.config.Stale.ExpireAfterHours) * time.Hour,
+
+			MentionTrigger:     ia.config.MentionTriggerEnabled(),
+			TriggerLabels:      triggerLabels,
+			TriageRepositories: ia.triageRepositories(repositories),
+			PriorityLabels:     ia.config.PriorityLabels,
 		},
 	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create poller: %w", err)
+	}
+	return poller, nil
+}
+
+// RunIssue takes a single issue through one round of the workflow and returns: a new issue
+// is analyzed and, once nothing is left to clarify, implemented and turned into a pull
+// request; an issue already in progress continues from its state
+func (ia *IssueAgent) RunIssue(owner, repo string, issueNumber int) error {
+	poller, err := core.NewPoller(ia.hosts, ia.stateManager, core.PollerConfig{
+		Repositories: []string{owner + "/" + repo},
+		StaleAfter:   time.Duration(ia.config.Stale.ReminderAfterHours) * time.Hour,
+		ExpireAfter:  time.Duration(ia.config.Stale.ExpireAfterHours) * time.Hour,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to create poller: %w", err)
 	}
+	return poller.PollIssue(owner, repo, issueNumber, ia.pollerHandlers())
+}
 
-	// Start polling and handle events
-	handlers := core.PollerHandlers{
+// pollerHandlers connects poller events to the workflows
+func (ia *IssueAgent) pollerHandlers() core.PollerHandlers {
+	return core.PollerHandlers{
 		HandleIssue: func(owner, repo string, issueNumber int) error {
 			return ia.HandleIssueAssignment(owner, repo, issueNumber)
 		},
-		HandleIssueComment: func(owner, repo string, issueNumber int, commentBody string) error {
-			return ia.HandleIssueComment(owner, repo, issueNumber, commentBody)
+		HandleIssueComments: func(owner, repo string, issueNumber int, commentBodies []string) error {
+			return ia.HandleIssueComments(owner, repo, issueNumber, commentBodies)
 		},
-		HandlePRComment: func(owner, repo string, prNumber int, commentBody string) error {
-			return ia.HandlePRComment(owner, repo, prNumber, commentBody)
+		HandlePRComments: func(owner, repo string, prNumber int, commentBodies []string) error {
+			return ia.HandlePRComments(owner, repo, prNumber, commentBodies)
 		},
+		CanComment: ia.CanComment,
 		HandleImplementation: func(owner, repo string, issueNumber int) error {
 			return ia.StartImplementation(owner, repo, issueNumber)
 		},
+		HandleStale: func(owner, repo string, issueNumber int, expire bool) error {
+			return ia.HandleStale(owner, repo, issueNumber, expire)
+		},
+		HandlePullRequest: func(owner, repo string, prNumber int) error {
+			return ia.HandlePullRequest(owner, repo, prNumber)
+		},
+		HandleClosed: func(owner, repo string, issueNumber int) error {
+			return ia.AbandonIssue(owner, repo, issueNumber, "closed")
+		},
+		HandleMention: func(owner, repo string, issueNumber int, author, body string) error {
+			_, err := ia.HandleMention(owner, repo, issueNumber, author, body)
+			return err
+		},
+		HandleCommand: func(owner, repo string, issueNumber int, author, body string) error {
+			_, err := ia.HandleCommand(owner, repo, issueNumber, author, body)
+			return err
+		},
+		HandleApproval: func(owner, repo string, issueNumber int) error {
+			_, err := ia.CheckApproval(owner, repo, issueNumber)
+			return err
+		},
+		HandleLabel: func(owner, repo string, issueNumber int, label string) error {
+			_, err := ia.HandleLabel(owner, repo, issueNumber, label)
+			return err
+		},
+		HandleNewIssue: func(owner, repo string, issueNumber int) error {
+			return ia.TriageIssue(owner, repo, issueNumber)
+		},
+		HandleReview: func(owner, repo string, prNumber int, requested bool) error {
+			return ia.ReviewPullRequest(owner, repo, prNumber, requested)
+		},
 	}
+}
 
-	return poller.Start(handlers)
+// maxFixIterations returns how many times the AI may try to fix failed verification
+func (ia *IssueAgent) maxFixIterations() int {
+	switch {
+	case ia.config.MaxFixIterations < 0:
+		return 0
+	case ia.config.MaxFixIterations == 0:
+		return defaultMaxFixIterations
+	}
+	return ia.config.MaxFixIterations
 }
@@ -0,0 +1,55 @@
+package workflows
+
+import (
+	"fmt"
+	"strings"
+
+	"NyteBubo/internal/core"
+)
+
+// verifySandbox runs build and test, once per toolchain version when the repository configures a
+// test matrix. On failure the outputs of the first failing version are returned for the fix prompt.
+func (ia *IssueAgent) verifySandbox(sandbox *core.Sandbox, owner, repo string) (buildOutput, testOutput string, results []core.MatrixResult, err error) {
+	matrix := matrixConfig(ia.config.ForRepo(owner, repo).TestMatrix)
+	if len(matrix.Versions) == 0 {
+		buildOutput, testOutput, err = sandbox.Verify()
+		return buildOutput, testOutput, nil, err
+	}
+
+	results, err = sandbox.VerifyMatrix(matrix)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	failed := core.FirstFailure(results)
+	if failed == nil {
+		last := results[len(results)-1]
+		return last.BuildOutput, last.TestOutput, results, nil
+	}
+
+	return failed.BuildOutput, failed.TestOutput, results, fmt.Errorf("version %s: %w", failed.Version, failed.Err)
+}
+
+// matrixReport renders verification results as a markdown table for the PR body
+func matrixReport(results []core.MatrixResult) string {
+	if len(results) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("**Test matrix:**\n\n| Version | Result |\n| --- | --- |")
+	for _, result := range results {
+		status := "✅ passed"
+		if result.Err != nil {
+			status = "❌ " + tableCell(result.Err.Error())
+		}
+		sb.WriteString(fmt.Sprintf("\n| `%s` | %s |", result.Version, status))
+	}
+	return sb.String()
+}
+
+// tableCell makes text safe for a markdown table cell, which ends at a pipe or a newline
+func tableCell(text string) string {
+	text = strings.ReplaceAll(text, "|", "\\|")
+	return strings.Join(strings.Fields(text), " ")
+}
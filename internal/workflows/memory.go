@@ -0,0 +1,102 @@
+package workflows
+
+import (
+	"fmt"
+	"strings"
+
+	"NyteBubo/internal/core"
+)
+
+const (
+	defaultMemoryTopK          = 3
+	defaultMemoryMinSimilarity = 0.75
+	// maxMemoryContentSize caps how much text is embedded and recalled per memory
+	maxMemoryContentSize = 4000
+)
+
+// recallMemories returns a prompt section describing past work in the repository
+// that is similar to the query, or an empty string if memory is disabled or nothing matches
+func (ia *IssueAgent) recallMemories(state *core.State, query string) string {
+	if !ia.config.Memory.Enabled || strings.TrimSpace(query) == "" {
+		return ""
+	}
+
+	embeddings, usage, err := ia.claude.WithIssue(state.Owner, state.Repo, state.IssueNumber).Embed([]string{truncateText(query, maxMemoryContentSize)})
+	if err != nil {
+		state.Logger().Warn("⚠️  Failed to embed issue for memory recall", "error", err)
+		return ""
+	}
+	state.AddUsage(usage)
+
+	topK := ia.config.Memory.TopK
+	if topK <= 0 {
+		topK = defaultMemoryTopK
+	}
+	minSimilarity := ia.config.Memory.MinSimilarity
+	if minSimilarity <= 0 {
+		minSimilarity = defaultMemoryMinSimilarity
+	}
+
+	matches, err := ia.stateManager.SearchMemories(state.Owner, state.Repo, state.IssueNumber, embeddings[0], topK, minSimilarity)
+	if err != nil {
+		state.Logger().Warn("⚠️  Failed to search memories", "error", err)
+		return ""
+	}
+	if len(matches) == 0 {
+		return ""
+	}
+
+	state.Logger().Info("🧠 Recalled related memories from past work", "memories", len(matches))
+
+	var b strings.Builder
+	b.WriteString("Relevant past work in this repository (for reference; it may not apply directly):\n")
+	for _, match := range matches {
+		b.WriteString(fmt.Sprintf("\n--- Issue #%d (%s, similarity %.2f) ---\n%s\n",
+			match.IssueNumber, match.Kind, match.Similarity, match.Content))
+	}
+	return b.String()
+}
+
+// remember stores a memory for the issue so that future, similar issues can recall it
+func (ia *IssueAgent) remember(state *core.State, kind, content string) {
+	if !ia.config.Memory.Enabled || strings.TrimSpace(content) == "" {
+		return
+	}
+
+	content = truncateText(content, maxMemoryContentSize)
+	embeddings, usage, err := ia.claude.WithIssue(state.Owner, state.Repo, state.IssueNumber).Embed([]string{content})
+	if err != nil {
+		state.Logger().Warn("⚠️  Failed to embed memory", "kind", kind, "error", err)
+		return
+	}
+	state.AddUsage(usage)
+
+	memory := &core.Memory{
+		Owner:       state.Owner,
+		Repo:        state.Repo,
+		IssueNumber: state.IssueNumber,
+		Kind:        kind,
+		Content:     content,
+		Embedding:   embeddings[0],
+	}
+	if err := ia.stateManager.SaveMemory(memory); err != nil {
+		state.Logger().Warn("⚠️  Failed to save memory", "kind", kind, "error", err)
+	}
+}
+
+// conversationDigest flattens a conversation into text suitable for embedding
+func conversationDigest(conversation []core.AgentMessage) string {
+	var b strings.Builder
+	for _, msg := range conversation {
+		b.WriteString(fmt.Sprintf("[%s] %s\n\n", msg.Role, msg.Content))
+	}
+	return b.String()
+}
+
+// truncateText shortens text to at most max bytes, marking the cut
+func truncateText(text string, max int) string {
+	if len(text) <= max {
+		return text
+	}
+	return text[:max] + "\n...(truncated)"
+}
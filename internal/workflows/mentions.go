@@ -0,0 +1,189 @@
+package workflows
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"NyteBubo/internal/core"
+	"github.com/google/go-github/v63/github"
+)
+
+// botLogin returns the bot's login on the host serving a repository
+func (ia *IssueAgent) botLogin(owner, repo string) (string, error) {
+	return ia.hosts.BotLogin(owner, repo)
+}
+
+// HandleMention starts the workflow on an issue when an authorized user @-mentions the bot,
+// or replies in the discussion when the mention is on someone else's pull request. It reports
+// whether the mention was handled; mentions on issues and pull requests the bot is already
+// working on are left to the normal comment handling.
+func (ia *IssueAgent) HandleMention(owner, repo string, issueNumber int, author, body string) (bool, error) {
+	if !ia.config.MentionTriggerEnabled() {
+		return false, nil
+	}
+
+	login, err := ia.botLogin(owner, repo)
+	if err != nil {
+		return false, err
+	}
+	if author == login || !core.MentionsUser(body, login) {
+		return false, nil
+	}
+
+	state, err := ia.stateManager.GetState(owner, repo, issueNumber)
+	if err != nil {
+		return false, fmt.Errorf("failed to get state: %w", err)
+	}
+	if state != nil {
+		return false, nil
+	}
+
+	// Only collaborators with enough access may hand work to the bot
+	required := ia.config.MentionPermission
+	if required == "" {
+		required = "write"
+	}
+	allowed, err := ia.hasPermission(owner, repo, author, required)
+	if err != nil {
+		return false, err
+	}
+	if !allowed {
+		core.IssueLogger(owner, repo, issueNumber).Info("🚫 Ignoring mention from user without permission", "author", author, "required", required)
+		return false, nil
+	}
+
+	issue, err := ia.host(owner, repo).GetIssue(owner, repo, issueNumber)
+	if err != nil {
+		return false, err
+	}
+	if issue.IsPullRequest() {
+		if owned, err := ia.OwnsPullRequest(owner, repo, issueNumber); err != nil || owned {
+			return false, err
+		}
+		return true, ia.replyToMention(owner, repo, issue, author, body)
+	}
+	if reason := ia.issueFilteredOut(issue); reason != "" {
+		core.IssueLogger(owner, repo, issueNumber).Info("🚫 Ignoring mention on issue I don't take on", "reason", reason)
+		return false, nil
+	}
+
+	core.IssueLogger(owner, repo, issueNumber).Info("📣 Asked for help", "author", author)
+
+	// Assign the bot so follow-up comments are picked up like any assigned issue
+	if err := ia.host(owner, repo).AddAssignee(owner, repo, issueNumber, login); err != nil {
+		core.IssueLogger(owner, repo, issueNumber).Warn("⚠️  Failed to assign myself to issue", "error", err)
+	}
+
+	return true, ia.HandleIssueAssignment(owner, repo, issueNumber)
+}
+
+// replyToMention answers a mention in the discussion of a pull request the bot didn't open,
+// with the pull request's description, diff and conversation as context
+func (ia *IssueAgent) replyToMention(owner, repo string, pr *github.Issue, author, body string) error {
+	prNumber := pr.GetNumber()
+	lock := ia.busyLock("mention:" + issueKey(owner, repo, prNumber))
+	lock.Lock()
+	defer lock.Unlock()
+
+	logger := core.RepoLogger(owner, repo).With("pr", prNumber)
+	host := ia.host(owner, repo)
+
+	if reason, err := ia.budgetExceeded(&core.State{Owner: owner, Repo: repo}); err != nil {
+		return fmt.Errorf("failed to check budget: %w", err)
+	} else if reason != "" {
+		logger.Warn("💸 Not replying to mention", "reason", reason)
+		return nil
+	}
+
+	logger.Info("📣 Replying to mention", "author", author)
+
+	diff, err := host.GetPullRequestDiff(owner, repo, prNumber)
+	if err != nil {
+		return err
+	}
+	comments, err := host.ListIssueComments(owner, repo, prNumber)
+	if err != nil {
+		return err
+	}
+
+	claude := ia.claude.WithLogger(logger).WithIssue(owner, repo, prNumber)
+	if instructions := ia.repoInstructions(owner, repo); instructions != "" {
+		claude = claude.WithInstructions(instructions)
+	}
+	response, usage, err := claude.AnswerMention(pr.GetTitle(), pr.GetBody(), truncateText(diff, maxReviewDiffBytes), mentionThread(comments, author, body))
+	if costErr := ia.stateManager.RecordMentionReply(owner, repo, prNumber, usage.Cost); costErr != nil {
+		logger.Warn("⚠️  Failed to record mention reply", "error", costErr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to answer mention: %w", err)
+	}
+
+	return ia.postPRComment(owner, repo, prNumber, botComment{Summary: "@" + author + " " + response}.String())
+}
+
+// maxMentionThreadBytes caps how much of a discussion is sent when replying to a mention;
+// older comments are dropped first
+const maxMentionThreadBytes = 32 * 1024
+
+// mentionThread formats a discussion for a reply, ending with the mention being answered.
+// The mention may be an inline review comment, which isn't part of the conversation.
+func mentionThread(comments []*github.IssueComment, author, body string) string {
+	var messages []string
+	for _, comment := range comments {
+		if comment.GetBody() == body {
+			continue
+		}
+		messages = append(messages, fmt.Sprintf("@%s: %s", comment.GetUser().GetLogin(), comment.GetBody()))
+	}
+	messages = append(messages, fmt.Sprintf("@%s: %s", author, body))
+
+	thread := strings.Join(messages, "\n\n")
+	for len(thread) > maxMentionThreadBytes && len(messages) > 1 {
+		messages = messages[1:]
+		thread = strings.Join(messages, "\n\n")
+	}
+	return thread
+}
+
+// HandleLabel starts the workflow on an issue when a trigger label is added. Only
+// collaborators with triage access can label issues, so no further permission check is needed.
+// It reports whether the label started a new workflow.
+func (ia *IssueAgent) HandleLabel(owner, repo string, issueNumber int, label string) (bool, error) {
+	if !slices.ContainsFunc(ia.triggerLabels(owner, repo), func(trigger string) bool {
+		return strings.EqualFold(label, trigger)
+	}) {
+		return false, nil
+	}
+
+	state, err := ia.stateManager.GetState(owner, repo, issueNumber)
+	if err != nil {
+		return false, fmt.Errorf("failed to get state: %w", err)
+	}
+	if state != nil {
+		return false, nil
+	}
+
+	issue, err := ia.host(owner, repo).GetIssue(owner, repo, issueNumber)
+	if err != nil {
+		return false, err
+	}
+	if reason := ia.issueFilteredOut(issue); reason != "" {
+		core.IssueLogger(owner, repo, issueNumber).Debug("Ignoring label on issue I don't take on", "reason", reason)
+		return false, nil
+	}
+
+	login, err := ia.botLogin(owner, repo)
+	if err != nil {
+		return false, err
+	}
+
+	core.IssueLogger(owner, repo, issueNumber).Info("🏷️  Issue labeled", "label", label)
+
+	// Assign the bot so follow-up comments are picked up like any assigned issue
+	if err := ia.host(owner, repo).AddAssignee(owner, repo, issueNumber, login); err != nil {
+		core.IssueLogger(owner, repo, issueNumber).Warn("⚠️  Failed to assign myself to issue", "error", err)
+	}
+
+	return true, ia.HandleIssueAssignment(owner, repo, issueNumber)
+}
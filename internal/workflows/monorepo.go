@@ -0,0 +1,59 @@
+package workflows
+
+import (
+	"regexp"
+	"strings"
+
+	"NyteBubo/internal/core"
+)
+
+// issueSubprojects returns the monorepo subprojects an issue's conversation refers to, by
+// directory or by a file inside one. It returns nil outside a monorepo or when the issue
+// doesn't name any, in which case the whole repository is in scope.
+func issueSubprojects(sandbox *core.Sandbox, conversation []core.AgentMessage) []core.Subproject {
+	subprojects, err := sandbox.DetectSubprojects()
+	if err != nil || len(subprojects) == 0 {
+		return nil
+	}
+
+	var text strings.Builder
+	for _, msg := range conversation {
+		if msg.Role == "user" {
+			text.WriteString(msg.Content + "\n")
+		}
+	}
+	mentioned := mentionedPaths(text.String())
+
+	var scope []core.Subproject
+	for _, project := range subprojects {
+		if project.Dir == "." {
+			continue
+		}
+		dirRe := regexp.MustCompile(`(?i)(^|[^\w./-])` + regexp.QuoteMeta(project.Dir) + `($|[^\w-])`)
+		if dirRe.MatchString(text.String()) || core.SubprojectsFor([]core.Subproject{project}, mentioned) != nil {
+			scope = append(scope, project)
+		}
+	}
+	return scope
+}
+
+// subprojectFiles keeps the files inside the subprojects, and those at the repository root
+// such as workspace manifests
+func subprojectFiles(files []string, scope []core.Subproject) []string {
+	var scoped []string
+	for _, file := range files {
+		if !strings.Contains(file, "/") || core.InSubprojects(scope, file) {
+			scoped = append(scoped, file)
+		}
+	}
+	return scoped
+}
+
+// subprojectDirs lists the subprojects' directories
+func subprojectDirs(scope []core.Subproject) []string {
+	dirs := make([]string, len(scope))
+	for i, project := range scope {
+		dirs[i] = project.Dir
+	}
+	return dirs
+}
@@ -0,0 +1,144 @@
+package workflows
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"NyteBubo/internal/core"
+)
+
+// editBlockRe matches a SEARCH/REPLACE block and the file path line before it,
+// optionally wrapped in a code fence
+var editBlockRe = regexp.MustCompile("(?ms)^(?:FILE:\\s*)?`?([\\w/._-]+)`?[ \\t]*\\n(?:```[^\\n]*\\n)?<<<<<<< SEARCH[ \\t]*\\n(.*?)^=======[ \\t]*\\n(.*?)^>>>>>>> REPLACE[ \\t]*(?:\\n```)?[ \\t]*\\n?")
+
+// editBlockContinuationRe matches further SEARCH/REPLACE blocks for the same file
+// that don't repeat the path
+var editBlockContinuationRe = regexp.MustCompile("(?ms)\\A(?:```[^\\n]*\\n)?<<<<<<< SEARCH[ \\t]*\\n(.*?)^=======[ \\t]*\\n(.*?)^>>>>>>> REPLACE[ \\t]*(?:\\n```)?[ \\t]*\\n?")
+
+// parseEditBlocks extracts SEARCH/REPLACE edits from a response and returns
+// the remaining text with the blocks removed
+func parseEditBlocks(response string) ([]core.FileEdit, string) {
+	var edits []core.FileEdit
+	var rest strings.Builder
+
+	remaining := response
+	for {
+		loc := editBlockRe.FindStringSubmatchIndex(remaining)
+		if loc == nil {
+			rest.WriteString(remaining)
+			break
+		}
+		rest.WriteString(remaining[:loc[0]])
+
+		path := remaining[loc[2]:loc[3]]
+		edits = append(edits, core.FileEdit{
+			Path:    path,
+			Search:  remaining[loc[4]:loc[5]],
+			Replace: remaining[loc[6]:loc[7]],
+		})
+		remaining = remaining[loc[1]:]
+
+		// Consecutive blocks may omit the path
+		for {
+			next := strings.TrimLeft(remaining, "\n")
+			match := editBlockContinuationRe.FindStringSubmatchIndex(next)
+			if match == nil {
+				break
+			}
+			edits = append(edits, core.FileEdit{
+				Path:    path,
+				Search:  next[match[2]:match[3]],
+				Replace: next[match[4]:match[5]],
+			})
+			remaining = next[match[1]:]
+		}
+	}
+
+	return edits, rest.String()
+}
+
+// generateChanges asks the AI for code changes in the configured edit mode. In agent
+// mode the AI makes the changes in the sandbox itself, and the response describes them.
+func (ia *IssueAgent) generateChanges(claude *core.ClaudeAgent, sandbox *core.Sandbox, state *core.State, task, repoContext, language string, conversation []core.AgentMessage) (string, core.TokenUsage, error) {
+	switch ia.config.EditMode {
+	case "patch":
+		return claude.GenerateEdits(task, repoContext, language, conversation)
+	case "agent":
+		return ia.generateWithTools(claude, sandbox, state, task, repoContext, language, conversation)
+	}
+	return claude.GenerateCode(task, repoContext, language, conversation)
+}
+
+// applyWithFallback applies changes to the sandbox. Files whose edits don't apply are
+// requested again as complete files, so a bad patch never silently drops changes.
+func (ia *IssueAgent) applyWithFallback(claude *core.ClaudeAgent, sandbox *core.Sandbox, state *core.State, changes codeChanges, response, repoContext, language string) error {
+	failed, err := applyChanges(sandbox, changes)
+	if err != nil {
+		return err
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+
+	// Edits outside the repository or under .git can't be applied as complete files
+	// either, and those files must never be read back into the prompt
+	paths := make([]string, 0, len(failed))
+	for path := range failed {
+		if !sandbox.InRepository(path) {
+			state.Logger().Warn("⚠️  Dropping edits to a path outside the repository", "path", path, "error", failed[path])
+			continue
+		}
+		paths = append(paths, path)
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	sort.Strings(paths)
+
+	state.Logger().Info("🔁 Requesting complete contents for files whose edits failed", "files", len(paths))
+
+	var prompt strings.Builder
+	prompt.WriteString("Some of your SEARCH/REPLACE edits could not be applied:\n")
+	for _, path := range paths {
+		prompt.WriteString(fmt.Sprintf("- %s: %v\n", path, failed[path]))
+	}
+	prompt.WriteString("\nReply with the complete updated content of each of these files as whole-file code blocks, including every change you intended. The current contents are:\n")
+	for _, path := range paths {
+		content, err := sandbox.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		prompt.WriteString(fmt.Sprintf("\n--- %s ---\n%s\n", path, content))
+	}
+
+	messages := append(state.Messages(),
+		core.AgentMessage{Role: "assistant", Content: response},
+		core.AgentMessage{Role: "user", Content: prompt.String()},
+	)
+	fallbackResponse, usage, err := claude.GenerateCode("Provide complete files for edits that failed to apply", repoContext, language, messages)
+	if err != nil {
+		return fmt.Errorf("failed to get complete files after edits failed: %w", err)
+	}
+	state.AddUsage(usage)
+
+	files := parseCodeChanges(fallbackResponse)
+	var missing []string
+	for _, path := range paths {
+		content, ok := files[path]
+		if !ok {
+			missing = append(missing, path)
+			continue
+		}
+		state.Logger().Info("  - Rewriting file", "path", path)
+		if err := sandbox.WriteFile(path, content); err != nil {
+			return fmt.Errorf("failed to write file %s: %w", path, err)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("edits to %s could not be applied and no complete content was returned", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
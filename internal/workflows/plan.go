@@ -0,0 +1,174 @@
+package workflows
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"NyteBubo/internal/core"
+)
+
+// generateWithPlan plans the implementation, posts the plan to the issue and generates
+// each step separately, verifying the sandbox between steps. It returns a response holding
+// the combined changes, and whether they are already applied to the sandbox.
+func (ia *IssueAgent) generateWithPlan(claude *core.ClaudeAgent, state *core.State, sandbox *core.Sandbox, task, repoContext, language string) (string, bool, error) {
+	owner, repo, issueNumber := state.Owner, state.Repo, state.IssueNumber
+	logger := state.Logger()
+
+	logger.Info("🗺️  Planning the implementation")
+	plan, usage, err := claude.PlanImplementation(task, repoContext, language, state.Messages())
+	state.AddUsage(usage)
+	if err != nil {
+		logger.Warn("⚠️  Failed to plan the implementation, generating it in one go", "error", err)
+		response, usage, err := ia.generateChanges(claude, sandbox, state, task, repoContext, language, state.Messages())
+		state.AddUsage(usage)
+		return response, false, err
+	}
+
+	sections := []commentSection{{Title: "Steps", Body: plan.String()}}
+	if len(plan.Risks) > 0 {
+		sections = append(sections, commentSection{Title: "Risks", Body: "- " + strings.Join(plan.Risks, "\n- ")})
+	}
+	comment := botComment{
+		Heading:  "🗺️ Implementation plan",
+		Summary:  plan.Summary,
+		Sections: sections,
+	}.String()
+	if err := ia.postComment(owner, repo, issueNumber, comment); err != nil {
+		return "", false, fmt.Errorf("failed to create comment: %w", err)
+	}
+	state.Conversation = append(state.Conversation, core.AgentMessage{
+		Role:    "assistant",
+		Content: "Implementation plan:\n\n" + plan.Summary + "\n\n" + plan.String(),
+	})
+
+	// A one-step plan is an ordinary generation with the plan as guidance
+	if len(plan.Steps) == 1 {
+		response, usage, err := ia.generateChanges(claude, sandbox, state, task, repoContext, language, state.Messages())
+		state.AddUsage(usage)
+		return response, false, err
+	}
+
+	feedback := ""
+	for i, step := range plan.Steps {
+		if err := ia.ctx.Err(); err != nil {
+			return "", false, err
+		}
+		if reason, err := ia.budgetExceeded(state); err != nil {
+			logger.Warn("⚠️  Failed to check budget", "error", err)
+		} else if reason != "" {
+			logger.Warn("💸 Stopping before the remaining plan steps", "reason", reason, "step", i+1)
+			break
+		}
+		logger.Info("🤖 Generating plan step", "step", i+1, "steps", len(plan.Steps), "title", step.Title)
+
+		messages := append(state.Messages(), core.AgentMessage{
+			Role:    "user",
+			Content: planStepPrompt(sandbox, plan, i, feedback),
+		})
+		stepTask := fmt.Sprintf("%s: step %d of %d, %s", task, i+1, len(plan.Steps), step.Title)
+		response, usage, err := ia.generateChanges(claude, sandbox, state, stepTask, repoContext, language, messages)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to generate step %d: %w", i+1, err)
+		}
+		state.AddUsage(usage)
+
+		changes := parseChanges(response)
+		if changes.empty() {
+			logger.Warn("⚠️  No file changes for plan step", "step", i+1)
+			continue
+		}
+		logger.Info("📝 Applying plan step", "step", i+1, "files", len(changes.paths()))
+		if err := ia.applyWithFallback(claude, sandbox, state, changes, response, repoContext, language); err != nil {
+			return "", false, err
+		}
+
+		// The last step is verified by the regular build/test loop
+		if i == len(plan.Steps)-1 {
+			break
+		}
+		feedback = ""
+		if buildOutput, testOutput, _, verifyErr := ia.verifySandbox(sandbox, owner, repo); verifyErr != nil {
+			logger.Warn("❌ Verification failed after plan step", "step", i+1, "error", verifyErr)
+			feedback = fmt.Sprintf("Build output:\n%s\n\nTest output:\n%s\n\nError: %v", logBlock(buildOutput), logBlock(testOutput), verifyErr)
+		}
+	}
+
+	response, err := sandboxChangesResponse(sandbox, plan.Summary)
+	return response, true, err
+}
+
+// planStepPrompt asks for one step of a plan, with the current contents of the files it
+// touches and the verification failures left by the previous step
+func planStepPrompt(sandbox *core.Sandbox, plan core.ImplementationPlan, index int, feedback string) string {
+	step := plan.Steps[index]
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Implement step %d of the plan only: **%s**\n\n%s\n", index+1, step.Title, step.Description))
+	switch {
+	case index == 1:
+		b.WriteString("\nStep 1 is already applied.\n")
+	case index > 1:
+		b.WriteString(fmt.Sprintf("\nSteps 1 to %d are already applied.\n", index))
+	}
+	if index < len(plan.Steps)-1 {
+		b.WriteString("\nLater steps will be implemented separately, so don't start on them.\n")
+	}
+
+	if feedback != "" {
+		b.WriteString("\nThe build or tests fail after the previous steps. Fix these failures as part of this step if they are caused by the changes so far:\n\n" + feedback + "\n")
+	}
+
+	if index > 0 {
+		var current strings.Builder
+		for _, path := range step.Files {
+			// The paths come from the model's plan; ones outside the repository or under
+			// .git are refused rather than read into the prompt
+			if !sandbox.InRepository(path) {
+				continue
+			}
+			content, err := sandbox.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			current.WriteString(fmt.Sprintf("\n--- %s ---\n%s\n", path, content))
+		}
+		if current.Len() > 0 {
+			b.WriteString("\nThe current contents of this step's files are:\n" + current.String())
+		}
+	}
+	return b.String()
+}
+
+// sandboxChangesResponse describes every change in the sandbox as a structured code
+// response, so the combined result of a plan can be checkpointed and re-applied
+func sandboxChangesResponse(sandbox *core.Sandbox, summary string) (string, error) {
+	paths, err := sandbox.ChangedFiles()
+	if err != nil {
+		return "", err
+	}
+
+	type file struct {
+		Action  string `json:"action"`
+		Path    string `json:"path"`
+		Content string `json:"content,omitempty"`
+	}
+	response := struct {
+		Summary string `json:"summary"`
+		Files   []file `json:"files"`
+	}{Summary: summary}
+	for _, path := range paths {
+		content, err := sandbox.ReadFile(path)
+		if err != nil {
+			response.Files = append(response.Files, file{Action: core.FileActionDelete, Path: path})
+			continue
+		}
+		response.Files = append(response.Files, file{Action: core.FileActionModify, Path: path, Content: content})
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode changes: %w", err)
+	}
+	return string(data), nil
+}
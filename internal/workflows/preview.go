@@ -0,0 +1,104 @@
+package workflows
+
+import (
+	"fmt"
+	"strings"
+
+	"NyteBubo/internal/core"
+)
+
+const (
+	// maxPreviewCommentBytes keeps each part of a previewed diff under the 65536 characters
+	// GitHub allows in a comment, with room for the heading and fence
+	maxPreviewCommentBytes = 60000
+
+	// maxPreviewComments caps how many comments a previewed diff is split across
+	maxPreviewComments = 10
+)
+
+// previewDiff posts the changes as a unified diff and holds them until a maintainer runs
+// /nytebubo apply
+func (ia *IssueAgent) previewDiff(impl *implementation) error {
+	state := impl.state
+	owner, repo, issueNumber := state.Owner, state.Repo, state.IssueNumber
+	state.Logger().Info("👀 Posting the diff for review before pushing")
+
+	// The diff is published on the issue, so it gets the same check as a push
+	findings, err := impl.sandbox.ScanSecrets()
+	if err != nil {
+		return fmt.Errorf("failed to scan for secrets: %w", err)
+	}
+	if len(findings) > 0 {
+		return ia.holdForSecrets(state, findings)
+	}
+
+	diff, err := impl.sandbox.Diff()
+	if err != nil {
+		return err
+	}
+	parts := splitDiff(diff, maxPreviewCommentBytes)
+	omitted := 0
+	if len(parts) > maxPreviewComments {
+		omitted = len(parts) - maxPreviewComments
+		parts = parts[:maxPreviewComments]
+	}
+
+	// The parts go first so the comment asking for a decision is the latest one
+	for i, part := range parts {
+		heading := "#### Changes"
+		if len(parts) > 1 {
+			heading = fmt.Sprintf("#### Changes (part %d of %d)", i+1, len(parts))
+		}
+		if err := ia.postComment(owner, repo, issueNumber, heading+"\n\n````diff\n"+part+"````"); err != nil {
+			return fmt.Errorf("failed to create comment: %w", err)
+		}
+	}
+
+	summary := "I've made the changes for this issue and they're posted above for review. Run `" + core.CommandPrefix + " apply` to push them and open the pull request, or `" + core.CommandPrefix + " retry` to start over."
+	if omitted > 0 {
+		summary += fmt.Sprintf(" The diff is too long to post in full: %d more part(s) will be in the pull request.", omitted)
+	}
+	comment := botComment{
+		Heading: "👀 Changes ready for review",
+		Summary: summary,
+	}.String()
+	return ia.holdImplementation(impl, "previewing", comment)
+}
+
+// splitDiff cuts a diff at line boundaries into parts of at most max bytes. Lines longer
+// than max are cut.
+func splitDiff(diff string, max int) []string {
+	var parts []string
+	var part strings.Builder
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if line == "" {
+			continue
+		}
+		if !strings.HasSuffix(line, "\n") {
+			line += "\n"
+		}
+		if len(line) > max {
+			line = strings.ToValidUTF8(line[:max-len("...\n")], "") + "...\n"
+		}
+		if part.Len()+len(line) > max {
+			parts = append(parts, part.String())
+			part.Reset()
+		}
+		part.WriteString(line)
+	}
+	if part.Len() > 0 {
+		parts = append(parts, part.String())
+	}
+	return parts
+}
+
+// commandApply resumes an implementation waiting on its previewed diff, pushing the changes
+func (ia *IssueAgent) commandApply(state *core.State, number int, author string) error {
+	if state.Status != "previewing" {
+		return ia.commandReply(state, number, "I don't have any changes waiting to be applied for this issue.")
+	}
+	state.Logger().Info("👀 Pushing the previewed changes", "applied_by", author)
+	return ia.resumeHeld(state, func(progress *implementationProgress) {
+		progress.AppliedBy = author
+	})
+}
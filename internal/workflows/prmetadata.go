@@ -0,0 +1,19 @@
+package workflows
+
+import "NyteBubo/internal/core"
+
+// applyPullRequestMetadata adds the repository's configured labels, assignees, reviewers
+// and milestone to a newly opened pull request. Failures are only logged, since the pull
+// request is already open.
+func (ia *IssueAgent) applyPullRequestMetadata(state *core.State, prNumber int) {
+	settings := ia.config.ForRepo(state.Owner, state.Repo).PullRequests
+	if len(settings.Labels) == 0 && len(settings.Assignees) == 0 && len(settings.Reviewers) == 0 &&
+		len(settings.TeamReviewers) == 0 && settings.Milestone == "" {
+		return
+	}
+
+	state.Logger().Info("🏷️  Applying pull request settings", "pr", prNumber)
+	if err := ia.host(state.Owner, state.Repo).SetPullRequestMetadata(state.Owner, state.Repo, prNumber, pullRequestMetadata(settings)); err != nil {
+		state.Logger().Warn("⚠️  Failed to apply some pull request settings", "pr", prNumber, "error", err)
+	}
+}
@@ -0,0 +1,99 @@
+package workflows
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"NyteBubo/internal/core"
+)
+
+// progressEditInterval is the minimum time between edits of a progress comment
+const progressEditInterval = 5 * time.Second
+
+// progressComment keeps a single issue comment updated while code is being generated
+type progressComment struct {
+	ia     *IssueAgent
+	owner  string
+	repo   string
+	number int
+
+	mu        sync.Mutex
+	commentID int64
+	lastEdit  time.Time
+}
+
+// withProgress returns a client that reports streaming progress as an edited issue comment,
+// or the client unchanged when progress comments are disabled
+func (ia *IssueAgent) withProgress(claude *core.ClaudeAgent, owner, repo string, issueNumber int) *core.ClaudeAgent {
+	if !ia.config.Streaming.Enabled || !ia.config.Streaming.ProgressComment {
+		return claude
+	}
+
+	tracker := &progressComment{ia: ia, owner: owner, repo: repo, number: issueNumber}
+	return claude.WithProgress(tracker.update)
+}
+
+// update creates, edits or removes the progress comment for a streaming update
+func (pc *progressComment) update(progress core.StreamProgress) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	// The comment is only useful while generation is running; the result is reported separately
+	if progress.Done {
+		if pc.commentID != 0 {
+			if err := pc.ia.host(pc.owner, pc.repo).DeleteIssueComment(pc.owner, pc.repo, pc.number, pc.commentID); err != nil {
+				pc.logger().Warn("⚠️  Failed to remove progress comment", "error", err)
+			}
+			pc.commentID = 0
+		}
+		return
+	}
+
+	body := progressBody(progress)
+	if pc.commentID == 0 {
+		id, err := pc.ia.host(pc.owner, pc.repo).CreateIssueCommentWithID(pc.owner, pc.repo, pc.number, body)
+		if err != nil {
+			pc.logger().Warn("⚠️  Failed to post progress comment", "error", err)
+			return
+		}
+		pc.commentID = id
+		pc.lastEdit = time.Now()
+		return
+	}
+
+	if time.Since(pc.lastEdit) < progressEditInterval {
+		return
+	}
+	if err := pc.ia.host(pc.owner, pc.repo).EditIssueComment(pc.owner, pc.repo, pc.number, pc.commentID, body); err != nil {
+		pc.logger().Warn("⚠️  Failed to update progress comment", "error", err)
+		return
+	}
+	pc.lastEdit = time.Now()
+}
+
+// logger returns a logger tagged with the issue
+func (pc *progressComment) logger() *slog.Logger {
+	return core.IssueLogger(pc.owner, pc.repo, pc.number)
+}
+
+// progressBody renders the progress comment
+func progressBody(progress core.StreamProgress) string {
+	current := progress.Files[len(progress.Files)-1]
+
+	var done []string
+	for _, file := range progress.Files[:len(progress.Files)-1] {
+		done = append(done, "- ✅ `"+file+"`")
+	}
+	done = append(done, "- ✍️ `"+current+"`")
+
+	return botComment{
+		Heading: "⏳ Generating code",
+		Summary: fmt.Sprintf("Writing file %d: `%s` (%d KB generated so far)", len(progress.Files), current, progress.Chars/1024),
+		Sections: []commentSection{
+			{Title: "Files", Body: strings.Join(done, "\n")},
+		},
+	}.String()
+}
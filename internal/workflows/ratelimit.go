@@ -0,0 +1,147 @@
+package workflows
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"NyteBubo/internal/core"
+)
+
+// commentThrottle spaces out the bot's comments and batches incoming comments per issue
+type commentThrottle struct {
+	mu       sync.Mutex
+	lastPost map[string]time.Time
+	pending  map[string]*pendingComments
+	busy     map[string]*sync.Mutex
+}
+
+// pendingComments are incoming comments waiting for the batch window to close
+type pendingComments struct {
+	bodies []string
+	timer  *time.Timer
+}
+
+// issueKey identifies an issue or pull request within the throttle maps
+func issueKey(owner, repo string, number int) string {
+	return fmt.Sprintf("%s/%s#%d", owner, repo, number)
+}
+
+// postComment posts a comment, waiting if the bot commented on the same issue too recently
+func (ia *IssueAgent) postComment(owner, repo string, number int, body string) error {
+	ia.waitToComment(owner, repo, number)
+	return ia.host(owner, repo).CreateIssueComment(owner, repo, number, body)
+}
+
+// postCommentWithID posts a rate-limited comment and returns its ID
+func (ia *IssueAgent) postCommentWithID(owner, repo string, number int, body string) (int64, error) {
+	ia.waitToComment(owner, repo, number)
+	return ia.host(owner, repo).CreateIssueCommentWithID(owner, repo, number, body)
+}
+
+// postPRComment posts a rate-limited comment on a pull request
+func (ia *IssueAgent) postPRComment(owner, repo string, number int, body string) error {
+	ia.waitToComment(owner, repo, number)
+	return ia.host(owner, repo).CreatePullRequestComment(owner, repo, number, body)
+}
+
+// waitToComment blocks until the bot may comment on an issue again
+func (ia *IssueAgent) waitToComment(owner, repo string, number int) {
+	interval := time.Duration(ia.config.CommentRateLimit.MinIntervalSeconds) * time.Second
+	key := issueKey(owner, repo, number)
+
+	if interval > 0 {
+		ia.throttle.mu.Lock()
+		if ia.throttle.lastPost == nil {
+			ia.throttle.lastPost = make(map[string]time.Time)
+		}
+		// Reserve the next slot so concurrent posts queue up behind each other
+		next := ia.throttle.lastPost[key].Add(interval)
+		if now := time.Now(); next.Before(now) {
+			next = now
+		}
+		ia.throttle.lastPost[key] = next
+		ia.throttle.mu.Unlock()
+
+		if wait := time.Until(next); wait > 0 {
+			core.IssueLogger(owner, repo, number).Info("⏳ Rate limiting comments", "wait", wait.Round(time.Second))
+			time.Sleep(wait)
+		}
+	}
+}
+
+// QueueIssueComment collects comments arriving within the batch window and handles them
+// together, so a burst of comments gets one response
+func (ia *IssueAgent) QueueIssueComment(owner, repo string, issueNumber int, commentBody string) {
+	ia.queueComment("issue", owner, repo, issueNumber, commentBody, ia.HandleIssueComments)
+}
+
+// QueuePRComment collects PR comments arriving within the batch window into one round of feedback
+func (ia *IssueAgent) QueuePRComment(owner, repo string, prNumber int, commentBody string) {
+	ia.queueComment("pr", owner, repo, prNumber, commentBody, ia.HandlePRComments)
+}
+
+// queueComment adds a comment to the pending batch and (re)starts the batch window
+func (ia *IssueAgent) queueComment(kind, owner, repo string, number int, body string, handle func(owner, repo string, number int, bodies []string) error) {
+	window := time.Duration(ia.config.CommentRateLimit.BatchWindowSeconds) * time.Second
+	key := kind + ":" + issueKey(owner, repo, number)
+
+	flush := func() {
+		ia.throttle.mu.Lock()
+		batch := ia.throttle.pending[key]
+		delete(ia.throttle.pending, key)
+		ia.throttle.mu.Unlock()
+
+		if batch == nil || len(batch.bodies) == 0 {
+			return
+		}
+
+		// Batches for the same issue are handled one at a time
+		lock := ia.busyLock(key)
+		lock.Lock()
+		defer lock.Unlock()
+		if err := handle(owner, repo, number, batch.bodies); err != nil {
+			core.IssueLogger(owner, repo, number).Error("Error handling comments", "kind", kind, "error", err)
+		}
+	}
+
+	ia.throttle.mu.Lock()
+	if ia.throttle.pending == nil {
+		ia.throttle.pending = make(map[string]*pendingComments)
+	}
+	batch, ok := ia.throttle.pending[key]
+	if !ok {
+		batch = &pendingComments{}
+		ia.throttle.pending[key] = batch
+	}
+	batch.bodies = append(batch.bodies, body)
+
+	if window <= 0 {
+		ia.throttle.mu.Unlock()
+		flush()
+		return
+	}
+
+	// Each new comment extends the window so a burst is answered once it settles
+	if batch.timer != nil {
+		batch.timer.Stop()
+	}
+	batch.timer = time.AfterFunc(window, flush)
+	ia.throttle.mu.Unlock()
+}
+
+// busyLock returns the lock that serializes work on an issue or pull request (keyed like
+// the comment batches), creating it on first use
+func (ia *IssueAgent) busyLock(key string) *sync.Mutex {
+	ia.throttle.mu.Lock()
+	defer ia.throttle.mu.Unlock()
+	if ia.throttle.busy == nil {
+		ia.throttle.busy = make(map[string]*sync.Mutex)
+	}
+	lock, ok := ia.throttle.busy[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		ia.throttle.busy[key] = lock
+	}
+	return lock
+}
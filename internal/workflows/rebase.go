@@ -0,0 +1,157 @@
+package workflows
+
+import (
+	"fmt"
+	"strings"
+
+	"NyteBubo/internal/core"
+	"github.com/google/go-github/v63/github"
+)
+
+// maxConflictRounds bounds how many conflicting commits one rebase resolves with the AI
+const maxConflictRounds = 10
+
+// hasConflicts reports whether the host found that a pull request conflicts with its
+// base branch. GitHub reports no mergeability while it is still computing it.
+func hasConflicts(pr *github.PullRequest) bool {
+	return pr.Mergeable != nil && !pr.GetMergeable()
+}
+
+// resolveConflicts rebases a conflicting bot pull request onto its base branch, asking the
+// AI to resolve the conflicts, and reports the outcome on the pull request. Each pair of
+// head and base commits is only attempted once.
+func (ia *IssueAgent) resolveConflicts(state *core.State, pr *github.PullRequest) error {
+	attempt := pr.GetHead().GetSHA() + ":" + pr.GetBase().GetSHA()
+	if state.ConflictAttempt == attempt || state.BranchName == "" {
+		return nil
+	}
+	if state.Status == "aborted" || state.Status == "budget_exceeded" {
+		return nil
+	}
+	if paused, err := ia.pauseForBudget(state); paused || err != nil {
+		return err
+	}
+
+	owner, repo, prNumber, base := state.Owner, state.Repo, pr.GetNumber(), pr.GetBase().GetRef()
+	logger := state.Logger()
+	logger.Info("🔀 PR conflicts with its base branch, rebasing", "pr", prNumber, "base", base)
+
+	resolved, explanation, rebaseErr := ia.rebaseBranch(state, base)
+	state.ConflictAttempt = attempt
+	if err := ia.stateManager.SaveState(state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	var comment string
+	if findings := secretsFound(rebaseErr); findings != nil {
+		comment = secretsComment(findings)
+	} else if rebaseErr != nil {
+		logger.Warn("⚠️  Couldn't resolve conflicts", "pr", prNumber, "error", rebaseErr)
+		comment = botComment{
+			Heading: "⚠️ Merge conflicts",
+			Summary: fmt.Sprintf("This pull request conflicts with `%s` and I couldn't resolve the conflicts automatically. I'll try again if either branch changes, or the conflicts can be resolved by hand.", base),
+		}.String()
+	} else {
+		logger.Info("✅ Rebased PR onto its base branch", "pr", prNumber, "resolved", len(resolved))
+		summary := fmt.Sprintf("`%s` moved ahead and this pull request no longer merged cleanly, so I rebased it onto the latest `%s`.", base, base)
+		if len(resolved) > 0 {
+			summary += " I resolved conflicts in:\n"
+			for _, path := range resolved {
+				summary += fmt.Sprintf("\n- `%s`", path)
+			}
+		}
+		comment = botComment{
+			Heading:  "🔄 Rebased onto " + base,
+			Summary:  summary,
+			Sections: []commentSection{{Title: "How the conflicts were resolved", Body: explanation}},
+		}.String()
+	}
+
+	if err := ia.postPRComment(owner, repo, prNumber, comment); err != nil {
+		return fmt.Errorf("failed to create comment: %w", err)
+	}
+	return nil
+}
+
+// rebaseBranch rebases the issue's branch onto base in a sandbox, resolving conflicts with
+// the AI, and force-pushes the result. It returns the files whose conflicts were resolved
+// and the AI's explanation of how.
+func (ia *IssueAgent) rebaseBranch(state *core.State, base string) ([]string, string, error) {
+	sandbox, err := ia.newSandbox(state.Owner, state.Repo, state.IssueNumber)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create sandbox: %w", err)
+	}
+	defer func() {
+		if err := sandbox.Cleanup(); err != nil {
+			sandbox.Logger().Warn("⚠️  Failed to clean up sandbox", "error", err)
+		}
+	}()
+
+	if err := sandbox.CloneRepo(); err != nil {
+		return nil, "", fmt.Errorf("failed to clone repo: %w", err)
+	}
+	if err := sandbox.CheckoutBranch(state.BranchName); err != nil {
+		return nil, "", err
+	}
+
+	conflicts, err := sandbox.Rebase(base)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var resolved, explanations []string
+	seen := make(map[string]bool)
+	for round := 1; len(conflicts) > 0; round++ {
+		if err := ia.ctx.Err(); err != nil {
+			return nil, "", err
+		}
+		if round > maxConflictRounds {
+			return nil, "", fmt.Errorf("more than %d commits conflicted", maxConflictRounds)
+		}
+		sandbox.Logger().Info("🤖 Asking AI to resolve conflicts", "files", len(conflicts), "round", round)
+
+		files := make(map[string]string, len(conflicts))
+		for _, path := range conflicts {
+			content, err := sandbox.ReadFile(path)
+			if err != nil {
+				// Deleted on one side; there's no merged content to work from
+				return nil, "", fmt.Errorf("can't resolve conflict in %s: %w", path, err)
+			}
+			files[path] = content
+		}
+
+		response, usage, err := ia.claudeFor(state).ResolveConflicts(base, files, state.Messages())
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get conflict resolution: %w", err)
+		}
+		state.AddUsage(usage)
+
+		contents := parseCodeChanges(response)
+		for _, path := range conflicts {
+			content, ok := contents[path]
+			if !ok || strings.Contains(content, "<<<<<<<") || strings.Contains(content, ">>>>>>>") {
+				return nil, "", fmt.Errorf("no resolution for %s", path)
+			}
+			if err := sandbox.WriteFile(path, content); err != nil {
+				return nil, "", fmt.Errorf("failed to write file %s: %w", path, err)
+			}
+			if !seen[path] {
+				seen[path] = true
+				resolved = append(resolved, path)
+			}
+		}
+		if explanation := strings.TrimSpace(stripCodeBlocks(response)); explanation != "" {
+			explanations = append(explanations, explanation)
+		}
+
+		conflicts, err = sandbox.ContinueRebase()
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := sandbox.ForcePush(state.BranchName); err != nil {
+		return nil, "", err
+	}
+	return resolved, strings.Join(explanations, "\n\n"), nil
+}
@@ -0,0 +1,231 @@
+package workflows
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"NyteBubo/internal/core"
+)
+
+const (
+	// defaultRepoContextTokens is the prompt budget for existing file contents
+	defaultRepoContextTokens = 12000
+	// maxContextFileSize skips files too large to be useful context
+	maxContextFileSize = 100 * 1024
+)
+
+var (
+	// contextWordRe extracts identifier-like words from issue text
+	contextWordRe = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]{2,}`)
+	// contextPathRe matches file paths or names mentioned in issue text
+	contextPathRe = regexp.MustCompile(`[\w./-]+\.[A-Za-z0-9]{1,8}\b`)
+	// camelBoundaryRe splits camelCase identifiers into words
+	camelBoundaryRe = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+)
+
+// contextStopWords are common words that say nothing about which files are relevant
+var contextStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "this": true, "that": true, "should": true,
+	"would": true, "could": true, "when": true, "from": true, "into": true, "have": true, "has": true,
+	"not": true, "are": true, "was": true, "but": true, "can": true, "will": true, "please": true,
+	"issue": true, "add": true, "new": true, "use": true, "using": true, "make": true, "also": true,
+	"there": true, "what": true, "which": true, "need": true, "needs": true, "like": true, "some": true,
+	"all": true, "any": true, "only": true, "then": true, "than": true, "now": true, "instead": true,
+	"implement": true, "changes": true, "change": true, "file": true, "files": true, "code": true,
+}
+
+// skippedContextDirs are directories whose files are never useful context
+var skippedContextDirs = []string{"vendor/", "node_modules/", "dist/", "build/", "target/", ".github/"}
+
+// contextCandidate is a repository file scored for relevance to an issue
+type contextCandidate struct {
+	path    string
+	content string
+	score   int
+}
+
+// buildFileContext selects existing files relevant to the issue and renders their
+// contents for the code generation prompt, staying within the configured token budget
+func (ia *IssueAgent) buildFileContext(sandbox *core.Sandbox, files []string, conversation []core.AgentMessage) string {
+	budget := ia.config.RepoContextTokens
+	if budget < 0 {
+		return ""
+	}
+	if budget == 0 {
+		budget = defaultRepoContextTokens
+	}
+
+	var text strings.Builder
+	for _, msg := range conversation {
+		if msg.Role == "user" {
+			text.WriteString(msg.Content + "\n")
+		}
+	}
+	keywords := contextKeywords(text.String())
+	mentioned := mentionedPaths(text.String())
+	if len(keywords) == 0 && len(mentioned) == 0 {
+		return ""
+	}
+
+	var candidates []contextCandidate
+	for _, file := range files {
+		file = filepath.ToSlash(file)
+		if skipContextFile(file) {
+			continue
+		}
+
+		content, err := sandbox.ReadFile(file)
+		if err != nil || len(content) > maxContextFileSize || !utf8.ValidString(content) || strings.ContainsRune(content, 0) {
+			continue
+		}
+
+		score := scoreContextFile(file, content, keywords, mentioned)
+		if score > 0 {
+			candidates = append(candidates, contextCandidate{path: file, content: content, score: score})
+		}
+	}
+
+	// Files next to the strongest matches are likely to share types and helpers
+	boostNeighbours(candidates)
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].path < candidates[j].path
+	})
+
+	var b strings.Builder
+	used := 0
+	var included []string
+	for _, candidate := range candidates {
+		block := fmt.Sprintf("\n--- %s ---\n%s\n", candidate.path, candidate.content)
+		tokens := core.CountTokens(block)
+		if used+tokens > budget {
+			continue
+		}
+		b.WriteString(block)
+		used += tokens
+		included = append(included, candidate.path)
+	}
+	if len(included) == 0 {
+		return ""
+	}
+
+	sandbox.Logger().Info("📚 Including relevant files in the prompt", "files", strings.Join(included, ", "), "tokens", used)
+	return "Contents of existing files that are likely relevant (modify these rather than rewriting from scratch):\n" + b.String()
+}
+
+// contextKeywords extracts distinct lowercase keywords from issue text, splitting identifiers
+func contextKeywords(text string) []string {
+	seen := make(map[string]bool)
+	var keywords []string
+	add := func(word string) {
+		word = strings.ToLower(word)
+		if len(word) < 3 || contextStopWords[word] || seen[word] {
+			return
+		}
+		seen[word] = true
+		keywords = append(keywords, word)
+	}
+
+	for _, word := range contextWordRe.FindAllString(text, -1) {
+		add(word)
+		split := camelBoundaryRe.ReplaceAllString(word, "${1}_${2}")
+		for _, part := range strings.Split(split, "_") {
+			add(part)
+		}
+	}
+	return keywords
+}
+
+// mentionedPaths returns file paths and names referenced in issue text
+func mentionedPaths(text string) []string {
+	var paths []string
+	for _, match := range contextPathRe.FindAllString(text, -1) {
+		match = strings.TrimPrefix(strings.Trim(match, "./"), "/")
+		if match != "" && !strings.HasPrefix(match, "http") {
+			paths = append(paths, strings.ToLower(match))
+		}
+	}
+	return paths
+}
+
+// skipContextFile reports whether a file should never be offered as context
+func skipContextFile(file string) bool {
+	for _, dir := range skippedContextDirs {
+		if strings.HasPrefix(file, dir) || strings.Contains(file, "/"+dir) {
+			return true
+		}
+	}
+	switch path.Base(file) {
+	case "go.sum", "package-lock.json", "yarn.lock", "pnpm-lock.yaml", "Cargo.lock", "poetry.lock":
+		return true
+	}
+	return false
+}
+
+// scoreContextFile rates how relevant a file is to the issue
+func scoreContextFile(file, content string, keywords, mentioned []string) int {
+	lowerPath := strings.ToLower(file)
+	score := 0
+
+	// An explicitly mentioned path is almost certainly relevant
+	for _, m := range mentioned {
+		if lowerPath == m || strings.HasSuffix(lowerPath, "/"+m) {
+			score += 20
+		}
+	}
+
+	pathWords := make(map[string]bool)
+	for _, part := range strings.FieldsFunc(lowerPath, func(r rune) bool {
+		return r == '/' || r == '.' || r == '_' || r == '-'
+	}) {
+		pathWords[part] = true
+	}
+
+	lowerContent := strings.ToLower(content)
+	for _, keyword := range keywords {
+		if pathWords[keyword] {
+			score += 5
+		} else if strings.Contains(lowerPath, keyword) {
+			score += 2
+		}
+		if strings.Contains(lowerContent, keyword) {
+			score++
+		}
+	}
+
+	// A handful of common words matching prose says little; require a stronger signal
+	if score < 3 {
+		return 0
+	}
+	return score
+}
+
+// boostNeighbours raises the score of files in the same directory as the top matches
+func boostNeighbours(candidates []contextCandidate) {
+	best := 0
+	for _, c := range candidates {
+		if c.score > best {
+			best = c.score
+		}
+	}
+
+	topDirs := make(map[string]bool)
+	for _, c := range candidates {
+		if c.score*2 >= best {
+			topDirs[path.Dir(c.path)] = true
+		}
+	}
+	for i := range candidates {
+		if topDirs[path.Dir(candidates[i].path)] {
+			candidates[i].score += 2
+		}
+	}
+}
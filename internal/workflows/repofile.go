@@ -0,0 +1,66 @@
+package workflows
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"NyteBubo/internal/core"
+)
+
+// repoFileCache caches repositories' .nytebubo.yaml, re-fetched as often as instructions
+type repoFileCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedRepoFile
+}
+
+type cachedRepoFile struct {
+	file      *core.RepoFile
+	fetchedAt time.Time
+}
+
+// repoFile returns the settings in a repository's .nytebubo.yaml on its default branch.
+// A missing or invalid file gives empty settings.
+func (ia *IssueAgent) repoFile(owner, repo string) *core.RepoFile {
+	key := owner + "/" + repo
+
+	ia.repoFiles.mu.Lock()
+	if entry, ok := ia.repoFiles.entries[key]; ok && time.Since(entry.fetchedAt) < instructionsCacheTTL {
+		ia.repoFiles.mu.Unlock()
+		return entry.file
+	}
+	ia.repoFiles.mu.Unlock()
+
+	file := &core.RepoFile{}
+	// An empty ref reads from the default branch
+	if content, err := ia.host(owner, repo).GetFileContent(owner, repo, core.RepoFileName, ""); err == nil && strings.TrimSpace(content) != "" {
+		if parsed, err := core.ParseRepoFile(content); err != nil {
+			core.RepoLogger(owner, repo).Warn("⚠️  Ignoring invalid repository settings", "path", core.RepoFileName, "error", err)
+		} else {
+			file = parsed
+			core.RepoLogger(owner, repo).Info("📘 Loaded repository settings", "path", core.RepoFileName)
+		}
+	}
+
+	ia.repoFiles.mu.Lock()
+	if ia.repoFiles.entries == nil {
+		ia.repoFiles.entries = make(map[string]cachedRepoFile)
+	}
+	ia.repoFiles.entries[key] = cachedRepoFile{file: file, fetchedAt: time.Now()}
+	ia.repoFiles.mu.Unlock()
+
+	return file
+}
+
+// triggerLabels returns the labels that start work on a repository's issues: the ones
+// its maintainers chose, or the configured label. It's empty unless the trigger is "label".
+func (ia *IssueAgent) triggerLabels(owner, repo string) []string {
+	label := ia.config.LabelTrigger()
+	if label == "" {
+		return nil
+	}
+	if labels := ia.repoFile(owner, repo).TriggerLabels; len(labels) > 0 {
+		return labels
+	}
+	return []string{label}
+}
@@ -0,0 +1,182 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"NyteBubo/internal/core"
+)
+
+// reportCheckInterval is how often the reporter checks whether a month has ended
+const reportCheckInterval = time.Hour
+
+// lastReportKey is the setting holding the last month ("2006-01") a cost report was posted for
+const lastReportKey = "last_cost_report"
+
+// RunReporter posts the cost report of each month once it has ended, checking now and then
+// every reportCheckInterval, until ctx is cancelled
+func (ia *IssueAgent) RunReporter(ctx context.Context) {
+	slog.Info("📑 Starting monthly cost reporter", "repository", ia.config.Reports.Repository, "gist", ia.config.Reports.Gist)
+	ticker := time.NewTicker(reportCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		lastMonth := time.Now().AddDate(0, -1, 0)
+		if err := ia.reportMonth(lastMonth); err != nil {
+			slog.Error("Error posting monthly cost report", "month", lastMonth.Format("2006-01"), "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reportMonth posts the cost report of the month containing t, unless it was posted already
+func (ia *IssueAgent) reportMonth(t time.Time) error {
+	month := t.Format("2006-01")
+	last, err := ia.stateManager.GetSetting(lastReportKey)
+	if err != nil {
+		return err
+	}
+	if last >= month {
+		return nil
+	}
+
+	urls, err := ia.PostCostReport(t)
+	for _, url := range urls {
+		slog.Info("📑 Posted monthly cost report", "month", month, "url", url)
+	}
+	// A report posted in one place isn't posted again because the other failed
+	if err != nil && len(urls) == 0 {
+		return err
+	}
+	// A dry run or a month without activity posts nothing, so the report is still due
+	if ia.dryRun != nil || len(urls) == 0 {
+		return nil
+	}
+	if setErr := ia.stateManager.SetSetting(lastReportKey, month); setErr != nil {
+		return setErr
+	}
+	return err
+}
+
+// PostCostReport posts the cost report of the month containing t to the configured
+// repository and/or gist and returns where it was posted. Nothing is posted for a month
+// without activity.
+func (ia *IssueAgent) PostCostReport(t time.Time) ([]string, error) {
+	since := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	until := since.AddDate(0, 1, 0)
+	title := fmt.Sprintf("NyteBubo cost report: %s", since.Format("January 2006"))
+
+	report, active, err := ia.CostReport(since, until)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		slog.Debug("No activity to report", "month", since.Format("2006-01"))
+		return nil, nil
+	}
+
+	github := ia.hosts.GitHub()
+	var urls []string
+	if repository := ia.config.Reports.Repository; repository != "" {
+		owner, repo, ok := strings.Cut(repository, "/")
+		if !ok {
+			return urls, fmt.Errorf("invalid reports repository %q (expected owner/repo)", repository)
+		}
+		if ia.dryRun != nil {
+			ia.dryRun.Report(owner, repo, 0, "open cost report issue", "# "+title+"\n\n"+report)
+		} else {
+			issue, err := github.CreateIssue(owner, repo, title, report)
+			if err != nil {
+				return urls, err
+			}
+			urls = append(urls, issue.GetHTMLURL())
+		}
+	}
+	if ia.config.Reports.Gist {
+		filename := fmt.Sprintf("nytebubo-cost-report-%s.md", since.Format("2006-01"))
+		if ia.dryRun != nil {
+			ia.dryRun.Report("gist", strings.TrimSuffix(filename, ".md"), 0, "create cost report gist", "# "+title+"\n\n"+report)
+		} else {
+			url, err := github.CreateGist(title, filename, "# "+title+"\n\n"+report)
+			if err != nil {
+				return urls, err
+			}
+			urls = append(urls, url)
+		}
+	}
+	return urls, nil
+}
+
+// CostReport returns a Markdown summary of the tokens and cost spent between since and
+// until, per repository and per model, and whether there was any activity
+func (ia *IssueAgent) CostReport(since, until time.Time) (string, bool, error) {
+	filter := core.StatsFilter{Since: since, Until: until}
+	byRepo, err := ia.stateManager.LLMCallBreakdown("repository", filter)
+	if err != nil {
+		return "", false, err
+	}
+	byModel, err := ia.stateManager.LLMCallBreakdown("model", filter)
+	if err != nil {
+		return "", false, err
+	}
+	states, err := ia.stateManager.ListIssueStats(filter)
+	if err != nil {
+		return "", false, err
+	}
+	if len(byRepo) == 0 && len(states) == 0 {
+		return "", false, nil
+	}
+
+	issues := make(map[string]int)
+	for _, state := range states {
+		issues[state.Owner+"/"+state.Repo]++
+	}
+
+	var total core.CallBreakdown
+	var b strings.Builder
+	b.WriteString("## By repository\n\n")
+	b.WriteString("| Repository | Issues | Calls | Input tokens | Output tokens | Cost |\n")
+	b.WriteString("|------------|-------:|------:|-------------:|--------------:|-----:|\n")
+	for _, row := range byRepo {
+		repository := row.Key
+		if repository == "/" {
+			repository = "_other_"
+		}
+		b.WriteString(fmt.Sprintf("| %s | %d | %d | %d | %d | $%.4f |\n",
+			repository, issues[row.Key], row.Calls, row.InputTokens, row.OutputTokens, row.Cost))
+		delete(issues, row.Key)
+		total.Calls += row.Calls
+		total.InputTokens += row.InputTokens
+		total.OutputTokens += row.OutputTokens
+		total.Cost += row.Cost
+	}
+	// Issues worked on without LLM calls recorded, e.g. before calls were logged
+	for _, repository := range slices.Sorted(maps.Keys(issues)) {
+		b.WriteString(fmt.Sprintf("| %s | %d | 0 | 0 | 0 | $0.0000 |\n", repository, issues[repository]))
+	}
+	b.WriteString(fmt.Sprintf("| **Total** | **%d** | **%d** | **%d** | **%d** | **$%.4f** |\n",
+		len(states), total.Calls, total.InputTokens, total.OutputTokens, total.Cost))
+
+	if len(byModel) > 0 {
+		b.WriteString("\n## By model\n\n")
+		b.WriteString("| Model | Calls | Failed | Cost |\n")
+		b.WriteString("|-------|------:|-------:|-----:|\n")
+		for _, row := range byModel {
+			b.WriteString(fmt.Sprintf("| `%s` | %d | %d | $%.4f |\n", row.Key, row.Calls, row.Failures, row.Cost))
+		}
+	}
+
+	b.WriteString(fmt.Sprintf("\n_From %s up to %s. Generated by NyteBubo; `nytebubo stats` has the per-issue breakdown._\n",
+		since.Format("2006-01-02"), until.Format("2006-01-02")))
+	return b.String(), true, nil
+}
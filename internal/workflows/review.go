@@ -0,0 +1,188 @@
+package workflows
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"NyteBubo/internal/core"
+)
+
+// maxReviewDiffBytes caps how much of a pull request's diff is sent for review
+const maxReviewDiffBytes = 100 * 1024
+
+// hunkHeaderRe matches a unified diff hunk header and captures the new file's start line
+var hunkHeaderRe = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// HandleReviewRequest reviews a pull request when the bot is requested as a reviewer
+func (ia *IssueAgent) HandleReviewRequest(owner, repo string, prNumber int, reviewer string) error {
+	login, err := ia.botLogin(owner, repo)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(reviewer, login) {
+		return nil
+	}
+	return ia.ReviewPullRequest(owner, repo, prNumber, true)
+}
+
+// ReviewPullRequest posts an AI review of a pull request someone else opened: inline comments
+// on the lines it adds and a summary. Requested reviews run again whenever the pull request
+// has new commits; unrequested ones (review_prs) run once per pull request.
+func (ia *IssueAgent) ReviewPullRequest(owner, repo string, prNumber int, requested bool) error {
+	if !requested && !ia.config.ForRepo(owner, repo).ReviewPRs {
+		return nil
+	}
+
+	lock := ia.busyLock("review:" + issueKey(owner, repo, prNumber))
+	lock.Lock()
+	defer lock.Unlock()
+
+	logger := core.RepoLogger(owner, repo).With("pr", prNumber)
+	host := ia.host(owner, repo)
+
+	pr, err := host.GetPullRequest(owner, repo, prNumber)
+	if err != nil {
+		return err
+	}
+	if pr.GetState() != "open" || (pr.GetDraft() && !requested) {
+		return nil
+	}
+	login, err := ia.botLogin(owner, repo)
+	if err != nil {
+		return err
+	}
+	if pr.GetUser().GetLogin() == login {
+		return nil
+	}
+	if owned, err := ia.OwnsPullRequest(owner, repo, prNumber); err != nil || owned {
+		return err
+	}
+
+	state, err := ia.stateManager.GetReviewState(owner, repo, prNumber)
+	if err != nil {
+		return err
+	}
+	if state != nil && (!requested || state.ReviewedSHA == pr.GetHead().GetSHA()) {
+		return nil
+	}
+	if state == nil {
+		state = &core.ReviewState{Owner: owner, Repo: repo, PRNumber: prNumber}
+	}
+
+	if reason, err := ia.budgetExceeded(&core.State{Owner: owner, Repo: repo}); err != nil {
+		return fmt.Errorf("failed to check budget: %w", err)
+	} else if reason != "" {
+		logger.Warn("💸 Skipping pull request review", "reason", reason)
+		return nil
+	}
+
+	state.Status = "reviewing"
+	if err := ia.stateManager.SaveReviewState(state); err != nil {
+		return err
+	}
+	logger.Info("🔍 Reviewing pull request", "requested", requested)
+
+	diff, err := host.GetPullRequestDiff(owner, repo, prNumber)
+	if err != nil {
+		return err
+	}
+	numbered, added := numberDiff(diff)
+
+	claude := ia.claude.WithLogger(logger).WithIssue(owner, repo, prNumber)
+	if instructions := ia.repoInstructions(owner, repo); instructions != "" {
+		claude = claude.WithInstructions(instructions)
+	}
+	review, usage, err := claude.ReviewPullRequest(pr.GetTitle(), pr.GetBody(), numbered)
+	state.AddUsage(usage)
+	if err != nil {
+		if saveErr := ia.stateManager.SaveReviewState(state); saveErr != nil {
+			logger.Warn("⚠️  Failed to save review state", "error", saveErr)
+		}
+		return fmt.Errorf("failed to review pull request: %w", err)
+	}
+
+	comments, body := reviewComments(review, added)
+	if err := host.CreateReview(owner, repo, pr, body, comments); err != nil {
+		return err
+	}
+	logger.Info("✅ Review posted", "comments", len(comments))
+
+	state.Status = "reviewed"
+	state.ReviewedSHA = pr.GetHead().GetSHA()
+	state.Reviews++
+	return ia.stateManager.SaveReviewState(state)
+}
+
+// reviewComments splits an AI review into inline comments on added lines and the review
+// body. Comments that don't point at an added line are listed in the body instead.
+func reviewComments(review core.CodeReview, added map[string]map[int]bool) ([]core.ReviewComment, string) {
+	var comments []core.ReviewComment
+	var other strings.Builder
+	for _, comment := range review.Comments {
+		body := strings.TrimSpace(comment.Body)
+		if body == "" {
+			continue
+		}
+		if added[comment.Path][comment.Line] {
+			comments = append(comments, core.ReviewComment{Path: comment.Path, Line: comment.Line, Body: body})
+			continue
+		}
+		other.WriteString(fmt.Sprintf("- `%s:%d`: %s\n", comment.Path, comment.Line, body))
+	}
+
+	var sections []commentSection
+	if other.Len() > 0 {
+		sections = append(sections, commentSection{Title: "Other notes", Body: other.String()})
+	}
+	body := botComment{
+		Heading:  "🔍 Code review",
+		Summary:  review.Summary,
+		Sections: sections,
+	}.String()
+	return comments, body
+}
+
+// numberDiff prefixes each added line of a unified diff with its line number in the new
+// file and returns the annotated diff with the added lines per file. Diffs longer than
+// maxReviewDiffBytes are cut at a line boundary.
+func numberDiff(diff string) (string, map[string]map[int]bool) {
+	added := make(map[string]map[int]bool)
+	var b strings.Builder
+
+	path, line, inHunk := "", 0, false
+	for _, text := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+		if b.Len()+len(text) > maxReviewDiffBytes {
+			b.WriteString("... (diff truncated)\n")
+			break
+		}
+
+		switch {
+		case strings.HasPrefix(text, "diff "):
+			path, inHunk = "", false
+		case !inHunk && strings.HasPrefix(text, "+++ "):
+			path = strings.TrimPrefix(strings.TrimPrefix(text, "+++ "), "b/")
+			if path == "/dev/null" {
+				path = ""
+			}
+		case hunkHeaderRe.MatchString(text):
+			start, _ := strconv.Atoi(hunkHeaderRe.FindStringSubmatch(text)[1])
+			line, inHunk = start, true
+		case inHunk && strings.HasPrefix(text, "+"):
+			if path != "" {
+				if added[path] == nil {
+					added[path] = make(map[int]bool)
+				}
+				added[path][line] = true
+			}
+			text = fmt.Sprintf("+%4d| %s", line, text[1:])
+			line++
+		case inHunk && strings.HasPrefix(text, " "):
+			line++
+		}
+		b.WriteString(text + "\n")
+	}
+
+	return b.String(), added
+}
@@ -0,0 +1,34 @@
+package workflows
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"NyteBubo/internal/core"
+)
+
+// secretsComment asks for a person to look at changes that weren't pushed because they look
+// like they contain credentials. The matched values themselves are never repeated.
+func secretsComment(findings []core.SecretFinding) string {
+	lines := make([]string, len(findings))
+	for i, finding := range findings {
+		lines[i] = fmt.Sprintf("- `%s:%d`: %s", finding.Path, finding.Line, finding.Kind)
+	}
+	return botComment{
+		Heading: "🔐 Possible secrets found",
+		Summary: "My changes contain lines that look like API keys, private keys or tokens, so I didn't push them. Please have a maintainer review this before I continue.",
+		Sections: []commentSection{
+			{Title: "Findings", Body: strings.Join(lines, "\n")},
+		},
+	}.String()
+}
+
+// secretsFound returns the findings if err is a refused push, or nil
+func secretsFound(err error) []core.SecretFinding {
+	var secretsErr *core.SecretsFoundError
+	if errors.As(err, &secretsErr) {
+		return secretsErr.Findings
+	}
+	return nil
+}
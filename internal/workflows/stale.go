@@ -0,0 +1,66 @@
+package workflows
+
+import (
+	"fmt"
+	"time"
+
+	"NyteBubo/internal/core"
+)
+
+// HandleStale reminds the participants of an issue waiting for clarification, or parks it as stale
+func (ia *IssueAgent) HandleStale(owner, repo string, issueNumber int, expire bool) error {
+	state, err := ia.stateManager.GetState(owner, repo, issueNumber)
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+	if state == nil || state.Status != "waiting_for_clarification" {
+		return nil
+	}
+
+	if !expire {
+		comment := botComment{
+			Heading: "⏰ Still waiting for clarification",
+			Summary: "I'm still waiting on answers to the questions above before I can start implementing this issue. Reply here whenever you're ready and I'll pick it back up.",
+		}.String()
+		if err := ia.postComment(owner, repo, issueNumber, comment); err != nil {
+			return fmt.Errorf("failed to create comment: %w", err)
+		}
+
+		now := time.Now()
+		state.ReminderSentAt = &now
+		if err := ia.stateManager.SaveState(state); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+		return nil
+	}
+
+	summary := "I haven't heard back, so I'm setting this issue aside to keep my queue clear."
+	if ia.config.Stale.Unassign {
+		summary += " I've unassigned myself; reassign me or reply here to start again."
+	} else {
+		summary += " Reply here to pick the conversation back up."
+	}
+	comment := botComment{
+		Heading: "💤 Marked as stale",
+		Summary: summary,
+	}.String()
+	if err := ia.postComment(owner, repo, issueNumber, comment); err != nil {
+		return fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	if ia.config.Stale.Unassign {
+		login, err := ia.botLogin(owner, repo)
+		if err != nil {
+			return err
+		}
+		if err := ia.host(owner, repo).RemoveAssignee(owner, repo, issueNumber, login); err != nil {
+			core.IssueLogger(owner, repo, issueNumber).Warn("⚠️  Failed to unassign from issue", "error", err)
+		}
+	}
+
+	state.Status = "stale"
+	if err := ia.stateManager.SaveState(state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package workflows
+
+import (
+	"NyteBubo/internal/core"
+)
+
+// summaryKeepMessages is how many of the latest messages stay verbatim when a conversation
+// is summarized
+const summaryKeepMessages = 4
+
+// summarizeConversation replaces the turns between the issue and the latest messages with
+// a summary once the conversation grows past summary_threshold tokens. If summarizing
+// fails the conversation is left as it is.
+func (ia *IssueAgent) summarizeConversation(claude *core.ClaudeAgent, state *core.State) {
+	threshold := ia.config.SummaryThreshold
+	if threshold <= 0 || len(state.Conversation) <= summaryKeepMessages+1 {
+		return
+	}
+	tokens := core.CountPromptTokens("", state.Messages())
+	if tokens <= threshold {
+		return
+	}
+
+	logger := state.Logger()
+	end := len(state.Conversation) - summaryKeepMessages
+	logger.Info("🗜️  Summarizing earlier conversation", "messages", end-1, "tokens", tokens, "threshold", threshold)
+
+	summary, usage, err := claude.SummarizeConversation(state.ConversationSummary, state.Conversation[1:end])
+	state.AddUsage(usage)
+	if err != nil {
+		logger.Warn("⚠️  Failed to summarize the conversation", "error", err)
+		return
+	}
+
+	state.ConversationSummary = summary
+	state.Conversation = append(state.Conversation[:1:1], state.Conversation[end:]...)
+	logger.Info("✅ Conversation summarized", "tokens", core.CountPromptTokens("", state.Messages()))
+}
@@ -0,0 +1,125 @@
+package workflows
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"NyteBubo/internal/core"
+	"NyteBubo/internal/types"
+
+	"github.com/google/go-github/v63/github"
+)
+
+// Templates that can override pull request text and the bot's canned comments
+const (
+	templatePRTitle       = "pr_title"
+	templatePRBody        = "pr_body"
+	templateAnalysis      = "analysis"
+	templatePlanApproval  = "plan_approval"
+	templatePROpened      = "pr_opened"
+	templateFormatFailure = "format_failure"
+)
+
+// templateNames lists every template that can be overridden
+var templateNames = []string{templatePRTitle, templatePRBody, templateAnalysis, templatePlanApproval, templatePROpened, templateFormatFailure}
+
+// templateData is what templates can refer to. Fields that don't apply to a template are empty.
+type templateData struct {
+	Owner            string
+	Repo             string
+	IssueNumber      int
+	IssueTitle       string
+	IssueURL         string
+	PRNumber         int
+	Summary          string // The changes, analysis, plan or generated response the text is about
+	VerificationNote string // Build/test failure details, empty when verification passed
+	Verified         bool
+	Part             int    // Part of a split issue the pull request delivers, 0 when the issue isn't split
+	Parts            int    // Pull requests the issue is split into
+	Permission       string // Permission needed to approve a plan
+	Default          string // The built-in text, for templates that only add to it
+}
+
+// loadTemplates parses the templates set in config and in templates_dir. Templates set in
+// config take precedence over files.
+func loadTemplates(config types.Config) (map[string]*template.Template, error) {
+	sources := make(map[string]string)
+	if config.TemplatesDir != "" {
+		for _, name := range templateNames {
+			content, err := os.ReadFile(filepath.Join(config.TemplatesDir, name+".tmpl"))
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read template %s: %w", name, err)
+			}
+			sources[name] = string(content)
+		}
+	}
+	for name, text := range config.Templates {
+		if !isTemplateName(name) {
+			return nil, fmt.Errorf("unknown template %q (expected one of %s)", name, strings.Join(templateNames, ", "))
+		}
+		sources[name] = text
+	}
+
+	templates := make(map[string]*template.Template, len(sources))
+	for name, text := range sources {
+		tmpl, err := template.New(name).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+		}
+		templates[name] = tmpl
+	}
+	return templates, nil
+}
+
+func isTemplateName(name string) bool {
+	for _, known := range templateNames {
+		if name == known {
+			return true
+		}
+	}
+	return false
+}
+
+// issueTemplateData returns the template data describing an issue
+func issueTemplateData(owner, repo string, issue *github.Issue) templateData {
+	return templateData{
+		Owner:       owner,
+		Repo:        repo,
+		IssueNumber: issue.GetNumber(),
+		IssueTitle:  issue.GetTitle(),
+		IssueURL:    issue.GetHTMLURL(),
+	}
+}
+
+// render renders a template with data, or returns data.Default when the template isn't
+// configured or fails. The issue is looked up when data doesn't describe it yet.
+func (ia *IssueAgent) render(name string, data templateData) string {
+	tmpl := ia.templates[name]
+	if tmpl == nil {
+		return data.Default
+	}
+
+	logger := core.IssueLogger(data.Owner, data.Repo, data.IssueNumber)
+	if data.IssueTitle == "" {
+		if issue, err := ia.host(data.Owner, data.Repo).GetIssue(data.Owner, data.Repo, data.IssueNumber); err != nil {
+			logger.Warn("⚠️  Failed to get the issue for a template", "template", name, "error", err)
+		} else {
+			data.IssueTitle, data.IssueURL = issue.GetTitle(), issue.GetHTMLURL()
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		logger.Warn("⚠️  Failed to render template, using the default text", "template", name, "error", err)
+		return data.Default
+	}
+	return buf.String()
+}
@@ -0,0 +1,42 @@
+package workflows
+
+import (
+	"fmt"
+
+	"NyteBubo/internal/core"
+)
+
+// defaultMaxAgentSteps is how many tool calls the AI may make per generation in agent mode
+const defaultMaxAgentSteps = 40
+
+// generateWithTools lets the AI explore the sandbox and make the changes with tools, then
+// describes everything changed in the sandbox as a structured code response. Applying that
+// response to the sandbox again changes nothing, so it can be checkpointed like any other.
+func (ia *IssueAgent) generateWithTools(claude *core.ClaudeAgent, sandbox *core.Sandbox, state *core.State, task, repoContext, language string, conversation []core.AgentMessage) (string, core.TokenUsage, error) {
+	runTests := func() (string, bool) {
+		if syntaxErrors, err := sandbox.CheckSyntax(); err == nil && len(syntaxErrors) > 0 {
+			return "Some of the changed files don't parse:\n" + logBlock(syntaxReport(syntaxErrors)), false
+		}
+		buildOutput, testOutput, _, err := ia.verifySandbox(sandbox, state.Owner, state.Repo)
+		output := fmt.Sprintf("Build output:\n%s\n\nTest output:\n%s", logBlock(buildOutput), logBlock(testOutput))
+		if err != nil {
+			output += fmt.Sprintf("\n\nError: %v", err)
+		}
+		return output, err == nil
+	}
+
+	summary, usage, err := claude.ImplementWithTools(task, repoContext, language, conversation, sandbox, runTests, ia.maxAgentSteps())
+	if err != nil {
+		return "", usage, err
+	}
+	response, err := sandboxChangesResponse(sandbox, summary)
+	return response, usage, err
+}
+
+// maxAgentSteps returns how many tool calls the AI may make per generation in agent mode
+func (ia *IssueAgent) maxAgentSteps() int {
+	if ia.config.MaxAgentSteps > 0 {
+		return ia.config.MaxAgentSteps
+	}
+	return defaultMaxAgentSteps
+}
@@ -0,0 +1,154 @@
+package workflows
+
+import (
+	"fmt"
+	"strings"
+
+	"NyteBubo/internal/core"
+)
+
+// maxTriageCandidates caps how many open issues are compared against a new one for duplicates
+const maxTriageCandidates = 200
+
+// TriageIssue comments on a newly opened issue with suggested labels, a complexity estimate
+// and likely duplicates among the repository's open issues, when triage is enabled for the
+// repository. Each issue is triaged at most once.
+func (ia *IssueAgent) TriageIssue(owner, repo string, issueNumber int) error {
+	if !ia.config.ForRepo(owner, repo).Triage {
+		return nil
+	}
+	logger := core.IssueLogger(owner, repo, issueNumber)
+
+	host := ia.host(owner, repo)
+	issue, err := host.GetIssue(owner, repo, issueNumber)
+	if err != nil {
+		return fmt.Errorf("failed to get issue: %w", err)
+	}
+	if login, err := ia.botLogin(owner, repo); err != nil {
+		return err
+	} else if issue.GetUser().GetLogin() == login {
+		return nil
+	}
+
+	state := &core.State{Owner: owner, Repo: repo, IssueNumber: issueNumber}
+	if reason, err := ia.budgetExceeded(state); err != nil {
+		return fmt.Errorf("failed to check budget: %w", err)
+	} else if reason != "" {
+		logger.Warn("💸 Skipping triage", "reason", reason)
+		return nil
+	}
+
+	claimed, err := ia.stateManager.ClaimTriage(owner, repo, issueNumber)
+	if err != nil || !claimed {
+		return err
+	}
+	logger.Info("🗂️  Triaging new issue")
+
+	labels, err := host.ListLabels(owner, repo)
+	if err != nil {
+		return err
+	}
+	openIssues, err := host.ListOpenIssues(owner, repo)
+	if err != nil {
+		return err
+	}
+
+	titles := make(map[int]string)
+	var candidates strings.Builder
+	for _, other := range openIssues {
+		if other.GetNumber() == issueNumber || len(titles) >= maxTriageCandidates {
+			continue
+		}
+		titles[other.GetNumber()] = other.GetTitle()
+		candidates.WriteString(fmt.Sprintf("- #%d %s", other.GetNumber(), other.GetTitle()))
+		if body := strings.Join(strings.Fields(other.GetBody()), " "); body != "" {
+			if runes := []rune(body); len(runes) > 200 {
+				body = string(runes[:200]) + "…"
+			}
+			candidates.WriteString(": " + body)
+		}
+		candidates.WriteString("\n")
+	}
+	if candidates.Len() == 0 {
+		candidates.WriteString("(none)\n")
+	}
+
+	issueText := fmt.Sprintf("Issue #%d: %s\n\n%s", issueNumber, issue.GetTitle(), issue.GetBody())
+	triage, usage, err := ia.claudeFor(state).TriageIssue(issueText, labels, candidates.String())
+	if costErr := ia.stateManager.RecordTriageCost(owner, repo, issueNumber, usage.Cost); costErr != nil {
+		logger.Warn("⚠️  Failed to record triage cost", "error", costErr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to triage issue: %w", err)
+	}
+
+	comment := formatTriageComment(triage, labels, titles)
+	if err := ia.postComment(owner, repo, issueNumber, comment); err != nil {
+		return fmt.Errorf("failed to create comment: %w", err)
+	}
+	logger.Info("✅ Issue triaged", "complexity", triage.Complexity, "duplicates", len(triage.Duplicates))
+	return nil
+}
+
+// triageRepositories returns the repositories ("owner/repo") with triage enabled
+func (ia *IssueAgent) triageRepositories(repositories []string) []string {
+	var enabled []string
+	for _, repoFullName := range repositories {
+		if ia.config.RepoSettings[repoFullName].Triage {
+			enabled = append(enabled, repoFullName)
+		}
+	}
+	return enabled
+}
+
+// formatTriageComment formats a triage, keeping only labels the repository has and
+// duplicates among the open issues that were compared
+func formatTriageComment(triage core.Triage, labels []string, titles map[int]string) string {
+	existing := make(map[string]string, len(labels))
+	for _, label := range labels {
+		existing[strings.ToLower(label)] = label
+	}
+	var suggested []string
+	for _, label := range triage.Labels {
+		if name, ok := existing[strings.ToLower(label)]; ok {
+			suggested = append(suggested, "`"+name+"`")
+		}
+	}
+
+	var facts strings.Builder
+	if len(suggested) > 0 {
+		facts.WriteString("**Suggested labels:** " + strings.Join(suggested, ", ") + "\n")
+	}
+	if triage.Complexity != "" {
+		facts.WriteString("**Complexity:** " + triage.Complexity)
+		if triage.Estimate != "" {
+			facts.WriteString(" (" + triage.Estimate + ")")
+		}
+		facts.WriteString("\n")
+	}
+
+	var duplicates strings.Builder
+	for _, duplicate := range triage.Duplicates {
+		title, ok := titles[duplicate.Number]
+		if !ok {
+			continue
+		}
+		duplicates.WriteString(fmt.Sprintf("- #%d %s", duplicate.Number, title))
+		if reason := strings.TrimSpace(duplicate.Reason); reason != "" {
+			duplicates.WriteString(": " + reason)
+		}
+		duplicates.WriteString("\n")
+	}
+
+	summary := strings.TrimSpace(triage.Summary)
+	if facts.Len() > 0 {
+		summary += "\n\n" + strings.TrimSpace(facts.String())
+	}
+	if duplicates.Len() > 0 {
+		summary += "\n\n**Possible duplicates:**\n" + strings.TrimSpace(duplicates.String())
+	}
+	return botComment{
+		Heading: "🗂️ Triage",
+		Summary: summary,
+	}.String()
+}
@@ -0,0 +1,8 @@
+package server
+
+// HandleEvent processes a single event payload, as a GitHub Actions workflow receives it,
+// and returns once all the work it started has finished. The event isn't queued, since
+// the workflow run is gone once it exits.
+func (ws *WebhookServer) HandleEvent(eventType string, payload []byte) error {
+	return ws.dispatch(eventType, payload)
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"NyteBubo/internal/core"
+)
+
+const (
+	// lastEventKey stores when the webhook server last received an event
+	lastEventKey = "webhook_last_event_at"
+	// maxCatchUpWindow matches how long GitHub retains webhook deliveries
+	maxCatchUpWindow = 72 * time.Hour
+)
+
+// recordEventTime stores the time of the most recent webhook event
+func (ws *WebhookServer) recordEventTime() {
+	if err := ws.agent.StateManager().SetSetting(lastEventKey, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		slog.Warn("Failed to record webhook event time", "error", err)
+	}
+}
+
+// CatchUp replays webhook deliveries that failed while the server was down.
+// Only deliveries for the hook pointing at webhookURL are considered, so other
+// integrations' webhooks are never touched.
+func (ws *WebhookServer) CatchUp(repositories []string, webhookURL string) {
+	if webhookURL == "" || len(repositories) == 0 {
+		return
+	}
+
+	lastEvent, err := ws.agent.StateManager().GetSetting(lastEventKey)
+	if err != nil || lastEvent == "" {
+		slog.Info("No previous webhook events recorded, skipping catch-up")
+		return
+	}
+	since, err := time.Parse(time.RFC3339, lastEvent)
+	if err != nil {
+		slog.Warn("Invalid webhook catch-up cursor", "cursor", lastEvent, "error", err)
+		return
+	}
+	if time.Since(since) > maxCatchUpWindow {
+		since = time.Now().Add(-maxCatchUpWindow)
+	}
+
+	slog.Info("Catching up on webhook deliveries", "since", since.Format(time.RFC3339))
+
+	github := ws.agent.GitHub()
+	for _, repoFullName := range repositories {
+		parts := strings.Split(repoFullName, "/")
+		if len(parts) != 2 {
+			slog.Warn("Invalid repository format (expected owner/repo)", "repository", repoFullName)
+			continue
+		}
+		owner, repo := parts[0], parts[1]
+		logger := core.RepoLogger(owner, repo)
+
+		hookID, err := github.FindHookID(owner, repo, webhookURL)
+		if err != nil {
+			logger.Error("Failed to find webhook", "error", err)
+			continue
+		}
+		if hookID == 0 {
+			logger.Info("No webhook points at the configured URL, skipping catch-up", "url", webhookURL)
+			continue
+		}
+
+		deliveries, err := github.ListHookDeliveries(owner, repo, hookID, since)
+		if err != nil {
+			logger.Error("Failed to list webhook deliveries", "error", err)
+			continue
+		}
+
+		// A delivery is missed if none of its attempts (including redeliveries) succeeded
+		succeeded := make(map[string]bool)
+		for _, delivery := range deliveries {
+			if code := delivery.GetStatusCode(); code >= 200 && code < 300 {
+				succeeded[delivery.GetGUID()] = true
+			}
+		}
+
+		// Replay oldest first so events are processed in the order they happened
+		sort.Slice(deliveries, func(i, j int) bool {
+			return deliveries[i].GetDeliveredAt().Time.Before(deliveries[j].GetDeliveredAt().Time)
+		})
+
+		replayed := make(map[string]bool)
+		for _, delivery := range deliveries {
+			guid := delivery.GetGUID()
+			if succeeded[guid] || replayed[guid] {
+				continue
+			}
+			replayed[guid] = true
+
+			payload, err := github.GetHookDeliveryPayload(owner, repo, hookID, delivery.GetID())
+			if err != nil {
+				logger.Error("Failed to fetch missed delivery", "event", delivery.GetEvent(), "error", err)
+				continue
+			}
+
+			logger.Info("Replaying missed event", "event", delivery.GetEvent(), "delivered", delivery.GetDeliveredAt().Format(time.RFC3339))
+			if err := ws.enqueue("github", delivery.GetEvent(), payload); err != nil {
+				logger.Error("Failed to queue missed delivery", "event", delivery.GetEvent(), "error", err)
+			}
+		}
+
+		if len(replayed) > 0 {
+			logger.Info("Replayed missed events", "count", len(replayed))
+		}
+	}
+}
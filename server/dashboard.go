@@ -0,0 +1,294 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"NyteBubo/internal/core"
+	"NyteBubo/internal/workflows"
+)
+
+// Dashboard serves a web page for monitoring issues and retrying or aborting work
+type Dashboard struct {
+	agent    *workflows.IssueAgent
+	token    string // Password for HTTP basic auth; empty disables authentication
+	index    *template.Template
+	issue    *template.Template
+	inflight sync.WaitGroup // Retries still running, waited for on shutdown
+}
+
+// NewDashboard creates a dashboard backed by the agent's state database
+func NewDashboard(agent *workflows.IssueAgent, token string) *Dashboard {
+	d := &Dashboard{agent: agent, token: token}
+	d.index = template.Must(template.New("index").Funcs(d.funcs()).Parse(dashboardIndexPage))
+	d.issue = template.Must(template.New("issue").Funcs(d.funcs()).Parse(dashboardIssuePage))
+	return d
+}
+
+// activeStatuses are listed before finished issues on the overview page
+var activeStatuses = map[string]bool{
+	"analyzing":                 true,
+	"waiting_for_clarification": true,
+	"waiting_for_approval":      true,
+	"ready_to_implement":        true,
+	"implementing":              true,
+	"blocked":                   true,
+	"pr_created":                true,
+	"reviewing":                 true,
+	"budget_exceeded":           true,
+	"held":                      true,
+	"previewing":                true,
+	"needs_human":               true,
+}
+
+// Handler returns the dashboard's routes
+func (d *Dashboard) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", d.handleIndex)
+	mux.HandleFunc("GET /issues/{owner}/{repo}/{number}", d.handleIssue)
+	mux.HandleFunc("POST /issues/{owner}/{repo}/{number}/{action}", d.handleAction)
+	return d.authenticate(mux)
+}
+
+// Start serves the dashboard on addr until ctx is cancelled, then waits for retries it started
+func (d *Dashboard) Start(ctx context.Context, addr string) error {
+	slog.Info("Starting dashboard", "url", "http://"+addr)
+	if err := serveUntilDone(ctx, &http.Server{Addr: addr, Handler: d.Handler()}, "", ""); err != nil {
+		return err
+	}
+	waitInflight(&d.inflight)
+	return nil
+}
+
+// spawn runs work in the background, tracked so shutdown can wait for it
+func (d *Dashboard) spawn(fn func()) {
+	d.inflight.Add(1)
+	go func() {
+		defer d.inflight.Done()
+		fn()
+	}()
+}
+
+// authenticate requires the dashboard token as the basic auth password and rejects
+// cross-site form posts
+func (d *Dashboard) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if d.token != "" {
+			_, password, ok := r.BasicAuth()
+			if !ok || subtle.ConstantTimeCompare([]byte(password), []byte(d.token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Basic realm="NyteBubo"`)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		if r.Method == http.MethodPost {
+			if origin := r.Header.Get("Origin"); origin != "" {
+				if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+					http.Error(w, "Forbidden", http.StatusForbidden)
+					return
+				}
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleIndex lists every issue, active ones first
+func (d *Dashboard) handleIndex(w http.ResponseWriter, r *http.Request) {
+	states, err := d.agent.StateManager().GetAllIssuesWithStats()
+	if err != nil {
+		slog.Error("Dashboard: failed to list issues", "error", err)
+		http.Error(w, "Failed to load issues", http.StatusInternalServerError)
+		return
+	}
+
+	sort.SliceStable(states, func(i, j int) bool {
+		return activeStatuses[states[i].Status] && !activeStatuses[states[j].Status]
+	})
+
+	var active int
+	var totalCost float64
+	var totalTokens int64
+	for _, state := range states {
+		if activeStatuses[state.Status] {
+			active++
+		}
+		totalCost += state.TotalCost
+		totalTokens += state.TotalInputTokens + state.TotalOutputTokens
+	}
+
+	d.render(w, d.index, map[string]any{
+		"States":      states,
+		"Active":      active,
+		"TotalCost":   totalCost,
+		"TotalTokens": totalTokens,
+	})
+}
+
+// handleIssue shows one issue's state and conversation
+func (d *Dashboard) handleIssue(w http.ResponseWriter, r *http.Request) {
+	state, ok := d.lookupState(w, r)
+	if !ok {
+		return
+	}
+
+	d.render(w, d.issue, map[string]any{
+		"State":  state,
+		"Active": activeStatuses[state.Status],
+	})
+}
+
+// handleAction retries or aborts an issue, then returns to its page
+func (d *Dashboard) handleAction(w http.ResponseWriter, r *http.Request) {
+	state, ok := d.lookupState(w, r)
+	if !ok {
+		return
+	}
+	owner, repo, number := state.Owner, state.Repo, state.IssueNumber
+	logger := state.Logger()
+
+	switch r.PathValue("action") {
+	case "retry":
+		// Implementation takes minutes; don't hold the request open
+		d.spawn(func() {
+			if err := d.agent.RetryIssue(owner, repo, number, false); err != nil {
+				logger.Error("Dashboard: error retrying issue", "error", err)
+			}
+		})
+	case "abort":
+		if err := d.agent.AbortIssue(owner, repo, number, false); err != nil {
+			logger.Error("Dashboard: error aborting issue", "error", err)
+			http.Error(w, "Failed to abort issue", http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	logger.Info("Dashboard: action requested", "action", r.PathValue("action"))
+	http.Redirect(w, r, issuePath(*state), http.StatusSeeOther)
+}
+
+// lookupState loads the state named in the request path, writing an error response if it can't
+func (d *Dashboard) lookupState(w http.ResponseWriter, r *http.Request) (*core.State, bool) {
+	number, err := strconv.Atoi(r.PathValue("number"))
+	if err != nil {
+		http.NotFound(w, r)
+		return nil, false
+	}
+
+	state, err := d.agent.StateManager().GetState(r.PathValue("owner"), r.PathValue("repo"), number)
+	if err != nil {
+		slog.Error("Dashboard: failed to get state", "error", err)
+		http.Error(w, "Failed to load issue", http.StatusInternalServerError)
+		return nil, false
+	}
+	if state == nil {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	return state, true
+}
+
+// render executes a page template
+func (d *Dashboard) render(w http.ResponseWriter, tmpl *template.Template, data map[string]any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := tmpl.Execute(w, data); err != nil {
+		slog.Error("Dashboard: failed to render page", "error", err)
+	}
+}
+
+// issuePath returns the dashboard page for an issue
+func issuePath(state core.State) string {
+	return fmt.Sprintf("/issues/%s/%s/%d", url.PathEscape(state.Owner), url.PathEscape(state.Repo), state.IssueNumber)
+}
+
+// funcs returns the functions the dashboard's pages use. Links point at the host serving
+// each repository.
+func (d *Dashboard) funcs() template.FuncMap {
+	return template.FuncMap{
+		"issuePath": issuePath,
+		"issueURL": func(state core.State) string {
+			return d.agent.IssueURL(state.Owner, state.Repo, state.IssueNumber)
+		},
+		"pullRequestURL": func(state core.State) string {
+			if state.PRNumber == nil {
+				return ""
+			}
+			return d.agent.PullRequestURL(state.Owner, state.Repo, *state.PRNumber)
+		},
+		"ago": func(t time.Time) string {
+			return time.Since(t).Round(time.Second).String() + " ago"
+		},
+	}
+}
+
+const dashboardStyle = `<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #1f2328; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 0.4rem 0.6rem; border-bottom: 1px solid #d0d7de; }
+th { background: #f6f8fa; }
+.status { font-family: monospace; }
+.message { border: 1px solid #d0d7de; border-radius: 6px; margin: 0.8rem 0; padding: 0.6rem; }
+.message pre { white-space: pre-wrap; margin: 0.4rem 0 0; }
+.assistant { background: #f6f8fa; }
+form { display: inline; }
+</style>`
+
+const dashboardIndexPage = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><meta http-equiv="refresh" content="30"><title>NyteBubo</title>` + dashboardStyle + `</head>
+<body>
+<h1>NyteBubo</h1>
+<p>{{.Active}} active of {{len .States}} issues &middot; {{.TotalTokens}} tokens &middot; ${{printf "%.4f" .TotalCost}} total</p>
+<table>
+<tr><th>Issue</th><th>Status</th><th>PR</th><th>Tokens</th><th>Cost</th><th>Updated</th></tr>
+{{range .States}}<tr>
+<td><a href="{{issuePath .}}">{{.Owner}}/{{.Repo}} #{{.IssueNumber}}</a></td>
+<td class="status">{{.Status}}</td>
+<td>{{if .PRNumber}}<a href="{{pullRequestURL .}}">#{{.PRNumber}}</a>{{end}}</td>
+<td>{{.TotalInputTokens}} / {{.TotalOutputTokens}}</td>
+<td>${{printf "%.4f" .TotalCost}}</td>
+<td>{{ago .UpdatedAt}}</td>
+</tr>{{else}}<tr><td colspan="6">No issues yet.</td></tr>{{end}}
+</table>
+</body></html>`
+
+const dashboardIssuePage = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.State.Owner}}/{{.State.Repo}} #{{.State.IssueNumber}} - NyteBubo</title>` + dashboardStyle + `</head>
+<body>
+{{with .State}}
+<p><a href="/">&larr; All issues</a></p>
+<h1>{{.Owner}}/{{.Repo}} <a href="{{issueURL .}}">#{{.IssueNumber}}</a></h1>
+<table>
+<tr><th>Status</th><td class="status">{{.Status}} (updated {{ago .UpdatedAt}})</td></tr>
+{{if .PRNumber}}<tr><th>Pull request</th><td><a href="{{pullRequestURL .}}">#{{.PRNumber}}</a></td></tr>{{end}}
+{{if .BranchName}}<tr><th>Branch</th><td>{{.BranchName}}</td></tr>{{end}}
+{{if .BlockedByPR}}<tr><th>Waiting on</th><td>#{{.BlockedByPR}}</td></tr>{{end}}
+{{if .ApprovedBy}}<tr><th>Approved by</th><td>{{.ApprovedBy}}</td></tr>{{end}}
+{{if .Model}}<tr><th>Model</th><td>{{.Model}}</td></tr>{{end}}
+{{if .Checkpoint}}<tr><th>Checkpoint</th><td>{{.Checkpoint}}</td></tr>{{end}}
+<tr><th>Tokens</th><td>{{.TotalInputTokens}} in / {{.TotalOutputTokens}} out ({{.TotalReasoningTokens}} reasoning)</td></tr>
+<tr><th>Cost</th><td>${{printf "%.4f" .TotalCost}}</td></tr>
+<tr><th>Started</th><td>{{.CreatedAt.Format "2006-01-02 15:04:05"}}</td></tr>
+</table>
+<p>
+<form method="post" action="{{issuePath .}}/retry"><button>Retry implementation</button></form>
+{{end}}
+{{if .Active}}<form method="post" action="{{issuePath .State}}/abort"><button>Abort</button></form>{{end}}
+</p>
+<h2>Conversation</h2>
+{{range .State.Conversation}}<div class="message {{.Role}}"><strong>{{.Role}}</strong><pre>{{.Content}}</pre></div>
+{{else}}<p>No messages yet.</p>{{end}}
+</body></html>`
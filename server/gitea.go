@@ -0,0 +1,142 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"NyteBubo/internal/core"
+
+	"github.com/google/go-github/v63/github"
+)
+
+// giteaHeader returns a Forgejo or Gitea webhook header ("X-Forgejo-<name>" or "X-Gitea-<name>")
+func giteaHeader(r *http.Request, name string) string {
+	if value := r.Header.Get("X-Forgejo-" + name); value != "" {
+		return value
+	}
+	return r.Header.Get("X-Gitea-" + name)
+}
+
+// giteaPayload holds the fields of Gitea's webhook payloads that the agent uses. Gitea
+// sends one payload shape per family of events, so a single struct covers them all.
+type giteaPayload struct {
+	Action string `json:"action"`
+	Issue  *struct {
+		ID     int64  `json:"id"`
+		Number int    `json:"number"`
+		Body   string `json:"body"`
+		User   struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		Labels []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+	} `json:"issue"`
+	PullRequest *struct {
+		Number int `json:"number"`
+	} `json:"pull_request"`
+	Comment *struct {
+		ID   int64  `json:"id"`
+		Body string `json:"body"`
+		User struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	} `json:"comment"`
+	Review *struct {
+		Type    string `json:"type"`
+		Content string `json:"content"`
+	} `json:"review"`
+	RequestedReviewer *struct {
+		Login string `json:"login"`
+	} `json:"requested_reviewer"`
+	Repository struct {
+		Name  string `json:"name"`
+		Owner struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+	} `json:"repository"`
+	Sender struct {
+		Login string `json:"login"`
+	} `json:"sender"`
+}
+
+// giteaReviewStates maps review event names (without any "pull_request_review_" prefix)
+// to GitHub review states
+var giteaReviewStates = map[string]string{
+	"pull_request_approved": "APPROVED",
+	"pull_request_rejected": "CHANGES_REQUESTED",
+	"pull_request_comment":  "COMMENTED",
+	"approved":              "APPROVED",
+	"rejected":              "CHANGES_REQUESTED",
+	"comment":               "COMMENTED",
+}
+
+// dispatchGitea routes a Gitea or Forgejo event to the same handlers as the equivalent
+// GitHub event and returns the error of any it failed
+func (ws *WebhookServer) dispatchGitea(eventType string, body []byte) error {
+	core.WebhookEvents.Inc("gitea_" + eventType)
+
+	var event giteaPayload
+	if err := json.Unmarshal(body, &event); err != nil {
+		return fmt.Errorf("failed to parse Gitea %s event: %w", eventType, err)
+	}
+	owner, repo := event.Repository.Owner.Login, event.Repository.Name
+	slog.Debug("Gitea event", "event", eventType, "action", event.Action)
+
+	switch {
+	case eventType == "issues" && event.Issue != nil:
+		switch event.Action {
+		case "assigned":
+			return ws.onIssueAssigned(owner, repo, event.Issue.Number)
+		case "label_updated":
+			// Gitea doesn't say which label was added; non-trigger labels are ignored
+			var errs []error
+			for _, label := range event.Issue.Labels {
+				errs = append(errs, ws.onIssueLabeled(owner, repo, event.Issue.Number, label.Name))
+			}
+			return errors.Join(errs...)
+		case "opened":
+			return ws.onIssueOpened(owner, repo, event.Issue.Number, event.Issue.ID, event.Issue.User.Login, event.Issue.Body)
+		}
+
+	case eventType == "issue_comment" && event.Issue != nil && event.Comment != nil:
+		if event.Action == "created" && ws.claimComment(owner, repo, core.CommentKindIssue, event.Comment.ID) {
+			err := ws.onIssueComment(owner, repo, event.Issue.Number, event.Comment.User.Login, event.Comment.Body)
+			return ws.releaseFailedComment(owner, repo, core.CommentKindIssue, event.Comment.ID, err)
+		}
+
+	case eventType == "pull_request" && event.PullRequest != nil:
+		switch event.Action {
+		case "closed":
+			return ws.onPRClosed(owner, repo, event.PullRequest.Number)
+		case "opened":
+			return ws.onPROpened(owner, repo, event.PullRequest.Number)
+		}
+
+	case eventType == "pull_request_review_request" && event.PullRequest != nil && event.RequestedReviewer != nil:
+		if event.Action == "review_requested" {
+			return ws.onReviewRequested(owner, repo, event.PullRequest.Number, event.RequestedReviewer.Login)
+		}
+
+	// Review events (pull_request_approved, pull_request_rejected, pull_request_comment
+	// and their pull_request_review_* variants) carry the review
+	case event.Review != nil && event.PullRequest != nil:
+		review := &github.PullRequestReview{
+			Body:  github.String(event.Review.Content),
+			State: github.String(giteaReviewStates[strings.TrimPrefix(eventType, "pull_request_review_")]),
+			User:  &github.User{Login: github.String(event.Sender.Login)},
+		}
+		if core.IsReviewFeedback(review) {
+			return ws.onPRComment(owner, repo, event.PullRequest.Number, event.Sender.Login, core.FormatReview(review))
+		}
+		return ws.onPRUpdated(owner, repo, event.PullRequest.Number)
+
+	default:
+		slog.Debug("Unhandled Gitea event type", "event", eventType)
+	}
+	return nil
+}
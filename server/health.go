@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"NyteBubo/internal/workflows"
+)
+
+const (
+	// healthCheckTimeout bounds each readiness check
+	healthCheckTimeout = 5 * time.Second
+	// healthCacheTTL is how long the result of a remote check is reused, so frequent
+	// probes don't spend the GitHub rate limit
+	healthCacheTTL = time.Minute
+)
+
+// cachedCheck is a readiness check whose result is reused for healthCacheTTL
+type cachedCheck struct {
+	check func(ctx context.Context) error
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	err       error
+}
+
+// run returns the latest result, running the check again once it is older than the TTL
+func (c *cachedCheck) run(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.checkedAt.IsZero() || time.Since(c.checkedAt) > healthCacheTTL {
+		c.err = c.check(ctx)
+		c.checkedAt = time.Now()
+	}
+	return c.err
+}
+
+// HealthChecker serves the liveness and readiness probes
+type HealthChecker struct {
+	agent  *workflows.IssueAgent
+	github *cachedCheck
+	llm    *cachedCheck
+}
+
+// NewHealthChecker creates the probes for an agent
+func NewHealthChecker(agent *workflows.IssueAgent) *HealthChecker {
+	h := &HealthChecker{agent: agent}
+	h.github = &cachedCheck{check: func(ctx context.Context) error {
+		github := agent.GitHub()
+		if github == nil || github.GetToken() == "" {
+			return nil
+		}
+		if _, err := github.GetAuthenticatedUser(); err != nil {
+			return fmt.Errorf("GitHub token rejected: %w", err)
+		}
+		return nil
+	}}
+	h.llm = &cachedCheck{check: agent.CheckLLM}
+	return h
+}
+
+// Register adds /livez, /readyz and /health (an alias of /livez) under base to mux
+func (h *HealthChecker) Register(mux *http.ServeMux, base string) {
+	mux.HandleFunc(base+"/livez", h.handleLive)
+	mux.HandleFunc(base+"/health", h.handleLive)
+	mux.HandleFunc(base+"/readyz", h.handleReady)
+}
+
+// handleLive reports that the process is up and serving requests
+func (h *HealthChecker) handleLive(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"status": "healthy"}`))
+}
+
+// handleReady checks that the state database accepts writes, the GitHub token is valid and
+// the LLM API is reachable, and answers 503 if any of them fails
+func (h *HealthChecker) handleReady(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	results := map[string]error{
+		"database": h.agent.StateManager().CheckWritable(),
+		"github":   h.github.run(ctx),
+		"llm":      h.llm.run(ctx),
+	}
+
+	status, code := "ready", http.StatusOK
+	checks := make(map[string]string, len(results))
+	for name, err := range results {
+		checks[name] = "ok"
+		if err != nil {
+			checks[name] = err.Error()
+			status, code = "not ready", http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]any{"status": status, "checks": checks})
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"NyteBubo/internal/core"
+)
+
+// ServeMetrics serves Prometheus metrics at /metrics, and the liveness and readiness
+// probes, on addr until ctx is cancelled
+func ServeMetrics(ctx context.Context, addr string, health *HealthChecker) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", core.MetricsHandler())
+	health.Register(mux, "")
+
+	slog.Info("Serving metrics", "url", "http://"+addr+"/metrics")
+	return serveUntilDone(ctx, &http.Server{Addr: addr, Handler: mux}, "", "")
+}
+
+// serveUntilDone runs an HTTP server and shuts it down gracefully once ctx is cancelled.
+// With a certificate and key file it serves HTTPS.
+func serveUntilDone(ctx context.Context, srv *http.Server, certFile, keyFile string) error {
+	errCh := make(chan error, 1)
+	go func() {
+		if certFile != "" {
+			errCh <- srv.ListenAndServeTLS(certFile, keyFile)
+			return
+		}
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down server: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"NyteBubo/internal/core"
+)
+
+const (
+	// defaultWorkers is how many webhook events are handled at once when workers isn't set
+	defaultWorkers = 4
+	// queueCheckInterval is how often idle workers look for events they weren't woken for
+	queueCheckInterval = 30 * time.Second
+	// eventRetention is how long handled events are kept for `nytebubo events`
+	eventRetention = 7 * 24 * time.Hour
+	// eventRetryDelay is how long a failed event waits before its next attempt, multiplied
+	// by the attempts so far
+	eventRetryDelay = time.Minute
+)
+
+// enqueue stores a webhook delivery and wakes a worker to handle it
+func (ws *WebhookServer) enqueue(source, eventType string, body []byte) error {
+	if err := ws.agent.StateManager().EnqueueEvent(source, eventType, body); err != nil {
+		return err
+	}
+	select {
+	case ws.wake <- struct{}{}:
+	default:
+		// The workers are already awake or haven't started, and pick the event up when they do
+	}
+	return nil
+}
+
+// runWorkers replays events a previous run left unhandled and starts the workers handling
+// queued events. Workers stop taking events once ctx is cancelled.
+func (ws *WebhookServer) runWorkers(ctx context.Context) {
+	stateManager := ws.agent.StateManager()
+	requeued, failed, err := stateManager.RequeueInterruptedEvents()
+	if err != nil {
+		slog.Error("Error requeueing interrupted webhook events", "error", err)
+	}
+	if requeued > 0 {
+		slog.Info("Replaying webhook events interrupted by the last shutdown", "count", requeued)
+	}
+	if failed > 0 {
+		slog.Warn("Giving up on webhook events interrupted on every attempt", "count", failed)
+	}
+	if _, err := stateManager.PruneEvents(time.Now().Add(-eventRetention)); err != nil {
+		slog.Warn("Failed to prune old webhook events", "error", err)
+	}
+
+	slog.Info("Starting webhook workers", "workers", ws.workers)
+	ws.wake = make(chan struct{}, ws.workers)
+	for range ws.workers {
+		go ws.work(ctx)
+	}
+}
+
+// work handles queued events until ctx is cancelled
+func (ws *WebhookServer) work(ctx context.Context) {
+	ticker := time.NewTicker(queueCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil && ws.handleNext() {
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ws.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// handleNext handles the oldest pending event and reports whether there was one
+func (ws *WebhookServer) handleNext() bool {
+	ws.inflight.Add(1)
+	defer ws.inflight.Done()
+
+	stateManager := ws.agent.StateManager()
+	event, err := stateManager.ClaimEvent()
+	if err != nil {
+		slog.Error("Error reading webhook event queue", "error", err)
+		return false
+	}
+	if event == nil {
+		return false
+	}
+
+	logger := slog.With("event", event.Type, "id", event.ID)
+	logger.Debug("Handling queued event", "attempt", event.Attempts)
+	handlingErr := ws.handleEvent(event.Source, event.Type, event.Payload)
+	if handlingErr == nil {
+		if err := stateManager.FinishEvent(event.ID, ""); err != nil {
+			logger.Error("Error recording handled webhook event", "error", err)
+		}
+		return true
+	}
+
+	if event.Attempts < core.MaxEventAttempts {
+		delay := eventRetryDelay * time.Duration(event.Attempts)
+		logger.Warn("Error handling webhook event, retrying", "attempt", event.Attempts, "in", delay, "error", handlingErr)
+		if err := stateManager.RetryEvent(event.ID, handlingErr.Error(), time.Now().Add(delay)); err != nil {
+			logger.Error("Error requeueing webhook event", "error", err)
+		}
+		return true
+	}
+	logger.Error("Error handling webhook event, giving up", "attempts", event.Attempts, "error", handlingErr)
+	if err := stateManager.FinishEvent(event.ID, handlingErr.Error()); err != nil {
+		logger.Error("Error recording handled webhook event", "error", err)
+	}
+	return true
+}
+
+// handleEvent dispatches a queued event and returns its handler's error, turning a panic
+// into one so a bad event doesn't stop the worker
+func (ws *WebhookServer) handleEvent(source, eventType string, payload []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+
+	if source == "gitea" {
+		return ws.dispatchGitea(eventType, payload)
+	}
+	return ws.dispatch(eventType, payload)
+}
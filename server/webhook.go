@@ -1,16 +1,21 @@
 package server
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
+	"NyteBubo/internal/core"
 	"NyteBubo/internal/workflows"
 
 	"github.com/google/go-github/v63/github"
@@ -20,13 +25,27 @@ import (
 type WebhookServer struct {
 	agent         *workflows.IssueAgent
 	webhookSecret string
+	workers       int            // Events handled at once
+	wake          chan struct{}  // Signals idle workers that an event was queued; nil until they start
+	inflight      sync.WaitGroup // Event handlers still running, waited for on shutdown
 }
 
+// shutdownTimeout bounds how long shutdown waits for in-flight work
+const shutdownTimeout = 30 * time.Second
+
 // NewWebhookServer creates a new webhook server
 func NewWebhookServer(agent *workflows.IssueAgent, webhookSecret string) *WebhookServer {
 	return &WebhookServer{
 		agent:         agent,
 		webhookSecret: webhookSecret,
+		workers:       defaultWorkers,
+	}
+}
+
+// SetWorkers sets how many queued events are handled at once
+func (ws *WebhookServer) SetWorkers(workers int) {
+	if workers > 0 {
+		ws.workers = workers
 	}
 }
 
@@ -41,42 +60,80 @@ func (ws *WebhookServer) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 	// Read the request body
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		log.Printf("Error reading request body: %v", err)
+		slog.Error("Error reading request body", "error", err)
 		http.Error(w, "Failed to read request body", http.StatusBadRequest)
 		return
 	}
 	defer r.Body.Close()
 
+	// Gitea and Forgejo also send GitHub's headers, but their payloads differ, so
+	// check for their own headers first
+	eventType, signature := r.Header.Get("X-GitHub-Event"), r.Header.Get("X-Hub-Signature-256")
+	giteaEvent := giteaHeader(r, "Event")
+	if giteaEvent != "" {
+		eventType, signature = giteaEvent, "sha256="+giteaHeader(r, "Signature")
+	}
+
 	// Verify webhook signature
 	if ws.webhookSecret != "" {
-		signature := r.Header.Get("X-Hub-Signature-256")
 		if !ws.verifySignature(signature, body) {
-			log.Println("Invalid webhook signature")
+			slog.Warn("Invalid webhook signature")
 			http.Error(w, "Invalid signature", http.StatusUnauthorized)
 			return
 		}
 	}
 
-	// Get the event type
-	eventType := r.Header.Get("X-GitHub-Event")
-	log.Printf("Received GitHub event: %s", eventType)
+	// Remember when we last heard from GitHub so missed events can be caught up after downtime
+	ws.recordEventTime()
+
+	source := "github"
+	if giteaEvent != "" {
+		source = "gitea"
+	}
+	if eventType == "ping" {
+		slog.Info("Received ping event")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message": "pong"}`))
+		return
+	}
+
+	// Queue the event so it survives a restart; a worker handles it once it is stored
+	slog.Debug("Received event", "source", source, "event", eventType)
+	if err := ws.enqueue(source, eventType, body); err != nil {
+		slog.Error("Error queueing event", "event", eventType, "error", err)
+		http.Error(w, "Failed to queue event", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte(`{"message": "Event queued"}`))
+}
 
-	// Handle different event types
+// dispatch routes an event payload to its handler and returns the error of any it failed
+func (ws *WebhookServer) dispatch(eventType string, body []byte) error {
+	core.WebhookEvents.Inc(eventType)
 	switch eventType {
 	case "issues":
-		ws.handleIssuesEvent(body, w)
+		return ws.handleIssuesEvent(body)
 	case "issue_comment":
-		ws.handleIssueCommentEvent(body, w)
+		return ws.handleIssueCommentEvent(body)
 	case "pull_request_review_comment":
-		ws.handlePRCommentEvent(body, w)
+		return ws.handlePRCommentEvent(body)
+	case "pull_request":
+		return ws.handlePullRequestEvent(body)
+	case "pull_request_review":
+		return ws.handlePullRequestReviewEvent(body)
+	case "check_suite":
+		return ws.handleCheckSuiteEvent(body)
+	case "check_run":
+		return ws.handleCheckRunEvent(body)
+	case "status":
+		return ws.handleStatusEvent(body)
 	case "ping":
-		log.Println("Received ping event")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"message": "pong"}`))
+		slog.Info("Received ping event")
 	default:
-		log.Printf("Unhandled event type: %s", eventType)
-		w.WriteHeader(http.StatusOK)
+		slog.Debug("Unhandled event type", "event", eventType)
 	}
+	return nil
 }
 
 // verifySignature verifies the GitHub webhook signature
@@ -101,136 +158,477 @@ func (ws *WebhookServer) verifySignature(signature string, body []byte) bool {
 }
 
 // handleIssuesEvent handles issue events (opened, assigned, etc.)
-func (ws *WebhookServer) handleIssuesEvent(body []byte, w http.ResponseWriter) {
+func (ws *WebhookServer) handleIssuesEvent(body []byte) error {
 	var event github.IssuesEvent
 	if err := json.Unmarshal(body, &event); err != nil {
-		log.Printf("Error parsing issues event: %v", err)
-		http.Error(w, "Failed to parse event", http.StatusBadRequest)
-		return
+		return fmt.Errorf("failed to parse issues event: %w", err)
 	}
 
 	action := event.GetAction()
-	log.Printf("Issues event action: %s", action)
+	slog.Debug("Issues event", "action", action)
+
+	owner := event.Repo.Owner.GetLogin()
+	repo := event.Repo.GetName()
+	issueNumber := event.Issue.GetNumber()
+
+	switch action {
+	case "assigned":
+		return ws.onIssueAssigned(owner, repo, issueNumber)
+	case "labeled":
+		return ws.onIssueLabeled(owner, repo, issueNumber, event.GetLabel().GetName())
+	case "opened":
+		return ws.onIssueOpened(owner, repo, issueNumber, event.Issue.GetID(), event.Issue.GetUser().GetLogin(), event.Issue.GetBody())
+	case "closed":
+		return ws.onIssueClosed(owner, repo, issueNumber)
+	case "unassigned":
+		return ws.onIssueUnassigned(owner, repo, issueNumber, event.GetAssignee().GetLogin())
+	}
+	return nil
+}
 
-	// Only handle "assigned" events where the bot is assigned
-	if action == "assigned" {
-		owner := event.Repo.Owner.GetLogin()
-		repo := event.Repo.GetName()
-		issueNumber := event.Issue.GetNumber()
+// handleIssueCommentEvent handles issue comment events
+func (ws *WebhookServer) handleIssueCommentEvent(body []byte) error {
+	var event github.IssueCommentEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return fmt.Errorf("failed to parse issue comment event: %w", err)
+	}
 
-		log.Printf("Agent assigned to issue #%d in %s/%s", issueNumber, owner, repo)
+	action := event.GetAction()
+	slog.Debug("Issue comment event", "action", action)
 
-		// Handle the assignment asynchronously
-		go func() {
-			if err := ws.agent.HandleIssueAssignment(owner, repo, issueNumber); err != nil {
-				log.Printf("Error handling issue assignment: %v", err)
-			}
-		}()
+	// Only handle "created" comments, once each
+	owner, repo, commentID := event.Repo.Owner.GetLogin(), event.Repo.GetName(), event.Comment.GetID()
+	if action != "created" || !ws.claimComment(owner, repo, core.CommentKindIssue, commentID) {
+		return nil
+	}
+	err := ws.onIssueComment(owner, repo, event.Issue.GetNumber(), event.Comment.User.GetLogin(), event.Comment.GetBody())
+	return ws.releaseFailedComment(owner, repo, core.CommentKindIssue, commentID, err)
+}
 
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"message": "Processing issue assignment"}`))
-		return
+// handlePRCommentEvent handles pull request review comment events
+func (ws *WebhookServer) handlePRCommentEvent(body []byte) error {
+	var event github.PullRequestReviewCommentEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return fmt.Errorf("failed to parse PR comment event: %w", err)
 	}
 
-	w.WriteHeader(http.StatusOK)
+	action := event.GetAction()
+	slog.Debug("PR comment event", "action", action)
+
+	// Only handle "created" comments, once each
+	owner, repo, commentID := event.Repo.Owner.GetLogin(), event.Repo.GetName(), event.Comment.GetID()
+	if action != "created" || !ws.claimComment(owner, repo, core.CommentKindPR, commentID) {
+		return nil
+	}
+	err := ws.onPRComment(owner, repo, event.PullRequest.GetNumber(), event.Comment.User.GetLogin(), event.Comment.GetBody())
+	return ws.releaseFailedComment(owner, repo, core.CommentKindPR, commentID, err)
 }
 
-// handleIssueCommentEvent handles issue comment events
-func (ws *WebhookServer) handleIssueCommentEvent(body []byte, w http.ResponseWriter) {
-	var event github.IssueCommentEvent
+// handlePullRequestEvent handles pull request events (closed PRs unblock waiting issues and
+// finish merged ones; new PRs and review requests are reviewed)
+func (ws *WebhookServer) handlePullRequestEvent(body []byte) error {
+	var event github.PullRequestEvent
 	if err := json.Unmarshal(body, &event); err != nil {
-		log.Printf("Error parsing issue comment event: %v", err)
-		http.Error(w, "Failed to parse event", http.StatusBadRequest)
-		return
+		return fmt.Errorf("failed to parse pull request event: %w", err)
 	}
 
 	action := event.GetAction()
-	log.Printf("Issue comment event action: %s", action)
-
-	// Only handle "created" comments
-	if action == "created" {
-		owner := event.Repo.Owner.GetLogin()
-		repo := event.Repo.GetName()
-		issueNumber := event.Issue.GetNumber()
-		commentBody := event.Comment.GetBody()
-		commentAuthor := event.Comment.User.GetLogin()
-
-		// Ignore comments from the bot itself (to avoid infinite loops)
-		if strings.Contains(strings.ToLower(commentAuthor), "bot") {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+	slog.Debug("Pull request event", "action", action)
+
+	owner, repo, prNumber := event.Repo.Owner.GetLogin(), event.Repo.GetName(), event.PullRequest.GetNumber()
+	switch action {
+	case "closed":
+		return ws.onPRClosed(owner, repo, prNumber)
+	case "opened", "ready_for_review":
+		return ws.onPROpened(owner, repo, prNumber)
+	case "review_requested":
+		return ws.onReviewRequested(owner, repo, prNumber, event.GetRequestedReviewer().GetLogin())
+	}
+	return nil
+}
 
-		log.Printf("New comment on issue #%d in %s/%s", issueNumber, owner, repo)
+// handlePullRequestReviewEvent treats a submitted review's summary as feedback, and
+// otherwise re-checks auto-merge
+func (ws *WebhookServer) handlePullRequestReviewEvent(body []byte) error {
+	var event github.PullRequestReviewEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return fmt.Errorf("failed to parse pull request review event: %w", err)
+	}
 
-		// Handle the comment asynchronously
-		go func() {
-			if err := ws.agent.HandleIssueComment(owner, repo, issueNumber, commentBody); err != nil {
-				log.Printf("Error handling issue comment: %v", err)
-			}
-		}()
+	if event.GetAction() != "submitted" {
+		return nil
+	}
+	owner, repo, prNumber := event.Repo.Owner.GetLogin(), event.Repo.GetName(), event.PullRequest.GetNumber()
+	if !core.IsReviewFeedback(event.Review) {
+		return ws.onPRUpdated(owner, repo, prNumber)
+	}
+	reviewID := event.Review.GetID()
+	if !ws.claimComment(owner, repo, core.CommentKindReview, reviewID) {
+		return nil
+	}
+	err := ws.onPRComment(owner, repo, prNumber, event.Review.GetUser().GetLogin(), core.FormatReview(event.Review))
+	return ws.releaseFailedComment(owner, repo, core.CommentKindReview, reviewID, err)
+}
 
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"message": "Processing comment"}`))
-		return
+// handleCheckSuiteEvent re-checks auto-merge for pull requests when CI completes
+func (ws *WebhookServer) handleCheckSuiteEvent(body []byte) error {
+	var event github.CheckSuiteEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return fmt.Errorf("failed to parse check suite event: %w", err)
 	}
 
-	w.WriteHeader(http.StatusOK)
+	var errs []error
+	if event.GetAction() == "completed" {
+		for _, pr := range event.CheckSuite.PullRequests {
+			errs = append(errs, ws.onPRUpdated(event.Repo.Owner.GetLogin(), event.Repo.GetName(), pr.GetNumber()))
+		}
+	}
+	return errors.Join(errs...)
 }
 
-// handlePRCommentEvent handles pull request review comment events
-func (ws *WebhookServer) handlePRCommentEvent(body []byte, w http.ResponseWriter) {
-	var event github.PullRequestReviewCommentEvent
+// handleCheckRunEvent re-checks pull requests when one of their check runs completes,
+// so failures are acted on without waiting for the whole suite
+func (ws *WebhookServer) handleCheckRunEvent(body []byte) error {
+	var event github.CheckRunEvent
 	if err := json.Unmarshal(body, &event); err != nil {
-		log.Printf("Error parsing PR comment event: %v", err)
-		http.Error(w, "Failed to parse event", http.StatusBadRequest)
-		return
+		return fmt.Errorf("failed to parse check run event: %w", err)
 	}
 
-	action := event.GetAction()
-	log.Printf("PR comment event action: %s", action)
-
-	// Only handle "created" comments
-	if action == "created" {
-		owner := event.Repo.Owner.GetLogin()
-		repo := event.Repo.GetName()
-		prNumber := event.PullRequest.GetNumber()
-		commentBody := event.Comment.GetBody()
-		commentAuthor := event.Comment.User.GetLogin()
-
-		// Ignore comments from the bot itself
-		if strings.Contains(strings.ToLower(commentAuthor), "bot") {
-			w.WriteHeader(http.StatusOK)
-			return
+	var errs []error
+	if event.GetAction() == "completed" {
+		for _, pr := range event.CheckRun.PullRequests {
+			errs = append(errs, ws.onPRUpdated(event.Repo.Owner.GetLogin(), event.Repo.GetName(), pr.GetNumber()))
 		}
+	}
+	return errors.Join(errs...)
+}
 
-		log.Printf("New comment on PR #%d in %s/%s", prNumber, owner, repo)
+// handleStatusEvent re-checks the bot pull requests of branches whose commit status failed
+func (ws *WebhookServer) handleStatusEvent(body []byte) error {
+	var event github.StatusEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return fmt.Errorf("failed to parse status event: %w", err)
+	}
 
-		// Handle the comment asynchronously
-		go func() {
-			if err := ws.agent.HandlePRComment(owner, repo, prNumber, commentBody); err != nil {
-				log.Printf("Error handling PR comment: %v", err)
-			}
-		}()
+	if state := event.GetState(); state != "failure" && state != "error" {
+		return nil
+	}
+	owner, repo := event.Repo.Owner.GetLogin(), event.Repo.GetName()
+	branches := make([]string, 0, len(event.Branches))
+	for _, branch := range event.Branches {
+		branches = append(branches, branch.GetName())
+	}
+	prNumbers, err := ws.agent.PullRequestsForBranches(owner, repo, branches)
+	if err != nil {
+		return fmt.Errorf("failed to find pull requests for status: %w", err)
+	}
+	var errs []error
+	for _, prNumber := range prNumbers {
+		errs = append(errs, ws.onPRUpdated(owner, repo, prNumber))
+	}
+	return errors.Join(errs...)
+}
 
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"message": "Processing PR comment"}`))
+// onIssueAssigned starts the workflow on an issue the bot was assigned to
+func (ws *WebhookServer) onIssueAssigned(owner, repo string, issueNumber int) error {
+	core.IssueLogger(owner, repo, issueNumber).Info("Agent assigned to issue")
+	if err := ws.agent.HandleIssueAssignment(owner, repo, issueNumber); err != nil {
+		return fmt.Errorf("failed to handle assignment to %s/%s#%d: %w", owner, repo, issueNumber, err)
+	}
+	return nil
+}
+
+// onIssueLabeled starts the workflow in label mode when the trigger label is added
+func (ws *WebhookServer) onIssueLabeled(owner, repo string, issueNumber int, label string) error {
+	if _, err := ws.agent.HandleLabel(owner, repo, issueNumber, label); err != nil {
+		return fmt.Errorf("failed to handle label on %s/%s#%d: %w", owner, repo, issueNumber, err)
+	}
+	return nil
+}
+
+// onIssueOpened triages new issues and lets those that @-mention the bot start the workflow
+// without an assignment
+func (ws *WebhookServer) onIssueOpened(owner, repo string, issueNumber int, issueID int64, author, issueBody string) error {
+	var triageErr error
+	if err := ws.agent.TriageIssue(owner, repo, issueNumber); err != nil {
+		triageErr = fmt.Errorf("failed to triage %s/%s#%d: %w", owner, repo, issueNumber, err)
+	}
+	if !ws.claimComment(owner, repo, core.CommentKindBody, issueID) {
+		return triageErr
+	}
+	if _, err := ws.agent.HandleMention(owner, repo, issueNumber, author, issueBody); err != nil {
+		err = fmt.Errorf("failed to handle mention in %s/%s#%d: %w", owner, repo, issueNumber, err)
+		return errors.Join(triageErr, ws.releaseFailedComment(owner, repo, core.CommentKindBody, issueID, err))
+	}
+	return triageErr
+}
+
+// onIssueClosed stops work on an issue that was closed
+func (ws *WebhookServer) onIssueClosed(owner, repo string, issueNumber int) error {
+	if err := ws.agent.AbandonIssue(owner, repo, issueNumber, "closed"); err != nil {
+		return fmt.Errorf("failed to handle closed issue %s/%s#%d: %w", owner, repo, issueNumber, err)
+	}
+	return nil
+}
+
+// onIssueUnassigned stops work on an issue when the bot is unassigned from it
+func (ws *WebhookServer) onIssueUnassigned(owner, repo string, issueNumber int, assignee string) error {
+	if err := ws.agent.HandleUnassignment(owner, repo, issueNumber, assignee); err != nil {
+		return fmt.Errorf("failed to handle unassignment from %s/%s#%d: %w", owner, repo, issueNumber, err)
+	}
+	return nil
+}
+
+// claimComment records a comment as processed and reports whether it is new. Redelivered
+// events and comments the poller already handled are skipped.
+func (ws *WebhookServer) claimComment(owner, repo, kind string, commentID int64) bool {
+	if commentID == 0 {
+		return true
+	}
+	claimed, err := ws.agent.StateManager().ClaimComment(owner, repo, kind, commentID)
+	if err != nil {
+		core.RepoLogger(owner, repo).Warn("Failed to record comment", "kind", kind, "comment", commentID, "error", err)
+		return true
+	}
+	return claimed
+}
+
+// releaseFailedComment forgets a claimed comment when handling it failed, so the event's
+// retry handles it again, and returns the handling error
+func (ws *WebhookServer) releaseFailedComment(owner, repo, kind string, commentID int64, handlingErr error) error {
+	if handlingErr == nil || commentID == 0 {
+		return handlingErr
+	}
+	if err := ws.agent.StateManager().ReleaseComment(owner, repo, kind, commentID); err != nil {
+		core.RepoLogger(owner, repo).Warn("Failed to release comment", "kind", kind, "comment", commentID, "error", err)
+	}
+	return handlingErr
+}
+
+// onIssueComment handles a new comment on an issue or a pull request's conversation.
+// Slash commands come first, then comments in the conversation of an agent pull request
+// are review feedback, then a mention on a new issue starts the workflow or one on
+// someone else's pull request gets a reply.
+func (ws *WebhookServer) onIssueComment(owner, repo string, issueNumber int, commentAuthor, commentBody string) error {
+	// Ignore comments from the bot itself (to avoid infinite loops)
+	if strings.Contains(strings.ToLower(commentAuthor), "bot") {
+		return nil
+	}
+
+	core.IssueLogger(owner, repo, issueNumber).Info("New comment on issue", "author", commentAuthor)
+
+	handled, err := ws.agent.HandleCommand(owner, repo, issueNumber, commentAuthor, commentBody)
+	if err != nil {
+		return fmt.Errorf("failed to handle command on %s/%s#%d: %w", owner, repo, issueNumber, err)
+	}
+	if handled {
+		return nil
+	}
+
+	isPR, err := ws.agent.OwnsPullRequest(owner, repo, issueNumber)
+	if err != nil {
+		return fmt.Errorf("failed to look up pull request %s/%s#%d: %w", owner, repo, issueNumber, err)
+	}
+	if isPR {
+		if ws.canComment(owner, repo, issueNumber, commentAuthor) {
+			ws.agent.QueuePRComment(owner, repo, issueNumber, commentBody)
+		}
+		return nil
+	}
+
+	started, err := ws.agent.HandleMention(owner, repo, issueNumber, commentAuthor, commentBody)
+	if err != nil {
+		return fmt.Errorf("failed to handle mention on %s/%s#%d: %w", owner, repo, issueNumber, err)
+	}
+	if started || !ws.canComment(owner, repo, issueNumber, commentAuthor) {
+		return nil
+	}
+	ws.agent.QueueIssueComment(owner, repo, issueNumber, commentBody)
+	return nil
+}
+
+// canComment reports whether a comment is acted on; comments whose author can't be
+// checked are skipped
+func (ws *WebhookServer) canComment(owner, repo string, number int, author string) bool {
+	allowed, err := ws.agent.CanComment(owner, repo, number, author)
+	if err != nil {
+		core.IssueLogger(owner, repo, number).Error("Error checking comment permission", "author", author, "error", err)
+		return false
+	}
+	return allowed
+}
+
+// onPRComment handles a new review comment on a pull request: comments on agent pull
+// requests are review feedback, and a mention on anyone else's gets a reply
+func (ws *WebhookServer) onPRComment(owner, repo string, prNumber int, commentAuthor, commentBody string) error {
+	// Ignore comments from the bot itself
+	if strings.Contains(strings.ToLower(commentAuthor), "bot") {
+		return nil
+	}
+
+	core.RepoLogger(owner, repo).With("pr", prNumber).Info("New comment on PR", "author", commentAuthor)
+
+	owned, err := ws.agent.OwnsPullRequest(owner, repo, prNumber)
+	if err != nil {
+		return fmt.Errorf("failed to look up pull request %s/%s#%d: %w", owner, repo, prNumber, err)
+	}
+	if owned {
+		if ws.canComment(owner, repo, prNumber, commentAuthor) {
+			ws.agent.QueuePRComment(owner, repo, prNumber, commentBody)
+		}
+		return nil
+	}
+	if _, err := ws.agent.HandleMention(owner, repo, prNumber, commentAuthor, commentBody); err != nil {
+		return fmt.Errorf("failed to handle mention on %s/%s#%d: %w", owner, repo, prNumber, err)
+	}
+	return nil
+}
+
+// onPRClosed finishes merged pull requests and resumes issues that were waiting on them
+func (ws *WebhookServer) onPRClosed(owner, repo string, prNumber int) error {
+	var errs []error
+	if err := ws.agent.HandlePullRequest(owner, repo, prNumber); err != nil {
+		errs = append(errs, fmt.Errorf("failed to handle closed PR %s/%s#%d: %w", owner, repo, prNumber, err))
+	}
+	if err := ws.agent.ResumeBlocked(owner, repo, prNumber); err != nil {
+		errs = append(errs, fmt.Errorf("failed to resume issues blocked by %s/%s#%d: %w", owner, repo, prNumber, err))
+	}
+	return errors.Join(errs...)
+}
+
+// onPROpened reviews a new pull request in repositories with review_prs enabled
+func (ws *WebhookServer) onPROpened(owner, repo string, prNumber int) error {
+	if err := ws.agent.ReviewPullRequest(owner, repo, prNumber, false); err != nil {
+		return fmt.Errorf("failed to review PR %s/%s#%d: %w", owner, repo, prNumber, err)
+	}
+	return nil
+}
+
+// onReviewRequested reviews a pull request when the bot is requested as a reviewer
+func (ws *WebhookServer) onReviewRequested(owner, repo string, prNumber int, reviewer string) error {
+	if err := ws.agent.HandleReviewRequest(owner, repo, prNumber, reviewer); err != nil {
+		return fmt.Errorf("failed to review PR %s/%s#%d: %w", owner, repo, prNumber, err)
+	}
+	return nil
+}
+
+// onPRUpdated re-checks a pull request after a review or CI result, e.g. for auto-merge
+func (ws *WebhookServer) onPRUpdated(owner, repo string, prNumber int) error {
+	if err := ws.agent.HandlePullRequest(owner, repo, prNumber); err != nil {
+		return fmt.Errorf("failed to check PR %s/%s#%d: %w", owner, repo, prNumber, err)
+	}
+	return nil
+}
+
+// spawn runs work in the background, tracked so shutdown can wait for it
+func (ws *WebhookServer) spawn(fn func()) {
+	ws.inflight.Add(1)
+	go func() {
+		defer ws.inflight.Done()
+		fn()
+	}()
+}
+
+// ResumeInterrupted restarts implementations that a previous shutdown or crash left unfinished
+func (ws *WebhookServer) ResumeInterrupted() {
+	states, err := ws.agent.InterruptedImplementations()
+	if err != nil {
+		slog.Error("Error checking for interrupted work", "error", err)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	for _, state := range states {
+		owner, repo, issueNumber := state.Owner, state.Repo, state.IssueNumber
+		logger := core.IssueLogger(owner, repo, issueNumber)
+		logger.Info("Resuming interrupted implementation")
+		ws.spawn(func() {
+			if err := ws.agent.StartImplementation(owner, repo, issueNumber); err != nil {
+				logger.Error("Error resuming issue", "error", err)
+			}
+		})
+	}
+}
+
+// ServerOptions configures the webhook server's listener
+type ServerOptions struct {
+	Address      string // Listen address, e.g. ":8080"
+	BasePath     string // Prefix of every route, e.g. "/nytebubo"; empty serves them at the root
+	TLSCertFile  string // Serve HTTPS with this certificate and TLSKeyFile
+	TLSKeyFile   string
+	ReadTimeout  time.Duration // Zero uses defaultServerTimeout
+	WriteTimeout time.Duration // Zero uses defaultServerTimeout
 }
 
-// Start starts the webhook server
-func (ws *WebhookServer) Start(port int) error {
-	http.HandleFunc("/webhook", ws.HandleWebhook)
+// defaultServerTimeout bounds reading a request and writing its response. Events are
+// only queued while the sender waits, so both are quick.
+const defaultServerTimeout = 30 * time.Second
 
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status": "healthy"}`))
-	})
+// base returns the base path without a trailing slash, or "" for the root
+func (o ServerOptions) base() string {
+	base := strings.TrimSuffix(o.BasePath, "/")
+	if base != "" && !strings.HasPrefix(base, "/") {
+		base = "/" + base
+	}
+	return base
+}
+
+// Routes returns the paths of the webhook endpoint and the liveness and readiness probes
+// under the base path
+func (o ServerOptions) Routes() (webhook, live, ready string) {
+	base := o.base()
+	return base + "/webhook", base + "/livez", base + "/readyz"
+}
+
+// Start starts the webhook server and shuts it down gracefully once ctx is cancelled
+func (ws *WebhookServer) Start(ctx context.Context, options ServerOptions) error {
+	if (options.TLSCertFile == "") != (options.TLSKeyFile == "") {
+		return fmt.Errorf("TLS needs both a certificate and a key file")
+	}
+
+	webhookPath, _, _ := options.Routes()
+	mux := http.NewServeMux()
+	mux.HandleFunc(webhookPath, ws.HandleWebhook)
+	NewHealthChecker(ws.agent).Register(mux, options.base())
 
-	addr := fmt.Sprintf(":%d", port)
-	log.Printf("Starting webhook server on %s", addr)
-	return http.ListenAndServe(addr, nil)
+	readTimeout, writeTimeout := options.ReadTimeout, options.WriteTimeout
+	if readTimeout <= 0 {
+		readTimeout = defaultServerTimeout
+	}
+	if writeTimeout <= 0 {
+		writeTimeout = defaultServerTimeout
+	}
+	srv := &http.Server{
+		Addr:              options.Address,
+		Handler:           mux,
+		ReadHeaderTimeout: readTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+	}
+
+	// Workers stop taking events once ctx is cancelled; the rest are handled on the next start
+	ws.runWorkers(ctx)
+
+	slog.Info("Starting webhook server", "address", options.Address, "webhook", webhookPath, "tls", options.TLSCertFile != "")
+	if err := serveUntilDone(ctx, srv, options.TLSCertFile, options.TLSKeyFile); err != nil {
+		return err
+	}
+	slog.Info("Webhook server stopped, waiting for in-flight work")
+
+	waitInflight(&ws.inflight)
+	return nil
+}
+
+// waitInflight waits up to shutdownTimeout for background work to finish. Running
+// workflows stop at their next step once the agent's context is cancelled.
+func waitInflight(inflight *sync.WaitGroup) {
+	done := make(chan struct{})
+	go func() {
+		inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(shutdownTimeout):
+		slog.Warn("Timed out waiting for in-flight work; it will resume on the next start")
+	}
 }